@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/smtp"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// emailPreviews lists the emails that can be previewed
+func (app *application) emailPreviews(w http.ResponseWriter, r *http.Request) {
+	templates, err := smtp.Templates()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, map[string]any{"templates": templates})
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// emailPreview renders an email with its preview data, as HTML or, with
+// ?format=text, as the plain text body under its subject. Embedded images
+// are served from /dev/email-images.
+func (app *application) emailPreview(w http.ResponseWriter, r *http.Request) {
+	name := httprouter.ParamsFromContext(r.Context()).ByName("template")
+	templates, err := smtp.Templates()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if !slices.Contains(templates, name) {
+		app.notFound(w, r)
+		return
+	}
+
+	email, err := smtp.Render(smtp.PreviewData[name], name)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" || email.HTMLBody == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("Subject: " + email.Subject + "\n\n" + email.PlainBody))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(strings.ReplaceAll(email.HTMLBody, "cid:", "/dev/email-images/")))
+}
+
+// emailImage serves an image emails embed, for previews to show
+func (app *application) emailImage(w http.ResponseWriter, r *http.Request) {
+	image, err := smtp.Image(httprouter.ParamsFromContext(r.Context()).ByName("name"))
+	if err != nil {
+		app.notFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(image))
+	w.Write(image)
+}
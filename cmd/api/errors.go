@@ -15,7 +15,7 @@ func (app *application) reportServerError(r *http.Request, err error) {
 	var (
 		message = err.Error()
 		method  = r.Method
-		url     = r.URL.String()
+		url     = loggedURL(r.URL)
 		trace   = string(debug.Stack())
 	)
 
@@ -29,7 +29,7 @@ func (app *application) reportServerError(r *http.Request, err error) {
 		data["RequestURL"] = url
 		data["Trace"] = trace
 
-		err := app.mailer.Send(app.config.notifications.email, data, "error-notification.tmpl")
+		err := app.sendEmail(app.config.notifications.email, data, "error-notification.tmpl")
 		if err != nil {
 			trace = string(debug.Stack())
 			app.logger.Error(err.Error(), requestAttrs, "trace", trace)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"big-spella-go/internal/request"
 	"big-spella-go/internal/response"
 	"big-spella-go/internal/validator"
+	"big-spella-go/internal/version"
 
 	"github.com/pascaldekloe/jwt"
 )
@@ -24,6 +26,58 @@ func (app *application) status(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (app *application) health(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	status, code := "OK", http.StatusOK
+	if err := app.db.PingContext(ctx); err != nil {
+		app.logger.Warn("health check failed", "error", err)
+		status, code = "Unavailable", http.StatusServiceUnavailable
+	}
+
+	data := map[string]any{
+		"Status":   status,
+		"Database": status,
+		"Version":  version.Get(),
+	}
+
+	// Migrations still to run don't fail the check, since instances of the
+	// previous release keep serving while a deploy migrates the database
+	if code == http.StatusOK {
+		migrations, err := app.db.MigrationStatus(ctx)
+		if err != nil {
+			app.logger.Warn("migration status unavailable", "error", err)
+		} else {
+			data["Migrations"] = migrations
+		}
+	}
+	data["Transcription"] = app.transcription.Health()
+	if app.dictionary != nil {
+		data["Dictionary"] = app.dictionary.Health()
+	}
+
+	err := response.JSON(w, code, data)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// serverTime lets clients work out how far their clocks are from the
+// server's, to line turn countdowns up with the server_time of game events
+func (app *application) serverTime(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	data := map[string]any{
+		"Time":      now.Format(time.RFC3339Nano),
+		"UnixMilli": now.UnixMilli(),
+	}
+
+	err := response.JSON(w, http.StatusOK, data)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
 func (app *application) createUser(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Email     string              `json:"Email"`
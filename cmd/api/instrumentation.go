@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"big-spella-go/internal/metrics"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/tracing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var requestDuration = metrics.NewHistogramVec("spella_http_request_duration_seconds",
+	"Time to serve HTTP requests, by method, route and status.", nil, "method", "route", "status")
+
+// measureRequests times each request under its route pattern rather than
+// its path, so games and users don't each get a series of their own
+func (app *application) measureRequests(router *httprouter.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A WebSocket lasts as long as the player stays, which says nothing
+		// about latency
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mw := response.NewMetricsResponseWriter(w)
+		started := time.Now()
+		next.ServeHTTP(mw, r)
+
+		requestDuration.With(r.Method, routePattern(router, r), strconv.Itoa(mw.StatusCode)).
+			Observe(time.Since(started).Seconds())
+	})
+}
+
+// traceRequests starts each request's server span, named for its route and
+// continuing the caller's trace when it sent a traceparent header
+func (app *application) traceRequests(router *httprouter.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routePattern(router, r)
+		r, span := tracing.StartServer(r, r.Method+" "+route,
+			tracing.String("http.method", r.Method), tracing.String("http.route", route))
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.End()
+
+		mw := response.NewMetricsResponseWriter(w)
+		next.ServeHTTP(mw, r)
+
+		span.SetAttributes(tracing.Int("http.status_code", mw.StatusCode))
+		if mw.StatusCode >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(mw.StatusCode)))
+		}
+	})
+}
+
+func routePattern(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return "unmatched"
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	next := 0
+	for _, param := range params {
+		for ; next < len(segments); next++ {
+			if segments[next] == param.Value {
+				segments[next] = ":" + param.Key
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"big-spella-go/internal/game/gamereport"
+	"big-spella-go/internal/game/invitations"
+	"big-spella-go/internal/game/wordofday"
+	"big-spella-go/internal/jobs"
+	"big-spella-go/internal/notifications"
+)
+
+const jobSendEmail = "send_email"
+
+// emailKinds are the kinds of notification the emails players can
+// unsubscribe from are. Emails not listed, like email change confirmations,
+// are always sent.
+var emailKinds = map[string]notifications.Kind{
+	invitations.Email:          notifications.KindGameInvitation,
+	wordofday.Email:            notifications.KindWordOfTheDay,
+	gamereport.ReportEmail:     notifications.KindGameReport,
+	"tournament_reminder.tmpl": notifications.KindTournamentStarting,
+	"weekly_digest.tmpl":       notifications.KindWeeklyDigest,
+}
+
+type emailJob struct {
+	Recipient string         `json:"recipient"`
+	Data      map[string]any `json:"data"`
+	Templates []string       `json:"templates"`
+}
+
+// sendEmail queues an email for the job workers, sending it straight away
+// when they are disabled or the queue can't take it. Emails players can
+// unsubscribe from aren't sent to those who have, and carry a link for the
+// rest to.
+func (app *application) sendEmail(recipient string, data map[string]any, templates ...string) error {
+	data, send, err := app.unsubscribable(recipient, data, templates)
+	if err != nil || !send {
+		return err
+	}
+
+	if app.jobs != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := app.jobs.Enqueue(ctx, jobSendEmail, emailJob{Recipient: recipient, Data: data, Templates: templates})
+		if err == nil {
+			return nil
+		}
+		app.logger.Warn("failed to queue email, sending it inline", "error", err)
+	}
+
+	return app.mailer.Send(recipient, data, templates...)
+}
+
+func (app *application) runSendEmail(ctx context.Context, job *jobs.Job) error {
+	var email emailJob
+	if err := job.Decode(&email); err != nil {
+		return err
+	}
+	return app.mailer.Send(email.Recipient, email.Data, email.Templates...)
+}
+
+// unsubscribable checks whether recipient takes the email of templates. If
+// they could unsubscribe from it, its data gets the UnsubscribeURL that
+// does.
+func (app *application) unsubscribable(recipient string, data map[string]any, templates []string) (map[string]any, bool, error) {
+	if app.notifier == nil {
+		return data, true, nil
+	}
+	var kind notifications.Kind
+	for _, template := range templates {
+		if k, ok := emailKinds[template]; ok {
+			kind = k
+		}
+	}
+	if kind == "" {
+		return data, true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userID, send, err := app.notifier.EmailRecipient(ctx, recipient, kind)
+	if err != nil || !send || userID == "" {
+		return data, send, err
+	}
+
+	withLink := make(map[string]any, len(data)+1)
+	for key, value := range data {
+		withLink[key] = value
+	}
+	withLink["UnsubscribeURL"] = app.config.baseURL + "/notifications/unsubscribe?" +
+		url.Values{"token": {app.notifier.UnsubscribeToken(userID, kind)}}.Encode()
+	return withLink, true, nil
+}
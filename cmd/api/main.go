@@ -1,18 +1,64 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"runtime/debug"
+	"strings"
 	"sync"
+	"time"
 
+	"big-spella-go/internal/account"
+	"big-spella-go/internal/admin"
+	"big-spella-go/internal/apikeys"
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/auth"
 	"big-spella-go/internal/database"
+	"big-spella-go/internal/feed"
+	"big-spella-go/internal/friends"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/category"
+	"big-spella-go/internal/game/daily"
+	"big-spella-go/internal/game/gamereport"
+	"big-spella-go/internal/game/integrity"
+	"big-spella-go/internal/game/invitations"
+	"big-spella-go/internal/game/ranking"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/game/solo"
+	"big-spella-go/internal/game/stt"
+	"big-spella-go/internal/game/wordlists"
+	"big-spella-go/internal/game/wordofday"
+	"big-spella-go/internal/graph"
+	"big-spella-go/internal/idempotency"
+	"big-spella-go/internal/infrastructure/aws/chime"
+	"big-spella-go/internal/infrastructure/aws/dynamodb"
+	"big-spella-go/internal/infrastructure/aws/eventbus"
+	"big-spella-go/internal/infrastructure/aws/s3"
+	"big-spella-go/internal/infrastructure/aws/sms"
+	"big-spella-go/internal/infrastructure/redis"
+	"big-spella-go/internal/jobs"
+	"big-spella-go/internal/notifications"
+	"big-spella-go/internal/parental"
+	"big-spella-go/internal/profile"
+	"big-spella-go/internal/queries"
+	"big-spella-go/internal/reports"
 	"big-spella-go/internal/smtp"
+	"big-spella-go/internal/stats"
+	"big-spella-go/internal/tracing"
+	"big-spella-go/internal/user"
 	"big-spella-go/internal/version"
+	"big-spella-go/internal/warehouse"
+	"big-spella-go/internal/webhooks"
 
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/lmittmann/tint"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -27,8 +73,12 @@ func main() {
 }
 
 type config struct {
-	baseURL   string
-	httpPort  int
+	baseURL         string
+	httpPort        int
+	grpcPort        int
+	shutdownTimeout time.Duration
+	// dev serves helpers for local development, like email previews
+	dev       bool
 	basicAuth struct {
 		username       string
 		hashedPassword string
@@ -36,15 +86,128 @@ type config struct {
 	cookie struct {
 		secretKey string
 	}
+	cors struct {
+		trustedOrigins []string
+	}
 	db struct {
-		dsn         string
-		automigrate bool
+		database.Config
+		replicaCheckInterval time.Duration
+		statsInterval        time.Duration
+	}
+	audio struct {
+		bucket    string
+		cdnURL    string
+		awsRegion string
+	}
+	avatars struct {
+		bucket string
+		cdnURL string
+	}
+	voice struct {
+		bucket        string
+		kmsKeyID      string
+		retention     time.Duration
+		purgeInterval time.Duration
+	}
+	solo struct {
+		store string
+	}
+	calibration struct {
+		interval time.Duration
+	}
+	reaper struct {
+		interval      time.Duration
+		abandonAfter  time.Duration
+		chimeMeetings bool
+	}
+	schedule struct {
+		interval time.Duration
+	}
+	gameEvents struct {
+		bus      string
+		topicARN string
+	}
+	gameReports struct {
+		bucket string
+	}
+	warehouse struct {
+		bucket   string
+		prefix   string
+		interval time.Duration
+	}
+	seasons struct {
+		finalizeInterval time.Duration
+		decayInterval    time.Duration
+		decayThreshold   int
+		decayAfter       time.Duration
+		decayPoints      int
+		decayWarning     time.Duration
+	}
+	wordOfTheDay struct {
+		interval time.Duration
+		sendHour int
+	}
+	reports struct {
+		muteThreshold int
+		muteWindow    time.Duration
+		muteDuration  time.Duration
+	}
+	getstream struct {
+		key                string
+		secret             string
+		host               string
+		followSyncInterval time.Duration
+	}
+	dictionary struct {
+		merriamWebsterKey string
+		thesaurusKey      string
+		wordnikKey        string
+		dataset           string
+	}
+	jobs struct {
+		workers int
 	}
 	jwt struct {
 		secretKey string
+		expiry    time.Duration
 	}
 	notifications struct {
-		email string
+		email          string
+		unsubscribeKey string
+	}
+	push struct {
+		apnsKeyFile         string
+		apnsKeyID           string
+		apnsTeamID          string
+		apnsTopic           string
+		apnsSandbox         bool
+		fcmCredentialsFile  string
+		tournamentReminders time.Duration
+	}
+	sms struct {
+		enabled  bool
+		senderID string
+	}
+	openAI struct {
+		apiKey string
+	}
+	stt struct {
+		providers        string
+		whisperServerURL string
+		checkInterval    time.Duration
+		letterNames      string
+	}
+	stripe struct {
+		secretKey string
+	}
+	redis struct {
+		url            string
+		idempotencyTTL time.Duration
+	}
+	tracing struct {
+		exporter     string
+		otlpEndpoint string
+		sampleRatio  float64
 	}
 	smtp struct {
 		host     string
@@ -56,11 +219,46 @@ type config struct {
 }
 
 type application struct {
-	config config
-	db     *database.DB
-	logger *slog.Logger
-	mailer *smtp.Mailer
-	wg     sync.WaitGroup
+	config       config
+	db           *database.DB
+	logger       *slog.Logger
+	mailer       *smtp.Mailer
+	auth         *auth.Service
+	authHandler  *auth.Handler
+	gameHandler  *game.Handler
+	jobs         *jobs.Queue
+	jobsHandler  *jobs.Handler
+	auditLog     *audit.Handler
+	admin        *admin.Handler
+	reports      *reports.Handler
+	feed         *feed.Handler
+	profiles     *profile.Handler
+	categories   *category.Handler
+	wordLists    *wordlists.Handler
+	gameReports  *gamereport.Handler
+	integrity    *integrity.Handler
+	friends      *friends.Handler
+	devices      *notifications.Handler
+	notifier     *notifications.Service
+	seasons      *season.Handler
+	daily        *daily.Handler
+	solo         *solo.Handler
+	userHandler  *user.Handler
+	stats        *stats.Handler
+	parental     *parental.Handler
+	wordOfTheDay *wordofday.Handler
+	invitations  *invitations.Handler
+	accounts     *account.Handler
+	apiKeys      *apikeys.Handler
+	graph        *graph.Handler
+	webhooks     *webhooks.Handler
+	grpc         *grpc.Server
+	wg           sync.WaitGroup
+
+	// transcription's and dictionary's provider health is reported by the
+	// health check
+	transcription *stt.Failover
+	dictionary    game.DictionaryHealth
 }
 
 func run(logger *slog.Logger) error {
@@ -68,20 +266,103 @@ func run(logger *slog.Logger) error {
 
 	flag.StringVar(&cfg.baseURL, "base-url", "http://localhost:4444", "base URL for the application")
 	flag.IntVar(&cfg.httpPort, "http-port", 4444, "port to listen on for HTTP requests")
+	flag.IntVar(&cfg.grpcPort, "grpc-port", 0, "port to serve the game and word services on over gRPC for internal consumers (0 disables)")
+	flag.DurationVar(&cfg.shutdownTimeout, "shutdown-timeout", defaultShutdownPeriod, "time to wait for in-flight requests on shutdown")
+	flag.BoolVar(&cfg.dev, "dev", false, "serve development helpers, like email previews (never in production)")
 	flag.StringVar(&cfg.basicAuth.username, "basic-auth-username", "admin", "basic auth username")
 	flag.StringVar(&cfg.basicAuth.hashedPassword, "basic-auth-hashed-password", "$2a$10$jRb2qniNcoCyQM23T59RfeEQUbgdAXfR6S0scynmKfJa5Gj3arGJa", "basic auth password hashed with bcrpyt")
 	flag.StringVar(&cfg.cookie.secretKey, "cookie-secret-key", "vqaxcu4yoqbxmjewsv4mdleri2ckt4hx", "secret key for cookie authentication/encryption")
-	flag.StringVar(&cfg.db.dsn, "db-dsn", "user:pass@localhost:5432/db", "postgreSQL DSN")
-	flag.BoolVar(&cfg.db.automigrate, "db-automigrate", true, "run migrations on startup")
+	flag.StringVar(&cfg.db.DSN, "db-dsn", "user:pass@localhost:5432/db", "postgreSQL DSN")
+	flag.BoolVar(&cfg.db.Automigrate, "db-automigrate", true, "run migrations on startup")
+	flag.IntVar(&cfg.db.MaxOpenConns, "db-max-open-conns", database.DefaultConfig.MaxOpenConns, "most connections open to each database at once (0 is unlimited)")
+	flag.IntVar(&cfg.db.MaxIdleConns, "db-max-idle-conns", database.DefaultConfig.MaxIdleConns, "most idle connections kept to each database")
+	flag.DurationVar(&cfg.db.ConnMaxIdleTime, "db-conn-max-idle-time", database.DefaultConfig.ConnMaxIdleTime, "how long a connection may sit idle before it's closed")
+	flag.DurationVar(&cfg.db.ConnMaxLifetime, "db-conn-max-lifetime", database.DefaultConfig.ConnMaxLifetime, "how long a connection is reused before it's replaced")
+	flag.DurationVar(&cfg.db.StatementTimeout, "db-statement-timeout", 0, "how long a query may run before it's cancelled (0 is unlimited)")
+	flag.DurationVar(&cfg.db.statsInterval, "db-stats-interval", 15*time.Second, "how often connection pool metrics are updated (0 disables)")
+	flag.DurationVar(&cfg.db.replicaCheckInterval, "db-replica-check-interval", 10*time.Second, "how often read replicas are health checked and timed")
+	flag.StringVar(&cfg.audio.bucket, "audio-bucket", "", "S3 bucket that caches generated word audio (empty disables caching)")
+	flag.StringVar(&cfg.audio.cdnURL, "audio-cdn-url", "", "CDN base URL serving the audio bucket, required with -audio-bucket")
+	flag.StringVar(&cfg.audio.awsRegion, "aws-region", "us-east-1", "AWS region of the audio, avatar and voice buckets")
+	flag.StringVar(&cfg.avatars.bucket, "avatar-bucket", "", "S3 bucket that stores profile avatars (empty disables uploads)")
+	flag.StringVar(&cfg.avatars.cdnURL, "avatar-cdn-url", "", "CDN base URL serving the avatar bucket (empty serves presigned S3 URLs)")
+	flag.StringVar(&cfg.voice.bucket, "voice-bucket", "", "S3 bucket voice attempt recordings are kept in, encrypted (empty discards them once transcribed)")
+	flag.StringVar(&cfg.voice.kmsKeyID, "voice-kms-key-id", "", "KMS key voice recordings are encrypted with (empty uses the account's S3 key)")
+	flag.DurationVar(&cfg.voice.retention, "voice-retention", game.DefaultVoiceRetention, "how long voice recordings are kept before they're purged (negative keeps none)")
+	flag.DurationVar(&cfg.voice.purgeInterval, "voice-purge-interval", time.Hour, "how often expired voice recordings are purged")
+	flag.StringVar(&cfg.solo.store, "solo-store", "postgres", "where solo games and daily challenges are kept: postgres or dynamodb")
+	flag.DurationVar(&cfg.calibration.interval, "word-calibration-interval", 24*time.Hour, "how often word difficulty is recalibrated from attempts (0 disables)")
+	flag.DurationVar(&cfg.reaper.interval, "game-reaper-interval", 5*time.Minute, "how often abandoned games are looked for (0 disables)")
+	flag.DurationVar(&cfg.reaper.abandonAfter, "game-abandon-after", game.DefaultAbandonAfter, "how long a game can go without activity before it's cancelled as abandoned")
+	flag.BoolVar(&cfg.reaper.chimeMeetings, "chime-meetings", false, "end the Chime meetings of games once they are over")
+	flag.DurationVar(&cfg.schedule.interval, "game-schedule-interval", time.Minute, "how often scheduled games are opened, started or cancelled (0 disables)")
+	flag.StringVar(&cfg.gameEvents.bus, "game-events-bus", "", "EventBridge bus name or ARN to publish game events to (empty disables)")
+	flag.StringVar(&cfg.gameEvents.topicARN, "game-events-topic-arn", "", "SNS topic ARN to publish game events to, instead of an EventBridge bus (empty disables)")
+	flag.StringVar(&cfg.gameReports.bucket, "game-reports-bucket", "", "S3 bucket that stores the reports of finished games (empty disables reports)")
+	flag.StringVar(&cfg.warehouse.bucket, "warehouse-bucket", "", "S3 bucket daily Parquet exports of games, attempts and results are written to (empty disables)")
+	flag.StringVar(&cfg.warehouse.prefix, "warehouse-prefix", "warehouse", "key prefix of the warehouse exports")
+	flag.DurationVar(&cfg.warehouse.interval, "warehouse-export-interval", time.Hour, "how often to check for days due to be exported to the warehouse")
+	flag.DurationVar(&cfg.wordOfTheDay.interval, "word-of-the-day-interval", 5*time.Minute, "how often to check whether the word of the day is due to be sent (0 disables sending)")
+	flag.IntVar(&cfg.wordOfTheDay.sendHour, "word-of-the-day-hour", wordofday.DefaultSendHour, "hour of the day, in UTC, the word of the day is sent to subscribers")
+	flag.DurationVar(&cfg.seasons.finalizeInterval, "season-finalize-interval", time.Hour, "how often ended seasons are finalized (0 disables)")
+	flag.DurationVar(&cfg.seasons.decayInterval, "rank-decay-interval", time.Hour, "how often inactive players are checked for rank decay (0 disables)")
+	flag.IntVar(&cfg.seasons.decayThreshold, "rank-decay-threshold", season.DefaultDecay.Threshold, "rank points above which an inactive player's rating decays")
+	flag.DurationVar(&cfg.seasons.decayAfter, "rank-decay-after", season.DefaultDecay.InactiveAfter, "how long without a ranked game before a rating starts to decay")
+	flag.IntVar(&cfg.seasons.decayPoints, "rank-decay-points", season.DefaultDecay.Points, "rank points an inactive player loses each week (0 disables)")
+	flag.DurationVar(&cfg.seasons.decayWarning, "rank-decay-warning", season.DefaultDecay.Warning, "how long before decay starts players are warned")
+	flag.IntVar(&cfg.reports.muteThreshold, "report-mute-threshold", reports.DefaultAutoMute.Threshold, "players reporting someone within the window that mutes them (0 disables)")
+	flag.DurationVar(&cfg.reports.muteWindow, "report-mute-window", reports.DefaultAutoMute.Window, "how far back reports count towards muting a player")
+	flag.DurationVar(&cfg.reports.muteDuration, "report-mute-duration", reports.DefaultAutoMute.Duration, "how long an automatic mute lasts")
+	flag.StringVar(&cfg.getstream.key, "getstream-key", "", "GetStream API key for activity feeds (empty disables feeds)")
+	flag.StringVar(&cfg.getstream.secret, "getstream-secret", "", "GetStream API secret")
+	flag.StringVar(&cfg.getstream.host, "getstream-host", feed.Host, "GetStream API endpoint")
+	flag.DurationVar(&cfg.getstream.followSyncInterval, "follow-sync-interval", 24*time.Hour, "how often follows are resynced to GetStream (0 disables)")
+	flag.StringVar(&cfg.dictionary.merriamWebsterKey, "merriam-webster-key", "", "Merriam-Webster dictionary API key")
+	flag.StringVar(&cfg.dictionary.thesaurusKey, "merriam-webster-thesaurus-key", "", "Merriam-Webster thesaurus API key")
+	flag.StringVar(&cfg.dictionary.wordnikKey, "wordnik-key", "", "Wordnik API key, for looking words up when Merriam-Webster can't (empty disables)")
+	flag.StringVar(&cfg.dictionary.dataset, "dictionary-dataset", "", "JSON file of words to look up when the online dictionaries can't (empty disables)")
+	flag.IntVar(&cfg.jobs.workers, "job-workers", jobs.DefaultConcurrency, "number of background jobs run at once (0 disables the workers)")
 	flag.StringVar(&cfg.jwt.secretKey, "jwt-secret-key", "l5iubo2d4c5xvbwp2vm6y6vtsrnvtzkq", "secret key for JWT authentication")
+	flag.DurationVar(&cfg.jwt.expiry, "jwt-expiry", 24*time.Hour, "lifetime of game access tokens")
 	flag.StringVar(&cfg.notifications.email, "notifications-email", "", "contact email address for error notifications")
+	flag.StringVar(&cfg.notifications.unsubscribeKey, "unsubscribe-secret-key", "f3kq7xw2mzp9vbn4hd6tyc8rlj5gsa1e", "secret key signing the unsubscribe links in emails")
+	flag.StringVar(&cfg.push.apnsKeyFile, "apns-key-file", "", "path to the APNs .p8 signing key (empty disables iOS push)")
+	flag.StringVar(&cfg.push.apnsKeyID, "apns-key-id", "", "APNs signing key ID")
+	flag.StringVar(&cfg.push.apnsTeamID, "apns-team-id", "", "Apple developer team ID")
+	flag.StringVar(&cfg.push.apnsTopic, "apns-topic", "", "iOS app bundle ID")
+	flag.BoolVar(&cfg.push.apnsSandbox, "apns-sandbox", false, "send iOS push through the APNs sandbox")
+	flag.BoolVar(&cfg.sms.enabled, "sms", false, "text time-sensitive notifications to consenting players through Amazon SNS")
+	flag.StringVar(&cfg.sms.senderID, "sms-sender-id", "BigSpella", "sender ID shown on texts where carriers support one")
+	flag.StringVar(&cfg.push.fcmCredentialsFile, "fcm-credentials-file", "", "path to the FCM service account key (empty disables Android push)")
+	flag.DurationVar(&cfg.push.tournamentReminders, "tournament-reminder-interval", time.Minute, "how often to check for starting tournaments to notify players of (0 disables)")
+	flag.StringVar(&cfg.stripe.secretKey, "stripe-secret-key", "", "Stripe secret key, for cancelling the subscriptions of deleted accounts")
+	flag.StringVar(&cfg.openAI.apiKey, "openai-api-key", "", "OpenAI API key for transcription and generated hints")
+	flag.StringVar(&cfg.stt.providers, "stt-providers", "openai", "transcription providers for voice attempts, tried in order: openai and whisper-server (space separated)")
+	flag.StringVar(&cfg.stt.whisperServerURL, "whisper-server-url", "", "URL of the whisper.cpp server the whisper-server transcription provider uses")
+	flag.DurationVar(&cfg.stt.checkInterval, "stt-check-interval", time.Minute, "how often transcription providers are health checked (0 disables)")
+	flag.StringVar(&cfg.stt.letterNames, "stt-letter-names", "", "JSON file of spoken letter names to add to the built-in ones, such as {\"aitch\": \"h\"} (empty uses the built-in ones)")
+	flag.StringVar(&cfg.redis.url, "redis-url", "", "redis://[:password@]host:port[/db] URL for player presence, idempotency keys and API key rate limits shared between instances (empty disables the first two and counts API key requests per instance)")
+	flag.DurationVar(&cfg.redis.idempotencyTTL, "idempotency-ttl", idempotency.DefaultTTL, "how long responses to requests with an Idempotency-Key are replayed")
+	flag.StringVar(&cfg.tracing.exporter, "trace-exporter", "none", "where to send request traces: none, log or otlp")
+	flag.StringVar(&cfg.tracing.otlpEndpoint, "otlp-endpoint", "http://localhost:4318", "OpenTelemetry collector OTLP/HTTP endpoint for the otlp trace exporter")
+	flag.Float64Var(&cfg.tracing.sampleRatio, "trace-sample-ratio", 1, "fraction of requests to trace when the caller hasn't decided (0 to 1)")
 	flag.StringVar(&cfg.smtp.host, "smtp-host", "example.smtp.host", "smtp host")
 	flag.IntVar(&cfg.smtp.port, "smtp-port", 25, "smtp port")
 	flag.StringVar(&cfg.smtp.username, "smtp-username", "example_username", "smtp username")
 	flag.StringVar(&cfg.smtp.password, "smtp-password", "pa55word", "smtp password")
 	flag.StringVar(&cfg.smtp.from, "smtp-from", "Example Name <no-reply@example.org>", "smtp sender")
 
+	flag.Func("db-replica-dsns", "postgreSQL DSNs of read replicas for leaderboards, listings and game views (space separated)", func(val string) error {
+		cfg.db.ReplicaDSNs = strings.Fields(val)
+		return nil
+	})
+	flag.Func("cors-trusted-origins", "trusted CORS origins, which may also open game WebSockets (space separated)", func(val string) error {
+		cfg.cors.trustedOrigins = strings.Fields(val)
+		return nil
+	})
+
 	showVersion := flag.Bool("version", false, "display version and exit")
+	migrateTo := flag.Int("migrate-to", -1, "migrate the database up or down to this version and exit (0 rolls back every migration)")
 
 	flag.Parse()
 
@@ -90,23 +371,564 @@ func run(logger *slog.Logger) error {
 		return nil
 	}
 
-	db, err := database.New(cfg.db.dsn, cfg.db.automigrate)
+	if *migrateTo >= 0 {
+		if err := database.MigrateTo(cfg.db.DSN, uint(*migrateTo)); err != nil {
+			return err
+		}
+		logger.Info("database migrated", "version", *migrateTo)
+		return nil
+	}
+
+	tracer, err := newTracer(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if tracer != nil {
+		tracing.SetTracer(tracer)
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			tracer.Shutdown(ctx)
+		}()
+	}
+
+	db, err := database.New(cfg.db.Config)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
+	if cfg.db.statsInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go db.RecordPoolStats(ctx, cfg.db.statsInterval)
+	}
+
+	if len(cfg.db.ReplicaDSNs) > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go db.Reads.Run(ctx, cfg.db.replicaCheckInterval, func(err error) {
+			logger.Warn("read replica check failed", "error", err)
+		})
+	}
+
+	// The games, players, attempts and users queries are prepared once and
+	// shared by the services that run them
+	dbQueries, err := queries.Prepare(context.Background(), db.DB)
+	if err != nil {
+		return err
+	}
+	defer dbQueries.Close()
+
 	mailer, err := smtp.NewMailer(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.from)
 	if err != nil {
 		return err
 	}
 
+	authService := auth.NewService(db.DB, []byte(cfg.jwt.secretKey), cfg.jwt.expiry)
+	auditService := audit.NewService(db.DB, func(err error) {
+		logger.Error("audit log failed", "error", err)
+	})
+	authService.SetAuditLog(auditService)
+	authService.SetQueries(dbQueries)
+
+	var dictFallbacks []game.DictionaryProvider
+	if cfg.dictionary.wordnikKey != "" {
+		dictFallbacks = append(dictFallbacks, game.NewWordnikProvider(cfg.dictionary.wordnikKey, &http.Client{Timeout: 10 * time.Second}))
+	}
+	if cfg.dictionary.dataset != "" {
+		local, err := game.LoadLocalProvider(cfg.dictionary.dataset)
+		if err != nil {
+			return err
+		}
+		dictFallbacks = append(dictFallbacks, local)
+	}
+	dictService := game.NewDictionaryService(cfg.dictionary.merriamWebsterKey, cfg.dictionary.thesaurusKey, cfg.openAI.apiKey, dictFallbacks...)
+	dictHealth, _ := dictService.(game.DictionaryHealth)
+	if cfg.openAI.apiKey != "" {
+		dictService = game.NewHintFallbackService(dictService, game.NewOpenAIHintGenerator(cfg.openAI.apiKey, &http.Client{Timeout: 10 * time.Second}), db.DB)
+	}
+	dictService = game.InstrumentDictionary(dictService)
+	notificationService, err := newNotificationService(db, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	jobQueue := jobs.NewQueue(db.DB)
+	worker := jobs.NewWorker(jobQueue, cfg.jobs.workers, func(job *jobs.Job, err error) {
+		if job == nil {
+			logger.Error("job worker failed", "error", err)
+			return
+		}
+		logger.Warn("job failed", "job", job.ID, "kind", job.Kind, "attempt", job.Attempts, "error", err)
+	})
+
+	serviceOpts := []game.ServiceOption{game.WithQueries(dbQueries)}
+	gameOpts := []game.HandlerOption{game.WithAuthenticator(authService), game.WithAllowedOrigins(cfg.cors.trustedOrigins)}
+	friendOpts := []friends.ServiceOption{friends.WithNotifier(notificationService)}
+	var apiKeyOpts []apikeys.ServiceOption
+	if cfg.redis.url != "" {
+		redisClient, err := redis.Open(cfg.redis.url)
+		if err != nil {
+			return err
+		}
+		defer redisClient.Close()
+
+		presence := friends.NewPresence(redisClient, func(err error) {
+			logger.Warn("presence tracking failed", "error", err)
+		})
+		keys := idempotency.NewStore(redisClient, cfg.redis.idempotencyTTL, func(err error) {
+			logger.Warn("idempotency key lookup failed", "error", err)
+		})
+		gameOpts = append(gameOpts, game.WithPresence(presence), game.WithIdempotency(keys.Middleware))
+		friendOpts = append(friendOpts, friends.WithPresence(presence))
+		serviceOpts = append(serviceOpts, game.WithLobbyPresence(presence))
+		apiKeyOpts = append(apiKeyOpts, apikeys.WithLimiter(apikeys.NewRedisLimiter(redisClient)))
+	}
+
+	var wordOfTheDayOpts []wordofday.ServiceOption
+	adminOpts := []admin.ServiceOption{admin.WithAuditLog(auditService)}
+	if cfg.audio.bucket != "" && cfg.jobs.workers > 0 {
+		// Audio URLs are saved on words, so they mustn't be presigned ones
+		// that expire
+		if cfg.audio.cdnURL == "" {
+			return errors.New("-audio-cdn-url must be set when word audio is cached")
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+
+		audioCache := game.NewAudioCache(db.DB, dictService, s3.NewStorage(awsCfg, cfg.audio.bucket, cfg.audio.cdnURL), jobQueue)
+		audioCache.RegisterJobs(worker)
+		serviceOpts = append(serviceOpts, game.WithAudioJobs(jobQueue))
+		adminOpts = append(adminOpts, admin.WithAudioJobs(jobQueue))
+		wordOfTheDayOpts = append(wordOfTheDayOpts, wordofday.WithAudio(audioCache))
+	}
+	if cfg.voice.bucket != "" {
+		if cfg.voice.purgeInterval <= 0 {
+			return errors.New("-voice-purge-interval must be positive when voice recordings are kept")
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+
+		voiceArchive := game.NewVoiceArchive(db.DB, s3.NewStorage(awsCfg, cfg.voice.bucket, ""), game.VoicePolicy{
+			Retention: cfg.voice.retention,
+			KMSKeyID:  cfg.voice.kmsKeyID,
+		})
+		serviceOpts = append(serviceOpts, game.WithVoiceArchive(voiceArchive))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go voiceArchive.RunPurge(ctx, cfg.voice.purgeInterval, func(err error) {
+			logger.Error("voice recording purge failed", "error", err)
+		})
+	}
+	if cfg.reaper.chimeMeetings {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+		serviceOpts = append(serviceOpts, game.WithMeetings(chime.NewMeetingService(awsCfg)))
+	}
+	if cfg.gameEvents.bus != "" || cfg.gameEvents.topicARN != "" {
+		if cfg.gameEvents.bus != "" && cfg.gameEvents.topicARN != "" {
+			return errors.New("game events can be published to an EventBridge bus or an SNS topic, not both")
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+		var target eventbus.Target = eventbus.NewEventBridge(awsCfg, cfg.gameEvents.bus)
+		if cfg.gameEvents.topicARN != "" {
+			target = eventbus.NewSNS(awsCfg, cfg.gameEvents.topicARN)
+		}
+		publisher := eventbus.NewPublisher(target, eventbus.DefaultQueueSize)
+		serviceOpts = append(serviceOpts, game.WithEventForwarder(publisher))
+
+		// Shutting down waits for the events still queued to be published
+		ctx, cancel := context.WithCancel(context.Background())
+		published := make(chan struct{})
+		defer func() {
+			cancel()
+			<-published
+		}()
+		go func() {
+			defer close(published)
+			publisher.Run(ctx, func(err error) {
+				logger.Warn("game event publishing failed", "error", err)
+			})
+		}()
+	}
+
+	reportService := reports.NewService(db.DB, reports.WithAuditLog(auditService), reports.WithAutoMute(reports.AutoMute{
+		Threshold: cfg.reports.muteThreshold,
+		Window:    cfg.reports.muteWindow,
+		Duration:  cfg.reports.muteDuration,
+	}))
+
+	feedOpts := []feed.ServiceOption{feed.WithMutes(reportService)}
+	if cfg.jobs.workers > 0 {
+		feedOpts = append(feedOpts, feed.WithJobs(jobQueue))
+	}
+	var feedClient *feed.Client
+	if cfg.getstream.key != "" {
+		feedClient = feed.NewClient(cfg.getstream.key, cfg.getstream.secret, cfg.getstream.host, &http.Client{Timeout: 10 * time.Second})
+	}
+	feedService := feed.NewService(db.DB, feedClient, func(err error) {
+		logger.Warn("activity feed update failed", "error", err)
+	}, feedOpts...)
+	feedService.RegisterJobs(worker)
+
+	webhookOpts := []webhooks.ServiceOption{webhooks.WithAuditLog(auditService)}
+	if cfg.jobs.workers > 0 {
+		webhookOpts = append(webhookOpts, webhooks.WithJobs(jobQueue))
+	}
+	webhookService := webhooks.NewService(db.DB, func(err error) {
+		logger.Warn("webhook delivery failed", "error", err)
+	}, webhookOpts...)
+	webhookService.RegisterJobs(worker)
+	authService.SetRegistrationObserver(webhookService)
+
+	var gameReportOpts []gamereport.ServiceOption
+	if cfg.gameReports.bucket != "" {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+		gameReportOpts = append(gameReportOpts, gamereport.WithStorage(s3.NewStorage(awsCfg, cfg.gameReports.bucket, "")))
+		if cfg.jobs.workers > 0 {
+			gameReportOpts = append(gameReportOpts, gamereport.WithJobs(jobQueue))
+		}
+	}
+	gameReportService := gamereport.NewService(db.DB, func(err error) {
+		logger.Warn("game report failed", "error", err)
+	}, gameReportOpts...)
+	gameReportService.RegisterJobs(worker)
+
+	var profileOpts []profile.ServiceOption
+	if cfg.avatars.bucket != "" {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+		profileOpts = append(profileOpts, profile.WithAvatars(s3.NewStorage(awsCfg, cfg.avatars.bucket, cfg.avatars.cdnURL)))
+	}
+
+	wordService := game.NewWordService(db.DB, cfg.openAI.apiKey)
+
+	var sttProviders []stt.Provider
+	for _, name := range strings.Fields(cfg.stt.providers) {
+		switch name {
+		case "openai":
+			sttProviders = append(sttProviders, stt.Provider{Name: name, Transcriber: wordService})
+		case "whisper-server":
+			if cfg.stt.whisperServerURL == "" {
+				return errors.New("the whisper-server transcription provider needs -whisper-server-url")
+			}
+			whisper := stt.NewWhisperServer(cfg.stt.whisperServerURL, &http.Client{Timeout: 30 * time.Second})
+			sttProviders = append(sttProviders, stt.Provider{Name: name, Transcriber: whisper})
+		default:
+			return fmt.Errorf("unknown transcription provider %q: must be openai or whisper-server", name)
+		}
+	}
+	if len(sttProviders) == 0 {
+		return errors.New("at least one transcription provider is needed")
+	}
+	transcription := stt.NewFailover(sttProviders...)
+
+	var letterNames map[string]string
+	if cfg.stt.letterNames != "" {
+		if letterNames, err = stt.LoadLetterNames(cfg.stt.letterNames); err != nil {
+			return err
+		}
+	}
+	spelledOut := stt.SpelledOut(transcription, stt.NewLetters(letterNames))
+	serviceOpts = append(serviceOpts, game.WithTranscriptionPool(stt.NewPool(spelledOut, stt.DefaultWorkers, stt.DefaultQueueDepth)))
+
+	if cfg.stt.checkInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go transcription.Run(ctx, cfg.stt.checkInterval, func(err error) {
+			logger.Warn("transcription provider health check failed", "error", err)
+		})
+	}
+
+	var soloStore interface {
+		solo.SoloGameStore
+		daily.Store
+	}
+	switch cfg.solo.store {
+	case "postgres":
+		soloStore = solo.NewPostgresStore(db.DB)
+	case "dynamodb":
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+		soloStore = dynamodb.NewDynamoDBService(awsdynamodb.NewFromConfig(awsCfg))
+	default:
+		return fmt.Errorf("unknown solo game store %q: must be postgres or dynamodb", cfg.solo.store)
+	}
+	soloService := solo.NewService(db.DB, soloStore, game.TranscribeWith(wordService, spelledOut))
+
+	accountOpts := []account.ServiceOption{account.WithSoloGames(soloService), account.WithAuditLog(auditService)}
+	if cfg.stripe.secretKey != "" {
+		accountOpts = append(accountOpts, account.WithSubscriptions(account.NewStripe(cfg.stripe.secretKey, "", &http.Client{Timeout: 10 * time.Second})))
+	}
+
+	seasonService := season.NewService(db.DB, season.WithNotifier(notificationService), season.WithAuditLog(auditService),
+		season.WithRewardPublisher(feedService), season.WithReadReplicas(db.Reads), season.WithDecay(season.Decay{
+			Threshold:     cfg.seasons.decayThreshold,
+			InactiveAfter: cfg.seasons.decayAfter,
+			Points:        cfg.seasons.decayPoints,
+			Warning:       cfg.seasons.decayWarning,
+		}))
+	ratingService := ranking.NewService(db.DB, ranking.WithNotifier(notificationService), ranking.WithListener(seasonService))
+	integrityService := integrity.NewService(db.DB)
+	gameService := game.NewGameService(db.DB, wordService, dictService,
+		append(serviceOpts, game.WithRankRecorder(ratingService), game.WithNotifier(notificationService), game.WithAuditLog(auditService),
+			game.WithResultPublisher(feedService), game.WithResultPublisher(webhookService), game.WithResultPublisher(gameReportService),
+			game.WithRoundReporter(webhookService), game.WithCheatScreen(integrityService), game.WithReadReplicas(db.Reads),
+			game.WithMutes(reportService))...)
+
+	if cfg.calibration.interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go game.NewCalibrator(db.DB).Run(ctx, cfg.calibration.interval, func(err error) {
+			logger.Error("word calibration failed", "error", err)
+		})
+	}
+
+	if cfg.warehouse.bucket != "" && cfg.warehouse.interval > 0 {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return err
+		}
+		exporter := warehouse.NewExporter(db.DB, s3.NewStorage(awsCfg, cfg.warehouse.bucket, ""), cfg.warehouse.prefix)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go exporter.Run(ctx, cfg.warehouse.interval, func(err error) {
+			logger.Error("warehouse export failed", "error", err)
+		})
+	}
+
+	if cfg.reaper.interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go game.NewReaper(gameService, cfg.reaper.abandonAfter).Run(ctx, cfg.reaper.interval, func(err error) {
+			logger.Error("abandoned game cleanup failed", "error", err)
+		})
+	}
+
+	if cfg.schedule.interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go game.NewScheduler(gameService).Run(ctx, cfg.schedule.interval, func(err error) {
+			logger.Error("scheduled game update failed", "error", err)
+		})
+	}
+
+	if cfg.seasons.finalizeInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go seasonService.Run(ctx, cfg.seasons.finalizeInterval, func(err error) {
+			logger.Error("season finalization failed", "error", err)
+		})
+	}
+
+	if cfg.seasons.decayInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go seasonService.RunDecay(ctx, cfg.seasons.decayInterval, func(err error) {
+			logger.Error("rank decay failed", "error", err)
+		})
+	}
+
+	if feedClient != nil && cfg.getstream.followSyncInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go feedService.Run(ctx, cfg.getstream.followSyncInterval, func(err error) {
+			logger.Error("follow sync failed", "error", err)
+		})
+	}
+
+	if cfg.push.tournamentReminders > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go notificationService.Run(ctx, cfg.push.tournamentReminders, func(err error) {
+			logger.Error("tournament reminders failed", "error", err)
+		})
+	}
+
+	// Pages the API trusts for CORS may also open game WebSockets
+	apiKeys := apikeys.NewService(db.DB, apiKeyOpts...)
+	authService.SetAPIKeys(apiKeys)
+
+	dailyService := daily.NewService(db.DB, soloStore, wordService, daily.WithReadReplicas(db.Reads))
+	profileService := profile.NewService(db.DB, profileOpts...)
+	historyStore := user.NewHistoryStore(db.DB)
+
 	app := &application{
-		config: cfg,
-		db:     db,
-		logger: logger,
-		mailer: mailer,
+		config:      cfg,
+		db:          db,
+		logger:      logger,
+		mailer:      mailer,
+		auth:        authService,
+		authHandler: auth.NewHandler(authService),
+		gameHandler: game.NewHandler(gameService, gameOpts...),
+		categories:  category.NewHandler(category.NewService(db.DB, category.WithAuditLog(auditService))),
+		wordLists:   wordlists.NewHandler(wordlists.NewService(db.DB, dictService)),
+		gameReports: gamereport.NewHandler(gameReportService),
+		integrity:   integrity.NewHandler(integrityService),
+		seasons:     season.NewHandler(seasonService),
+		daily:       daily.NewHandler(dailyService),
+		solo:        solo.NewHandler(soloService),
+		friends:     friends.NewHandler(friends.NewService(db.DB, gameService, friendOpts...)),
+		devices:     notifications.NewHandler(notificationService),
+		notifier:    notificationService,
+		userHandler: user.NewHandler(historyStore),
+		stats:       stats.NewHandler(stats.NewService(db.DB, ratingService)),
+		jobsHandler: jobs.NewHandler(jobQueue),
+		auditLog:    audit.NewHandler(auditService),
+		admin:       admin.NewHandler(admin.NewService(db.DB, adminOpts...), gameService, seasonService),
+		reports:     reports.NewHandler(reportService),
+		feed:        feed.NewHandler(feedService),
+		profiles:    profile.NewHandler(profileService),
+		accounts:    account.NewHandler(account.NewService(db.DB, accountOpts...)),
+		apiKeys:     apikeys.NewHandler(apiKeys),
+		graph:       graph.NewHandler(gameService, profileService, historyStore, seasonService, dailyService),
+		webhooks:    webhooks.NewHandler(webhookService),
+
+		transcription: transcription,
+		dictionary:    dictHealth,
+	}
+	if cfg.grpcPort > 0 {
+		app.grpc = game.NewGRPCServer(gameService, wordService, authService)
+	}
+
+	// Consent requests and email change confirmations go out through the
+	// app's email queue, so they're wired up once there's an app to send them
+	parentalService := parental.NewService(db.DB, app.sendEmail, cfg.baseURL+"/parental-consent")
+	authService.SetConsentRequester(parentalService)
+	authService.SetEmailSender(app.sendEmail, cfg.baseURL+"/confirm-email")
+	gameReportService.SetEmailSender(app.sendEmail)
+	app.parental = parental.NewHandler(parentalService)
+
+	wordOfTheDay := wordofday.NewService(db.DB, dictService, func(err error) {
+		logger.Warn("word of the day enrichment failed", "error", err)
+	}, append(wordOfTheDayOpts, wordofday.WithNotifier(notificationService), wordofday.WithEmail(app.sendEmail))...)
+	app.wordOfTheDay = wordofday.NewHandler(wordOfTheDay)
+
+	app.invitations = invitations.NewHandler(invitations.NewService(db.DB, cfg.baseURL+"/join", func(err error) {
+		logger.Warn("game invitation delivery failed", "error", err)
+	}, invitations.WithNotifier(notificationService), invitations.WithEmail(app.sendEmail)))
+
+	if cfg.wordOfTheDay.interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go wordOfTheDay.Run(ctx, cfg.wordOfTheDay.interval, cfg.wordOfTheDay.sendHour, func(err error) {
+			logger.Error("sending the word of the day failed", "error", err)
+		})
+	}
+
+	if cfg.jobs.workers > 0 {
+		app.jobs = jobQueue
+		worker.Register(jobSendEmail, app.runSendEmail)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go worker.Run(ctx)
 	}
 
 	return app.serveHTTP()
 }
+
+// newNotificationService sends push notifications through whichever of APNs
+// and FCM are configured, and texts through SNS if it's enabled
+func newNotificationService(db *database.DB, cfg config, logger *slog.Logger) (*notifications.Service, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	opts := []notifications.ServiceOption{notifications.WithUnsubscribeKey([]byte(cfg.notifications.unsubscribeKey))}
+
+	if cfg.push.apnsKeyFile != "" {
+		key, err := os.ReadFile(cfg.push.apnsKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		apnsConfig := notifications.APNsConfig{
+			Key:    key,
+			KeyID:  cfg.push.apnsKeyID,
+			TeamID: cfg.push.apnsTeamID,
+			Topic:  cfg.push.apnsTopic,
+		}
+		if cfg.push.apnsSandbox {
+			apnsConfig.Host = notifications.APNsSandboxHost
+		}
+		apns, err := notifications.NewAPNs(apnsConfig, client)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, notifications.WithSender(notifications.PlatformIOS, apns))
+	}
+
+	if cfg.push.fcmCredentialsFile != "" {
+		credentials, err := os.ReadFile(cfg.push.fcmCredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		fcm, err := notifications.NewFCM(credentials, client)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, notifications.WithSender(notifications.PlatformAndroid, fcm))
+	}
+
+	if cfg.sms.enabled {
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(cfg.audio.awsRegion))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, notifications.WithSMS(sms.NewSNS(awsCfg, cfg.sms.senderID)))
+	}
+
+	return notifications.NewService(db.DB, func(err error) {
+		logger.Warn("push notification failed", "error", err)
+	}, opts...), nil
+}
+
+// newTracer builds the configured trace exporter, or returns nil when
+// tracing is off
+func newTracer(cfg config, logger *slog.Logger) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+	switch cfg.tracing.exporter {
+	case "none", "":
+		return nil, nil
+	case "log":
+		exporter = tracing.NewLogExporter(logger)
+	case "otlp":
+		exporter = tracing.NewOTLPExporter(cfg.tracing.otlpEndpoint, "big-spella-api", &http.Client{Timeout: 10 * time.Second})
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.tracing.exporter)
+	}
+
+	return tracing.NewTracer(exporter, cfg.tracing.sampleRatio, func(err error) {
+		logger.Warn("tracing failed", "error", err)
+	}), nil
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 
 	"time"
 
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/auth"
 	"big-spella-go/internal/response"
 
 	"github.com/pascaldekloe/jwt"
@@ -38,7 +42,7 @@ func (app *application) logAccess(next http.Handler) http.Handler {
 		var (
 			ip     = realip.FromRequest(r)
 			method = r.Method
-			url    = r.URL.String()
+			url    = loggedURL(r.URL)
 			proto  = r.Proto
 		)
 
@@ -50,6 +54,121 @@ func (app *application) logAccess(next http.Handler) http.Handler {
 	})
 }
 
+// redactedParams are the query parameters that carry credentials: the game
+// WebSocket's access token and unsubscribe links' tokens
+var redactedParams = []string{"access_token", "token"}
+
+// loggedURL is u as access logs record it, with credentials in its query
+// redacted
+func loggedURL(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+
+	logged := *u
+	logged.RawQuery = query.Encode()
+	return logged.String()
+}
+
+// noteClientIP has audit log entries recorded while serving r note where it
+// came from
+func (app *application) noteClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(audit.WithIP(r.Context(), realip.FromRequest(r))))
+	})
+}
+
+func (app *application) enableCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+
+		origin := r.Header.Get("Origin")
+
+		if origin != "" && slices.Contains(app.config.cors.trustedOrigins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PUT, PATCH, DELETE")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireTournamentsScope limits next to organizer tooling holding a token
+// with the tournaments:manage scope
+func (app *application) requireTournamentsScope(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(auth.ScopeTournamentsManage, next))
+}
+
+// requireWordsScope limits next to admin tooling holding a token with the
+// words:manage scope
+func (app *application) requireWordsScope(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(auth.ScopeWordsManage, next))
+}
+
+// requireSeasonsScope limits next to admin tooling holding a token with the
+// seasons:manage scope
+func (app *application) requireSeasonsScope(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(auth.ScopeSeasonsManage, next))
+}
+
+// requireJobsScope limits next to admin tooling holding a token with the
+// jobs:manage scope
+func (app *application) requireJobsScope(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(auth.ScopeJobsManage, next))
+}
+
+// requireAuditScope limits next to admin tooling holding a token with the
+// audit:read scope
+func (app *application) requireAuditScope(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(auth.ScopeAuditRead, next))
+}
+
+// requireOperator limits next to the admin console: operators signed in as
+// themselves, or a token with the admin scope
+func (app *application) requireOperator(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireOperator(next))
+}
+
+// requirePlayerScope limits next to players whose token lets them act in
+// games, which covers their devices, blocks and reports too
+func (app *application) requirePlayerScope(next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesWrite, next))
+}
+
+// requireReadScope limits next to players' tokens, which can read games, and
+// to API keys granted scope, for the data third-party integrations read
+func (app *application) requireReadScope(scope auth.Scope, next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireAnyScope([]auth.Scope{auth.ScopeGamesRead, scope}, next))
+}
+
+// requireRecentTwoFactor limits next to players as requirePlayerScope does,
+// and has those with two-factor authentication on confirm a code first, for
+// sensitive changes to their account
+func (app *application) requireRecentTwoFactor(next http.HandlerFunc) http.Handler {
+	return app.requirePlayerScope(app.auth.RequireRecentTwoFactor(next).ServeHTTP)
+}
+
+// requireAdultScope limits next to tokens holding scope that weren't issued
+// to a child's account, for the social features children are kept out of
+func (app *application) requireAdultScope(scope auth.Scope, next http.HandlerFunc) http.Handler {
+	return app.auth.Middleware(app.auth.RequireScope(scope, app.auth.RequireAdult(next)))
+}
+
 func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Authorization")
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+
+	"big-spella-go/internal/admin"
+	"big-spella-go/internal/apikeys"
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/feed"
+	"big-spella-go/internal/friends"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/category"
+	"big-spella-go/internal/game/daily"
+	"big-spella-go/internal/game/invitations"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/game/solo"
+	"big-spella-go/internal/game/wordlists"
+	"big-spella-go/internal/game/wordofday"
+	"big-spella-go/internal/graph"
+	"big-spella-go/internal/notifications"
+	"big-spella-go/internal/openapi"
+	"big-spella-go/internal/parental"
+	"big-spella-go/internal/profile"
+	"big-spella-go/internal/reports"
+	"big-spella-go/internal/webhooks"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// requestBodies are the JSON bodies routes take, by method and path. Routes
+// not listed are described without one.
+var requestBodies = map[string]any{
+	"POST /auth/register":      auth.RegisterInput{},
+	"POST /auth/login":         auth.LoginInput{},
+	"POST /auth/refresh":       auth.RefreshInput{},
+	"POST /auth/service-token": auth.ServiceTokenInput{},
+	"POST /auth/2fa/verify":    auth.CodeInput{},
+	"POST /auth/2fa/confirm":   auth.CodeInput{},
+	"POST /auth/2fa/step-up":   auth.CodeInput{},
+	"DELETE /auth/2fa":         auth.CodeInput{},
+	"PUT /auth/email":          auth.ChangeEmailInput{},
+	"POST /auth/email/confirm": auth.ConfirmEmailInput{},
+	"PUT /auth/password":       auth.ChangePasswordInput{},
+
+	"POST /games":                                          game.CreateGameRequest{},
+	"POST /games/:gameID":                                  game.JoinByCodeRequest{},
+	"POST /games/:gameID/join":                             game.JoinGameRequest{},
+	"POST /games/:gameID/attempt":                          game.MakeAttemptRequest{},
+	"POST /games/:gameID/hint":                             game.HintRequest{},
+	"POST /games/:gameID/attempts/:attemptID/ruling":       game.RulingRequest{},
+	"POST /games/:gameID/attempts/:attemptID/confirmation": game.ConfirmationRequest{},
+	"POST /games/:gameID/attempts/:attemptID/appeal":       game.AppealRequest{},
+	"POST /appeals/:appealID/decision":                     game.AppealDecisionRequest{},
+	"PUT /audio/preferences":                               game.AudioPreferencesRequest{},
+	"POST /games/:gameID/invitations":                      invitations.InviteRequest{},
+	"POST /friends/:userID/challenge":                      friends.ChallengeRequest{},
+	"POST /friend-requests":                                friends.FriendRequest{},
+	"POST /reports":                                        reports.FileRequest{},
+	"PATCH /profile":                                       profile.UpdateRequest{},
+	"POST /profile/avatar/uploads":                         profile.AvatarUploadRequest{},
+	"POST /profile/avatar/uploads/complete":                profile.CompleteUploadRequest{},
+	"POST /posts":                                          feed.PostRequest{},
+	"POST /posts/:postID/comments":                         feed.CommentRequest{},
+	"POST /parental-consent":                               parental.ConsentRequest{},
+	"POST /devices":                                        notifications.DeviceRequest{},
+	"PUT /notifications/preferences":                       notifications.PreferencesRequest{},
+	"PUT /words/today/subscription":                        wordofday.Subscription{},
+	"POST /categories":                                     category.CreateRequest{},
+	"PATCH /categories/:categoryID":                        category.UpdateRequest{},
+	"POST /categories/:categoryID/words":                   category.AddWordsRequest{},
+	"POST /word-lists":                                     wordlists.CreateRequest{},
+	"PATCH /word-lists/:listID":                            wordlists.RenameRequest{},
+	"PUT /word-lists/:listID/words":                        wordlists.WordsRequest{},
+	"POST /seasons":                                        season.CreateRequest{},
+	"POST /daily/start":                                    daily.StartRequest{},
+	"POST /daily/submit":                                   daily.SubmitRequest{},
+	"POST /solo/games":                                     solo.StartRequest{},
+	"POST /solo/games/:gameID/attempts":                    solo.AttemptRequest{},
+	"POST /solo/drills":                                    solo.DrillRequest{},
+	"POST /graphql":                                        graph.Request{},
+	"POST /developers/keys":                                apikeys.CreateInput{},
+	"POST /webhooks":                                       webhooks.CreateInput{},
+	"POST /admin/users/:userID/suspension":                 admin.SuspendRequest{},
+	"POST /admin/users/:userID/rating-adjustments":         admin.AdjustRatingRequest{},
+	"PUT /admin/users/:userID/decay-exemption":             admin.DecayExemptionRequest{},
+	"POST /admin/games/:gameID/cancel":                     admin.EndGameRequest{},
+	"POST /admin/games/:gameID/end":                        admin.EndGameRequest{},
+	"POST /admin/games/:gameID/flags/review":               admin.ReviewFlagsRequest{},
+	"PUT /admin/word-filters/:language/:word":              admin.ListWordRequest{},
+	"PATCH /admin/words/:wordID":                           admin.EditWordRequest{},
+	"POST /admin/words/:wordID/status":                     admin.WordStatusRequest{},
+	"POST /admin/reports/:reportID/resolve":                reports.CloseRequest{},
+	"POST /admin/reports/:reportID/dismiss":                reports.CloseRequest{},
+}
+
+// router registers routes with httprouter, describing each in the OpenAPI
+// document as it goes and holding requests to the body it describes
+type router struct {
+	*httprouter.Router
+	spec   *openapi.Spec
+	routes map[string]bool
+}
+
+func newRouter(spec *openapi.Spec) *router {
+	return &router{Router: httprouter.New(), spec: spec, routes: map[string]bool{}}
+}
+
+func (r *router) Handler(method, path string, handler http.Handler) {
+	route := method + " " + path
+	r.routes[route] = true
+	r.Router.Handler(method, path, r.spec.Route(method, path, requestBodies[route], handler))
+}
+
+func (r *router) HandlerFunc(method, path string, handler http.HandlerFunc) {
+	r.Handler(method, path, handler)
+}
+
+// checkBodies panics if a body is listed for a route that was never
+// registered, since its requests would go unchecked
+func (r *router) checkBodies() {
+	for route := range requestBodies {
+		if !r.routes[route] {
+			panic("openapi: body listed for unregistered route " + route)
+		}
+	}
+}
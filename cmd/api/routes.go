@@ -1,24 +1,201 @@
 package main
 
 import (
+	"expvar"
 	"net/http"
 
-	"github.com/julienschmidt/httprouter"
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/metrics"
+	"big-spella-go/internal/openapi"
+	"big-spella-go/internal/version"
 )
 
 func (app *application) routes() http.Handler {
-	mux := httprouter.New()
+	mux := newRouter(openapi.New("Big Spella API", version.Get()))
 
 	mux.NotFound = http.HandlerFunc(app.notFound)
 	mux.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowed)
 
 	mux.HandlerFunc("GET", "/status", app.status)
+	mux.HandlerFunc("GET", "/health", app.health)
+	mux.HandlerFunc("GET", "/time", app.serverTime)
+	mux.Handler("GET", "/openapi.json", mux.spec)
 	mux.HandlerFunc("POST", "/users", app.createUser)
 	mux.HandlerFunc("POST", "/authentication-tokens", app.createAuthenticationToken)
 
-	mux.Handler("GET", "/protected", app.requireAuthenticatedUser(http.HandlerFunc(app.protected)))
+	mux.Handler("GET", "/protected", app.authenticate(app.requireAuthenticatedUser(http.HandlerFunc(app.protected))))
 
 	mux.Handler("GET", "/basic-auth-protected", app.requireBasicAuthentication(http.HandlerFunc(app.protected)))
+	mux.Handler("GET", "/debug/vars", app.requireBasicAuthentication(expvar.Handler()))
+	mux.Handler("GET", "/metrics", app.requireBasicAuthentication(metrics.Handler()))
 
-	return app.logAccess(app.recoverPanic(app.authenticate(mux)))
+	if app.config.dev {
+		mux.HandlerFunc("GET", "/dev/emails", app.emailPreviews)
+		mux.HandlerFunc("GET", "/dev/emails/:template", app.emailPreview)
+		mux.HandlerFunc("GET", "/dev/email-images/:name", app.emailImage)
+	}
+
+	// Game players authenticate with tokens from the auth service, which
+	// the template's authenticate middleware would reject, so each half of
+	// the API checks its own tokens
+	mux.HandlerFunc("POST", "/auth/register", app.authHandler.Register)
+	mux.HandlerFunc("POST", "/auth/login", app.authHandler.Login)
+	mux.HandlerFunc("POST", "/auth/refresh", app.authHandler.RefreshToken)
+	mux.HandlerFunc("POST", "/auth/service-token", app.authHandler.ServiceToken)
+	mux.Handler("GET", "/auth/me", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.Me))))
+	mux.Handler("GET", "/auth/sessions", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.Sessions))))
+	mux.Handler("DELETE", "/auth/sessions/:id", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.RevokeSession))))
+	mux.HandlerFunc("POST", "/auth/2fa/verify", app.authHandler.VerifyTwoFactor)
+	mux.Handler("POST", "/auth/2fa", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.EnrollTwoFactor))))
+	mux.Handler("POST", "/auth/2fa/confirm", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.ConfirmTwoFactor))))
+	mux.Handler("POST", "/auth/2fa/step-up", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.StepUpTwoFactor))))
+	mux.Handler("DELETE", "/auth/2fa", app.auth.Middleware(app.auth.RequireAuth(http.HandlerFunc(app.authHandler.DisableTwoFactor))))
+	mux.Handler("PUT", "/auth/email", app.requireRecentTwoFactor(app.authHandler.ChangeEmail))
+	mux.HandlerFunc("POST", "/auth/email/confirm", app.authHandler.ConfirmEmail)
+	mux.Handler("PUT", "/auth/password", app.requireRecentTwoFactor(app.authHandler.ChangePassword))
+
+	app.gameHandler.Register(mux, app.auth.Middleware)
+
+	mux.Handler("GET", "/users/:id/games", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.userHandler.GameHistory))))
+	mux.Handler("GET", "/users/:id/stats", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.stats.Get))))
+	mux.Handler("GET", "/users/:id/profile", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.profiles.Get))))
+	mux.Handler("GET", "/users/:id/feed", app.requireAdultScope(auth.ScopeUsersRead, app.feed.UserFeed))
+	mux.Handler("GET", "/users/:id/posts", app.requireAdultScope(auth.ScopeUsersRead, app.feed.Posts))
+	mux.Handler("GET", "/users/:id/export", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.accounts.Export))))
+	mux.Handler("DELETE", "/users/me", app.requireRecentTwoFactor(app.accounts.Delete))
+
+	mux.Handler("GET", "/friends", app.requireAdultScope(auth.ScopeUsersRead, app.friends.List))
+	mux.Handler("DELETE", "/friends/:userID", app.requireAdultScope(auth.ScopeGamesWrite, app.friends.Remove))
+	mux.Handler("POST", "/friends/:userID/challenge", app.requireAdultScope(auth.ScopeGamesWrite, app.friends.Challenge))
+	mux.Handler("GET", "/friend-requests", app.requireAdultScope(auth.ScopeUsersRead, app.friends.Requests))
+	mux.Handler("POST", "/friend-requests", app.requireAdultScope(auth.ScopeGamesWrite, app.friends.SendRequest))
+	mux.Handler("POST", "/friend-requests/:userID/accept", app.requireAdultScope(auth.ScopeGamesWrite, app.friends.Accept))
+	mux.Handler("POST", "/friend-requests/:userID/decline", app.requireAdultScope(auth.ScopeGamesWrite, app.friends.Decline))
+	mux.Handler("GET", "/friend-challenges", app.requireAdultScope(auth.ScopeUsersRead, app.friends.Challenges))
+	mux.Handler("GET", "/games/:gameID/report", app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesRead, http.HandlerFunc(app.gameReports.Get))))
+	mux.Handler("POST", "/games/:gameID/invitations", app.requireAdultScope(auth.ScopeGamesWrite, app.invitations.Invite))
+	mux.Handler("POST", "/blocks/:userID", app.requirePlayerScope(app.friends.Block))
+	mux.Handler("DELETE", "/blocks/:userID", app.requirePlayerScope(app.friends.Unblock))
+	mux.Handler("POST", "/reports", app.requirePlayerScope(app.reports.File))
+	mux.Handler("GET", "/profile", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.profiles.Me))))
+	mux.Handler("PATCH", "/profile", app.requireAdultScope(auth.ScopeGamesWrite, app.profiles.Update))
+	mux.Handler("POST", "/profile/avatar", app.requireAdultScope(auth.ScopeGamesWrite, app.profiles.UploadAvatar))
+	mux.Handler("POST", "/profile/avatar/uploads", app.requireAdultScope(auth.ScopeGamesWrite, app.profiles.StartAvatarUpload))
+	mux.Handler("POST", "/profile/avatar/uploads/complete", app.requireAdultScope(auth.ScopeGamesWrite, app.profiles.CompleteAvatarUpload))
+	mux.Handler("GET", "/feed/timeline", app.requireAdultScope(auth.ScopeUsersRead, app.feed.Timeline))
+	mux.Handler("POST", "/posts", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.CreatePost))
+	mux.Handler("GET", "/posts/:postID", app.requireAdultScope(auth.ScopeUsersRead, app.feed.Post))
+	mux.Handler("DELETE", "/posts/:postID", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.DeletePost))
+	mux.Handler("POST", "/posts/:postID/likes", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.Like))
+	mux.Handler("DELETE", "/posts/:postID/likes", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.Unlike))
+	mux.Handler("GET", "/posts/:postID/comments", app.requireAdultScope(auth.ScopeUsersRead, app.feed.Comments))
+	mux.Handler("POST", "/posts/:postID/comments", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.AddComment))
+	mux.Handler("DELETE", "/posts/:postID/comments/:commentID", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.DeleteComment))
+	mux.Handler("POST", "/follows/:userID", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.Follow))
+	mux.Handler("DELETE", "/follows/:userID", app.requireAdultScope(auth.ScopeGamesWrite, app.feed.Unfollow))
+
+	// Parents consent to their children's accounts and follow them from
+	// their own
+	mux.Handler("POST", "/parental-consent", app.requireAdultScope(auth.ScopeGamesWrite, app.parental.Consent))
+	mux.Handler("GET", "/children", app.requireAdultScope(auth.ScopeUsersRead, app.parental.Children))
+	mux.Handler("GET", "/children/:childID/dashboard", app.requireAdultScope(auth.ScopeUsersRead, app.parental.Dashboard))
+	mux.Handler("DELETE", "/children/:childID/consent", app.requireAdultScope(auth.ScopeGamesWrite, app.parental.WithdrawConsent))
+	mux.Handler("PUT", "/children/:childID/consent", app.requireAdultScope(auth.ScopeGamesWrite, app.parental.RestoreConsent))
+
+	mux.Handler("POST", "/devices", app.requirePlayerScope(app.devices.RegisterDevice))
+	mux.Handler("DELETE", "/devices/:token", app.requirePlayerScope(app.devices.UnregisterDevice))
+	mux.Handler("GET", "/notifications/preferences", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.devices.Preferences))))
+	mux.Handler("PUT", "/notifications/preferences", app.requirePlayerScope(app.devices.UpdatePreferences))
+	mux.Handler("GET", "/notifications/phone", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.devices.Phone))))
+	mux.Handler("PUT", "/notifications/phone", app.requireAdultScope(auth.ScopeGamesWrite, app.devices.UpdatePhone))
+	mux.Handler("DELETE", "/notifications/phone", app.requirePlayerScope(app.devices.RemovePhone))
+	mux.Handler("POST", "/notifications/phone/verify", app.requireAdultScope(auth.ScopeGamesWrite, app.devices.VerifyPhone))
+	mux.Handler("PUT", "/notifications/phone/consent", app.requireAdultScope(auth.ScopeGamesWrite, app.devices.UpdateSMSConsent))
+	mux.HandlerFunc("GET", "/notifications/unsubscribe", app.devices.ConfirmUnsubscribe)
+	mux.HandlerFunc("POST", "/notifications/unsubscribe", app.devices.Unsubscribe)
+
+	mux.Handler("GET", "/words/today", app.requireReadScope(auth.ScopeWordsRead, app.wordOfTheDay.Today))
+	mux.Handler("GET", "/words/today/subscription", app.auth.Middleware(app.auth.RequireScope(auth.ScopeUsersRead, http.HandlerFunc(app.wordOfTheDay.Subscription))))
+	mux.Handler("PUT", "/words/today/subscription", app.requirePlayerScope(app.wordOfTheDay.Subscribe))
+	mux.Handler("GET", "/categories", app.requireReadScope(auth.ScopeWordsRead, app.categories.List))
+	mux.Handler("POST", "/categories", app.requireWordsScope(app.categories.Create))
+	mux.Handler("PATCH", "/categories/:categoryID", app.requireWordsScope(app.categories.Update))
+	mux.Handler("DELETE", "/categories/:categoryID", app.requireWordsScope(app.categories.Delete))
+	mux.Handler("POST", "/categories/:categoryID/words", app.requireWordsScope(app.categories.AddWords))
+	mux.Handler("DELETE", "/categories/:categoryID/words/:wordID", app.requireWordsScope(app.categories.RemoveWord))
+	mux.Handler("GET", "/word-lists", app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesRead, http.HandlerFunc(app.wordLists.List))))
+	mux.Handler("POST", "/word-lists", app.requirePlayerScope(app.wordLists.Create))
+	mux.Handler("GET", "/word-lists/:listID", app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesRead, http.HandlerFunc(app.wordLists.Get))))
+	mux.Handler("PATCH", "/word-lists/:listID", app.requirePlayerScope(app.wordLists.Rename))
+	mux.Handler("PUT", "/word-lists/:listID/words", app.requirePlayerScope(app.wordLists.SetWords))
+	mux.Handler("DELETE", "/word-lists/:listID", app.requirePlayerScope(app.wordLists.Delete))
+
+	mux.Handler("GET", "/seasons", app.requireReadScope(auth.ScopeLeaderboardsRead, app.seasons.List))
+	mux.Handler("GET", "/seasons/:seasonID", app.requireReadScope(auth.ScopeLeaderboardsRead, app.seasons.Get))
+	mux.Handler("GET", "/seasons/:seasonID/standings", app.requireReadScope(auth.ScopeLeaderboardsRead, app.seasons.Standings))
+	mux.Handler("POST", "/seasons", app.requireSeasonsScope(app.seasons.Create))
+	mux.Handler("POST", "/seasons/:seasonID/finalize", app.requireSeasonsScope(app.seasons.Finalize))
+
+	mux.Handler("POST", "/daily/start", app.requirePlayerScope(app.daily.Start))
+	mux.Handler("POST", "/daily/submit", app.requirePlayerScope(app.daily.Submit))
+	mux.Handler("GET", "/daily/leaderboard", app.requireReadScope(auth.ScopeLeaderboardsRead, app.daily.Leaderboard))
+
+	mux.Handler("POST", "/solo/games", app.requirePlayerScope(app.solo.Start))
+	mux.Handler("GET", "/solo/games", app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesRead, http.HandlerFunc(app.solo.List))))
+	mux.Handler("GET", "/solo/games/:gameID", app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesRead, http.HandlerFunc(app.solo.Get))))
+	mux.Handler("DELETE", "/solo/games/:gameID", app.requirePlayerScope(app.solo.Delete))
+	mux.Handler("POST", "/solo/games/:gameID/attempts", app.requirePlayerScope(app.solo.Attempt))
+	mux.Handler("POST", "/solo/games/:gameID/complete", app.requirePlayerScope(app.solo.Complete))
+	mux.Handler("GET", "/solo/missed-words", app.auth.Middleware(app.auth.RequireScope(auth.ScopeGamesRead, http.HandlerFunc(app.solo.MissedWords))))
+	mux.Handler("POST", "/solo/drills", app.requirePlayerScope(app.solo.StartDrill))
+
+	// Fields check their own scopes, so one query can mix what a token may
+	// and may not read
+	mux.Handler("POST", "/graphql", app.auth.Middleware(app.graph))
+
+	// Players make API keys for bots and classroom tools here
+	mux.Handler("GET", "/developers/keys", app.requireAdultScope(auth.ScopeUsersRead, app.apiKeys.List))
+	mux.Handler("POST", "/developers/keys", app.requireAdultScope(auth.ScopeGamesWrite, app.apiKeys.Create))
+	mux.Handler("DELETE", "/developers/keys/:keyID", app.requireAdultScope(auth.ScopeGamesWrite, app.apiKeys.Revoke))
+
+	mux.Handler("POST", "/tournaments/:tournamentID/integrity-report", app.requireTournamentsScope(app.integrity.GenerateReport))
+	mux.Handler("GET", "/tournaments/:tournamentID/integrity-report", app.requireTournamentsScope(app.integrity.GetReport))
+
+	// Organizers' tournament software hears about games and new players here
+	mux.Handler("GET", "/webhooks", app.requireTournamentsScope(app.webhooks.List))
+	mux.Handler("POST", "/webhooks", app.requireTournamentsScope(app.webhooks.Create))
+	mux.Handler("DELETE", "/webhooks/:webhookID", app.requireTournamentsScope(app.webhooks.Delete))
+	mux.Handler("GET", "/webhooks/:webhookID/deliveries", app.requireTournamentsScope(app.webhooks.Deliveries))
+
+	mux.Handler("GET", "/jobs", app.requireJobsScope(app.jobsHandler.List))
+	mux.Handler("POST", "/jobs/:jobID/retry", app.requireJobsScope(app.jobsHandler.Retry))
+	mux.Handler("GET", "/job-stats", app.requireJobsScope(app.jobsHandler.Stats))
+
+	mux.Handler("GET", "/audit-log", app.requireAuditScope(app.auditLog.List))
+
+	mux.Handler("GET", "/admin/users", app.requireOperator(app.admin.SearchUsers))
+	mux.Handler("POST", "/admin/users/:userID/suspension", app.requireOperator(app.admin.Suspend))
+	mux.Handler("DELETE", "/admin/users/:userID/suspension", app.requireOperator(app.admin.Reinstate))
+	mux.Handler("POST", "/admin/users/:userID/rating-adjustments", app.requireOperator(app.admin.AdjustRating))
+	mux.Handler("PUT", "/admin/users/:userID/decay-exemption", app.requireOperator(app.admin.SetDecayExemption))
+	mux.Handler("POST", "/admin/games/:gameID/cancel", app.requireOperator(app.admin.CancelGame))
+	mux.Handler("POST", "/admin/games/:gameID/end", app.requireOperator(app.admin.EndGame))
+	mux.Handler("GET", "/admin/integrity-reports", app.requireOperator(app.admin.IntegrityReports))
+	mux.Handler("GET", "/admin/game-flags", app.requireOperator(app.admin.GameFlags))
+	mux.Handler("POST", "/admin/games/:gameID/flags/review", app.requireOperator(app.admin.ReviewFlags))
+	mux.Handler("GET", "/admin/word-filters", app.requireOperator(app.admin.ListedWords))
+	mux.Handler("PUT", "/admin/word-filters/:language/:word", app.requireOperator(app.admin.ListWord))
+	mux.Handler("DELETE", "/admin/word-filters/:language/:word", app.requireOperator(app.admin.UnlistWord))
+	mux.Handler("GET", "/admin/words", app.requireOperator(app.admin.Words))
+	mux.Handler("PATCH", "/admin/words/:wordID", app.requireOperator(app.admin.EditWord))
+	mux.Handler("POST", "/admin/words/:wordID/status", app.requireOperator(app.admin.SetWordStatus))
+	mux.Handler("GET", "/admin/words/:wordID/reviews", app.requireOperator(app.admin.WordReviews))
+	mux.Handler("POST", "/admin/word-levels/:level/audio", app.requireOperator(app.admin.PregenerateAudio))
+	mux.Handler("GET", "/admin/reports", app.requireOperator(app.reports.Queue))
+	mux.Handler("POST", "/admin/reports/:reportID/resolve", app.requireOperator(app.reports.Resolve))
+	mux.Handler("POST", "/admin/reports/:reportID/dismiss", app.requireOperator(app.reports.Dismiss))
+
+	mux.checkBodies()
+
+	return app.traceRequests(mux.Router, app.measureRequests(mux.Router, app.logAccess(app.recoverPanic(app.noteClientIP(app.enableCORS(mux))))))
 }
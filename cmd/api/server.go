@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -34,14 +35,36 @@ func (app *application) serveHTTP() error {
 	go func() {
 		quitChan := make(chan os.Signal, 1)
 		signal.Notify(quitChan, syscall.SIGINT, syscall.SIGTERM)
-		<-quitChan
+		sig := <-quitChan
 
-		ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownPeriod)
+		app.logger.Info("shutting down server", slog.Group("server", "addr", srv.Addr), "signal", sig.String())
+
+		ctx, cancel := context.WithTimeout(context.Background(), app.config.shutdownTimeout)
 		defer cancel()
 
+		if app.grpc != nil {
+			app.wg.Add(1)
+			go func() {
+				defer app.wg.Done()
+				app.stopGRPC(ctx)
+			}()
+		}
 		shutdownErrorChan <- srv.Shutdown(ctx)
 	}()
 
+	if app.grpc != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", app.config.grpcPort))
+		if err != nil {
+			return err
+		}
+		go func() {
+			app.logger.Info("starting gRPC server", slog.Group("server", "addr", lis.Addr().String()))
+			if err := app.grpc.Serve(lis); err != nil {
+				app.logger.Error("gRPC server failed", "error", err)
+			}
+		}()
+	}
+
 	app.logger.Info("starting server", slog.Group("server", "addr", srv.Addr))
 
 	err := srv.ListenAndServe()
@@ -59,3 +82,18 @@ func (app *application) serveHTTP() error {
 	app.wg.Wait()
 	return nil
 }
+
+// stopGRPC lets in-flight gRPC calls finish until ctx is done, then cuts
+// off those left, such as event streams that never end on their own
+func (app *application) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		app.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		app.grpc.Stop()
+	}
+}
@@ -0,0 +1,109 @@
+package account
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+// Me is the path ID players use for themselves. Exports are served at
+// /users/:id/export as the router can't have /users/me beside /users/:id.
+const Me = "me"
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Delete erases the caller's account
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Export serves a copy of the caller's personal data, at /users/me/export
+// or under their ID, as JSON or, with ?format=zip, a ZIP archive
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var v validator.Validator
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if id != Me {
+		_, err := uuid.Parse(id)
+		v.CheckField(err == nil, "id", "Must be a valid ID or \"me\"")
+	}
+	format := r.URL.Query().Get("format")
+	v.CheckField(validator.In(format, "", "json", "zip"), "format", "Must be json or zip")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+	if id != Me && id != userID {
+		http.Error(w, "players can only export their own data", http.StatusForbidden)
+		return
+	}
+
+	export, err := h.service.Export(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="big-spella-export.zip"`)
+		export.WriteZip(w)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="big-spella-export.json"`)
+	if err := response.JSON(w, http.StatusOK, export); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrBillingUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,71 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestExportRequest(t *testing.T) {
+	tests := []struct {
+		id, query string
+		code      int
+	}{
+		{"not-a-uuid", "", http.StatusUnprocessableEntity},
+		{Me, "?format=csv", http.StatusUnprocessableEntity},
+		{"6f1c2a9e-3b7d-4c1e-9a2f-1d5b8e7c4a30", "", http.StatusForbidden},
+	}
+
+	h := NewHandler(NewService(nil))
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users/"+tt.id+"/export"+tt.query, nil)
+		ctx := auth.SetUserIDInContext(req.Context(), "0b7e5c1d-2f4a-4e8b-b6c3-9d1a7f2e5c48")
+		ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tt.id}})
+		rec := httptest.NewRecorder()
+
+		h.Export(rec, req.WithContext(ctx))
+
+		assert.Equal(t, tt.code, rec.Code, tt.id+tt.query)
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	export := &Export{
+		ExportedAt: time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC),
+		Profile:    Profile{ID: "u-1", Username: "ada"},
+		Games:      []Game{{GameID: "g-1", Score: 40}},
+		Attempts:   []Attempt{},
+		Posts:      []Post{},
+		Comments:   []Comment{{ID: "c-1", PostID: "p-1", Content: "well spelled"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteZip(&buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	assert.Len(t, files, 6)
+
+	r, err := files["profile.json"].Open()
+	require.NoError(t, err)
+	defer r.Close()
+	var profile Profile
+	require.NoError(t, json.NewDecoder(r).Decode(&profile))
+	assert.Equal(t, "ada", profile.Username)
+}
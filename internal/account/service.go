@@ -0,0 +1,349 @@
+// Package account lets players take a copy of their personal data, or have
+// it erased
+package account
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/game/solo"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrBillingUnavailable is returned when deleting the account of a
+	// paying player without a way to cancel their subscriptions
+	ErrBillingUnavailable = errors.New("subscriptions can't be cancelled")
+)
+
+// soloPageSize is how many solo games are read at a time for an export
+const soloPageSize = 100
+
+// Subscriptions cancels a customer's subscriptions. Stripe implements it.
+type Subscriptions interface {
+	CancelSubscriptions(ctx context.Context, customerID string) error
+}
+
+// SoloGames keeps players' solo games. solo.Service implements it.
+type SoloGames interface {
+	List(ctx context.Context, userID string, before time.Time, limit int) ([]solo.Game, error)
+	Purge(ctx context.Context, userID string) error
+}
+
+type ServiceOption func(*Service)
+
+// WithSubscriptions cancels paying players' subscriptions when they delete
+// their account
+func WithSubscriptions(subscriptions Subscriptions) ServiceOption {
+	return func(s *Service) {
+		s.subscriptions = subscriptions
+	}
+}
+
+// WithSoloGames exports players' solo games and purges them with their
+// account
+func WithSoloGames(games SoloGames) ServiceOption {
+	return func(s *Service) {
+		s.solo = games
+	}
+}
+
+// WithAuditLog records account deletions in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *Service) {
+		s.audit = log
+	}
+}
+
+type Service struct {
+	db            *sqlx.DB
+	subscriptions Subscriptions
+	solo          SoloGames
+	audit         audit.Recorder
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Delete erases userID's personal data and closes their account. Their
+// subscriptions are cancelled and their solo games purged first. Their
+// username and email are replaced, their phone number is removed, and what
+// they wrote and who they knew is deleted, but the games they played stay,
+// under the anonymous account, so other players' results and the
+// leaderboards still add up. Moderation records, such as reports,
+// suspensions and the record of their consent to texts, are kept.
+func (s *Service) Delete(ctx context.Context, userID string) error {
+	var customerID sql.NullString
+	if err := s.db.GetContext(ctx, &customerID, `
+		SELECT stripe_customer_id FROM users
+		WHERE id = $1 AND deleted_at IS NULL`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if customerID.Valid && customerID.String != "" {
+		if s.subscriptions == nil {
+			return ErrBillingUnavailable
+		}
+		if err := s.subscriptions.CancelSubscriptions(ctx, customerID.String); err != nil {
+			return fmt.Errorf("failed to cancel subscriptions: %w", err)
+		}
+	}
+	if s.solo != nil {
+		if err := s.solo.Purge(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET username = 'deleted-' || id, email = id || '@deleted.invalid', password_hash = '',
+			stripe_customer_id = NULL, is_premium = FALSE, premium_until = NULL,
+			bio = NULL, profile_image_url = NULL, social_links = NULL, notification_preferences = NULL,
+			phone_number = NULL, phone_verified_at = NULL, sms_consent_at = NULL,
+			deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize account: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrAccountNotFound
+	}
+
+	// Voice recordings expire straight away, so the voice archive's next
+	// purge deletes them
+	for _, stmt := range []string{
+		`UPDATE subscriptions SET status = 'canceled', updated_at = NOW() WHERE user_id = $1`,
+		`UPDATE spelling_attempts SET voice_data = NULL, voice_expires_at = NOW()
+			WHERE player_id = $1 AND (voice_data IS NOT NULL OR voice_s3_key IS NOT NULL)`,
+		`DELETE FROM user_follows WHERE follower_id = $1 OR following_id = $1`,
+		`DELETE FROM friendships WHERE user_id = $1 OR friend_id = $1`,
+		`DELETE FROM friend_challenges WHERE challenger_id = $1 OR challenged_id = $1`,
+		`DELETE FROM game_invitations WHERE host_id = $1 OR invitee_id = $1`,
+		`DELETE FROM device_tokens WHERE user_id = $1`,
+		`DELETE FROM auth_sessions WHERE user_id = $1`,
+		`DELETE FROM two_factor_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_two_factor WHERE user_id = $1`,
+		`DELETE FROM email_changes WHERE user_id = $1`,
+		`DELETE FROM api_keys WHERE owner_id = $1`,
+		`DELETE FROM user_preferences WHERE user_id = $1`,
+		`DELETE FROM parental_consents WHERE child_id = $1`,
+		`DELETE FROM phone_verifications WHERE user_id = $1`,
+		`DELETE FROM sms_messages WHERE user_id = $1`,
+		`DELETE FROM chat_messages WHERE sender_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, userID); err != nil {
+			return fmt.Errorf("failed to erase account data: %w", err)
+		}
+	}
+	if err := erasePosts(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: audit.ActionAccountDeleted, ActorID: userID, TargetType: "user", TargetID: userID})
+	}
+	return nil
+}
+
+// erasePosts deletes userID's posts, comments and likes, and recounts the
+// likes and comments on the other posts they were on
+func erasePosts(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	var touched []string
+	if err := tx.SelectContext(ctx, &touched, `
+		SELECT DISTINCT post_id FROM post_interactions
+		WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to find posts interacted with: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_interactions WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete comments and likes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete posts: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts p SET
+			likes_count = (SELECT COUNT(*) FROM post_interactions i WHERE i.post_id = p.id AND i.type = 'like'),
+			comments_count = (SELECT COUNT(*) FROM post_interactions i WHERE i.post_id = p.id AND i.type = 'comment')
+		WHERE p.id = ANY($1)`, pq.Array(touched)); err != nil {
+		return fmt.Errorf("failed to recount post interactions: %w", err)
+	}
+	return nil
+}
+
+// Profile is the account details and standing a player's export holds
+type Profile struct {
+	ID              string          `json:"id" db:"id"`
+	Username        string          `json:"username" db:"username"`
+	Email           string          `json:"email" db:"email"`
+	PhoneNumber     *string         `json:"phone_number" db:"phone_number"`
+	PhoneVerifiedAt *time.Time      `json:"phone_verified_at,omitempty" db:"phone_verified_at"`
+	SMSConsentAt    *time.Time      `json:"sms_consent_at,omitempty" db:"sms_consent_at"`
+	IsChild         bool            `json:"is_child" db:"is_child"`
+	IsPremium       bool            `json:"is_premium" db:"is_premium"`
+	PremiumUntil    *time.Time      `json:"premium_until,omitempty" db:"premium_until"`
+	Bio             *string         `json:"bio" db:"bio"`
+	ProfileImageURL *string         `json:"profile_image_url" db:"profile_image_url"`
+	SocialLinks     json.RawMessage `json:"social_links" db:"social_links"`
+	RankPoints      int             `json:"rank_points" db:"rank_points"`
+	RankColor       string          `json:"rank_color" db:"rank_color"`
+	ELO             int             `json:"elo" db:"elo"`
+	GamesPlayed     int             `json:"games_played" db:"games_played"`
+	GamesWon        int             `json:"games_won" db:"games_won"`
+	CurrentStreak   int             `json:"current_streak" db:"current_streak"`
+	LongestStreak   int             `json:"longest_streak" db:"longest_streak"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Game is a game the player finished
+type Game struct {
+	GameID    string    `json:"game_id" db:"game_id"`
+	GameType  string    `json:"game_type" db:"game_type"`
+	Score     int       `json:"score" db:"score"`
+	Position  int       `json:"position" db:"position"`
+	Duration  int       `json:"duration" db:"duration"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Attempt is one of the player's tries at spelling a word in a game
+type Attempt struct {
+	ID        string    `json:"id" db:"id"`
+	GameID    string    `json:"game_id" db:"game_id"`
+	Word      string    `json:"word" db:"word"`
+	Type      string    `json:"type" db:"type"`
+	Text      string    `json:"text" db:"text"`
+	IsCorrect bool      `json:"is_correct" db:"is_correct"`
+	Points    int       `json:"points" db:"points"`
+	HintsUsed int       `json:"hints_used" db:"hints_used"`
+	AnswerMS  *int      `json:"answer_ms,omitempty" db:"answer_ms"`
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+}
+
+// Post is something the player posted
+type Post struct {
+	ID        string          `json:"id" db:"id"`
+	Type      string          `json:"type" db:"type"`
+	Content   json.RawMessage `json:"content" db:"content"`
+	GameID    *string         `json:"game_id,omitempty" db:"game_id"`
+	MediaURLs json.RawMessage `json:"media_urls" db:"media_urls"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Comment is a comment the player left on a post
+type Comment struct {
+	ID        string    `json:"id" db:"id"`
+	PostID    string    `json:"post_id" db:"post_id"`
+	Content   string    `json:"content" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Export is a copy of everything personal kept about a player
+type Export struct {
+	ExportedAt time.Time   `json:"exported_at"`
+	Profile    Profile     `json:"profile"`
+	Games      []Game      `json:"games"`
+	Attempts   []Attempt   `json:"attempts"`
+	Posts      []Post      `json:"posts"`
+	Comments   []Comment   `json:"comments"`
+	SoloGames  []solo.Game `json:"solo_games"`
+}
+
+// Export gathers a copy of userID's personal data
+func (s *Service) Export(ctx context.Context, userID string) (*Export, error) {
+	export := &Export{
+		ExportedAt: time.Now(),
+		Games:      []Game{},
+		Attempts:   []Attempt{},
+		Posts:      []Post{},
+		Comments:   []Comment{},
+		SoloGames:  []solo.Game{},
+	}
+
+	if err := s.db.GetContext(ctx, &export.Profile, `
+		SELECT id, username, email, phone_number, phone_verified_at, sms_consent_at, is_child, is_premium, premium_until, bio, profile_image_url,
+			social_links, rank_points, rank_color, elo, games_played, games_won,
+			current_streak, longest_streak, created_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &export.Games, `
+		SELECT game_id, game_type, score, position, duration, created_at
+		FROM game_history
+		WHERE user_id = $1
+		ORDER BY created_at`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get games: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &export.Attempts, `
+		SELECT id, game_id, word, type, text, is_correct, points, hints_used, answer_ms, timestamp
+		FROM spelling_attempts
+		WHERE player_id = $1
+		ORDER BY timestamp`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get attempts: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &export.Posts, `
+		SELECT id, type, content, game_id, media_urls, created_at
+		FROM posts
+		WHERE user_id = $1
+		ORDER BY created_at`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+
+	if err := s.db.SelectContext(ctx, &export.Comments, `
+		SELECT id, post_id, content, created_at
+		FROM post_interactions
+		WHERE user_id = $1 AND type = 'comment'
+		ORDER BY created_at`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	if s.solo != nil {
+		var before time.Time
+		for {
+			games, err := s.solo.List(ctx, userID, before, soloPageSize)
+			if err != nil {
+				return nil, err
+			}
+			export.SoloGames = append(export.SoloGames, games...)
+			if len(games) < soloPageSize {
+				break
+			}
+			before = games[len(games)-1].CreatedAt
+		}
+	}
+
+	return export, nil
+}
@@ -0,0 +1,100 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StripeHost is Stripe's API endpoint
+const StripeHost = "https://api.stripe.com"
+
+// Stripe calls the parts of Stripe's REST API that account deletion needs
+type Stripe struct {
+	client *http.Client
+	host   string
+	key    string
+}
+
+// NewStripe talks to Stripe at host, StripeHost when empty, with secret
+// key key
+func NewStripe(key, host string, client *http.Client) *Stripe {
+	if host == "" {
+		host = StripeHost
+	}
+	return &Stripe{client: client, host: strings.TrimRight(host, "/"), key: key}
+}
+
+type stripeSubscription struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// CancelSubscriptions cancels every subscription of the Stripe customer
+// with customerID that hasn't already ended, straight away
+func (s *Stripe) CancelSubscriptions(ctx context.Context, customerID string) error {
+	query := url.Values{"customer": {customerID}, "status": {"all"}, "limit": {"100"}}
+	for {
+		var page struct {
+			Data    []stripeSubscription `json:"data"`
+			HasMore bool                 `json:"has_more"`
+		}
+		if err := s.do(ctx, http.MethodGet, "subscriptions", query, &page); err != nil {
+			return err
+		}
+
+		for _, sub := range page.Data {
+			if sub.Status == "canceled" || sub.Status == "incomplete_expired" {
+				continue
+			}
+			if err := s.do(ctx, http.MethodDelete, "subscriptions/"+url.PathEscape(sub.ID), nil, nil); err != nil {
+				return err
+			}
+		}
+
+		if !page.HasMore || len(page.Data) == 0 {
+			return nil
+		}
+		query.Set("starting_after", page.Data[len(page.Data)-1].ID)
+	}
+}
+
+func (s *Stripe) do(ctx context.Context, method, path string, query url.Values, result any) error {
+	target := s.host + "/v1/" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.key, "")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&failure)
+		return fmt.Errorf("Stripe returned %s: %s", resp.Status, failure.Error.Message)
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to read Stripe response: %w", err)
+	}
+	return nil
+}
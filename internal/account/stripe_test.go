@@ -0,0 +1,50 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelSubscriptions(t *testing.T) {
+	var cancelled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _, _ := r.BasicAuth()
+		assert.Equal(t, "sk_test", key)
+
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "cus_1", r.URL.Query().Get("customer"))
+			if r.URL.Query().Get("starting_after") == "" {
+				w.Write([]byte(`{"data":[{"id":"sub_1","status":"active"},{"id":"sub_2","status":"canceled"}],"has_more":true}`))
+				return
+			}
+			assert.Equal(t, "sub_2", r.URL.Query().Get("starting_after"))
+			w.Write([]byte(`{"data":[{"id":"sub_3","status":"past_due"}],"has_more":false}`))
+		case http.MethodDelete:
+			cancelled = append(cancelled, r.URL.Path)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	stripe := NewStripe("sk_test", server.URL, server.Client())
+	require.NoError(t, stripe.CancelSubscriptions(context.Background(), "cus_1"))
+	assert.Equal(t, []string{"/v1/subscriptions/sub_1", "/v1/subscriptions/sub_3"}, cancelled, "ended subscriptions are left alone")
+}
+
+func TestStripeErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"Invalid API Key provided"}}`))
+	}))
+	defer server.Close()
+
+	err := NewStripe("sk_bad", server.URL, server.Client()).CancelSubscriptions(context.Background(), "cus_1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid API Key provided")
+}
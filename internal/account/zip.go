@@ -0,0 +1,39 @@
+package account
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteZip writes the export as a ZIP archive holding a JSON file for each
+// kind of data
+func (e *Export) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", e.Profile},
+		{"games.json", e.Games},
+		{"attempts.json", e.Attempts},
+		{"posts.json", e.Posts},
+		{"comments.json", e.Comments},
+		{"solo_games.json", e.SoloGames},
+	}
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: e.ExportedAt})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to export: %w", file.name, err)
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return fmt.Errorf("failed to write %s to export: %w", file.name, err)
+		}
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,783 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/integrity"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+
+	DefaultReportLimit = 20
+	MaxReportLimit     = 100
+)
+
+// Games is the part of the game service the console ends games through
+type Games interface {
+	CancelGame(ctx context.Context, gameID, reason string) (*game.Game, error)
+	EndGame(ctx context.Context, gameID, reason string) (*game.Game, error)
+	ReleaseRankedResults(ctx context.Context, gameID string) error
+}
+
+// Ratings adjusts players' ranking points and exempts them from decay
+type Ratings interface {
+	AdjustRating(ctx context.Context, userID string, points int, reason season.ReasonCode, note, adjustedBy string) (*season.Adjustment, error)
+	SetDecayExempt(ctx context.Context, userID string, exempt bool) error
+}
+
+type Handler struct {
+	service *Service
+	games   Games
+	ratings Ratings
+}
+
+func NewHandler(service *Service, games Games, ratings Ratings) *Handler {
+	return &Handler{service: service, games: games, ratings: ratings}
+}
+
+type SearchRequest struct {
+	Query     string
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseSearchRequest(query url.Values) SearchRequest {
+	req := SearchRequest{Query: query.Get("q"), Page: 1, PageSize: DefaultPageSize}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *SearchRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Query), "q", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(r.Query, 100), "q", "Must not be more than 100 characters")
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+type SuspendRequest struct {
+	Reason string `json:"reason"`
+	// Until is when the suspension runs out; leaving it out bans the user
+	Until     *time.Time          `json:"until"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *SuspendRequest) validate(now time.Time) {
+	r.Validator.CheckField(validator.NotBlank(r.Reason), "reason", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(r.Reason, 500), "reason", "Must not be more than 500 characters")
+	if r.Until != nil {
+		r.Validator.CheckField(r.Until.After(now), "until", "Must be in the future")
+	}
+}
+
+type AdjustRatingRequest struct {
+	Points     int                 `json:"points"`
+	ReasonCode season.ReasonCode   `json:"reason_code"`
+	Note       string              `json:"note"`
+	Validator  validator.Validator `json:"-"`
+}
+
+func (r *AdjustRatingRequest) validate() {
+	r.Validator.CheckField(r.Points != 0, "points", "Must not be zero")
+	r.Validator.CheckField(validator.Between(r.Points, -1200, 1200), "points", "Must be between -1200 and 1200")
+	r.Validator.CheckField(validator.In(r.ReasonCode, season.ReasonCodes...), "reason_code", "Must be a known reason code")
+	r.Validator.CheckField(validator.MaxRunes(r.Note, 500), "note", "Must not be more than 500 characters")
+	if r.ReasonCode == season.ReasonOther {
+		r.Validator.CheckField(validator.NotBlank(r.Note), "note", "Must explain an adjustment for another reason")
+	}
+}
+
+type DecayExemptionRequest struct {
+	Exempt    *bool               `json:"exempt"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *DecayExemptionRequest) validate() {
+	r.Validator.CheckField(r.Exempt != nil, "exempt", "Must be provided")
+}
+
+type EndGameRequest struct {
+	Reason    string              `json:"reason"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *EndGameRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Reason), "reason", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(r.Reason, 500), "reason", "Must not be more than 500 characters")
+}
+
+type FlagsRequest struct {
+	Status    integrity.FlagStatus
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseFlagsRequest(query url.Values) FlagsRequest {
+	req := FlagsRequest{Status: integrity.FlagPending, Page: 1, PageSize: DefaultPageSize}
+	if status := query.Get("status"); status != "" {
+		req.Status = integrity.FlagStatus(status)
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *FlagsRequest) validate() {
+	r.Validator.CheckField(validator.In(r.Status, integrity.FlagStatuses...), "status", "Must be a known flag status")
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+type ReviewFlagsRequest struct {
+	// Status is cleared to release the game's ranked results or confirmed
+	// to keep them held
+	Status    integrity.FlagStatus `json:"status"`
+	Note      string               `json:"note"`
+	Validator validator.Validator  `json:"-"`
+}
+
+func (r *ReviewFlagsRequest) validate() {
+	r.Validator.CheckField(validator.In(r.Status, integrity.FlagCleared, integrity.FlagConfirmed), "status", "Must be cleared or confirmed")
+	r.Validator.CheckField(validator.MaxRunes(r.Note, 500), "note", "Must not be more than 500 characters")
+	if r.Status == integrity.FlagConfirmed {
+		r.Validator.CheckField(validator.NotBlank(r.Note), "note", "Must explain a confirmed flag")
+	}
+}
+
+type ListedWordsRequest struct {
+	List      string
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseListedWordsRequest(query url.Values) ListedWordsRequest {
+	req := ListedWordsRequest{List: query.Get("list"), Page: 1, PageSize: DefaultPageSize}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *ListedWordsRequest) validate() {
+	r.Validator.CheckField(validator.In(r.List, game.WordListBlock, game.WordListAllow), "list", "Must be block or allow")
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+type ListWordRequest struct {
+	// List is block to keep the word out of every game, or allow to serve
+	// it though the dictionary marks it offensive
+	List      string              `json:"list"`
+	Reason    string              `json:"reason"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *ListWordRequest) validate(word, language string) {
+	r.Validator.CheckField(validator.NotBlank(word), "word", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(word, game.MaxTextAttempt), "word", "Must not be more than 100 characters")
+	r.Validator.CheckField(game.IsSupportedLanguage(language), "language", "Must be a supported language")
+	r.Validator.CheckField(validator.In(r.List, game.WordListBlock, game.WordListAllow), "list", "Must be block or allow")
+	r.Validator.CheckField(validator.MaxRunes(r.Reason, 500), "reason", "Must not be more than 500 characters")
+}
+
+type WordsRequest struct {
+	Status    game.WordStatus
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseWordsRequest(query url.Values) WordsRequest {
+	req := WordsRequest{Status: game.WordStatusPending, Page: 1, PageSize: DefaultPageSize}
+	if status := query.Get("status"); status != "" {
+		req.Status = game.WordStatus(status)
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *WordsRequest) validate() {
+	r.Validator.CheckField(validator.In(r.Status, game.WordStatuses...), "status", "Must be a known word status")
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+type EditWordRequest struct {
+	WordEdit
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *EditWordRequest) validate() {
+	r.Validator.CheckField(r.Definition != nil || r.ExampleSentence != nil || r.Etymology != nil || r.PartOfSpeech != nil ||
+		r.AcceptedSpellings != nil, "word", "Must change at least one field")
+	if r.Definition != nil {
+		r.Validator.CheckField(validator.NotBlank(*r.Definition), "definition", "Must not be blank")
+		r.Validator.CheckField(validator.MaxRunes(*r.Definition, 2000), "definition", "Must not be more than 2000 characters")
+	}
+	if r.ExampleSentence != nil {
+		r.Validator.CheckField(validator.MaxRunes(*r.ExampleSentence, 2000), "example_sentence", "Must not be more than 2000 characters")
+	}
+	if r.Etymology != nil {
+		r.Validator.CheckField(validator.MaxRunes(*r.Etymology, 2000), "etymology", "Must not be more than 2000 characters")
+	}
+	if r.PartOfSpeech != nil {
+		r.Validator.CheckField(validator.MaxRunes(*r.PartOfSpeech, 50), "part_of_speech", "Must not be more than 50 characters")
+	}
+	if r.AcceptedSpellings != nil {
+		seen := make(map[string]bool, len(*r.AcceptedSpellings))
+		for _, accepted := range *r.AcceptedSpellings {
+			spelling := strings.ToLower(strings.TrimSpace(accepted.Spelling))
+			r.Validator.CheckField(spelling != "", "accepted_spellings", "Must not contain blank spellings")
+			r.Validator.CheckField(validator.MaxRunes(spelling, game.MaxTextAttempt), "accepted_spellings", "Must not contain spellings of more than 100 characters")
+			r.Validator.CheckField(validator.In(accepted.Variety, "", game.VarietyUS, game.VarietyUK), "accepted_spellings", "Must give each spelling's variety as us, uk or not at all")
+			r.Validator.CheckField(!seen[spelling], "accepted_spellings", "Must not contain duplicates")
+			seen[spelling] = true
+		}
+	}
+}
+
+type WordStatusRequest struct {
+	Status    game.WordStatus     `json:"status"`
+	Note      string              `json:"note"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *WordStatusRequest) validate() {
+	r.Validator.CheckField(validator.In(r.Status, game.WordStatuses...), "status", "Must be a known word status")
+	r.Validator.CheckField(validator.MaxRunes(r.Note, 500), "note", "Must not be more than 500 characters")
+	if r.Status == game.WordStatusRetired {
+		r.Validator.CheckField(validator.NotBlank(r.Note), "note", "Must explain retiring a word")
+	}
+}
+
+// SearchUsers serves a page of users matching q by username, email or ID
+func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	req := parseSearchRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	users, total, err := h.service.SearchUsers(r.Context(), req.Query, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"users":     users,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// Suspend keeps a user from signing in for a while, or bans them
+func (h *Handler) Suspend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+
+	var req SuspendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(time.Now()); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	suspension, err := h.service.Suspend(r.Context(), userID, req.Reason, req.Until, actor(r))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(suspension)
+}
+
+// Reinstate lifts a user's suspension or ban
+func (h *Handler) Reinstate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+
+	suspension, err := h.service.Reinstate(r.Context(), userID, actor(r))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suspension)
+}
+
+// AdjustRating moves a user's ranking points, with a reason code saying why
+func (h *Handler) AdjustRating(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+
+	var req AdjustRatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	adjustment, err := h.ratings.AdjustRating(r.Context(), userID, req.Points, req.ReasonCode, req.Note, actor(r))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(adjustment)
+}
+
+// SetDecayExemption exempts a user's rating from decay while they're away
+// from ranked games, or makes it decay again
+func (h *Handler) SetDecayExemption(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+
+	var req DecayExemptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	if err := h.ratings.SetDecayExempt(r.Context(), userID, *req.Exempt); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"user_id": userID, "exempt": *req.Exempt})
+}
+
+// CancelGame calls off a game without recording results
+func (h *Handler) CancelGame(w http.ResponseWriter, r *http.Request) {
+	h.endGame(w, r, h.games.CancelGame)
+}
+
+// EndGame finishes a game in progress on the scores so far
+func (h *Handler) EndGame(w http.ResponseWriter, r *http.Request) {
+	h.endGame(w, r, h.games.EndGame)
+}
+
+func (h *Handler) endGame(w http.ResponseWriter, r *http.Request, end func(ctx context.Context, gameID, reason string) (*game.Game, error)) {
+	gameID, ok := pathID(w, r, "gameID", "game_id")
+	if !ok {
+		return
+	}
+
+	var req EndGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	g, err := end(r.Context(), gameID, req.Reason)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g)
+}
+
+// IntegrityReports serves summaries of the latest tournament integrity
+// reports
+func (h *Handler) IntegrityReports(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+	limit := DefaultReportLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		v.CheckField(err == nil && validator.Between(n, 1, MaxReportLimit), "limit", "Must be between 1 and 100")
+		limit = n
+	}
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	reports, err := h.service.RecentReports(r.Context(), limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"reports": reports})
+}
+
+// GameFlags serves a page of the games the cheat screen flagged, pending
+// review unless ?status= asks for reviewed ones
+func (h *Handler) GameFlags(w http.ResponseWriter, r *http.Request) {
+	req := parseFlagsRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	flags, total, err := h.service.GameFlags(r.Context(), req.Status, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"flags":     flags,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// ReviewFlags settles a game's pending flags. Clearing them awards the
+// ranking points the game held back.
+func (h *Handler) ReviewFlags(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := pathID(w, r, "gameID", "game_id")
+	if !ok {
+		return
+	}
+
+	var req ReviewFlagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	var release func(ctx context.Context, gameID string) error
+	if req.Status == integrity.FlagCleared {
+		release = h.games.ReleaseRankedResults
+	}
+
+	flags, err := h.service.ReviewFlags(r.Context(), gameID, req.Status, req.Note, actor(r), release)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"flags": flags})
+}
+
+// ListedWords serves a page of the words on the blocklist or allowlist
+func (h *Handler) ListedWords(w http.ResponseWriter, r *http.Request) {
+	req := parseListedWordsRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	words, total, err := h.service.ListedWords(r.Context(), req.List, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"words":     words,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// ListWord puts a word on the blocklist or allowlist
+func (h *Handler) ListWord(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	word, language := params.ByName("word"), params.ByName("language")
+
+	var req ListWordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(word, language); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	listing, err := h.service.ListWord(r.Context(), word, language, req.List, req.Reason, actor(r))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// UnlistWord takes a word off the blocklist or allowlist
+func (h *Handler) UnlistWord(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	listing, err := h.service.UnlistWord(r.Context(), params.ByName("word"), params.ByName("language"))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// Words serves a page of the words in a curation status, pending unless
+// ?status= asks for another
+func (h *Handler) Words(w http.ResponseWriter, r *http.Request) {
+	req := parseWordsRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	words, total, err := h.service.Words(r.Context(), req.Status, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"words":     words,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// EditWord corrects a word's definition, example sentence, etymology or
+// part of speech, or replaces the other spellings it's accepted in
+func (h *Handler) EditWord(w http.ResponseWriter, r *http.Request) {
+	wordID, ok := pathID(w, r, "wordID", "word_id")
+	if !ok {
+		return
+	}
+
+	var req EditWordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	word, err := h.service.EditWord(r.Context(), wordID, req.WordEdit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(word)
+}
+
+// SetWordStatus moves a word along its curation, such as approving a
+// reviewed word for players
+func (h *Handler) SetWordStatus(w http.ResponseWriter, r *http.Request) {
+	wordID, ok := pathID(w, r, "wordID", "word_id")
+	if !ok {
+		return
+	}
+
+	var req WordStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	word, err := h.service.SetWordStatus(r.Context(), wordID, req.Status, req.Note, actor(r))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(word)
+}
+
+// WordReviews serves the history of a word's curation
+func (h *Handler) WordReviews(w http.ResponseWriter, r *http.Request) {
+	wordID, ok := pathID(w, r, "wordID", "word_id")
+	if !ok {
+		return
+	}
+
+	reviews, err := h.service.WordReviews(r.Context(), wordID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"reviews": reviews})
+}
+
+// PregenerateAudio queues the audio of the words at a level to be cached
+// ahead of play, so players aren't kept waiting on TTS
+func (h *Handler) PregenerateAudio(w http.ResponseWriter, r *http.Request) {
+	level, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("level"))
+
+	var v validator.Validator
+	v.CheckField(err == nil && validator.Between(level, game.MinWordLevel, game.MaxWordLevel), "level", "Must be between 1 and 10")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	job, err := h.service.PregenerateAudio(r.Context(), level)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"job_id": job.ID, "level": level})
+}
+
+// actor names the admin making a request in the records it leaves
+func actor(r *http.Request) string {
+	if principal := auth.GetPrincipal(r.Context()); principal != nil {
+		return principal.ActorID()
+	}
+	return ""
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, season.ErrUserNotFound), errors.Is(err, game.ErrGameNotFound),
+		errors.Is(err, ErrWordNotListed), errors.Is(err, ErrWordNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotSuspended), errors.Is(err, ErrAlreadyBanned), errors.Is(err, game.ErrInvalidGameState),
+		errors.Is(err, ErrNoPendingFlags), errors.Is(err, game.ErrInvalidWordTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrAudioDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// pathID reads the UUID path parameter param and responds with 422, naming
+// it field, when it isn't one
+func pathID(w http.ResponseWriter, r *http.Request, param, field string) (string, bool) {
+	id := httprouter.ParamsFromContext(r.Context()).ByName(param)
+
+	var v validator.Validator
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, field, "Must be a valid ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return "", false
+	}
+	return id, true
+}
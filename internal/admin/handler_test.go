@@ -0,0 +1,265 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/integrity"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/jobs"
+)
+
+type gamesStub struct {
+	err    error
+	reason string
+}
+
+func (s *gamesStub) CancelGame(ctx context.Context, gameID, reason string) (*game.Game, error) {
+	s.reason = reason
+	return &game.Game{ID: gameID, Status: game.GameStatusCancelled}, s.err
+}
+
+func (s *gamesStub) EndGame(ctx context.Context, gameID, reason string) (*game.Game, error) {
+	s.reason = reason
+	return &game.Game{ID: gameID, Status: game.GameStatusFinished}, s.err
+}
+
+func (s *gamesStub) ReleaseRankedResults(ctx context.Context, gameID string) error {
+	return s.err
+}
+
+type queueStub struct {
+	kind    string
+	payload any
+}
+
+func (q *queueStub) Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error) {
+	q.kind, q.payload = kind, payload
+	return &jobs.Job{ID: uuid.New().String(), Kind: kind}, nil
+}
+
+func withParam(req *http.Request, key, value string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: key, Value: value}}))
+}
+
+func TestSearchRequestValidation(t *testing.T) {
+	req := parseSearchRequest(url.Values{"q": {"ada"}})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, DefaultPageSize, req.PageSize)
+
+	req = parseSearchRequest(url.Values{"page": {"0"}, "page_size": {"500"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "q")
+	assert.Contains(t, req.Validator.FieldErrors, "page")
+	assert.Contains(t, req.Validator.FieldErrors, "page_size")
+}
+
+func TestWordFilterRequestValidation(t *testing.T) {
+	list := parseListedWordsRequest(url.Values{"list": {"block"}})
+	list.validate()
+	assert.False(t, list.Validator.HasErrors())
+
+	list = parseListedWordsRequest(url.Values{"list": {"grey"}, "page": {"x"}})
+	list.validate()
+	assert.Contains(t, list.Validator.FieldErrors, "list")
+	assert.Contains(t, list.Validator.FieldErrors, "page")
+
+	req := ListWordRequest{List: "allow", Reason: "a medical term"}
+	req.validate("Scrotum", "en")
+	assert.False(t, req.Validator.HasErrors())
+
+	req = ListWordRequest{List: "grey"}
+	req.validate(" ", "tlh")
+	assert.Contains(t, req.Validator.FieldErrors, "word")
+	assert.Contains(t, req.Validator.FieldErrors, "language")
+	assert.Contains(t, req.Validator.FieldErrors, "list")
+}
+
+func TestWordCurationRequestValidation(t *testing.T) {
+	list := parseWordsRequest(url.Values{})
+	list.validate()
+	assert.False(t, list.Validator.HasErrors())
+	assert.Equal(t, game.WordStatusPending, list.Status)
+
+	list = parseWordsRequest(url.Values{"status": {"draft"}})
+	list.validate()
+	assert.Contains(t, list.Validator.FieldErrors, "status")
+
+	definition, blank := "a small domesticated feline", " "
+	edit := EditWordRequest{WordEdit: WordEdit{Definition: &definition}}
+	edit.validate()
+	assert.False(t, edit.Validator.HasErrors())
+
+	edit = EditWordRequest{}
+	edit.validate()
+	assert.Contains(t, edit.Validator.FieldErrors, "word", "an edit must change something")
+
+	edit = EditWordRequest{WordEdit: WordEdit{Definition: &blank}}
+	edit.validate()
+	assert.Contains(t, edit.Validator.FieldErrors, "definition")
+
+	spellings := game.AcceptedSpellings{{Spelling: "theatre", Variety: game.VarietyUK}, {Spelling: "theater"}}
+	edit = EditWordRequest{WordEdit: WordEdit{AcceptedSpellings: &spellings}}
+	edit.validate()
+	assert.False(t, edit.Validator.HasErrors())
+
+	spellings = game.AcceptedSpellings{{Spelling: "theatre", Variety: "au"}, {Spelling: "Theatre"}}
+	edit = EditWordRequest{WordEdit: WordEdit{AcceptedSpellings: &spellings}}
+	edit.validate()
+	assert.Contains(t, edit.Validator.FieldErrors, "accepted_spellings")
+
+	status := WordStatusRequest{Status: game.WordStatusPublished}
+	status.validate()
+	assert.False(t, status.Validator.HasErrors())
+
+	status = WordStatusRequest{Status: game.WordStatusRetired}
+	status.validate()
+	assert.Contains(t, status.Validator.FieldErrors, "note", "retiring a word needs a reason")
+}
+
+func TestSuspendRequestValidation(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	ban := SuspendRequest{Reason: "harassment"}
+	ban.validate(now)
+	assert.False(t, ban.Validator.HasErrors(), "leaving until out bans the user")
+
+	past := now.Add(-time.Hour)
+	req := SuspendRequest{Until: &past}
+	req.validate(now)
+	assert.Contains(t, req.Validator.FieldErrors, "reason")
+	assert.Contains(t, req.Validator.FieldErrors, "until")
+}
+
+func TestAdjustRatingRequestValidation(t *testing.T) {
+	req := AdjustRatingRequest{Points: -50, ReasonCode: season.ReasonCheating}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = AdjustRatingRequest{ReasonCode: "grudge"}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "points")
+	assert.Contains(t, req.Validator.FieldErrors, "reason_code")
+
+	req = AdjustRatingRequest{Points: 25, ReasonCode: season.ReasonOther}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "note", "other reasons need explaining")
+}
+
+func TestDecayExemptionRequestValidation(t *testing.T) {
+	exempt := false
+	req := DecayExemptionRequest{Exempt: &exempt}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = DecayExemptionRequest{}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "exempt")
+}
+
+func TestEndGameHandlers(t *testing.T) {
+	gameID := uuid.New().String()
+
+	tests := []struct {
+		gameID string
+		body   string
+		err    error
+		code   int
+	}{
+		{gameID, `{"reason":"stuck lobby"}`, nil, http.StatusOK},
+		{gameID, `{"reason":"stuck lobby"}`, game.ErrGameNotFound, http.StatusNotFound},
+		{gameID, `{"reason":"stuck lobby"}`, game.ErrInvalidGameState, http.StatusConflict},
+		{gameID, `{}`, nil, http.StatusUnprocessableEntity},
+		{"not-a-uuid", `{"reason":"stuck lobby"}`, nil, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		games := &gamesStub{err: tt.err}
+		h := NewHandler(nil, games, nil)
+
+		for _, serve := range []http.HandlerFunc{h.CancelGame, h.EndGame} {
+			req := httptest.NewRequest(http.MethodPost, "/admin/games/"+tt.gameID+"/end", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			serve(rec, withParam(req, "gameID", tt.gameID))
+
+			assert.Equal(t, tt.code, rec.Code, tt.body)
+		}
+		if tt.code == http.StatusOK {
+			assert.Equal(t, "stuck lobby", games.reason)
+		}
+	}
+}
+
+func TestPregenerateAudio(t *testing.T) {
+	queue := &queueStub{}
+	h := NewHandler(NewService(nil, WithAudioJobs(queue)), nil, nil)
+
+	for _, level := range []string{"0", "11", "hard"} {
+		rec := httptest.NewRecorder()
+		h.PregenerateAudio(rec, withParam(httptest.NewRequest(http.MethodPost, "/admin/word-levels/"+level+"/audio", nil), "level", level))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, level)
+	}
+	assert.Empty(t, queue.kind)
+
+	rec := httptest.NewRecorder()
+	h.PregenerateAudio(rec, withParam(httptest.NewRequest(http.MethodPost, "/admin/word-levels/4/audio", nil), "level", "4"))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, game.JobPregenerateLevel, queue.kind)
+	assert.Equal(t, game.PregenerateLevelJob{Level: 4}, queue.payload)
+
+	h = NewHandler(NewService(nil), nil, nil)
+	rec = httptest.NewRecorder()
+	h.PregenerateAudio(rec, withParam(httptest.NewRequest(http.MethodPost, "/admin/word-levels/4/audio", nil), "level", "4"))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "without an audio cache there's nothing to queue")
+}
+
+func TestIntegrityReportsLimitValidation(t *testing.T) {
+	h := NewHandler(nil, nil, nil)
+
+	for _, limit := range []string{"0", "101", "some"} {
+		rec := httptest.NewRecorder()
+		h.IntegrityReports(rec, httptest.NewRequest(http.MethodGet, "/admin/integrity-reports?limit="+limit, nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, limit)
+	}
+}
+
+func TestFlagsRequestValidation(t *testing.T) {
+	req := parseFlagsRequest(url.Values{})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, integrity.FlagPending, req.Status, "pending flags are listed by default")
+
+	req = parseFlagsRequest(url.Values{"status": {"ignored"}, "page_size": {"0"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "status")
+	assert.Contains(t, req.Validator.FieldErrors, "page_size")
+}
+
+func TestReviewFlagsRequestValidation(t *testing.T) {
+	req := ReviewFlagsRequest{Status: integrity.FlagCleared}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = ReviewFlagsRequest{Status: integrity.FlagPending}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "status", "reviews have to settle the flags")
+
+	req = ReviewFlagsRequest{Status: integrity.FlagConfirmed}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "note", "confirmed flags need explaining")
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\%\_sure\\`, escapeLike(`100%_sure\`))
+}
@@ -0,0 +1,672 @@
+// Package admin is the console platform operators moderate players and
+// games from
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/integrity"
+	"big-spella-go/internal/jobs"
+)
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrNotSuspended   = errors.New("user is not suspended")
+	ErrAlreadyBanned  = errors.New("user is already banned")
+	ErrNoPendingFlags = errors.New("game has no flags pending review")
+
+	ErrWordNotListed = errors.New("word is on neither the blocklist nor the allowlist")
+	ErrWordNotFound  = errors.New("word not found")
+
+	ErrAudioDisabled = errors.New("word audio caching is not configured")
+)
+
+// UserSummary is what the console shows of a user in search results
+type UserSummary struct {
+	ID          string      `json:"id" db:"id"`
+	Username    string      `json:"username" db:"username"`
+	Email       string      `json:"email" db:"email"`
+	RankPoints  int         `json:"rank_points" db:"rank_points"`
+	RankColor   string      `json:"rank_color" db:"rank_color"`
+	GamesPlayed int         `json:"games_played" db:"games_played"`
+	DecayExempt bool        `json:"rank_decay_exempt" db:"rank_decay_exempt"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	Suspension  *Suspension `json:"suspension,omitempty" db:"-"`
+}
+
+// Suspension keeps a user from signing in until EndsAt. Without EndsAt it
+// is a ban.
+type Suspension struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Reason      string     `json:"reason" db:"reason"`
+	EndsAt      *time.Time `json:"ends_at" db:"ends_at"`
+	SuspendedBy string     `json:"suspended_by" db:"suspended_by"`
+	LiftedAt    *time.Time `json:"lifted_at,omitempty" db:"lifted_at"`
+	LiftedBy    *string    `json:"lifted_by,omitempty" db:"lifted_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsBan reports whether the suspension never runs out
+func (s *Suspension) IsBan() bool {
+	return s.EndsAt == nil
+}
+
+// ReportSummary counts what a tournament integrity report flagged, so the
+// console can point organizers at the reports worth reading
+type ReportSummary struct {
+	TournamentID      string    `json:"tournament_id" db:"tournament_id"`
+	TournamentName    string    `json:"tournament_name" db:"tournament_name"`
+	GeneratedAt       time.Time `json:"generated_at" db:"generated_at"`
+	TimingAnomalies   int       `json:"timing_anomalies" db:"timing_anomalies"`
+	RepeatedWords     int       `json:"repeated_words" db:"repeated_words"`
+	JudgeRulings      int       `json:"judge_rulings" db:"judge_rulings"`
+	Departures        int       `json:"departures" db:"departures"`
+	UnfinishedMatches int       `json:"unfinished_matches" db:"unfinished_matches"`
+}
+
+// ListedWord is a word on the blocklist, which is never served, or the
+// allowlist, which is served though the dictionary marks it offensive. See
+// game.WordFilter.
+type ListedWord struct {
+	Word      string    `json:"word" db:"word"`
+	Language  string    `json:"language" db:"language"`
+	List      string    `json:"list" db:"list"`
+	Reason    string    `json:"reason" db:"reason"`
+	AddedBy   string    `json:"added_by" db:"added_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// WordEdit is a change to a word's content. Fields left nil are kept.
+type WordEdit struct {
+	Definition      *string `json:"definition"`
+	ExampleSentence *string `json:"example_sentence"`
+	Etymology       *string `json:"etymology"`
+	PartOfSpeech    *string `json:"part_of_speech"`
+	// AcceptedSpellings replaces the word's other spellings when given
+	AcceptedSpellings *game.AcceptedSpellings `json:"accepted_spellings"`
+}
+
+// WordReview is a word's move from one curation status to another
+type WordReview struct {
+	ID         string          `json:"id" db:"id"`
+	WordID     string          `json:"word_id" db:"word_id"`
+	FromStatus game.WordStatus `json:"from_status" db:"from_status"`
+	ToStatus   game.WordStatus `json:"to_status" db:"to_status"`
+	Note       string          `json:"note" db:"note"`
+	ReviewedBy string          `json:"reviewed_by" db:"reviewed_by"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// JobQueue queues work to run in the background
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error)
+}
+
+type ServiceOption func(*Service)
+
+// WithAuditLog records suspensions and reinstatements in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *Service) {
+		s.audit = log
+	}
+}
+
+// WithAudioJobs lets the console queue word audio to be pregenerated
+// through queue. Without it, PregenerateAudio returns ErrAudioDisabled.
+func WithAudioJobs(queue JobQueue) ServiceOption {
+	return func(s *Service) {
+		s.audioJobs = queue
+	}
+}
+
+type Service struct {
+	db        *sqlx.DB
+	audit     audit.Recorder
+	audioJobs JobQueue
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Service) record(ctx context.Context, action audit.Action, userID string, before, after any) {
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: action, TargetType: "user", TargetID: userID, Before: before, After: after})
+	}
+}
+
+// activeSuspension matches the suspension keeping a user out right now
+const activeSuspension = `lifted_at IS NULL AND (ends_at IS NULL OR ends_at > NOW())`
+
+// SearchUsers finds users whose username or email contains query, or whose
+// ID is query, ordered by username
+func (s *Service) SearchUsers(ctx context.Context, query string, limit, offset int) ([]UserSummary, int, error) {
+	pattern := "%" + escapeLike(query) + "%"
+
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM users
+		WHERE username ILIKE $1 OR email ILIKE $1 OR id::text = $2`,
+		pattern, query); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	users := []UserSummary{}
+	if err := s.db.SelectContext(ctx, &users, `
+		SELECT id, username, email, rank_points, rank_color, games_played, rank_decay_exempt, created_at
+		FROM users
+		WHERE username ILIKE $1 OR email ILIKE $1 OR id::text = $2
+		ORDER BY username
+		LIMIT $3 OFFSET $4`,
+		pattern, query, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+	if len(users) == 0 {
+		return users, total, nil
+	}
+
+	ids := make([]string, len(users))
+	index := make(map[string]*UserSummary, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+		index[users[i].ID] = &users[i]
+	}
+
+	var suspensions []Suspension
+	if err := s.db.SelectContext(ctx, &suspensions, `
+		SELECT * FROM account_suspensions
+		WHERE user_id = ANY($1) AND `+activeSuspension, pq.Array(ids)); err != nil {
+		return nil, 0, fmt.Errorf("failed to get suspensions: %w", err)
+	}
+	for i := range suspensions {
+		index[suspensions[i].UserID].Suspension = &suspensions[i]
+	}
+
+	return users, total, nil
+}
+
+// escapeLike stops LIKE treating characters in a search as wildcards
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Suspend keeps a user from signing in until endsAt, or for good when endsAt
+// is nil, and signs them out of every device. It replaces any suspension already running, so a suspension can be
+// extended or turned into a ban, but a ban has to be lifted before it can be
+// shortened.
+func (s *Service) Suspend(ctx context.Context, userID, reason string, endsAt *time.Time, suspendedBy string) (*Suspension, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	previous, err := currentSuspension(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.IsBan() {
+		return nil, ErrAlreadyBanned
+	}
+
+	now := time.Now()
+	if previous != nil {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE account_suspensions
+			SET lifted_at = $1, lifted_by = $2
+			WHERE id = $3`, now, suspendedBy, previous.ID); err != nil {
+			return nil, fmt.Errorf("failed to replace suspension: %w", err)
+		}
+	}
+
+	suspension := &Suspension{}
+	if err := tx.GetContext(ctx, suspension, `
+		INSERT INTO account_suspensions (id, user_id, reason, ends_at, suspended_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *`,
+		uuid.New().String(), userID, reason, endsAt, suspendedBy, now); err != nil {
+		return nil, fmt.Errorf("failed to suspend user: %w", err)
+	}
+
+	// Sign them out everywhere, so their refresh tokens can't be traded in
+	// once the suspension ends
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE auth_sessions SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL`, now, userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit suspension: %w", err)
+	}
+
+	var before any
+	if previous != nil {
+		before = previous
+	}
+	s.record(ctx, audit.ActionUserSuspended, userID, before, suspension)
+	return suspension, nil
+}
+
+// Reinstate lifts the suspension or ban keeping a user out
+func (s *Service) Reinstate(ctx context.Context, userID, liftedBy string) (*Suspension, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	previous, err := currentSuspension(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return nil, ErrNotSuspended
+	}
+
+	suspension := &Suspension{}
+	if err := tx.GetContext(ctx, suspension, `
+		UPDATE account_suspensions
+		SET lifted_at = $1, lifted_by = $2
+		WHERE id = $3
+		RETURNING *`, time.Now(), liftedBy, previous.ID); err != nil {
+		return nil, fmt.Errorf("failed to lift suspension: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reinstatement: %w", err)
+	}
+
+	s.record(ctx, audit.ActionUserReinstated, userID, previous, suspension)
+	return suspension, nil
+}
+
+// lockUser holds the user's row for the rest of tx so concurrent
+// suspensions of the same user queue up behind each other
+func lockUser(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	var id string
+	if err := tx.GetContext(ctx, &id, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return nil
+}
+
+func currentSuspension(ctx context.Context, tx *sqlx.Tx, userID string) (*Suspension, error) {
+	suspension := &Suspension{}
+	if err := tx.GetContext(ctx, suspension, `
+		SELECT * FROM account_suspensions
+		WHERE user_id = $1 AND `+activeSuspension+`
+		ORDER BY created_at DESC
+		LIMIT 1`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get suspension: %w", err)
+	}
+	return suspension, nil
+}
+
+// RecentReports summarizes the latest tournament integrity reports. Lists a
+// report found nothing for are stored as null.
+func (s *Service) RecentReports(ctx context.Context, limit int) ([]ReportSummary, error) {
+	reports := []ReportSummary{}
+	if err := s.db.SelectContext(ctx, &reports, `
+		SELECT r.tournament_id, t.name AS tournament_name, r.generated_at,
+			jsonb_array_length(COALESCE(NULLIF(r.report->'timing_anomalies', 'null'), '[]')) AS timing_anomalies,
+			jsonb_array_length(COALESCE(NULLIF(r.report->'repeated_words', 'null'), '[]')) AS repeated_words,
+			jsonb_array_length(COALESCE(NULLIF(r.report->'judge_rulings', 'null'), '[]')) AS judge_rulings,
+			jsonb_array_length(COALESCE(NULLIF(r.report->'departures', 'null'), '[]')) AS departures,
+			jsonb_array_length(COALESCE(NULLIF(r.report->'unfinished_matches', 'null'), '[]')) AS unfinished_matches
+		FROM tournament_integrity_reports r
+		JOIN tournaments t ON t.id = r.tournament_id
+		ORDER BY r.generated_at DESC
+		LIMIT $1`, limit); err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	return reports, nil
+}
+
+// GameFlags lists the cheat screen's flags with status, oldest first so
+// the longest held results are reviewed first
+func (s *Service) GameFlags(ctx context.Context, status integrity.FlagStatus, limit, offset int) ([]integrity.Flag, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM game_flags WHERE status = $1`, status); err != nil {
+		return nil, 0, fmt.Errorf("failed to count flags: %w", err)
+	}
+
+	flags := []integrity.Flag{}
+	if err := s.db.SelectContext(ctx, &flags, `
+		SELECT * FROM game_flags
+		WHERE status = $1
+		ORDER BY created_at, game_id, player_id
+		LIMIT $2 OFFSET $3`, status, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list flags: %w", err)
+	}
+	return flags, total, nil
+}
+
+// ReviewFlags settles every flag pending on a game, clearing the players or
+// confirming they cheated. release, when given, runs before the review is
+// committed so a game's held results are released with it or not at all.
+func (s *Service) ReviewFlags(ctx context.Context, gameID string, status integrity.FlagStatus, note, reviewedBy string, release func(ctx context.Context, gameID string) error) ([]integrity.Flag, error) {
+	var reviewNote *string
+	if note != "" {
+		reviewNote = &note
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	flags := []integrity.Flag{}
+	if err := tx.SelectContext(ctx, &flags, `
+		UPDATE game_flags
+		SET status = $1, reviewed_by = $2, reviewed_at = $3, note = $4
+		WHERE game_id = $5 AND status = $6
+		RETURNING *`,
+		status, reviewedBy, time.Now(), reviewNote, gameID, integrity.FlagPending); err != nil {
+		return nil, fmt.Errorf("failed to review flags: %w", err)
+	}
+	if len(flags) == 0 {
+		return nil, ErrNoPendingFlags
+	}
+
+	if release != nil {
+		if err := release(ctx, gameID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: audit.ActionFlagsReviewed, TargetType: "game", TargetID: gameID, After: flags})
+	}
+	return flags, nil
+}
+
+// ListedWords lists the words on list, alphabetically
+func (s *Service) ListedWords(ctx context.Context, list string, limit, offset int) ([]ListedWord, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM word_filters WHERE list = $1`, list); err != nil {
+		return nil, 0, fmt.Errorf("failed to count word filters: %w", err)
+	}
+
+	words := []ListedWord{}
+	if err := s.db.SelectContext(ctx, &words, `
+		SELECT * FROM word_filters
+		WHERE list = $1
+		ORDER BY word, language
+		LIMIT $2 OFFSET $3`, list, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list word filters: %w", err)
+	}
+	return words, total, nil
+}
+
+// ListWord puts word on list, moving it off the other list if it was on
+// it. Words are matched whatever their case.
+func (s *Service) ListWord(ctx context.Context, word, language, list, reason, addedBy string) (*ListedWord, error) {
+	word = strings.ToLower(strings.TrimSpace(word))
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := listedWord(ctx, tx, word, language)
+	if err != nil && !errors.Is(err, ErrWordNotListed) {
+		return nil, err
+	}
+
+	listing := &ListedWord{}
+	if err := tx.GetContext(ctx, listing, `
+		INSERT INTO word_filters (word, language, list, reason, added_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (word, language) DO UPDATE
+		SET list = EXCLUDED.list, reason = EXCLUDED.reason, added_by = EXCLUDED.added_by, created_at = EXCLUDED.created_at
+		RETURNING *`,
+		word, language, list, reason, addedBy, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to set word filter: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit word filter: %w", err)
+	}
+
+	var before any
+	if previous != nil {
+		before = previous
+	}
+	s.recordListing(ctx, audit.ActionWordListed, listing, before, listing)
+	return listing, nil
+}
+
+// UnlistWord takes word off whichever list it's on, so it's served or not
+// as the dictionary says
+func (s *Service) UnlistWord(ctx context.Context, word, language string) (*ListedWord, error) {
+	listing := &ListedWord{}
+	if err := s.db.GetContext(ctx, listing, `
+		DELETE FROM word_filters
+		WHERE word = $1 AND language = $2
+		RETURNING *`, strings.ToLower(strings.TrimSpace(word)), language); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWordNotListed
+		}
+		return nil, fmt.Errorf("failed to remove word filter: %w", err)
+	}
+
+	s.recordListing(ctx, audit.ActionWordUnlisted, listing, listing, nil)
+	return listing, nil
+}
+
+func listedWord(ctx context.Context, tx *sqlx.Tx, word, language string) (*ListedWord, error) {
+	listing := &ListedWord{}
+	if err := tx.GetContext(ctx, listing, `
+		SELECT * FROM word_filters
+		WHERE word = $1 AND language = $2
+		FOR UPDATE`, word, language); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWordNotListed
+		}
+		return nil, fmt.Errorf("failed to get word filter: %w", err)
+	}
+	return listing, nil
+}
+
+func (s *Service) recordListing(ctx context.Context, action audit.Action, listing *ListedWord, before, after any) {
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: action, TargetType: "word", TargetID: listing.Language + ":" + listing.Word, Before: before, After: after})
+	}
+}
+
+// curatedWordColumns are the columns of a word the console shows, with
+// missing content read as empty
+const curatedWordColumns = `id, word, definition, language, source, offensive, status, accepted_spellings, created_at, updated_at,
+	COALESCE(example_sentence, '') AS example_sentence,
+	COALESCE(etymology, '') AS etymology,
+	COALESCE(part_of_speech, '') AS part_of_speech,
+	COALESCE(pronunciation, '') AS pronunciation,
+	COALESCE(respelling, '') AS respelling,
+	COALESCE(audio_url, '') AS audio_url`
+
+// Words lists the words in status, oldest first so the longest waiting are
+// reviewed first
+func (s *Service) Words(ctx context.Context, status game.WordStatus, limit, offset int) ([]game.Word, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM words WHERE status = $1`, status); err != nil {
+		return nil, 0, fmt.Errorf("failed to count words: %w", err)
+	}
+
+	words := []game.Word{}
+	if err := s.db.SelectContext(ctx, &words, `
+		SELECT `+curatedWordColumns+` FROM words
+		WHERE status = $1
+		ORDER BY created_at, word
+		LIMIT $2 OFFSET $3`, status, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list words: %w", err)
+	}
+	return words, total, nil
+}
+
+// EditWord corrects a word's content, or the other spellings it's accepted
+// in, in whatever status it's in. A published word's changes reach players
+// from its next game.
+func (s *Service) EditWord(ctx context.Context, wordID string, edit WordEdit) (*game.Word, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := lockWord(ctx, tx, wordID)
+	if err != nil {
+		return nil, err
+	}
+
+	word := &game.Word{}
+	if err := tx.GetContext(ctx, word, `
+		UPDATE words
+		SET definition = COALESCE($1, definition),
+			example_sentence = COALESCE($2, example_sentence),
+			etymology = COALESCE($3, etymology),
+			part_of_speech = COALESCE($4, part_of_speech),
+			accepted_spellings = COALESCE($5, accepted_spellings),
+			updated_at = $6
+		WHERE id = $7
+		RETURNING `+curatedWordColumns,
+		edit.Definition, edit.ExampleSentence, edit.Etymology, edit.PartOfSpeech, edit.AcceptedSpellings, time.Now(), wordID); err != nil {
+		return nil, fmt.Errorf("failed to edit word: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit word: %w", err)
+	}
+
+	s.recordWord(ctx, audit.ActionWordEdited, wordID, previous, word)
+	return word, nil
+}
+
+// SetWordStatus moves a word along its curation, as game.WordStatus allows,
+// keeping a record of the move
+func (s *Service) SetWordStatus(ctx context.Context, wordID string, status game.WordStatus, note, reviewedBy string) (*game.Word, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	previous, err := lockWord(ctx, tx, wordID)
+	if err != nil {
+		return nil, err
+	}
+	if !previous.Status.CanMoveTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", game.ErrInvalidWordTransition, previous.Status, status)
+	}
+
+	now := time.Now()
+	word := &game.Word{}
+	if err := tx.GetContext(ctx, word, `
+		UPDATE words
+		SET status = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING `+curatedWordColumns, status, now, wordID); err != nil {
+		return nil, fmt.Errorf("failed to set word status: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO word_reviews (id, word_id, from_status, to_status, note, reviewed_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), wordID, previous.Status, status, note, reviewedBy, now); err != nil {
+		return nil, fmt.Errorf("failed to record word review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit word status: %w", err)
+	}
+
+	s.recordWord(ctx, audit.ActionWordCurated, wordID, previous, word)
+	return word, nil
+}
+
+// WordReviews lists a word's moves between statuses, oldest first
+func (s *Service) WordReviews(ctx context.Context, wordID string) ([]WordReview, error) {
+	reviews := []WordReview{}
+	if err := s.db.SelectContext(ctx, &reviews, `
+		SELECT * FROM word_reviews
+		WHERE word_id = $1
+		ORDER BY created_at`, wordID); err != nil {
+		return nil, fmt.Errorf("failed to list word reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// lockWord holds the word's row for the rest of tx so concurrent reviews of
+// the same word queue up behind each other
+func lockWord(ctx context.Context, tx *sqlx.Tx, wordID string) (*game.Word, error) {
+	word := &game.Word{}
+	if err := tx.GetContext(ctx, word, `
+		SELECT `+curatedWordColumns+` FROM words
+		WHERE id = $1
+		FOR UPDATE`, wordID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWordNotFound
+		}
+		return nil, fmt.Errorf("failed to get word: %w", err)
+	}
+	return word, nil
+}
+
+func (s *Service) recordWord(ctx context.Context, action audit.Action, wordID string, before, after any) {
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: action, TargetType: "word", TargetID: wordID, Before: before, After: after})
+	}
+}
+
+// PregenerateAudio queues the pronunciations of the words at level that
+// have no audio yet to be generated and cached in the background
+func (s *Service) PregenerateAudio(ctx context.Context, level int) (*jobs.Job, error) {
+	if s.audioJobs == nil {
+		return nil, ErrAudioDisabled
+	}
+
+	job, err := s.audioJobs.Enqueue(ctx, game.JobPregenerateLevel, game.PregenerateLevelJob{Level: level})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue audio pregeneration: %w", err)
+	}
+	return job, nil
+}
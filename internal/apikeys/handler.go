@@ -0,0 +1,116 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Create makes the caller a key, responding with its secret, which can't be
+// shown again
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var input CreateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.validate(); input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	key, err := h.service.Create(r.Context(), userID, input)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusCreated, key); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// List serves the caller's keys
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusOK, map[string]any{"keys": keys}); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// Revoke stops one of the caller's keys working
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var v validator.Validator
+	keyID := httprouter.ParamsFromContext(r.Context()).ByName("keyID")
+	_, err := uuid.Parse(keyID)
+	v.CheckField(err == nil, "keyID", "Must be a valid key ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), userID, keyID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrTooManyKeys):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+package apikeys
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// window is the span rate limits count requests over
+const window = time.Minute
+
+// Limiter counts a key's requests, reporting whether another is allowed
+// under limit a minute, and if not, how long until one is
+type Limiter interface {
+	Allow(ctx context.Context, keyID string, limit int) (retryAfter time.Duration, allowed bool, err error)
+}
+
+// MemoryLimiter counts requests in fixed one-minute windows in memory. Each
+// instance of the API counts its own, so it suits a single instance.
+type MemoryLimiter struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*counter
+}
+
+type counter struct {
+	start time.Time
+	count int
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{now: time.Now, windows: make(map[string]*counter)}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, keyID string, limit int) (time.Duration, bool, error) {
+	now := l.now()
+	start := now.Truncate(window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.windows[keyID]
+	if !ok || !c.start.Equal(start) {
+		// Counts from windows that have passed go whenever a key starts a
+		// new one, so idle keys don't pile up
+		for id, old := range l.windows {
+			if old.start.Before(start) {
+				delete(l.windows, id)
+			}
+		}
+		c = &counter{start: start}
+		l.windows[keyID] = c
+	}
+	if c.count >= limit {
+		return start.Add(window).Sub(now), false, nil
+	}
+	c.count++
+	return 0, true, nil
+}
+
+// Redis is the part of the Redis client requests are counted with
+type Redis interface {
+	Int(ctx context.Context, args ...string) (int64, error)
+}
+
+// RedisLimiter counts requests in fixed one-minute windows in Redis, shared
+// by every instance of the API
+type RedisLimiter struct {
+	redis Redis
+	now   func() time.Time
+}
+
+func NewRedisLimiter(redis Redis) *RedisLimiter {
+	return &RedisLimiter{redis: redis, now: time.Now}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, keyID string, limit int) (time.Duration, bool, error) {
+	now := l.now()
+	start := now.Truncate(window)
+	key := "apikey:" + keyID + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	count, err := l.redis.Int(ctx, "INCR", key)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to count request: %w", err)
+	}
+	if count == 1 {
+		if _, err := l.redis.Int(ctx, "EXPIRE", key, strconv.Itoa(int(2*window/time.Second))); err != nil {
+			return 0, false, fmt.Errorf("failed to expire request count: %w", err)
+		}
+	}
+	if count > int64(limit) {
+		return start.Add(window).Sub(now), false, nil
+	}
+	return 0, true, nil
+}
@@ -0,0 +1,75 @@
+package apikeys
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 15, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, allowed, err := limiter.Allow(ctx, "key-1", 3)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	retryAfter, allowed, err := limiter.Allow(ctx, "key-1", 3)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 45*time.Second, retryAfter)
+
+	_, allowed, _ = limiter.Allow(ctx, "key-2", 3)
+	assert.True(t, allowed, "each key has its own limit")
+
+	now = now.Add(time.Minute)
+	_, allowed, _ = limiter.Allow(ctx, "key-1", 3)
+	assert.True(t, allowed, "a new minute starts a new count")
+	assert.Len(t, limiter.windows, 1, "past windows are dropped")
+}
+
+type fakeRedis struct {
+	counts  map[string]int64
+	expires map[string]string
+}
+
+func (r *fakeRedis) Int(_ context.Context, args ...string) (int64, error) {
+	switch strings.ToUpper(args[0]) {
+	case "INCR":
+		r.counts[args[1]]++
+		return r.counts[args[1]], nil
+	case "EXPIRE":
+		r.expires[args[1]] = args[2]
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestRedisLimiter(t *testing.T) {
+	redis := &fakeRedis{counts: map[string]int64{}, expires: map[string]string{}}
+	now := time.Date(2026, 10, 15, 9, 0, 50, 0, time.UTC)
+	limiter := NewRedisLimiter(redis)
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, allowed, err := limiter.Allow(ctx, "key-1", 1)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	retryAfter, allowed, err := limiter.Allow(ctx, "key-1", 1)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	key := fmt.Sprintf("apikey:key-1:%d", now.Truncate(time.Minute).Unix())
+	assert.Equal(t, int64(2), redis.counts[key])
+	assert.Equal(t, "120", redis.expires[key])
+}
@@ -0,0 +1,262 @@
+// Package apikeys lets players create API keys for bots and classroom tools,
+// which read leaderboards and word data without signing in as them
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	// keyPrefix starts every key, so leaked keys are easy to spot
+	keyPrefix = "bsk_"
+
+	// DefaultRateLimit and MaxRateLimit bound how many requests a minute a
+	// key may make
+	DefaultRateLimit = 60
+	MaxRateLimit     = 600
+
+	// MaxKeys is how many live keys a player may have
+	MaxKeys = 10
+)
+
+var (
+	ErrKeyNotFound = errors.New("API key not found")
+	ErrTooManyKeys = errors.New("too many API keys, revoke one first")
+)
+
+// Key is an API key as its owner sees it. The secret is only ever shown
+// when it's created.
+type Key struct {
+	ID         string         `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Prefix     string         `json:"prefix" db:"prefix"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	RateLimit  int            `json:"rate_limit" db:"rate_limit"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// CreatedKey is a new key along with its secret
+type CreatedKey struct {
+	Key
+	Secret string `json:"key"`
+}
+
+type CreateInput struct {
+	Name string `json:"name"`
+	// Scopes are picked from auth.APIKeyScopes
+	Scopes []auth.Scope `json:"scopes"`
+	// RateLimit is requests a minute, defaulting to DefaultRateLimit
+	RateLimit int                 `json:"rate_limit"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (i *CreateInput) validate() {
+	v := &i.Validator
+	v.CheckField(validator.NotBlank(i.Name), "name", "Name is required")
+	v.CheckField(validator.MaxRunes(i.Name, 100), "name", "Must be no more than 100 characters")
+	v.CheckField(len(i.Scopes) > 0, "scopes", "At least one scope is required")
+	v.CheckField(validator.AllIn(i.Scopes, auth.APIKeyScopes...), "scopes", "Must be leaderboards:read or words:read")
+	v.CheckField(validator.NoDuplicates(i.Scopes), "scopes", "Must not repeat a scope")
+	if i.RateLimit == 0 {
+		i.RateLimit = DefaultRateLimit
+	}
+	v.CheckField(validator.Between(i.RateLimit, 1, MaxRateLimit), "rate_limit", fmt.Sprintf("Must be between 1 and %d requests a minute", MaxRateLimit))
+}
+
+type ServiceOption func(*Service)
+
+// WithLimiter counts keys' requests with limiter, such as a RedisLimiter
+// shared between instances, instead of in memory
+func WithLimiter(limiter Limiter) ServiceOption {
+	return func(s *Service) {
+		s.limiter = limiter
+	}
+}
+
+type Service struct {
+	db      *sqlx.DB
+	limiter Limiter
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db, limiter: NewMemoryLimiter()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create makes a key for ownerID
+func (s *Service) Create(ctx context.Context, ownerID string, input CreateInput) (*CreatedKey, error) {
+	prefix, secret, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make(pq.StringArray, len(input.Scopes))
+	for i, scope := range input.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize a player's key creation so two at once can't both squeeze
+	// under MaxKeys
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to lock owner: %w", err)
+	}
+	var live int
+	if err := tx.GetContext(ctx, &live, `
+		SELECT COUNT(*) FROM api_keys WHERE owner_id = $1 AND revoked_at IS NULL`, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to count keys: %w", err)
+	}
+	if live >= MaxKeys {
+		return nil, ErrTooManyKeys
+	}
+
+	created := &CreatedKey{Secret: secret}
+	if err := tx.GetContext(ctx, &created.Key, `
+		INSERT INTO api_keys (owner_id, name, prefix, key_hash, scopes, rate_limit)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, prefix, scopes, rate_limit, created_at, last_used_at`,
+		ownerID, input.Name, prefix, hashKey(secret), scopes, input.RateLimit); err != nil {
+		return nil, fmt.Errorf("failed to save key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+// List returns ownerID's live keys, newest first
+func (s *Service) List(ctx context.Context, ownerID string) ([]Key, error) {
+	keys := []Key{}
+	if err := s.db.SelectContext(ctx, &keys, `
+		SELECT id, name, prefix, scopes, rate_limit, created_at, last_used_at
+		FROM api_keys
+		WHERE owner_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke stops one of ownerID's keys working
+func (s *Service) Revoke(ctx context.Context, ownerID, keyID string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND owner_id = $2 AND revoked_at IS NULL`, keyID, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to revoke key: %w", err)
+	} else if n == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateKey returns the principal secret speaks for, counting the
+// request against its rate limit. It implements auth.APIKeyAuthenticator.
+func (s *Service) AuthenticateKey(ctx context.Context, secret string) (*auth.Principal, error) {
+	prefix, ok := parseKey(secret)
+	if !ok {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	var key struct {
+		ID        string         `db:"id"`
+		Hash      string         `db:"key_hash"`
+		Scopes    pq.StringArray `db:"scopes"`
+		RateLimit int            `db:"rate_limit"`
+	}
+	err := s.db.GetContext(ctx, &key, `
+		SELECT k.id, k.key_hash, k.scopes, k.rate_limit
+		FROM api_keys k
+		JOIN users u ON u.id = k.owner_id
+		WHERE k.prefix = $1 AND k.revoked_at IS NULL AND u.deleted_at IS NULL`, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashKey(secret))) != 1 {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	retryAfter, allowed, err := s.limiter.Allow(ctx, key.ID, key.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, &auth.RateLimitError{RetryAfter: retryAfter}
+	}
+
+	// Noted at most once a minute, so busy keys don't write on every
+	// request
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`, key.ID); err != nil {
+		return nil, fmt.Errorf("failed to note key used: %w", err)
+	}
+
+	principal := &auth.Principal{APIKey: key.ID, Scopes: make([]auth.Scope, len(key.Scopes))}
+	for i, scope := range key.Scopes {
+		principal.Scopes[i] = auth.Scope(scope)
+	}
+	return principal, nil
+}
+
+// newKey generates a key, "bsk_<prefix>_<secret>", returning the prefix it's
+// looked up by along with it
+func newKey() (prefix, key string, err error) {
+	b := make([]byte, 30)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	prefix = hex.EncodeToString(b[:6])
+	return prefix, keyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(b[6:]), nil
+}
+
+// parseKey returns the prefix of a well-formed key
+func parseKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, keyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// hashKey is how keys are kept. They're random enough that a plain hash
+// keeps them safe.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package apikeys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestKeys(t *testing.T) {
+	prefix, key, err := newKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "bsk_"+prefix+"_"))
+
+	parsed, ok := parseKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, parsed)
+	assert.Len(t, hashKey(key), 64)
+
+	for _, bad := range []string{"", "bsk_", "bsk_abc_def", "sk_" + prefix + "_secret", "bsk_" + prefix + "_"} {
+		_, ok := parseKey(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestCreateInputValidation(t *testing.T) {
+	input := CreateInput{Name: "Class 4B leaderboard", Scopes: []auth.Scope{auth.ScopeLeaderboardsRead}}
+	input.validate()
+	assert.False(t, input.Validator.HasErrors())
+	assert.Equal(t, DefaultRateLimit, input.RateLimit)
+
+	input = CreateInput{Name: "bot", Scopes: []auth.Scope{auth.ScopeGamesWrite}, RateLimit: MaxRateLimit + 1}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "scopes", "keys can't act as players")
+	assert.Contains(t, input.Validator.FieldErrors, "rate_limit")
+
+	input = CreateInput{Scopes: []auth.Scope{auth.ScopeWordsRead, auth.ScopeWordsRead}}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "name")
+	assert.Contains(t, input.Validator.FieldErrors, "scopes")
+}
@@ -0,0 +1,229 @@
+// Package audit keeps an append-only record of sensitive actions: who did
+// what to which target, from where, and what it looked like before and after
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Action string
+
+const (
+	ActionLogin           Action = "auth.login"
+	ActionLoginFailed     Action = "auth.login_failed"
+	ActionServiceToken    Action = "auth.service_token"
+	ActionSessionRevoked  Action = "auth.session_revoked"
+	ActionCategoryCreated Action = "category.created"
+	ActionCategoryUpdated Action = "category.updated"
+	ActionCategoryDeleted Action = "category.deleted"
+	ActionWordsAdded      Action = "category.words_added"
+	ActionWordRemoved     Action = "category.word_removed"
+	ActionGameCancelled   Action = "game.cancelled"
+	ActionAppealDecided   Action = "appeal.decided"
+	ActionGameEnded       Action = "game.ended"
+	ActionUserSuspended   Action = "user.suspended"
+	ActionUserReinstated  Action = "user.reinstated"
+	ActionRatingAdjusted  Action = "user.rating_adjusted"
+	ActionDecayExemption  Action = "user.decay_exemption"
+	ActionUserMuted       Action = "user.muted"
+	ActionAccountDeleted  Action = "user.deleted"
+	ActionEmailChanged    Action = "user.email_changed"
+	ActionPasswordChanged Action = "user.password_changed"
+	ActionReportClosed    Action = "report.closed"
+	ActionFlagsReviewed   Action = "game.flags_reviewed"
+	ActionWebhookCreated  Action = "webhook.created"
+	ActionWebhookDeleted  Action = "webhook.deleted"
+	ActionWordListed      Action = "word_filter.listed"
+	ActionWordUnlisted    Action = "word_filter.unlisted"
+	ActionWordEdited      Action = "word.edited"
+	ActionWordCurated     Action = "word.curated"
+)
+
+var knownActions = []Action{
+	ActionLogin, ActionLoginFailed, ActionServiceToken, ActionSessionRevoked,
+	ActionCategoryCreated, ActionCategoryUpdated, ActionCategoryDeleted, ActionWordsAdded, ActionWordRemoved,
+	ActionGameCancelled, ActionGameEnded, ActionAppealDecided, ActionFlagsReviewed,
+	ActionUserSuspended, ActionUserReinstated, ActionRatingAdjusted, ActionDecayExemption, ActionUserMuted,
+	ActionAccountDeleted, ActionEmailChanged, ActionPasswordChanged, ActionReportClosed,
+	ActionWebhookCreated, ActionWebhookDeleted, ActionWordListed, ActionWordUnlisted,
+	ActionWordEdited, ActionWordCurated,
+}
+
+// Event is an action to record. The actor and IP are taken from the
+// context when left empty.
+type Event struct {
+	Action     Action
+	ActorID    string
+	TargetType string
+	TargetID   string
+	// Before and After are snapshots of the target, encoded as JSON
+	Before any
+	After  any
+}
+
+// Recorder records events. Recording never fails the action it describes.
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+// Entry is a recorded event
+type Entry struct {
+	ID         int64            `json:"id" db:"id"`
+	Action     Action           `json:"action" db:"action"`
+	ActorID    *string          `json:"actor_id" db:"actor_id"`
+	IP         *string          `json:"ip" db:"ip"`
+	TargetType string           `json:"target_type" db:"target_type"`
+	TargetID   string           `json:"target_id" db:"target_id"`
+	Before     *json.RawMessage `json:"before" db:"before"`
+	After      *json.RawMessage `json:"after" db:"after"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+}
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	ipKey
+)
+
+// WithActor attributes events recorded with ctx to actorID: a user ID, or
+// "service:<name>" for a service account
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey, actorID)
+}
+
+// WithIP notes the client address events recorded with ctx came from
+func WithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ipKey, ip)
+}
+
+// IP returns the client address noted on ctx by WithIP, if any
+func IP(ctx context.Context) string {
+	ip, _ := ctx.Value(ipKey).(string)
+	return ip
+}
+
+type Service struct {
+	db      *sqlx.DB
+	onError func(error)
+}
+
+// NewService reports events it fails to record to onError
+func NewService(db *sqlx.DB, onError func(error)) *Service {
+	return &Service{db: db, onError: onError}
+}
+
+func (s *Service) Record(ctx context.Context, event Event) {
+	if err := s.record(ctx, event); err != nil && s.onError != nil {
+		s.onError(fmt.Errorf("failed to record %s for %s %s: %w", event.Action, event.TargetType, event.TargetID, err))
+	}
+}
+
+func (s *Service) record(ctx context.Context, event Event) error {
+	actorID := event.ActorID
+	if actorID == "" {
+		actorID, _ = ctx.Value(actorKey).(string)
+	}
+	ip := IP(ctx)
+
+	before, err := snapshot(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := snapshot(event.After)
+	if err != nil {
+		return err
+	}
+
+	// The action has already happened, so its record is written even if
+	// the client has gone
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (action, actor_id, ip, target_type, target_id, before, after)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7)`,
+		event.Action, actorID, ip, event.TargetType, event.TargetID, before, after)
+	return err
+}
+
+// snapshot encodes v for a JSONB column, leaving it NULL when v is nil
+func snapshot(v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	s := string(data)
+	return &s, nil
+}
+
+// Filter narrows down the entries List returns. Zero fields match anything.
+type Filter struct {
+	Action     Action
+	ActorID    string
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
+
+// List returns the entries matching filter, newest first, along with how
+// many match in total
+func (s *Service) List(ctx context.Context, filter Filter) ([]Entry, int, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.ActorID != "" {
+		where("actor_id = $%d", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		where("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		where("target_id = $%d", filter.TargetID)
+	}
+	if filter.Since != nil {
+		where("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		where("created_at < $%d", *filter.Until)
+	}
+
+	query := "FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.GetContext(ctx, &total, "SELECT COUNT(*) "+query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log: %w", err)
+	}
+
+	entries := []Entry{}
+	args = append(args, filter.Limit, filter.Offset)
+	if err := s.db.SelectContext(ctx, &entries, fmt.Sprintf(
+		"SELECT * %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", query, len(args)-1, len(args)),
+		args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type ListRequest struct {
+	Filter
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseListRequest(query url.Values) ListRequest {
+	req := ListRequest{
+		Filter: Filter{
+			Action:     Action(query.Get("action")),
+			ActorID:    query.Get("actor_id"),
+			TargetType: query.Get("target_type"),
+			TargetID:   query.Get("target_id"),
+		},
+		Page:     1,
+		PageSize: DefaultPageSize,
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readTime := func(key string, dst **time.Time) {
+		if raw := query.Get(key); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			req.Validator.CheckField(err == nil, key, "Must be an RFC 3339 timestamp")
+			*dst = &t
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+	readTime("since", &req.Since)
+	readTime("until", &req.Until)
+
+	return req
+}
+
+func (r *ListRequest) validate() {
+	if r.Action != "" {
+		r.Validator.CheckField(validator.In(r.Action, knownActions...), "action", "Must be a known action")
+	}
+	if r.Since != nil && r.Until != nil {
+		r.Validator.CheckField(r.Until.After(*r.Since), "until", "Must be after since")
+	}
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+// List serves a page of the audit log, newest first, filtered by action,
+// actor, target and time
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	req := parseListRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		if err := response.JSON(w, http.StatusUnprocessableEntity, req.Validator); err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	req.Limit, req.Offset = req.PageSize, (req.Page-1)*req.PageSize
+	entries, total, err := h.service.List(r.Context(), req.Filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"entries": entries,
+		"page": map[string]int{
+			"page":      req.Page,
+			"page_size": req.PageSize,
+			"total":     total,
+		},
+	})
+}
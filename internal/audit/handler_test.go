@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListRequest(t *testing.T) {
+	req := parseListRequest(url.Values{
+		"action":    {"auth.login"},
+		"actor_id":  {"user-1"},
+		"since":     {"2024-03-01T00:00:00Z"},
+		"page":      {"3"},
+		"page_size": {"20"},
+	})
+	req.validate()
+
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, ActionLogin, req.Action)
+	assert.Equal(t, "user-1", req.ActorID)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *req.Since)
+	assert.Nil(t, req.Until)
+	assert.Equal(t, 3, req.Page)
+	assert.Equal(t, 20, req.PageSize)
+}
+
+func TestListValidation(t *testing.T) {
+	h := NewHandler(nil)
+
+	for query, field := range map[string]string{
+		"action=auth.logout": "action",
+		"since=yesterday":    "since",
+		"page=0":             "page",
+		"page_size=500":      "page_size",
+		"page_size=lots":     "page_size",
+		"since=2024-03-02T00:00:00Z&until=2024-03-01T00:00:00Z": "until",
+	} {
+		rec := httptest.NewRecorder()
+		h.List(rec, httptest.NewRequest(http.MethodGet, "/audit-log?"+query, nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, query)
+		assert.Contains(t, rec.Body.String(), field, query)
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"big-spella-go/internal/audit"
+)
+
+// APIKeyHeader is the header third-party integrations send their API key in
+const APIKeyHeader = "X-API-Key"
+
+var ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+
+// APIKeyAuthenticator looks up the principal an API key speaks for. It's
+// satisfied by apikeys.Service.
+type APIKeyAuthenticator interface {
+	AuthenticateKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// RateLimitError is returned by an APIKeyAuthenticator for a key that has
+// used up its requests for now
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("API key rate limit exceeded, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// SetAPIKeys has Middleware accept API keys in the X-API-Key header,
+// checked with keys. It is meant to be called during startup; without it,
+// requests sending a key are turned away.
+func (s *Service) SetAPIKeys(keys APIKeyAuthenticator) {
+	s.apiKeys = keys
+}
+
+// authenticateAPIKey adds the principal key speaks for to r's context, or
+// responds itself and returns false if it can't
+func (s *Service) authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string) (*http.Request, bool) {
+	if s.apiKeys == nil {
+		http.Error(w, ErrInvalidAPIKey.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	principal, err := s.apiKeys.AuthenticateKey(r.Context(), key)
+	if err != nil {
+		var limited *RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+			http.Error(w, limited.Error(), http.StatusTooManyRequests)
+		case errors.Is(err, ErrInvalidAPIKey):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	ctx := SetPrincipalInContext(r.Context(), principal)
+	ctx = audit.WithActor(ctx, principal.ActorID())
+	return r.WithContext(ctx), true
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeys map[string]error
+
+func (k fakeAPIKeys) AuthenticateKey(_ context.Context, key string) (*Principal, error) {
+	if err, ok := k[key]; ok {
+		return nil, err
+	}
+	return &Principal{APIKey: "key-1", Scopes: []Scope{ScopeLeaderboardsRead}}, nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	handler := service.Middleware(service.RequireAnyScope([]Scope{ScopeGamesRead, ScopeLeaderboardsRead}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "apikey:key-1", GetPrincipal(r.Context()).ActorID())
+		assert.Empty(t, GetUserIDFromContext(r.Context()))
+	})))
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/daily/leaderboard", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("bsk_good").Code, "keys are refused until the service is set")
+
+	service.SetAPIKeys(fakeAPIKeys{
+		"bsk_bad":  ErrInvalidAPIKey,
+		"bsk_busy": &RateLimitError{RetryAfter: 1500 * time.Millisecond},
+	})
+	assert.Equal(t, http.StatusOK, serve("bsk_good").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("bsk_bad").Code)
+
+	rec := serve("bsk_busy")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
+
+func TestRequireAnyScope(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	handler := service.RequireAnyScope([]Scope{ScopeGamesRead, ScopeWordsRead}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(principal *Principal) int {
+		req := httptest.NewRequest(http.MethodGet, "/categories", nil)
+		if principal != nil {
+			req = req.WithContext(SetPrincipalInContext(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(&Principal{UserID: "user-1", Scopes: UserScopes}))
+	assert.Equal(t, http.StatusOK, serve(&Principal{APIKey: "key-1", Scopes: []Scope{ScopeWordsRead}}))
+	assert.Equal(t, http.StatusForbidden, serve(&Principal{APIKey: "key-1", Scopes: []Scope{ScopeLeaderboardsRead}}))
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+}
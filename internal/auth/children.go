@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ChildAge is the age under which an account is a child's. A child's
+// account can't be used until a parent consents to it, and it's kept out of
+// social features.
+const ChildAge = 13
+
+var (
+	ErrConsentRequired        = errors.New("a parent must consent before this account can be used")
+	ErrChildAccount           = errors.New("not available on child accounts")
+	ErrChildSignupUnavailable = errors.New("accounts for players under 13 can't be registered right now")
+)
+
+// ConsentRequester asks a child's parent to consent to their account. It's
+// satisfied by parental.Service.
+type ConsentRequester interface {
+	RequestConsent(ctx context.Context, child *User, parentEmail string) error
+}
+
+// SetConsentRequester sends the consent requests children's accounts need
+// before they can be used. It is meant to be called during startup; without
+// one, children can't register.
+func (s *Service) SetConsentRequester(requester ConsentRequester) {
+	s.consent = requester
+}
+
+// checkConsent returns ErrConsentRequired for a child's account until a
+// parent consents to it, and again once they withdraw their consent
+func (s *Service) checkConsent(ctx context.Context, user *User) error {
+	if !user.IsChild {
+		return nil
+	}
+
+	var consented bool
+	if err := s.db.GetContext(ctx, &consented, `
+		SELECT EXISTS (
+			SELECT 1 FROM parental_consents
+			WHERE child_id = $1 AND granted_at IS NOT NULL AND revoked_at IS NULL
+		)`, user.ID); err != nil {
+		return fmt.Errorf("check parental consent: %w", err)
+	}
+	if !consented {
+		return ErrConsentRequired
+	}
+	return nil
+}
+
+// IsChild reports whether the request's principal is a child's account
+func IsChild(ctx context.Context) bool {
+	principal := GetPrincipal(ctx)
+	return principal != nil && principal.Child
+}
+
+// RequireAdult creates a middleware that keeps children's accounts out of
+// next, such as social features
+func (s *Service) RequireAdult(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := GetPrincipal(r.Context())
+		if principal == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if principal.Child {
+			http.Error(w, ErrChildAccount.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildrenNameAParent(t *testing.T) {
+	age := func(n int) *int { return &n }
+	input := func(years *int, parentEmail string) RegisterInput {
+		return RegisterInput{
+			Username:    "speller",
+			Email:       "speller@example.com",
+			Password:    "correct horse battery",
+			Age:         years,
+			ParentEmail: parentEmail,
+		}
+	}
+
+	adult := input(age(30), "")
+	adult.validate()
+	assert.False(t, adult.Validator.HasErrors())
+	assert.False(t, adult.isChild())
+
+	unsaid := input(nil, "")
+	unsaid.validate()
+	assert.False(t, unsaid.Validator.HasErrors(), "age is optional")
+
+	child := input(age(9), "")
+	child.validate()
+	assert.True(t, child.isChild())
+	assert.Contains(t, child.Validator.FieldErrors, "parent_email")
+
+	child = input(age(9), "SPELLER@example.com")
+	child.validate()
+	assert.Contains(t, child.Validator.FieldErrors, "parent_email", "children can't consent for themselves")
+
+	child = input(age(ChildAge-1), "parent@example.com")
+	child.validate()
+	assert.False(t, child.Validator.HasErrors())
+
+	nonsense := input(age(0), "")
+	nonsense.validate()
+	assert.Contains(t, nonsense.Validator.FieldErrors, "age")
+}
+
+func TestChildTokens(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+
+	tokens, err := service.generateTokenPair(&User{ID: "user-1", Username: "speller", IsChild: true}, "", time.Time{})
+	require.NoError(t, err)
+	principal, err := service.ParseToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, principal.Child)
+	assert.True(t, IsChild(SetPrincipalInContext(context.Background(), principal)))
+
+	tokens, err = service.generateTokenPair(&User{ID: "user-2", Username: "grown-up"}, "", time.Time{})
+	require.NoError(t, err)
+	principal, err = service.ParseToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, principal.Child)
+}
+
+func TestRequireAdult(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	handler := service.RequireAdult(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(principal *Principal) int {
+		r := httptest.NewRequest(http.MethodGet, "/friends", nil)
+		if principal != nil {
+			r = r.WithContext(SetPrincipalInContext(r.Context(), principal))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(&Principal{UserID: "user-2"}))
+	assert.Equal(t, http.StatusForbidden, serve(&Principal{UserID: "user-1", Child: true}))
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+}
+
+func TestChildrenCantRegisterWithoutConsentRequests(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	age := 8
+	_, err := service.Register(context.Background(), RegisterInput{Age: &age, ParentEmail: "parent@example.com"})
+	assert.ErrorIs(t, err, ErrChildSignupUnavailable)
+}
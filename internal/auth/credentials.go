@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/queries"
+	"big-spella-go/internal/smtp"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	// EmailChangeEmail is the template email change confirmations are
+	// sent with
+	EmailChangeEmail = "email_change.tmpl"
+	// EmailChangeTTL is how long the link to confirm a new address works
+	EmailChangeTTL = 24 * time.Hour
+)
+
+var (
+	ErrEmailChangeNotFound    = errors.New("no email change matches that token, or it has expired")
+	ErrEmailChangeUnavailable = errors.New("email changes can't be confirmed right now")
+)
+
+const uniqueViolation = "23505"
+
+type ChangeEmailInput struct {
+	Email     string              `json:"email"`
+	Password  string              `json:"password"`
+	Validator validator.Validator `json:"-"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string              `json:"current_password"`
+	NewPassword     string              `json:"new_password"`
+	Validator       validator.Validator `json:"-"`
+}
+
+// SetEmailSender has email changes confirmed by a link to confirmURL, with
+// the change's token added as the token parameter, emailed to the new
+// address with send. It is meant to be called during startup; without it,
+// players can't change their email.
+func (s *Service) SetEmailSender(send smtp.SendEmail, confirmURL string) {
+	s.sendEmail = send
+	s.emailConfirmURL = confirmURL
+}
+
+// checkPassword returns ErrInvalidCredentials unless password is userID's
+func (s *Service) checkPassword(ctx context.Context, userID, password string) error {
+	var hash string
+	err := s.queries.GetPasswordHash(ctx, &hash, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("get user: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// ChangeEmail emails a link to the new address for userID to confirm it's
+// theirs, once they confirm their password. The account keeps its old
+// address until then. Asking again replaces the earlier change, whose link
+// stops working.
+func (s *Service) ChangeEmail(ctx context.Context, userID string, input ChangeEmailInput) error {
+	if s.sendEmail == nil {
+		return ErrEmailChangeUnavailable
+	}
+	if err := s.checkPassword(ctx, userID, input.Password); err != nil {
+		return err
+	}
+
+	var taken bool
+	if err := s.queries.EmailTaken(ctx, &taken, input.Email); err != nil {
+		return fmt.Errorf("check email: %w", err)
+	}
+	if taken {
+		return ErrUserExists
+	}
+
+	token, hash, err := newEmailChangeToken()
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_changes (user_id, new_email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET new_email = EXCLUDED.new_email, token_hash = EXCLUDED.token_hash,
+			expires_at = EXCLUDED.expires_at, requested_at = NOW()`,
+		userID, input.Email, hash, time.Now().Add(EmailChangeTTL)); err != nil {
+		return fmt.Errorf("save email change: %w", err)
+	}
+	var username string
+	if err := s.queries.GetUsername(ctx, &username, userID); err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	link := s.emailConfirmURL + "?" + url.Values{"token": {token}}.Encode()
+	if err := s.sendEmail(input.Email, map[string]any{
+		"Username":   username,
+		"ConfirmURL": link,
+	}, EmailChangeEmail); err != nil {
+		return fmt.Errorf("email confirmation: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches an account to the address token was emailed
+// to
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var change struct {
+		UserID   string `db:"user_id"`
+		NewEmail string `db:"new_email"`
+	}
+	err = tx.GetContext(ctx, &change, `
+		DELETE FROM email_changes
+		WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING user_id, new_email`, hashEmailChangeToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEmailChangeNotFound
+		}
+		return fmt.Errorf("get email change: %w", err)
+	}
+
+	n, err := s.queries.WithTx(tx).UpdateEmail(ctx, queries.UpdateEmailParams{Email: change.NewEmail, ID: change.UserID})
+	if err != nil {
+		// Someone else took the address while the link sat in their inbox
+		if hasCode(err, uniqueViolation) {
+			return ErrUserExists
+		}
+		return fmt.Errorf("update email: %w", err)
+	}
+	if n == 0 {
+		return ErrEmailChangeNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	s.record(ctx, audit.Event{Action: audit.ActionEmailChanged, ActorID: change.UserID, TargetType: "user", TargetID: change.UserID})
+	return nil
+}
+
+// ChangePassword sets principal's new password once they confirm their
+// current one, signing their other devices out
+func (s *Service) ChangePassword(ctx context.Context, principal *Principal, input ChangePasswordInput) error {
+	if err := s.checkPassword(ctx, principal.UserID, input.CurrentPassword); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.queries.WithTx(tx).UpdatePassword(ctx, queries.UpdatePasswordParams{
+		PasswordHash: string(hash), ID: principal.UserID,
+	}); err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND id::TEXT <> $2 AND revoked_at IS NULL`, principal.UserID, principal.SessionID); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	s.record(ctx, audit.Event{Action: audit.ActionPasswordChanged, ActorID: principal.UserID, TargetType: "user", TargetID: principal.UserID})
+	return nil
+}
+
+// passwordChanged reports whether userID has changed their password since
+// registering, which refresh tokens from before sessions were tracked
+// can't outlive
+func (s *Service) passwordChanged(ctx context.Context, userID string) (bool, error) {
+	var changed bool
+	if err := s.queries.PasswordChanged(ctx, &changed, userID); err != nil {
+		return false, fmt.Errorf("check password changed: %w", err)
+	}
+	return changed, nil
+}
+
+// newEmailChangeToken returns a random token and the hash it's stored as
+func newEmailChangeToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate email change token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashEmailChangeToken(token), nil
+}
+
+func hashEmailChangeToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePasswordValidation(t *testing.T) {
+	input := ChangePasswordInput{CurrentPassword: "old password", NewPassword: "correct horse battery"}
+	input.validate()
+	assert.False(t, input.Validator.HasErrors())
+
+	input = ChangePasswordInput{CurrentPassword: "old password", NewPassword: "short"}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "new_password")
+
+	input = ChangePasswordInput{CurrentPassword: "same password", NewPassword: "same password"}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "new_password")
+
+	input = ChangePasswordInput{NewPassword: "correct horse battery"}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "current_password")
+}
+
+func TestChangeEmailNeedsASender(t *testing.T) {
+	h := NewHandler(NewService(nil, []byte("test-secret"), time.Hour))
+
+	req := httptest.NewRequest(http.MethodPut, "/auth/email", strings.NewReader(`{"email":"new@example.com","password":"hunter22"}`))
+	rec := httptest.NewRecorder()
+	h.ChangeEmail(rec, req.WithContext(SetUserIDInContext(req.Context(), "user-1")))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/auth/email", strings.NewReader(`{"email":"not an email","password":"hunter22"}`))
+	rec = httptest.NewRecorder()
+	h.ChangeEmail(rec, req.WithContext(SetUserIDInContext(req.Context(), "user-1")))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -2,7 +2,15 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
 )
 
 type Handler struct {
@@ -20,11 +28,18 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if input.validate(); input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
 	user, err := h.service.Register(r.Context(), input)
 	if err != nil {
 		switch err {
 		case ErrUserExists:
 			http.Error(w, err.Error(), http.StatusConflict)
+		case ErrChildSignupUnavailable:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		default:
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 		}
@@ -43,11 +58,19 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if input.validate(); input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+	input.UserAgent = r.UserAgent()
+
 	tokens, err := h.service.Login(r.Context(), input)
 	if err != nil {
 		switch err {
 		case ErrInvalidCredentials:
 			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case ErrAccountSuspended, ErrConsentRequired:
+			http.Error(w, err.Error(), http.StatusForbidden)
 		default:
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 		}
@@ -58,20 +81,32 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(tokens)
 }
 
+// RefreshInput trades a refresh token for a new pair of tokens
+type RefreshInput struct {
+	RefreshToken string              `json:"refresh_token"`
+	Validator    validator.Validator `json:"-"`
+}
+
 func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		RefreshToken string `json:"refresh_token"`
-	}
+	var input RefreshInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	input.Validator.CheckField(validator.NotBlank(input.RefreshToken), "refresh_token", "Refresh token is required")
+	if input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
 	tokens, err := h.service.RefreshToken(r.Context(), input.RefreshToken)
 	if err != nil {
 		switch err {
 		case ErrInvalidToken:
 			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case ErrAccountSuspended, ErrConsentRequired:
+			http.Error(w, err.Error(), http.StatusForbidden)
 		default:
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 		}
@@ -92,3 +127,346 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// ChangeEmail emails the new address the caller gives a link to confirm
+// switching their account to it
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var input ChangeEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.validate(); input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	if err := h.service.ChangeEmail(r.Context(), userID, input); err != nil {
+		credentialsError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmEmailInput carries the token emailed to a new address
+type ConfirmEmailInput struct {
+	Token     string              `json:"token"`
+	Validator validator.Validator `json:"-"`
+}
+
+// ConfirmEmail switches an account to the address the token in the request
+// was emailed to
+func (h *Handler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var input ConfirmEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	input.Validator.CheckField(validator.NotBlank(input.Token), "token", "Token is required")
+	if input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	if err := h.service.ConfirmEmailChange(r.Context(), input.Token); err != nil {
+		credentialsError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePassword sets the caller's new password, signing their other
+// devices out
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	principal := GetPrincipal(r.Context())
+	if principal == nil || principal.UserID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var input ChangePasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.validate(); input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	if err := h.service.ChangePassword(r.Context(), principal, input); err != nil {
+		credentialsError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func credentialsError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrInvalidCredentials:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case ErrUserExists:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrUserNotFound, ErrEmailChangeNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrEmailChangeUnavailable:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// EnrollTwoFactor starts turning two-factor authentication on, returning
+// the secret for the caller's authenticator app
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := GetUser(r.Context())
+	if user == nil {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.service.EnrollTwoFactor(r.Context(), user)
+	if err != nil {
+		twoFactorError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusCreated, enrollment); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// ConfirmTwoFactor turns two-factor authentication on given a code from
+// the caller's app, responding with their recovery codes
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	input, ok := readCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(r.Context(), userID, input.Code)
+	if err != nil {
+		twoFactorError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusOK, map[string]any{"recovery_codes": codes}); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// DisableTwoFactor turns two-factor authentication off given a code
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	input, ok := readCode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(r.Context(), userID, input.Code); err != nil {
+		twoFactorError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyTwoFactor finishes signing in a player Login asked for a code
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	input, ok := readCode(w, r)
+	if !ok {
+		return
+	}
+	if input.ChallengeToken == "" {
+		input.Validator.AddFieldError("challenge_token", "Challenge token is required")
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	tokens, err := h.service.VerifyTwoFactor(r.Context(), input.ChallengeToken, input.Code)
+	if err != nil {
+		twoFactorError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// StepUpTwoFactor reissues the caller's tokens once they confirm a code, so
+// they can make sensitive changes
+func (h *Handler) StepUpTwoFactor(w http.ResponseWriter, r *http.Request) {
+	principal := GetPrincipal(r.Context())
+	if principal == nil || principal.UserID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	input, ok := readCode(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.service.StepUpTwoFactor(r.Context(), principal, input.Code)
+	if err != nil {
+		twoFactorError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CodeInput gives a two-factor or recovery code, and the challenge token
+// from signing in when it finishes a sign in
+type CodeInput struct {
+	Code           string              `json:"code"`
+	ChallengeToken string              `json:"challenge_token,omitempty"`
+	Validator      validator.Validator `json:"-"`
+}
+
+// readCode decodes a request giving a two-factor or recovery code,
+// responding itself if it isn't valid
+func readCode(w http.ResponseWriter, r *http.Request) (*CodeInput, bool) {
+	var input CodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	input.Validator.CheckField(validator.NotBlank(input.Code), "code", "Code is required")
+	if input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return nil, false
+	}
+	return &input, true
+}
+
+func twoFactorError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrInvalidCode, ErrInvalidToken:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case ErrTwoFactorEnabled:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrTwoFactorNotEnrolled, ErrUserNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrTwoFactorLocked:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// Sessions lists the devices signed in to the caller's account
+func (h *Handler) Sessions(w http.ResponseWriter, r *http.Request) {
+	principal := GetPrincipal(r.Context())
+	if principal == nil || principal.UserID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.service.ListSessions(r.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, map[string]any{"sessions": sessions}); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// RevokeSession signs one of the caller's devices out
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var v validator.Validator
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, "id", "Must be a valid session ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), userID, id); err != nil {
+		switch err {
+		case ErrSessionNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServiceTokenInput optionally narrows a service token to some of the
+// account's scopes
+type ServiceTokenInput struct {
+	Scopes    []Scope             `json:"scopes"`
+	Validator validator.Validator `json:"-"`
+}
+
+// ServiceToken issues a short-lived, narrowly scoped token to an internal
+// service authenticating with its account name and secret over basic auth
+func (h *Handler) ServiceToken(w http.ResponseWriter, r *http.Request) {
+	name, secret, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="service", charset="UTF-8"`)
+		http.Error(w, "service credentials required", http.StatusUnauthorized)
+		return
+	}
+
+	var input ServiceTokenInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	input.Validator.CheckField(validator.AllIn(input.Scopes, knownScopes...), "scopes", "Contains an unknown scope")
+	input.Validator.CheckField(validator.NoDuplicates(input.Scopes), "scopes", "Must not contain duplicates")
+	if input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	token, err := h.service.IssueServiceToken(name, secret, input.Scopes)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownService), errors.Is(err, ErrInvalidCredentials):
+			h.service.recordFailedLogin(r.Context(), "service_account", name)
+			http.Error(w, ErrInvalidCredentials.Error(), http.StatusUnauthorized)
+		case errors.Is(err, ErrInsufficientScope):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.service.record(r.Context(), audit.Event{
+		Action:     audit.ActionServiceToken,
+		ActorID:    "service:" + name,
+		TargetType: "service_account",
+		TargetID:   name,
+		After:      map[string]any{"scopes": token.Scopes, "expires_at": token.ExpiresAt},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
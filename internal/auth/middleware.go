@@ -4,10 +4,14 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
+
+	"big-spella-go/internal/audit"
 )
 
 const (
 	UserContextKey contextKey = "user"
+	principalKey   contextKey = "principal"
 )
 
 // Middleware creates a new middleware handler for authentication
@@ -16,6 +20,13 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 		// Get token from Authorization header
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
+			// Third-party integrations send an API key instead
+			if key := r.Header.Get(APIKeyHeader); key != "" {
+				if r, ok := s.authenticateAPIKey(w, r, key); ok {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -27,19 +38,49 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Validate token
-		user, err := s.ValidateToken(parts[1])
+		ctx, err := s.Authenticate(r.Context(), parts[1])
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
-
-		// Add user to context
-		ctx := context.WithValue(r.Context(), UserContextKey, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// Authenticate validates token and adds who it identifies to ctx, the way
+// Middleware does for a request's bearer token. It's for tokens that arrive
+// some other way, such as over a WebSocket.
+func (s *Service) Authenticate(ctx context.Context, token string) (context.Context, error) {
+	principal, err := s.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	ctx = SetPrincipalInContext(ctx, principal)
+	ctx = audit.WithActor(ctx, principal.ActorID())
+
+	// Service tokens carry no user; they only get what their scopes allow
+	if principal.Service != "" {
+		return ctx, nil
+	}
+
+	user, err := s.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	// A parent withdrawing consent, or an admin suspending the account,
+	// takes effect straight away
+	if err := s.checkConsent(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	// Add user to context
+	ctx = context.WithValue(ctx, UserContextKey, user)
+	return SetUserIDInContext(ctx, user.ID), nil
+}
+
 // RequireAuth creates a middleware that requires authentication
 func (s *Service) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -55,8 +96,8 @@ func (s *Service) RequireAuth(next http.Handler) http.Handler {
 // RequirePremium creates a middleware that requires premium subscription
 func (s *Service) RequirePremium(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		user := r.Context().Value(UserContextKey).(*User)
-		if !user.IsPremium {
+		user := GetUser(r.Context())
+		if user == nil || !user.HasPremium(time.Now()) {
 			http.Error(w, "premium subscription required", http.StatusForbidden)
 			return
 		}
@@ -64,6 +105,12 @@ func (s *Service) RequirePremium(next http.Handler) http.Handler {
 	})
 }
 
+// HasPremium reports whether the user has premium at now. Premium granted
+// with an end date, such as a season reward trial, lapses after it.
+func (u *User) HasPremium(now time.Time) bool {
+	return u.IsPremium && (u.PremiumUntil == nil || now.Before(*u.PremiumUntil))
+}
+
 // GetUser retrieves the user from the context
 func GetUser(ctx context.Context) *User {
 	user, _ := ctx.Value(UserContextKey).(*User)
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrNotOperator = errors.New("only operators can do that")
+
+// RequireOperator creates a middleware that limits next to platform
+// operators: players whose account is flagged as an admin's, and service
+// tokens with ScopeAdmin. Operators signing in as themselves are named in
+// the audit log, where a service token only names its service.
+func (s *Service) RequireOperator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := GetPrincipal(r.Context())
+		if principal == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if principal.HasScope(ScopeAdmin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if principal.UserID == "" || principal.APIKey != "" {
+			http.Error(w, ErrNotOperator.Error(), http.StatusForbidden)
+			return
+		}
+
+		operator, err := s.isOperator(r.Context(), principal.UserID)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !operator {
+			http.Error(w, ErrNotOperator.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isOperator reports whether userID's account is flagged as an admin's. It
+// is checked on every request, so taking the flag away takes effect
+// straight away.
+func (s *Service) isOperator(ctx context.Context, userID string) (bool, error) {
+	var operator bool
+	if err := s.db.GetContext(ctx, &operator, `
+		SELECT EXISTS (
+			SELECT 1 FROM users WHERE id = $1 AND is_admin AND deleted_at IS NULL
+		)`, userID); err != nil {
+		return false, fmt.Errorf("check operator: %w", err)
+	}
+	return operator, nil
+}
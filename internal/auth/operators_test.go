@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireOperator(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	handler := service.RequireOperator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(principal *Principal) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+		if principal != nil {
+			req = req.WithContext(SetPrincipalInContext(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(&Principal{Service: "console", Scopes: []Scope{ScopeAdmin}}))
+	assert.Equal(t, http.StatusForbidden, serve(&Principal{Service: "matchmaker", Scopes: []Scope{ScopeGamesRead}}))
+	assert.Equal(t, http.StatusForbidden, serve(&Principal{APIKey: "key-1", UserID: "user-1", Scopes: []Scope{ScopeWordsRead}}))
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+}
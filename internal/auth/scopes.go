@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Scope names a permission carried in a token's "scope" claim
+type Scope string
+
+const (
+	ScopeGamesRead     Scope = "games:read"
+	ScopeGamesWrite    Scope = "games:write"
+	ScopeEventsRead    Scope = "events:read"
+	ScopeEventsPublish Scope = "events:publish"
+	ScopeUsersRead     Scope = "users:read"
+	ScopeStatsWrite    Scope = "stats:write"
+
+	// ScopeTournamentsManage is for organizer tooling and is never granted
+	// to players
+	ScopeTournamentsManage Scope = "tournaments:manage"
+
+	// ScopeWordsManage is for admin tooling that curates categories and
+	// word lists
+	ScopeWordsManage Scope = "words:manage"
+
+	// ScopeAppealsModerate is for moderators deciding players' appeals of
+	// rulings and is never granted to players
+	ScopeAppealsModerate Scope = "appeals:moderate"
+
+	// ScopeSeasonsManage is for admin tooling that schedules and closes
+	// ranked seasons
+	ScopeSeasonsManage Scope = "seasons:manage"
+
+	// ScopeJobsManage is for admin tooling that inspects and retries
+	// background jobs and is never granted to players
+	ScopeJobsManage Scope = "jobs:manage"
+
+	// ScopeAuditRead is for admin tooling that reviews the audit log and is
+	// never granted to players
+	ScopeAuditRead Scope = "audit:read"
+
+	// ScopeAdmin is for the admin console operators moderate players and
+	// games from and is never granted to players
+	ScopeAdmin Scope = "admin"
+
+	// ScopeLeaderboardsRead and ScopeWordsRead are for API keys, letting
+	// bots and classroom tools read leaderboards and word data. Players
+	// reach the same routes with ScopeGamesRead.
+	ScopeLeaderboardsRead Scope = "leaderboards:read"
+	ScopeWordsRead        Scope = "words:read"
+
+	// ScopeGamesDelegate is for internal services, such as matchmaking
+	// workers and bot runners, that create, join and play games for players
+	// over gRPC. It is never granted to players.
+	ScopeGamesDelegate Scope = "games:delegate"
+)
+
+var knownScopes = []Scope{
+	ScopeGamesRead, ScopeGamesWrite, ScopeEventsRead, ScopeEventsPublish, ScopeUsersRead, ScopeStatsWrite,
+	ScopeTournamentsManage, ScopeWordsManage, ScopeAppealsModerate, ScopeSeasonsManage,
+	ScopeJobsManage, ScopeAuditRead, ScopeAdmin, ScopeLeaderboardsRead, ScopeWordsRead, ScopeGamesDelegate,
+}
+
+// APIKeyScopes are the scopes players may grant the API keys they create
+var APIKeyScopes = []Scope{ScopeLeaderboardsRead, ScopeWordsRead}
+
+// UserScopes are granted to every token issued to a signed-in user. Access
+// tokens minted before scopes existed carry no claim and are treated the
+// same way.
+var UserScopes = []Scope{ScopeGamesRead, ScopeGamesWrite, ScopeEventsRead, ScopeUsersRead}
+
+const ServiceTokenTTL = 15 * time.Minute
+
+var (
+	ErrInsufficientScope = errors.New("token lacks the required scope")
+	ErrUnknownService    = errors.New("unknown service account")
+)
+
+// ServiceAccount is an internal worker allowed to request narrowly scoped
+// tokens with its own credentials
+type ServiceAccount struct {
+	Name       string
+	SecretHash string
+	Scopes     []Scope
+}
+
+// Principal is whoever a validated token speaks for: a user or a service
+type Principal struct {
+	UserID  string  `json:"user_id,omitempty"`
+	Service string  `json:"service,omitempty"`
+	Scopes  []Scope `json:"scopes"`
+	// Child marks a child's account, which is kept out of social features
+	Child bool `json:"child,omitempty"`
+	// SessionID is the signed in device a user's token was issued to
+	SessionID string `json:"session_id,omitempty"`
+	// TwoFactorAt is when the user last confirmed a two-factor code, if
+	// they did to get their token
+	TwoFactorAt time.Time `json:"-"`
+	// APIKey is the ID of the API key a third-party integration sent
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope Scope) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ActorID names the principal in the audit log
+func (p *Principal) ActorID() string {
+	if p.Service != "" {
+		return "service:" + p.Service
+	}
+	if p.APIKey != "" {
+		return "apikey:" + p.APIKey
+	}
+	return p.UserID
+}
+
+// ServiceToken is the response to a successful service-account token request
+type ServiceToken struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Scopes      []Scope   `json:"scopes"`
+}
+
+func formatScopes(scopes []Scope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseScopes(claim string) []Scope {
+	var scopes []Scope
+	for _, s := range strings.Fields(claim) {
+		scopes = append(scopes, Scope(s))
+	}
+	return scopes
+}
+
+// RegisterServiceAccount makes account available to IssueServiceToken. It
+// is meant to be called during startup.
+func (s *Service) RegisterServiceAccount(account ServiceAccount) {
+	if s.serviceAccounts == nil {
+		s.serviceAccounts = make(map[string]ServiceAccount)
+	}
+	s.serviceAccounts[account.Name] = account
+}
+
+// IssueServiceToken checks the account's secret and returns a short-lived
+// token limited to the requested scopes, or to every scope the account
+// holds when none are requested
+func (s *Service) IssueServiceToken(name, secret string, requested []Scope) (*ServiceToken, error) {
+	account, ok := s.serviceAccounts[name]
+	if !ok {
+		return nil, ErrUnknownService
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(account.SecretHash), []byte(secret)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	scopes := requested
+	if len(scopes) == 0 {
+		scopes = account.Scopes
+	}
+	for _, scope := range scopes {
+		allowed := &Principal{Scopes: account.Scopes}
+		if !allowed.HasScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInsufficientScope, scope)
+		}
+	}
+
+	expiresAt := time.Now().Add(ServiceTokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     "service:" + account.Name,
+		"service": account.Name,
+		"scope":   formatScopes(scopes),
+		"exp":     expiresAt.Unix(),
+	})
+	signed, err := token.SignedString(s.jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("sign service token: %w", err)
+	}
+
+	return &ServiceToken{
+		AccessToken: signed,
+		ExpiresAt:   expiresAt,
+		Scopes:      scopes,
+	}, nil
+}
+
+// ParseToken validates an access token and returns its principal without
+// touching the database
+func (s *Service) ParseToken(tokenString string) (*Principal, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Access tokens are untyped; refresh and challenge tokens aren't them
+	if typ, _ := claims["typ"].(string); typ != "" {
+		return nil, ErrInvalidToken
+	}
+	if _, scoped := claims["scope"]; !scoped && !s.legacyAccessToken(claims) {
+		return nil, ErrInvalidToken
+	}
+
+	principal := &Principal{}
+	if service, ok := claims["service"].(string); ok && service != "" {
+		principal.Service = service
+	} else if userID, ok := claims["user_id"].(string); ok && userID != "" {
+		principal.UserID = userID
+		principal.Child, _ = claims["child"].(bool)
+		principal.SessionID, _ = claims["sid"].(string)
+		if tfa, ok := claims["tfa"].(float64); ok {
+			principal.TwoFactorAt = time.Unix(int64(tfa), 0)
+		}
+	} else {
+		return nil, ErrInvalidToken
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = parseScopes(scope)
+	} else if principal.UserID != "" {
+		principal.Scopes = UserScopes
+	}
+
+	return principal, nil
+}
+
+// legacyAccessToken reports whether claims without a type or scopes are
+// those of an access token minted before tokens carried either. The refresh
+// tokens minted then look the same, but last 30 days where access tokens
+// expire within jwtExpiry.
+func (s *Service) legacyAccessToken(claims jwt.MapClaims) bool {
+	exp, err := claims.GetExpirationTime()
+	return err == nil && exp != nil && !exp.After(time.Now().Add(s.jwtExpiry))
+}
+
+// GetPrincipal retrieves the authenticated principal from the context
+func GetPrincipal(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey).(*Principal)
+	return principal
+}
+
+// SetPrincipalInContext sets the authenticated principal in the context
+func SetPrincipalInContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// HasScope reports whether the request's principal holds scope
+func HasScope(ctx context.Context, scope Scope) bool {
+	return GetPrincipal(ctx).HasScope(scope)
+}
+
+// RequireScope creates a middleware that rejects principals without scope
+func (s *Service) RequireScope(scope Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := GetPrincipal(r.Context())
+		if principal == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if !principal.HasScope(scope) {
+			http.Error(w, ErrInsufficientScope.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAnyScope creates a middleware that rejects principals holding none
+// of scopes, for routes players and API keys reach with different scopes
+func (s *Service) RequireAnyScope(scopes []Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := GetPrincipal(r.Context())
+		if principal == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		for _, scope := range scopes {
+			if principal.HasScope(scope) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		http.Error(w, ErrInsufficientScope.Error(), http.StatusForbidden)
+	})
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newScopedService(t *testing.T) *Service {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	service.RegisterServiceAccount(ServiceAccount{
+		Name:       "matchmaker",
+		SecretHash: string(hash),
+		Scopes:     []Scope{ScopeGamesRead, ScopeEventsPublish},
+	})
+	return service
+}
+
+func TestIssueServiceToken(t *testing.T) {
+	service := newScopedService(t)
+
+	token, err := service.IssueServiceToken("matchmaker", "s3cret", []Scope{ScopeGamesRead})
+	require.NoError(t, err)
+
+	principal, err := service.ParseToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "matchmaker", principal.Service)
+	assert.Empty(t, principal.UserID)
+	assert.True(t, principal.HasScope(ScopeGamesRead))
+	assert.False(t, principal.HasScope(ScopeEventsPublish))
+	assert.False(t, principal.HasScope(ScopeGamesWrite))
+
+	// Service tokens can't be refreshed into a user session
+	_, err = service.RefreshToken(context.Background(), token.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestIssueServiceTokenRejections(t *testing.T) {
+	service := newScopedService(t)
+
+	_, err := service.IssueServiceToken("matchmaker", "wrong", nil)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = service.IssueServiceToken("stats", "s3cret", nil)
+	assert.ErrorIs(t, err, ErrUnknownService)
+
+	_, err = service.IssueServiceToken("matchmaker", "s3cret", []Scope{ScopeGamesWrite})
+	assert.ErrorIs(t, err, ErrInsufficientScope)
+}
+
+func TestUserTokensCarryUserScopes(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+
+	tokens, err := service.generateTokenPair(&User{ID: "user-1", Username: "speller"}, "session-1", time.Time{})
+	require.NoError(t, err)
+
+	principal, err := service.ParseToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", principal.UserID)
+	assert.Equal(t, "session-1", principal.SessionID)
+	assert.ElementsMatch(t, UserScopes, principal.Scopes)
+
+	_, err = service.ParseToken(tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestUntypedTokens(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+	sign := func(claims jwt.MapClaims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		require.NoError(t, err)
+		return signed
+	}
+
+	// Access and refresh tokens minted before tokens were typed
+	access := sign(jwt.MapClaims{"user_id": "user-1", "username": "speller", "exp": time.Now().Add(time.Hour).Unix()})
+	refresh := sign(jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(30 * 24 * time.Hour).Unix()})
+
+	principal, err := service.ParseToken(access)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, UserScopes, principal.Scopes)
+
+	_, err = service.ParseToken(refresh)
+	assert.ErrorIs(t, err, ErrInvalidToken, "old refresh tokens aren't access tokens")
+
+	_, err = service.RefreshToken(context.Background(), access)
+	assert.ErrorIs(t, err, ErrInvalidToken, "old access tokens can't be refreshed")
+}
+
+func TestPrincipalActorID(t *testing.T) {
+	assert.Equal(t, "user-1", (&Principal{UserID: "user-1"}).ActorID())
+	assert.Equal(t, "service:matchmaker", (&Principal{Service: "matchmaker"}).ActorID())
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/crypto/bcrypt"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/queries"
+	"big-spella-go/internal/smtp"
+	"big-spella-go/internal/validator"
 )
 
 var (
@@ -17,12 +22,24 @@ var (
 	ErrUserExists        = errors.New("user already exists")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrInvalidToken      = errors.New("invalid or expired token")
+	ErrAccountSuspended  = errors.New("account is suspended")
 )
 
+const refreshTokenType = "refresh"
+
 type Service struct {
 	db         *sqlx.DB
+	queries    *queries.Queries
 	jwtSecret  []byte
 	jwtExpiry  time.Duration
+
+	serviceAccounts map[string]ServiceAccount
+	audit           audit.Recorder
+	consent         ConsentRequester
+	sendEmail       smtp.SendEmail
+	emailConfirmURL string
+	apiKeys         APIKeyAuthenticator
+	registrations   RegistrationObserver
 }
 
 type User struct {
@@ -34,6 +51,8 @@ type User struct {
 	IsPremium       bool       `db:"is_premium" json:"is_premium"`
 	PremiumUntil    *time.Time `db:"premium_until" json:"premium_until,omitempty"`
 	StripeCustomerID *string    `db:"stripe_customer_id" json:"stripe_customer_id,omitempty"`
+	// IsChild marks the account of a player under ChildAge
+	IsChild         bool        `db:"is_child" json:"is_child"`
 	CreatedAt       time.Time   `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
 }
@@ -42,34 +61,89 @@ type RegisterInput struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Age is optional, but players who give one under ChildAge must name a
+	// parent to consent to their account
+	Age         *int                `json:"age,omitempty"`
+	ParentEmail string              `json:"parent_email,omitempty"`
+	Validator   validator.Validator `json:"-"`
+}
+
+// isChild reports whether the account being registered is a child's
+func (i RegisterInput) isChild() bool {
+	return i.Age != nil && *i.Age < ChildAge
 }
 
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// DeviceName labels the session in the player's list of signed in
+	// devices, defaulting to UserAgent
+	DeviceName string              `json:"device_name,omitempty"`
+	UserAgent  string              `json:"-"`
+	Validator  validator.Validator `json:"-"`
 }
 
+// TokenPair is what signing in returns. Players with two-factor
+// authentication on get a ChallengeToken instead, which VerifyTwoFactor
+// trades for tokens along with a code.
 type TokenPair struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken       string `json:"access_token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
 }
 
 func NewService(db *sqlx.DB, jwtSecret []byte, jwtExpiry time.Duration) *Service {
 	return &Service{
 		db:         db,
+		queries:    queries.New(db),
 		jwtSecret:  jwtSecret,
 		jwtExpiry:  jwtExpiry,
 	}
 }
 
+// SetQueries runs the service's users queries through q, such as one whose
+// statements are prepared. It is meant to be called during startup.
+func (s *Service) SetQueries(q *queries.Queries) {
+	s.queries = q
+}
+
+// SetAuditLog records logins and service token grants to log. It is meant
+// to be called during startup.
+func (s *Service) SetAuditLog(log audit.Recorder) {
+	s.audit = log
+}
+
+// RegistrationObserver is told about each account as it's registered
+type RegistrationObserver interface {
+	PlayerRegistered(ctx context.Context, user *User)
+}
+
+// SetRegistrationObserver tells observer about new accounts. It is meant to
+// be called during startup.
+func (s *Service) SetRegistrationObserver(observer RegistrationObserver) {
+	s.registrations = observer
+}
+
+func (s *Service) record(ctx context.Context, event audit.Event) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event)
+	}
+}
+
+// recordFailedLogin notes a rejected sign in against the identity tried
+func (s *Service) recordFailedLogin(ctx context.Context, targetType, targetID string) {
+	s.record(ctx, audit.Event{Action: audit.ActionLoginFailed, TargetType: targetType, TargetID: targetID})
+}
+
 func (s *Service) Register(ctx context.Context, input RegisterInput) (*User, error) {
+	if input.isChild() && s.consent == nil {
+		return nil, ErrChildSignupUnavailable
+	}
+
 	// Check if user exists
 	var exists bool
-	err := s.db.GetContext(ctx, &exists, `
-		SELECT EXISTS(
-			SELECT 1 FROM users WHERE email = $1 OR username = $2
-		)
-	`, input.Email, input.Username)
+	err := s.queries.UserExists(ctx, &exists, queries.UserExistsParams{Email: input.Email, Username: input.Username})
 	if err != nil {
 		return nil, fmt.Errorf("check user exists: %w", err)
 	}
@@ -89,30 +163,42 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) (*User, err
 		Email:        input.Email,
 		PasswordHash: string(hash),
 		ELO:         1200, // Starting ELO
+		IsChild:      input.isChild(),
 	}
-
-	query := `
-		INSERT INTO users (username, email, password_hash, elo)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at, updated_at
-	`
-	err = s.db.GetContext(ctx, user, query,
-		user.Username, user.Email, user.PasswordHash, user.ELO,
-	)
+	err = s.queries.CreateUser(ctx, user, queries.CreateUserParams{
+		Username:     user.Username,
+		Email:        user.Email,
+		PasswordHash: user.PasswordHash,
+		Elo:          user.ELO,
+		IsChild:      user.IsChild,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("insert user: %w", err)
 	}
 
+	if user.IsChild {
+		if err := s.consent.RequestConsent(ctx, user, input.ParentEmail); err != nil {
+			// Nobody could ever consent to the account, so it goes and the
+			// child can try again
+			if delErr := s.queries.DeleteUser(ctx, user.ID); delErr != nil {
+				return nil, fmt.Errorf("remove user: %w", errors.Join(err, delErr))
+			}
+			return nil, fmt.Errorf("request parental consent: %w", err)
+		}
+	}
+
+	if s.registrations != nil {
+		s.registrations.PlayerRegistered(ctx, user)
+	}
 	return user, nil
 }
 
 func (s *Service) Login(ctx context.Context, input LoginInput) (*TokenPair, error) {
 	user := &User{}
-	err := s.db.GetContext(ctx, user, `
-		SELECT * FROM users WHERE email = $1
-	`, input.Email)
+	err := s.queries.GetUserByEmail(ctx, user, input.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			s.recordFailedLogin(ctx, "email", input.Email)
 			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("get user: %w", err)
@@ -121,11 +207,42 @@ func (s *Service) Login(ctx context.Context, input LoginInput) (*TokenPair, erro
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password))
 	if err != nil {
+		s.recordFailedLogin(ctx, "email", input.Email)
 		return nil, ErrInvalidCredentials
 	}
 
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.checkConsent(ctx, user); err != nil {
+		return nil, err
+	}
+
+	device := deviceName(input.DeviceName, input.UserAgent)
+	twoFactor, err := s.TwoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor {
+		challenge, err := s.challengeToken(user.ID, device)
+		if err != nil {
+			return nil, err
+		}
+		return &TokenPair{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	sessionID, err := s.startSession(ctx, user.ID, device)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate tokens
-	return s.generateTokenPair(user)
+	tokens, err := s.generateTokenPair(user, sessionID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, audit.Event{Action: audit.ActionLogin, ActorID: user.ID, TargetType: "user", TargetID: user.ID})
+	return tokens, nil
 }
 
 func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
@@ -145,6 +262,22 @@ func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*Token
 		return nil, ErrInvalidToken
 	}
 
+	// Access and service tokens can't be traded in for a new pair
+	if _, isService := claims["service"]; isService {
+		return nil, ErrInvalidToken
+	}
+	if typ, ok := claims["typ"].(string); ok && typ != refreshTokenType {
+		return nil, ErrInvalidToken
+	}
+	if _, hasScope := claims["scope"]; hasScope {
+		return nil, ErrInvalidToken
+	}
+	// Untyped refresh tokens from before tokens were typed are still honoured,
+	// until their last jwtExpiry, but not the access tokens minted with them
+	if _, typed := claims["typ"]; !typed && s.legacyAccessToken(claims) {
+		return nil, ErrInvalidToken
+	}
+
 	// Get user
 	userID, ok := claims["user_id"].(string)
 	if !ok {
@@ -152,9 +285,7 @@ func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*Token
 	}
 
 	user := &User{}
-	err = s.db.GetContext(ctx, user, `
-		SELECT * FROM users WHERE id = $1
-	`, userID)
+	err = s.queries.GetActiveUser(ctx, user, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
@@ -162,28 +293,101 @@ func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*Token
 		return nil, fmt.Errorf("get user: %w", err)
 	}
 
+	if err := s.checkSuspension(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.checkConsent(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Tokens from before sessions were tracked join one on their first
+	// refresh, so they can be revoked like any other
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		// Unless the password has changed since, which signs every other
+		// device out
+		changed, err := s.passwordChanged(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			return nil, ErrInvalidToken
+		}
+		sessionID, err = s.startSession(ctx, user.ID, deviceName("", ""))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = s.touchSession(ctx, sessionID, user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A confirmed code still counts for as long as it's recent
+	var verifiedAt time.Time
+	if tfa, ok := claims["tfa"].(float64); ok {
+		verifiedAt = time.Unix(int64(tfa), 0)
+	}
+
 	// Generate new token pair
-	return s.generateTokenPair(user)
+	return s.generateTokenPair(user, sessionID, verifiedAt)
 }
 
-func (s *Service) generateTokenPair(user *User) (*TokenPair, error) {
+// checkSuspension returns ErrAccountSuspended while an admin has the user
+// suspended or banned
+func (s *Service) checkSuspension(ctx context.Context, userID string) error {
+	var suspended bool
+	if err := s.db.GetContext(ctx, &suspended, `
+		SELECT EXISTS (
+			SELECT 1 FROM account_suspensions
+			WHERE user_id = $1 AND lifted_at IS NULL AND (ends_at IS NULL OR ends_at > NOW())
+		)`, userID); err != nil {
+		return fmt.Errorf("check suspension: %w", err)
+	}
+	if suspended {
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// generateTokenPair issues tokens to user for the device signed in as
+// sessionID, noting when they last confirmed a two-factor code if they have
+func (s *Service) generateTokenPair(user *User, sessionID string, verifiedAt time.Time) (*TokenPair, error) {
 	// Generate access token
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		"user_id":    user.ID,
 		"username":   user.Username,
 		"is_premium": user.IsPremium,
+		"scope":      formatScopes(UserScopes),
 		"exp":        time.Now().Add(s.jwtExpiry).Unix(),
-	})
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+	if !verifiedAt.IsZero() {
+		claims["tfa"] = verifiedAt.Unix()
+	}
+	if user.IsChild {
+		claims["child"] = true
+	}
+	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	accessTokenString, err := accessToken.SignedString(s.jwtSecret)
 	if err != nil {
 		return nil, fmt.Errorf("sign access token: %w", err)
 	}
 
 	// Generate refresh token (valid for 30 days)
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	refreshClaims := jwt.MapClaims{
 		"user_id": user.ID,
+		"typ":     refreshTokenType,
+		"sid":     sessionID,
 		"exp":     time.Now().Add(30 * 24 * time.Hour).Unix(),
-	})
+	}
+	if !verifiedAt.IsZero() {
+		refreshClaims["tfa"] = verifiedAt.Unix()
+	}
+	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 	refreshTokenString, err := refreshToken.SignedString(s.jwtSecret)
 	if err != nil {
 		return nil, fmt.Errorf("sign refresh token: %w", err)
@@ -196,28 +400,16 @@ func (s *Service) generateTokenPair(user *User) (*TokenPair, error) {
 }
 
 func (s *Service) ValidateToken(tokenString string) (*User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
-	})
+	principal, err := s.ParseToken(tokenString)
 	if err != nil {
-		return nil, ErrInvalidToken
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return nil, ErrInvalidToken
+		return nil, err
 	}
-
-	userID, ok := claims["user_id"].(string)
-	if !ok {
+	if principal.UserID == "" {
 		return nil, ErrInvalidToken
 	}
 
 	user := &User{}
-	err = s.db.Get(user, `SELECT * FROM users WHERE id = $1`, userID)
+	err = s.db.Get(user, `SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL`, principal.UserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrUserNotFound
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"big-spella-go/internal/audit"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// maxDeviceName bounds the device names players give, and the user agents
+// standing in for them
+const maxDeviceName = 100
+
+// Session is a device signed in to a player's account. Every refresh token
+// belongs to one, and revoking it stops them being traded in.
+type Session struct {
+	ID         string    `db:"id" json:"id"`
+	DeviceName string    `db:"device_name" json:"device_name"`
+	IP         string    `db:"ip" json:"ip"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at" json:"last_seen_at"`
+	// Current marks the session the request listing them was made from
+	Current bool `db:"-" json:"current"`
+}
+
+// deviceName picks what to call a device signing in, falling back from the
+// name the player gave to its user agent
+func deviceName(name, userAgent string) string {
+	if name == "" {
+		name = userAgent
+	}
+	if name == "" {
+		return "Unknown device"
+	}
+	if r := []rune(name); len(r) > maxDeviceName {
+		return string(r[:maxDeviceName])
+	}
+	return name
+}
+
+// startSession records a device signing in to userID's account from the
+// address noted on ctx
+func (s *Service) startSession(ctx context.Context, userID, device string) (string, error) {
+	var id string
+	if err := s.db.GetContext(ctx, &id, `
+		INSERT INTO auth_sessions (user_id, device_name, ip)
+		VALUES ($1, $2, $3)
+		RETURNING id`, userID, device, audit.IP(ctx)); err != nil {
+		return "", fmt.Errorf("start session: %w", err)
+	}
+	return id, nil
+}
+
+// touchSession notes the session being used again, returning
+// ErrInvalidToken if it has been revoked
+func (s *Service) touchSession(ctx context.Context, sessionID, userID string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE auth_sessions
+		SET last_seen_at = NOW(), ip = COALESCE(NULLIF($3, ''), ip)
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID, audit.IP(ctx))
+	if err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	} else if n == 0 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// ListSessions returns the devices signed in to userID's account, most
+// recently used first, marking currentID as the current one
+func (s *Service) ListSessions(ctx context.Context, userID, currentID string) ([]Session, error) {
+	sessions := []Session{}
+	if err := s.db.SelectContext(ctx, &sessions, `
+		SELECT id, device_name, COALESCE(ip, '') AS ip, created_at, last_seen_at
+		FROM auth_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC`, userID); err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a device out of userID's account. Its refresh tokens
+// stop working straight away; access tokens already issued run out on
+// their own.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	var device string
+	err := s.db.GetContext(ctx, &device, `
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		RETURNING device_name`, sessionID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	s.record(ctx, audit.Event{
+		Action:     audit.ActionSessionRevoked,
+		ActorID:    userID,
+		TargetType: "session",
+		TargetID:   sessionID,
+		Before:     map[string]string{"device_name": device},
+	})
+	return nil
+}
@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceName(t *testing.T) {
+	assert.Equal(t, "Ada's phone", deviceName("Ada's phone", "Mozilla/5.0"))
+	assert.Equal(t, "Mozilla/5.0", deviceName("", "Mozilla/5.0"))
+	assert.Equal(t, "Unknown device", deviceName("", ""))
+	assert.Len(t, []rune(deviceName("", strings.Repeat("é", 300))), maxDeviceName)
+}
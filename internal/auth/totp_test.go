@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA-1 key from RFC 6238's test vectors
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	// The RFC's eight digit codes, cut to the six apps show
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		counter, ok := validateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		assert.True(t, ok, tt.code)
+		assert.Equal(t, uint64(tt.unix/30), counter)
+	}
+
+	_, ok := validateTOTP(rfc6238Secret, "287082", time.Unix(59+30, 0))
+	assert.True(t, ok, "the last period's code is still accepted")
+	_, ok = validateTOTP(rfc6238Secret, "287082", time.Unix(59+90, 0))
+	assert.False(t, ok)
+	_, ok = validateTOTP(rfc6238Secret, "28708", time.Unix(59, 0))
+	assert.False(t, ok)
+	_, ok = validateTOTP("not base32!", "287082", time.Unix(59, 0))
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret, err := newTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri, err := url.Parse(provisioningURI(secret, "speller"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Big Spella:speller", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Big Spella", uri.Query().Get("issuer"))
+}
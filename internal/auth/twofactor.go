@@ -0,0 +1,382 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+)
+
+var (
+	ErrTwoFactorEnabled        = errors.New("two-factor authentication is already on")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication isn't set up")
+	ErrInvalidCode             = errors.New("invalid verification code")
+	ErrTwoFactorLocked         = errors.New("too many invalid codes, try again later")
+	ErrRecentTwoFactorRequired = errors.New("confirm a two-factor code to continue")
+)
+
+const (
+	challengeTokenType = "2fa_challenge"
+	// ChallengeTTL is how long players have after their password is
+	// accepted to give a code
+	ChallengeTTL = 5 * time.Minute
+	// RecentTwoFactor is how long after confirming a code players with
+	// two-factor authentication on can make sensitive changes
+	RecentTwoFactor = 15 * time.Minute
+
+	recoveryCodeCount = 10
+	// Players giving maxFailedCodes invalid codes in a row are locked out of
+	// giving any more for codeLockout
+	maxFailedCodes = 5
+	codeLockout    = 15 * time.Minute
+)
+
+// TwoFactorEnrollment is what an authenticator app needs to start
+// generating codes for a player
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type twoFactor struct {
+	Secret         string     `db:"secret"`
+	EnabledAt      *time.Time `db:"enabled_at"`
+	LastCounter    int64      `db:"last_counter"`
+	FailedAttempts int        `db:"failed_attempts"`
+	LockedUntil    *time.Time `db:"locked_until"`
+}
+
+// TwoFactorEnabled reports whether userID has two-factor authentication on
+func (s *Service) TwoFactorEnabled(ctx context.Context, userID string) (bool, error) {
+	var enabled bool
+	if err := s.db.GetContext(ctx, &enabled, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_two_factor WHERE user_id = $1 AND enabled_at IS NOT NULL
+		)`, userID); err != nil {
+		return false, fmt.Errorf("check two-factor: %w", err)
+	}
+	return enabled, nil
+}
+
+// EnrollTwoFactor generates a new secret for user. Two-factor
+// authentication only comes on once ConfirmTwoFactor is given a code from
+// it; enrolling again before then replaces the secret.
+func (s *Service) EnrollTwoFactor(ctx context.Context, user *User) (*TwoFactorEnrollment, error) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_two_factor (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_counter = 0, failed_attempts = 0, locked_until = NULL, created_at = NOW()
+		WHERE user_two_factor.enabled_at IS NULL`, user.ID, secret)
+	if err != nil {
+		return nil, fmt.Errorf("enroll two-factor: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("enroll two-factor: %w", err)
+	} else if n == 0 {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: provisioningURI(secret, user.Username),
+	}, nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on once the player shows
+// their app gives valid codes, returning the recovery codes they can sign
+// in with if they lose it. The codes aren't kept, so can't be shown again.
+func (s *Service) ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkCode(ctx, userID, code, false, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE user_two_factor SET enabled_at = NOW() WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("enable two-factor: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("clear recovery codes: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO two_factor_recovery_codes (user_id, code_hash)
+			SELECT $1, UNNEST($2::TEXT[])`, userID, pq.Array(hashes)); err != nil {
+			return fmt.Errorf("save recovery codes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off given a current
+// code or an unused recovery code
+func (s *Service) DisableTwoFactor(ctx context.Context, userID, code string) error {
+	return s.checkCode(ctx, userID, code, true, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("clear recovery codes: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("disable two-factor: %w", err)
+		}
+		return nil
+	})
+}
+
+// VerifyTwoFactor completes a login Login challenged for a code, signing
+// the device in
+func (s *Service) VerifyTwoFactor(ctx context.Context, challengeToken, code string) (*TokenPair, error) {
+	userID, device, err := s.parseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCode(ctx, userID, code, true, nil); err != nil {
+		return nil, err
+	}
+
+	user := &User{}
+	err = s.queries.GetActiveUser(ctx, user, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	sessionID, err := s.startSession(ctx, user.ID, device)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.generateTokenPair(user, sessionID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, audit.Event{Action: audit.ActionLogin, ActorID: user.ID, TargetType: "user", TargetID: user.ID})
+	return tokens, nil
+}
+
+// StepUpTwoFactor reissues principal's tokens marked as having confirmed a
+// code just now, for the sensitive changes RequireRecentTwoFactor guards
+func (s *Service) StepUpTwoFactor(ctx context.Context, principal *Principal, code string) (*TokenPair, error) {
+	if err := s.checkCode(ctx, principal.UserID, code, true, nil); err != nil {
+		return nil, err
+	}
+
+	user := &User{}
+	if err := s.queries.GetActiveUser(ctx, user, principal.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	return s.generateTokenPair(user, principal.SessionID, time.Now())
+}
+
+// checkCode checks code against userID's authenticator, or with enabled,
+// against their recovery codes too, running then in the same transaction if
+// it's valid. Invalid codes count towards a lockout, and each code
+// only works once.
+func (s *Service) checkCode(ctx context.Context, userID, code string, enabled bool, then func(*sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tf twoFactor
+	err = tx.GetContext(ctx, &tf, `
+		SELECT secret, enabled_at, last_counter, failed_attempts, locked_until
+		FROM user_two_factor WHERE user_id = $1
+		FOR UPDATE`, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTwoFactorNotEnrolled
+		}
+		return fmt.Errorf("get two-factor: %w", err)
+	}
+	switch {
+	case enabled && tf.EnabledAt == nil:
+		return ErrTwoFactorNotEnrolled
+	case !enabled && tf.EnabledAt != nil:
+		return ErrTwoFactorEnabled
+	case tf.LockedUntil != nil && time.Now().Before(*tf.LockedUntil):
+		return ErrTwoFactorLocked
+	}
+
+	valid := false
+	if counter, ok := validateTOTP(tf.Secret, code, time.Now()); ok && int64(counter) > tf.LastCounter {
+		if _, err := tx.ExecContext(ctx, `UPDATE user_two_factor SET last_counter = $2 WHERE user_id = $1`, userID, int64(counter)); err != nil {
+			return fmt.Errorf("note code used: %w", err)
+		}
+		valid = true
+	} else if enabled {
+		if valid, err = useRecoveryCode(ctx, tx, userID, code); err != nil {
+			return err
+		}
+	}
+
+	if !valid {
+		failed, lockedUntil := tf.FailedAttempts+1, (*time.Time)(nil)
+		if failed >= maxFailedCodes {
+			until := time.Now().Add(codeLockout)
+			failed, lockedUntil = 0, &until
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_two_factor SET failed_attempts = $2, locked_until = $3 WHERE user_id = $1`,
+			userID, failed, lockedUntil); err != nil {
+			return fmt.Errorf("note invalid code: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit transaction: %w", err)
+		}
+		return ErrInvalidCode
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_two_factor SET failed_attempts = 0, locked_until = NULL WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("reset invalid codes: %w", err)
+	}
+	if then != nil {
+		if err := then(tx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// useRecoveryCode spends one of userID's recovery codes, reporting whether
+// code was one they hadn't used
+func useRecoveryCode(ctx context.Context, tx *sqlx.Tx, userID, code string) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE two_factor_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	return n > 0, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes generates a set of recovery codes, formatted like
+// "abcde-fghij", and the hashes they're kept as
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes code however the player typed it. Recovery codes
+// are random enough that a plain hash keeps them safe.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// challengeToken lets the holder of userID's password finish signing in on
+// device by giving a code. It can't be used as an access or refresh token.
+func (s *Service) challengeToken(userID, device string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"typ":     challengeTokenType,
+		"device":  device,
+		"exp":     time.Now().Add(ChallengeTTL).Unix(),
+	})
+	signed, err := token.SignedString(s.jwtSecret)
+	if err != nil {
+		return "", fmt.Errorf("sign challenge token: %w", err)
+	}
+	return signed, nil
+}
+
+func (s *Service) parseChallengeToken(tokenString string) (userID, device string, err error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return "", "", ErrInvalidToken
+	}
+	if typ, _ := claims["typ"].(string); typ != challengeTokenType {
+		return "", "", ErrInvalidToken
+	}
+	userID, _ = claims["user_id"].(string)
+	if userID == "" {
+		return "", "", ErrInvalidToken
+	}
+	device, _ = claims["device"].(string)
+	return userID, device, nil
+}
+
+// RequireRecentTwoFactor creates a middleware that keeps players with
+// two-factor authentication on out of next, such as changing their email,
+// unless they confirmed a code within RecentTwoFactor. It's meant to go
+// after RequireAuth or RequireScope.
+func (s *Service) RequireRecentTwoFactor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := GetPrincipal(r.Context())
+		if principal == nil || principal.UserID == "" {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if time.Since(principal.TwoFactorAt) <= RecentTwoFactor {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		enabled, err := s.TwoFactorEnabled(r.Context(), principal.UserID)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if enabled {
+			http.Error(w, ErrRecentTwoFactorRequired.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	assert.Equal(t, hashes[0], hashRecoveryCode(codes[0]))
+
+	// However the player types it
+	upper := []rune(codes[0])
+	for i, r := range upper {
+		if r >= 'a' && r <= 'z' {
+			upper[i] = r - 'a' + 'A'
+		}
+	}
+	assert.Equal(t, hashes[0], hashRecoveryCode(string(upper)))
+	assert.Equal(t, hashes[0], hashRecoveryCode(codes[0][:5]+" "+codes[0][6:]))
+	assert.NotEqual(t, hashes[0], hashes[1])
+}
+
+func TestChallengeTokens(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+
+	challenge, err := service.challengeToken("user-1", "Ada's phone")
+	require.NoError(t, err)
+
+	userID, device, err := service.parseChallengeToken(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+	assert.Equal(t, "Ada's phone", device)
+
+	// A password alone gets a player no further
+	_, err = service.ParseToken(challenge)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.RefreshToken(context.Background(), challenge)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	tokens, err := service.generateTokenPair(&User{ID: "user-1", Username: "speller"}, "", time.Time{})
+	require.NoError(t, err)
+	_, _, err = service.parseChallengeToken(tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRequireRecentTwoFactor(t *testing.T) {
+	service := NewService(nil, []byte("test-secret"), time.Hour)
+
+	verifiedAt := time.Now().Add(-time.Minute)
+	tokens, err := service.generateTokenPair(&User{ID: "user-1", Username: "speller"}, "session-1", verifiedAt)
+	require.NoError(t, err)
+	principal, err := service.ParseToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, verifiedAt.Unix(), principal.TwoFactorAt.Unix())
+
+	reached := false
+	handler := service.RequireRecentTwoFactor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "/auth/email", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(SetPrincipalInContext(req.Context(), principal)))
+	assert.True(t, reached, "a recent code needs no lookup")
+
+	reached = false
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.False(t, reached)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
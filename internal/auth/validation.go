@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"big-spella-go/internal/password"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+var rgxUsername = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// failedValidation responds with 422 and the per-field errors in v
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (i *RegisterInput) validate() {
+	v := &i.Validator
+	v.CheckField(validator.NotBlank(i.Username), "username", "Username is required")
+	v.CheckField(validator.Between(len(i.Username), 3, 32), "username", "Must be between 3 and 32 characters")
+	v.CheckField(validator.Matches(i.Username, rgxUsername), "username", "May only contain letters, digits, '.', '_' and '-'")
+
+	v.CheckField(validator.NotBlank(i.Email), "email", "Email is required")
+	v.CheckField(validator.IsEmail(i.Email), "email", "Must be a valid email address")
+
+	checkNewPassword(v, "password", i.Password)
+
+	if i.Age != nil {
+		v.CheckField(validator.Between(*i.Age, 1, 120), "age", "Must be between 1 and 120")
+	}
+	if i.isChild() {
+		v.CheckField(validator.IsEmail(i.ParentEmail), "parent_email", "A parent's email address is required for players under 13")
+		v.CheckField(!strings.EqualFold(i.ParentEmail, i.Email), "parent_email", "Must be a parent's own email address")
+	}
+}
+
+// checkNewPassword checks a password being set meets the rules for one
+func checkNewPassword(v *validator.Validator, key, pw string) {
+	v.CheckField(pw != "", key, "Password is required")
+	v.CheckField(len(pw) >= 8, key, "Password is too short")
+	v.CheckField(len(pw) <= 72, key, "Password is too long")
+	v.CheckField(validator.NotIn(pw, password.CommonPasswords...), key, "Password is too common")
+}
+
+func (i *ChangePasswordInput) validate() {
+	i.Validator.CheckField(i.CurrentPassword != "", "current_password", "Current password is required")
+	checkNewPassword(&i.Validator, "new_password", i.NewPassword)
+	i.Validator.CheckField(i.NewPassword != i.CurrentPassword, "new_password", "Must be different from the current password")
+}
+
+func (i *ChangeEmailInput) validate() {
+	i.Validator.CheckField(validator.NotBlank(i.Email), "email", "Email is required")
+	i.Validator.CheckField(validator.IsEmail(i.Email), "email", "Must be a valid email address")
+	i.Validator.CheckField(i.Password != "", "password", "Password is required")
+}
+
+func (i *LoginInput) validate() {
+	i.Validator.CheckField(validator.NotBlank(i.Email), "email", "Email is required")
+	i.Validator.CheckField(i.Password != "", "password", "Password is required")
+	i.Validator.CheckField(validator.MaxRunes(i.DeviceName, maxDeviceName), "device_name", "Must be no more than 100 characters")
+}
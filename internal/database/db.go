@@ -2,58 +2,147 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
-	"big-spella-go/assets"
+	"big-spella-go/internal/tracing"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
-
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 const defaultTimeout = 3 * time.Second
 
+// driverName is lib/pq with its statements traced, which costs nothing
+// until tracing is turned on
+const driverName = "postgres+traced"
+
+var tracedDriver = tracing.WrapDriver(&pq.Driver{})
+
+func init() {
+	sql.Register(driverName, tracedDriver)
+	sqlx.BindDriver(driverName, sqlx.DOLLAR)
+}
+
+// Config says where the database is and how its connections are pooled.
+// The pool settings apply to the primary and each replica alike.
+type Config struct {
+	DSN         string
+	Automigrate bool
+	// ReplicaDSNs are read replicas for Reads. A replica that can't be
+	// reached at startup is an error, like the primary, since it's likely
+	// misconfigured.
+	ReplicaDSNs []string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	ConnMaxLifetime time.Duration
+
+	// StatementTimeout is how long Postgres lets a statement run before
+	// cancelling it. Statements run without a deadline of their own also
+	// get one a little longer, so a connection that stops answering is
+	// given up on too. Zero leaves statements unlimited.
+	StatementTimeout time.Duration
+}
+
+// DefaultConfig is the pool New used before it could be configured
+var DefaultConfig = Config{
+	MaxOpenConns:    25,
+	MaxIdleConns:    25,
+	ConnMaxIdleTime: 5 * time.Minute,
+	ConnMaxLifetime: 2 * time.Hour,
+}
+
 type DB struct {
 	*sqlx.DB
+	// Reads runs read-only queries against the read replicas, or the
+	// primary when there are none
+	Reads *Replicas
 }
 
-func New(dsn string, automigrate bool) (*DB, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
-	defer cancel()
-
-	db, err := sqlx.ConnectContext(ctx, "postgres", "postgres://"+dsn)
+func New(cfg Config) (*DB, error) {
+	db, err := connect(cfg, cfg.DSN)
 	if err != nil {
 		return nil, err
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxIdleTime(5 * time.Minute)
-	db.SetConnMaxLifetime(2 * time.Hour)
-
-	if automigrate {
-		iofsDriver, err := iofs.New(assets.EmbeddedFiles, "migrations")
+	replicas := make([]*sqlx.DB, 0, len(cfg.ReplicaDSNs))
+	for i, replicaDSN := range cfg.ReplicaDSNs {
+		replica, err := connect(cfg, replicaDSN)
 		if err != nil {
-			return nil, err
+			for _, connected := range replicas {
+				connected.Close()
+			}
+			db.Close()
+			return nil, fmt.Errorf("failed to connect to replica %d: %w", i, err)
 		}
+		replicas = append(replicas, replica)
+	}
+	reads := newReplicas(db, replicas...)
 
-		migrator, err := migrate.NewWithSourceInstance("iofs", iofsDriver, "postgres://"+dsn)
-		if err != nil {
-			return nil, err
+	if cfg.Automigrate {
+		if err := Migrate(cfg.DSN); err != nil {
+			return nil, errors.Join(err, db.Close(), reads.close())
 		}
+	}
 
-		err = migrator.Up()
-		switch {
-		case errors.Is(err, migrate.ErrNoChange):
-			break
-		case err != nil:
-			return nil, err
-		}
+	return &DB{DB: db, Reads: reads}, nil
+}
+
+// Close closes the primary and the replicas
+func (db *DB) Close() error {
+	return errors.Join(db.DB.Close(), db.Reads.close())
+}
+
+func connect(cfg Config, dsn string) (*sqlx.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	name, err := withStatementTimeout("postgres://"+dsn, cfg.StatementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	connector := &timeoutConnector{name: name}
+	if cfg.StatementTimeout > 0 {
+		connector.timeout = clientTimeout(cfg.StatementTimeout)
 	}
+	db := sqlx.NewDb(sql.OpenDB(connector), driverName)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return db, nil
+}
+
+// withStatementTimeout has Postgres enforce timeout on the sessions
+// opened with the connection URL name. lib/pq sends parameters it doesn't
+// know itself on to the server as settings.
+func withStatementTimeout(name string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return name, nil
+	}
+	u, err := url.Parse(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid database DSN: %w", err)
+	}
+	query := u.Query()
+	query.Set("statement_timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
 
-	return &DB{db}, nil
+// clientTimeout leaves Postgres time to cancel a statement that ran too
+// long and say so, which is clearer than the client giving up first
+func clientTimeout(statementTimeout time.Duration) time.Duration {
+	return statementTimeout + time.Second
 }
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"big-spella-go/internal/metrics"
+)
+
+var (
+	connectionsGauge = metrics.NewGaugeVec("spella_db_connections",
+		"Pooled database connections, by pool and state: in_use or idle.", "pool", "state")
+	maxConnectionsGauge = metrics.NewGaugeVec("spella_db_max_open_connections",
+		"The most connections each pool may open.", "pool")
+	waitsTotal = metrics.NewCounterVec("spella_db_waits_total",
+		"Queries that had to wait for a free connection, by pool.", "pool")
+	waitSecondsTotal = metrics.NewCounterVec("spella_db_wait_seconds_total",
+		"Time spent waiting for a free connection, by pool.", "pool")
+	closedTotal = metrics.NewCounterVec("spella_db_connections_closed_total",
+		"Connections the pool closed, by pool and reason: max_idle, max_idle_time or max_lifetime.", "pool", "reason")
+)
+
+// pool is one connection pool's name in the metrics and its stats when
+// they were last recorded
+type pool struct {
+	name string
+	db   *sql.DB
+	last sql.DBStats
+}
+
+// record brings the metrics up to date with the pool's stats. The pool
+// only keeps running totals, so counters go up by how much they grew.
+func (p *pool) record() {
+	stats := p.db.Stats()
+	connectionsGauge.With(p.name, "in_use").Set(float64(stats.InUse))
+	connectionsGauge.With(p.name, "idle").Set(float64(stats.Idle))
+	maxConnectionsGauge.With(p.name).Set(float64(stats.MaxOpenConnections))
+	waitsTotal.With(p.name).Add(float64(stats.WaitCount - p.last.WaitCount))
+	waitSecondsTotal.With(p.name).Add((stats.WaitDuration - p.last.WaitDuration).Seconds())
+	closedTotal.With(p.name, "max_idle").Add(float64(stats.MaxIdleClosed - p.last.MaxIdleClosed))
+	closedTotal.With(p.name, "max_idle_time").Add(float64(stats.MaxIdleTimeClosed - p.last.MaxIdleTimeClosed))
+	closedTotal.With(p.name, "max_lifetime").Add(float64(stats.MaxLifetimeClosed - p.last.MaxLifetimeClosed))
+	p.last = stats
+}
+
+// RecordPoolStats updates the connection pool metrics of the primary and
+// each replica every interval until ctx is cancelled, so a pool running
+// out of connections shows up before requests start timing out
+func (db *DB) RecordPoolStats(ctx context.Context, interval time.Duration) {
+	pools := []*pool{{name: "primary", db: db.DB.DB}}
+	for i, replica := range db.Reads.replicas {
+		pools = append(pools, &pool{name: "replica_" + strconv.Itoa(i), db: replica.db.DB})
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, p := range pools {
+			p.record()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"big-spella-go/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
+
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+)
+
+// MigrationsTable is where the schema's version is kept. The starter
+// migrations the embedded ones replaced kept theirs in schema_migrations,
+// so a database they ran against doesn't look migrated already.
+const MigrationsTable = "schema_versions"
+
+// MigrationStatus is how far the database's schema has been migrated
+type MigrationStatus struct {
+	// Version is the last migration applied, 0 when there are none
+	Version uint `json:"version"`
+	// Latest is the newest migration this build has
+	Latest  uint `json:"latest"`
+	Pending int  `json:"pending"`
+	// Dirty means a migration failed partway. The schema has to be fixed by
+	// hand and the version forced before migrations run again.
+	Dirty bool `json:"dirty"`
+}
+
+// Migrate applies the migrations the database doesn't have yet
+func Migrate(dsn string) error {
+	m, err := newMigrator(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	return nil
+}
+
+// MigrateTo applies or rolls back migrations until the database is at
+// version. Version 0 rolls every migration back.
+func MigrateTo(dsn string, version uint) error {
+	m, err := newMigrator(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if version == 0 {
+		err = m.Down()
+	} else {
+		err = m.Migrate(version)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate to %d: %w", version, err)
+	}
+	return nil
+}
+
+// newMigrator migrates the database at dsn with the embedded migrations.
+// Migrations connect without the statement timeout, since building an
+// index can rightly take a while.
+func newMigrator(dsn string) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.Files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse("postgres://" + dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database DSN: %w", err)
+	}
+	query := u.Query()
+	query.Set("x-migrations-table", MigrationsTable)
+	u.RawQuery = query.Encode()
+
+	return migrate.NewWithSourceInstance("iofs", source, u.String())
+}
+
+// migrationVersions are the versions of the embedded migrations, in order
+var migrationVersions = sync.OnceValues(func() ([]uint, error) {
+	return versionsIn(migrations.Files)
+})
+
+func versionsIn(fsys fs.FS) ([]uint, error) {
+	files, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]uint, 0, len(files))
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(file, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s isn't numbered", file)
+		}
+		versions = append(versions, uint(version))
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// MigrationStatus reads the schema's version, and compares it with the
+// migrations this build has
+func (db *DB) MigrationStatus(ctx context.Context) (MigrationStatus, error) {
+	versions, err := migrationVersions()
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	err = db.GetContext(ctx, &row, `SELECT version, dirty FROM `+MigrationsTable+` LIMIT 1`)
+	var pqErr *pq.Error
+	switch {
+	// Nothing has been migrated yet, or everything was rolled back
+	case errors.Is(err, sql.ErrNoRows), errors.As(err, &pqErr) && pqErr.Code == "42P01":
+	case err != nil:
+		return MigrationStatus{}, fmt.Errorf("get migration version: %w", err)
+	}
+
+	return statusOf(versions, row.Version, row.Dirty), nil
+}
+
+func statusOf(versions []uint, version int64, dirty bool) MigrationStatus {
+	status := MigrationStatus{Dirty: dirty}
+	if version > 0 {
+		status.Version = uint(version)
+	}
+	for _, v := range versions {
+		if v > status.Version {
+			status.Pending++
+		}
+	}
+	if len(versions) > 0 {
+		status.Latest = versions[len(versions)-1]
+	}
+	return status
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionsIn(t *testing.T) {
+	versions, err := versionsIn(fstest.MapFS{
+		"010_later.up.sql":   {},
+		"010_later.down.sql": {},
+		"002_second.up.sql":  {},
+		"001_first.up.sql":   {},
+		"migrations.go":      {},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2, 10}, versions)
+
+	_, err = versionsIn(fstest.MapFS{"first.up.sql": {}})
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	versions, err := migrationVersions()
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+	for i, version := range versions {
+		assert.Equal(t, uint(i+1), version, "migrations are numbered without gaps")
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	versions := []uint{1, 2, 3}
+
+	assert.Equal(t, MigrationStatus{Latest: 3, Pending: 3}, statusOf(versions, 0, false))
+	assert.Equal(t, MigrationStatus{Version: 2, Latest: 3, Pending: 1}, statusOf(versions, 2, false))
+	assert.Equal(t, MigrationStatus{Version: 3, Latest: 3, Dirty: true}, statusOf(versions, 3, true))
+	// golang-migrate records no migrations as version -1
+	assert.Equal(t, MigrationStatus{Latest: 3, Pending: 3}, statusOf(versions, -1, false))
+}
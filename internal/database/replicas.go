@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ReplicaCooldown is how long a replica that failed a query is left out
+// before it's tried again, unless a health check finds it well sooner
+const ReplicaCooldown = 30 * time.Second
+
+// latencyWeight is how much each new measurement moves a replica's latency
+const latencyWeight = 0.2
+
+// Replicas runs read-only queries against read replicas, preferring the
+// quickest to respond, and against the primary when none are available or
+// the one chosen fails. With no replicas every query goes to the primary.
+//
+// Replicas lag the primary, so only reads that can show slightly stale data
+// belong here.
+type Replicas struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	now      func() time.Time
+}
+
+type replica struct {
+	db *sqlx.DB
+
+	mu        sync.Mutex
+	latency   time.Duration
+	downUntil time.Time
+}
+
+func newReplicas(primary *sqlx.DB, dbs ...*sqlx.DB) *Replicas {
+	r := &Replicas{primary: primary, now: time.Now}
+	for _, db := range dbs {
+		r.replicas = append(r.replicas, &replica{db: db})
+	}
+	return r
+}
+
+func (r *Replicas) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.run(ctx, func(db *sqlx.DB) error {
+		return db.GetContext(ctx, dest, query, args...)
+	})
+}
+
+func (r *Replicas) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.run(ctx, func(db *sqlx.DB) error {
+		return db.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// run sends query to a replica, retrying it on the primary if the replica
+// couldn't answer
+func (r *Replicas) run(ctx context.Context, query func(db *sqlx.DB) error) error {
+	chosen := r.pick()
+	if chosen == nil {
+		return query(r.primary)
+	}
+
+	start := r.now()
+	err := query(chosen.db)
+	if err == nil || !unavailable(err) || ctx.Err() != nil {
+		chosen.observe(r.now().Sub(start))
+		return err
+	}
+
+	chosen.markDown(r.now().Add(ReplicaCooldown))
+	return query(r.primary)
+}
+
+// pick chooses the quicker of two replicas that are up, so the fastest
+// doesn't take every query, or returns nil if none are up
+func (r *Replicas) pick() *replica {
+	now := r.now()
+	up := make([]*replica, 0, len(r.replicas))
+	for _, replica := range r.replicas {
+		if replica.isUp(now) {
+			up = append(up, replica)
+		}
+	}
+
+	switch len(up) {
+	case 0:
+		return nil
+	case 1:
+		return up[0]
+	}
+	i := rand.Intn(len(up))
+	j := rand.Intn(len(up) - 1)
+	if j >= i {
+		j++
+	}
+	if up[j].currentLatency() < up[i].currentLatency() {
+		return up[j]
+	}
+	return up[i]
+}
+
+// Check pings each replica, timing the ones that answer and leaving out
+// the ones that don't
+func (r *Replicas) Check(ctx context.Context) error {
+	var errs []error
+	for i, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+		start := r.now()
+		err := replica.db.PingContext(pingCtx)
+		cancel()
+		if err != nil {
+			replica.markDown(r.now().Add(ReplicaCooldown))
+			errs = append(errs, fmt.Errorf("replica %d is unavailable: %w", i, err))
+			continue
+		}
+		replica.markUp(r.now().Sub(start))
+	}
+	return errors.Join(errs...)
+}
+
+// Run checks the replicas every interval until ctx is cancelled
+func (r *Replicas) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Check(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (r *Replicas) close() error {
+	var errs []error
+	for _, replica := range r.replicas {
+		errs = append(errs, replica.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *replica) isUp(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !now.Before(r.downUntil)
+}
+
+func (r *replica) currentLatency() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latency
+}
+
+// observe folds a measurement into the replica's latency, smoothing out
+// one-off slow queries
+func (r *replica) observe(took time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.latency == 0 {
+		r.latency = took
+		return
+	}
+	r.latency += time.Duration(latencyWeight * float64(took-r.latency))
+}
+
+func (r *replica) markUp(took time.Duration) {
+	r.observe(took)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = time.Time{}
+}
+
+func (r *replica) markDown(until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = until
+}
+
+// unavailable reports whether err means the replica couldn't run the
+// query, rather than the query itself being at fault
+func unavailable(err error) bool {
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// A query cancelled for running too long would be as slow on the
+		// primary
+		if pqErr.Code == "57014" {
+			return false
+		}
+		switch pqErr.Code.Class() {
+		// Connection trouble, too many connections or the server shutting
+		// down
+		case "08", "53", "57":
+			return true
+		}
+		// Standbys cancel queries that conflict with replaying the primary's
+		// changes
+		return pqErr.Code == "40001"
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPick(t *testing.T) {
+	now := time.Now()
+	r := newReplicas(nil, &sqlx.DB{}, &sqlx.DB{})
+	r.now = func() time.Time { return now }
+	fast, slow := r.replicas[0], r.replicas[1]
+	fast.observe(2 * time.Millisecond)
+	slow.observe(40 * time.Millisecond)
+
+	for i := 0; i < 20; i++ {
+		assert.Same(t, fast, r.pick(), "the quicker replica is preferred")
+	}
+
+	fast.markDown(now.Add(ReplicaCooldown))
+	assert.Same(t, slow, r.pick())
+
+	slow.markDown(now.Add(time.Second))
+	assert.Nil(t, r.pick(), "queries go to the primary when no replica is up")
+
+	now = now.Add(2 * time.Second)
+	assert.Same(t, slow, r.pick(), "replicas are tried again after their cooldown")
+
+	fast.markUp(3 * time.Millisecond)
+	assert.Same(t, fast, r.pick(), "a health check brings a replica straight back")
+
+	assert.Nil(t, newReplicas(nil).pick())
+}
+
+func TestObserve(t *testing.T) {
+	var r replica
+	r.observe(10 * time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, r.latency)
+	r.observe(60 * time.Millisecond)
+	assert.Equal(t, 20*time.Millisecond, r.latency, "one slow query only nudges the latency")
+}
+
+func TestUnavailable(t *testing.T) {
+	for _, err := range []error{
+		driver.ErrBadConn,
+		sql.ErrConnDone,
+		fmt.Errorf("failed to get game: %w", &pq.Error{Code: "57P01"}),
+		&pq.Error{Code: "08006"},
+		&pq.Error{Code: "53300"},
+		&pq.Error{Code: "40001"},
+	} {
+		assert.True(t, unavailable(err), "%v", err)
+	}
+
+	for _, err := range []error{
+		sql.ErrNoRows,
+		&pq.Error{Code: "42P01"},
+		&pq.Error{Code: "57014"},
+		errors.New("missing destination name"),
+	} {
+		assert.False(t, unavailable(err), "%v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// timeoutConnector opens traced connections to name. When timeout is set,
+// statements run without a deadline of their own get one, so a store
+// method handed a context that's never cancelled can't hold a pooled
+// connection forever.
+type timeoutConnector struct {
+	name    string
+	timeout time.Duration
+}
+
+func (c *timeoutConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := tracedDriver.Open(c.name)
+	if err != nil {
+		return nil, err
+	}
+	if c.timeout <= 0 {
+		return conn, nil
+	}
+	full, ok := conn.(contextConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("database: %T doesn't support contexts", conn)
+	}
+	return &timeoutConn{contextConn: full, timeout: c.timeout}, nil
+}
+
+func (c *timeoutConnector) Driver() driver.Driver {
+	return tracedDriver
+}
+
+// contextConn is everything database/sql uses a connection for
+type contextConn interface {
+	driver.Conn
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type timeoutConn struct {
+	contextConn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *timeoutConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+	return c.contextConn.ExecContext(ctx, query, args)
+}
+
+// QueryContext's deadline covers reading the rows too, so it's only
+// released once they're closed
+func (c *timeoutConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, cancel := c.withDeadline(ctx)
+	rows, err := c.contextConn.QueryContext(ctx, query, args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+type timeoutRows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityJSON(t *testing.T) {
+	at := time.Date(2026, 3, 14, 15, 9, 26, 535000000, time.UTC)
+	activity := Activity{
+		Actor:     "user:1",
+		Verb:      VerbFinishedGame,
+		Object:    "game:9",
+		ForeignID: "result:9:1",
+		Time:      at,
+		Extra:     map[string]any{"placement": float64(2)},
+	}
+
+	data, err := json.Marshal(activity)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"actor":"user:1","verb":"finished_game","object":"game:9",
+		"foreign_id":"result:9:1","time":"2026-03-14T15:09:26.535","placement":2}`, string(data))
+
+	var decoded Activity
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, activity, decoded)
+}
+
+func TestClientSignsRequests(t *testing.T) {
+	var got struct {
+		method, path, apiKey, authType string
+		claims                         jwt.MapClaims
+		body                           []map[string]string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method, got.path = r.Method, r.URL.Path
+		got.apiKey, got.authType = r.URL.Query().Get("api_key"), r.Header.Get("Stream-Auth-Type")
+
+		got.claims = jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(r.Header.Get("Authorization"), got.claims, func(*jwt.Token) (any, error) {
+			return []byte("s3cret"), nil
+		})
+		assert.NoError(t, err)
+
+		if r.URL.Path == "/api/v1.0/follow_many/" {
+			json.NewDecoder(r.Body).Decode(&got.body)
+		}
+		w.Write([]byte(`{"results":[{"id":"a1","actor":"user:2","verb":"posted","object":"post:5","time":"2026-03-14T15:09:26"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "s3cret", server.URL, server.Client())
+
+	activities, err := client.Activities(context.Background(), TimelineFeed("1"), 10, "a0")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, got.method)
+	assert.Equal(t, "/api/v1.0/feed/timeline/1/", got.path)
+	assert.Equal(t, "key", got.apiKey)
+	assert.Equal(t, "jwt", got.authType)
+	assert.Equal(t, "*", got.claims["resource"])
+	require.Len(t, activities, 1)
+	assert.Equal(t, "a1", activities[0].ID)
+	assert.Equal(t, "post:5", activities[0].Object)
+
+	err = client.FollowMany(context.Background(), []Follow{{Source: TimelineFeed("1"), Target: UserFeed("2")}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"source": "timeline:1", "target": "user:2"}}, got.body)
+
+	require.NoError(t, client.Unfollow(context.Background(), TimelineFeed("1"), UserFeed("2")))
+	assert.Equal(t, http.MethodDelete, got.method)
+	assert.Equal(t, "/api/v1.0/feed/timeline/1/follows/user:2/", got.path)
+}
+
+func TestClientErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"detail":"bad activity"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("key", "s3cret", server.URL, server.Client())
+
+	err := client.AddActivity(context.Background(), UserFeed("1"), Activity{Actor: "user:1"})
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.ErrorContains(t, err, "bad activity")
+
+	status = http.StatusTooManyRequests
+	err = client.AddActivity(context.Background(), UserFeed("1"), Activity{Actor: "user:1"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRejected, "rate limits are worth retrying")
+}
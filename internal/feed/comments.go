@@ -0,0 +1,220 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"big-spella-go/internal/profile"
+)
+
+const (
+	InteractionLike    = "like"
+	InteractionComment = "comment"
+)
+
+var (
+	ErrAlreadyLiked    = errors.New("you have already liked this post")
+	ErrNotLiked        = errors.New("you haven't liked this post")
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// Comment is a comment on a post, with how many replies it has. Replies
+// have ParentID set to the comment they answer.
+type Comment struct {
+	profile.PostInteraction
+	Username string `json:"username" db:"username"`
+	Replies  int    `json:"replies" db:"replies"`
+}
+
+// Like adds userID's like to a post
+func (s *Service) Like(ctx context.Context, userID, postID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO post_interactions (id, post_id, user_id, type, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		uuid.New().String(), postID, userID, InteractionLike, time.Now()); err != nil {
+		switch {
+		case hasCode(err, uniqueViolation):
+			return ErrAlreadyLiked
+		case hasCode(err, foreignKeyViolation):
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("failed to like post: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts SET likes_count = likes_count + 1 WHERE id = $1`, postID); err != nil {
+		return fmt.Errorf("failed to count like: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit like: %w", err)
+	}
+	return nil
+}
+
+// Unlike takes back userID's like of a post
+func (s *Service) Unlike(ctx context.Context, userID, postID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM post_interactions
+		WHERE post_id = $1 AND user_id = $2 AND type = $3`, postID, userID, InteractionLike)
+	if err != nil {
+		return fmt.Errorf("failed to unlike post: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotLiked
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts SET likes_count = GREATEST(likes_count - 1, 0) WHERE id = $1`, postID); err != nil {
+		return fmt.Errorf("failed to count like: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unlike: %w", err)
+	}
+	return nil
+}
+
+// AddComment stores userID's comment on a post, or their reply to the
+// comment with parentID when it isn't nil. Threads are one level deep, so a
+// reply to a reply joins the thread of the comment it answers.
+func (s *Service) AddComment(ctx context.Context, userID, postID string, parentID *string, text string) (*profile.PostInteraction, error) {
+	if err := s.checkMuted(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if parentID != nil {
+		var thread struct {
+			PostID   string  `db:"post_id"`
+			ParentID *string `db:"parent_id"`
+		}
+		if err := tx.GetContext(ctx, &thread, `
+			SELECT post_id, parent_id FROM post_interactions
+			WHERE id = $1 AND type = $2`, *parentID, InteractionComment); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrCommentNotFound
+			}
+			return nil, fmt.Errorf("failed to get comment: %w", err)
+		}
+		if thread.PostID != postID {
+			return nil, ErrCommentNotFound
+		}
+		if thread.ParentID != nil {
+			parentID = thread.ParentID
+		}
+	}
+
+	comment := &profile.PostInteraction{}
+	if err := tx.GetContext(ctx, comment, `
+		INSERT INTO post_interactions (id, post_id, user_id, type, content, parent_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`,
+		uuid.New().String(), postID, userID, InteractionComment, text, parentID, time.Now()); err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return nil, ErrPostNotFound
+		}
+		return nil, fmt.Errorf("failed to add comment: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts SET comments_count = comments_count + 1 WHERE id = $1`, postID); err != nil {
+		return nil, fmt.Errorf("failed to count comment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit comment: %w", err)
+	}
+	return comment, nil
+}
+
+// Comments returns a page of a post's comments, oldest first, or of the
+// replies to the comment with parentID when it isn't nil, along with how
+// many there are
+func (s *Service) Comments(ctx context.Context, postID string, parentID *string, limit, offset int) ([]Comment, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM post_interactions
+		WHERE post_id = $1 AND type = $2 AND parent_id IS NOT DISTINCT FROM $3`,
+		postID, InteractionComment, parentID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	comments := []Comment{}
+	if err := s.db.SelectContext(ctx, &comments, `
+		SELECT c.*, u.username,
+			(SELECT COUNT(*) FROM post_interactions r WHERE r.parent_id = c.id) AS replies
+		FROM post_interactions c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.post_id = $1 AND c.type = $2 AND c.parent_id IS NOT DISTINCT FROM $3
+		ORDER BY c.created_at, c.id
+		LIMIT $4 OFFSET $5`,
+		postID, InteractionComment, parentID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+// DeleteComment removes userID's comment from a post along with its replies
+func (s *Service) DeleteComment(ctx context.Context, userID, postID, commentID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var authorID string
+	if err := tx.GetContext(ctx, &authorID, `
+		SELECT user_id FROM post_interactions
+		WHERE id = $1 AND post_id = $2 AND type = $3`, commentID, postID, InteractionComment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("failed to get comment: %w", err)
+	}
+	if authorID != userID {
+		return ErrNotAuthor
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM post_interactions WHERE id = $1 OR parent_id = $1`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts SET comments_count = GREATEST(comments_count - $1, 0) WHERE id = $2`, deleted, postID); err != nil {
+		return fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment deletion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+// Package feed publishes game results, season badges and players' posts to
+// GetStream activity feeds and keeps GetStream's follow graph in step with
+// the user_follows table.
+//
+// Each player has a "user" feed of their own activities and a "timeline"
+// feed following the user feeds of the players they follow.
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/jobs"
+	"big-spella-go/internal/reports"
+)
+
+const (
+	UserGroup     = "user"
+	TimelineGroup = "timeline"
+
+	JobPublish = "feed_publish"
+	JobRemove  = "feed_remove"
+	JobFollow  = "feed_follow"
+
+	// followCopyLimit is how many of a player's past activities show up on
+	// the timeline of someone who starts following them
+	followCopyLimit = 20
+
+	syncBatchSize = 500
+	inlineTimeout = 10 * time.Second
+)
+
+// Verbs of the activities the service publishes
+const (
+	VerbFinishedGame = "finished_game"
+	VerbEarnedBadge  = "earned_badge"
+	VerbPosted       = "posted"
+)
+
+var (
+	ErrDisabled     = errors.New("activity feeds are not configured")
+	ErrUserNotFound = errors.New("user not found")
+	ErrSelfFollow   = errors.New("you can't follow yourself")
+	ErrNotFollowing = errors.New("not following this player")
+	ErrGameNotFound = errors.New("game not found")
+	ErrMuted        = errors.New("you are muted and can't post right now")
+)
+
+// Postgres error codes the service turns into its own errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+func UserFeed(userID string) FeedID     { return FeedID{Slug: UserGroup, ID: userID} }
+func TimelineFeed(userID string) FeedID { return FeedID{Slug: TimelineGroup, ID: userID} }
+
+// JobQueue queues feed updates to run in the background with retries
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error)
+}
+
+// MuteChecker finds the mute keeping a player from posting, nil when there
+// isn't one
+type MuteChecker interface {
+	Muted(ctx context.Context, userID string) (*reports.Mute, error)
+}
+
+type ServiceOption func(*Service)
+
+// WithJobs sends feed updates through queue, so GetStream outages are
+// retried. Without it they are sent straight away and dropped on failure.
+func WithJobs(queue JobQueue) ServiceOption {
+	return func(s *Service) {
+		s.queue = queue
+	}
+}
+
+// WithMutes keeps muted players from posting
+func WithMutes(mutes MuteChecker) ServiceOption {
+	return func(s *Service) {
+		s.mutes = mutes
+	}
+}
+
+type Service struct {
+	db      *sqlx.DB
+	client  *Client
+	queue   JobQueue
+	mutes   MuteChecker
+	onError func(error)
+}
+
+// NewService publishes through client. With a nil client, posts and follows
+// are still stored but nothing reaches GetStream and feeds can't be read.
+// Updates that fail to send are reported to onError.
+func NewService(db *sqlx.DB, client *Client, onError func(error), opts ...ServiceOption) *Service {
+	s := &Service{db: db, client: client, onError: onError}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type publishJob struct {
+	Feed     FeedID   `json:"feed"`
+	Activity Activity `json:"activity"`
+}
+
+type removeJob struct {
+	Feed      FeedID `json:"feed"`
+	ForeignID string `json:"foreign_id"`
+}
+
+type followJob struct {
+	Source   FeedID `json:"source"`
+	Target   FeedID `json:"target"`
+	Unfollow bool   `json:"unfollow,omitempty"`
+}
+
+// RegisterJobs has worker run the feed updates the service queues
+func (s *Service) RegisterJobs(worker *jobs.Worker) {
+	worker.Register(JobPublish, func(ctx context.Context, job *jobs.Job) error {
+		var payload publishJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return permanentIfRejected(s.publish(ctx, payload))
+	})
+	worker.Register(JobRemove, func(ctx context.Context, job *jobs.Job) error {
+		var payload removeJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return permanentIfRejected(s.remove(ctx, payload))
+	})
+	worker.Register(JobFollow, func(ctx context.Context, job *jobs.Job) error {
+		var payload followJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return permanentIfRejected(s.follow(ctx, payload))
+	})
+}
+
+func permanentIfRejected(err error) error {
+	if errors.Is(err, ErrRejected) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+func (s *Service) publish(ctx context.Context, job publishJob) error {
+	return s.client.AddActivity(ctx, job.Feed, job.Activity)
+}
+
+func (s *Service) remove(ctx context.Context, job removeJob) error {
+	return s.client.RemoveActivity(ctx, job.Feed, job.ForeignID)
+}
+
+func (s *Service) follow(ctx context.Context, job followJob) error {
+	if job.Unfollow {
+		return s.client.Unfollow(ctx, job.Source, job.Target)
+	}
+	return s.client.FollowMany(ctx, []Follow{{Source: job.Source, Target: job.Target}}, followCopyLimit)
+}
+
+// dispatch queues an update for GetStream, or sends it in the background
+// when there is no queue
+func (s *Service) dispatch(ctx context.Context, kind string, payload any, send func(ctx context.Context) error) {
+	if s.client == nil {
+		return
+	}
+
+	if s.queue != nil {
+		if _, err := s.queue.Enqueue(ctx, kind, payload); err != nil {
+			s.report(err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inlineTimeout)
+	go func() {
+		defer cancel()
+		if err := send(ctx); err != nil {
+			s.report(fmt.Errorf("failed to send %s: %w", kind, err))
+		}
+	}()
+}
+
+func (s *Service) report(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *Service) addActivity(ctx context.Context, userID string, activity Activity) {
+	job := publishJob{Feed: UserFeed(userID), Activity: activity}
+	s.dispatch(ctx, JobPublish, job, func(ctx context.Context) error { return s.publish(ctx, job) })
+}
+
+// PublishResults posts each player's result to their feed once a game
+// finishes, and a highlight post for the winners of tournament games.
+// Children's results aren't shared.
+func (s *Service) PublishResults(ctx context.Context, g *game.Game, results []game.PlayerResult) {
+	playerIDs := make([]string, len(results))
+	for i, result := range results {
+		playerIDs[i] = result.PlayerID
+	}
+	children, err := s.children(ctx, playerIDs...)
+	if err != nil {
+		s.report(err)
+		return
+	}
+
+	if g.Settings.IsTournament {
+		s.publishHighlights(ctx, g, results, children)
+	}
+
+	now := time.Now()
+	for _, result := range results {
+		if children[result.PlayerID] {
+			continue
+		}
+		s.addActivity(ctx, result.PlayerID, Activity{
+			Actor:     "user:" + result.PlayerID,
+			Verb:      VerbFinishedGame,
+			Object:    "game:" + g.ID,
+			ForeignID: "result:" + g.ID + ":" + result.PlayerID,
+			Time:      now,
+			Extra: map[string]any{
+				"game_type":     g.Type,
+				"placement":     result.Placement,
+				"players":       len(results),
+				"score":         result.Score,
+				"words_spelled": len(result.WordsSpelled),
+				"ranked":        g.Settings.IsRanked,
+			},
+		})
+	}
+}
+
+// PublishReward posts a badge earned at the end of a season to the
+// player's feed, unless they're a child
+func (s *Service) PublishReward(ctx context.Context, reward season.Reward) {
+	children, err := s.children(ctx, reward.UserID)
+	if err != nil {
+		s.report(err)
+		return
+	}
+	if children[reward.UserID] {
+		return
+	}
+
+	s.addActivity(ctx, reward.UserID, Activity{
+		Actor:     "user:" + reward.UserID,
+		Verb:      VerbEarnedBadge,
+		Object:    "badge:" + reward.Badge,
+		ForeignID: "reward:" + reward.SeasonID + ":" + reward.UserID,
+		Time:      time.Now(),
+		Extra: map[string]any{
+			"season_id": reward.SeasonID,
+			"badge":     reward.Badge,
+		},
+	})
+}
+
+// Follow has followerID's timeline show followingID's activities
+func (s *Service) Follow(ctx context.Context, followerID, followingID string) error {
+	if followerID == followingID {
+		return ErrSelfFollow
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_follows (follower_id, following_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, followerID, followingID); err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to follow: %w", err)
+	}
+
+	job := followJob{Source: TimelineFeed(followerID), Target: UserFeed(followingID)}
+	s.dispatch(ctx, JobFollow, job, func(ctx context.Context) error { return s.follow(ctx, job) })
+	return nil
+}
+
+// Unfollow takes followingID's activities off followerID's timeline
+func (s *Service) Unfollow(ctx context.Context, followerID, followingID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM user_follows
+		WHERE follower_id = $1 AND following_id = $2`, followerID, followingID)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFollowing
+	}
+
+	job := followJob{Source: TimelineFeed(followerID), Target: UserFeed(followingID), Unfollow: true}
+	s.dispatch(ctx, JobFollow, job, func(ctx context.Context) error { return s.follow(ctx, job) })
+	return nil
+}
+
+// SyncFollows replays every follow in user_follows to GetStream, repairing
+// any a failed update left out, and returns how many there were. Follows
+// removed from the table while GetStream was unreachable aren't undone.
+func (s *Service) SyncFollows(ctx context.Context) (int, error) {
+	if s.client == nil {
+		return 0, ErrDisabled
+	}
+
+	var (
+		synced                 int
+		lastFollower, lastUser = "00000000-0000-0000-0000-000000000000", "00000000-0000-0000-0000-000000000000"
+	)
+	for {
+		var batch []struct {
+			FollowerID  string `db:"follower_id"`
+			FollowingID string `db:"following_id"`
+		}
+		if err := s.db.SelectContext(ctx, &batch, `
+			SELECT follower_id, following_id FROM user_follows
+			WHERE (follower_id, following_id) > ($1, $2)
+			ORDER BY follower_id, following_id
+			LIMIT $3`, lastFollower, lastUser, syncBatchSize); err != nil {
+			return synced, fmt.Errorf("failed to list follows: %w", err)
+		}
+		if len(batch) == 0 {
+			return synced, nil
+		}
+
+		follows := make([]Follow, len(batch))
+		for i, f := range batch {
+			follows[i] = Follow{Source: TimelineFeed(f.FollowerID), Target: UserFeed(f.FollowingID)}
+		}
+		if err := s.client.FollowMany(ctx, follows, 0); err != nil {
+			return synced, fmt.Errorf("failed to sync follows: %w", err)
+		}
+
+		synced += len(batch)
+		last := batch[len(batch)-1]
+		lastFollower, lastUser = last.FollowerID, last.FollowingID
+	}
+}
+
+// Run calls SyncFollows every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SyncFollows(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Timeline returns activities from the players userID follows, newest
+// first, starting after the activity with ID before when it isn't empty
+func (s *Service) Timeline(ctx context.Context, userID string, limit int, before string) ([]Activity, error) {
+	if s.client == nil {
+		return nil, ErrDisabled
+	}
+	return s.client.Activities(ctx, TimelineFeed(userID), limit, before)
+}
+
+// Activities returns userID's own activities, newest first
+func (s *Service) Activities(ctx context.Context, userID string, limit int, before string) ([]Activity, error) {
+	if s.client == nil {
+		return nil, ErrDisabled
+	}
+	return s.client.Activities(ctx, UserFeed(userID), limit, before)
+}
+
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
+
+// children picks out the children's accounts among userIDs, whose activity
+// is kept off feeds
+func (s *Service) children(ctx context.Context, userIDs ...string) (map[string]bool, error) {
+	var ids []string
+	if err := s.db.SelectContext(ctx, &ids, `
+		SELECT id FROM users WHERE id = ANY($1) AND is_child`, pq.Array(userIDs)); err != nil {
+		return nil, fmt.Errorf("failed to look up children's accounts: %w", err)
+	}
+
+	children := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		children[id] = true
+	}
+	return children, nil
+}
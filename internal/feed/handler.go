@@ -0,0 +1,471 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultPageSize = 25
+	MaxPageSize     = 100
+
+	MaxCommentRunes = 1000
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type PageRequest struct {
+	Limit     int
+	Before    string
+	Validator validator.Validator
+}
+
+func parsePageRequest(query url.Values) PageRequest {
+	req := PageRequest{Limit: DefaultPageSize, Before: query.Get("before")}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		req.Validator.CheckField(err == nil, "limit", "Must be a whole number")
+		req.Limit = n
+	}
+	return req
+}
+
+func (r *PageRequest) validate() {
+	r.Validator.CheckField(validator.Between(r.Limit, 1, MaxPageSize), "limit", "Must be between 1 and 100")
+	r.Validator.CheckField(validator.MaxRunes(r.Before, 100), "before", "Must not be more than 100 characters")
+}
+
+// ListRequest pages through posts and comments stored here, rather than
+// activities read from GetStream
+type ListRequest struct {
+	Page      int
+	PageSize  int
+	ParentID  *string
+	Validator validator.Validator
+}
+
+func parseListRequest(query url.Values) ListRequest {
+	req := ListRequest{Page: 1, PageSize: DefaultPageSize}
+	if raw := query.Get("parent_id"); raw != "" {
+		_, err := uuid.Parse(raw)
+		req.Validator.CheckField(err == nil, "parent_id", "Must be a valid ID")
+		req.ParentID = &raw
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *ListRequest) validate() {
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 100")
+}
+
+type CommentRequest struct {
+	Text      string              `json:"text"`
+	ParentID  *string             `json:"parent_id"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *CommentRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Text), "text", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(r.Text, MaxCommentRunes), "text", "Must not be more than 1000 characters")
+	if r.ParentID != nil {
+		_, err := uuid.Parse(*r.ParentID)
+		r.Validator.CheckField(err == nil, "parent_id", "Must be a valid ID")
+	}
+}
+
+type PostRequest struct {
+	Type      string              `json:"type"`
+	Text      string              `json:"text"`
+	GameID    *string             `json:"game_id"`
+	MediaURLs []string            `json:"media_urls"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *PostRequest) validate() {
+	r.Validator.CheckField(validator.In(r.Type, PostTypes...), "type", "Must be game_recap, photo or video")
+	r.Validator.CheckField(validator.MaxRunes(r.Text, 2000), "text", "Must not be more than 2000 characters")
+	r.Validator.CheckField(len(r.MediaURLs) <= 10, "media_urls", "Must not have more than 10 items")
+
+	switch r.Type {
+	case PostGameRecap:
+		r.Validator.CheckField(r.GameID != nil, "game_id", "Must be provided for a game recap")
+	case PostPhoto, PostVideo:
+		r.Validator.CheckField(len(r.MediaURLs) > 0, "media_urls", "Must be provided for a photo or video")
+	}
+	if r.GameID != nil {
+		_, err := uuid.Parse(*r.GameID)
+		r.Validator.CheckField(err == nil, "game_id", "Must be a valid ID")
+	}
+	for _, raw := range r.MediaURLs {
+		r.Validator.CheckField(validator.IsURL(raw), "media_urls", "Must be URLs")
+	}
+}
+
+// Timeline serves activities from the players the caller follows
+func (h *Handler) Timeline(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := parsePageRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	activities, err := h.service.Timeline(r.Context(), userID, req.Limit, req.Before)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writePage(w, activities)
+}
+
+// UserFeed serves a player's own activities
+func (h *Handler) UserFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "user_id")
+	if !ok {
+		return
+	}
+
+	req := parsePageRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	activities, err := h.service.Activities(r.Context(), userID, req.Limit, req.Before)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writePage(w, activities)
+}
+
+// CreatePost shares a post on the caller's feed
+func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req PostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	post, err := h.service.CreatePost(r.Context(), userID, NewPost{
+		Type:      req.Type,
+		Text:      req.Text,
+		GameID:    req.GameID,
+		MediaURLs: req.MediaURLs,
+	})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(post)
+}
+
+// Follow adds a player's activities to the caller's timeline
+func (h *Handler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	followingID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Follow(r.Context(), userID, followingID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unfollow takes a player's activities off the caller's timeline
+func (h *Handler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	followingID, ok := pathID(w, r, "userID", "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Unfollow(r.Context(), userID, followingID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Posts serves a page of a player's posts
+func (h *Handler) Posts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "user_id")
+	if !ok {
+		return
+	}
+
+	req := parseListRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	posts, total, err := h.service.Posts(r.Context(), userID, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"posts":     posts,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// Post serves a single post
+func (h *Handler) Post(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathID(w, r, "postID", "post_id")
+	if !ok {
+		return
+	}
+
+	post, err := h.service.Post(r.Context(), postID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// DeletePost removes one of the caller's posts
+func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	h.onPost(w, r, h.service.DeletePost)
+}
+
+// Like adds the caller's like to a post
+func (h *Handler) Like(w http.ResponseWriter, r *http.Request) {
+	h.onPost(w, r, h.service.Like)
+}
+
+// Unlike takes back the caller's like of a post
+func (h *Handler) Unlike(w http.ResponseWriter, r *http.Request) {
+	h.onPost(w, r, h.service.Unlike)
+}
+
+// onPost runs action for the caller on the post in the path, responding
+// with 204 when it succeeds
+func (h *Handler) onPost(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, userID, postID string) error) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, ok := pathID(w, r, "postID", "post_id")
+	if !ok {
+		return
+	}
+
+	if err := action(r.Context(), userID, postID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddComment comments on a post, or replies to one of its comments
+func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, ok := pathID(w, r, "postID", "post_id")
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	comment, err := h.service.AddComment(r.Context(), userID, postID, req.ParentID, req.Text)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// Comments serves a page of a post's comments, or of the replies to the
+// comment given as parent_id
+func (h *Handler) Comments(w http.ResponseWriter, r *http.Request) {
+	postID, ok := pathID(w, r, "postID", "post_id")
+	if !ok {
+		return
+	}
+
+	req := parseListRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	comments, total, err := h.service.Comments(r.Context(), postID, req.ParentID, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"comments":  comments,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// DeleteComment removes one of the caller's comments and its replies
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID, ok := pathID(w, r, "postID", "post_id")
+	if !ok {
+		return
+	}
+	commentID, ok := pathID(w, r, "commentID", "comment_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteComment(r.Context(), userID, postID, commentID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writePage(w http.ResponseWriter, activities []Activity) {
+	next := ""
+	if len(activities) > 0 {
+		next = activities[len(activities)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"activities": activities,
+		"next":       next,
+	})
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrNotFollowing), errors.Is(err, ErrGameNotFound),
+		errors.Is(err, ErrPostNotFound), errors.Is(err, ErrCommentNotFound), errors.Is(err, ErrNotLiked):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSelfFollow), errors.Is(err, ErrMuted), errors.Is(err, ErrNotAuthor):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrAlreadyLiked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, ErrRejected):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// pathID reads an ID path parameter and responds with 422, naming field,
+// when it isn't a UUID
+func pathID(w http.ResponseWriter, r *http.Request, name, field string) (string, bool) {
+	id := httprouter.ParamsFromContext(r.Context()).ByName(name)
+
+	var v validator.Validator
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, field, "Must be a valid ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,115 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestPostRequestValidation(t *testing.T) {
+	gameID := uuid.New().String()
+
+	req := PostRequest{Type: PostGameRecap, Text: "Won on 'onomatopoeia'", GameID: &gameID}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = PostRequest{Type: PostGameRecap}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "game_id")
+
+	req = PostRequest{Type: PostPhoto, MediaURLs: []string{"not a url"}}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "media_urls")
+
+	req = PostRequest{Type: "status"}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "type")
+}
+
+func TestPageRequestValidation(t *testing.T) {
+	req := parsePageRequest(url.Values{})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, DefaultPageSize, req.Limit)
+
+	req = parsePageRequest(url.Values{"limit": {"500"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "limit")
+}
+
+func TestTimelineWithoutGetStream(t *testing.T) {
+	h := NewHandler(NewService(nil, nil, nil))
+
+	rec := httptest.NewRecorder()
+	h.Timeline(rec, httptest.NewRequest(http.MethodGet, "/feed/timeline", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/feed/timeline", nil)
+	req = req.WithContext(auth.SetUserIDInContext(req.Context(), uuid.New().String()))
+	rec = httptest.NewRecorder()
+	h.Timeline(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestFollowRejectsBadIDs(t *testing.T) {
+	h := NewHandler(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/follows/nope", strings.NewReader(""))
+	ctx := auth.SetUserIDInContext(req.Context(), uuid.New().String())
+	ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "userID", Value: "nope"}})
+	rec := httptest.NewRecorder()
+	h.Follow(rec, req.WithContext(ctx))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestFollowingYourself(t *testing.T) {
+	userID := uuid.New().String()
+	err := NewService(nil, nil, nil).Follow(context.Background(), userID, userID)
+	assert.ErrorIs(t, err, ErrSelfFollow)
+}
+
+func TestCommentRequestValidation(t *testing.T) {
+	parentID := uuid.New().String()
+	req := CommentRequest{Text: "Nice spelling!", ParentID: &parentID}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	bad := "comment-1"
+	req = CommentRequest{Text: "  ", ParentID: &bad}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "text")
+	assert.Contains(t, req.Validator.FieldErrors, "parent_id")
+}
+
+func TestListRequestValidation(t *testing.T) {
+	req := parseListRequest(url.Values{})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Nil(t, req.ParentID)
+
+	req = parseListRequest(url.Values{"parent_id": {"nope"}, "page": {"0"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "parent_id")
+	assert.Contains(t, req.Validator.FieldErrors, "page")
+}
+
+func TestLikeRejectsBadIDs(t *testing.T) {
+	h := NewHandler(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/posts/nope/likes", nil)
+	ctx := auth.SetUserIDInContext(req.Context(), uuid.New().String())
+	ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "postID", Value: "nope"}})
+	rec := httptest.NewRecorder()
+	h.Like(rec, req.WithContext(ctx))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "post_id")
+}
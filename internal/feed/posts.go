@@ -0,0 +1,229 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/profile"
+)
+
+// Kinds of post a player can share
+const (
+	PostGameRecap = "game_recap"
+	PostPhoto     = "photo"
+	PostVideo     = "video"
+	// PostGameHighlight is generated when a player wins a tournament game;
+	// players can't create them themselves
+	PostGameHighlight = "game_highlight"
+)
+
+var PostTypes = []string{PostGameRecap, PostPhoto, PostVideo}
+
+var (
+	ErrPostNotFound = errors.New("post not found")
+	ErrNotAuthor    = errors.New("only the author can delete this")
+)
+
+// NewPost is what a player shares
+type NewPost struct {
+	Type      string
+	Text      string
+	GameID    *string
+	MediaURLs []string
+}
+
+// checkMuted keeps muted players from posting and commenting
+func (s *Service) checkMuted(ctx context.Context, userID string) error {
+	if s.mutes == nil {
+		return nil
+	}
+	mute, err := s.mutes.Muted(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mute != nil {
+		return ErrMuted
+	}
+	return nil
+}
+
+// CreatePost stores userID's post and adds it to their feed
+func (s *Service) CreatePost(ctx context.Context, userID string, post NewPost) (*profile.Post, error) {
+	if err := s.checkMuted(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(map[string]string{"text": post.Text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode post: %w", err)
+	}
+	media, err := json.Marshal(post.MediaURLs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode post: %w", err)
+	}
+
+	now := time.Now()
+	created := &profile.Post{}
+	if err := s.db.GetContext(ctx, created, `
+		INSERT INTO posts (id, user_id, type, content, game_id, media_urls, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING *`,
+		uuid.New().String(), userID, post.Type, content, post.GameID, media, now); err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return nil, ErrGameNotFound
+		}
+		return nil, fmt.Errorf("failed to create post: %w", err)
+	}
+
+	extra := map[string]any{"type": post.Type, "text": post.Text}
+	if post.GameID != nil {
+		extra["game_id"] = *post.GameID
+	}
+	if len(post.MediaURLs) > 0 {
+		extra["media_urls"] = post.MediaURLs
+	}
+	s.addPostActivity(ctx, created, extra)
+	return created, nil
+}
+
+func (s *Service) addPostActivity(ctx context.Context, post *profile.Post, extra map[string]any) {
+	userID, postID := post.UserID.String(), post.ID.String()
+	s.addActivity(ctx, userID, Activity{
+		Actor:     "user:" + userID,
+		Verb:      VerbPosted,
+		Object:    "post:" + postID,
+		ForeignID: "post:" + postID,
+		Time:      post.CreatedAt,
+		Extra:     extra,
+	})
+}
+
+// Posts returns a page of userID's posts, newest first, along with how many
+// they have made
+func (s *Service) Posts(ctx context.Context, userID string, limit, offset int) ([]profile.Post, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM posts WHERE user_id = $1`, userID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	posts := []profile.Post{}
+	if err := s.db.SelectContext(ctx, &posts, `
+		SELECT * FROM posts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3`, userID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list posts: %w", err)
+	}
+	return posts, total, nil
+}
+
+// Post returns the post with postID
+func (s *Service) Post(ctx context.Context, postID string) (*profile.Post, error) {
+	post := &profile.Post{}
+	if err := s.db.GetContext(ctx, post, `SELECT * FROM posts WHERE id = $1`, postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPostNotFound
+		}
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	return post, nil
+}
+
+// DeletePost removes userID's post, its likes and comments, and takes it
+// off their feed
+func (s *Service) DeletePost(ctx context.Context, userID, postID string) error {
+	post, err := s.Post(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if post.UserID.String() != userID {
+		return ErrNotAuthor
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM posts WHERE id = $1`, postID); err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+
+	job := removeJob{Feed: UserFeed(userID), ForeignID: "post:" + postID}
+	s.dispatch(ctx, JobRemove, job, func(ctx context.Context) error { return s.remove(ctx, job) })
+	return nil
+}
+
+// publishHighlights posts a highlight for the winners of a tournament game
+// who aren't children. Tied winners each get one.
+func (s *Service) publishHighlights(ctx context.Context, g *game.Game, results []game.PlayerResult, children map[string]bool) {
+	var tournament string
+	if err := s.db.GetContext(ctx, &tournament, `
+		SELECT t.name FROM tournament_matches m
+		JOIN tournaments t ON t.id = m.tournament_id
+		WHERE m.game_id = $1
+		LIMIT 1`, g.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.report(fmt.Errorf("failed to get tournament of game %s: %w", g.ID, err))
+	}
+
+	for _, result := range results {
+		if result.Placement != 1 || children[result.PlayerID] {
+			continue
+		}
+		if err := s.createHighlight(ctx, g, tournament, result, len(results)); err != nil {
+			s.report(err)
+		}
+	}
+}
+
+func (s *Service) createHighlight(ctx context.Context, g *game.Game, tournament string, result game.PlayerResult, players int) error {
+	text := fmt.Sprintf("Won a tournament game with %d points", result.Score)
+	if tournament != "" {
+		text = fmt.Sprintf("Won a game in %s with %d points", tournament, result.Score)
+	}
+
+	// The longest words make the best highlights
+	words := append([]string(nil), result.WordsSpelled...)
+	sort.SliceStable(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	if len(words) > 3 {
+		words = words[:3]
+	}
+
+	content, err := json.Marshal(map[string]any{
+		"text":       text,
+		"tournament": tournament,
+		"score":      result.Score,
+		"players":    players,
+		"top_words":  words,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode highlight: %w", err)
+	}
+
+	now := time.Now()
+	post := &profile.Post{}
+	err = s.db.GetContext(ctx, post, `
+		INSERT INTO posts (id, user_id, type, content, game_id, media_urls, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, '[]', $6, $6)
+		ON CONFLICT (user_id, game_id) WHERE type = 'game_highlight' DO NOTHING
+		RETURNING *`,
+		uuid.New().String(), result.PlayerID, PostGameHighlight, content, g.ID, now)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Already generated
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to create highlight: %w", err)
+	}
+
+	s.addPostActivity(ctx, post, map[string]any{
+		"type":      PostGameHighlight,
+		"text":      text,
+		"game_id":   g.ID,
+		"top_words": words,
+	})
+	return nil
+}
@@ -0,0 +1,240 @@
+package friends
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type FriendRequest struct {
+	UserID    string              `json:"user_id"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *FriendRequest) validate(userID string) {
+	_, err := uuid.Parse(r.UserID)
+	r.Validator.CheckField(err == nil, "user_id", "Must be a valid ID")
+	r.Validator.CheckField(r.UserID != userID, "user_id", "Must not be yourself")
+}
+
+type ChallengeRequest struct {
+	WordLevel int                 `json:"word_level"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *ChallengeRequest) validate() {
+	r.Validator.CheckField(validator.Between(r.WordLevel, 1, 10), "word_level", "Must be between 1 and 10")
+}
+
+// List serves the user's friends and whether each is online
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	friends, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"friends": friends})
+}
+
+// Requests serves the user's pending friend requests, both ways
+func (h *Handler) Requests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := h.service.Requests(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"requests": requests})
+}
+
+func (h *Handler) SendRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req FriendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(userID); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	status, err := h.service.SendRequest(r.Context(), userID, req.UserID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"user_id": req.UserID, "status": status})
+}
+
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+	h.withOther(w, r, h.service.Accept)
+}
+
+func (h *Handler) Decline(w http.ResponseWriter, r *http.Request) {
+	h.withOther(w, r, h.service.Decline)
+}
+
+func (h *Handler) Remove(w http.ResponseWriter, r *http.Request) {
+	h.withOther(w, r, h.service.Remove)
+}
+
+func (h *Handler) Block(w http.ResponseWriter, r *http.Request) {
+	h.withOther(w, r, h.service.Block)
+}
+
+func (h *Handler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.withOther(w, r, h.service.Unblock)
+}
+
+// Challenge creates a private RapidFire game and invites the friend to it
+func (h *Handler) Challenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	friendID := httprouter.ParamsFromContext(r.Context()).ByName("userID")
+	if !validID(w, friendID) {
+		return
+	}
+
+	req := ChallengeRequest{WordLevel: 1}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	challenge, err := h.service.Challenge(r.Context(), userID, friendID, req.WordLevel)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// Challenges serves the open challenges the user has been sent
+func (h *Handler) Challenges(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	challenges, err := h.service.Challenges(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"challenges": challenges})
+}
+
+// withOther runs action between the user and the player named in the path
+func (h *Handler) withOther(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, userID, otherID string) error) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	otherID := httprouter.ParamsFromContext(r.Context()).ByName("userID")
+	if !validID(w, otherID) {
+		return
+	}
+
+	if err := action(r.Context(), userID, otherID); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrRequestNotFound), errors.Is(err, ErrNotFriends), errors.Is(err, ErrNotBlocked):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrBlocked), errors.Is(err, ErrNotChallengable):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrAlreadyFriends), errors.Is(err, ErrRequestExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// validID checks the user ID path parameter and responds with 422 when it
+// isn't a UUID
+func validID(w http.ResponseWriter, id string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, "user_id", "Must be a valid ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package friends
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game/modes"
+)
+
+func TestFriendRequestValidation(t *testing.T) {
+	self := "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d"
+
+	req := FriendRequest{UserID: "6b0d5f3e-2c1a-4e8b-9f7d-1a2b3c4d5e6f"}
+	req.validate(self)
+	assert.False(t, req.Validator.HasErrors())
+
+	req = FriendRequest{UserID: self}
+	req.validate(self)
+	assert.Equal(t, "Must not be yourself", req.Validator.FieldErrors["user_id"])
+
+	req = FriendRequest{UserID: "ada"}
+	req.validate(self)
+	assert.Contains(t, req.Validator.FieldErrors, "user_id")
+}
+
+func TestChallengeSettings(t *testing.T) {
+	settings := ChallengeSettings(4)
+
+	assert.Equal(t, modes.ModeRapidFire, settings.Mode)
+	assert.True(t, settings.IsPrivate, "challenges are invite only")
+	assert.Equal(t, 2, settings.MinPlayers)
+	assert.Equal(t, 2, settings.MaxPlayers)
+	assert.Equal(t, 4, settings.WordLevel)
+	assert.NoError(t, modes.ValidateSettings(modes.GameSettings{
+		Mode:       settings.Mode,
+		MaxPlayers: settings.MaxPlayers,
+		TimeLimit:  settings.TimeLimit,
+		WordLevel:  settings.WordLevel,
+		IsPrivate:  settings.IsPrivate,
+	}))
+}
+
+func TestSortOnlineFirst(t *testing.T) {
+	friends := []Friend{
+		{Username: "ada"},
+		{Username: "bo", Online: true},
+		{Username: "cy"},
+		{Username: "di", Online: true},
+	}
+
+	sortOnlineFirst(friends)
+
+	var names []string
+	for _, friend := range friends {
+		names = append(names, friend.Username)
+	}
+	assert.Equal(t, []string{"bo", "di", "ada", "cy"}, names)
+}
+
+func TestActionsCheckUserAndID(t *testing.T) {
+	h := NewHandler(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/blocks/x", nil)
+	rec := httptest.NewRecorder()
+	h.Block(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/blocks/x", nil)
+	ctx := auth.SetUserIDInContext(req.Context(), "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d")
+	ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "userID", Value: "x"}})
+	rec = httptest.NewRecorder()
+	h.Block(rec, req.WithContext(ctx))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "user_id")
+}
+
+func TestServiceErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{ErrUserNotFound, http.StatusNotFound},
+		{ErrRequestNotFound, http.StatusNotFound},
+		{ErrNotFriends, http.StatusNotFound},
+		{ErrBlocked, http.StatusForbidden},
+		{ErrNotChallengable, http.StatusForbidden},
+		{ErrAlreadyFriends, http.StatusConflict},
+		{ErrRequestExists, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		serviceError(rec, tt.err)
+		assert.Equal(t, tt.code, rec.Code, tt.err)
+	}
+}
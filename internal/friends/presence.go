@@ -0,0 +1,168 @@
+package friends
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"big-spella-go/internal/infrastructure/redis"
+)
+
+const (
+	// PresenceTTL is how long a connection counts as online without a
+	// heartbeat, so a crashed server's connections age out on their own
+	PresenceTTL = 60 * time.Second
+
+	presenceHeartbeat = PresenceTTL / 3
+)
+
+// Presence tracks who is online, and which game or lobby they're in, by
+// their open game WebSocket connections. Each user has a sorted set of
+// "connection:game" members scored by when they expire, so a player with
+// two tabs open stays online until both close. Each game has a set of
+// "connection:user" members the same way, for its lobby listing.
+type Presence struct {
+	redis   *redis.Client
+	onError func(error)
+}
+
+func NewPresence(client *redis.Client, onError func(error)) *Presence {
+	return &Presence{redis: client, onError: onError}
+}
+
+func presenceKey(userID string) string {
+	return "presence:" + userID
+}
+
+func gamePresenceKey(gameID string) string {
+	return "presence:game:" + gameID
+}
+
+// Track marks userID online in gameID until untrack is called or ctx ends.
+// joined reports whether userID had no other connection to the game, and
+// untrack whether the connection was their last one.
+func (p *Presence) Track(ctx context.Context, userID, gameID string) (joined bool, untrack func() (left bool)) {
+	connID := uuid.New().String()
+	ctx, cancel := context.WithCancel(ctx)
+
+	joined = !p.connected(ctx, userID, gameID)
+	p.refresh(ctx, userID, gameID, connID)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(presenceHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.refresh(ctx, userID, gameID, connID)
+			}
+		}
+	}()
+
+	return joined, func() bool {
+		cancel()
+		<-done
+
+		// The request's context is usually gone by now
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := p.redis.Do(ctx, "ZREM", presenceKey(userID), connID+":"+gameID); err != nil {
+			p.onError(fmt.Errorf("failed to clear presence: %w", err))
+		}
+		if _, err := p.redis.Do(ctx, "ZREM", gamePresenceKey(gameID), connID+":"+userID); err != nil {
+			p.onError(fmt.Errorf("failed to clear presence: %w", err))
+		}
+		return !p.connected(ctx, userID, gameID)
+	}
+}
+
+func (p *Presence) refresh(ctx context.Context, userID, gameID, connID string) {
+	now := time.Now()
+	expires := now.Add(PresenceTTL)
+
+	for key, member := range map[string]string{
+		presenceKey(userID):     connID + ":" + gameID,
+		gamePresenceKey(gameID): connID + ":" + userID,
+	} {
+		// Drop connections a crashed server never cleared
+		if _, err := p.redis.Do(ctx, "ZREMRANGEBYSCORE", key, "-inf", strconv.FormatInt(now.UnixMilli(), 10)); err != nil {
+			p.onError(fmt.Errorf("failed to record presence: %w", err))
+			return
+		}
+		if _, err := p.redis.Do(ctx, "ZADD", key, strconv.FormatInt(expires.UnixMilli(), 10), member); err != nil {
+			p.onError(fmt.Errorf("failed to record presence: %w", err))
+			return
+		}
+		if _, err := p.redis.Do(ctx, "PEXPIRE", key, strconv.FormatInt(PresenceTTL.Milliseconds(), 10)); err != nil {
+			p.onError(fmt.Errorf("failed to record presence: %w", err))
+		}
+	}
+}
+
+// live returns the members of key whose connections haven't expired, the
+// most recently refreshed first
+func (p *Presence) live(ctx context.Context, key string) ([]string, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return p.redis.Strings(ctx, "ZREVRANGEBYSCORE", key, "+inf", "("+now)
+}
+
+// connected reports whether userID has a live connection to gameID. When
+// presence can't be read it reports that they do, so no change is announced
+// that might not have happened.
+func (p *Presence) connected(ctx context.Context, userID, gameID string) bool {
+	members, err := p.live(ctx, presenceKey(userID))
+	if err != nil {
+		p.onError(fmt.Errorf("failed to get presence: %w", err))
+		return true
+	}
+	for _, member := range members {
+		if _, game, _ := strings.Cut(member, ":"); game == gameID {
+			return true
+		}
+	}
+	return false
+}
+
+// Online returns the game each of userIDs who has a live connection is in.
+// Those who are offline are left out.
+func (p *Presence) Online(ctx context.Context, userIDs []string) (map[string]string, error) {
+	online := make(map[string]string, len(userIDs))
+	for _, userID := range userIDs {
+		members, err := p.live(ctx, presenceKey(userID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get presence: %w", err)
+		}
+		if len(members) > 0 {
+			_, online[userID], _ = strings.Cut(members[0], ":")
+		}
+	}
+	return online, nil
+}
+
+// Connected returns the users with a live connection to each of gameIDs
+func (p *Presence) Connected(ctx context.Context, gameIDs []string) (map[string][]string, error) {
+	connected := make(map[string][]string, len(gameIDs))
+	for _, gameID := range gameIDs {
+		members, err := p.live(ctx, gamePresenceKey(gameID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lobby presence: %w", err)
+		}
+		seen := make(map[string]bool, len(members))
+		for _, member := range members {
+			_, userID, _ := strings.Cut(member, ":")
+			if !seen[userID] {
+				seen[userID] = true
+				connected[gameID] = append(connected[gameID], userID)
+			}
+		}
+	}
+	return connected, nil
+}
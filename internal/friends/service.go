@@ -0,0 +1,428 @@
+package friends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/modes"
+	"big-spella-go/internal/notifications"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrBlocked         = errors.New("this player can't be added as a friend")
+	ErrAlreadyFriends  = errors.New("already friends with this player")
+	ErrRequestExists   = errors.New("a friend request is already pending")
+	ErrRequestNotFound = errors.New("no pending friend request from this player")
+	ErrNotFriends      = errors.New("not friends with this player")
+	ErrNotChallengable = errors.New("only friends can be challenged")
+	ErrNotBlocked      = errors.New("this player isn't blocked")
+)
+
+// Postgres error codes the service turns into its own errors
+const foreignKeyViolation = "23503"
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusAccepted Status = "accepted"
+	StatusBlocked  Status = "blocked"
+)
+
+// Direction says who sent a pending friend request
+type Direction string
+
+const (
+	DirectionIncoming Direction = "incoming"
+	DirectionOutgoing Direction = "outgoing"
+)
+
+// Friend is someone the user is friends with
+type Friend struct {
+	UserID   string    `json:"user_id" db:"user_id"`
+	Username string    `json:"username" db:"username"`
+	Since    time.Time `json:"since" db:"since"`
+	Online   bool      `json:"online" db:"-"`
+	// GameID is the game or lobby the friend is in while they're online
+	GameID string `json:"game_id,omitempty" db:"-"`
+}
+
+// Request is a friend request waiting on an answer
+type Request struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	Direction Direction `json:"direction" db:"direction"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Challenge is a private game one friend has invited another to. The
+// invite code is what the challenged player joins with.
+type Challenge struct {
+	ID                 string    `json:"id" db:"id"`
+	ChallengerID       string    `json:"challenger_id" db:"challenger_id"`
+	ChallengerUsername string    `json:"challenger_username" db:"challenger_username"`
+	ChallengedID       string    `json:"challenged_id" db:"challenged_id"`
+	GameID             string    `json:"game_id" db:"game_id"`
+	InviteCode         string    `json:"invite_code" db:"invite_code"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+}
+
+// GameCreator starts the private games friends challenge each other to
+type GameCreator interface {
+	CreateGame(ctx context.Context, hostID string, gameType game.GameType, settings game.GameSettings) (*game.Game, error)
+}
+
+// PresenceChecker reports which users are connected right now, and to
+// which game
+type PresenceChecker interface {
+	Online(ctx context.Context, userIDs []string) (map[string]string, error)
+}
+
+type ServiceOption func(*Service)
+
+// WithPresence shows which friends are online. Without it every friend is
+// listed as offline.
+func WithPresence(presence PresenceChecker) ServiceOption {
+	return func(s *Service) {
+		s.presence = presence
+	}
+}
+
+// WithNotifier sends challenged friends a push notification
+func WithNotifier(notifier notifications.Notifier) ServiceOption {
+	return func(s *Service) {
+		s.notifier = notifier
+	}
+}
+
+type Service struct {
+	db       *sqlx.DB
+	games    GameCreator
+	presence PresenceChecker
+	notifier notifications.Notifier
+}
+
+func NewService(db *sqlx.DB, games GameCreator, opts ...ServiceOption) *Service {
+	s := &Service{db: db, games: games}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// relationship is the row between two users, from either side
+type relationship struct {
+	UserID   string `db:"user_id"`
+	FriendID string `db:"friend_id"`
+	Status   Status `db:"status"`
+}
+
+func (s *Service) relationships(ctx context.Context, q sqlx.QueryerContext, userID, otherID string) ([]relationship, error) {
+	var rows []relationship
+	if err := sqlx.SelectContext(ctx, q, &rows, `
+		SELECT user_id, friend_id, status FROM friendships
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)`,
+		userID, otherID); err != nil {
+		return nil, fmt.Errorf("failed to get friendship: %w", err)
+	}
+	return rows, nil
+}
+
+// SendRequest asks friendID to be friends. If they had already asked the
+// user, the two become friends straight away.
+func (s *Service) SendRequest(ctx context.Context, userID, friendID string) (Status, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := s.relationships(ctx, tx, userID, friendID)
+	if err != nil {
+		return "", err
+	}
+
+	status := StatusPending
+	for _, row := range rows {
+		switch {
+		case row.Status == StatusBlocked:
+			return "", ErrBlocked
+		case row.Status == StatusAccepted:
+			return "", ErrAlreadyFriends
+		case row.UserID == userID:
+			return "", ErrRequestExists
+		default:
+			status = StatusAccepted
+		}
+	}
+
+	if status == StatusAccepted {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE friendships SET status = $1, updated_at = NOW()
+			WHERE user_id = $2 AND friend_id = $3`, StatusAccepted, friendID, userID)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO friendships (user_id, friend_id, status)
+			VALUES ($1, $2, $3)`, userID, friendID, StatusPending)
+	}
+	if err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to send friend request: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return status, nil
+}
+
+// Accept accepts requesterID's pending request
+func (s *Service) Accept(ctx context.Context, userID, requesterID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE friendships SET status = $1, updated_at = NOW()
+		WHERE user_id = $2 AND friend_id = $3 AND status = $4`,
+		StatusAccepted, requesterID, userID, StatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to accept friend request: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrRequestNotFound
+	}
+	return nil
+}
+
+// Decline turns down requesterID's pending request. They may ask again.
+func (s *Service) Decline(ctx context.Context, userID, requesterID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE user_id = $1 AND friend_id = $2 AND status = $3`,
+		requesterID, userID, StatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to decline friend request: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrRequestNotFound
+	}
+	return nil
+}
+
+// Remove ends a friendship, or withdraws a request the user sent
+func (s *Service) Remove(ctx context.Context, userID, friendID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE ((user_id = $1 AND friend_id = $2 AND status IN ($3, $4))
+			OR (user_id = $2 AND friend_id = $1 AND status = $4))`,
+		userID, friendID, StatusPending, StatusAccepted)
+	if err != nil {
+		return fmt.Errorf("failed to remove friend: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+// Block ends any friendship or request between the two and stops targetID
+// from sending the user requests. A block targetID placed on the user is
+// left alone.
+func (s *Service) Block(ctx context.Context, userID, targetID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE user_id = $2 AND friend_id = $1 AND status <> $3`,
+		userID, targetID, StatusBlocked); err != nil {
+		return fmt.Errorf("failed to block player: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO friendships (user_id, friend_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, friend_id) DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()`,
+		userID, targetID, StatusBlocked); err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to block player: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Unblock lifts the user's block on targetID
+func (s *Service) Unblock(ctx context.Context, userID, targetID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE user_id = $1 AND friend_id = $2 AND status = $3`,
+		userID, targetID, StatusBlocked)
+	if err != nil {
+		return fmt.Errorf("failed to unblock player: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// List returns the user's friends, online ones first
+func (s *Service) List(ctx context.Context, userID string) ([]Friend, error) {
+	friends := []Friend{}
+	if err := s.db.SelectContext(ctx, &friends, `
+		SELECT u.id AS user_id, u.username, f.updated_at AS since
+		FROM friendships f
+		JOIN users u ON u.id = CASE WHEN f.user_id = $1 THEN f.friend_id ELSE f.user_id END
+		WHERE (f.user_id = $1 OR f.friend_id = $1) AND f.status = $2
+		ORDER BY u.username`, userID, StatusAccepted); err != nil {
+		return nil, fmt.Errorf("failed to list friends: %w", err)
+	}
+
+	if s.presence == nil || len(friends) == 0 {
+		return friends, nil
+	}
+
+	ids := make([]string, len(friends))
+	for i, friend := range friends {
+		ids[i] = friend.UserID
+	}
+	online, err := s.presence.Online(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range friends {
+		friends[i].GameID, friends[i].Online = online[friends[i].UserID]
+	}
+	sortOnlineFirst(friends)
+	return friends, nil
+}
+
+// sortOnlineFirst moves online friends ahead of offline ones, keeping each
+// group in the order it was in
+func sortOnlineFirst(friends []Friend) {
+	sorted := make([]Friend, 0, len(friends))
+	for _, online := range []bool{true, false} {
+		for _, friend := range friends {
+			if friend.Online == online {
+				sorted = append(sorted, friend)
+			}
+		}
+	}
+	copy(friends, sorted)
+}
+
+// Requests returns the pending requests sent to and by the user
+func (s *Service) Requests(ctx context.Context, userID string) ([]Request, error) {
+	requests := []Request{}
+	if err := s.db.SelectContext(ctx, &requests, `
+		SELECT u.id AS user_id, u.username, f.created_at,
+			CASE WHEN f.friend_id = $1 THEN $3 ELSE $4 END AS direction
+		FROM friendships f
+		JOIN users u ON u.id = CASE WHEN f.user_id = $1 THEN f.friend_id ELSE f.user_id END
+		WHERE (f.user_id = $1 OR f.friend_id = $1) AND f.status = $2
+		ORDER BY f.created_at DESC`,
+		userID, StatusPending, DirectionIncoming, DirectionOutgoing); err != nil {
+		return nil, fmt.Errorf("failed to list friend requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ChallengeSettings are the settings of the private RapidFire game a
+// friend challenge creates
+func ChallengeSettings(wordLevel int) game.GameSettings {
+	defaults := modes.DefaultSettings(modes.ModeRapidFire)
+	return game.GameSettings{
+		Mode:       modes.ModeRapidFire,
+		MinPlayers: defaults.MaxPlayers,
+		MaxPlayers: defaults.MaxPlayers,
+		TimeLimit:  defaults.TimeLimit,
+		WordLevel:  wordLevel,
+		IsPrivate:  true,
+	}
+}
+
+// Challenge creates a private RapidFire game hosted by the user and invites
+// friendID to it
+func (s *Service) Challenge(ctx context.Context, userID, friendID string, wordLevel int) (*Challenge, error) {
+	var friends bool
+	if err := s.db.GetContext(ctx, &friends, `
+		SELECT EXISTS(
+			SELECT 1 FROM friendships
+			WHERE ((user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)) AND status = $3)`,
+		userID, friendID, StatusAccepted); err != nil {
+		return nil, fmt.Errorf("failed to get friendship: %w", err)
+	}
+	if !friends {
+		return nil, ErrNotChallengable
+	}
+
+	g, err := s.games.CreateGame(ctx, userID, game.GameTypeMulti, ChallengeSettings(wordLevel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create challenge game: %w", err)
+	}
+
+	challenge := &Challenge{
+		ID:           uuid.New().String(),
+		ChallengerID: userID,
+		ChallengedID: friendID,
+		GameID:       g.ID,
+		CreatedAt:    time.Now(),
+	}
+	if g.InviteCode != nil {
+		challenge.InviteCode = *g.InviteCode
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO friend_challenges (id, challenger_id, challenged_id, game_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		challenge.ID, challenge.ChallengerID, challenge.ChallengedID, challenge.GameID, challenge.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to record challenge: %w", err)
+	}
+
+	if err := s.db.GetContext(ctx, &challenge.ChallengerUsername, `
+		SELECT username FROM users WHERE id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get challenger: %w", err)
+	}
+
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, friendID, notifications.FriendChallenge(challenge.ChallengerUsername, challenge.GameID, challenge.InviteCode))
+	}
+	return challenge, nil
+}
+
+// Challenges returns the challenges to the user whose games haven't started
+func (s *Service) Challenges(ctx context.Context, userID string) ([]Challenge, error) {
+	challenges := []Challenge{}
+	if err := s.db.SelectContext(ctx, &challenges, `
+		SELECT c.id, c.challenger_id, u.username AS challenger_username, c.challenged_id,
+			c.game_id, COALESCE(g.invite_code, '') AS invite_code, c.created_at
+		FROM friend_challenges c
+		JOIN users u ON u.id = c.challenger_id
+		JOIN games g ON g.id = c.game_id
+		WHERE c.challenged_id = $1 AND g.status IN ($2, $3)
+		ORDER BY c.created_at DESC`,
+		userID, game.GameStatusInitializing, game.GameStatusWaiting); err != nil {
+		return nil, fmt.Errorf("failed to list challenges: %w", err)
+	}
+	return challenges, nil
+}
+
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"big-spella-go/internal/game/modes"
+)
+
+const (
+	// ShortWordLetters is how long a word can be before it earns more time
+	ShortWordLetters = 5
+	// MaxAnswerWindow caps the answer window a host can set
+	MaxAnswerWindow = 2 * time.Minute
+
+	// ErrCodeAnswerTooLate is sent with ErrAnswerTooLate, so clients can
+	// tell a late answer from the other reasons one is turned away
+	ErrCodeAnswerTooLate = "answer_too_late"
+)
+
+var ErrAnswerTooLate = errors.New("the answer came in after the answer window closed")
+
+// AnswerWindowSettings size each turn's answer window to its word, so long
+// and hard words get more time than short, easy ones. Anything left unset
+// takes the default for the game's mode.
+type AnswerWindowSettings struct {
+	// Base is the window for words of up to ShortWordLetters letters at
+	// level 1
+	Base time.Duration `json:"base,omitempty"`
+	// PerLetter is added for each letter past ShortWordLetters
+	PerLetter time.Duration `json:"per_letter,omitempty"`
+	// PerLevel is added for each word level past 1
+	PerLevel time.Duration `json:"per_level,omitempty"`
+	// Max caps the window however long and hard the word
+	Max time.Duration `json:"max,omitempty"`
+}
+
+// defaultAnswerWindow is the answer window of each game mode. Rapid fire is
+// played against the clock, so gives less time.
+func defaultAnswerWindow(mode modes.GameMode) AnswerWindowSettings {
+	if mode == modes.ModeRapidFire {
+		return AnswerWindowSettings{Base: 6 * time.Second, PerLetter: 250 * time.Millisecond, PerLevel: 250 * time.Millisecond, Max: 12 * time.Second}
+	}
+	return AnswerWindowSettings{Base: TurnTimeout, PerLetter: 500 * time.Millisecond, PerLevel: 500 * time.Millisecond, Max: 30 * time.Second}
+}
+
+// withDefaults fills in whatever w leaves unset from mode's defaults
+func (w AnswerWindowSettings) withDefaults(mode modes.GameMode) AnswerWindowSettings {
+	defaults := defaultAnswerWindow(mode)
+	if w.Base == 0 {
+		w.Base = defaults.Base
+	}
+	if w.PerLetter == 0 {
+		w.PerLetter = defaults.PerLetter
+	}
+	if w.PerLevel == 0 {
+		w.PerLevel = defaults.PerLevel
+	}
+	if w.Max == 0 {
+		w.Max = max(defaults.Max, w.Base)
+	}
+	return w
+}
+
+// For is the answer window for word at level
+func (w AnswerWindowSettings) For(word string, level int) time.Duration {
+	window := w.Base
+	if letters := utf8.RuneCountInString(word); letters > ShortWordLetters {
+		window += time.Duration(letters-ShortWordLetters) * w.PerLetter
+	}
+	if level > 1 {
+		window += time.Duration(level-1) * w.PerLevel
+	}
+	if w.Max > 0 {
+		window = min(window, w.Max)
+	}
+	return window
+}
+
+// answerWindow is how long the current player has to answer, TurnTimeout
+// for engines not given the game's settings
+func (g *GameEngine) answerWindow() time.Duration {
+	if g.AnswerWindow <= 0 {
+		return TurnTimeout
+	}
+	return g.AnswerWindow
+}
+
+// CheckAnswerTime returns ErrAnswerTooLate when an answer received at
+// received came in after the answer window closed. Both times are read from
+// the server's clock, whose monotonic reading keeps the comparison right
+// however the wall clock is adjusted in between.
+func (g *GameEngine) CheckAnswerTime(received time.Time) error {
+	if g.TurnStartedAt == nil {
+		return ErrTurnNotActive
+	}
+	if received.Sub(*g.TurnStartedAt) > g.answerWindow() {
+		return ErrAnswerTooLate
+	}
+	return nil
+}
+
+// errorCode is the code clients are sent with err, if it has one
+func errorCode(err error) string {
+	if errors.Is(err, ErrAnswerTooLate) {
+		return ErrCodeAnswerTooLate
+	}
+	return ""
+}
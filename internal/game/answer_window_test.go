@@ -0,0 +1,38 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/game/modes"
+)
+
+func TestAnswerWindowFor(t *testing.T) {
+	window := AnswerWindowSettings{}.withDefaults(modes.ModeRoundRobin)
+
+	assert.Equal(t, TurnTimeout, window.For("cat", 1))
+	assert.Equal(t, TurnTimeout+2*time.Second, window.For("elephant", 2), "three letters and a level over")
+	assert.Equal(t, 30*time.Second, window.For("pneumonoultramicroscopicsilicovolcanoconiosis", 10), "capped at the maximum")
+
+	rapid := AnswerWindowSettings{}.withDefaults(modes.ModeRapidFire)
+	assert.Less(t, rapid.For("elephant", 2), window.For("elephant", 2), "rapid fire gives less time")
+
+	custom := AnswerWindowSettings{Base: 45 * time.Second}.withDefaults(modes.ModeRoundRobin)
+	assert.Equal(t, 45*time.Second, custom.For("elephant", 2), "a long base raises the default maximum with it")
+}
+
+func TestCheckAnswerTime(t *testing.T) {
+	engine := NewGameEngine("game", nil)
+	assert.ErrorIs(t, engine.CheckAnswerTime(time.Now()), ErrTurnNotActive)
+
+	engine.RestartTurn()
+	engine.AnswerWindow = 4 * time.Second
+	assert.NoError(t, engine.CheckAnswerTime(engine.TurnStartedAt.Add(4*time.Second)))
+	assert.ErrorIs(t, engine.CheckAnswerTime(engine.TurnStartedAt.Add(4*time.Second+time.Millisecond)), ErrAnswerTooLate)
+	assert.Equal(t, engine.TurnStartedAt.Add(4*time.Second), *engine.TurnDeadline())
+
+	assert.Equal(t, ErrCodeAnswerTooLate, errorCode(ErrAnswerTooLate))
+	assert.Empty(t, errorCode(ErrNotPlayerTurn))
+}
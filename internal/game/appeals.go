@@ -0,0 +1,327 @@
+package game
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/game/stt"
+)
+
+const DefaultAppealWindow = 2 * time.Minute
+
+var (
+	ErrAppealsDisabled    = errors.New("appeals are not enabled for this game")
+	ErrAttemptNotFound    = errors.New("attempt not found")
+	ErrNotAppealable      = errors.New("only your own attempts ruled incorrect can be appealed")
+	ErrAppealWindowClosed = errors.New("the window to appeal this attempt has closed")
+	ErrAppealExists       = errors.New("attempt has already been appealed")
+	ErrAppealNotFound     = errors.New("appeal not found")
+	ErrAppealDecided      = errors.New("appeal has already been decided")
+)
+
+// AppealSettings lets players dispute attempts ruled incorrect
+type AppealSettings struct {
+	// Window is how long after an attempt its player can appeal the ruling.
+	// Zero means DefaultAppealWindow; a negative value disables appeals.
+	Window time.Duration `json:"window"`
+}
+
+func (a AppealSettings) window() time.Duration {
+	if a.Window == 0 {
+		return DefaultAppealWindow
+	}
+	return a.Window
+}
+
+// AppealStatus is where an appeal is in review
+type AppealStatus string
+
+const (
+	AppealStatusPending AppealStatus = "pending"
+	// AppealStatusUpheld means the original ruling stands
+	AppealStatusUpheld     AppealStatus = "upheld"
+	AppealStatusOverturned AppealStatus = "overturned"
+)
+
+// AppealMethod records how an appeal was decided
+type AppealMethod string
+
+const (
+	AppealMethodModerator AppealMethod = "moderator"
+	// AppealMethodRetranscription is a second transcription of a voice
+	// attempt's audio that heard the word spelled correctly
+	AppealMethodRetranscription AppealMethod = "retranscription"
+)
+
+// Appeal is a player's dispute of an attempt ruled incorrect
+type Appeal struct {
+	ID        string        `json:"id" db:"id"`
+	AttemptID string        `json:"attempt_id" db:"attempt_id"`
+	GameID    string        `json:"game_id" db:"game_id"`
+	PlayerID  string        `json:"player_id" db:"player_id"`
+	Reason    string        `json:"reason" db:"reason"`
+	Status    AppealStatus  `json:"status" db:"status"`
+	Method    *AppealMethod `json:"method,omitempty" db:"method"`
+	Reviewer  *string       `json:"reviewer,omitempty" db:"reviewer"`
+	Note      string        `json:"note,omitempty" db:"note"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	DecidedAt *time.Time    `json:"decided_at,omitempty" db:"decided_at"`
+
+	// Word and Text are what the moderator rules on. They are only filled
+	// when appeals are listed for review.
+	Word string `json:"word,omitempty" db:"word"`
+	Text string `json:"text,omitempty" db:"text"`
+}
+
+func (s *gameService) attempt(ctx context.Context, q sqlx.QueryerContext, gameID, attemptID string) (*SpellingAttempt, error) {
+	attempt := &SpellingAttempt{}
+	if err := sqlx.GetContext(ctx, q, attempt, `
+		SELECT id, game_id, player_id, word, type, text, is_correct, timestamp, status,
+			COALESCE(ruling, '') AS ruling, confidence, judge_id, answer_ms,
+			COALESCE(match, '') AS match, points, voice_s3_key, voice_expires_at
+		FROM spelling_attempts
+		WHERE id = $1 AND game_id = $2`, attemptID, gameID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttemptNotFound
+		}
+		return nil, fmt.Errorf("failed to get attempt: %w", err)
+	}
+	return attempt, nil
+}
+
+// FileAppeal disputes playerID's own attempt that was ruled incorrect. Voice
+// attempts whose audio was kept are transcribed a second time straight
+// away; anything that doesn't clear that way waits for a moderator.
+func (s *gameService) FileAppeal(ctx context.Context, gameID, attemptID, playerID, reason string) (*Appeal, error) {
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := game.Settings.Appeals.window()
+	if window < 0 {
+		return nil, ErrAppealsDisabled
+	}
+
+	attempt, err := s.attempt(ctx, s.db, gameID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.PlayerID != playerID || attempt.Status != AttemptStatusJudged || attempt.IsCorrect {
+		return nil, ErrNotAppealable
+	}
+	if time.Since(attempt.Timestamp) > window {
+		return nil, ErrAppealWindowClosed
+	}
+
+	appeal := &Appeal{}
+	if err := s.db.GetContext(ctx, appeal, `
+		INSERT INTO appeals (id, attempt_id, game_id, player_id, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (attempt_id) DO NOTHING
+		RETURNING *`,
+		uuid.New().String(), attemptID, gameID, playerID, reason, AppealStatusPending, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAppealExists
+		}
+		return nil, fmt.Errorf("failed to file appeal: %w", err)
+	}
+
+	s.emitEvent(EventTypeAppealFiled, gameID, &playerID, map[string]any{
+		"appeal_id":  appeal.ID,
+		"attempt_id": attemptID,
+	})
+
+	if attempt.Type == AttemptTypeVoice && attempt.VoiceKey != nil && s.voice != nil {
+		go s.retranscribe(appeal.ID, attempt, game.Settings.Language, s.transcriptionPriority(ctx, gameID))
+	}
+
+	return appeal, nil
+}
+
+// retranscribe runs a voice attempt's audio through the recogniser again and
+// overturns the appeal if it hears the word spelled correctly. Otherwise the
+// appeal is left for a moderator.
+func (s *gameService) retranscribe(appealID string, attempt *SpellingAttempt, language string, priority stt.Priority) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	audio, err := s.voice.Load(ctx, attempt)
+	if err != nil || audio == nil {
+		return
+	}
+
+	transcription, err := s.stt.TranscribeWithConfidence(ctx, audio, languageOr(language), priority)
+	if err != nil || !strings.EqualFold(strings.TrimSpace(transcription.Text), attempt.Word) {
+		return
+	}
+
+	note := fmt.Sprintf("second transcription heard %q", transcription.Text)
+	_, _ = s.decideAppeal(ctx, appealID, "", AppealMethodRetranscription, true, note)
+}
+
+// ListAppeals returns up to 100 appeals with status, oldest first
+func (s *gameService) ListAppeals(ctx context.Context, status AppealStatus) ([]Appeal, error) {
+	appeals := []Appeal{}
+	if err := s.db.SelectContext(ctx, &appeals, `
+		SELECT a.*, sa.word, sa.text
+		FROM appeals a
+		JOIN spelling_attempts sa ON sa.id = a.attempt_id
+		WHERE a.status = $1
+		ORDER BY a.created_at
+		LIMIT 100`, status); err != nil {
+		return nil, fmt.Errorf("failed to list appeals: %w", err)
+	}
+	return appeals, nil
+}
+
+// DecideAppeal records a moderator's decision on a pending appeal
+func (s *gameService) DecideAppeal(ctx context.Context, appealID, reviewer string, overturn bool, note string) (*Appeal, error) {
+	return s.decideAppeal(ctx, appealID, reviewer, AppealMethodModerator, overturn, note)
+}
+
+// decideAppeal closes a pending appeal. Overturning it rules the attempt
+// correct and awards its points; play isn't rewound, so in a game still in
+// progress the points count towards the final results, and in a finished
+// game the players' history and placements are corrected.
+func (s *gameService) decideAppeal(ctx context.Context, appealID, reviewer string, method AppealMethod, overturn bool, note string) (*Appeal, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	appeal := &Appeal{}
+	if err := tx.GetContext(ctx, appeal, `SELECT * FROM appeals WHERE id = $1 FOR UPDATE`, appealID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAppealNotFound
+		}
+		return nil, fmt.Errorf("failed to get appeal: %w", err)
+	}
+	if appeal.Status != AppealStatusPending {
+		return nil, ErrAppealDecided
+	}
+
+	game, err := s.GetGame(ctx, appeal.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, awarded := AppealStatusUpheld, 0
+	var results []PlayerResult
+	if overturn {
+		status = AppealStatusOverturned
+
+		attempt, err := s.attempt(ctx, tx, appeal.GameID, appeal.AttemptID)
+		if err != nil {
+			return nil, err
+		}
+		before := attempt.Points
+		attempt.IsCorrect = true
+		attempt.Ruling = RulingAppeal
+		// The streak the word would have extended is gone by now
+		scoreAttempt(game, attempt, 0)
+		awarded = attempt.Points - before
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE spelling_attempts
+			SET is_correct = $1, ruling = $2, match = $3, points = $4
+			WHERE id = $5`,
+			attempt.IsCorrect, attempt.Ruling, attempt.Match, attempt.Points, attempt.ID); err != nil {
+			return nil, fmt.Errorf("failed to overturn ruling: %w", err)
+		}
+
+		if game.Status == GameStatusFinished {
+			if results, err = s.adjustHistory(ctx, tx, game.ID, attempt, awarded); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.GetContext(ctx, appeal, `
+		UPDATE appeals
+		SET status = $1, method = $2, reviewer = NULLIF($3, ''), note = $4, decided_at = $5
+		WHERE id = $6
+		RETURNING *`,
+		status, method, reviewer, note, time.Now(), appealID); err != nil {
+		return nil, fmt.Errorf("failed to decide appeal: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit appeal decision: %w", err)
+	}
+	s.record(ctx, audit.Event{
+		Action:     audit.ActionAppealDecided,
+		TargetType: "appeal",
+		TargetID:   appeal.ID,
+		Before:     map[string]any{"status": AppealStatusPending},
+		After:      appeal,
+	})
+
+	data := map[string]any{
+		"appeal_id":      appeal.ID,
+		"attempt_id":     appeal.AttemptID,
+		"status":         appeal.Status,
+		"method":         method,
+		"points_awarded": awarded,
+	}
+	if results != nil {
+		data["results"] = results
+	}
+	s.emitEvent(EventTypeAppealResolved, appeal.GameID, &appeal.PlayerID, data)
+
+	return appeal, nil
+}
+
+// adjustHistory credits an attempt overturned after its game finished to
+// the player's history and places everyone again
+func (s *gameService) adjustHistory(ctx context.Context, tx *sqlx.Tx, gameID string, attempt *SpellingAttempt, awarded int) ([]PlayerResult, error) {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE game_history
+		SET score = score + $1, words_spelled = array_append(words_spelled, $2)
+		WHERE game_id = $3 AND user_id = $4`,
+		awarded, attempt.Word, gameID, attempt.PlayerID); err != nil {
+		return nil, fmt.Errorf("failed to adjust game history: %w", err)
+	}
+
+	var rows []struct {
+		UserID       string         `db:"user_id"`
+		Score        int            `db:"score"`
+		WordsSpelled pq.StringArray `db:"words_spelled"`
+		Team         *int           `db:"team"`
+	}
+	if err := tx.SelectContext(ctx, &rows, `
+		SELECT user_id, score, words_spelled, team FROM game_history WHERE game_id = $1`, gameID); err != nil {
+		return nil, fmt.Errorf("failed to get game history: %w", err)
+	}
+
+	results := make([]PlayerResult, len(rows))
+	for i, row := range rows {
+		results[i] = PlayerResult{PlayerID: row.UserID, Score: row.Score, WordsSpelled: row.WordsSpelled, Team: row.Team}
+	}
+	if len(teamResults(results)) > 0 {
+		results = rankTeams(results, nil)
+	} else {
+		results = rankResults(results)
+	}
+
+	for _, result := range results {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE game_history SET position = $1
+			WHERE game_id = $2 AND user_id = $3`,
+			result.Placement, gameID, result.PlayerID); err != nil {
+			return nil, fmt.Errorf("failed to update placements: %w", err)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/validator"
+)
+
+func TestAppealWindow(t *testing.T) {
+	assert.Equal(t, DefaultAppealWindow, AppealSettings{}.window())
+	assert.Equal(t, time.Hour, AppealSettings{Window: time.Hour}.window())
+	assert.Negative(t, AppealSettings{Window: -1}.window(), "a negative window disables appeals")
+
+	var v validator.Validator
+	validateSettings(&v, GameSettings{MaxPlayers: 4, WordLevel: 1, Appeals: AppealSettings{Window: 48 * time.Hour}})
+	assert.Contains(t, v.FieldErrors, "settings.appeals.window")
+}
+
+type appealStub struct {
+	GameService
+	err      error
+	reviewer string
+}
+
+func (s *appealStub) FileAppeal(ctx context.Context, gameID, attemptID, playerID, reason string) (*Appeal, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Appeal{ID: "appeal-1", AttemptID: attemptID, PlayerID: playerID, Reason: reason, Status: AppealStatusPending}, nil
+}
+
+func (s *appealStub) DecideAppeal(ctx context.Context, appealID, reviewer string, overturn bool, note string) (*Appeal, error) {
+	s.reviewer = reviewer
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Appeal{ID: appealID, Status: AppealStatusOverturned}, nil
+}
+
+func TestFileAppealErrors(t *testing.T) {
+	attemptID := "6b0d5f3e-2c1a-4e8b-9f7d-1a2b3c4d5e6f"
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, http.StatusCreated},
+		{ErrAttemptNotFound, http.StatusNotFound},
+		{ErrNotAppealable, http.StatusForbidden},
+		{ErrAppealWindowClosed, http.StatusConflict},
+		{ErrAppealExists, http.StatusConflict},
+		{ErrAppealsDisabled, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		h := NewHandler(&appealStub{err: tt.err})
+		req := httptest.NewRequest(http.MethodPost, "/games/x/attempts/y/appeal", strings.NewReader(`{"reason":"I said it right"}`))
+		req = req.WithContext(auth.SetUserIDInContext(req.Context(), "ada"))
+		rec := httptest.NewRecorder()
+
+		h.FileAppeal(rec, req, httprouter.Params{
+			{Key: "gameID", Value: "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d"},
+			{Key: "attemptID", Value: attemptID},
+		})
+
+		assert.Equal(t, tt.code, rec.Code, tt.err)
+	}
+}
+
+func TestDecideAppeal(t *testing.T) {
+	appealID := "6b0d5f3e-2c1a-4e8b-9f7d-1a2b3c4d5e6f"
+	tests := []struct {
+		body string
+		err  error
+		code int
+	}{
+		{`{"overturn":true}`, nil, http.StatusOK},
+		{`{"note":"no decision"}`, nil, http.StatusUnprocessableEntity},
+		{`{"overturn":false}`, ErrAppealNotFound, http.StatusNotFound},
+		{`{"overturn":false}`, ErrAppealDecided, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		h := NewHandler(&appealStub{err: tt.err})
+		req := httptest.NewRequest(http.MethodPost, "/appeals/x/decision", strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+
+		h.DecideAppeal(rec, req, httprouter.Params{{Key: "appealID", Value: appealID}})
+
+		assert.Equal(t, tt.code, rec.Code, tt.body)
+	}
+}
+
+func TestListAppealsRejectsUnknownStatus(t *testing.T) {
+	h := NewHandler(&appealStub{})
+	req := httptest.NewRequest(http.MethodGet, "/appeals?status=lost", nil)
+	rec := httptest.NewRecorder()
+
+	h.ListAppeals(rec, req, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
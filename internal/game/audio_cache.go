@@ -0,0 +1,168 @@
+package game
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/jobs"
+)
+
+// JobWordAudio is the job kind that caches a word's pronunciation and saves
+// its URL on the word
+const JobWordAudio = "word_audio"
+
+// WordAudioJob is the payload of a JobWordAudio job
+type WordAudioJob struct {
+	WordID string `json:"word_id"`
+}
+
+// JobPregenerateLevel is the job kind that queues a JobWordAudio job for
+// every word at a level that has no audio yet
+const JobPregenerateLevel = "pregenerate_level"
+
+// PregenerateLevelJob is the payload of a JobPregenerateLevel job
+type PregenerateLevelJob struct {
+	Level int `json:"level"`
+}
+
+// AudioStore persists generated pronunciation audio and hands out URLs for it
+type AudioStore interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// AudioCache sits in front of TTS so each word+voice pair is only
+// synthesized once
+type AudioCache struct {
+	db    *sqlx.DB
+	dict  DictionaryService
+	store AudioStore
+	queue JobQueue
+}
+
+// NewAudioCache caches audio in store, generating it in the background
+// through queue
+func NewAudioCache(db *sqlx.DB, dict DictionaryService, store AudioStore, queue JobQueue) *AudioCache {
+	return &AudioCache{
+		db:    db,
+		dict:  dict,
+		store: store,
+		queue: queue,
+	}
+}
+
+func audioKey(word, voice string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(word))))
+	return fmt.Sprintf("audio/%s/%x.mp3", voice, sum[:16])
+}
+
+// AudioURL returns a URL for the spoken word, generating and uploading the
+// audio on a cache miss
+func (c *AudioCache) AudioURL(ctx context.Context, word, voice string) (string, error) {
+	if voice == "" {
+		voice = DefaultVoice
+	}
+	key := audioKey(word, voice)
+
+	exists, err := c.store.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check audio cache: %w", err)
+	}
+
+	if !exists {
+		audio, err := c.dict.SynthesizeSpeech(ctx, word, voice, DefaultSpeechRate)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate audio: %w", err)
+		}
+		if err := c.store.Put(ctx, key, audio, "audio/mpeg"); err != nil {
+			return "", fmt.Errorf("failed to store audio: %w", err)
+		}
+	}
+
+	return c.store.URL(ctx, key)
+}
+
+// EnsureWordAudio points word.AudioURL at the cached audio in the voice for
+// the word's language and persists it on the word row
+func (c *AudioCache) EnsureWordAudio(ctx context.Context, word *Word) error {
+	url, err := c.AudioURL(ctx, word.Word, LanguageVoice(word.Language))
+	if err != nil {
+		return err
+	}
+	word.AudioURL = url
+
+	if word.ID == "" {
+		return nil
+	}
+
+	if _, err := c.db.ExecContext(ctx,
+		"UPDATE words SET audio_url = $1 WHERE id = $2", url, word.ID); err != nil {
+		return fmt.Errorf("failed to save audio url: %w", err)
+	}
+	return nil
+}
+
+// PregenerateLevel queues the audio of every word at level that has none
+// yet to be cached, one job per word so a large level isn't held to a
+// single job's timeout. It returns how many words were queued.
+func (c *AudioCache) PregenerateLevel(ctx context.Context, level int) (int, error) {
+	var wordIDs []string
+	if err := c.db.SelectContext(ctx, &wordIDs, `
+		SELECT id FROM words
+		WHERE level = $1 AND COALESCE(audio_url, '') = ''
+		ORDER BY word`, level); err != nil {
+		return 0, fmt.Errorf("failed to list words: %w", err)
+	}
+
+	for i, wordID := range wordIDs {
+		if _, err := c.queue.Enqueue(ctx, JobWordAudio, WordAudioJob{WordID: wordID}); err != nil {
+			return i, fmt.Errorf("failed to queue audio for word %s: %w", wordID, err)
+		}
+	}
+
+	return len(wordIDs), nil
+}
+
+// RegisterJobs has worker run the cache's jobs
+func (c *AudioCache) RegisterJobs(worker *jobs.Worker) {
+	worker.Register(JobWordAudio, c.runWordAudio)
+	worker.Register(JobPregenerateLevel, c.runPregenerateLevel)
+}
+
+func (c *AudioCache) runPregenerateLevel(ctx context.Context, job *jobs.Job) error {
+	var payload PregenerateLevelJob
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.Level < MinWordLevel || payload.Level > MaxWordLevel {
+		return jobs.Permanent(fmt.Errorf("word level %d is out of range", payload.Level))
+	}
+
+	_, err := c.PregenerateLevel(ctx, payload.Level)
+	return err
+}
+
+func (c *AudioCache) runWordAudio(ctx context.Context, job *jobs.Job) error {
+	var payload WordAudioJob
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	word := &Word{}
+	if err := c.db.GetContext(ctx, word,
+		"SELECT id, word, language FROM words WHERE id = $1", payload.WordID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return jobs.Permanent(fmt.Errorf("word %s no longer exists", payload.WordID))
+		}
+		return fmt.Errorf("failed to get word: %w", err)
+	}
+
+	return c.EnsureWordAudio(ctx, word)
+}
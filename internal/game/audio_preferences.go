@@ -0,0 +1,119 @@
+package game
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Voices are the TTS voices players can choose to hear words in
+var Voices = []string{"alloy", "echo", "fable", "onyx", "nova", "shimmer"}
+
+const (
+	// DefaultSpeechRate reads words at the TTS voice's normal speed.
+	// MinSpeechRate and MaxSpeechRate bound the rates players can pick.
+	DefaultSpeechRate = 1.0
+	MinSpeechRate     = 0.5
+	MaxSpeechRate     = 2.0
+
+	// slowRepeatRate is how much slower than their usual rate a word is
+	// repeated for players who asked for a slow repeat, and slowestSpeech
+	// the slowest the TTS API reads
+	slowRepeatRate = 0.6
+	slowestSpeech  = 0.25
+)
+
+// AudioPreferences are how a player likes words read out to them, for
+// younger players and those who rely on hearing the word
+type AudioPreferences struct {
+	// Voice is the TTS voice, or empty for the voice of the word's language
+	Voice string  `json:"voice,omitempty" db:"tts_voice"`
+	Rate  float64 `json:"rate" db:"speech_rate"`
+	// SlowRepeat follows the word with a slower reading of it
+	SlowRepeat bool `json:"slow_repeat" db:"slow_repeat"`
+}
+
+// DefaultAudioPreferences are the preferences of players who haven't set
+// any
+func DefaultAudioPreferences() AudioPreferences {
+	return AudioPreferences{Rate: DefaultSpeechRate}
+}
+
+// AudioPreferences returns how userID likes words read out
+func (s *gameService) AudioPreferences(ctx context.Context, userID string) (*AudioPreferences, error) {
+	prefs := DefaultAudioPreferences()
+	err := s.db.GetContext(ctx, &prefs, `
+		SELECT COALESCE(tts_voice, '') AS tts_voice, speech_rate, slow_repeat
+		FROM user_preferences
+		WHERE user_id = $1`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get audio preferences: %w", err)
+	}
+	return &prefs, nil
+}
+
+// UpdateAudioPreferences changes how userID hears words from their next
+// turn on
+func (s *gameService) UpdateAudioPreferences(ctx context.Context, userID string, prefs AudioPreferences) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_preferences (user_id, tts_voice, speech_rate, slow_repeat)
+		VALUES ($1, NULLIF($2, ''), $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET tts_voice = EXCLUDED.tts_voice, speech_rate = EXCLUDED.speech_rate,
+			slow_repeat = EXCLUDED.slow_repeat, updated_at = NOW()`,
+		userID, prefs.Voice, prefs.Rate, prefs.SlowRepeat); err != nil {
+		return fmt.Errorf("failed to update audio preferences: %w", err)
+	}
+	return nil
+}
+
+// personalAudio reads the current word out the way prefs ask, keeping it
+// for the word's other turns. The dictionary's recording is used where the
+// player only asked for a slow repeat.
+func (s *gameService) personalAudio(ctx context.Context, engine *GameEngine, prefs AudioPreferences) ([]byte, error) {
+	s.mu.RLock()
+	word, audio := engine.CurrentWord, engine.personalAudio[prefs]
+	s.mu.RUnlock()
+	if audio != nil {
+		return audio, nil
+	}
+	if word == nil {
+		return nil, ErrNoWordSet
+	}
+
+	voice := prefs.Voice
+	if voice == "" {
+		voice = LanguageVoice(word.Language)
+	}
+
+	var err error
+	if prefs.Voice == "" && prefs.Rate == DefaultSpeechRate {
+		audio, err = s.wordAudio(ctx, engine)
+	} else {
+		audio, err = s.dictService.SynthesizeSpeech(ctx, word.Word, voice, prefs.Rate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word audio: %w", err)
+	}
+
+	if prefs.SlowRepeat {
+		slow, err := s.dictService.SynthesizeSpeech(ctx, word.Word, voice, max(prefs.Rate*slowRepeatRate, slowestSpeech))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get slow word audio: %w", err)
+		}
+		// MP3 streams play one after the other when joined
+		audio = append(slices.Clip(audio), slow...)
+	}
+
+	s.mu.Lock()
+	if engine.CurrentWord == word {
+		if engine.personalAudio == nil {
+			engine.personalAudio = make(map[AudioPreferences][]byte)
+		}
+		engine.personalAudio[prefs] = audio
+	}
+	s.mu.Unlock()
+	return audio, nil
+}
@@ -0,0 +1,445 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v5.27.1
+// source: bigspella/v1/game.proto
+
+package bigspellav1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	GameService_CreateGame_FullMethodName  = "/bigspella.v1.GameService/CreateGame"
+	GameService_JoinGame_FullMethodName    = "/bigspella.v1.GameService/JoinGame"
+	GameService_GetGame_FullMethodName     = "/bigspella.v1.GameService/GetGame"
+	GameService_MakeAttempt_FullMethodName = "/bigspella.v1.GameService/MakeAttempt"
+	GameService_GameEvents_FullMethodName  = "/bigspella.v1.GameService/GameEvents"
+)
+
+// GameServiceClient is the client API for GameService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GameService runs games for internal services, such as matchmaking workers
+// and bot runners. Calls carry an access token in the authorization
+// metadata, "Bearer <token>", the same as the HTTP API. A player's token
+// acts for that player; a service token with the games:delegate scope acts
+// for whichever player a request names.
+type GameServiceClient interface {
+	CreateGame(ctx context.Context, in *CreateGameRequest, opts ...grpc.CallOption) (*Game, error)
+	JoinGame(ctx context.Context, in *JoinGameRequest, opts ...grpc.CallOption) (*Game, error)
+	GetGame(ctx context.Context, in *GetGameRequest, opts ...grpc.CallOption) (*Game, error)
+	MakeAttempt(ctx context.Context, in *MakeAttemptRequest, opts ...grpc.CallOption) (*MakeAttemptResponse, error)
+	// GameEvents follows a game's events. It starts with a snapshot of the
+	// game unless every event after after_seq could be replayed, and ends
+	// with UNAVAILABLE if the caller falls too far behind, when it should
+	// resume from the last event it saw.
+	GameEvents(ctx context.Context, in *GameEventsRequest, opts ...grpc.CallOption) (GameService_GameEventsClient, error)
+}
+
+type gameServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGameServiceClient(cc grpc.ClientConnInterface) GameServiceClient {
+	return &gameServiceClient{cc}
+}
+
+func (c *gameServiceClient) CreateGame(ctx context.Context, in *CreateGameRequest, opts ...grpc.CallOption) (*Game, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Game)
+	err := c.cc.Invoke(ctx, GameService_CreateGame_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameServiceClient) JoinGame(ctx context.Context, in *JoinGameRequest, opts ...grpc.CallOption) (*Game, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Game)
+	err := c.cc.Invoke(ctx, GameService_JoinGame_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameServiceClient) GetGame(ctx context.Context, in *GetGameRequest, opts ...grpc.CallOption) (*Game, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Game)
+	err := c.cc.Invoke(ctx, GameService_GetGame_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameServiceClient) MakeAttempt(ctx context.Context, in *MakeAttemptRequest, opts ...grpc.CallOption) (*MakeAttemptResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MakeAttemptResponse)
+	err := c.cc.Invoke(ctx, GameService_MakeAttempt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gameServiceClient) GameEvents(ctx context.Context, in *GameEventsRequest, opts ...grpc.CallOption) (GameService_GameEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GameService_ServiceDesc.Streams[0], GameService_GameEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gameServiceGameEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GameService_GameEventsClient interface {
+	Recv() (*GameEventsResponse, error)
+	grpc.ClientStream
+}
+
+type gameServiceGameEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *gameServiceGameEventsClient) Recv() (*GameEventsResponse, error) {
+	m := new(GameEventsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GameServiceServer is the server API for GameService service.
+// All implementations must embed UnimplementedGameServiceServer
+// for forward compatibility
+//
+// GameService runs games for internal services, such as matchmaking workers
+// and bot runners. Calls carry an access token in the authorization
+// metadata, "Bearer <token>", the same as the HTTP API. A player's token
+// acts for that player; a service token with the games:delegate scope acts
+// for whichever player a request names.
+type GameServiceServer interface {
+	CreateGame(context.Context, *CreateGameRequest) (*Game, error)
+	JoinGame(context.Context, *JoinGameRequest) (*Game, error)
+	GetGame(context.Context, *GetGameRequest) (*Game, error)
+	MakeAttempt(context.Context, *MakeAttemptRequest) (*MakeAttemptResponse, error)
+	// GameEvents follows a game's events. It starts with a snapshot of the
+	// game unless every event after after_seq could be replayed, and ends
+	// with UNAVAILABLE if the caller falls too far behind, when it should
+	// resume from the last event it saw.
+	GameEvents(*GameEventsRequest, GameService_GameEventsServer) error
+	mustEmbedUnimplementedGameServiceServer()
+}
+
+// UnimplementedGameServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedGameServiceServer struct {
+}
+
+func (UnimplementedGameServiceServer) CreateGame(context.Context, *CreateGameRequest) (*Game, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGame not implemented")
+}
+func (UnimplementedGameServiceServer) JoinGame(context.Context, *JoinGameRequest) (*Game, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinGame not implemented")
+}
+func (UnimplementedGameServiceServer) GetGame(context.Context, *GetGameRequest) (*Game, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGame not implemented")
+}
+func (UnimplementedGameServiceServer) MakeAttempt(context.Context, *MakeAttemptRequest) (*MakeAttemptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MakeAttempt not implemented")
+}
+func (UnimplementedGameServiceServer) GameEvents(*GameEventsRequest, GameService_GameEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GameEvents not implemented")
+}
+func (UnimplementedGameServiceServer) mustEmbedUnimplementedGameServiceServer() {}
+
+// UnsafeGameServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GameServiceServer will
+// result in compilation errors.
+type UnsafeGameServiceServer interface {
+	mustEmbedUnimplementedGameServiceServer()
+}
+
+func RegisterGameServiceServer(s grpc.ServiceRegistrar, srv GameServiceServer) {
+	s.RegisterService(&GameService_ServiceDesc, srv)
+}
+
+func _GameService_CreateGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GameServiceServer).CreateGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GameService_CreateGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GameServiceServer).CreateGame(ctx, req.(*CreateGameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GameService_JoinGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinGameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GameServiceServer).JoinGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GameService_JoinGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GameServiceServer).JoinGame(ctx, req.(*JoinGameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GameService_GetGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GameServiceServer).GetGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GameService_GetGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GameServiceServer).GetGame(ctx, req.(*GetGameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GameService_MakeAttempt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MakeAttemptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GameServiceServer).MakeAttempt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GameService_MakeAttempt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GameServiceServer).MakeAttempt(ctx, req.(*MakeAttemptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GameService_GameEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GameEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GameServiceServer).GameEvents(m, &gameServiceGameEventsServer{ServerStream: stream})
+}
+
+type GameService_GameEventsServer interface {
+	Send(*GameEventsResponse) error
+	grpc.ServerStream
+}
+
+type gameServiceGameEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *gameServiceGameEventsServer) Send(m *GameEventsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// GameService_ServiceDesc is the grpc.ServiceDesc for GameService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GameService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bigspella.v1.GameService",
+	HandlerType: (*GameServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateGame",
+			Handler:    _GameService_CreateGame_Handler,
+		},
+		{
+			MethodName: "JoinGame",
+			Handler:    _GameService_JoinGame_Handler,
+		},
+		{
+			MethodName: "GetGame",
+			Handler:    _GameService_GetGame_Handler,
+		},
+		{
+			MethodName: "MakeAttempt",
+			Handler:    _GameService_MakeAttempt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GameEvents",
+			Handler:       _GameService_GameEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bigspella/v1/game.proto",
+}
+
+const (
+	WordService_GetRandomWord_FullMethodName    = "/bigspella.v1.WordService/GetRandomWord"
+	WordService_ValidateSpelling_FullMethodName = "/bigspella.v1.WordService/ValidateSpelling"
+)
+
+// WordServiceClient is the client API for WordService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WordService serves word data to callers with the games:read or words:read
+// scope
+type WordServiceClient interface {
+	GetRandomWord(ctx context.Context, in *GetRandomWordRequest, opts ...grpc.CallOption) (*Word, error)
+	ValidateSpelling(ctx context.Context, in *ValidateSpellingRequest, opts ...grpc.CallOption) (*ValidateSpellingResponse, error)
+}
+
+type wordServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWordServiceClient(cc grpc.ClientConnInterface) WordServiceClient {
+	return &wordServiceClient{cc}
+}
+
+func (c *wordServiceClient) GetRandomWord(ctx context.Context, in *GetRandomWordRequest, opts ...grpc.CallOption) (*Word, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Word)
+	err := c.cc.Invoke(ctx, WordService_GetRandomWord_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordServiceClient) ValidateSpelling(ctx context.Context, in *ValidateSpellingRequest, opts ...grpc.CallOption) (*ValidateSpellingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateSpellingResponse)
+	err := c.cc.Invoke(ctx, WordService_ValidateSpelling_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WordServiceServer is the server API for WordService service.
+// All implementations must embed UnimplementedWordServiceServer
+// for forward compatibility
+//
+// WordService serves word data to callers with the games:read or words:read
+// scope
+type WordServiceServer interface {
+	GetRandomWord(context.Context, *GetRandomWordRequest) (*Word, error)
+	ValidateSpelling(context.Context, *ValidateSpellingRequest) (*ValidateSpellingResponse, error)
+	mustEmbedUnimplementedWordServiceServer()
+}
+
+// UnimplementedWordServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedWordServiceServer struct {
+}
+
+func (UnimplementedWordServiceServer) GetRandomWord(context.Context, *GetRandomWordRequest) (*Word, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRandomWord not implemented")
+}
+func (UnimplementedWordServiceServer) ValidateSpelling(context.Context, *ValidateSpellingRequest) (*ValidateSpellingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateSpelling not implemented")
+}
+func (UnimplementedWordServiceServer) mustEmbedUnimplementedWordServiceServer() {}
+
+// UnsafeWordServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WordServiceServer will
+// result in compilation errors.
+type UnsafeWordServiceServer interface {
+	mustEmbedUnimplementedWordServiceServer()
+}
+
+func RegisterWordServiceServer(s grpc.ServiceRegistrar, srv WordServiceServer) {
+	s.RegisterService(&WordService_ServiceDesc, srv)
+}
+
+func _WordService_GetRandomWord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRandomWordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordServiceServer).GetRandomWord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WordService_GetRandomWord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordServiceServer).GetRandomWord(ctx, req.(*GetRandomWordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WordService_ValidateSpelling_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateSpellingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordServiceServer).ValidateSpelling(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WordService_ValidateSpelling_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordServiceServer).ValidateSpelling(ctx, req.(*ValidateSpellingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WordService_ServiceDesc is the grpc.ServiceDesc for WordService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WordService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bigspella.v1.WordService",
+	HandlerType: (*WordServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRandomWord",
+			Handler:    _WordService_GetRandomWord_Handler,
+		},
+		{
+			MethodName: "ValidateSpelling",
+			Handler:    _WordService_ValidateSpelling_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "bigspella/v1/game.proto",
+}
@@ -0,0 +1,118 @@
+package game
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// MinCalibrationAttempts is how many judged attempts a word needs before
+	// its empirical level is worked out
+	MinCalibrationAttempts = 20
+
+	MinWordLevel = 1
+	MaxWordLevel = 10
+)
+
+// WordStats is how players have fared with a word across every game
+type WordStats struct {
+	WordID      string          `db:"word_id"`
+	Level       int             `db:"level"`
+	Attempts    int             `db:"attempts"`
+	CorrectRate float64         `db:"correct_rate"`
+	AvgAnswerMS sql.NullFloat64 `db:"avg_answer_ms"`
+}
+
+// empiricalLevel places a word on the 1-10 scale from its miss rate and how
+// much of the turn players needed to answer. The catalogued level is kept
+// as a prior so a word's level shifts gradually as attempts come in.
+func empiricalLevel(stats WordStats) int {
+	difficulty := 1 - stats.CorrectRate
+	if stats.AvgAnswerMS.Valid {
+		pace := math.Min(math.Max(stats.AvgAnswerMS.Float64/float64(TurnTimeout.Milliseconds()), 0), 1)
+		difficulty = 0.75*difficulty + 0.25*pace
+	}
+	observed := float64(MinWordLevel) + difficulty*float64(MaxWordLevel-MinWordLevel)
+
+	weight := float64(stats.Attempts) / float64(stats.Attempts+MinCalibrationAttempts)
+	level := int(math.Round(weight*observed + (1-weight)*float64(stats.Level)))
+
+	return min(max(level, MinWordLevel), MaxWordLevel)
+}
+
+// Calibrator periodically recomputes each word's empirical level from the
+// attempts made on it
+type Calibrator struct {
+	db *sqlx.DB
+}
+
+func NewCalibrator(db *sqlx.DB) *Calibrator {
+	return &Calibrator{db: db}
+}
+
+// Calibrate updates the empirical level of every word with enough judged
+// attempts and returns how many words it updated
+func (c *Calibrator) Calibrate(ctx context.Context) (int, error) {
+	var stats []WordStats
+	if err := c.db.SelectContext(ctx, &stats, `
+		SELECT w.id AS word_id, w.level, COUNT(*) AS attempts,
+			AVG(CASE WHEN a.is_correct THEN 1.0 ELSE 0.0 END) AS correct_rate,
+			AVG(a.answer_ms) AS avg_answer_ms
+		FROM words w
+		JOIN spelling_attempts a ON LOWER(a.word) = LOWER(w.word)
+		WHERE a.status = $1
+		GROUP BY w.id, w.level
+		HAVING COUNT(*) >= $2`,
+		AttemptStatusJudged, MinCalibrationAttempts); err != nil {
+		return 0, fmt.Errorf("failed to get word stats: %w", err)
+	}
+
+	if len(stats) == 0 {
+		return 0, nil
+	}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, word := range stats {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE words
+			SET empirical_level = $1, calibrated_at = $2
+			WHERE id = $3`,
+			empiricalLevel(word), now, word.WordID); err != nil {
+			return 0, fmt.Errorf("failed to update word level: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit calibration: %w", err)
+	}
+
+	return len(stats), nil
+}
+
+// Run calls Calibrate every interval until ctx is cancelled
+func (c *Calibrator) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Calibrate(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package game
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmpiricalLevel(t *testing.T) {
+	// A word everyone misses, slowly, climbs toward the top of the scale
+	hard := WordStats{Level: 3, Attempts: 180, CorrectRate: 0, AvgAnswerMS: sql.NullFloat64{Float64: 9500, Valid: true}}
+	assert.Equal(t, 9, empiricalLevel(hard))
+
+	// A word everyone gets right straight away sinks toward the bottom
+	easy := WordStats{Level: 8, Attempts: 180, CorrectRate: 1, AvgAnswerMS: sql.NullFloat64{Float64: 500, Valid: true}}
+	assert.Equal(t, 2, empiricalLevel(easy))
+
+	// With only the minimum attempts the catalogued level still counts for half
+	fresh := WordStats{Level: 8, Attempts: MinCalibrationAttempts, CorrectRate: 1}
+	assert.Equal(t, 5, empiricalLevel(fresh))
+
+	// Without answer times the miss rate decides on its own
+	untimed := WordStats{Level: 7, Attempts: 380, CorrectRate: 0.5}
+	assert.Equal(t, 6, empiricalLevel(untimed))
+}
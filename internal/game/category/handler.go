@@ -0,0 +1,225 @@
+package category
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+// MaxWordsPerRequest caps how many words one request can attach
+const MaxWordsPerRequest = 500
+
+var slugRX = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type CreateRequest struct {
+	Slug        string              `json:"slug"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Validator   validator.Validator `json:"-"`
+}
+
+func (r *CreateRequest) validate() {
+	r.Validator.CheckField(validator.Matches(r.Slug, slugRX), "slug", "Must be lowercase letters and digits separated by hyphens")
+	r.Validator.CheckField(validator.MaxRunes(r.Slug, 50), "slug", "Must not be more than 50 characters")
+	checkDetails(&r.Validator, r.Name, r.Description)
+}
+
+type UpdateRequest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Validator   validator.Validator `json:"-"`
+}
+
+func (r *UpdateRequest) validate() {
+	checkDetails(&r.Validator, r.Name, r.Description)
+}
+
+func checkDetails(v *validator.Validator, name, description string) {
+	v.CheckField(validator.NotBlank(name), "name", "Must be provided")
+	v.CheckField(validator.MaxRunes(name, 100), "name", "Must not be more than 100 characters")
+	v.CheckField(validator.MaxRunes(description, 500), "description", "Must not be more than 500 characters")
+}
+
+type AddWordsRequest struct {
+	WordIDs   []string            `json:"word_ids"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *AddWordsRequest) validate() {
+	r.Validator.CheckField(len(r.WordIDs) > 0, "word_ids", "Must contain at least one word")
+	r.Validator.CheckField(len(r.WordIDs) <= MaxWordsPerRequest, "word_ids", "Must not contain more than 500 words")
+	r.Validator.CheckField(validator.NoDuplicates(r.WordIDs), "word_ids", "Must not contain duplicates")
+	for _, id := range r.WordIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			r.Validator.AddFieldError("word_ids", "Must only contain valid word IDs")
+			break
+		}
+	}
+}
+
+// List serves every category with its word counts per level
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"categories": categories})
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	category, err := h.service.Create(r.Context(), req.Slug, req.Name, req.Description)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDuplicateSlug):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	categoryID := httprouter.ParamsFromContext(r.Context()).ByName("categoryID")
+	if !validID(w, "category_id", categoryID) {
+		return
+	}
+
+	var req UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	category, err := h.service.Update(r.Context(), categoryID, req.Name, req.Description)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	categoryID := httprouter.ParamsFromContext(r.Context()).ByName("categoryID")
+	if !validID(w, "category_id", categoryID) {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), categoryID); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddWords attaches words to the category's curated list
+func (h *Handler) AddWords(w http.ResponseWriter, r *http.Request) {
+	categoryID := httprouter.ParamsFromContext(r.Context()).ByName("categoryID")
+	if !validID(w, "category_id", categoryID) {
+		return
+	}
+
+	var req AddWordsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	if err := h.service.AddWords(r.Context(), categoryID, req.WordIDs); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveWord takes a word off the category's curated list
+func (h *Handler) RemoveWord(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	categoryID, wordID := params.ByName("categoryID"), params.ByName("wordID")
+	if !validID(w, "category_id", categoryID) || !validID(w, "word_id", wordID) {
+		return
+	}
+
+	if err := h.service.RemoveWord(r.Context(), categoryID, wordID); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCategoryNotFound), errors.Is(err, ErrWordNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// validID checks a UUID path parameter and responds with 422 when it isn't
+// one
+func validID(w http.ResponseWriter, field, id string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, field, "Must be a valid ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package category
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRequestValidation(t *testing.T) {
+	req := CreateRequest{Slug: "ocean-life", Name: "Ocean life"}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = CreateRequest{Slug: "Ocean Life", Description: strings.Repeat("x", 501)}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "slug")
+	assert.Contains(t, req.Validator.FieldErrors, "name")
+	assert.Contains(t, req.Validator.FieldErrors, "description")
+}
+
+func TestAddWordsRequestValidation(t *testing.T) {
+	id := uuid.New().String()
+
+	req := AddWordsRequest{WordIDs: []string{id}}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	for _, ids := range [][]string{nil, {id, id}, {"not-a-uuid"}} {
+		req = AddWordsRequest{WordIDs: ids}
+		req.validate()
+		assert.Contains(t, req.Validator.FieldErrors, "word_ids", ids)
+	}
+}
+
+func TestRemoveWordChecksIDs(t *testing.T) {
+	h := NewHandler(nil)
+	req := httptest.NewRequest(http.MethodDelete, "/categories/x/words/y", nil)
+	req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+		{Key: "categoryID", Value: uuid.New().String()},
+		{Key: "wordID", Value: "y"},
+	}))
+	rec := httptest.NewRecorder()
+
+	h.RemoveWord(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "word_id")
+}
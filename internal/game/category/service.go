@@ -0,0 +1,235 @@
+package category
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrDuplicateSlug    = errors.New("a category with this slug already exists")
+	ErrWordNotFound     = errors.New("word not found")
+)
+
+// Postgres error codes the service turns into its own errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Category is a curated list of words games can be restricted to
+type Category struct {
+	ID          string    `json:"id" db:"id"`
+	Slug        string    `json:"slug" db:"slug"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Summary is a category with how many of its words sit at each level
+type Summary struct {
+	Category
+	WordCount int         `json:"word_count"`
+	Levels    map[int]int `json:"levels"`
+}
+
+type Service struct {
+	db    *sqlx.DB
+	audit audit.Recorder
+}
+
+// ServiceOption configures optional Service dependencies
+type ServiceOption func(*Service)
+
+// WithAuditLog records changes to categories and their words in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *Service) {
+		s.audit = log
+	}
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Service) record(ctx context.Context, action audit.Action, id string, before, after any) {
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: action, TargetType: "category", TargetID: id, Before: before, After: after})
+	}
+}
+
+// List returns every category with its word counts per level
+func (s *Service) List(ctx context.Context) ([]Summary, error) {
+	var categories []Category
+	if err := s.db.SelectContext(ctx, &categories, `
+		SELECT id, slug, name, description, created_at, updated_at
+		FROM categories
+		ORDER BY name`); err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	var counts []struct {
+		CategoryID string `db:"category_id"`
+		Level      int    `db:"level"`
+		Count      int    `db:"count"`
+	}
+	if err := s.db.SelectContext(ctx, &counts, `
+		SELECT wc.category_id, w.level, COUNT(*) AS count
+		FROM word_categories wc
+		JOIN words w ON w.id = wc.word_id
+		GROUP BY wc.category_id, w.level`); err != nil {
+		return nil, fmt.Errorf("failed to count category words: %w", err)
+	}
+
+	summaries := make([]Summary, len(categories))
+	index := make(map[string]*Summary, len(categories))
+	for i, category := range categories {
+		summaries[i] = Summary{Category: category, Levels: map[int]int{}}
+		index[category.ID] = &summaries[i]
+	}
+	for _, count := range counts {
+		if summary := index[count.CategoryID]; summary != nil {
+			summary.Levels[count.Level] = count.Count
+			summary.WordCount += count.Count
+		}
+	}
+
+	return summaries, nil
+}
+
+// Get returns the category with id
+func (s *Service) Get(ctx context.Context, id string) (*Category, error) {
+	var category Category
+	if err := s.db.GetContext(ctx, &category, `
+		SELECT id, slug, name, description, created_at, updated_at
+		FROM categories
+		WHERE id = $1`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return &category, nil
+}
+
+// Exists reports whether a category with slug exists
+func (s *Service) Exists(ctx context.Context, slug string) (bool, error) {
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, `
+		SELECT EXISTS (SELECT 1 FROM categories WHERE slug = $1)`, slug); err != nil {
+		return false, fmt.Errorf("failed to look up category: %w", err)
+	}
+	return exists, nil
+}
+
+// Create adds a category
+func (s *Service) Create(ctx context.Context, slug, name, description string) (*Category, error) {
+	var category Category
+	if err := s.db.GetContext(ctx, &category, `
+		INSERT INTO categories (slug, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, slug, name, description, created_at, updated_at`,
+		slug, name, description); err != nil {
+		if hasCode(err, uniqueViolation) {
+			return nil, ErrDuplicateSlug
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+	s.record(ctx, audit.ActionCategoryCreated, category.ID, nil, category)
+	return &category, nil
+}
+
+// Update renames or re-describes a category. Its slug never changes, since
+// game settings refer to it.
+func (s *Service) Update(ctx context.Context, id, name, description string) (*Category, error) {
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var category Category
+	if err := s.db.GetContext(ctx, &category, `
+		UPDATE categories
+		SET name = $1, description = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING id, slug, name, description, created_at, updated_at`,
+		name, description, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	s.record(ctx, audit.ActionCategoryUpdated, id, before, category)
+	return &category, nil
+}
+
+// Delete removes a category. Its words stay in the dictionary.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrCategoryNotFound
+	}
+	s.record(ctx, audit.ActionCategoryDeleted, id, before, nil)
+	return nil
+}
+
+// AddWords attaches words to a category, skipping any already in it
+func (s *Service) AddWords(ctx context.Context, id string, wordIDs []string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO word_categories (word_id, category_id)
+		SELECT UNNEST($1::uuid[]), $2
+		ON CONFLICT DO NOTHING`,
+		pq.Array(wordIDs), id); err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return ErrWordNotFound
+		}
+		return fmt.Errorf("failed to add words to category: %w", err)
+	}
+	s.record(ctx, audit.ActionWordsAdded, id, nil, map[string]any{"word_ids": wordIDs})
+	return nil
+}
+
+// RemoveWord detaches a word from a category
+func (s *Service) RemoveWord(ctx context.Context, id, wordID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM word_categories
+		WHERE category_id = $1 AND word_id = $2`, id, wordID)
+	if err != nil {
+		return fmt.Errorf("failed to remove word from category: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrWordNotFound
+	}
+	s.record(ctx, audit.ActionWordRemoved, id, map[string]any{"word_id": wordID}, nil)
+	return nil
+}
+
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
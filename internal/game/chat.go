@@ -0,0 +1,105 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"big-spella-go/internal/reports"
+)
+
+// MaxChatLength bounds a chat message, in characters
+const MaxChatLength = 500
+
+// ChatRetention is how long chat messages are kept for reports on them to
+// be checked against
+const ChatRetention = 7 * 24 * time.Hour
+
+var (
+	ErrChatEmpty   = errors.New("chat message is empty")
+	ErrChatTooLong = errors.New("chat message is too long")
+	ErrMuted       = errors.New("you are muted and can't chat right now")
+)
+
+// MuteChecker finds the mute keeping a player from chatting, nil when there
+// isn't one. reports.Service implements it.
+type MuteChecker interface {
+	Muted(ctx context.Context, userID string) (*reports.Mute, error)
+}
+
+// WithMutes keeps muted players from chatting
+func WithMutes(mutes MuteChecker) ServiceOption {
+	return func(s *gameService) {
+		s.mutes = mutes
+	}
+}
+
+// SendChat shares a message with everyone watching the game. Only players
+// still in the game, and not muted, can chat. The message is kept for
+// ChatRetention, so it can be reported by its ID.
+func (s *gameService) SendChat(ctx context.Context, gameID, playerID, message string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return ErrChatEmpty
+	}
+	if utf8.RuneCountInString(message) > MaxChatLength {
+		return ErrChatTooLong
+	}
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	if !inGame(game, playerID) {
+		return ErrPlayerNotFound
+	}
+
+	if s.mutes != nil {
+		mute, err := s.mutes.Muted(ctx, playerID)
+		if err != nil {
+			return err
+		}
+		if mute != nil {
+			return ErrMuted
+		}
+	}
+
+	messageID := uuid.New().String()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO chat_messages (id, game_id, sender_id, message)
+		VALUES ($1, $2, $3, $4)`, messageID, gameID, playerID, message); err != nil {
+		return fmt.Errorf("failed to save chat message: %w", err)
+	}
+
+	s.emitEvent(EventTypeChatMessage, gameID, &playerID, map[string]any{
+		"message_id": messageID,
+		"player_id":  playerID,
+		"message":    message,
+	})
+	return nil
+}
+
+// purgeChat deletes the chat messages older than ChatRetention
+func (s *gameService) purgeChat(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM chat_messages WHERE created_at < $1`, time.Now().Add(-ChatRetention)); err != nil {
+		return fmt.Errorf("failed to purge chat messages: %w", err)
+	}
+	return nil
+}
+
+// inGame reports whether playerID joined game and hasn't left or been
+// kicked since
+func inGame(game *Game, playerID string) bool {
+	for _, p := range game.Players {
+		if p != nil && p.UserID == playerID {
+			return p.Status != "left" && p.Status != "kicked"
+		}
+	}
+	return false
+}
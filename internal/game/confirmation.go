@@ -0,0 +1,204 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultConfirmationThreshold is the transcription confidence below
+	// which players are asked to confirm, when the game doesn't set one
+	DefaultConfirmationThreshold = 0.6
+	DefaultConfirmationTimeout   = 10 * time.Second
+	MaxConfirmationTimeout       = time.Minute
+)
+
+var (
+	ErrConfirmationPending   = errors.New("a transcription is awaiting the player's confirmation")
+	ErrNoPendingConfirmation = errors.New("no transcription is awaiting confirmation")
+)
+
+// ConfirmationSettings ask players to confirm how a voice attempt the
+// recogniser wasn't sure about was transcribed before it's judged, so they
+// aren't failed for the recogniser's mistakes
+type ConfirmationSettings struct {
+	Enabled bool `json:"enabled"`
+	// Threshold is the transcription confidence, from 0 to 1, below which
+	// the player is asked. Zero means DefaultConfirmationThreshold.
+	Threshold float64 `json:"threshold"`
+	// Timeout is how long the player has to answer before the attempt is
+	// judged as transcribed. Zero means DefaultConfirmationTimeout.
+	Timeout time.Duration `json:"timeout"`
+}
+
+func (c ConfirmationSettings) threshold() float64 {
+	if c.Threshold <= 0 {
+		return DefaultConfirmationThreshold
+	}
+	return c.Threshold
+}
+
+func (c ConfirmationSettings) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultConfirmationTimeout
+	}
+	return c.Timeout
+}
+
+// PendingConfirmation is a voice attempt waiting on the player to confirm
+// its transcription. The answer clock keeps running, but the turn doesn't
+// pass while one is open.
+type PendingConfirmation struct {
+	Attempt  *SpellingAttempt `json:"attempt"`
+	Deadline time.Time        `json:"deadline"`
+	// provider transcribed the attempt, for its accuracy metrics
+	provider string
+}
+
+// needsConfirmation reports whether the player should confirm attempt's
+// transcription before it's judged
+func needsConfirmation(game *Game, attempt *SpellingAttempt) bool {
+	c := game.Settings.Confirmation
+	return c.Enabled && attempt.Type == AttemptTypeVoice && attempt.Confidence != nil && *attempt.Confidence < c.threshold()
+}
+
+func (s *gameService) pendingConfirmation(gameID string) *PendingConfirmation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if engine := s.activeGames[gameID]; engine != nil {
+		return engine.Confirmation
+	}
+	return nil
+}
+
+// takeConfirmation clears the open confirmation for attemptID and returns
+// it, so that exactly one of the player and the timer gets to answer
+func (s *gameService) takeConfirmation(gameID, attemptID string) *PendingConfirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engine := s.activeGames[gameID]
+	if engine == nil || engine.Confirmation == nil || engine.Confirmation.Attempt.ID != attemptID {
+		return nil
+	}
+
+	pending := engine.Confirmation
+	engine.Confirmation = nil
+	engine.Phase = PhaseAnswering
+	return pending
+}
+
+// requestConfirmation holds attempt until its player confirms or rejects
+// the transcription. If they don't answer in time it's judged as
+// transcribed.
+func (s *gameService) requestConfirmation(game *Game, engine *GameEngine, attempt *SpellingAttempt, provider string) {
+	timeout := game.Settings.Confirmation.timeout()
+	attempt.Status = AttemptStatusPendingConfirmation
+	pending := &PendingConfirmation{
+		Attempt:  attempt,
+		Deadline: time.Now().Add(timeout),
+		provider: provider,
+	}
+
+	s.mu.Lock()
+	engine.Confirmation = pending
+	engine.Phase = PhaseConfirming
+	s.mu.Unlock()
+
+	gameID, attemptID := game.ID, attempt.ID
+	s.timers.Schedule(timerKey(gameID, "confirmation"), timeout, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		ctx, unlock := s.lockEngine(ctx, gameID)
+		defer unlock()
+
+		pending := s.takeConfirmation(gameID, attemptID)
+		if pending == nil {
+			return
+		}
+		_ = s.finishConfirmation(ctx, gameID, pending, false)
+	})
+
+	// Only the player is told what they were heard to spell
+	s.emitEvent(EventTypeConfirmationRequested, gameID, &attempt.PlayerID, withCountdown(map[string]any{
+		"attempt_id":    attempt.ID,
+		"player_id":     attempt.PlayerID,
+		"transcription": onlyFor{viewers: []string{attempt.PlayerID}, value: attempt.Text},
+		"confidence":    attempt.Confidence,
+		"deadline":      pending.Deadline,
+	}, time.Now(), timeout))
+}
+
+// ConfirmAttempt answers the confirmation of the transcription of
+// playerID's voice attempt. A confirmed attempt is judged as transcribed,
+// without going to a judge; a rejected one is thrown away and the player
+// can answer again while their answer window is open.
+func (s *gameService) ConfirmAttempt(ctx context.Context, gameID, playerID, attemptID string, confirmed bool) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	pending := s.pendingConfirmation(gameID)
+	if pending == nil || pending.Attempt.ID != attemptID || pending.Attempt.PlayerID != playerID {
+		return ErrNoPendingConfirmation
+	}
+	if pending = s.takeConfirmation(gameID, attemptID); pending == nil {
+		// Time ran out first
+		return ErrNoPendingConfirmation
+	}
+	s.timers.Cancel(timerKey(gameID, "confirmation"))
+
+	if confirmed {
+		return s.finishConfirmation(ctx, gameID, pending, true)
+	}
+
+	s.emitEvent(EventTypeConfirmationResolved, gameID, &playerID, map[string]any{
+		"attempt_id": attemptID,
+		"player_id":  playerID,
+		"confirmed":  false,
+	})
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	engine := s.engine(gameID)
+	if engine == nil {
+		return ErrGameNotFound
+	}
+	// The turn may have run out while the player was deciding, in which
+	// case it passes on straight away
+	s.startPlayerTurn(game, engine)
+	return nil
+}
+
+// finishConfirmation judges a confirmed attempt, or one whose player didn't
+// answer in time
+func (s *gameService) finishConfirmation(ctx context.Context, gameID string, pending *PendingConfirmation, confirmed bool) error {
+	attempt := pending.Attempt
+	s.emitEvent(EventTypeConfirmationResolved, gameID, &attempt.PlayerID, map[string]any{
+		"attempt_id": attempt.ID,
+		"player_id":  attempt.PlayerID,
+		"confirmed":  confirmed,
+	})
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	engine := s.engine(gameID)
+	if engine == nil {
+		return ErrGameNotFound
+	}
+	return s.judgeAttempt(ctx, game, engine, attempt, pending.provider, confirmed)
+}
+
+// dropConfirmation throws away playerID's attempt awaiting confirmation,
+// when they leave the game
+func (s *gameService) dropConfirmation(gameID, playerID string) {
+	if pending := s.pendingConfirmation(gameID); pending != nil && pending.Attempt.PlayerID == playerID &&
+		s.takeConfirmation(gameID, pending.Attempt.ID) != nil {
+		s.timers.Cancel(timerKey(gameID, "confirmation"))
+	}
+}
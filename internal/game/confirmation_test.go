@@ -0,0 +1,79 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNeedsConfirmation(t *testing.T) {
+	low, high := 0.4, 0.9
+	game := &Game{}
+	game.Settings.Confirmation.Enabled = true
+
+	assert.True(t, needsConfirmation(game, &SpellingAttempt{Type: AttemptTypeVoice, Confidence: &low}))
+	assert.False(t, needsConfirmation(game, &SpellingAttempt{Type: AttemptTypeVoice, Confidence: &high}))
+	assert.False(t, needsConfirmation(game, &SpellingAttempt{Type: AttemptTypeText, Confidence: &low}))
+
+	game.Settings.Confirmation.Threshold = 0.3
+	assert.False(t, needsConfirmation(game, &SpellingAttempt{Type: AttemptTypeVoice, Confidence: &low}))
+
+	game.Settings.Confirmation = ConfirmationSettings{}
+	assert.False(t, needsConfirmation(game, &SpellingAttempt{Type: AttemptTypeVoice, Confidence: &low}), "confirmation is off unless enabled")
+
+	assert.Equal(t, DefaultConfirmationTimeout, ConfirmationSettings{}.timeout())
+}
+
+func TestRequestingConfirmation(t *testing.T) {
+	s := &gameService{events: newEventLog(), timers: newTimerSet(), activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	engine.Phase = PhaseAnswering
+	s.activeGames["game-1"] = engine
+
+	player := s.Subscribe("game-1", "ada", 0)
+	defer player.Cancel()
+	spectator := s.Subscribe("game-1", "bo", 0)
+	defer spectator.Cancel()
+
+	confidence := 0.3
+	attempt := &SpellingAttempt{ID: "attempt-1", PlayerID: "ada", Type: AttemptTypeVoice, Text: "bumblebea", Confidence: &confidence}
+	game := &Game{ID: "game-1"}
+	game.Settings.Confirmation = ConfirmationSettings{Enabled: true, Timeout: time.Hour}
+	s.requestConfirmation(game, engine, attempt, "openai")
+	defer s.timers.Cancel(timerKey("game-1", "confirmation"))
+
+	assert.Equal(t, AttemptStatusPendingConfirmation, attempt.Status)
+	assert.Equal(t, PhaseConfirming, engine.Phase)
+
+	event := <-player.Events
+	assert.Equal(t, EventTypeConfirmationRequested, event.Type)
+	assert.Equal(t, "bumblebea", event.Payload["transcription"])
+	assert.Equal(t, time.Hour.Milliseconds(), event.Payload["remaining_ms"])
+	event = <-spectator.Events
+	assert.Nil(t, event.Payload["transcription"], "only the player is told what they were heard to spell")
+
+	// Only the player can answer, and only once
+	err := s.ConfirmAttempt(context.Background(), "game-1", "bo", "attempt-1", true)
+	assert.ErrorIs(t, err, ErrNoPendingConfirmation)
+	err = s.ConfirmAttempt(context.Background(), "game-1", "ada", "attempt-2", true)
+	assert.ErrorIs(t, err, ErrNoPendingConfirmation)
+
+	require.NotNil(t, s.takeConfirmation("game-1", "attempt-1"))
+	assert.Nil(t, s.takeConfirmation("game-1", "attempt-1"))
+	assert.Equal(t, PhaseAnswering, engine.Phase)
+}
+
+func TestLeavingDropsConfirmation(t *testing.T) {
+	s := &gameService{events: newEventLog(), timers: newTimerSet(), activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	engine.Confirmation = &PendingConfirmation{Attempt: &SpellingAttempt{ID: "attempt-1", PlayerID: "ada"}}
+	s.activeGames["game-1"] = engine
+
+	s.dropConfirmation("game-1", "bo")
+	assert.NotNil(t, engine.Confirmation)
+	s.dropConfirmation("game-1", "ada")
+	assert.Nil(t, engine.Confirmation)
+}
@@ -0,0 +1,14 @@
+package game
+
+import "time"
+
+// withCountdown adds the server's time and the milliseconds left on a turn
+// clock to an event's payload. Clients count remaining_ms down from when the
+// event arrives, rather than from a deadline read against their own clock,
+// so every client shows the same countdown however far their clocks are
+// off. server_time, with GET /time, lets them work out how far that is.
+func withCountdown(payload map[string]any, now time.Time, remaining time.Duration) map[string]any {
+	payload["server_time"] = now
+	payload["remaining_ms"] = remaining.Milliseconds()
+	return payload
+}
@@ -0,0 +1,193 @@
+package daily
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultLeaderboardSize = 50
+	MaxLeaderboardSize     = 100
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type StartRequest struct {
+	Level     int                 `json:"level"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *StartRequest) validate() {
+	r.Validator.CheckField(validator.Between(r.Level, game.MinWordLevel, game.MaxWordLevel), "level", "Must be between 1 and 10")
+}
+
+type SubmitRequest struct {
+	// Date is the day the challenge was started, today when empty
+	Date      string              `json:"date"`
+	Answers   map[string]string   `json:"answers"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *SubmitRequest) validate() {
+	if r.Date != "" {
+		_, err := time.Parse(DateLayout, r.Date)
+		r.Validator.CheckField(err == nil, "date", "Must be a date like 2006-01-02")
+	}
+	r.Validator.CheckField(len(r.Answers) <= WordsPerChallenge, "answers", "Must not have more than 5 answers")
+	for _, answer := range r.Answers {
+		r.Validator.CheckField(validator.MaxRunes(answer, 100), "answers", "Must not be more than 100 characters each")
+	}
+}
+
+type LeaderboardRequest struct {
+	Date      string
+	Level     int
+	Limit     int
+	Validator validator.Validator
+}
+
+func parseLeaderboardRequest(query url.Values, today string) LeaderboardRequest {
+	req := LeaderboardRequest{Date: today, Level: game.MinWordLevel, Limit: DefaultLeaderboardSize}
+	if date := query.Get("date"); date != "" {
+		req.Date = date
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("level", &req.Level)
+	readInt("limit", &req.Limit)
+
+	return req
+}
+
+func (r *LeaderboardRequest) validate() {
+	_, err := time.Parse(DateLayout, r.Date)
+	r.Validator.CheckField(err == nil, "date", "Must be a date like 2006-01-02")
+	r.Validator.CheckField(validator.Between(r.Level, game.MinWordLevel, game.MaxWordLevel), "level", "Must be between 1 and 10")
+	r.Validator.CheckField(validator.Between(r.Limit, 1, MaxLeaderboardSize), "limit", "Must be between 1 and 100")
+}
+
+// Start begins the caller's play of today's challenge and serves its clues
+func (h *Handler) Start(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	challenge, err := h.service.Start(r.Context(), userID, req.Level)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// Submit grades the caller's answers to the challenge they started
+func (h *Handler) Submit(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req SubmitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	date := req.Date
+	if date == "" {
+		date = h.service.Today()
+	}
+	result, err := h.service.Submit(r.Context(), userID, date, req.Answers)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Leaderboard serves the best plays of a day's challenge at a level,
+// today's at level 1 by default
+func (h *Handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	req := parseLeaderboardRequest(r.URL.Query(), h.service.Today())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	entries, err := h.service.Leaderboard(r.Context(), req.Date, req.Level, req.Limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"date":    req.Date,
+		"level":   req.Level,
+		"entries": entries,
+	})
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNoWords), errors.Is(err, ErrNotStarted):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrAlreadyPlayed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
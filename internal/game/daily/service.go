@@ -0,0 +1,377 @@
+// Package daily runs the daily challenge: the same few words for everyone
+// playing a level on a given day, one try per player per day, a leaderboard
+// for each day and level, and streaks for playing day after day.
+package daily
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/respell"
+	"big-spella-go/internal/infrastructure/aws/dynamodb"
+)
+
+const (
+	// WordsPerChallenge is how many words a day's challenge has at each
+	// level
+	WordsPerChallenge = 5
+	// TimeLimit is how long a player has to earn a time bonus once they
+	// start; answers sent later still count, without the bonus
+	TimeLimit = 10 * time.Minute
+
+	pointsPerWord = 100
+	// maxTimeBonus is earned by answering every word correctly straight away
+	maxTimeBonus = 100
+
+	// DateLayout is how challenge days are written, always in UTC
+	DateLayout = "2006-01-02"
+
+	statusInProgress = "in_progress"
+	statusCompleted  = "completed"
+)
+
+var (
+	ErrDisabled      = errors.New("daily challenges are not configured")
+	ErrNoWords       = errors.New("no words at this level")
+	ErrAlreadyPlayed = errors.New("you have already played today's challenge")
+	ErrNotStarted    = errors.New("you haven't started this challenge")
+)
+
+// Store keeps daily challenge plays as solo games
+type Store interface {
+	CreateSoloGame(ctx context.Context, game *dynamodb.SoloGame) error
+	GetSoloGame(ctx context.Context, id string) (*dynamodb.SoloGame, error)
+	ReplaceSoloGame(ctx context.Context, game *dynamodb.SoloGame, fromStatus string) error
+	TopDailyScores(ctx context.Context, board string, limit int) ([]dynamodb.SoloGame, error)
+}
+
+// Speller decides whether an answer spells a word
+type Speller interface {
+	ValidateSpelling(ctx context.Context, word, attempt string) bool
+}
+
+// Clue is what a player is given for each word: everything but its spelling
+type Clue struct {
+	ID            string `json:"id"`
+	Definition    string `json:"definition"`
+	PartOfSpeech  string `json:"part_of_speech"`
+	Pronunciation string `json:"pronunciation"`
+	Respelling    string `json:"respelling"`
+	AudioURL      string `json:"audio_url"`
+}
+
+// Challenge is a started play of a day's challenge
+type Challenge struct {
+	Date      string    `json:"date"`
+	Level     int       `json:"level"`
+	Words     []Clue    `json:"words"`
+	StartedAt time.Time `json:"started_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// WordResult is how a player did on one word
+type WordResult struct {
+	ID      string `json:"id"`
+	Word    string `json:"word"`
+	Answer  string `json:"answer"`
+	Correct bool   `json:"correct"`
+}
+
+// Result is a player's completed challenge
+type Result struct {
+	Date          string       `json:"date"`
+	Level         int          `json:"level"`
+	Score         int          `json:"score"`
+	Correct       int          `json:"correct"`
+	Words         []WordResult `json:"words"`
+	CompletedAt   time.Time    `json:"completed_at"`
+	CurrentStreak int          `json:"current_streak"`
+	LongestStreak int          `json:"longest_streak"`
+}
+
+// Entry is a player's place on a day's leaderboard. Players with the same
+// score share a rank.
+type Entry struct {
+	Rank        int       `json:"rank"`
+	UserID      string    `json:"user_id"`
+	Username    string    `json:"username"`
+	Score       int       `json:"score"`
+	Correct     int       `json:"correct"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type Service struct {
+	db      *sqlx.DB
+	reads   Reader
+	store   Store
+	speller Speller
+	now     func() time.Time
+}
+
+// Reader runs read-only queries, such as against read replicas
+type Reader interface {
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+type ServiceOption func(*Service)
+
+// WithReadReplicas looks up the names on leaderboards in reads
+func WithReadReplicas(reads Reader) ServiceOption {
+	return func(s *Service) {
+		s.reads = reads
+	}
+}
+
+// NewService keeps plays in store. With a nil store every call fails with
+// ErrDisabled.
+func NewService(db *sqlx.DB, store Store, speller Speller, opts ...ServiceOption) *Service {
+	s := &Service{db: db, reads: db, store: store, speller: speller, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Today is the current challenge day
+func (s *Service) Today() string {
+	return s.now().UTC().Format(DateLayout)
+}
+
+func playID(date, userID string) string { return "daily#" + date + "#" + userID }
+
+func board(date string, level int) string { return fmt.Sprintf("%s#%d", date, level) }
+
+// word is the part of a words row a challenge needs
+type word struct {
+	ID            string `db:"id"`
+	Word          string `db:"word"`
+	Definition    string `db:"definition"`
+	PartOfSpeech  string `db:"part_of_speech"`
+	Pronunciation string `db:"pronunciation"`
+	Respelling    string `db:"respelling"`
+	AudioURL      string `db:"audio_url"`
+}
+
+const wordColumns = `id, word, definition,
+	COALESCE(part_of_speech, '') AS part_of_speech,
+	COALESCE(pronunciation, '') AS pronunciation,
+	COALESCE(respelling, '') AS respelling,
+	COALESCE(audio_url, '') AS audio_url`
+
+// words picks a day's words for a level. Ordering by a hash of each word's
+// ID and the day gives everyone the same words without storing the picks.
+// The challenge is in English for now.
+func (s *Service) words(ctx context.Context, date string, level int) ([]word, error) {
+	words := []word{}
+	if err := s.db.SelectContext(ctx, &words, `
+		SELECT `+wordColumns+` FROM words
+		WHERE level = $1 AND language = $4 AND status = 'published'
+		ORDER BY md5(id::text || $2), id
+		LIMIT $3`, level, date, WordsPerChallenge, game.DefaultLanguage); err != nil {
+		return nil, fmt.Errorf("failed to pick daily words: %w", err)
+	}
+	if len(words) == 0 {
+		return nil, ErrNoWords
+	}
+	return words, nil
+}
+
+// Start begins userID's one play of today's challenge at level
+func (s *Service) Start(ctx context.Context, userID string, level int) (*Challenge, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	date, now := s.Today(), s.now()
+	words, err := s.words(ctx, date, level)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &Challenge{Date: date, Level: level, StartedAt: now, EndsAt: now.Add(TimeLimit)}
+	ids := make([]string, len(words))
+	for i, w := range words {
+		ids[i] = w.ID
+		respelling := w.Respelling
+		if respelling == "" {
+			respelling = respell.Respell(w.Pronunciation)
+		}
+		challenge.Words = append(challenge.Words, Clue{
+			ID:            w.ID,
+			Definition:    w.Definition,
+			PartOfSpeech:  w.PartOfSpeech,
+			Pronunciation: w.Pronunciation,
+			Respelling:    respelling,
+			AudioURL:      w.AudioURL,
+		})
+	}
+
+	if err := s.store.CreateSoloGame(ctx, &dynamodb.SoloGame{
+		ID:            playID(date, userID),
+		UserID:        userID,
+		Status:        statusInProgress,
+		StartedAt:     now,
+		CreatedAt:     now,
+		ChallengeDate: date,
+		Level:         level,
+		WordIDs:       ids,
+	}); err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameExists) {
+			return nil, ErrAlreadyPlayed
+		}
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// Submit grades userID's answers, keyed by word ID, to the challenge they
+// started on date, and extends their streak
+func (s *Service) Submit(ctx context.Context, userID, date string, answers map[string]string) (*Result, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	play, err := s.store.GetSoloGame(ctx, playID(date, userID))
+	if err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameNotFound) {
+			return nil, ErrNotStarted
+		}
+		return nil, err
+	}
+	if play.Status != statusInProgress {
+		return nil, ErrAlreadyPlayed
+	}
+
+	words := []word{}
+	if err := s.db.SelectContext(ctx, &words, `
+		SELECT `+wordColumns+` FROM words WHERE id = ANY($1)`, pq.Array(play.WordIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get daily words: %w", err)
+	}
+	byID := make(map[string]word, len(words))
+	for _, w := range words {
+		byID[w.ID] = w
+	}
+
+	now := s.now()
+	result := &Result{Date: play.ChallengeDate, Level: play.Level, CompletedAt: now}
+	for _, id := range play.WordIDs {
+		w, ok := byID[id]
+		if !ok {
+			// Deleted since the challenge started; nobody is marked down
+			continue
+		}
+		answer := answers[id]
+		correct := answer != "" && s.speller.ValidateSpelling(ctx, w.Word, answer)
+		if correct {
+			result.Correct++
+		}
+		result.Words = append(result.Words, WordResult{ID: id, Word: w.Word, Answer: answer, Correct: correct})
+		play.Attempts = append(play.Attempts, dynamodb.Attempt{Word: answer, Type: "text", IsCorrect: correct, Timestamp: now})
+	}
+	result.Score = score(result.Correct, len(result.Words), now.Sub(play.StartedAt))
+
+	play.Status = statusCompleted
+	play.Score = result.Score
+	play.CompletedAt = now
+	play.DailyBoard = board(play.ChallengeDate, play.Level)
+	if err := s.store.ReplaceSoloGame(ctx, play, statusInProgress); err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameChanged) {
+			return nil, ErrAlreadyPlayed
+		}
+		return nil, err
+	}
+
+	// Playing again the same day leaves the streak alone, and a late
+	// answer to yesterday's challenge can't undo today's
+	if err := s.db.QueryRowxContext(ctx, `
+		UPDATE users u SET
+			current_streak = next.streak,
+			longest_streak = GREATEST(u.longest_streak, next.streak),
+			last_daily_date = GREATEST(u.last_daily_date, $1::date)
+		FROM (
+			SELECT CASE
+				WHEN last_daily_date = $1::date - 1 THEN current_streak + 1
+				WHEN last_daily_date >= $1::date THEN current_streak
+				ELSE 1 END AS streak
+			FROM users WHERE id = $2
+		) next
+		WHERE u.id = $2
+		RETURNING u.current_streak, u.longest_streak`, play.ChallengeDate, userID,
+	).Scan(&result.CurrentStreak, &result.LongestStreak); err != nil {
+		return nil, fmt.Errorf("failed to update streak: %w", err)
+	}
+	return result, nil
+}
+
+// score awards points for each correct word, plus a bonus for answering
+// quickly that shrinks with every word missed
+func score(correct, total int, elapsed time.Duration) int {
+	if total == 0 {
+		return 0
+	}
+	points := correct * pointsPerWord
+	if remaining := TimeLimit - elapsed; remaining > 0 {
+		points += int(maxTimeBonus*remaining/TimeLimit) * correct / total
+	}
+	return points
+}
+
+// Leaderboard returns up to limit of the best plays of date's challenge at
+// level
+func (s *Service) Leaderboard(ctx context.Context, date string, level, limit int) ([]Entry, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	plays, err := s.store.TopDailyScores(ctx, board(date, level), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(plays))
+	for i, p := range plays {
+		ids[i] = p.UserID
+	}
+	var users []struct {
+		ID       string `db:"id"`
+		Username string `db:"username"`
+	}
+	if err := s.reads.SelectContext(ctx, &users, `
+		SELECT id, username FROM users WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to get usernames: %w", err)
+	}
+	usernames := make(map[string]string, len(users))
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+	}
+
+	entries := make([]Entry, len(plays))
+	for i, p := range plays {
+		correct := 0
+		for _, a := range p.Attempts {
+			if a.IsCorrect {
+				correct++
+			}
+		}
+
+		rank := i + 1
+		if i > 0 && p.Score == plays[i-1].Score {
+			rank = entries[i-1].Rank
+		}
+		entries[i] = Entry{
+			Rank:        rank,
+			UserID:      p.UserID,
+			Username:    usernames[p.UserID],
+			Score:       p.Score,
+			Correct:     correct,
+			CompletedAt: p.CompletedAt,
+		}
+	}
+	return entries, nil
+}
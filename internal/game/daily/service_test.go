@@ -0,0 +1,82 @@
+package daily
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 0, score(0, 0, 0))
+	assert.Equal(t, 600, score(5, 5, 0), "every word straight away earns the full bonus")
+	assert.Equal(t, 550, score(5, 5, TimeLimit/2))
+	assert.Equal(t, 500, score(5, 5, TimeLimit), "no bonus once time is up")
+	assert.Equal(t, 500, score(5, 5, 2*TimeLimit))
+	assert.Equal(t, 360, score(3, 5, 0), "the bonus shrinks with each word missed")
+	assert.Equal(t, 0, score(0, 5, 0))
+}
+
+func TestKeys(t *testing.T) {
+	assert.Equal(t, "daily#2026-10-15#user-1", playID("2026-10-15", "user-1"))
+	assert.Equal(t, "2026-10-15#3", board("2026-10-15", 3))
+}
+
+func TestToday(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	s.now = func() time.Time {
+		return time.Date(2026, 10, 15, 23, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+	}
+	assert.Equal(t, "2026-10-16", s.Today(), "days are in UTC")
+}
+
+func TestDisabled(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	ctx := context.Background()
+
+	_, err := s.Start(ctx, "user-1", 1)
+	assert.ErrorIs(t, err, ErrDisabled)
+	_, err = s.Submit(ctx, "user-1", s.Today(), nil)
+	assert.ErrorIs(t, err, ErrDisabled)
+	_, err = s.Leaderboard(ctx, s.Today(), 1, 10)
+	assert.ErrorIs(t, err, ErrDisabled)
+
+	h := NewHandler(s)
+	req := httptest.NewRequest(http.MethodPost, "/daily/start", strings.NewReader(`{"level": 2}`))
+	req = req.WithContext(auth.SetUserIDInContext(req.Context(), uuid.New().String()))
+	rec := httptest.NewRecorder()
+	h.Start(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestRequestValidation(t *testing.T) {
+	start := StartRequest{Level: 11}
+	start.validate()
+	assert.Contains(t, start.Validator.FieldErrors, "level")
+
+	submit := SubmitRequest{Date: "15/10/2026"}
+	submit.validate()
+	assert.Contains(t, submit.Validator.FieldErrors, "date")
+
+	submit = SubmitRequest{Answers: map[string]string{"word-1": "necessary"}}
+	submit.validate()
+	assert.False(t, submit.Validator.HasErrors())
+
+	board := parseLeaderboardRequest(url.Values{}, "2026-10-15")
+	board.validate()
+	assert.False(t, board.Validator.HasErrors())
+	assert.Equal(t, DefaultLeaderboardSize, board.Limit)
+
+	board = parseLeaderboardRequest(url.Values{"level": {"0"}, "limit": {"x"}}, "2026-10-15")
+	board.validate()
+	assert.Contains(t, board.Validator.FieldErrors, "level")
+	assert.Contains(t, board.Validator.FieldErrors, "limit")
+}
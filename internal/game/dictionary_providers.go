@@ -0,0 +1,348 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrWordNotFound = errors.New("word not found")
+
+// DictionaryProvider looks words up in a single dictionary source
+type DictionaryProvider interface {
+	Name() string
+	Lookup(ctx context.Context, word string) (*Word, error)
+}
+
+type wordnikProvider struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewWordnikProvider(apiKey string, httpClient *http.Client) DictionaryProvider {
+	return &wordnikProvider{
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+func (p *wordnikProvider) Name() string {
+	return "wordnik"
+}
+
+type wordnikDefinition struct {
+	Text         string `json:"text"`
+	PartOfSpeech string `json:"partOfSpeech"`
+}
+
+type wordnikExample struct {
+	Text string `json:"text"`
+}
+
+type wordnikPronunciation struct {
+	Raw     string `json:"raw"`
+	RawType string `json:"rawType"`
+}
+
+func (p *wordnikProvider) get(ctx context.Context, word, resource string, query neturl.Values, dst any) error {
+	query.Set("api_key", p.apiKey)
+	url := fmt.Sprintf("https://api.wordnik.com/v4/word.json/%s/%s?%s",
+		neturl.PathEscape(word), resource, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", resource, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrWordNotFound, word)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", resource, err)
+	}
+	return nil
+}
+
+func (p *wordnikProvider) Lookup(ctx context.Context, word string) (*Word, error) {
+	var definitions []wordnikDefinition
+	if err := p.get(ctx, word, "definitions", neturl.Values{"limit": {"5"}}, &definitions); err != nil {
+		return nil, err
+	}
+
+	wordInfo := &Word{Word: word}
+	for _, def := range definitions {
+		if def.Text == "" {
+			continue
+		}
+		wordInfo.Definition = strings.TrimSpace(def.Text)
+		wordInfo.PartOfSpeech = def.PartOfSpeech
+		break
+	}
+	if wordInfo.Definition == "" {
+		return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+	}
+
+	// Examples and pronunciations are nice to have; a miss shouldn't fail
+	// the whole lookup
+	var example wordnikExample
+	if err := p.get(ctx, word, "topExample", neturl.Values{}, &example); err == nil {
+		wordInfo.ExampleSentence = strings.TrimSpace(example.Text)
+	}
+
+	var pronunciations []wordnikPronunciation
+	if err := p.get(ctx, word, "pronunciations", neturl.Values{"typeFormat": {"IPA"}, "limit": {"1"}}, &pronunciations); err == nil && len(pronunciations) > 0 {
+		wordInfo.Pronunciation = pronunciations[0].Raw
+	}
+
+	return wordInfo, nil
+}
+
+// wiktionaryProvider looks words up in Wiktionary, which defines words from
+// many languages and so backs the languages Merriam-Webster doesn't cover
+type wiktionaryProvider struct {
+	language   string
+	httpClient *http.Client
+}
+
+// NewWiktionaryProvider looks up words in language, an ISO 639-1 code
+func NewWiktionaryProvider(language string, httpClient *http.Client) DictionaryProvider {
+	return &wiktionaryProvider{
+		language:   language,
+		httpClient: httpClient,
+	}
+}
+
+func (p *wiktionaryProvider) Name() string {
+	return "wiktionary_" + p.language
+}
+
+// wiktionaryUsage is one part of speech a word is used as in a language
+type wiktionaryUsage struct {
+	PartOfSpeech string `json:"partOfSpeech"`
+	Definitions  []struct {
+		Definition string   `json:"definition"`
+		Examples   []string `json:"examples"`
+	} `json:"definitions"`
+}
+
+var htmlTags = regexp.MustCompile(`<[^>]*>`)
+
+// stripHTML reduces Wiktionary's marked up definitions to plain text
+func stripHTML(s string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTags.ReplaceAllString(s, "")))
+}
+
+func (p *wiktionaryProvider) Lookup(ctx context.Context, word string) (*Word, error) {
+	url := "https://en.wiktionary.org/api/rest_v1/page/definition/" + neturl.PathEscape(word)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Wikimedia turns away clients that don't say who they are
+	req.Header.Set("User-Agent", "big-spella/1.0")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get definitions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Definitions are grouped by the language the word is used in
+	var languages map[string][]wiktionaryUsage
+	if err := json.NewDecoder(resp.Body).Decode(&languages); err != nil {
+		return nil, fmt.Errorf("failed to parse definitions: %w", err)
+	}
+
+	wordInfo := &Word{Word: word, Language: p.language, Source: "wiktionary"}
+	for _, usage := range languages[p.language] {
+		for _, def := range usage.Definitions {
+			definition := stripHTML(def.Definition)
+			if definition == "" {
+				continue
+			}
+			wordInfo.Definition = definition
+			wordInfo.PartOfSpeech = strings.ToLower(usage.PartOfSpeech)
+			if len(def.Examples) > 0 {
+				wordInfo.ExampleSentence = stripHTML(def.Examples[0])
+			}
+			return wordInfo, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+}
+
+// localProvider serves words from an in-memory dataset so lookups keep
+// working with no network at all
+type localProvider struct {
+	words map[string]*Word
+}
+
+func NewLocalProvider(words []*Word) DictionaryProvider {
+	p := &localProvider{words: make(map[string]*Word, len(words))}
+	for _, w := range words {
+		p.words[strings.ToLower(w.Word)] = w
+	}
+	return p
+}
+
+// LoadLocalProvider reads a JSON array of words, as produced by encoding
+// []Word, from path
+func LoadLocalProvider(path string) (DictionaryProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary dataset: %w", err)
+	}
+	defer f.Close()
+
+	var words []*Word
+	if err := json.NewDecoder(f).Decode(&words); err != nil {
+		return nil, fmt.Errorf("failed to parse dictionary dataset: %w", err)
+	}
+
+	return NewLocalProvider(words), nil
+}
+
+func (p *localProvider) Name() string {
+	return "local"
+}
+
+func (p *localProvider) Lookup(ctx context.Context, word string) (*Word, error) {
+	w, ok := p.words[strings.ToLower(word)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+	}
+	copied := *w
+	return &copied, nil
+}
+
+// ProviderHealth is a snapshot of how a provider has been behaving
+type ProviderHealth struct {
+	Name                string        `json:"name"`
+	Successes           int64         `json:"successes"`
+	NotFound            int64         `json:"not_found"`
+	Failures            int64         `json:"failures"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastError           string        `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time    `json:"last_failure_at,omitempty"`
+	LastLatency         time.Duration `json:"last_latency"`
+}
+
+// CompositeProvider tries each provider in order and returns the first hit.
+// "Not found" answers fall through to the next provider without counting
+// against its health; anything else is recorded as a failure.
+type CompositeProvider struct {
+	providers []DictionaryProvider
+
+	mu     sync.Mutex
+	health map[string]*ProviderHealth
+}
+
+func NewCompositeProvider(providers ...DictionaryProvider) *CompositeProvider {
+	c := &CompositeProvider{
+		providers: providers,
+		health:    make(map[string]*ProviderHealth, len(providers)),
+	}
+	for _, p := range providers {
+		c.health[p.Name()] = &ProviderHealth{Name: p.Name()}
+	}
+	return c
+}
+
+func (c *CompositeProvider) Name() string {
+	return "composite"
+}
+
+func (c *CompositeProvider) Lookup(ctx context.Context, word string) (*Word, error) {
+	var errs []error
+
+	for _, p := range c.providers {
+		start := time.Now()
+		w, err := p.Lookup(ctx, word)
+		c.record(p.Name(), time.Since(start), err)
+
+		if err == nil {
+			if w.Source == "" {
+				w.Source = p.Name()
+			}
+			return w, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("all dictionary providers failed: %w", errors.Join(errs...))
+}
+
+func (c *CompositeProvider) record(name string, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.health[name]
+	h.LastLatency = latency
+
+	switch {
+	case err == nil:
+		h.Successes++
+		h.ConsecutiveFailures = 0
+	case errors.Is(err, ErrWordNotFound):
+		h.NotFound++
+		h.ConsecutiveFailures = 0
+	default:
+		now := time.Now()
+		h.Failures++
+		h.ConsecutiveFailures++
+		h.LastError = err.Error()
+		h.LastFailureAt = &now
+	}
+}
+
+// DictionaryHealth reports how a dictionary's providers have been behaving.
+// The DictionaryService NewDictionaryService returns implements it.
+type DictionaryHealth interface {
+	Health() []ProviderHealth
+}
+
+// Health returns a snapshot for every provider in fallback order
+func (c *CompositeProvider) Health() []ProviderHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]ProviderHealth, 0, len(c.providers))
+	for _, p := range c.providers {
+		out = append(out, *c.health[p.Name()])
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// respondWith answers every request with status and body
+func respondWith(status int, body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     make(http.Header),
+			Request:    r,
+		}, nil
+	})}
+}
+
+const abejaDefinitions = `{
+	"en": [{"partOfSpeech": "Noun", "definitions": [{"definition": "an English usage"}]}],
+	"es": [{"partOfSpeech": "Noun", "definitions": [
+		{"definition": ""},
+		{"definition": "<a href=\"/wiki/bee\">bee</a> &amp; honey maker", "examples": ["La <b>abeja</b> vuela."]}
+	]}]
+}`
+
+func TestWiktionaryLooksUpTheWordsLanguage(t *testing.T) {
+	word, err := NewWiktionaryProvider("es", respondWith(http.StatusOK, abejaDefinitions)).Lookup(context.Background(), "abeja")
+	require.NoError(t, err)
+	assert.Equal(t, "bee & honey maker", word.Definition)
+	assert.Equal(t, "noun", word.PartOfSpeech)
+	assert.Equal(t, "La abeja vuela.", word.ExampleSentence)
+	assert.Equal(t, "es", word.Language)
+	assert.Equal(t, "wiktionary", word.Source)
+
+	// Words only defined in other languages aren't words in this one
+	_, err = NewWiktionaryProvider("fr", respondWith(http.StatusOK, abejaDefinitions)).Lookup(context.Background(), "abeja")
+	assert.ErrorIs(t, err, ErrWordNotFound)
+
+	_, err = NewWiktionaryProvider("es", respondWith(http.StatusNotFound, "")).Lookup(context.Background(), "zzz")
+	assert.ErrorIs(t, err, ErrWordNotFound)
+}
+
+func TestDictionaryPicksTheLanguagesProvider(t *testing.T) {
+	s := &dictionaryService{providers: map[string]DictionaryProvider{
+		DefaultLanguage: NewLocalProvider([]*Word{{Word: "bee", Pronunciation: "ˈbiː"}}),
+		"es":            NewLocalProvider([]*Word{{Word: "abeja", Pronunciation: "aˈβexa"}}),
+	}}
+
+	word, err := s.GetWordInfo(context.Background(), "bee", "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLanguage, word.Language)
+	assert.NotEmpty(t, word.Respelling)
+
+	word, err = s.GetWordInfo(context.Background(), "abeja", "es")
+	require.NoError(t, err)
+	assert.Equal(t, "es", word.Language)
+	assert.Empty(t, word.Respelling, "respellings are for English words")
+
+	_, err = s.GetWordInfo(context.Background(), "bee", "es")
+	assert.ErrorIs(t, err, ErrWordNotFound)
+	_, err = s.GetWordInfo(context.Background(), "biene", "de")
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+}
+
+func TestLanguages(t *testing.T) {
+	assert.Contains(t, Languages(), DefaultLanguage)
+	assert.True(t, IsSupportedLanguage("fr"))
+	assert.False(t, IsSupportedLanguage("tlh"))
+	assert.Equal(t, DefaultVoice, LanguageVoice(DefaultLanguage))
+	assert.Equal(t, DefaultVoice, LanguageVoice("tlh"))
+	assert.NotEqual(t, DefaultVoice, LanguageVoice("es"))
+}
+
+func TestDictionaryHealthCoversTheFallbacks(t *testing.T) {
+	s := NewDictionaryService("", "", "", NewWordnikProvider("", nil), NewLocalProvider(nil))
+
+	health, ok := s.(DictionaryHealth)
+	require.True(t, ok)
+	var names []string
+	for _, provider := range health.Health() {
+		names = append(names, provider.Name)
+	}
+	assert.Equal(t, []string{"merriam_webster", "wordnik", "local"}, names)
+}
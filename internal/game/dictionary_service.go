@@ -3,11 +3,16 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"strings"
 	"time"
+
+	"big-spella-go/internal/game/respell"
+	"big-spella-go/internal/tracing"
 )
 
 type DictionaryEntry struct {
@@ -20,6 +25,7 @@ type DictionaryEntry struct {
 	HWI struct {
 		Pronunciation struct {
 			IPA  string `json:"ipa"`
+			MW   string `json:"mw"`
 			WAV  string `json:"wav"`
 			MWOD []struct {
 				Subdirectory string `json:"subdirectory"`
@@ -45,39 +51,109 @@ type Definition struct {
 }
 
 type DictionaryService interface {
-	GetWordInfo(ctx context.Context, word string) (*Word, error)
+	GetWordInfo(ctx context.Context, word, language string) (*Word, error)
 	GenerateAudio(ctx context.Context, text string) ([]byte, error)
+	SynthesizeSpeech(ctx context.Context, text, voice string, rate float64) ([]byte, error)
 	GetHint(ctx context.Context, word *Word, hintType HintType) (string, error)
 }
 
+// DefaultVoice is the OpenAI TTS voice used when none is requested
+const DefaultVoice = "onyx"
+
+// ErrUnsupportedLanguage means no dictionary covers the language a word was
+// looked up in
+var ErrUnsupportedLanguage = errors.New("no dictionary for that language")
+
 type dictionaryService struct {
-	dictionaryAPIKey string
-	thesaurusAPIKey  string
-	openAIKey        string
-	httpClient       *http.Client
+	// providers holds the provider for each supported language
+	providers       map[string]DictionaryProvider
+	thesaurusAPIKey string
+	openAIKey       string
+	httpClient      *http.Client
 }
 
-func NewDictionaryService(dictionaryAPIKey, thesaurusAPIKey, openAIKey string) DictionaryService {
+// NewDictionaryService looks English words up in Merriam-Webster first and
+// then in each fallback provider in order. Words in the other supported
+// languages are looked up in Wiktionary.
+func NewDictionaryService(dictionaryAPIKey, thesaurusAPIKey, openAIKey string, fallbacks ...DictionaryProvider) DictionaryService {
+	httpClient := &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: &tracing.Transport{},
+	}
+
+	english := append([]DictionaryProvider{NewMerriamWebsterProvider(dictionaryAPIKey, httpClient)}, fallbacks...)
+	providers := map[string]DictionaryProvider{DefaultLanguage: NewCompositeProvider(english...)}
+	for _, language := range Languages() {
+		if language != DefaultLanguage {
+			providers[language] = NewWiktionaryProvider(language, httpClient)
+		}
+	}
+
 	return &dictionaryService{
-		dictionaryAPIKey: dictionaryAPIKey,
-		thesaurusAPIKey:  thesaurusAPIKey,
+		providers:       providers,
+		thesaurusAPIKey: thesaurusAPIKey,
 		openAIKey:       openAIKey,
-		httpClient: &http.Client{
-			Timeout: time.Second * 10,
-		},
+		httpClient:      httpClient,
+	}
+}
+
+// Health reports how the English dictionaries have been behaving, in the
+// order they're tried
+func (s *dictionaryService) Health() []ProviderHealth {
+	if composite, ok := s.providers[DefaultLanguage].(*CompositeProvider); ok {
+		return composite.Health()
+	}
+	return nil
+}
+
+// GetWordInfo looks word up in the dictionary for language
+func (s *dictionaryService) GetWordInfo(ctx context.Context, word, language string) (*Word, error) {
+	language = languageOr(language)
+	provider, ok := s.providers[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+
+	wordInfo, err := provider.Lookup(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+	wordInfo.Language = language
+
+	// Respellings are written for English speakers
+	if wordInfo.Respelling == "" && language == DefaultLanguage {
+		wordInfo.Respelling = respell.Respell(wordInfo.Pronunciation)
+	}
+
+	return wordInfo, nil
+}
+
+type merriamWebsterProvider struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewMerriamWebsterProvider(apiKey string, httpClient *http.Client) DictionaryProvider {
+	return &merriamWebsterProvider{
+		apiKey:     apiKey,
+		httpClient: httpClient,
 	}
 }
 
-func (s *dictionaryService) GetWordInfo(ctx context.Context, word string) (*Word, error) {
+func (p *merriamWebsterProvider) Name() string {
+	return "merriam_webster"
+}
+
+func (p *merriamWebsterProvider) Lookup(ctx context.Context, word string) (*Word, error) {
 	url := fmt.Sprintf("https://www.dictionaryapi.com/api/v3/references/collegiate/json/%s?key=%s",
-		word, s.dictionaryAPIKey)
+		neturl.PathEscape(word), p.apiKey)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get word info: %w", err)
 	}
@@ -88,19 +164,29 @@ func (s *dictionaryService) GetWordInfo(ctx context.Context, word string) (*Word
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
 	var entries []DictionaryEntry
 	if err := json.Unmarshal(body, &entries); err != nil {
+		// Unknown words come back as a list of spelling suggestions
+		var suggestions []string
+		if json.Unmarshal(body, &suggestions) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+		}
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(entries) == 0 {
-		return nil, fmt.Errorf("word not found: %s", word)
+		return nil, fmt.Errorf("%w: %s", ErrWordNotFound, word)
 	}
 
 	entry := entries[0]
 	wordInfo := &Word{
 		Word:          word,
 		PartOfSpeech: entry.FL,
+		Offensive:     entry.Meta.Offensive,
 	}
 
 	// Get pronunciation
@@ -111,6 +197,9 @@ func (s *dictionaryService) GetWordInfo(ctx context.Context, word string) (*Word
 			pron.Subdirectory, pron.FileName)
 		wordInfo.Pronunciation = entry.HWI.Pronunciation.IPA
 	}
+	if wordInfo.Pronunciation == "" {
+		wordInfo.Pronunciation = entry.HWI.Pronunciation.MW
+	}
 
 	// Get definition and example
 	if len(entry.Def) > 0 && len(entry.Def[0].SseqList) > 0 {
@@ -139,11 +228,18 @@ func (s *dictionaryService) GetWordInfo(ctx context.Context, word string) (*Word
 }
 
 func (s *dictionaryService) GenerateAudio(ctx context.Context, text string) ([]byte, error) {
+	return s.SynthesizeSpeech(ctx, text, DefaultVoice, DefaultSpeechRate)
+}
+
+// SynthesizeSpeech generates audio for text using the given TTS voice,
+// spoken at rate times the normal speed
+func (s *dictionaryService) SynthesizeSpeech(ctx context.Context, text, voice string, rate float64) ([]byte, error) {
 	url := "https://api.openai.com/v1/audio/speech"
 	reqBody := map[string]interface{}{
 		"model": "tts-1",
 		"input": text,
-		"voice": "onyx",
+		"voice": voice,
+		"speed": rate,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -182,13 +278,16 @@ func (s *dictionaryService) GetHint(ctx context.Context, word *Word, hintType Hi
 	switch hintType {
 	case HintTypeDefinition:
 		return word.Definition, nil
-	case HintTypeSentence:
+	case HintTypeSentence, HintTypeExampleSentence:
 		return word.ExampleSentence, nil
 	case HintTypeEtymology:
 		return word.Etymology, nil
 	case HintTypePartOfSpeech:
 		return word.PartOfSpeech, nil
 	case HintTypePronunciation:
+		if word.Respelling != "" {
+			return word.Respelling, nil
+		}
 		return word.Pronunciation, nil
 	default:
 		return "", fmt.Errorf("invalid hint type: %s", hintType)
@@ -0,0 +1,174 @@
+package game
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// recentEvents is how many of each game's latest events are kept for
+	// clients reconnecting to catch up on
+	recentEvents = 256
+	// subscriberBacklog is how far a subscriber may fall behind before it's
+	// dropped; it can then reconnect and resume from the last event it saw
+	subscriberBacklog = 64
+)
+
+// eventLog numbers each game's events, keeps the recent ones and passes them
+// on to the game's subscribers, each shaped for whoever is subscribing
+type eventLog struct {
+	mu    sync.Mutex
+	games map[string]*gameEvents
+}
+
+// gameEvents is one game's event history and subscribers
+type gameEvents struct {
+	seq    int64
+	recent []GameEvent
+	// subs maps each subscriber to the viewer it delivers events to
+	subs  map[chan GameEvent]string
+	ended bool
+	// latest is when the game's last event happened
+	latest time.Time
+}
+
+// Subscription delivers a game's events as they happen
+type Subscription struct {
+	// Events is closed when the subscription is cancelled or falls too far
+	// behind
+	Events <-chan GameEvent
+	// Missed are the events after the one resumed from, when Resumed
+	Missed []GameEvent
+	// Resumed reports whether every event after the one resumed from could
+	// be replayed. When it's false the subscriber needs a fresh snapshot.
+	Resumed bool
+	// Seq is the sequence number of the game's latest event when subscribing
+	Seq int64
+
+	cancel func()
+}
+
+// Cancel stops delivering events
+func (s *Subscription) Cancel() {
+	s.cancel()
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{
+		games: make(map[string]*gameEvents),
+	}
+}
+
+// publish numbers event and passes it on to the game's subscribers,
+// returning it numbered
+func (l *eventLog) publish(event GameEvent) GameEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g := l.game(event.GameID)
+	g.seq++
+	event.Seq = g.seq
+	g.latest = event.Timestamp
+	g.recent = append(g.recent, event)
+	if len(g.recent) > recentEvents {
+		g.recent = g.recent[len(g.recent)-recentEvents:]
+	}
+
+	for ch, viewerID := range g.subs {
+		select {
+		case ch <- eventFor(event, viewerID):
+		default:
+			// Too slow to keep up; it catches up by resuming instead
+			delete(g.subs, ch)
+			close(ch)
+		}
+	}
+
+	if event.Type == EventTypeGameEnded || event.Type == EventTypeGameCancelled {
+		g.ended = true
+		l.forget(event.GameID, g)
+	}
+	return event
+}
+
+// broadcast passes event on to the game's subscribers without numbering or
+// keeping it, for passing activity such as spelling statuses that a client
+// catching up has no use for. Subscribers too far behind to take it miss it.
+func (l *eventLog) broadcast(event GameEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g, ok := l.games[event.GameID]
+	if !ok {
+		return
+	}
+	for ch, viewerID := range g.subs {
+		select {
+		case ch <- eventFor(event, viewerID):
+		default:
+		}
+	}
+}
+
+// subscribe delivers gameID's events to viewerID from now on. With after
+// above zero, the events since the one numbered after are replayed first
+// where they are still kept.
+func (l *eventLog) subscribe(gameID, viewerID string, after int64) *Subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g := l.game(gameID)
+	ch := make(chan GameEvent, subscriberBacklog)
+	g.subs[ch] = viewerID
+
+	sub := &Subscription{Events: ch, Seq: g.seq}
+	if after > 0 && after <= g.seq {
+		missed := g.seq - after
+		if missed <= int64(len(g.recent)) {
+			for _, event := range g.recent[int64(len(g.recent))-missed:] {
+				sub.Missed = append(sub.Missed, eventFor(event, viewerID))
+			}
+			sub.Resumed = true
+		}
+	}
+
+	sub.cancel = func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := g.subs[ch]; ok {
+			delete(g.subs, ch)
+			close(ch)
+		}
+		l.forget(gameID, g)
+	}
+	return sub
+}
+
+// latest is when gameID's last event happened, or zero when none has since
+// the server started
+func (l *eventLog) latest(gameID string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if g, ok := l.games[gameID]; ok {
+		return g.latest
+	}
+	return time.Time{}
+}
+
+func (l *eventLog) game(gameID string) *gameEvents {
+	g, ok := l.games[gameID]
+	if !ok {
+		g = &gameEvents{subs: make(map[chan GameEvent]string)}
+		l.games[gameID] = g
+	}
+	return g
+}
+
+// forget drops a game's history once nobody is following it, if the game
+// has ended or there's no history to keep
+func (l *eventLog) forget(gameID string, g *gameEvents) {
+	if (g.ended || len(g.recent) == 0) && len(g.subs) == 0 && l.games[gameID] == g {
+		delete(l.games, gameID)
+	}
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishN(log *eventLog, gameID string, n int) {
+	for i := 0; i < n; i++ {
+		log.publish(GameEvent{Type: EventTypeLetterSpelled, GameID: gameID})
+	}
+}
+
+func TestEventsAreNumberedPerGame(t *testing.T) {
+	log := newEventLog()
+	sub := log.subscribe("game-1", "ada", 0)
+	defer sub.Cancel()
+
+	publishN(log, "game-1", 2)
+	publishN(log, "game-2", 1)
+
+	assert.Equal(t, int64(1), (<-sub.Events).Seq)
+	assert.Equal(t, int64(2), (<-sub.Events).Seq)
+	assert.Empty(t, sub.Events, "other games' events aren't delivered")
+	assert.Equal(t, int64(1), log.games["game-2"].seq)
+}
+
+func TestResumingReplaysMissedEvents(t *testing.T) {
+	log := newEventLog()
+	publishN(log, "game-1", 5)
+
+	sub := log.subscribe("game-1", "ada", 3)
+	defer sub.Cancel()
+	require.True(t, sub.Resumed)
+	require.Len(t, sub.Missed, 2)
+	assert.Equal(t, int64(4), sub.Missed[0].Seq)
+	assert.Equal(t, int64(5), sub.Seq)
+
+	caughtUp := log.subscribe("game-1", "ada", 5)
+	defer caughtUp.Cancel()
+	assert.True(t, caughtUp.Resumed)
+	assert.Empty(t, caughtUp.Missed)
+
+	fresh := log.subscribe("game-1", "ada", 0)
+	defer fresh.Cancel()
+	assert.False(t, fresh.Resumed)
+}
+
+func TestResumingTooFarBack(t *testing.T) {
+	log := newEventLog()
+	publishN(log, "game-1", recentEvents+10)
+
+	sub := log.subscribe("game-1", "ada", 5)
+	defer sub.Cancel()
+	assert.False(t, sub.Resumed)
+	assert.Empty(t, sub.Missed)
+
+	sub = log.subscribe("game-1", "ada", 10)
+	defer sub.Cancel()
+	assert.True(t, sub.Resumed)
+	assert.Len(t, sub.Missed, recentEvents)
+}
+
+func TestSlowSubscribersAreDropped(t *testing.T) {
+	log := newEventLog()
+	sub := log.subscribe("game-1", "ada", 0)
+	publishN(log, "game-1", subscriberBacklog+1)
+
+	n := 0
+	for range sub.Events {
+		n++
+	}
+	assert.Equal(t, subscriberBacklog, n)
+	sub.Cancel()
+}
+
+func TestEndedGamesAreForgotten(t *testing.T) {
+	log := newEventLog()
+	sub := log.subscribe("game-1", "ada", 0)
+	log.publish(GameEvent{Type: EventTypeGameEnded, GameID: "game-1"})
+	assert.Contains(t, log.games, "game-1", "kept while it's followed")
+
+	sub.Cancel()
+	assert.NotContains(t, log.games, "game-1")
+
+	log.publish(GameEvent{Type: EventTypeGameCancelled, GameID: "game-2"})
+	assert.NotContains(t, log.games, "game-2", "cancelled games end too")
+}
+
+func TestUnknownGamesAreForgotten(t *testing.T) {
+	log := newEventLog()
+	sub := log.subscribe("no-such-game", "ada", 0)
+	sub.Cancel()
+
+	assert.Empty(t, log.games, "nothing was kept for a game without events")
+}
+
+func TestLatestEvent(t *testing.T) {
+	log := newEventLog()
+	assert.True(t, log.latest("game-1").IsZero())
+	assert.NotContains(t, log.games, "game-1", "looking doesn't start a history")
+
+	at := time.Now()
+	log.publish(GameEvent{Type: EventTypeChatMessage, GameID: "game-1", Timestamp: at})
+	assert.Equal(t, at, log.latest("game-1"))
+}
+
+func TestEventsAreShapedForEachSubscriber(t *testing.T) {
+	log := newEventLog()
+	judge := log.subscribe("game-1", "judge", 0)
+	defer judge.Cancel()
+	speller := log.subscribe("game-1", "speller", 0)
+	defer speller.Cancel()
+
+	publishN(log, "game-1", 1)
+	log.publish(GameEvent{Type: EventTypeReviewRequested, GameID: "game-1", Payload: map[string]any{
+		"word": onlyFor{viewers: []string{"judge"}, value: "heron"},
+	}})
+
+	<-judge.Events
+	<-speller.Events
+	assert.Equal(t, "heron", (<-judge.Events).Payload["word"])
+	assert.NotContains(t, (<-speller.Events).Payload, "word")
+
+	// Replayed events are shaped too
+	resumed := log.subscribe("game-1", "speller", 1)
+	defer resumed.Cancel()
+	require.Len(t, resumed.Missed, 1)
+	assert.NotContains(t, resumed.Missed[0].Payload, "word")
+}
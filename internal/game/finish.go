@@ -0,0 +1,246 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game/modes"
+	"big-spella-go/internal/game/ranking"
+)
+
+// PlayerResult is how one player finished a game
+type PlayerResult struct {
+	PlayerID     string   `json:"player_id"`
+	Score        int      `json:"score"`
+	Placement    int      `json:"placement"`
+	WordsSpelled []string `json:"words_spelled"`
+	// Team is the player's team in a team relay, which Placement is then
+	// the placement of
+	Team *int `json:"team,omitempty"`
+}
+
+// roundsComplete reports whether the round just played was the last one
+func roundsComplete(game *Game) bool {
+	return game.Settings.MaxRounds > 0 && game.Round+1 >= game.Settings.MaxRounds
+}
+
+// rankResults orders players by score and places them, with tied players
+// sharing a placement
+func rankResults(results []PlayerResult) []PlayerResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	for i := range results {
+		results[i].Placement = i + 1
+		if i > 0 && results[i].Score == results[i-1].Score {
+			results[i].Placement = results[i-1].Placement
+		}
+	}
+	return results
+}
+
+// finishGame ends a game that has played all its rounds and records each
+// player's result in their game history
+func (s *gameService) finishGame(ctx context.Context, game *Game) error {
+	for _, name := range []string{"turn", "listening", "intermission", "review"} {
+		s.timers.Cancel(timerKey(game.ID, name))
+	}
+
+	s.mu.Lock()
+	delete(s.activeGames, game.ID)
+	activeGamesGauge.Set(float64(len(s.activeGames)))
+	s.mu.Unlock()
+
+	results, err := s.playerResults(ctx, game)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE games
+		SET status = $1, updated_at = $2, current_turn = NULL, turn_started_at = NULL,
+			version = version + 1
+		WHERE id = $3`, GameStatusFinished, now, game.ID); err != nil {
+		return fmt.Errorf("failed to finish game: %w", err)
+	}
+
+	duration := int(now.Sub(game.CreatedAt).Seconds())
+	for _, result := range results {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO game_history (id, user_id, game_id, game_type, score, position,
+				words_spelled, duration, created_at, team)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (user_id, game_id) DO NOTHING`,
+			uuid.New().String(), result.PlayerID, game.ID, game.Type, result.Score, result.Placement,
+			pq.Array(result.WordsSpelled), duration, now, result.Team); err != nil {
+			return fmt.Errorf("failed to record game history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit game results: %w", err)
+	}
+	game.Status = GameStatusFinished
+
+	ended := map[string]any{
+		"status":  GameStatusFinished,
+		"results": results,
+	}
+	if teams := teamResults(results); len(teams) > 0 {
+		ended["teams"] = teams
+	}
+	s.emitEvent(EventTypeGameEnded, game.ID, nil, ended)
+	for _, publisher := range s.results {
+		publisher.PublishResults(ctx, game, results)
+	}
+
+	return s.awardRankingPoints(ctx, game, results)
+}
+
+// awardRankingPoints credits the placings of a ranked game to the players'
+// ratings, unless the cheat screen flags the game, which holds them until
+// the flags are reviewed
+func (s *gameService) awardRankingPoints(ctx context.Context, game *Game, results []PlayerResult) error {
+	if !game.Settings.IsRanked || s.ranks == nil {
+		return nil
+	}
+
+	if s.cheats != nil {
+		flagged, err := s.cheats.Screen(ctx, game.ID)
+		if err != nil {
+			return fmt.Errorf("failed to screen game: %w", err)
+		}
+		if flagged {
+			return nil
+		}
+	}
+
+	return s.recordRankings(ctx, game, results)
+}
+
+// recordRankings hands the players' placings to the rank recorder, which
+// moves all their ratings at once
+func (s *gameService) recordRankings(ctx context.Context, game *Game, results []PlayerResult) error {
+	// Team relays are placed, and so scored, by team
+	field := len(results)
+	if teams := teamResults(results); len(teams) > 0 {
+		field = len(teams)
+	}
+	placings := make([]ranking.Result, len(results))
+	for i, result := range results {
+		placings[i] = ranking.Result{UserID: result.PlayerID, Placement: result.Placement}
+	}
+	if _, err := s.ranks.ApplyResult(ctx, game.ID, placings, field, game.Settings.IsTournament); err != nil {
+		return fmt.Errorf("failed to award ranking points: %w", err)
+	}
+	return nil
+}
+
+// ReleaseRankedResults awards the ranking points a flagged ranked game held
+// back, once its flags have been cleared. Games whose points were already
+// awarded are left alone.
+func (s *gameService) ReleaseRankedResults(ctx context.Context, gameID string) error {
+	if s.ranks == nil {
+		return nil
+	}
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	if game.Status != GameStatusFinished || !game.Settings.IsRanked {
+		return ErrInvalidGameState
+	}
+
+	var awarded bool
+	if err := s.db.GetContext(ctx, &awarded, `
+		SELECT EXISTS (SELECT 1 FROM game_results WHERE game_id = $1)`, gameID); err != nil {
+		return fmt.Errorf("failed to check ranking points: %w", err)
+	}
+	if awarded {
+		return nil
+	}
+
+	var rows []struct {
+		UserID   string `db:"user_id"`
+		Position int    `db:"position"`
+		Team     *int   `db:"team"`
+	}
+	if err := s.db.SelectContext(ctx, &rows, `
+		SELECT user_id, position, team
+		FROM game_history
+		WHERE game_id = $1
+		ORDER BY position`, gameID); err != nil {
+		return fmt.Errorf("failed to get game history: %w", err)
+	}
+
+	results := make([]PlayerResult, len(rows))
+	for i, row := range rows {
+		results[i] = PlayerResult{PlayerID: row.UserID, Placement: row.Position, Team: row.Team}
+	}
+	return s.recordRankings(ctx, game, results)
+}
+
+// playerResults tallies the points and spelled words of everyone who played,
+// including players who left before the end but not those the host kicked
+func (s *gameService) playerResults(ctx context.Context, game *Game) ([]PlayerResult, error) {
+	var attempts []struct {
+		PlayerID  string `db:"player_id"`
+		Word      string `db:"word"`
+		IsCorrect bool   `db:"is_correct"`
+		Points    int    `db:"points"`
+	}
+	if err := s.db.SelectContext(ctx, &attempts, `
+		SELECT player_id, word, is_correct, points
+		FROM spelling_attempts
+		WHERE game_id = $1 AND status = $2
+		ORDER BY timestamp`, game.ID, AttemptStatusJudged); err != nil {
+		return nil, fmt.Errorf("failed to get attempts: %w", err)
+	}
+
+	results := []PlayerResult{}
+	index := make(map[string]int)
+	for _, player := range game.Players {
+		if player == nil || player.Status == "kicked" {
+			continue
+		}
+		index[player.UserID] = len(results)
+		results = append(results, PlayerResult{PlayerID: player.UserID, WordsSpelled: []string{}})
+	}
+
+	total := make([]int, len(results))
+	for _, attempt := range attempts {
+		i, ok := index[attempt.PlayerID]
+		if !ok {
+			continue
+		}
+		total[i]++
+		results[i].Score += attempt.Points
+		if attempt.IsCorrect {
+			results[i].WordsSpelled = append(results[i].WordsSpelled, attempt.Word)
+		}
+	}
+
+	// Accurate spellers earn the game's accuracy bonus on their final score
+	scoring := game.Settings.scoring()
+	for i := range results {
+		results[i].Score = modes.Apply(results[i].Score, scoring.AccuracyMultiplier(len(results[i].WordsSpelled), total[i]))
+	}
+
+	if game.Settings.isTeamRelay() {
+		return rankTeams(results, game.Players), nil
+	}
+	return rankResults(results), nil
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/game/ranking"
+)
+
+func TestRankResults(t *testing.T) {
+	results := rankResults([]PlayerResult{
+		{PlayerID: "ada", Score: 2},
+		{PlayerID: "bo", Score: 5},
+		{PlayerID: "cy", Score: 2},
+		{PlayerID: "di", Score: 0},
+	})
+
+	var order []string
+	var placements []int
+	for _, result := range results {
+		order = append(order, result.PlayerID)
+		placements = append(placements, result.Placement)
+	}
+	assert.Equal(t, []string{"bo", "ada", "cy", "di"}, order)
+	assert.Equal(t, []int{1, 2, 2, 4}, placements, "tied players share a placement")
+}
+
+func TestRoundsComplete(t *testing.T) {
+	game := &Game{Round: 2}
+	assert.False(t, roundsComplete(game), "games without a round limit run until stopped")
+
+	game.Settings.MaxRounds = 3
+	assert.True(t, roundsComplete(game))
+
+	game.Round = 1
+	assert.False(t, roundsComplete(game))
+}
+
+type rankRecorder map[string]int
+
+func (r rankRecorder) ApplyResult(ctx context.Context, gameID string, results []ranking.Result, field int, isTournament bool) ([]ranking.Change, error) {
+	for _, result := range results {
+		r[result.UserID] = ranking.CalculatePoints(result.Placement, field, isTournament)
+	}
+	return nil, nil
+}
+
+func (r rankRecorder) CreditActivity(ctx context.Context, userIDs []string, at time.Time) error {
+	for _, userID := range userIDs {
+		r[userID] = 0
+	}
+	return nil
+}
+
+func TestAwardRankingPoints(t *testing.T) {
+	recorded := rankRecorder{}
+	s := &gameService{ranks: recorded}
+	results := []PlayerResult{{PlayerID: "bo", Placement: 1}, {PlayerID: "ada", Placement: 2}}
+
+	game := &Game{}
+	require.NoError(t, s.awardRankingPoints(context.Background(), game, results))
+	assert.Empty(t, recorded, "casual games don't move ratings")
+
+	game.Settings.IsRanked = true
+	require.NoError(t, s.awardRankingPoints(context.Background(), game, results))
+	assert.Equal(t, rankRecorder{"bo": ranking.GoldPoints, "ada": ranking.SilverPoints}, recorded)
+}
+
+type cheatScreen bool
+
+func (c cheatScreen) Screen(ctx context.Context, gameID string) (bool, error) {
+	return bool(c), nil
+}
+
+func TestFlaggedGamesHoldRankingPoints(t *testing.T) {
+	recorded := rankRecorder{}
+	s := &gameService{ranks: recorded, cheats: cheatScreen(true)}
+	game := &Game{Settings: GameSettings{IsRanked: true}}
+	results := []PlayerResult{{PlayerID: "bo", Placement: 1}, {PlayerID: "ada", Placement: 2}}
+
+	require.NoError(t, s.awardRankingPoints(context.Background(), game, results))
+	assert.Empty(t, recorded, "flagged games wait for review")
+
+	s.cheats = cheatScreen(false)
+	require.NoError(t, s.awardRankingPoints(context.Background(), game, results))
+	assert.Len(t, recorded, 2)
+}
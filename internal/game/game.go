@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	MaxHints    = 3
+	// TurnTimeout is the answer window for short words at level 1, which
+	// longer and harder words are given more time than
 	TurnTimeout = 10 * time.Second
 )
 
@@ -17,26 +21,130 @@ var (
 	ErrNoWordSet     = errors.New("no word is set for the current turn")
 	ErrMaxHintsUsed  = errors.New("maximum number of hints already used")
 	ErrTurnNotActive = errors.New("no active turn")
+
+	ErrInvalidHintType = errors.New("invalid hint type")
+	ErrHintNotAllowed  = errors.New("hint type is not allowed in this game")
+	ErrHintAlreadyUsed = errors.New("hint type already used for this word")
+	ErrNoHintAvailable = errors.New("no hint available for this word")
 )
 
+// HintOrder is the order hint types are offered in when the client doesn't
+// ask for one, or the one it asked for has no content for the word
+var HintOrder = []HintType{
+	HintTypeDefinition,
+	HintTypeExampleSentence,
+	HintTypePartOfSpeech,
+	HintTypeEtymology,
+	HintTypePronunciation,
+}
+
 type GameEngine struct {
+	// mu is held by whatever moves the turn on, from checking the turn
+	// through to acting on it; see gameService.lockEngine
+	mu            sync.Mutex
+
 	ID            string
 	dict          DictionaryService
 	CurrentWord   *Word
 	WordMasked    bool
-	HintsUsed     int
 	TurnStartedAt *time.Time
+
+	// TurnOrder holds the players in the order they spell; turnIndex is
+	// whose turn it is
+	TurnOrder     []string
+	turnIndex     int
+
+	// HintsUsed holds the hint types each player has used this turn and
+	// HintsAllowed is the per-player budget
+	HintsUsed     map[string][]HintType
+	HintsAllowed  int
+	AllowedHints  []HintType
+
+	// Intermission is set between rounds while the next turn is pending
+	Intermission  *Intermission
+
+	// Review is set while a low-confidence voice attempt awaits a judge
+	Review        *PendingReview
+
+	// Confirmation is set while a low-confidence voice attempt awaits the
+	// player's confirmation of its transcription
+	Confirmation  *PendingConfirmation
+
+	// ReadyCheck is set while the host waits on the lobby to confirm it's
+	// ready to start
+	ReadyCheck    *ReadyCheck
+
+	// PausedAt is set while the host has the game paused
+	PausedAt      *time.Time
+
+	// Served holds the IDs of the words served so far, so none comes up twice
+	Served        []string
+
+	// Spelling is the attempt being spelled letter by letter, if any
+	Spelling      *LiveSpelling
+
+	// Phase is where the current turn is; Pronounce games announce the word
+	// before each answer window, and Replays counts the repeats asked for
+	Phase         TurnPhase
+	Pronounce     bool
+	Replays       int
+	audio         []byte
+	// personalAudio holds the word read out for players who changed how
+	// they hear words, by their preferences
+	personalAudio map[AudioPreferences][]byte
+
+	// Streaks counts the words each player has spelled correctly in a row
+	Streaks       map[string]int
+
+	// Teams holds a team relay's members, and RelayLetters has them take
+	// turns at the letters of each word
+	Teams         [][]string
+	RelayLetters  bool
+
+	// Language is the language the game's words are looked up and read out
+	// in
+	Language      string
+	// Spellings is which of a word's accepted spellings count
+	Spellings     SpellingPolicy
+
+	// Window sizes each turn's AnswerWindow to the word and Level, the
+	// game's word level
+	Window        AnswerWindowSettings
+	Level         int
+	AnswerWindow  time.Duration
+
+	// RoundStartedAt is when the round's word was served, and reportedOut
+	// holds the players round summaries have already reported out of the
+	// game
+	RoundStartedAt time.Time
+	reportedOut   map[string]bool
 }
 
 func NewGameEngine(id string, dict DictionaryService) *GameEngine {
 	return &GameEngine{
-		ID:   id,
-		dict: dict,
+		ID:           id,
+		dict:         dict,
+		HintsUsed:    make(map[string][]HintType),
+		HintsAllowed: MaxHints,
+		Streaks:      make(map[string]int),
+		Language:     DefaultLanguage,
+	}
+}
+
+// RecordResult extends playerID's streak of correct words, or ends it
+func (g *GameEngine) RecordResult(playerID string, correct bool) {
+	if g.Streaks == nil {
+		g.Streaks = make(map[string]int)
+	}
+	if correct {
+		g.Streaks[playerID]++
+	} else {
+		g.Streaks[playerID] = 0
 	}
 }
 
 func (g *GameEngine) StartNewTurn(ctx context.Context) error {
-	word, err := g.dict.GetWordInfo(ctx, "")
+	word, err := g.dict.GetWordInfo(ctx, "", g.Language)
 	if err != nil {
 		return fmt.Errorf("failed to get word: %w", err)
 	}
@@ -44,27 +152,54 @@ func (g *GameEngine) StartNewTurn(ctx context.Context) error {
 	now := time.Now()
 	g.CurrentWord = word
 	g.WordMasked = true
-	g.HintsUsed = 0
+	g.HintsUsed = make(map[string][]HintType)
 	g.TurnStartedAt = &now
 	
 	return nil
 }
 
 func (g *GameEngine) StartTurn(ctx context.Context, word string) error {
-	wordInfo, err := g.dict.GetWordInfo(ctx, word)
+	wordInfo, err := g.dict.GetWordInfo(ctx, word, g.Language)
 	if err != nil {
 		return fmt.Errorf("failed to get word info: %w", err)
 	}
+	if wordInfo.Language == "" {
+		wordInfo.Language = g.Language
+	}
 
 	now := time.Now()
 	g.CurrentWord = wordInfo
 	g.WordMasked = true
-	g.HintsUsed = 0
+	g.HintsUsed = make(map[string][]HintType)
 	g.TurnStartedAt = &now
+	g.Spelling = nil
+	g.Phase = g.openingPhase()
+	g.Replays = 0
+	g.audio = nil
+	g.personalAudio = nil
+	g.AnswerWindow = g.Window.For(wordInfo.Word, g.Level)
 
 	return nil
 }
 
+// StartWord starts a turn at word, as picked from the game's word list,
+// accepting the spellings listed for it as well as the dictionary's
+func (g *GameEngine) StartWord(ctx context.Context, word *Word) error {
+	if err := g.StartTurn(ctx, word.Word); err != nil {
+		return err
+	}
+	g.CurrentWord.AcceptedSpellings = word.AcceptedSpellings
+	g.RoundStartedAt = *g.TurnStartedAt
+	return nil
+}
+
+// MarkServed records that the word with wordID has been served in the game
+func (g *GameEngine) MarkServed(wordID string) {
+	if wordID != "" && !slices.Contains(g.Served, wordID) {
+		g.Served = append(g.Served, wordID)
+	}
+}
+
 func (g *GameEngine) ValidateAttempt(attempt string) (bool, error) {
 	if g.CurrentWord == nil {
 		return false, ErrNoWordSet
@@ -74,36 +209,114 @@ func (g *GameEngine) ValidateAttempt(attempt string) (bool, error) {
 		return false, ErrTurnNotActive
 	}
 	
-	if time.Since(*g.TurnStartedAt) > TurnTimeout {
-		return false, errors.New("turn has timed out")
-	}
-	
-	return strings.EqualFold(attempt, g.CurrentWord.Word), nil
+	return g.CurrentWord.Accepts(attempt, g.Spellings), nil
 }
 
-func (g *GameEngine) GetHint(ctx context.Context, hintType HintType) (string, error) {
+// GetHint serves playerID a hint of hintType, or the first allowed unused
+// type in HintOrder when hintType is empty or has nothing to show
+func (g *GameEngine) GetHint(ctx context.Context, playerID string, hintType HintType) (*Hint, error) {
 	if g.CurrentWord == nil {
-		return "", ErrNoWordSet
+		return nil, ErrNoWordSet
 	}
 	
-	if g.HintsUsed >= MaxHints {
-		return "", ErrMaxHintsUsed
+	if g.HintsRemaining(playerID) <= 0 {
+		return nil, ErrMaxHintsUsed
 	}
-	
-	hint, err := g.dict.GetHint(ctx, g.CurrentWord, hintType)
-	if err != nil {
-		return "", fmt.Errorf("failed to get hint: %w", err)
+
+	var candidates []HintType
+	if hintType != "" {
+		hintType = normalizeHintType(hintType)
+		switch {
+		case !isSupportedHintType(hintType):
+			return nil, ErrInvalidHintType
+		case !g.hintAllowed(hintType):
+			return nil, ErrHintNotAllowed
+		case g.hintUsed(playerID, hintType):
+			return nil, ErrHintAlreadyUsed
+		}
+		candidates = append(candidates, hintType)
 	}
-	
-	g.HintsUsed++
-	return hint, nil
+
+	for _, t := range HintOrder {
+		if t != hintType && g.hintAllowed(t) && !g.hintUsed(playerID, t) {
+			candidates = append(candidates, t)
+		}
+	}
+
+	for _, t := range candidates {
+		content, err := g.dict.GetHint(ctx, g.CurrentWord, t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hint: %w", err)
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		g.HintsUsed[playerID] = append(g.HintsUsed[playerID], t)
+		return &Hint{Type: t, Content: content}, nil
+	}
+
+	return nil, ErrNoHintAvailable
+}
+
+func normalizeHintType(t HintType) HintType {
+	if t == HintTypeSentence {
+		return HintTypeExampleSentence
+	}
+	return t
+}
+
+func isSupportedHintType(t HintType) bool {
+	for _, supported := range HintOrder {
+		if t == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GameEngine) hintAllowed(t HintType) bool {
+	if len(g.AllowedHints) == 0 {
+		return true
+	}
+	for _, allowed := range g.AllowedHints {
+		if normalizeHintType(allowed) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GameEngine) hintUsed(playerID string, t HintType) bool {
+	for _, used := range g.HintsUsed[playerID] {
+		if used == t {
+			return true
+		}
+	}
+	return false
+}
+
+// HintsRemaining is how many more hints playerID may use this turn
+func (g *GameEngine) HintsRemaining(playerID string) int {
+	remaining := g.HintsAllowed - len(g.HintsUsed[playerID])
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// EndTurn closes the answer window for the current word
+func (g *GameEngine) EndTurn() {
+	g.TurnStartedAt = nil
+	g.Spelling = nil
+	g.Phase = ""
 }
 
 func (g *GameEngine) CheckTimeLimit() bool {
 	if g.TurnStartedAt == nil {
 		return false
 	}
-	return time.Since(*g.TurnStartedAt) <= TurnTimeout
+	return time.Since(*g.TurnStartedAt) <= g.answerWindow()
 }
 
 func (g *GameEngine) RevealWord() error {
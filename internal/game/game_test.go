@@ -24,9 +24,31 @@ func TestRequestHint(t *testing.T) {
 
 	mockDictService.On("GetHint", mock.Anything, testWord, HintTypeDefinition).Return("A test word", nil)
 
-	hint, err := engine.GetHint(context.Background(), HintTypeDefinition)
+	hint, err := engine.GetHint(context.Background(), "player-1", HintTypeDefinition)
 	assert.NoError(t, err)
-	assert.Equal(t, "A test word", hint)
+	assert.Equal(t, "A test word", hint.Content)
+	assert.Equal(t, MaxHints-1, engine.HintsRemaining("player-1"))
+	assert.Equal(t, MaxHints, engine.HintsRemaining("player-2"))
+}
+
+func TestRequestHintFallsBackWhenEmpty(t *testing.T) {
+	mockDictService := new(MockDictionaryService)
+	engine := NewGameEngine("test-game", mockDictService)
+
+	now := time.Now()
+	testWord := &Word{Word: "TESTING", Definition: "A test word"}
+	engine.CurrentWord = testWord
+	engine.TurnStartedAt = &now
+
+	mockDictService.On("GetHint", mock.Anything, testWord, HintTypeEtymology).Return("", nil)
+	mockDictService.On("GetHint", mock.Anything, testWord, HintTypeDefinition).Return("A test word", nil)
+
+	hint, err := engine.GetHint(context.Background(), "player-1", HintTypeEtymology)
+	assert.NoError(t, err)
+	assert.Equal(t, HintTypeDefinition, hint.Type)
+
+	_, err = engine.GetHint(context.Background(), "player-1", HintTypeDefinition)
+	assert.ErrorIs(t, err, ErrHintAlreadyUsed)
 }
 
 func TestUnmaskWord(t *testing.T) {
@@ -46,3 +68,19 @@ func TestUnmaskWord(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, engine.WordMasked)
 }
+
+func TestMarkServedIgnoresRepeats(t *testing.T) {
+	engine := NewGameEngine("game-1", nil)
+	engine.MarkServed("w1")
+	engine.MarkServed("w2")
+	engine.MarkServed("w1")
+	engine.MarkServed("")
+
+	assert.Equal(t, []string{"w1", "w2"}, engine.Served)
+}
+
+func TestAdjacentLevels(t *testing.T) {
+	assert.Equal(t, []int{5, 6, 4, 7, 3, 8, 2, 9, 1, 10}, adjacentLevels(5))
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, adjacentLevels(1))
+	assert.Equal(t, []int{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}, adjacentLevels(10))
+}
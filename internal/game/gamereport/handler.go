@@ -0,0 +1,66 @@
+package gamereport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Get serves links to download the report of a finished game, to its host
+// and players
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	gameID := httprouter.ParamsFromContext(r.Context()).ByName("gameID")
+	var v validator.Validator
+	_, err := uuid.Parse(gameID)
+	v.CheckField(err == nil, "game_id", "Must be a valid ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	links, err := h.service.Links(r.Context(), gameID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, game.ErrGameNotFound), errors.Is(err, ErrReportNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrNotParticipant):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrReportsDisabled):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
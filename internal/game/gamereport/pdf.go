@@ -0,0 +1,140 @@
+package gamereport
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Reports are laid out on US Letter pages with inch margins
+const (
+	pageWidth    = 612
+	pageHeight   = 792
+	pageMargin   = 72
+	lineSpacing  = 1.4
+	maxLineRunes = 90
+)
+
+// pdfLine is a line of report text, or a blank line when text is empty
+type pdfLine struct {
+	text string
+	size float64
+	bold bool
+}
+
+// renderPDF writes lines top to bottom onto as many pages as they need. The
+// text is set in Helvetica, one of the fonts every PDF reader has, so the
+// document doesn't embed any.
+func renderPDF(lines []pdfLine) []byte {
+	pages := paginate(lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(page), page))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// paginate wraps lines and returns the content stream of each page they
+// fill. There is always at least one page.
+func paginate(lines []pdfLine) []string {
+	var pages []string
+	var page strings.Builder
+	y := float64(pageHeight - pageMargin)
+	for _, line := range lines {
+		for _, text := range wrap(line.text, maxLineRunes) {
+			leading := line.size * lineSpacing
+			if y-leading < pageMargin {
+				pages = append(pages, page.String())
+				page.Reset()
+				y = pageHeight - pageMargin
+			}
+			y -= leading
+			if text == "" {
+				continue
+			}
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&page, "BT /%s %g Tf %d %.2f Td (%s) Tj ET\n", font, line.size, pageMargin, y, pdfString(text))
+		}
+	}
+	return append(pages, page.String())
+}
+
+// wrap breaks text into lines of at most width runes, between words where
+// it can
+func wrap(text string, width int) []string {
+	var lines []string
+	indent := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string([]rune(word)[:width]))
+			word = string([]rune(word)[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line += " " + word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	if indent != "" {
+		lines[0] = indent + lines[0]
+	}
+	return lines
+}
+
+// pdfString encodes text for a PDF string literal in WinAnsiEncoding.
+// Characters the encoding can't show are replaced with question marks.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff || (r >= 0x7f && r < 0xa0):
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,217 @@
+// Package gamereport writes the shareable report of a finished game: the
+// words asked, each player's attempts at them and how the players placed.
+// Reports are stored as JSON and PDF for players to download through
+// presigned links, and are emailed to the hosts of tournament games.
+package gamereport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"big-spella-go/internal/game"
+)
+
+// Report is everything that happened in a finished game
+type Report struct {
+	GameID       string        `json:"game_id"`
+	Type         game.GameType `json:"type"`
+	Mode         string        `json:"mode,omitempty"`
+	IsTournament bool          `json:"is_tournament"`
+	HostID       string        `json:"host_id"`
+	FinishedAt   time.Time     `json:"finished_at"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+	// Players are listed by Placement
+	Players []PlayerReport `json:"players"`
+	// Words are the words asked, in the order they were asked
+	Words []WordReport `json:"words"`
+}
+
+// PlayerReport is how one player did over the whole game
+type PlayerReport struct {
+	PlayerID  string `json:"player_id"`
+	Username  string `json:"username"`
+	Placement int    `json:"placement"`
+	Score     int    `json:"score"`
+	Attempts  int    `json:"attempts"`
+	Correct   int    `json:"correct"`
+	// Accuracy is the fraction of Attempts that were correct
+	Accuracy  float64 `json:"accuracy"`
+	HintsUsed int     `json:"hints_used"`
+	Team      *int    `json:"team,omitempty"`
+}
+
+// WordReport is one word asked and the attempts made at it
+type WordReport struct {
+	Round int `json:"round"`
+	// Word is empty when the game never reveals missed words and nobody
+	// spelled it
+	Word     string          `json:"word"`
+	Attempts []AttemptReport `json:"attempts"`
+}
+
+type AttemptReport struct {
+	PlayerID  string `json:"player_id"`
+	Text      string `json:"text"`
+	Correct   bool   `json:"correct"`
+	HintsUsed int    `json:"hints_used"`
+	Points    int    `json:"points"`
+}
+
+// Attempt is a judged attempt from the game, as the report is built from
+type Attempt struct {
+	PlayerID  string    `db:"player_id"`
+	Word      string    `db:"word"`
+	Text      string    `db:"text"`
+	IsCorrect bool      `db:"is_correct"`
+	HintsUsed int       `db:"hints_used"`
+	Points    int       `db:"points"`
+	Timestamp time.Time `db:"timestamp"`
+}
+
+// Build writes the report of g from its results and its judged attempts in
+// the order they were made. Attempts at the same word one after another
+// are one round; usernames maps player IDs to their usernames.
+func Build(g *game.Game, results []game.PlayerResult, attempts []Attempt, usernames map[string]string, generatedAt time.Time) *Report {
+	report := &Report{
+		GameID:       g.ID,
+		Type:         g.Type,
+		Mode:         g.Mode,
+		IsTournament: g.Settings.IsTournament,
+		HostID:       g.HostID,
+		FinishedAt:   g.UpdatedAt,
+		GeneratedAt:  generatedAt,
+		Players:      make([]PlayerReport, 0, len(results)),
+		Words:        []WordReport{},
+	}
+
+	tallies := make(map[string]*PlayerReport, len(results))
+	for _, result := range results {
+		report.Players = append(report.Players, PlayerReport{
+			PlayerID:  result.PlayerID,
+			Username:  usernames[result.PlayerID],
+			Placement: result.Placement,
+			Score:     result.Score,
+			Team:      result.Team,
+		})
+	}
+	for i := range report.Players {
+		tallies[report.Players[i].PlayerID] = &report.Players[i]
+	}
+
+	// spelled is whether anyone got each round's word
+	var spelled []bool
+	for _, attempt := range attempts {
+		if n := len(report.Words); n == 0 || report.Words[n-1].Word != attempt.Word {
+			report.Words = append(report.Words, WordReport{Round: n + 1, Word: attempt.Word, Attempts: []AttemptReport{}})
+			spelled = append(spelled, false)
+		}
+		n := len(report.Words) - 1
+		report.Words[n].Attempts = append(report.Words[n].Attempts, AttemptReport{
+			PlayerID:  attempt.PlayerID,
+			Text:      attempt.Text,
+			Correct:   attempt.IsCorrect,
+			HintsUsed: attempt.HintsUsed,
+			Points:    attempt.Points,
+		})
+		spelled[n] = spelled[n] || attempt.IsCorrect
+
+		if tally := tallies[attempt.PlayerID]; tally != nil {
+			tally.Attempts++
+			tally.HintsUsed += attempt.HintsUsed
+			if attempt.IsCorrect {
+				tally.Correct++
+			}
+		}
+	}
+
+	if g.Settings.Reveal() == game.RevealNever {
+		for i := range report.Words {
+			if !spelled[i] {
+				report.Words[i].Word = ""
+			}
+		}
+	}
+	for i := range report.Players {
+		if player := &report.Players[i]; player.Attempts > 0 {
+			player.Accuracy = float64(player.Correct) / float64(player.Attempts)
+		}
+	}
+	sort.SliceStable(report.Players, func(i, j int) bool {
+		return report.Players[i].Placement < report.Players[j].Placement
+	})
+	return report
+}
+
+// RenderJSON encodes the report for download
+func (r *Report) RenderJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+	return data, nil
+}
+
+// RenderPDF lays the report out as a printable document
+func (r *Report) RenderPDF() []byte {
+	name := func(playerID string) string {
+		for _, player := range r.Players {
+			if player.PlayerID == playerID && player.Username != "" {
+				return player.Username
+			}
+		}
+		return playerID
+	}
+
+	lines := []pdfLine{
+		{text: "Big Spella game report", size: 18, bold: true},
+		{text: "Game " + r.GameID, size: 10},
+		{text: describeGame(r), size: 10},
+		{text: "Finished " + r.FinishedAt.UTC().Format("2 January 2006 15:04 MST"), size: 10},
+		{size: 10},
+		{text: "Final placements", size: 13, bold: true},
+	}
+	for _, player := range r.Players {
+		line := fmt.Sprintf("%d. %s - %d points, %d of %d correct (%.0f%%), %d hints used",
+			player.Placement, name(player.PlayerID), player.Score, player.Correct, player.Attempts,
+			player.Accuracy*100, player.HintsUsed)
+		if player.Team != nil {
+			line += fmt.Sprintf(", team %d", *player.Team)
+		}
+		lines = append(lines, pdfLine{text: line, size: 10})
+	}
+
+	lines = append(lines, pdfLine{size: 10}, pdfLine{text: "Words", size: 13, bold: true})
+	for _, word := range r.Words {
+		spelling := word.Word
+		if spelling == "" {
+			spelling = "(not revealed)"
+		}
+		lines = append(lines, pdfLine{text: fmt.Sprintf("Round %d: %s", word.Round, spelling), size: 10, bold: true})
+		for _, attempt := range word.Attempts {
+			verdict := "missed"
+			if attempt.Correct {
+				verdict = "correct"
+			}
+			line := fmt.Sprintf(`    %s: "%s", %s, %+d points`, name(attempt.PlayerID), attempt.Text, verdict, attempt.Points)
+			if attempt.HintsUsed > 0 {
+				line += fmt.Sprintf(", %d hints", attempt.HintsUsed)
+			}
+			lines = append(lines, pdfLine{text: line, size: 10})
+		}
+	}
+	return renderPDF(lines)
+}
+
+func describeGame(r *Report) string {
+	parts := []string{string(r.Type) + " game"}
+	if r.Mode != "" {
+		parts = append(parts, strings.ReplaceAll(r.Mode, "_", " "))
+	}
+	if r.IsTournament {
+		parts = append(parts, "tournament")
+	}
+	return strings.Join(parts, ", ")
+}
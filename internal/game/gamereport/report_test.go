@@ -0,0 +1,103 @@
+package gamereport
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+)
+
+func testGame(reveal game.RevealPolicy) *game.Game {
+	return &game.Game{
+		ID:       "game-1",
+		Type:     game.GameType("casual"),
+		HostID:   "alice",
+		Settings: game.GameSettings{RevealPolicy: reveal},
+	}
+}
+
+func testAttempts() []Attempt {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []Attempt{
+		{PlayerID: "alice", Word: "rhythm", Text: "rythm", Timestamp: start},
+		{PlayerID: "bob", Word: "rhythm", Text: "rhythm", IsCorrect: true, HintsUsed: 1, Points: 8, Timestamp: start.Add(time.Second)},
+		{PlayerID: "alice", Word: "queue", Text: "que", Timestamp: start.Add(time.Minute)},
+		{PlayerID: "bob", Word: "queue", Text: "cue", Timestamp: start.Add(time.Minute + time.Second)},
+		{PlayerID: "alice", Word: "rhythm", Text: "rhythm", IsCorrect: true, Points: 10, Timestamp: start.Add(2 * time.Minute)},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	results := []game.PlayerResult{
+		{PlayerID: "bob", Score: 8, Placement: 2},
+		{PlayerID: "alice", Score: 10, Placement: 1},
+	}
+	report := Build(testGame(game.RevealAlways), results, testAttempts(), map[string]string{"alice": "Alice", "bob": "Bob"}, time.Now())
+
+	require.Len(t, report.Players, 2)
+	alice, bob := report.Players[0], report.Players[1]
+	assert.Equal(t, "alice", alice.PlayerID)
+	assert.Equal(t, "Alice", alice.Username)
+	assert.Equal(t, 3, alice.Attempts)
+	assert.Equal(t, 1, alice.Correct)
+	assert.InDelta(t, 1.0/3, alice.Accuracy, 0.001)
+	assert.Equal(t, 1, bob.HintsUsed)
+
+	// The word coming back later is a round of its own
+	require.Len(t, report.Words, 3)
+	assert.Equal(t, []string{"rhythm", "queue", "rhythm"}, []string{report.Words[0].Word, report.Words[1].Word, report.Words[2].Word})
+	assert.Equal(t, 3, report.Words[2].Round)
+	assert.Len(t, report.Words[0].Attempts, 2)
+}
+
+func TestBuildHidesWordsNeverRevealed(t *testing.T) {
+	report := Build(testGame(game.RevealNever), nil, testAttempts(), nil, time.Now())
+
+	require.Len(t, report.Words, 3)
+	assert.Equal(t, "rhythm", report.Words[0].Word)
+	assert.Empty(t, report.Words[1].Word)
+}
+
+func TestRenderPDF(t *testing.T) {
+	attempts := testAttempts()
+	for i := 0; i < 100; i++ {
+		attempts = append(attempts, Attempt{PlayerID: "bob", Word: "(paren)", Text: `back\slash – ünïcode ✓`})
+	}
+	report := Build(testGame(game.RevealAlways), []game.PlayerResult{{PlayerID: "bob", Placement: 1}}, attempts, nil, time.Now())
+
+	pdf := report.RenderPDF()
+
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), `\(paren\)`)
+	assert.Contains(t, string(pdf), "back\\\\slash ? \xfcn\xefcode ?")
+	assert.Contains(t, string(pdf), "/Count 3")
+}
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, []string{""}, wrap("", 10))
+	assert.Equal(t, []string{"  one two", "three"}, wrap("  one two three", 9))
+	assert.Equal(t, []string{"abcde", "fgh"}, wrap("abcdefgh", 5))
+}
+
+func TestGetChecksGameID(t *testing.T) {
+	h := NewHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/games/x/report", nil)
+	ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "gameID", Value: "x"}})
+	req = req.WithContext(auth.SetUserIDInContext(ctx, "user-1"))
+	rec := httptest.NewRecorder()
+
+	h.Get(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "game_id")
+}
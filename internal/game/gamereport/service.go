@@ -0,0 +1,295 @@
+package gamereport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/jobs"
+	"big-spella-go/internal/smtp"
+)
+
+const (
+	JobGenerate = "game_report_generate"
+
+	// ReportEmail is the template tournament hosts are sent their report in
+	ReportEmail = "game_report.tmpl"
+
+	// LinkExpiry is how long the download links players are given last
+	LinkExpiry = time.Hour
+	// EmailLinkExpiry is how long the links emailed to hosts last, the
+	// longest S3 allows
+	EmailLinkExpiry = 7 * 24 * time.Hour
+
+	inlineTimeout = 30 * time.Second
+)
+
+var (
+	ErrReportNotFound  = errors.New("the game's report isn't ready")
+	ErrReportsDisabled = errors.New("game reports are not configured")
+	ErrNotParticipant  = errors.New("only the game's host and players can see its report")
+)
+
+// Store keeps the rendered reports
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// JobQueue queues reports to be generated in the background with retries
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error)
+}
+
+// Links are where a game's report can be downloaded from until ExpiresAt
+type Links struct {
+	JSONURL     string    `json:"json_url"`
+	PDFURL      string    `json:"pdf_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type ServiceOption func(*Service)
+
+// WithStorage keeps reports in store. Without it no reports are generated.
+func WithStorage(store Store) ServiceOption {
+	return func(s *Service) {
+		s.store = store
+	}
+}
+
+// WithJobs generates reports through queue, so storage outages are
+// retried. Without it they are generated straight away and dropped on
+// failure.
+func WithJobs(queue JobQueue) ServiceOption {
+	return func(s *Service) {
+		s.queue = queue
+	}
+}
+
+type Service struct {
+	db      *sqlx.DB
+	store   Store
+	queue   JobQueue
+	send    smtp.SendEmail
+	onError func(error)
+}
+
+// NewService reports reports that couldn't be generated or emailed to
+// onError
+func NewService(db *sqlx.DB, onError func(error), opts ...ServiceOption) *Service {
+	s := &Service{db: db, onError: onError}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetEmailSender emails the reports of tournament games to their hosts with
+// send. It is meant to be called during startup; without it, reports are
+// only downloaded.
+func (s *Service) SetEmailSender(send smtp.SendEmail) {
+	s.send = send
+}
+
+type generateJob struct {
+	Game    *game.Game          `json:"game"`
+	Results []game.PlayerResult `json:"results"`
+}
+
+// RegisterJobs has worker generate the reports the service queues
+func (s *Service) RegisterJobs(worker *jobs.Worker) {
+	worker.Register(JobGenerate, func(ctx context.Context, job *jobs.Job) error {
+		var payload generateJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		if payload.Game == nil {
+			return jobs.Permanent(errors.New("report job has no game"))
+		}
+		return s.generate(ctx, payload)
+	})
+}
+
+// PublishResults generates the report of a game once it finishes
+func (s *Service) PublishResults(ctx context.Context, g *game.Game, results []game.PlayerResult) {
+	if s.store == nil {
+		return
+	}
+
+	job := generateJob{Game: g, Results: results}
+	if s.queue != nil {
+		if _, err := s.queue.Enqueue(ctx, JobGenerate, job); err != nil {
+			s.report(err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inlineTimeout)
+	go func() {
+		defer cancel()
+		if err := s.generate(ctx, job); err != nil {
+			s.report(err)
+		}
+	}()
+}
+
+func (s *Service) report(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// generate builds, stores and records the report of the game in job, and
+// emails it to the host when the game was a tournament
+func (s *Service) generate(ctx context.Context, job generateJob) error {
+	g := job.Game
+
+	var attempts []Attempt
+	if err := s.db.SelectContext(ctx, &attempts, `
+		SELECT player_id, word, text, is_correct, hints_used, points, timestamp
+		FROM spelling_attempts
+		WHERE game_id = $1 AND status = $2
+		ORDER BY timestamp, id`, g.ID, game.AttemptStatusJudged); err != nil {
+		return fmt.Errorf("failed to get attempts: %w", err)
+	}
+
+	playerIDs := make([]string, len(job.Results))
+	for i, result := range job.Results {
+		playerIDs[i] = result.PlayerID
+	}
+	var users []struct {
+		ID       string `db:"id"`
+		Username string `db:"username"`
+	}
+	if err := s.db.SelectContext(ctx, &users, `
+		SELECT id, username FROM users WHERE id = ANY($1)`, pq.Array(playerIDs)); err != nil {
+		return fmt.Errorf("failed to get usernames: %w", err)
+	}
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	report := Build(g, job.Results, attempts, usernames, time.Now())
+	data, err := report.RenderJSON()
+	if err != nil {
+		return err
+	}
+	jsonKey, pdfKey := reportKey(g.ID, "json"), reportKey(g.ID, "pdf")
+	if err := s.store.Put(ctx, jsonKey, data, "application/json"); err != nil {
+		return err
+	}
+	if err := s.store.Put(ctx, pdfKey, report.RenderPDF(), "application/pdf"); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO game_reports (game_id, json_key, pdf_key, generated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (game_id) DO UPDATE
+		SET json_key = EXCLUDED.json_key, pdf_key = EXCLUDED.pdf_key, generated_at = EXCLUDED.generated_at`,
+		g.ID, jsonKey, pdfKey, report.GeneratedAt); err != nil {
+		return fmt.Errorf("failed to record report: %w", err)
+	}
+
+	if g.Settings.IsTournament && s.send != nil {
+		// The report is stored whether or not the email goes out
+		if err := s.emailHost(ctx, g, jsonKey, pdfKey); err != nil {
+			s.report(err)
+		}
+	}
+	return nil
+}
+
+func reportKey(gameID, ext string) string {
+	return "reports/games/" + gameID + "/report." + ext
+}
+
+// emailHost sends the host of g links to its report
+func (s *Service) emailHost(ctx context.Context, g *game.Game, jsonKey, pdfKey string) error {
+	var host struct {
+		Username string `db:"username"`
+		Email    string `db:"email"`
+	}
+	if err := s.db.GetContext(ctx, &host, `
+		SELECT username, email FROM users WHERE id = $1`, g.HostID); err != nil {
+		return fmt.Errorf("failed to get host: %w", err)
+	}
+
+	links, err := s.presign(ctx, jsonKey, pdfKey, EmailLinkExpiry)
+	if err != nil {
+		return err
+	}
+	if err := s.send(host.Email, map[string]any{
+		"Username":  host.Username,
+		"GameID":    g.ID,
+		"PDFURL":    links.PDFURL,
+		"JSONURL":   links.JSONURL,
+		"ExpiresAt": links.ExpiresAt.UTC().Format("2 January 2006 15:04 MST"),
+	}, ReportEmail); err != nil {
+		return fmt.Errorf("failed to email report of game %s: %w", g.ID, err)
+	}
+	return nil
+}
+
+func (s *Service) presign(ctx context.Context, jsonKey, pdfKey string, expiry time.Duration) (*Links, error) {
+	links := &Links{ExpiresAt: time.Now().Add(expiry)}
+	var err error
+	if links.JSONURL, err = s.store.PresignGet(ctx, jsonKey, expiry); err != nil {
+		return nil, err
+	}
+	if links.PDFURL, err = s.store.PresignGet(ctx, pdfKey, expiry); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Links returns links to download the report of gameID, for its host and
+// players only
+func (s *Service) Links(ctx context.Context, gameID, userID string) (*Links, error) {
+	if s.store == nil {
+		return nil, ErrReportsDisabled
+	}
+
+	var allowed bool
+	if err := s.db.GetContext(ctx, &allowed, `
+		SELECT g.host_id = $2 OR EXISTS (
+			SELECT 1 FROM players p WHERE p.game_id = g.id AND p.player_id = $2)
+		FROM games g
+		WHERE g.id = $1`, gameID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, game.ErrGameNotFound
+		}
+		return nil, fmt.Errorf("failed to get game: %w", err)
+	}
+	if !allowed {
+		return nil, ErrNotParticipant
+	}
+
+	var row struct {
+		JSONKey     string    `db:"json_key"`
+		PDFKey      string    `db:"pdf_key"`
+		GeneratedAt time.Time `db:"generated_at"`
+	}
+	if err := s.db.GetContext(ctx, &row, `
+		SELECT json_key, pdf_key, generated_at FROM game_reports WHERE game_id = $1`, gameID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReportNotFound
+		}
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+
+	links, err := s.presign(ctx, row.JSONKey, row.PDFKey, LinkExpiry)
+	if err != nil {
+		return nil, err
+	}
+	links.GeneratedAt = row.GeneratedAt
+	return links, nil
+}
@@ -0,0 +1,486 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game/bigspellav1"
+	"big-spella-go/internal/game/modes"
+	"big-spella-go/internal/game/stt"
+	"big-spella-go/internal/validator"
+)
+
+var errFellBehind = errors.New("fell behind the game's events")
+
+// methodScopes are the scopes the gRPC methods need, any one of which will do
+var methodScopes = map[string][]auth.Scope{
+	bigspellav1.GameService_CreateGame_FullMethodName:       {auth.ScopeGamesWrite},
+	bigspellav1.GameService_JoinGame_FullMethodName:         {auth.ScopeGamesWrite},
+	bigspellav1.GameService_GetGame_FullMethodName:          {auth.ScopeGamesRead},
+	bigspellav1.GameService_MakeAttempt_FullMethodName:      {auth.ScopeGamesWrite},
+	bigspellav1.GameService_GameEvents_FullMethodName:       {auth.ScopeEventsRead},
+	bigspellav1.WordService_GetRandomWord_FullMethodName:    {auth.ScopeGamesRead, auth.ScopeWordsRead},
+	bigspellav1.WordService_ValidateSpelling_FullMethodName: {auth.ScopeGamesRead, auth.ScopeWordsRead},
+}
+
+// NewGRPCServer serves games and words over gRPC for internal consumers,
+// such as matchmaking workers and bot runners, authenticating each call's
+// bearer token with authenticator
+func NewGRPCServer(games GameService, words WordService, authenticator Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	a := &grpcAuth{authenticator: authenticator}
+	srv := grpc.NewServer(append(opts, grpc.UnaryInterceptor(a.unary), grpc.StreamInterceptor(a.stream))...)
+	bigspellav1.RegisterGameServiceServer(srv, &gameServer{games: games})
+	bigspellav1.RegisterWordServiceServer(srv, &wordServer{words: words})
+	return srv
+}
+
+// grpcAuth signs in each call from the bearer token in its authorization
+// metadata and checks it holds one of the method's scopes
+type grpcAuth struct {
+	authenticator Authenticator
+}
+
+func (a *grpcAuth) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *grpcAuth) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *grpcAuth) authenticate(ctx context.Context, method string) (context.Context, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	ctx, err := a.authenticator.Authenticate(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	principal := auth.GetPrincipal(ctx)
+	for _, scope := range methodScopes[method] {
+		if principal.HasScope(scope) {
+			return ctx, nil
+		}
+	}
+	return nil, status.Error(codes.PermissionDenied, auth.ErrInsufficientScope.Error())
+}
+
+// authedStream carries the signed in context to a streaming method
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+// actingPlayer returns who a call acts for: the player whose token it
+// carries, or the player a delegating service names in playerID. Services
+// may leave playerID empty where spectator will do.
+func actingPlayer(ctx context.Context, playerID string, spectator bool) (string, error) {
+	principal := auth.GetPrincipal(ctx)
+	if principal.UserID != "" {
+		if playerID != "" && playerID != principal.UserID {
+			return "", status.Error(codes.PermissionDenied, "a player's token can only act for them")
+		}
+		return principal.UserID, nil
+	}
+
+	if playerID == "" && spectator {
+		return "", nil
+	}
+	if !principal.HasScope(auth.ScopeGamesDelegate) {
+		return "", status.Error(codes.PermissionDenied, auth.ErrInsufficientScope.Error())
+	}
+	var v validator.Validator
+	_, err := uuid.Parse(playerID)
+	v.CheckField(err == nil, "player_id", "Must be a valid player ID")
+	if v.HasErrors() {
+		return "", invalidArgument(v)
+	}
+	return playerID, nil
+}
+
+type gameServer struct {
+	bigspellav1.UnimplementedGameServiceServer
+	games GameService
+}
+
+func (s *gameServer) CreateGame(ctx context.Context, req *bigspellav1.CreateGameRequest) (*bigspellav1.Game, error) {
+	hostID, err := actingPlayer(ctx, req.PlayerId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	create := CreateGameRequest{Type: GameType(req.Type), Settings: settingsFromProto(req.Settings)}
+	if create.validate(); create.Validator.HasErrors() {
+		return nil, invalidArgument(create.Validator)
+	}
+
+	game, err := s.games.CreateGame(ctx, hostID, create.Type, create.Settings)
+	if err != nil {
+		if errors.Is(err, ErrUnknownCategory) {
+			create.Validator.AddFieldError("settings.category", "Must be an existing category")
+			return nil, invalidArgument(create.Validator)
+		}
+		if errors.Is(err, ErrUnknownWordList) {
+			create.Validator.AddFieldError("settings.word_list", "Must be one of your word lists")
+			return nil, invalidArgument(create.Validator)
+		}
+		return nil, grpcError(err)
+	}
+	return gameToProto(viewFor(game, hostID)), nil
+}
+
+func (s *gameServer) JoinGame(ctx context.Context, req *bigspellav1.JoinGameRequest) (*bigspellav1.Game, error) {
+	playerID, err := actingPlayer(ctx, req.PlayerId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	join := JoinGameRequest{InviteCode: req.InviteCode}
+	join.Validator.CheckField(validGameUUID(req.GameId), "game_id", "Must be a valid game ID")
+	if join.validate(); join.Validator.HasErrors() {
+		return nil, invalidArgument(join.Validator)
+	}
+
+	game, err := s.games.JoinGame(ctx, req.GameId, playerID, req.InviteCode)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return gameToProto(viewFor(game, playerID)), nil
+}
+
+func (s *gameServer) GetGame(ctx context.Context, req *bigspellav1.GetGameRequest) (*bigspellav1.Game, error) {
+	viewerID, err := actingPlayer(ctx, req.PlayerId, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGameID(req.GameId); err != nil {
+		return nil, err
+	}
+
+	game, err := s.games.GetGame(ReadOnly(ctx), req.GameId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return gameToProto(viewFor(game, viewerID)), nil
+}
+
+func (s *gameServer) MakeAttempt(ctx context.Context, req *bigspellav1.MakeAttemptRequest) (*bigspellav1.MakeAttemptResponse, error) {
+	playerID, err := actingPlayer(ctx, req.PlayerId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var attemptReq MakeAttemptRequest
+	switch a := req.Attempt.(type) {
+	case *bigspellav1.MakeAttemptRequest_Text:
+		attemptReq = MakeAttemptRequest{Type: AttemptTypeText, Text: &a.Text}
+	case *bigspellav1.MakeAttemptRequest_VoiceData:
+		attemptReq = MakeAttemptRequest{Type: AttemptTypeVoice, VoiceData: a.VoiceData}
+	}
+	attemptReq.Validator.CheckField(validGameUUID(req.GameId), "game_id", "Must be a valid game ID")
+	if attemptReq.validate(); attemptReq.Validator.HasErrors() {
+		return nil, invalidArgument(attemptReq.Validator)
+	}
+	if attemptReq.Type == AttemptTypeVoice && auth.IsChild(ctx) {
+		return nil, status.Error(codes.PermissionDenied, errChildVoice.Error())
+	}
+
+	attempt := attemptReq.attempt()
+	if err := s.games.MakeAttempt(ctx, req.GameId, playerID, attempt); err != nil {
+		return nil, grpcError(err)
+	}
+	return &bigspellav1.MakeAttemptResponse{
+		AttemptId: attempt.ID,
+		Status:    string(attempt.Status),
+		IsCorrect: attempt.IsCorrect,
+	}, nil
+}
+
+func (s *gameServer) GameEvents(req *bigspellav1.GameEventsRequest, stream bigspellav1.GameService_GameEventsServer) error {
+	ctx := stream.Context()
+	viewerID, err := actingPlayer(ctx, req.PlayerId, true)
+	if err != nil {
+		return err
+	}
+	if err := checkGameID(req.GameId); err != nil {
+		return err
+	}
+
+	// Only games that are still going have events to follow
+	game, err := s.games.GetGame(ctx, req.GameId)
+	if err != nil {
+		return grpcError(err)
+	}
+	if game.Status == GameStatusFinished || game.Status == GameStatusCancelled {
+		snapshot := &bigspellav1.GameEventsResponse_Snapshot{Snapshot: gameToProto(viewFor(game, viewerID))}
+		return stream.Send(&bigspellav1.GameEventsResponse{Item: snapshot})
+	}
+
+	sub := s.games.Subscribe(req.GameId, viewerID, req.AfterSeq)
+	defer sub.Cancel()
+
+	if sub.Resumed {
+		for _, event := range sub.Missed {
+			if err := sendEvent(stream, event); err != nil {
+				return err
+			}
+		}
+	} else {
+		game, err := s.games.GetGame(ctx, req.GameId)
+		if err != nil {
+			return grpcError(err)
+		}
+		snapshot := &bigspellav1.GameEventsResponse_Snapshot{Snapshot: gameToProto(viewFor(game, viewerID))}
+		if err := stream.Send(&bigspellav1.GameEventsResponse{Item: snapshot}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event, ok := <-sub.Events:
+			if !ok {
+				// The caller resumes from the last event it saw
+				return status.Error(codes.Unavailable, errFellBehind.Error())
+			}
+			if err := sendEvent(stream, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sendEvent(stream bigspellav1.GameService_GameEventsServer, event GameEvent) error {
+	pb, err := eventToProto(event)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.Send(&bigspellav1.GameEventsResponse{Item: &bigspellav1.GameEventsResponse_Event{Event: pb}})
+}
+
+type wordServer struct {
+	bigspellav1.UnimplementedWordServiceServer
+	words WordService
+}
+
+func (s *wordServer) GetRandomWord(ctx context.Context, req *bigspellav1.GetRandomWordRequest) (*bigspellav1.Word, error) {
+	var v validator.Validator
+	v.CheckField(validator.Between(int(req.Level), 1, 10), "level", "Must be between 1 and 10")
+	v.CheckField(req.Language == "" || IsSupportedLanguage(req.Language), "language", "Must be a supported language")
+	if v.HasErrors() {
+		return nil, invalidArgument(v)
+	}
+
+	word, err := s.words.GetRandomWord(ctx, WordQuery{
+		Level:    int(req.Level),
+		Category: req.Category,
+		Language: req.Language,
+		Exclude:  req.Exclude,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return wordToProto(word), nil
+}
+
+func (s *wordServer) ValidateSpelling(ctx context.Context, req *bigspellav1.ValidateSpellingRequest) (*bigspellav1.ValidateSpellingResponse, error) {
+	var v validator.Validator
+	v.CheckField(validator.NotBlank(req.Word), "word", "Word is required")
+	v.CheckField(validator.MaxRunes(req.Attempt, MaxTextAttempt), "attempt", "Must not be more than 100 characters")
+	if v.HasErrors() {
+		return nil, invalidArgument(v)
+	}
+	return &bigspellav1.ValidateSpellingResponse{Correct: s.words.ValidateSpelling(ctx, req.Word, req.Attempt)}, nil
+}
+
+func validGameUUID(gameID string) bool {
+	_, err := uuid.Parse(gameID)
+	return err == nil
+}
+
+func checkGameID(gameID string) error {
+	var v validator.Validator
+	v.CheckField(validGameUUID(gameID), "game_id", "Must be a valid game ID")
+	if v.HasErrors() {
+		return invalidArgument(v)
+	}
+	return nil
+}
+
+// invalidArgument reports v's errors, one field to a line
+func invalidArgument(v validator.Validator) error {
+	fields := make([]string, 0, len(v.FieldErrors))
+	for field, msg := range v.FieldErrors {
+		fields = append(fields, field+": "+msg)
+	}
+	sort.Strings(fields)
+	return status.Error(codes.InvalidArgument, strings.Join(append(v.Errors, fields...), "\n"))
+}
+
+// grpcError maps the game service's errors to status codes, the way the
+// HTTP handlers map them to status codes of their own
+func grpcError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, ErrGameNotFound), errors.Is(err, ErrInvalidInviteCode), errors.Is(err, ErrPlayerNotFound):
+		code = codes.NotFound
+	case errors.Is(err, ErrPlayerKicked), errors.Is(err, ErrInviteRequired), errors.Is(err, ErrInvalidInvitation),
+		errors.Is(err, ErrNotHost):
+		code = codes.PermissionDenied
+	case errors.Is(err, ErrGameFull), errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrReviewPending),
+		errors.Is(err, ErrNotPlayerTurn), errors.Is(err, ErrGamePaused), errors.Is(err, ErrAnswerWindowClosed),
+		errors.Is(err, ErrGameChanged), errors.Is(err, ErrAnswerTooLate), errors.Is(err, ErrConfirmationPending):
+		code = codes.FailedPrecondition
+	case errors.Is(err, ErrInvalidRevealPolicy):
+		code = codes.InvalidArgument
+	case errors.Is(err, stt.ErrOverloaded):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+func settingsFromProto(s *bigspellav1.GameSettings) GameSettings {
+	if s == nil {
+		return GameSettings{}
+	}
+	return GameSettings{
+		Mode:         modes.GameMode(s.Mode),
+		MaxRounds:    int(s.MaxRounds),
+		MinPlayers:   int(s.MinPlayers),
+		MaxPlayers:   int(s.MaxPlayers),
+		TimeLimit:    s.TimeLimit.AsDuration(),
+		Category:     s.Category,
+		IsRanked:     s.IsRanked,
+		IsPrivate:    s.IsPrivate,
+		Elimination:  s.Elimination,
+		WordLevel:    int(s.WordLevel),
+		HintsAllowed: int(s.HintsAllowed),
+		Language:     s.Language,
+	}
+}
+
+func settingsToProto(s GameSettings) *bigspellav1.GameSettings {
+	return &bigspellav1.GameSettings{
+		Mode:         string(s.Mode),
+		MaxRounds:    int32(s.MaxRounds),
+		MinPlayers:   int32(s.MinPlayers),
+		MaxPlayers:   int32(s.MaxPlayers),
+		TimeLimit:    durationpb.New(s.TimeLimit),
+		Category:     s.Category,
+		IsRanked:     s.IsRanked,
+		IsPrivate:    s.IsPrivate,
+		Elimination:  s.Elimination,
+		WordLevel:    int32(s.WordLevel),
+		HintsAllowed: int32(s.HintsAllowed),
+		Language:     s.Language,
+	}
+}
+
+func gameToProto(view GameView) *bigspellav1.Game {
+	game := view.Game
+	pb := &bigspellav1.Game{
+		Id:          game.ID,
+		Type:        string(game.Type),
+		Status:      string(game.Status),
+		Mode:        game.Mode,
+		Settings:    settingsToProto(game.Settings),
+		Round:       int32(game.Round),
+		HostId:      game.HostID,
+		CurrentTurn: game.CurrentTurn,
+		Version:     int32(game.Version),
+		CreatedAt:   timestamppb.New(game.CreatedAt),
+		UpdatedAt:   timestamppb.New(game.UpdatedAt),
+		InviteCode:  view.InviteCode,
+	}
+	if game.CurrentWord != nil {
+		pb.CurrentWord = wordToProto(game.CurrentWord)
+	}
+	for _, p := range game.Players {
+		player := &bigspellav1.Player{
+			UserId:   p.UserID,
+			Score:    int32(p.Score),
+			Status:   p.Status,
+			IsBot:    p.IsBot,
+			Attempts: int32(p.Attempts),
+			Correct:  int32(p.Correct),
+			JoinedAt: timestamppb.New(p.JoinedAt),
+		}
+		if p.Team != nil {
+			team := int32(*p.Team)
+			player.Team = &team
+		}
+		pb.Players = append(pb.Players, player)
+	}
+	return pb
+}
+
+func wordToProto(w *Word) *bigspellav1.Word {
+	return &bigspellav1.Word{
+		Id:              w.ID,
+		Word:            w.Word,
+		Definition:      w.Definition,
+		ExampleSentence: w.ExampleSentence,
+		PartOfSpeech:    w.PartOfSpeech,
+		Pronunciation:   w.Pronunciation,
+		Language:        w.Language,
+		AudioUrl:        w.AudioURL,
+	}
+}
+
+// eventToProto converts event, whose payload goes over as the JSON the
+// WebSocket would send
+func eventToProto(event GameEvent) (*bigspellav1.GameEvent, error) {
+	payload := &structpb.Struct{}
+	if len(event.Payload) > 0 {
+		b, err := json.Marshal(event.Payload)
+		if err != nil {
+			return nil, err
+		}
+		if err := protojson.Unmarshal(b, payload); err != nil {
+			return nil, err
+		}
+	}
+	return &bigspellav1.GameEvent{
+		Seq:       event.Seq,
+		Type:      string(event.Type),
+		GameId:    event.GameID,
+		PlayerId:  event.PlayerID,
+		Timestamp: timestamppb.New(event.Timestamp),
+		Payload:   payload,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package game
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game/bigspellav1"
+)
+
+const (
+	grpcPlayerID = "6f1c2a4e-8d3b-4c1e-9a7f-2b5d8e0c3a11"
+	grpcGameID   = "0b7e9c52-3f4a-4d8e-b1c6-7a2e5f9d4c30"
+)
+
+// grpcGames fakes the parts of GameService the gRPC server calls
+type grpcGames struct {
+	GameService
+	hostID   string
+	settings GameSettings
+	events   chan GameEvent
+}
+
+func (g *grpcGames) CreateGame(_ context.Context, hostID string, gameType GameType, settings GameSettings) (*Game, error) {
+	g.hostID, g.settings = hostID, settings
+	code := "ABC123"
+	return &Game{ID: grpcGameID, Type: gameType, HostID: hostID, Settings: settings, InviteCode: &code}, nil
+}
+
+func (g *grpcGames) GetGame(_ context.Context, gameID string) (*Game, error) {
+	if gameID != grpcGameID {
+		return nil, ErrGameNotFound
+	}
+	return &Game{ID: gameID, HostID: grpcPlayerID}, nil
+}
+
+func (g *grpcGames) Subscribe(gameID, viewerID string, after int64) *Subscription {
+	return &Subscription{Events: g.events, cancel: func() {}}
+}
+
+// grpcTokens signs in the tokens it knows
+type grpcTokens map[string]*auth.Principal
+
+func (t grpcTokens) Authenticate(ctx context.Context, token string) (context.Context, error) {
+	principal, ok := t[token]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+	ctx = auth.SetPrincipalInContext(ctx, principal)
+	if principal.UserID != "" {
+		ctx = auth.SetUserIDInContext(ctx, principal.UserID)
+	}
+	return ctx, nil
+}
+
+func dialGRPC(t *testing.T, games GameService) bigspellav1.GameServiceClient {
+	t.Helper()
+	tokens := grpcTokens{
+		"player":   {UserID: grpcPlayerID, Scopes: auth.UserScopes},
+		"matcher":  {Service: "matchmaker", Scopes: []auth.Scope{auth.ScopeGamesWrite, auth.ScopeGamesDelegate}},
+		"reporter": {Service: "reporter", Scopes: []auth.Scope{auth.ScopeGamesWrite}},
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(games, nil, tokens)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return bigspellav1.NewGameServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPCCreateGame(t *testing.T) {
+	games := &grpcGames{}
+	client := dialGRPC(t, games)
+	req := &bigspellav1.CreateGameRequest{
+		Type:     string(GameTypeMulti),
+		Settings: &bigspellav1.GameSettings{MaxPlayers: 4, WordLevel: 3, IsPrivate: true},
+	}
+
+	_, err := client.CreateGame(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	game, err := client.CreateGame(withToken("player"), req)
+	require.NoError(t, err)
+	assert.Equal(t, grpcPlayerID, games.hostID)
+	assert.Equal(t, 4, games.settings.MaxPlayers)
+	assert.Equal(t, "ABC123", game.InviteCode, "the host sees the invite code")
+
+	// Players can't act for someone else
+	req.PlayerId = "2d9e4b1a-7c3f-4e8a-a5b2-9f1d6c0e8b47"
+	_, err = client.CreateGame(withToken("player"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Delegating services act for the player they name
+	game, err = client.CreateGame(withToken("matcher"), req)
+	require.NoError(t, err)
+	assert.Equal(t, req.PlayerId, game.HostId)
+
+	// Other services can't
+	_, err = client.CreateGame(withToken("reporter"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	req.Settings.MaxPlayers = 64
+	_, err = client.CreateGame(withToken("player"), &bigspellav1.CreateGameRequest{Type: "arcade", Settings: req.Settings})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCGetGame(t *testing.T) {
+	client := dialGRPC(t, &grpcGames{})
+
+	_, err := client.GetGame(withToken("player"), &bigspellav1.GetGameRequest{GameId: "not-a-game"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetGame(withToken("player"), &bigspellav1.GetGameRequest{GameId: "a3c8e1f0-5b2d-4f7a-9e6c-1d4b8a2f7e90"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// The matchmaker's token lacks games:read
+	_, err = client.GetGame(withToken("matcher"), &bigspellav1.GetGameRequest{GameId: grpcGameID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestGRPCGameEvents(t *testing.T) {
+	events := make(chan GameEvent, 1)
+	client := dialGRPC(t, &grpcGames{events: events})
+
+	ctx, cancel := context.WithTimeout(withToken("player"), 5*time.Second)
+	defer cancel()
+	stream, err := client.GameEvents(ctx, &bigspellav1.GameEventsRequest{GameId: grpcGameID})
+	require.NoError(t, err)
+
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, first.GetSnapshot(), "a fresh subscription starts with a snapshot")
+	assert.Equal(t, grpcGameID, first.GetSnapshot().Id)
+
+	events <- GameEvent{Seq: 7, Type: EventTypeRoundStarted, GameID: grpcGameID, Payload: map[string]any{"round": 2}}
+	next, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, next.GetEvent())
+	assert.Equal(t, int64(7), next.GetEvent().Seq)
+	assert.Equal(t, float64(2), next.GetEvent().Payload.AsMap()["round"])
+
+	close(events)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err), "falling behind ends the stream")
+}
@@ -1,36 +1,94 @@
 package game
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 	
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
 
 	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game/stt"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
 )
 
 type Handler struct {
-	service  GameService
-	upgrader websocket.Upgrader
+	service        GameService
+	upgrader       websocket.Upgrader
+	presence       PresenceTracker
+	idempotency    func(http.Handler) http.Handler
+	authenticator  Authenticator
+	allowedOrigins []string
 }
 
-func NewHandler(service GameService) *Handler {
-	return &Handler{
+// PresenceTracker marks players online in a game while they hold its
+// WebSocket open. Track reports whether the user wasn't already connected
+// to the game, and untrack whether they no longer are.
+type PresenceTracker interface {
+	Track(ctx context.Context, userID, gameID string) (joined bool, untrack func() (left bool))
+}
+
+type HandlerOption func(*Handler)
+
+// WithPresence reports signed-in players as online while they are
+// connected, announcing each one's arrival in and departure from a game with
+// a presence_changed event
+func WithPresence(presence PresenceTracker) HandlerOption {
+	return func(h *Handler) {
+		h.presence = presence
+	}
+}
+
+// WithIdempotency passes the requests that create games, join them and
+// attempt words through middleware, which replays the response to a retried
+// request rather than serving it twice
+func WithIdempotency(middleware func(http.Handler) http.Handler) HandlerOption {
+	return func(h *Handler) {
+		h.idempotency = middleware
+	}
+}
+
+// WithAuthenticator lets clients that can't set an Authorization header,
+// such as browsers, sign in to the game WebSocket with a token in its URL or
+// in their first message
+func WithAuthenticator(authenticator Authenticator) HandlerOption {
+	return func(h *Handler) {
+		h.authenticator = authenticator
+	}
+}
+
+// WithAllowedOrigins lets pages served from origins open the game WebSocket,
+// besides those served by the API itself
+func WithAllowedOrigins(origins []string) HandlerOption {
+	return func(h *Handler) {
+		h.allowedOrigins = origins
+	}
+}
+
+func NewHandler(service GameService, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		service: service,
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
-			CheckOrigin: func(r *http.Request) bool {
-				return true // TODO: Add proper origin check
-			},
 		},
 	}
+	h.upgrader.CheckOrigin = h.checkOrigin
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 type CreateGameRequest struct {
-	Type     GameType     `json:"type"`
-	Settings GameSettings `json:"settings"`
+	Type      GameType            `json:"type"`
+	Settings  GameSettings        `json:"settings"`
+	Validator validator.Validator `json:"-"`
 }
 
 func (h *Handler) CreateGame(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -46,34 +104,191 @@ func (h *Handler) CreateGame(w http.ResponseWriter, r *http.Request, _ httproute
 		return
 	}
 
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
 	game, err := h.service.CreateGame(r.Context(), userID, req.Type, req.Settings)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, ErrInvalidRevealPolicy):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrUnknownCategory):
+			req.Validator.AddFieldError("settings.category", "Must be an existing category")
+			failedValidation(w, req.Validator)
+		case errors.Is(err, ErrUnknownWordList):
+			req.Validator.AddFieldError("settings.word_list", "Must be one of your word lists")
+			failedValidation(w, req.Validator)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	json.NewEncoder(w).Encode(game)
+	json.NewEncoder(w).Encode(viewFor(game, userID))
+}
+
+type JoinGameRequest struct {
+	InviteCode string `json:"invite_code"`
+	// InviteToken is the token from an invitation the player was sent,
+	// which stands in for the invite code
+	InviteToken string              `json:"invite_token"`
+	Validator   validator.Validator `json:"-"`
 }
 
 func (h *Handler) JoinGame(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req JoinGameRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	var game *Game
+	var err error
+	if req.InviteToken != "" {
+		game, err = h.service.JoinGameByInvitation(r.Context(), gameID, userID, req.InviteToken)
+	} else {
+		game, err = h.service.JoinGame(r.Context(), gameID, userID, req.InviteCode)
+	}
+	if err != nil {
+		joinFailed(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(game)
+}
+
+type JoinByCodeRequest struct {
+	InviteCode string              `json:"invite_code"`
+	Validator  validator.Validator `json:"-"`
+}
+
+func (h *Handler) JoinGameByCode(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	game, err := h.service.JoinGame(r.Context(), gameID, userID)
+	var req JoinByCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	game, err := h.service.JoinGameByCode(r.Context(), req.InviteCode, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		joinFailed(w, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(game)
 }
 
+func joinFailed(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrGameNotFound), errors.Is(err, ErrInvalidInviteCode):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrPlayerKicked), errors.Is(err, ErrInviteRequired), errors.Is(err, ErrInvalidInvitation):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrGameFull), errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrReadyCheckPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) LeaveGame(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.LeaveGame(r.Context(), gameID, userID); err != nil {
+		switch {
+		case errors.Is(err, ErrGameNotFound), errors.Is(err, ErrPlayerNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrInvalidGameState):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) KickPlayer(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	playerID := ps.ByName("playerID")
+	if !validPlayerID(w, playerID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.KickPlayer(r.Context(), gameID, userID, playerID); err != nil {
+		switch {
+		case errors.Is(err, ErrGameNotFound), errors.Is(err, ErrPlayerNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrNotHost):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrCannotKickHost), errors.Is(err, ErrInvalidGameState):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) StartGame(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
 	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -82,6 +297,11 @@ func (h *Handler) StartGame(w http.ResponseWriter, r *http.Request, ps httproute
 
 	game, err := h.service.StartGame(r.Context(), gameID, userID)
 	if err != nil {
+		if errors.Is(err, ErrGameChanged) || errors.Is(err, ErrTeamsUnfilled) || errors.Is(err, ErrReadyCheckPending) ||
+			errors.Is(err, ErrNotEnoughPlayers) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -90,15 +310,23 @@ func (h *Handler) StartGame(w http.ResponseWriter, r *http.Request, ps httproute
 }
 
 type MakeAttemptRequest struct {
-	Type      AttemptType `json:"type"`
-	Text      *string     `json:"text,omitempty"`
-	VoiceData []byte      `json:"voice_data,omitempty"`
+	Type      AttemptType         `json:"type"`
+	Text      *string             `json:"text,omitempty"`
+	VoiceData []byte              `json:"voice_data,omitempty"`
+	Validator validator.Validator `json:"-"`
+}
+
+// attempt is the spelling attempt a validated request makes
+func (r *MakeAttemptRequest) attempt() *SpellingAttempt {
+	if r.Type == AttemptTypeVoice {
+		return &SpellingAttempt{Type: AttemptTypeVoice, VoiceData: r.VoiceData}
+	}
+	return &SpellingAttempt{Type: AttemptTypeText, Text: *r.Text}
 }
 
 func (h *Handler) MakeAttempt(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	gameID := ps.ByName("gameID")
-	if gameID == "" {
-		http.Error(w, "Game ID is required", http.StatusBadRequest)
+	if !validGameID(w, gameID) {
 		return
 	}
 
@@ -114,85 +342,873 @@ func (h *Handler) MakeAttempt(w http.ResponseWriter, r *http.Request, ps httprou
 		return
 	}
 
-	var attempt *SpellingAttempt
-	switch req.Type {
-	case AttemptTypeText:
-		if req.Text == nil {
-			http.Error(w, "Text is required for text attempt", http.StatusBadRequest)
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+	if req.Type == AttemptTypeVoice && auth.IsChild(r.Context()) {
+		http.Error(w, errChildVoice.Error(), http.StatusForbidden)
+		return
+	}
+
+	attempt := req.attempt()
+	if err := h.service.MakeAttempt(r.Context(), gameID, userID, attempt); err != nil {
+		if errors.Is(err, stt.ErrOverloaded) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(stt.DefaultRetryAfter.Seconds())))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
 			return
 		}
-		attempt = &SpellingAttempt{
-			Type: AttemptTypeText,
-			Text: *req.Text,
+		if errors.Is(err, ErrAnswerTooLate) {
+			response.JSON(w, http.StatusConflict, map[string]string{"error": err.Error(), "code": errorCode(err)})
+			return
 		}
-	case AttemptTypeVoice:
-		if len(req.VoiceData) == 0 {
-			http.Error(w, "Voice data is required for voice attempt", http.StatusBadRequest)
+		if errors.Is(err, ErrReviewPending) || errors.Is(err, ErrNotPlayerTurn) || errors.Is(err, ErrGamePaused) ||
+			errors.Is(err, ErrAnswerWindowClosed) || errors.Is(err, ErrGameChanged) || errors.Is(err, ErrConfirmationPending) {
+			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
-		attempt = &SpellingAttempt{
-			Type:      AttemptTypeVoice,
-			VoiceData: req.VoiceData,
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if data := pendingAttempt(attempt); data != nil {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// pendingAttempt is what the player is told of an attempt that hasn't been
+// judged yet, or nil once it has. An attempt awaiting confirmation comes
+// with the transcription they're asked to confirm.
+func pendingAttempt(attempt *SpellingAttempt) map[string]any {
+	switch attempt.Status {
+	case AttemptStatusPendingReview:
+		return map[string]any{"attempt_id": attempt.ID, "status": attempt.Status}
+	case AttemptStatusPendingConfirmation:
+		return map[string]any{"attempt_id": attempt.ID, "status": attempt.Status, "transcription": attempt.Text, "confidence": attempt.Confidence}
+	}
+	return nil
+}
+
+type ConfirmationRequest struct {
+	Confirmed *bool               `json:"confirmed"`
+	Validator validator.Validator `json:"-"`
+}
+
+// ConfirmAttempt confirms or rejects how the signed-in player's voice
+// attempt was transcribed
+func (h *Handler) ConfirmAttempt(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ConfirmationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(ps.ByName("attemptID")); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	if err := h.service.ConfirmAttempt(r.Context(), gameID, userID, ps.ByName("attemptID"), *req.Confirmed); err != nil {
+		switch {
+		case errors.Is(err, ErrNoPendingConfirmation):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
-	default:
-		http.Error(w, "Invalid attempt type", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.MakeAttempt(r.Context(), gameID, userID, attempt); err != nil {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type RulingRequest struct {
+	Correct   *bool               `json:"correct"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (h *Handler) RuleOnAttempt(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req RulingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(ps.ByName("attemptID")); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	if err := h.service.RuleOnAttempt(r.Context(), gameID, ps.ByName("attemptID"), userID, *req.Correct); err != nil {
+		switch {
+		case errors.Is(err, ErrNotJudge):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrNoPendingReview):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type AppealRequest struct {
+	Reason    string              `json:"reason"`
+	Validator validator.Validator `json:"-"`
+}
+
+// FileAppeal disputes the signed-in player's attempt that was ruled
+// incorrect
+func (h *Handler) FileAppeal(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AppealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(ps.ByName("attemptID")); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	appeal, err := h.service.FileAppeal(r.Context(), gameID, ps.ByName("attemptID"), userID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrGameNotFound), errors.Is(err, ErrAttemptNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrNotAppealable):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrAppealsDisabled), errors.Is(err, ErrAppealWindowClosed), errors.Is(err, ErrAppealExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(appeal)
+}
+
+// ListAppeals lists appeals for moderators, pending ones unless the status
+// query parameter asks for others
+func (h *Handler) ListAppeals(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	status := AppealStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = AppealStatusPending
+	}
+
+	var v validator.Validator
+	if v.CheckField(validator.In(status, AppealStatusPending, AppealStatusUpheld, AppealStatusOverturned), "status", "Must be one of pending, upheld or overturned"); v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	appeals, err := h.service.ListAppeals(r.Context(), status)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"appeals": appeals})
+}
+
+type AppealDecisionRequest struct {
+	Overturn  *bool               `json:"overturn"`
+	Note      string              `json:"note"`
+	Validator validator.Validator `json:"-"`
+}
+
+// DecideAppeal records a moderator's decision on a pending appeal
+func (h *Handler) DecideAppeal(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req AppealDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(ps.ByName("appealID")); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	// Moderation tooling signs in with a service token; the decision is
+	// recorded against whoever the token speaks for
+	reviewer := ""
+	if principal := auth.GetPrincipal(r.Context()); principal != nil {
+		reviewer = principal.UserID
+		if principal.Service != "" {
+			reviewer = principal.Service
+		}
+	}
+
+	appeal, err := h.service.DecideAppeal(r.Context(), ps.ByName("appealID"), reviewer, *req.Overturn, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAppealNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrAppealDecided):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(appeal)
 }
 
 func (h *Handler) GetGame(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	gameID := ps.ByName("gameID")
-	if gameID == "" {
-		http.Error(w, "Game ID is required", http.StatusBadRequest)
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	game, err := h.service.GetGame(ReadOnly(r.Context()), gameID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(viewFor(game, userID))
+}
+
+// ListGamesRequest holds the lobby browser's query string
+type ListGamesRequest struct {
+	Status    string
+	Type      string
+	Mode      string
+	Level     int
+	Ranked    *bool
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func (h *Handler) ListGames(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := parseListGamesRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
 		return
 	}
 
+	games, total, err := h.service.ListGames(r.Context(), req.filter())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"games": games,
+		"page":  Page{Number: req.Page, Size: req.PageSize, Total: total},
+	})
+}
+
+// UpcomingGames lists the scheduled games the user can sign up for or has,
+// as JSON or, with format=ics, as a calendar to import
+func (h *Handler) UpcomingGames(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	game, err := h.service.GetGame(r.Context(), gameID)
+	var v validator.Validator
+	format := r.URL.Query().Get("format")
+	v.CheckField(validator.In(format, "", "json", "ics"), "format", "Must be json or ics")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	games, err := h.service.UpcomingGames(r.Context(), userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if format == "ics" {
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="big-spella-games.ics"`)
+		w.Write(Calendar(games, time.Now()))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"games": games})
+}
+
+type HintRequest struct {
+	Type      HintType            `json:"type"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (h *Handler) GetHint(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req HintRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	hint, err := h.service.GetHint(r.Context(), gameID, userID, req.Type)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidHintType):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrHintNotAllowed):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrHintAlreadyUsed), errors.Is(err, ErrMaxHintsUsed),
+			errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrNoWordSet):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, ErrNoHintAvailable):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(hint)
+}
+
+func (h *Handler) AdvanceRound(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.AdvanceRound(r.Context(), gameID, userID); err != nil {
+		switch {
+		case errors.Is(err, ErrNotHost):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrGamePaused):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) PauseGame(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.hostControl(w, r, ps, h.service.PauseGame)
+}
+
+func (h *Handler) ResumeGame(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.hostControl(w, r, ps, h.service.ResumeGame)
+}
+
+// hostControl runs a host-only game flow action and responds with the
+// updated game
+func (h *Handler) hostControl(w http.ResponseWriter, r *http.Request, ps httprouter.Params, action func(ctx context.Context, gameID string, userID string) (*Game, error)) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	game, err := action(r.Context(), gameID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrGameNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrNotHost):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrGameChanged), errors.Is(err, ErrReadyCheckPending):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	json.NewEncoder(w).Encode(game)
 }
 
+// StartReadyCheck has the lobby confirm it's ready, starting the game once
+// it has
+func (h *Handler) StartReadyCheck(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.hostControl(w, r, ps, h.service.StartReadyCheck)
+}
+
+// ConfirmReady confirms the player is ready during a ready check
+func (h *Handler) ConfirmReady(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.ConfirmReady(r.Context(), gameID, userID); err != nil {
+		switch {
+		case errors.Is(err, ErrPlayerNotFound):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrNoReadyCheck), errors.Is(err, ErrInvalidGameState):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReplayWord reads the word out again to the player whose turn it is
+func (h *Handler) ReplayWord(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	left, err := h.service.ReplayWord(r.Context(), gameID, userID)
+	if err != nil {
+		pronunciationFailed(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int{"replays_left": left})
+}
+
+// Pronunciation serves the current word's audio to the player whose turn it
+// is
+func (h *Handler) Pronunciation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	gameID := ps.ByName("gameID")
+	if !validGameID(w, gameID) {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	audio, err := h.service.PronunciationAudio(r.Context(), gameID, userID)
+	if err != nil {
+		pronunciationFailed(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(audio)
+}
+
+// AudioPreferences serves how the caller likes words read out
+func (h *Handler) AudioPreferences(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := h.service.AudioPreferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// AudioPreferencesRequest changes the preferences it names, leaving the
+// rest as they were
+type AudioPreferencesRequest struct {
+	Voice      *string             `json:"voice"`
+	Rate       *float64            `json:"rate"`
+	SlowRepeat *bool               `json:"slow_repeat"`
+	Validator  validator.Validator `json:"-"`
+}
+
+// UpdateAudioPreferences changes how the caller hears words from their next
+// turn on
+func (h *Handler) UpdateAudioPreferences(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AudioPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	prefs, err := h.service.AudioPreferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.apply(prefs)
+
+	if err := h.service.UpdateAudioPreferences(r.Context(), userID, *prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+func pronunciationFailed(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrGameNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotPlayerTurn):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrNoReplaysLeft):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrGamePaused), errors.Is(err, ErrTurnNotActive):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SubscribeToEvents streams game events over a WebSocket, starting with a
+// snapshot of the game. Players send their commands over it too: see
+// ClientMessage.
+//
+// Clients sign in with an Authorization header, the access_token query
+// parameter, or an auth message sent first thing after connecting. The
+// connection then acts as whoever signed in.
+//
+// A client reconnecting passes the seq of the last event it saw as
+// resume_from, and is sent the events it missed instead of the snapshot.
+// When those are no longer kept it gets the snapshot after all. A game that
+// is already over is sent as a snapshot and the connection closed.
 func (h *Handler) SubscribeToEvents(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !validGameID(w, ps.ByName("gameID")) {
+		return
+	}
+	gameID := ps.ByName("gameID")
+
+	var resumeFrom int64
+	if value := r.URL.Query().Get("resume_from"); value != "" {
+		var v validator.Validator
+		n, err := strconv.ParseInt(value, 10, 64)
+		if v.CheckField(err == nil && n >= 0, "resume_from", "Must be an event seq"); v.HasErrors() {
+			failedValidation(w, v)
+			return
+		}
+		resumeFrom = n
+	}
+
+	ctx := r.Context()
+	if token := r.URL.Query().Get(accessTokenParam); token != "" && auth.GetPrincipal(ctx) == nil {
+		var err error
+		if ctx, err = h.authenticate(ctx, token); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	if principal := auth.GetPrincipal(ctx); principal != nil && !principal.HasScope(auth.ScopeEventsRead) {
+		http.Error(w, auth.ErrInsufficientScope.Error(), http.StatusForbidden)
+		return
+	}
+	if auth.GetPrincipal(ctx) == nil && h.authenticator == nil {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		// The upgrader has already answered the request
 		return
 	}
 	defer conn.Close()
+	conn.SetReadLimit(maxAuthMessage)
+
+	socketsGauge.Inc()
+	defer socketsGauge.Dec()
 
-	for event := range h.service.Events() {
-		if err := conn.WriteJSON(event); err != nil {
-			break
+	ws := &socket{conn: conn}
+	if auth.GetPrincipal(ctx) == nil {
+		if ctx, err = h.awaitAuth(ctx, ws); err != nil {
+			ws.close(websocket.ClosePolicyViolation, err.Error())
+			return
+		}
+	}
+	conn.SetReadLimit(maxSocketMessage)
+	userID := auth.GetUserIDFromContext(ctx)
+	ws.child = auth.IsChild(ctx)
+
+	// Only games that are still going have events to follow
+	game, err := h.service.GetGame(ctx, gameID)
+	if err != nil {
+		ws.closeUnloaded(err)
+		return
+	}
+	if game.Status == GameStatusFinished || game.Status == GameStatusCancelled {
+		if ws.writeSnapshot("", game, userID) == nil {
+			ws.close(websocket.CloseNormalClosure, "the game is over")
+		}
+		return
+	}
+
+	sub := h.service.Subscribe(gameID, userID, resumeFrom)
+	defer sub.Cancel()
+	ws.seq = sub.Seq
+
+	// Presence is tracked while subscribed, so leaving is announced before
+	// an ended game's events are forgotten along with its last subscriber
+	if h.presence != nil && userID != "" {
+		joined, untrack := h.presence.Track(ctx, userID, gameID)
+		if joined {
+			h.service.PresenceChanged(gameID, userID, true)
+		}
+		defer func() {
+			if untrack() {
+				h.service.PresenceChanged(gameID, userID, false)
+			}
+		}()
+	}
+
+	if sub.Resumed {
+		for _, event := range sub.Missed {
+			if err := ws.writeEvent(event); err != nil {
+				return
+			}
+		}
+	} else {
+		// Loaded again now the subscription has started, so no event falls
+		// between the snapshot and the first one sent
+		game, err := h.service.GetGame(ctx, gameID)
+		if err != nil {
+			ws.closeUnloaded(err)
+			return
+		}
+		if ws.writeSnapshot("", game, userID) != nil {
+			return
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		h.readMessages(ctx, ws, gameID, userID)
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Fell behind; the client reconnects and resumes
+				ws.close(websocket.CloseTryAgainLater, "fell behind the game's events")
+				return
+			}
+			if err := ws.writeEvent(event); err != nil {
+				return
+			}
 		}
 	}
 }
 
 func (h *Handler) Routes() *httprouter.Router {
 	router := httprouter.New()
+	h.Register(router, func(next http.Handler) http.Handler { return next })
+	return router
+}
 
-	router.POST("/games", h.CreateGame)
-	router.POST("/games/:gameID/join", h.JoinGame)
-	router.POST("/games/:gameID/start", h.StartGame)
-	router.POST("/games/:gameID/attempt", h.MakeAttempt)
-	router.GET("/games/:gameID", h.GetGame)
-	router.GET("/games/:gameID/events", h.SubscribeToEvents)
+// Router is what Register mounts the game routes on, an httprouter.Router or
+// something wrapping one
+type Router interface {
+	Handler(method, path string, handler http.Handler)
+}
 
-	return router
+// Register mounts the game routes on router, passing each request through
+// middleware before the route's scope check
+func (h *Handler) Register(router Router, middleware func(http.Handler) http.Handler) {
+	handle := func(method, path string, scope auth.Scope, next httprouter.Handle) {
+		router.Handler(method, path, middleware(withParams(requireScope(scope, next))))
+	}
+
+	handle(http.MethodPost, "/games", auth.ScopeGamesWrite, h.idempotent(h.CreateGame))
+	handle(http.MethodGet, "/games", auth.ScopeGamesRead, h.ListGames)
+	handle(http.MethodPost, "/games/:gameID/join", auth.ScopeGamesWrite, h.idempotent(h.JoinGame))
+	// httprouter won't register a static segment alongside :gameID, so
+	// join-by-code is dispatched from the wildcard route
+	handle(http.MethodPost, "/games/:gameID", auth.ScopeGamesWrite, h.idempotent(h.gameAction))
+	handle(http.MethodPost, "/games/:gameID/leave", auth.ScopeGamesWrite, h.LeaveGame)
+	handle(http.MethodPost, "/games/:gameID/kick/:playerID", auth.ScopeGamesWrite, h.KickPlayer)
+	handle(http.MethodPost, "/games/:gameID/start", auth.ScopeGamesWrite, h.StartGame)
+	handle(http.MethodPost, "/games/:gameID/ready-check", auth.ScopeGamesWrite, h.StartReadyCheck)
+	handle(http.MethodPost, "/games/:gameID/ready", auth.ScopeGamesWrite, h.ConfirmReady)
+	handle(http.MethodPost, "/games/:gameID/attempt", auth.ScopeGamesWrite, h.idempotent(h.MakeAttempt))
+	handle(http.MethodPost, "/games/:gameID/hint", auth.ScopeGamesWrite, h.GetHint)
+	handle(http.MethodPost, "/games/:gameID/replay", auth.ScopeGamesWrite, h.ReplayWord)
+	handle(http.MethodGet, "/games/:gameID/pronunciation", auth.ScopeGamesRead, h.Pronunciation)
+	handle(http.MethodGet, "/audio/preferences", auth.ScopeGamesRead, h.AudioPreferences)
+	handle(http.MethodPut, "/audio/preferences", auth.ScopeGamesWrite, h.UpdateAudioPreferences)
+	handle(http.MethodPost, "/games/:gameID/advance", auth.ScopeGamesWrite, h.AdvanceRound)
+	handle(http.MethodPost, "/games/:gameID/pause", auth.ScopeGamesWrite, h.PauseGame)
+	handle(http.MethodPost, "/games/:gameID/resume", auth.ScopeGamesWrite, h.ResumeGame)
+	handle(http.MethodPost, "/games/:gameID/attempts/:attemptID/ruling", auth.ScopeGamesWrite, h.RuleOnAttempt)
+	handle(http.MethodPost, "/games/:gameID/attempts/:attemptID/confirmation", auth.ScopeGamesWrite, h.ConfirmAttempt)
+	handle(http.MethodPost, "/games/:gameID/attempts/:attemptID/appeal", auth.ScopeGamesWrite, h.FileAppeal)
+	handle(http.MethodGet, "/appeals", auth.ScopeAppealsModerate, h.ListAppeals)
+	handle(http.MethodPost, "/appeals/:appealID/decision", auth.ScopeAppealsModerate, h.DecideAppeal)
+	handle(http.MethodGet, "/games/:gameID", auth.ScopeGamesRead, h.gameView)
+	// The events socket checks its own scope, since browsers can only sign
+	// in to it once it's open
+	router.Handler(http.MethodGet, "/games/:gameID/events", middleware(withParams(h.SubscribeToEvents)))
+}
+
+// idempotent passes next through the idempotency middleware, if there is
+// one
+func (h *Handler) idempotent(next httprouter.Handle) httprouter.Handle {
+	if h.idempotency == nil {
+		return next
+	}
+	wrapped := h.idempotency(withParams(next))
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		wrapped.ServeHTTP(w, r)
+	}
+}
+
+// gameAction serves the POST /games/<action> routes that collide with
+// /games/:gameID
+func (h *Handler) gameAction(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	switch ps.ByName("gameID") {
+	case "join-by-code":
+		h.JoinGameByCode(w, r, ps)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// gameView dispatches the reads that share the game route, the same way
+// gameAction does
+func (h *Handler) gameView(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	switch ps.ByName("gameID") {
+	case "upcoming":
+		h.UpcomingGames(w, r, ps)
+	default:
+		h.GetGame(w, r, ps)
+	}
+}
+
+// withParams adapts next to a plain handler, reading the route parameters
+// router.Handler stores in the request context
+func withParams(next httprouter.Handle) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next(w, r, httprouter.ParamsFromContext(r.Context()))
+	})
+}
+
+// requireScope rejects requests whose token wasn't granted scope before they
+// reach next
+func requireScope(scope auth.Scope, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		principal := auth.GetPrincipal(r.Context())
+		if principal == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if !principal.HasScope(scope) {
+			http.Error(w, auth.ErrInsufficientScope.Error(), http.StatusForbidden)
+			return
+		}
+		next(w, r, ps)
+	}
 }
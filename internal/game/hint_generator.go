@@ -0,0 +1,210 @@
+package game
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MaskedWord replaces the target word in generated hints
+const MaskedWord = "_____"
+
+// HintGenerator writes hint text for words the dictionary has no content for
+type HintGenerator interface {
+	GenerateHint(ctx context.Context, word string, hintType HintType) (string, error)
+}
+
+type openAIHintGenerator struct {
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+func NewOpenAIHintGenerator(apiKey string, httpClient *http.Client) HintGenerator {
+	return &openAIHintGenerator{
+		apiKey:     apiKey,
+		model:      "gpt-4o-mini",
+		httpClient: httpClient,
+	}
+}
+
+var hintPrompts = map[HintType]string{
+	HintTypeExampleSentence: "Write one short example sentence, suitable for a spelling bee, that uses the word %q in its most common sense. Reply with the sentence only.",
+	HintTypeEtymology:       "Summarize the etymology of the word %q in one or two sentences for a spelling bee contestant. Do not spell out the word itself. Reply with the summary only.",
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (g *openAIHintGenerator) GenerateHint(ctx context.Context, word string, hintType HintType) (string, error) {
+	prompt, ok := hintPrompts[hintType]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidHintType, hintType)
+	}
+
+	reqBody := map[string]interface{}{
+		"model":       g.model,
+		"temperature": 0.3,
+		"max_tokens":  120,
+		"messages": []chatMessage{
+			{Role: "user", Content: fmt.Sprintf(prompt, word)},
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", strings.NewReader(string(jsonBody)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate hint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("failed to parse completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("completion returned no choices")
+	}
+
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
+
+// maskWord hides every occurrence of word in text, including inflected
+// forms such as plurals, so a hint never gives the spelling away
+func maskWord(text, word string) string {
+	if word == "" {
+		return text
+	}
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\w*`)
+	return re.ReplaceAllString(text, MaskedWord)
+}
+
+// hintFallbackService fills empty example sentence and etymology hints with
+// generated text. Generated hints are cached per word in memory and, when a
+// database is configured, in the generated_hints table.
+type hintFallbackService struct {
+	DictionaryService
+	generator HintGenerator
+	db        *sqlx.DB
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewHintFallbackService wraps dict so that example sentence and etymology
+// hints missing from the dictionary are generated instead of coming back
+// empty. db may be nil to cache in memory only.
+func NewHintFallbackService(dict DictionaryService, generator HintGenerator, db *sqlx.DB) DictionaryService {
+	return &hintFallbackService{
+		DictionaryService: dict,
+		generator:         generator,
+		db:                db,
+		cache:             make(map[string]string),
+	}
+}
+
+func hintCacheKey(word string, hintType HintType) string {
+	return strings.ToLower(word) + "|" + string(hintType)
+}
+
+func (s *hintFallbackService) GetHint(ctx context.Context, word *Word, hintType HintType) (string, error) {
+	content, err := s.DictionaryService.GetHint(ctx, word, hintType)
+	if err != nil || content != "" {
+		return content, err
+	}
+
+	hintType = normalizeHintType(hintType)
+	if _, ok := hintPrompts[hintType]; !ok {
+		return content, nil
+	}
+
+	// A generator outage shouldn't block the hint; returning nothing lets
+	// the engine move on to the next hint type
+	generated, err := s.generated(ctx, word.Word, hintType)
+	if err != nil {
+		return "", nil
+	}
+	return generated, nil
+}
+
+func (s *hintFallbackService) generated(ctx context.Context, word string, hintType HintType) (string, error) {
+	key := hintCacheKey(word, hintType)
+
+	s.mu.Lock()
+	content, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+
+	if s.db != nil {
+		err := s.db.GetContext(ctx, &content, `
+			SELECT content FROM generated_hints
+			WHERE word = $1 AND hint_type = $2`,
+			strings.ToLower(word), hintType)
+		switch {
+		case err == nil:
+			s.remember(key, content)
+			return content, nil
+		case !errors.Is(err, sql.ErrNoRows):
+			return "", fmt.Errorf("failed to get cached hint: %w", err)
+		}
+	}
+
+	content, err := s.generator.GenerateHint(ctx, word, hintType)
+	if err != nil {
+		return "", err
+	}
+	content = maskWord(content, word)
+
+	if s.db != nil {
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO generated_hints (word, hint_type, content)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (word, hint_type) DO NOTHING`,
+			strings.ToLower(word), hintType, content); err != nil {
+			return "", fmt.Errorf("failed to cache hint: %w", err)
+		}
+	}
+	s.remember(key, content)
+
+	return content, nil
+}
+
+func (s *hintFallbackService) remember(key, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[key] = content
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHintGenerator struct {
+	content string
+	err     error
+	calls   int
+}
+
+func (g *fakeHintGenerator) GenerateHint(ctx context.Context, word string, hintType HintType) (string, error) {
+	g.calls++
+	return g.content, g.err
+}
+
+func TestMaskWord(t *testing.T) {
+	assert.Equal(t, "The _____ flew over _____ nests.",
+		maskWord("The Heron flew over herons nests.", "heron"))
+	assert.Equal(t, "No match here.", maskWord("No match here.", "heron"))
+}
+
+func TestHintFallbackGeneratesAndCaches(t *testing.T) {
+	dict := new(MockDictionaryService)
+	word := &Word{Word: "heron", Definition: "A wading bird"}
+	dict.On("GetHint", mock.Anything, word, HintTypeExampleSentence).Return("", nil)
+	dict.On("GetHint", mock.Anything, word, HintTypeDefinition).Return("A wading bird", nil)
+
+	gen := &fakeHintGenerator{content: "A heron stood still in the reeds."}
+	svc := NewHintFallbackService(dict, gen, nil)
+
+	for i := 0; i < 2; i++ {
+		hint, err := svc.GetHint(context.Background(), word, HintTypeExampleSentence)
+		require.NoError(t, err)
+		assert.Equal(t, "A _____ stood still in the reeds.", hint)
+	}
+	assert.Equal(t, 1, gen.calls)
+
+	// Dictionary content is passed through and other types are never generated
+	hint, err := svc.GetHint(context.Background(), word, HintTypeDefinition)
+	require.NoError(t, err)
+	assert.Equal(t, "A wading bird", hint)
+	assert.Equal(t, 1, gen.calls)
+}
+
+func TestHintFallbackSwallowsGeneratorErrors(t *testing.T) {
+	dict := new(MockDictionaryService)
+	word := &Word{Word: "heron"}
+	dict.On("GetHint", mock.Anything, word, HintTypeEtymology).Return("", nil)
+
+	svc := NewHintFallbackService(dict, &fakeHintGenerator{err: errors.New("rate limited")}, nil)
+
+	hint, err := svc.GetHint(context.Background(), word, HintTypeEtymology)
+	require.NoError(t, err)
+	assert.Empty(t, hint)
+}
@@ -0,0 +1,194 @@
+package integrity
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// FlagKind names the heuristic that flagged a player
+type FlagKind string
+
+const (
+	// FlagAnswerLatency is a player whose answers came in faster, or more
+	// evenly spaced, than a person spells
+	FlagAnswerLatency FlagKind = "answer_latency"
+	// FlagImpossibleAccuracy is a player who spelled every word of a long
+	// game at a level hardly anyone gets through
+	FlagImpossibleAccuracy FlagKind = "impossible_accuracy"
+	// FlagPasteSpeed is a player whose typed answers came in faster than
+	// they could have been typed
+	FlagPasteSpeed FlagKind = "paste_speed"
+	// FlagSharedDevice is two players in the same game who have signed in
+	// on the same device
+	FlagSharedDevice FlagKind = "shared_device"
+)
+
+// FlagStatus is where a flag is in review
+type FlagStatus string
+
+const (
+	FlagPending   FlagStatus = "pending"
+	FlagCleared   FlagStatus = "cleared"
+	FlagConfirmed FlagStatus = "confirmed"
+)
+
+// FlagStatuses lists every status a flag can have
+var FlagStatuses = []FlagStatus{FlagPending, FlagCleared, FlagConfirmed}
+
+const (
+	// MinLatencySamples is how many timed answers a player needs before
+	// their latencies are judged
+	MinLatencySamples = 5
+	// MinHumanLatency is the fastest median answer time a person manages
+	MinHumanLatency = 1500 * time.Millisecond
+	// MinLatencyVariation is the least a person's answer times vary, as the
+	// standard deviation over the mean
+	MinLatencyVariation = 0.1
+
+	// HighWordLevel is the level from which perfect games are suspect, and
+	// MinPerfectWords how long a perfect game has to be to be flagged
+	HighWordLevel   = 8
+	MinPerfectWords = 10
+
+	// MaxTypingSpeed is the fastest, in characters a second, a person types
+	// an answer from when the word was given, counting words of at least
+	// MinPasteLength. MinPastes of them flag a player.
+	MaxTypingSpeed = 12.0
+	MinPasteLength = 6
+	MinPastes      = 2
+)
+
+// Flag is one reason to suspect a player cheated in a game. Ranked games
+// with pending flags don't count towards ratings until they are reviewed.
+type Flag struct {
+	ID         string     `json:"id" db:"id"`
+	GameID     string     `json:"game_id" db:"game_id"`
+	PlayerID   string     `json:"player_id" db:"player_id"`
+	Kind       FlagKind   `json:"kind" db:"kind"`
+	Detail     string     `json:"detail" db:"detail"`
+	Status     FlagStatus `json:"status" db:"status"`
+	ReviewedBy *string    `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	Note       *string    `json:"note,omitempty" db:"note"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SharedDevice is a device two players of a game have both signed in on
+type SharedDevice struct {
+	PlayerID      string `db:"player_id"`
+	OtherPlayerID string `db:"other_player_id"`
+}
+
+// Detect runs the cheat heuristics over a finished game's attempts. level
+// is the game's word level and shared the devices its players have in
+// common. Each player is flagged at most once per kind, in player order.
+func Detect(gameID string, level int, attempts []Attempt, shared []SharedDevice) []Flag {
+	byPlayer := make(map[string][]Attempt)
+	var players []string
+	for _, attempt := range attempts {
+		if _, ok := byPlayer[attempt.PlayerID]; !ok {
+			players = append(players, attempt.PlayerID)
+		}
+		byPlayer[attempt.PlayerID] = append(byPlayer[attempt.PlayerID], attempt)
+	}
+
+	flags := []Flag{}
+	flag := func(playerID string, kind FlagKind, detail string) {
+		flags = append(flags, Flag{GameID: gameID, PlayerID: playerID, Kind: kind, Detail: detail, Status: FlagPending})
+	}
+
+	for _, playerID := range players {
+		played := byPlayer[playerID]
+		if detail, ok := suspectLatency(played); ok {
+			flag(playerID, FlagAnswerLatency, detail)
+		}
+		if detail, ok := suspectAccuracy(level, played); ok {
+			flag(playerID, FlagImpossibleAccuracy, detail)
+		}
+		if detail, ok := suspectPasting(played); ok {
+			flag(playerID, FlagPasteSpeed, detail)
+		}
+	}
+
+	sharing := make(map[string]bool)
+	for _, device := range shared {
+		if !sharing[device.PlayerID] {
+			sharing[device.PlayerID] = true
+			flag(device.PlayerID, FlagSharedDevice, fmt.Sprintf("shares a device with player %s", device.OtherPlayerID))
+		}
+	}
+	return flags
+}
+
+// suspectLatency checks the times a player took over their correct answers
+// for a median no person could manage, or a spread too even to be human
+func suspectLatency(attempts []Attempt) (string, bool) {
+	var latencies []float64
+	for _, attempt := range attempts {
+		if attempt.IsCorrect && attempt.AnswerMS != nil {
+			latencies = append(latencies, float64(*attempt.AnswerMS))
+		}
+	}
+	if len(latencies) < MinLatencySamples {
+		return "", false
+	}
+
+	sort.Float64s(latencies)
+	median := latencies[len(latencies)/2]
+	if len(latencies)%2 == 0 {
+		median = (latencies[len(latencies)/2-1] + median) / 2
+	}
+	if time.Duration(median)*time.Millisecond < MinHumanLatency {
+		return fmt.Sprintf("median answer time of %dms over %d answers", int(median), len(latencies)), true
+	}
+
+	var mean, variance float64
+	for _, latency := range latencies {
+		mean += latency
+	}
+	mean /= float64(len(latencies))
+	for _, latency := range latencies {
+		variance += (latency - mean) * (latency - mean)
+	}
+	variation := math.Sqrt(variance/float64(len(latencies))) / mean
+	if variation < MinLatencyVariation {
+		return fmt.Sprintf("answer times varied by only %.0f%% over %d answers", variation*100, len(latencies)), true
+	}
+	return "", false
+}
+
+// suspectAccuracy checks for a perfect run through a long game at a high
+// level
+func suspectAccuracy(level int, attempts []Attempt) (string, bool) {
+	if level < HighWordLevel || len(attempts) < MinPerfectWords {
+		return "", false
+	}
+	if slices.ContainsFunc(attempts, func(attempt Attempt) bool { return !attempt.IsCorrect }) {
+		return "", false
+	}
+	return fmt.Sprintf("spelled all %d words correctly at level %d", len(attempts), level), true
+}
+
+// suspectPasting counts the long words a player typed out faster than
+// MaxTypingSpeed
+func suspectPasting(attempts []Attempt) (string, bool) {
+	pastes := 0
+	for _, attempt := range attempts {
+		length := utf8.RuneCountInString(attempt.Word)
+		if attempt.Type != "text" || attempt.AnswerMS == nil || length < MinPasteLength {
+			continue
+		}
+		seconds := max(float64(*attempt.AnswerMS)/1000, 0.001)
+		if float64(length)/seconds > MaxTypingSpeed {
+			pastes++
+		}
+	}
+	if pastes < MinPastes {
+		return "", false
+	}
+	return fmt.Sprintf("typed %d answers faster than %.0f characters a second", pastes, MaxTypingSpeed), true
+}
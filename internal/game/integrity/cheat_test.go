@@ -0,0 +1,79 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	ms := func(n int64) *int64 { return &n }
+
+	var attempts []Attempt
+	// p1 answers every word in 400ms, p2 types long words in a quarter of
+	// a second, p3 spells at a human pace with a miss
+	for i, latency := range []int64{2100, 3400, 2800, 5200, 2600, 4100} {
+		attempts = append(attempts,
+			Attempt{PlayerID: "p1", Word: "heron", Type: "voice", IsCorrect: true, AnswerMS: ms(400)},
+			Attempt{PlayerID: "p2", Word: "cormorant", Type: "text", IsCorrect: true, AnswerMS: ms(250)},
+			Attempt{PlayerID: "p3", Word: "kingfisher", Type: "text", IsCorrect: i != 2, AnswerMS: ms(latency)},
+		)
+	}
+
+	flags := Detect("g1", 3, attempts, []SharedDevice{
+		{PlayerID: "p3", OtherPlayerID: "p4"},
+		{PlayerID: "p3", OtherPlayerID: "p5"},
+	})
+
+	kinds := make(map[string][]FlagKind)
+	for _, flag := range flags {
+		assert.Equal(t, "g1", flag.GameID)
+		assert.Equal(t, FlagPending, flag.Status)
+		kinds[flag.PlayerID] = append(kinds[flag.PlayerID], flag.Kind)
+	}
+	assert.Equal(t, map[string][]FlagKind{
+		"p1": {FlagAnswerLatency},
+		"p2": {FlagAnswerLatency, FlagPasteSpeed},
+		"p3": {FlagSharedDevice},
+	}, kinds, "players sharing several devices are flagged once")
+}
+
+func TestSuspectLatency(t *testing.T) {
+	ms := func(n int64) *int64 { return &n }
+	answers := func(latencies ...int64) []Attempt {
+		var attempts []Attempt
+		for _, latency := range latencies {
+			attempts = append(attempts, Attempt{IsCorrect: true, AnswerMS: ms(latency)})
+		}
+		return attempts
+	}
+
+	_, ok := suspectLatency(answers(300, 300, 300, 300))
+	assert.False(t, ok, "too few answers to judge")
+
+	_, ok = suspectLatency(answers(3000, 3050, 2980, 3020, 3010))
+	assert.True(t, ok, "answers too evenly spaced to be human")
+
+	_, ok = suspectLatency(answers(2100, 3400, 2800, 5200, 2600))
+	assert.False(t, ok)
+}
+
+func TestSuspectAccuracy(t *testing.T) {
+	perfect := make([]Attempt, MinPerfectWords)
+	for i := range perfect {
+		perfect[i].IsCorrect = true
+	}
+
+	_, ok := suspectAccuracy(HighWordLevel, perfect)
+	assert.True(t, ok)
+
+	_, ok = suspectAccuracy(HighWordLevel-1, perfect)
+	assert.False(t, ok, "perfect games at lower levels are expected")
+
+	_, ok = suspectAccuracy(HighWordLevel, perfect[1:])
+	assert.False(t, ok, "short games can be perfect")
+
+	perfect[3].IsCorrect = false
+	_, ok = suspectAccuracy(HighWordLevel, perfect)
+	assert.False(t, ok)
+}
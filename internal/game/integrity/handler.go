@@ -0,0 +1,93 @@
+package integrity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GenerateReport compiles and stores the report for a finished tournament
+func (h *Handler) GenerateReport(w http.ResponseWriter, r *http.Request) {
+	tournamentID := httprouter.ParamsFromContext(r.Context()).ByName("tournamentID")
+	if !validTournamentID(w, tournamentID) {
+		return
+	}
+
+	report, err := h.service.Generate(r.Context(), tournamentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTournamentNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrTournamentInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetReport serves the stored report, as a downloadable Markdown document
+// when ?format=markdown is given
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	tournamentID := httprouter.ParamsFromContext(r.Context()).ByName("tournamentID")
+	if !validTournamentID(w, tournamentID) {
+		return
+	}
+
+	report, err := h.service.Get(r.Context(), tournamentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrReportNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if r.URL.Query().Get("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="integrity-report-%s.md"`, tournamentID))
+		w.Write(report.Markdown())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// validTournamentID checks the tournament ID path parameter and responds
+// with 422 when it isn't a UUID
+func validTournamentID(w http.ResponseWriter, tournamentID string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(tournamentID)
+	v.CheckField(err == nil, "tournament_id", "Must be a valid tournament ID")
+
+	if v.HasErrors() {
+		if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
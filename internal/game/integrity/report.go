@@ -0,0 +1,300 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinAnswerTime is the fastest a correct answer can plausibly follow the
+// previous attempt in the same match
+const MinAnswerTime = 2 * time.Second
+
+// Tournament is the part of a tournament record the report covers
+type Tournament struct {
+	ID        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Status    string     `json:"status" db:"status"`
+	StartTime time.Time  `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty" db:"end_time"`
+}
+
+// Match is one game played as part of the tournament
+type Match struct {
+	ID     string `json:"id" db:"id"`
+	GameID string `json:"game_id" db:"game_id"`
+	Round  int    `json:"round" db:"round"`
+	Number int    `json:"match_number" db:"match_number"`
+	Status string `json:"status" db:"status"`
+}
+
+// Attempt is a spelling attempt made during a tournament match
+type Attempt struct {
+	ID         string    `db:"id"`
+	GameID     string    `db:"game_id"`
+	PlayerID   string    `db:"player_id"`
+	Word       string    `db:"word"`
+	Type       string    `db:"type"`
+	IsCorrect  bool      `db:"is_correct"`
+	Timestamp  time.Time `db:"timestamp"`
+	Ruling     *string   `db:"ruling"`
+	Confidence *float64  `db:"confidence"`
+	JudgeID    *string   `db:"judge_id"`
+	AnswerMS   *int64    `db:"answer_ms"`
+}
+
+// Participant is a player's standing in one match
+type Participant struct {
+	GameID   string `db:"game_id"`
+	PlayerID string `db:"player_id"`
+	Status   string `db:"status"`
+}
+
+// Report is the integrity report compiled for organizers once a tournament
+// is over
+type Report struct {
+	Tournament        Tournament      `json:"tournament"`
+	GeneratedAt       time.Time       `json:"generated_at"`
+	WordLists         []MatchWords    `json:"word_lists"`
+	RepeatedWords     []RepeatedWord  `json:"repeated_words"`
+	TimingAnomalies   []TimingAnomaly `json:"timing_anomalies"`
+	JudgeRulings      []JudgeRuling   `json:"judge_rulings"`
+	Departures        []Departure     `json:"departures"`
+	DepartureCounts   map[string]int  `json:"departure_counts"`
+	UnfinishedMatches []Match         `json:"unfinished_matches"`
+}
+
+// MatchWords lists the words served in a match in order, with a digest
+// organizers can compare against the word list they prepared
+type MatchWords struct {
+	MatchID string   `json:"match_id"`
+	GameID  string   `json:"game_id"`
+	Round   int      `json:"round"`
+	Words   []string `json:"words"`
+	Digest  string   `json:"digest"`
+}
+
+// RepeatedWord is a word served in more than one match, which lets players
+// in later matches see it coming
+type RepeatedWord struct {
+	Word    string   `json:"word"`
+	GameIDs []string `json:"game_ids"`
+}
+
+// TimingAnomaly is a correct answer that came in faster than MinAnswerTime
+type TimingAnomaly struct {
+	AttemptID string        `json:"attempt_id"`
+	GameID    string        `json:"game_id"`
+	PlayerID  string        `json:"player_id"`
+	Word      string        `json:"word"`
+	Gap       time.Duration `json:"gap"`
+}
+
+// JudgeRuling is an attempt a judge, or the fallback in their absence,
+// ruled on instead of the automatic check, or whose ruling was overturned
+// on appeal
+type JudgeRuling struct {
+	AttemptID  string   `json:"attempt_id"`
+	GameID     string   `json:"game_id"`
+	PlayerID   string   `json:"player_id"`
+	Word       string   `json:"word"`
+	Ruling     string   `json:"ruling"`
+	Correct    bool     `json:"correct"`
+	Confidence *float64 `json:"confidence,omitempty"`
+	JudgeID    *string  `json:"judge_id,omitempty"`
+}
+
+// Departure is a player who left a match before it finished, by
+// disconnecting, forfeiting or being removed
+type Departure struct {
+	GameID   string `json:"game_id"`
+	PlayerID string `json:"player_id"`
+	Status   string `json:"status"`
+}
+
+// Build compiles the report from a tournament's matches and what happened
+// in them
+func Build(tournament Tournament, matches []Match, attempts []Attempt, participants []Participant, now time.Time) *Report {
+	report := &Report{
+		Tournament:        tournament,
+		GeneratedAt:       now,
+		WordLists:         []MatchWords{},
+		RepeatedWords:     []RepeatedWord{},
+		TimingAnomalies:   []TimingAnomaly{},
+		JudgeRulings:      []JudgeRuling{},
+		Departures:        []Departure{},
+		DepartureCounts:   map[string]int{},
+		UnfinishedMatches: []Match{},
+	}
+
+	byGame := make(map[string][]Attempt)
+	for _, attempt := range attempts {
+		byGame[attempt.GameID] = append(byGame[attempt.GameID], attempt)
+	}
+	for _, gameAttempts := range byGame {
+		sort.SliceStable(gameAttempts, func(i, j int) bool {
+			return gameAttempts[i].Timestamp.Before(gameAttempts[j].Timestamp)
+		})
+	}
+
+	servedIn := make(map[string][]string)
+	for _, match := range matches {
+		words := servedWords(byGame[match.GameID])
+		report.WordLists = append(report.WordLists, MatchWords{
+			MatchID: match.ID,
+			GameID:  match.GameID,
+			Round:   match.Round,
+			Words:   words,
+			Digest:  digest(words),
+		})
+		for _, word := range words {
+			servedIn[word] = append(servedIn[word], match.GameID)
+		}
+
+		if match.Status != "completed" {
+			report.UnfinishedMatches = append(report.UnfinishedMatches, match)
+		}
+
+		report.TimingAnomalies = append(report.TimingAnomalies, timingAnomalies(byGame[match.GameID])...)
+	}
+
+	for word, gameIDs := range servedIn {
+		if len(gameIDs) > 1 {
+			report.RepeatedWords = append(report.RepeatedWords, RepeatedWord{Word: word, GameIDs: gameIDs})
+		}
+	}
+	sort.Slice(report.RepeatedWords, func(i, j int) bool {
+		return report.RepeatedWords[i].Word < report.RepeatedWords[j].Word
+	})
+
+	for _, attempt := range attempts {
+		if attempt.Ruling == nil || (*attempt.Ruling != "judge" && *attempt.Ruling != "fallback" && *attempt.Ruling != "appeal") {
+			continue
+		}
+		report.JudgeRulings = append(report.JudgeRulings, JudgeRuling{
+			AttemptID:  attempt.ID,
+			GameID:     attempt.GameID,
+			PlayerID:   attempt.PlayerID,
+			Word:       attempt.Word,
+			Ruling:     *attempt.Ruling,
+			Correct:    attempt.IsCorrect,
+			Confidence: attempt.Confidence,
+			JudgeID:    attempt.JudgeID,
+		})
+	}
+
+	for _, participant := range participants {
+		if participant.Status == "active" {
+			continue
+		}
+		report.Departures = append(report.Departures, Departure(participant))
+		report.DepartureCounts[participant.Status]++
+	}
+
+	return report
+}
+
+// servedWords lists the distinct words attempted in a match in the order
+// they were first attempted
+func servedWords(attempts []Attempt) []string {
+	words := []string{}
+	seen := make(map[string]bool)
+	for _, attempt := range attempts {
+		word := strings.ToLower(attempt.Word)
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func digest(words []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(words, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// timingAnomalies flags correct answers that followed the previous attempt
+// in the match too quickly to have been spelled out
+func timingAnomalies(attempts []Attempt) []TimingAnomaly {
+	var anomalies []TimingAnomaly
+	for i := 1; i < len(attempts); i++ {
+		attempt := attempts[i]
+		gap := attempt.Timestamp.Sub(attempts[i-1].Timestamp)
+		if attempt.IsCorrect && gap < MinAnswerTime {
+			anomalies = append(anomalies, TimingAnomaly{
+				AttemptID: attempt.ID,
+				GameID:    attempt.GameID,
+				PlayerID:  attempt.PlayerID,
+				Word:      attempt.Word,
+				Gap:       gap,
+			})
+		}
+	}
+	return anomalies
+}
+
+// Markdown renders the report as a document organizers can download and file
+func (r *Report) Markdown() []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Integrity report: %s\n\n", r.Tournament.Name)
+	fmt.Fprintf(&b, "- Tournament: %s\n", r.Tournament.ID)
+	fmt.Fprintf(&b, "- Started: %s\n", r.Tournament.StartTime.Format(time.RFC3339))
+	if r.Tournament.EndTime != nil {
+		fmt.Fprintf(&b, "- Ended: %s\n", r.Tournament.EndTime.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "- Generated: %s\n", r.GeneratedAt.Format(time.RFC3339))
+
+	b.WriteString("\n## Word lists\n\n")
+	for _, list := range r.WordLists {
+		fmt.Fprintf(&b, "- Round %d, game %s: %d words, digest `%s`\n", list.Round, list.GameID, len(list.Words), list.Digest)
+	}
+	if len(r.RepeatedWords) == 0 {
+		b.WriteString("\nNo word was served in more than one match.\n")
+	}
+	for _, repeated := range r.RepeatedWords {
+		fmt.Fprintf(&b, "- Repeated: %q in games %s\n", repeated.Word, strings.Join(repeated.GameIDs, ", "))
+	}
+
+	b.WriteString("\n## Attempt timing\n\n")
+	if len(r.TimingAnomalies) == 0 {
+		fmt.Fprintf(&b, "No correct answer came in under %s.\n", MinAnswerTime)
+	}
+	for _, anomaly := range r.TimingAnomalies {
+		fmt.Fprintf(&b, "- Player %s spelled %q correctly %s after the previous attempt (game %s)\n", anomaly.PlayerID, anomaly.Word, anomaly.Gap, anomaly.GameID)
+	}
+
+	b.WriteString("\n## Judge rulings\n\n")
+	if len(r.JudgeRulings) == 0 {
+		b.WriteString("Every attempt was ruled on automatically.\n")
+	}
+	for _, ruling := range r.JudgeRulings {
+		verdict := "incorrect"
+		if ruling.Correct {
+			verdict = "correct"
+		}
+		fmt.Fprintf(&b, "- %s ruling on player %s's %q: %s (game %s)\n", ruling.Ruling, ruling.PlayerID, ruling.Word, verdict, ruling.GameID)
+	}
+
+	b.WriteString("\n## Disconnects and forfeits\n\n")
+	if len(r.Departures) == 0 {
+		b.WriteString("Every player finished their matches.\n")
+	}
+	statuses := make([]string, 0, len(r.DepartureCounts))
+	for status := range r.DepartureCounts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(&b, "- %s: %d\n", status, r.DepartureCounts[status])
+	}
+	for _, match := range r.UnfinishedMatches {
+		fmt.Fprintf(&b, "- Match %d of round %d ended %s\n", match.Number, match.Round, match.Status)
+	}
+
+	return []byte(b.String())
+}
@@ -0,0 +1,48 @@
+package integrity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	judge, fallback := "judge", "fallback"
+
+	matches := []Match{
+		{ID: "m1", GameID: "g1", Round: 1, Number: 1, Status: "completed"},
+		{ID: "m2", GameID: "g2", Round: 1, Number: 2, Status: "forfeited"},
+	}
+	attempts := []Attempt{
+		{ID: "a1", GameID: "g1", PlayerID: "p1", Word: "heron", Timestamp: start},
+		{ID: "a2", GameID: "g1", PlayerID: "p2", Word: "heron", IsCorrect: true, Timestamp: start.Add(time.Second)},
+		{ID: "a3", GameID: "g1", PlayerID: "p1", Word: "egret", IsCorrect: true, Timestamp: start.Add(20 * time.Second), Ruling: &judge},
+		{ID: "a4", GameID: "g2", PlayerID: "p3", Word: "Heron", Timestamp: start, Ruling: &fallback},
+	}
+	participants := []Participant{
+		{GameID: "g1", PlayerID: "p1", Status: "active"},
+		{GameID: "g2", PlayerID: "p4", Status: "disconnected"},
+		{GameID: "g2", PlayerID: "p5", Status: "forfeited"},
+	}
+
+	report := Build(Tournament{ID: "t1", Name: "Spring Bee", StartTime: start}, matches, attempts, participants, start)
+
+	assert.Equal(t, []string{"heron", "egret"}, report.WordLists[0].Words)
+	assert.Equal(t, digest([]string{"heron", "egret"}), report.WordLists[0].Digest)
+	assert.Equal(t, []RepeatedWord{{Word: "heron", GameIDs: []string{"g1", "g2"}}}, report.RepeatedWords)
+
+	assert.Len(t, report.TimingAnomalies, 1)
+	assert.Equal(t, "a2", report.TimingAnomalies[0].AttemptID)
+	assert.Equal(t, time.Second, report.TimingAnomalies[0].Gap)
+
+	assert.Len(t, report.JudgeRulings, 2)
+	assert.Equal(t, map[string]int{"disconnected": 1, "forfeited": 1}, report.DepartureCounts)
+	assert.Equal(t, []Match{matches[1]}, report.UnfinishedMatches)
+
+	doc := string(report.Markdown())
+	assert.Contains(t, doc, "# Integrity report: Spring Bee")
+	assert.Contains(t, doc, `Repeated: "heron"`)
+	assert.Contains(t, doc, "- disconnected: 1")
+}
@@ -0,0 +1,181 @@
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTournamentNotFound   = errors.New("tournament not found")
+	ErrTournamentInProgress = errors.New("tournament has not ended")
+	ErrReportNotFound       = errors.New("no integrity report has been generated for this tournament")
+	ErrGameNotFound         = errors.New("game not found")
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+// Generate compiles the integrity report for a finished tournament and
+// stores it with the tournament, replacing any earlier one
+func (s *Service) Generate(ctx context.Context, tournamentID string) (*Report, error) {
+	var tournament Tournament
+	if err := s.db.GetContext(ctx, &tournament, `
+		SELECT id, name, status, start_time, end_time
+		FROM tournaments
+		WHERE id = $1`, tournamentID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTournamentNotFound
+		}
+		return nil, fmt.Errorf("failed to get tournament: %w", err)
+	}
+
+	if tournament.EndTime == nil {
+		return nil, ErrTournamentInProgress
+	}
+
+	var matches []Match
+	if err := s.db.SelectContext(ctx, &matches, `
+		SELECT id, game_id, round, match_number, status
+		FROM tournament_matches
+		WHERE tournament_id = $1
+		ORDER BY round, match_number`, tournamentID); err != nil {
+		return nil, fmt.Errorf("failed to get matches: %w", err)
+	}
+
+	var attempts []Attempt
+	if err := s.db.SelectContext(ctx, &attempts, `
+		SELECT a.id, a.game_id, a.player_id, a.word, a.type, a.is_correct,
+			a.timestamp, a.ruling, a.confidence, a.judge_id, a.answer_ms
+		FROM spelling_attempts a
+		JOIN tournament_matches m ON m.game_id = a.game_id
+		WHERE m.tournament_id = $1
+		ORDER BY a.timestamp`, tournamentID); err != nil {
+		return nil, fmt.Errorf("failed to get attempts: %w", err)
+	}
+
+	var participants []Participant
+	if err := s.db.SelectContext(ctx, &participants, `
+		SELECT p.game_id, p.player_id, p.status
+		FROM players p
+		JOIN tournament_matches m ON m.game_id = p.game_id
+		WHERE m.tournament_id = $1
+		ORDER BY p.joined_at`, tournamentID); err != nil {
+		return nil, fmt.Errorf("failed to get participants: %w", err)
+	}
+
+	report := Build(tournament, matches, attempts, participants, time.Now())
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO tournament_integrity_reports (tournament_id, report, generated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tournament_id) DO UPDATE
+		SET report = EXCLUDED.report, generated_at = EXCLUDED.generated_at`,
+		tournamentID, data, report.GeneratedAt); err != nil {
+		return nil, fmt.Errorf("failed to store report: %w", err)
+	}
+
+	return report, nil
+}
+
+// Get returns the report stored for a tournament
+func (s *Service) Get(ctx context.Context, tournamentID string) (*Report, error) {
+	var data []byte
+	if err := s.db.GetContext(ctx, &data, `
+		SELECT report
+		FROM tournament_integrity_reports
+		WHERE tournament_id = $1`, tournamentID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrReportNotFound
+		}
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %w", err)
+	}
+
+	return &report, nil
+}
+
+// Screen runs the cheat heuristics over a finished game and stores what
+// they flag, reporting whether anyone in the game has a flag pending review.
+// Screening a game again only adds flags it didn't have.
+func (s *Service) Screen(ctx context.Context, gameID string) (bool, error) {
+	var level int
+	if err := s.db.GetContext(ctx, &level, `
+		SELECT COALESCE((settings->>'word_level')::int, 0)
+		FROM games
+		WHERE id = $1`, gameID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrGameNotFound
+		}
+		return false, fmt.Errorf("failed to get game: %w", err)
+	}
+
+	var attempts []Attempt
+	if err := s.db.SelectContext(ctx, &attempts, `
+		SELECT id, game_id, player_id, word, type, is_correct,
+			timestamp, ruling, confidence, judge_id, answer_ms
+		FROM spelling_attempts
+		WHERE game_id = $1
+		ORDER BY timestamp`, gameID); err != nil {
+		return false, fmt.Errorf("failed to get attempts: %w", err)
+	}
+
+	var shared []SharedDevice
+	if err := s.db.SelectContext(ctx, &shared, `
+		SELECT DISTINCT a.user_id AS player_id, b.user_id AS other_player_id
+		FROM players p
+		JOIN players q ON q.game_id = p.game_id AND q.player_id <> p.player_id
+		JOIN device_accounts a ON a.user_id = p.player_id
+		JOIN device_accounts b ON b.token = a.token AND b.user_id = q.player_id
+		WHERE p.game_id = $1`, gameID); err != nil {
+		return false, fmt.Errorf("failed to get shared devices: %w", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, flag := range Detect(gameID, level, attempts, shared) {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO game_flags (id, game_id, player_id, kind, detail, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (game_id, player_id, kind) DO NOTHING`,
+			uuid.New().String(), flag.GameID, flag.PlayerID, flag.Kind, flag.Detail, flag.Status); err != nil {
+			return false, fmt.Errorf("failed to store flag: %w", err)
+		}
+	}
+
+	var pending bool
+	if err := tx.GetContext(ctx, &pending, `
+		SELECT EXISTS (SELECT 1 FROM game_flags WHERE game_id = $1 AND status = $2)`,
+		gameID, FlagPending); err != nil {
+		return false, fmt.Errorf("failed to check flags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit flags: %w", err)
+	}
+	return pending, nil
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidInvitation = errors.New("invitation is invalid, has expired or was sent to someone else")
+
+// NewInvitationToken returns a random token for an invitation to a game and
+// the hash it's stored as
+func NewInvitationToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashInvitationToken(token), nil
+}
+
+func HashInvitationToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// JoinGameByInvitation joins playerID to gameID on the invitation token they
+// were sent, which lets them into a private game without its invite code.
+// Each invitation is accepted once.
+func (s *gameService) JoinGameByInvitation(ctx context.Context, gameID, playerID, token string) (*Game, error) {
+	var invitationID string
+	if err := s.db.GetContext(ctx, &invitationID, `
+		SELECT id FROM game_invitations
+		WHERE token_hash = $1 AND game_id = $2 AND invitee_id = $3
+			AND accepted_at IS NULL AND expires_at > NOW()`,
+		HashInvitationToken(token), gameID, playerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	var inviteCode string
+	if game.InviteCode != nil {
+		inviteCode = *game.InviteCode
+	}
+
+	joined, err := s.JoinGame(ctx, gameID, playerID, inviteCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE game_invitations SET accepted_at = NOW() WHERE id = $1`, invitationID); err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return joined, nil
+}
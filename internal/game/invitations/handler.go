@@ -0,0 +1,100 @@
+package invitations
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type InviteRequest struct {
+	// Invitees are the usernames or emails of the players to invite
+	Invitees  []string            `json:"invitees"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *InviteRequest) validate() {
+	r.Validator.CheckField(validator.Between(len(r.Invitees), 1, MaxInvitees), "invitees", "Must name between 1 and 20 players")
+	for _, invitee := range r.Invitees {
+		r.Validator.CheckField(validator.NotBlank(invitee), "invitees", "Must not be blank")
+		r.Validator.CheckField(validator.MaxRunes(invitee, 254), "invitees", "Must not be more than 254 characters each")
+	}
+}
+
+// Invite invites players to the caller's game by username or email
+func (h *Handler) Invite(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	gameID := httprouter.ParamsFromContext(r.Context()).ByName("gameID")
+	var v validator.Validator
+	_, err := uuid.Parse(gameID)
+	v.CheckField(err == nil, "game_id", "Must be a valid game ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	var req InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	invitations, err := h.service.Invite(r.Context(), gameID, userID, req.Invitees)
+	if err != nil {
+		if errors.Is(err, ErrUnknownInvitee) {
+			req.Validator.AddFieldError("invitees", err.Error())
+			failedValidation(w, req.Validator)
+			return
+		}
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"invitations": invitations})
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, game.ErrGameNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, game.ErrNotHost):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, game.ErrInvalidGameState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,38 @@
+package invitations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInviteRequestValidation(t *testing.T) {
+	req := InviteRequest{Invitees: []string{"ada", "bo@example.com"}}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = InviteRequest{}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "invitees", "someone has to be invited")
+
+	req = InviteRequest{Invitees: []string{"ada", " "}}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "invitees")
+
+	req = InviteRequest{Invitees: strings.Split(strings.Repeat("x,", MaxInvitees), ",")}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "invitees")
+}
+
+func TestUnmatched(t *testing.T) {
+	users := []invitee{{ID: "1", Username: "Ada", Email: "ada@example.com"}, {ID: "2", Username: "bo", Email: "bo@example.com"}}
+
+	assert.Empty(t, unmatched([]string{"ada", "bo@example.com"}, users), "usernames match whatever their case")
+	assert.Equal(t, []string{"cy"}, unmatched([]string{"ada", "cy", "cy"}, users))
+}
+
+func TestJoinLink(t *testing.T) {
+	s := NewService(nil, "https://bigspella.example/join", nil)
+	assert.Equal(t, "https://bigspella.example/join?game=g-1&token=a%2Bb", s.JoinLink("g-1", "a+b"))
+}
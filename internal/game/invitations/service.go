@@ -0,0 +1,213 @@
+// Package invitations lets a game's host invite players by username or
+// email. Invited players are sent a link that joins them to the game, even
+// a private one, without going through the lobby.
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/notifications"
+	"big-spella-go/internal/smtp"
+)
+
+const (
+	// Email is the template invitations are emailed with
+	Email = "game_invitation.tmpl"
+
+	// Expiry is how long an invitation can be accepted for
+	Expiry = 24 * time.Hour
+
+	// MaxInvitees is how many players can be invited at once
+	MaxInvitees = 20
+)
+
+var ErrUnknownInvitee = errors.New("no player matches")
+
+// Invitation is a host's invitation for a player to join their game
+type Invitation struct {
+	ID              string     `json:"id" db:"id"`
+	GameID          string     `json:"game_id" db:"game_id"`
+	HostID          string     `json:"host_id" db:"host_id"`
+	InviteeID       string     `json:"invitee_id" db:"invitee_id"`
+	InviteeUsername string     `json:"invitee_username" db:"invitee_username"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+type ServiceOption func(*Service)
+
+// WithNotifier sends invited players a push notification through notifier
+func WithNotifier(notifier notifications.Notifier) ServiceOption {
+	return func(s *Service) {
+		s.notifier = notifier
+	}
+}
+
+// WithEmail emails invited players with send
+func WithEmail(send smtp.SendEmail) ServiceOption {
+	return func(s *Service) {
+		s.send = send
+	}
+}
+
+type Service struct {
+	db       *sqlx.DB
+	joinURL  string
+	notifier notifications.Notifier
+	send     smtp.SendEmail
+	onError  func(error)
+}
+
+// NewService links invited players to joinURL, with the game and their
+// invitation's token added as the game and token parameters. Invitations
+// that were made but couldn't be emailed are reported to onError.
+func NewService(db *sqlx.DB, joinURL string, onError func(error), opts ...ServiceOption) *Service {
+	s := &Service{db: db, joinURL: joinURL, onError: onError}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type invitee struct {
+	ID       string `db:"id"`
+	Username string `db:"username"`
+	Email    string `db:"email"`
+}
+
+// Invite invites the players named in invitees, by username or email, to
+// the game hostID is hosting. Nobody is invited unless every name matches a
+// player; children's accounts and players who have blocked the host don't.
+func (s *Service) Invite(ctx context.Context, gameID, hostID string, invitees []string) ([]Invitation, error) {
+	var g struct {
+		HostID string          `db:"host_id"`
+		Status game.GameStatus `db:"status"`
+	}
+	if err := s.db.GetContext(ctx, &g, `SELECT host_id, status FROM games WHERE id = $1`, gameID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, game.ErrGameNotFound
+		}
+		return nil, fmt.Errorf("failed to get game: %w", err)
+	}
+	switch {
+	case g.HostID != hostID:
+		return nil, game.ErrNotHost
+	case g.Status != game.GameStatusInitializing && g.Status != game.GameStatusWaiting:
+		return nil, game.ErrInvalidGameState
+	}
+
+	names := make([]string, len(invitees))
+	for i, name := range invitees {
+		names[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	var users []invitee
+	if err := s.db.SelectContext(ctx, &users, `
+		SELECT id, username, email FROM users u
+		WHERE (LOWER(username) = ANY($1) OR LOWER(email) = ANY($1))
+			AND id <> $2 AND NOT is_child
+			AND NOT EXISTS (
+				SELECT 1 FROM friendships
+				WHERE user_id = u.id AND friend_id = $2 AND status = 'blocked')`,
+		pq.Array(names), hostID); err != nil {
+		return nil, fmt.Errorf("failed to find invitees: %w", err)
+	}
+	if missing := unmatched(names, users); len(missing) > 0 {
+		return nil, fmt.Errorf("%w %s", ErrUnknownInvitee, strings.Join(missing, ", "))
+	}
+
+	var host string
+	if err := s.db.GetContext(ctx, &host, `SELECT username FROM users WHERE id = $1`, hostID); err != nil {
+		return nil, fmt.Errorf("failed to get host: %w", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	invitations := make([]Invitation, len(users))
+	tokens := make([]string, len(users))
+	for i, user := range users {
+		token, hash, err := game.NewInvitationToken()
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = token
+		invitations[i] = Invitation{
+			ID:              uuid.New().String(),
+			GameID:          gameID,
+			HostID:          hostID,
+			InviteeID:       user.ID,
+			InviteeUsername: user.Username,
+			ExpiresAt:       now.Add(Expiry),
+			CreatedAt:       now,
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO game_invitations (id, game_id, host_id, invitee_id, token_hash, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			invitations[i].ID, gameID, hostID, user.ID, hash, invitations[i].ExpiresAt, now); err != nil {
+			return nil, fmt.Errorf("failed to create invitation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit invitations: %w", err)
+	}
+
+	for i, user := range users {
+		s.deliver(ctx, host, user, invitations[i], tokens[i])
+	}
+	return invitations, nil
+}
+
+// unmatched returns the names no user's username or email matches
+func unmatched(names []string, users []invitee) []string {
+	var missing []string
+	for _, name := range names {
+		if !slices.ContainsFunc(users, func(u invitee) bool {
+			return strings.ToLower(u.Username) == name || strings.ToLower(u.Email) == name
+		}) && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// JoinLink is the link that joins a player to gameID on their invitation's
+// token
+func (s *Service) JoinLink(gameID, token string) string {
+	return s.joinURL + "?" + url.Values{"game": {gameID}, "token": {token}}.Encode()
+}
+
+func (s *Service) deliver(ctx context.Context, host string, user invitee, invitation Invitation, token string) {
+	link := s.JoinLink(invitation.GameID, token)
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, user.ID, notifications.GameInvitation(host, invitation.GameID, link))
+	}
+	if s.send != nil {
+		if err := s.send(user.Email, map[string]any{
+			"Username":  user.Username,
+			"Host":      host,
+			"JoinURL":   link,
+			"ExpiresAt": invitation.ExpiresAt.UTC().Format("2 January 15:04 MST"),
+		}, Email); err != nil && s.onError != nil {
+			s.onError(fmt.Errorf("failed to email invitation %s: %w", invitation.ID, err))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// InviteCodeLength is the length of the codes handed out for private games
+const InviteCodeLength = 8
+
+// inviteCodeAlphabet leaves out characters that are easy to misread when a
+// code is read aloud or copied by hand
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var (
+	ErrInviteRequired    = errors.New("a valid invite code is required to join this game")
+	ErrInvalidInviteCode = errors.New("no game matches that invite code")
+)
+
+func newInviteCode() (string, error) {
+	code := make([]byte, InviteCodeLength)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate invite code: %w", err)
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// canJoin reports whether playerID may join game with inviteCode. Public
+// games need no code and the host never does.
+func canJoin(game *Game, playerID string, inviteCode string) bool {
+	if !game.Settings.IsPrivate || playerID == game.HostID {
+		return true
+	}
+	if game.InviteCode == nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(*game.InviteCode), []byte(normalizeInviteCode(inviteCode))) == 1
+}
+
+// JoinGameByCode joins playerID to the private game the invite code belongs to
+func (s *gameService) JoinGameByCode(ctx context.Context, inviteCode string, playerID string) (*Game, error) {
+	var gameID string
+	if err := s.db.GetContext(ctx, &gameID, `
+		SELECT id FROM games
+		WHERE invite_code = $1`, normalizeInviteCode(inviteCode)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidInviteCode
+		}
+		return nil, fmt.Errorf("failed to find game: %w", err)
+	}
+
+	return s.JoinGame(ctx, gameID, playerID, inviteCode)
+}
+
+// GameView is a game as shown to one user. Only the host sees the invite
+// code for a private game.
+type GameView struct {
+	*Game
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+// ViewFor returns game as userID may see it, for callers outside the package
+// that serve games their own way
+func ViewFor(game *Game, userID string) GameView {
+	return viewFor(game, userID)
+}
+
+func viewFor(game *Game, userID string) GameView {
+	view := GameView{Game: maskedGame(game)}
+	if game.InviteCode != nil && game.HostID == userID {
+		view.InviteCode = *game.InviteCode
+	}
+	return view
+}
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInviteCode(t *testing.T) {
+	code, err := newInviteCode()
+	require.NoError(t, err)
+	assert.Len(t, code, InviteCodeLength)
+	for _, c := range code {
+		assert.True(t, strings.ContainsRune(inviteCodeAlphabet, c))
+	}
+}
+
+func TestNewInvitationToken(t *testing.T) {
+	token, hash, err := NewInvitationToken()
+	require.NoError(t, err)
+	assert.Len(t, token, 43, "32 random bytes, base64url encoded")
+	assert.Equal(t, hash, HashInvitationToken(token))
+
+	other, _, err := NewInvitationToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestCanJoin(t *testing.T) {
+	code := "K7QXM2PA"
+	game := &Game{HostID: "host", InviteCode: &code}
+	game.Settings.IsPrivate = true
+
+	assert.True(t, canJoin(game, "host", ""), "the host never needs the code")
+	assert.True(t, canJoin(game, "player", " k7qxm2pa "), "codes are case-insensitive")
+	assert.False(t, canJoin(game, "player", ""))
+	assert.False(t, canJoin(game, "player", "WRONGONE"))
+
+	assert.True(t, canJoin(&Game{HostID: "host"}, "player", ""), "public games need no code")
+}
+
+func TestInviteCodeOnlyShownToHost(t *testing.T) {
+	code := "K7QXM2PA"
+	game := &Game{ID: "game", HostID: "host", InviteCode: &code}
+
+	hostView, err := json.Marshal(viewFor(game, "host"))
+	require.NoError(t, err)
+	assert.Contains(t, string(hostView), code)
+
+	playerView, err := json.Marshal(viewFor(game, "player"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(playerView), code)
+
+	raw, err := json.Marshal(game)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), code, "events carry the bare game")
+}
+
+func TestRoutesRegister(t *testing.T) {
+	assert.NotPanics(t, func() { NewHandler(nil).Routes() })
+}
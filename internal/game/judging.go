@@ -0,0 +1,197 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const DefaultJudgeTimeout = 15 * time.Second
+
+var (
+	ErrReviewPending   = errors.New("an attempt is awaiting a judge's ruling")
+	ErrNotJudge        = errors.New("only the assigned judge can rule on this attempt")
+	ErrNoPendingReview = errors.New("attempt is not awaiting review")
+)
+
+// JudgingSettings routes voice attempts the recogniser wasn't sure about
+// to a standby judge instead of failing them automatically
+type JudgingSettings struct {
+	// ConfidenceThreshold is the transcription confidence, from 0 to 1,
+	// below which a voice attempt goes to a judge. Zero disables review.
+	ConfidenceThreshold float64 `json:"confidence_threshold"`
+	// Judges are the user IDs of the standby judges. The host stands by
+	// when none are listed.
+	Judges []string `json:"judges,omitempty"`
+	// Timeout is how long a judge has to rule before the automatic ruling
+	// stands. Zero means DefaultJudgeTimeout.
+	Timeout time.Duration `json:"timeout"`
+}
+
+func (j JudgingSettings) timeout() time.Duration {
+	if j.Timeout <= 0 {
+		return DefaultJudgeTimeout
+	}
+	return j.Timeout
+}
+
+// PendingReview is a voice attempt waiting on a judge. Turns don't advance
+// while one is open.
+type PendingReview struct {
+	Attempt  *SpellingAttempt `json:"attempt"`
+	JudgeID  string           `json:"judge_id"`
+	Deadline time.Time        `json:"deadline"`
+}
+
+// assignJudge picks the standby judge for an attempt by playerID. Players
+// never judge their own attempts.
+func assignJudge(game *Game, playerID string) (string, bool) {
+	candidates := game.Settings.Judging.Judges
+	if len(candidates) == 0 {
+		candidates = []string{game.HostID}
+	}
+
+	for _, judgeID := range candidates {
+		if judgeID != "" && judgeID != playerID {
+			return judgeID, true
+		}
+	}
+	return "", false
+}
+
+// needsReview reports whether attempt should wait for a judge, and which one
+func needsReview(game *Game, attempt *SpellingAttempt) (string, bool) {
+	threshold := game.Settings.Judging.ConfidenceThreshold
+	if attempt.Type != AttemptTypeVoice || threshold <= 0 || attempt.Confidence == nil || *attempt.Confidence >= threshold {
+		return "", false
+	}
+	return assignJudge(game, attempt.PlayerID)
+}
+
+func (s *gameService) pendingReview(gameID string) *PendingReview {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if engine := s.activeGames[gameID]; engine != nil {
+		return engine.Review
+	}
+	return nil
+}
+
+// takeReview clears the open review for attemptID and returns it, so that
+// exactly one of the judge and the fallback timer gets to rule
+func (s *gameService) takeReview(gameID, attemptID string) *PendingReview {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engine := s.activeGames[gameID]
+	if engine == nil || engine.Review == nil || engine.Review.Attempt.ID != attemptID {
+		return nil
+	}
+
+	review := engine.Review
+	engine.Review = nil
+	return review
+}
+
+// requestReview holds attempt for judgeID and notifies them. The automatic
+// ruling already on the attempt stands if they don't answer in time.
+func (s *gameService) requestReview(game *Game, attempt *SpellingAttempt, judgeID string) {
+	timeout := game.Settings.Judging.timeout()
+	review := &PendingReview{
+		Attempt:  attempt,
+		JudgeID:  judgeID,
+		Deadline: time.Now().Add(timeout),
+	}
+
+	s.mu.Lock()
+	if engine := s.activeGames[game.ID]; engine != nil {
+		engine.Review = review
+		engine.Phase = PhaseJudging
+	}
+	s.mu.Unlock()
+
+	gameID, attemptID := game.ID, attempt.ID
+	s.timers.Schedule(timerKey(gameID, "review"), timeout, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		ctx, unlock := s.lockEngine(ctx, gameID)
+		defer unlock()
+
+		review := s.takeReview(gameID, attemptID)
+		if review == nil {
+			return
+		}
+		_ = s.finishReview(ctx, gameID, review, review.Attempt.IsCorrect, RulingFallback)
+	})
+
+	// Only the judge is told the word and the automatic ruling
+	s.emitEvent(EventTypeReviewRequested, gameID, &attempt.PlayerID, map[string]any{
+		"attempt_id":       attempt.ID,
+		"judge_id":         judgeID,
+		"transcription":    attempt.Text,
+		"confidence":       attempt.Confidence,
+		"deadline":         review.Deadline,
+		"word":             onlyFor{viewers: []string{judgeID}, value: attempt.Word},
+		"automatic_ruling": onlyFor{viewers: []string{judgeID}, value: attempt.IsCorrect},
+	})
+}
+
+// RuleOnAttempt records the assigned judge's ruling on an attempt awaiting
+// review and resumes the game
+func (s *gameService) RuleOnAttempt(ctx context.Context, gameID, attemptID, judgeID string, correct bool) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	review := s.pendingReview(gameID)
+	if review == nil || review.Attempt.ID != attemptID {
+		return ErrNoPendingReview
+	}
+	if review.JudgeID != judgeID {
+		return ErrNotJudge
+	}
+
+	if review = s.takeReview(gameID, attemptID); review == nil {
+		// The fallback ruling got there first
+		return ErrNoPendingReview
+	}
+	s.timers.Cancel(timerKey(gameID, "review"))
+
+	return s.finishReview(ctx, gameID, review, correct, RulingJudge)
+}
+
+func (s *gameService) finishReview(ctx context.Context, gameID string, review *PendingReview, correct bool, ruling RulingSource) error {
+	attempt := review.Attempt
+	attempt.IsCorrect = correct
+	attempt.Status = AttemptStatusJudged
+	attempt.Ruling = ruling
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	scoreAttempt(game, attempt, s.streak(gameID, attempt.PlayerID))
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE spelling_attempts
+		SET status = $1, ruling = $2, is_correct = $3, match = $4, points = $5
+		WHERE id = $6`,
+		attempt.Status, attempt.Ruling, attempt.IsCorrect, attempt.Match, attempt.Points, attempt.ID); err != nil {
+		return fmt.Errorf("failed to record ruling: %w", err)
+	}
+
+	s.emitEvent(EventTypeReviewResolved, gameID, &attempt.PlayerID, map[string]any{
+		"attempt_id": attempt.ID,
+		"judge_id":   review.JudgeID,
+		"ruling":     ruling,
+		"correct":    correct,
+	})
+
+	engine := s.engine(gameID)
+	if engine == nil {
+		return ErrGameNotFound
+	}
+
+	return s.resolveAttempt(ctx, game, engine, attempt)
+}
@@ -0,0 +1,44 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignJudge(t *testing.T) {
+	game := &Game{HostID: "host"}
+
+	judgeID, ok := assignJudge(game, "player")
+	assert.True(t, ok)
+	assert.Equal(t, "host", judgeID, "host stands by when no judges are listed")
+
+	_, ok = assignJudge(game, "host")
+	assert.False(t, ok, "host can't judge their own attempt")
+
+	game.Settings.Judging.Judges = []string{"player", "judge"}
+	judgeID, ok = assignJudge(game, "player")
+	assert.True(t, ok)
+	assert.Equal(t, "judge", judgeID)
+}
+
+func TestNeedsReview(t *testing.T) {
+	low, high := 0.4, 0.9
+	game := &Game{HostID: "host"}
+	game.Settings.Judging.ConfidenceThreshold = 0.6
+
+	_, ok := needsReview(game, &SpellingAttempt{PlayerID: "player", Type: AttemptTypeVoice, Confidence: &low})
+	assert.True(t, ok)
+
+	_, ok = needsReview(game, &SpellingAttempt{PlayerID: "player", Type: AttemptTypeVoice, Confidence: &high})
+	assert.False(t, ok)
+
+	_, ok = needsReview(game, &SpellingAttempt{PlayerID: "player", Type: AttemptTypeText, Confidence: &low})
+	assert.False(t, ok)
+
+	game.Settings.Judging.ConfidenceThreshold = 0
+	_, ok = needsReview(game, &SpellingAttempt{PlayerID: "player", Type: AttemptTypeVoice, Confidence: &low})
+	assert.False(t, ok, "a zero threshold disables review")
+
+	assert.Equal(t, DefaultJudgeTimeout, JudgingSettings{}.timeout())
+}
@@ -0,0 +1,51 @@
+package game
+
+import "slices"
+
+// DefaultLanguage is the language of games and words that don't name one
+const DefaultLanguage = "en"
+
+// languageVoices is the TTS voice words in each supported language are read
+// out in, keyed by ISO 639-1 code. A language is only playable once it has
+// words in the word bank.
+var languageVoices = map[string]string{
+	"en": DefaultVoice,
+	"es": "nova",
+	"fr": "shimmer",
+	"de": "echo",
+	"it": "fable",
+	"pt": "alloy",
+}
+
+// Languages lists the codes of the languages games can be played in
+func Languages() []string {
+	languages := make([]string, 0, len(languageVoices))
+	for language := range languageVoices {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// IsSupportedLanguage reports whether games can be played in language
+func IsSupportedLanguage(language string) bool {
+	_, ok := languageVoices[language]
+	return ok
+}
+
+// LanguageVoice returns the TTS voice for words in language, falling back
+// to DefaultVoice
+func LanguageVoice(language string) string {
+	if voice, ok := languageVoices[language]; ok {
+		return voice
+	}
+	return DefaultVoice
+}
+
+// languageOr returns language, or DefaultLanguage if it's empty
+func languageOr(language string) string {
+	if language == "" {
+		return DefaultLanguage
+	}
+	return language
+}
@@ -0,0 +1,201 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/queries"
+)
+
+var (
+	ErrPlayerKicked   = errors.New("player was removed from this game by the host")
+	ErrCannotKickHost = errors.New("the host can't remove themselves")
+)
+
+// LeaveGame takes playerID out of the game. Lobby players are removed
+// outright; players in a started game are marked as having left and their
+// turns are skipped. A departing host hands the game to the longest-seated
+// player, and a started game left short of players is cancelled.
+func (s *gameService) LeaveGame(ctx context.Context, gameID string, playerID string) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	switch game.Status {
+	case GameStatusFinished, GameStatusCancelled:
+		return ErrInvalidGameState
+	}
+
+	isPlayer := false
+	for _, player := range game.Players {
+		if player != nil && player.UserID == playerID && player.Status == "active" {
+			isPlayer = true
+		}
+	}
+	isHost := game.HostID == playerID
+	if !isPlayer && !isHost {
+		return ErrPlayerNotFound
+	}
+
+	if isPlayer {
+		if err := s.removePlayer(ctx, game, playerID, "left"); err != nil {
+			return err
+		}
+		s.emitEvent(EventTypePlayerLeft, gameID, &playerID, map[string]any{
+			"player_id": playerID,
+		})
+	}
+
+	return s.afterDeparture(ctx, game, playerID)
+}
+
+// KickPlayer lets the host remove a disruptive player, who can't rejoin the
+// game afterwards
+func (s *gameService) KickPlayer(ctx context.Context, gameID string, hostID string, playerID string) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	if game.HostID != hostID {
+		return ErrNotHost
+	}
+
+	if playerID == hostID {
+		return ErrCannotKickHost
+	}
+
+	switch game.Status {
+	case GameStatusFinished, GameStatusCancelled:
+		return ErrInvalidGameState
+	}
+
+	isPlayer := false
+	for _, player := range game.Players {
+		if player != nil && player.UserID == playerID && player.Status == "active" {
+			isPlayer = true
+		}
+	}
+	if !isPlayer {
+		return ErrPlayerNotFound
+	}
+
+	if err := s.removePlayer(ctx, game, playerID, "kicked"); err != nil {
+		return err
+	}
+
+	s.emitEvent(EventTypePlayerKicked, gameID, &playerID, map[string]any{
+		"player_id": playerID,
+		"kicked_by": hostID,
+	})
+
+	return s.afterDeparture(ctx, game, playerID)
+}
+
+// removePlayer drops a player who left a lobby or a scheduled game's
+// sign-ups, and otherwise marks them with status. Kicked players keep their
+// row so they can't rejoin.
+func (s *gameService) removePlayer(ctx context.Context, game *Game, playerID string, status string) error {
+	var err error
+	if (game.Status == GameStatusWaiting || game.Status == GameStatusScheduled) && status == "left" {
+		err = s.queries.RemovePlayer(ctx, queries.RemovePlayerParams{GameID: game.ID, PlayerID: playerID})
+	} else {
+		err = s.queries.SetPlayerStatus(ctx, queries.SetPlayerStatusParams{
+			Status: status, GameID: game.ID, PlayerID: playerID,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove player: %w", err)
+	}
+
+	for _, player := range game.Players {
+		if player != nil && player.UserID == playerID {
+			player.Status = status
+		}
+	}
+	return nil
+}
+
+// afterDeparture keeps the game playable once playerID is gone: it cancels a
+// started game that no longer has enough players, moves the host role on
+// and skips the departed player's turn
+func (s *gameService) afterDeparture(ctx context.Context, game *Game, playerID string) error {
+	remaining := turnOrder(&Game{Players: game.Players})
+	started := game.Status == GameStatusActive || game.Status == GameStatusPaused
+
+	if len(remaining) == 0 || (started && len(remaining) < game.Settings.MinPlayers) {
+		return s.cancelGame(ctx, game, "not enough players")
+	}
+
+	if game.HostID == playerID {
+		newHostID := remaining[0]
+		if err := s.queries.TransferHost(ctx, queries.TransferHostParams{
+			HostID: newHostID, Now: time.Now(), ID: game.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to transfer host: %w", err)
+		}
+		game.HostID = newHostID
+
+		s.emitEvent(EventTypeHostTransferred, game.ID, &newHostID, map[string]any{
+			"previous_host_id": playerID,
+			"host_id":          newHostID,
+		})
+	}
+
+	engine := s.engine(game.ID)
+	if engine == nil || !started {
+		return nil
+	}
+
+	s.dropConfirmation(game.ID, playerID)
+	wasCurrent := engine.RemovePlayer(playerID)
+	if !wasCurrent || game.Status != GameStatusActive || engine.Intermission != nil ||
+		engine.TurnStartedAt == nil || s.pendingReview(game.ID) != nil {
+		return nil
+	}
+
+	return s.handOverTurn(ctx, game.ID, engine)
+}
+
+// cancelGame ends a game early, stopping its clocks and dropping its engine
+func (s *gameService) cancelGame(ctx context.Context, game *Game, reason string) error {
+	for _, name := range []string{"turn", "listening", "intermission", "review", "confirmation", "ready_check"} {
+		s.timers.Cancel(timerKey(game.ID, name))
+	}
+
+	s.mu.Lock()
+	delete(s.activeGames, game.ID)
+	activeGamesGauge.Set(float64(len(s.activeGames)))
+	s.mu.Unlock()
+
+	if err := s.queries.SetGameStatus(ctx, queries.SetGameStatusParams{
+		Status: string(GameStatusCancelled), Now: time.Now(), ID: game.ID,
+	}); err != nil {
+		return fmt.Errorf("failed to cancel game: %w", err)
+	}
+	s.record(ctx, audit.Event{
+		Action:     audit.ActionGameCancelled,
+		TargetType: "game",
+		TargetID:   game.ID,
+		Before:     map[string]any{"status": game.Status},
+		After:      map[string]any{"status": GameStatusCancelled, "reason": reason},
+	})
+	game.Status = GameStatusCancelled
+
+	s.emitEvent(EventTypeGameCancelled, game.ID, nil, map[string]any{
+		"status": GameStatusCancelled,
+		"reason": reason,
+	})
+
+	return nil
+}
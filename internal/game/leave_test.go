@@ -0,0 +1,50 @@
+package game
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+type kickStub struct {
+	GameService
+	err error
+}
+
+func (s kickStub) KickPlayer(ctx context.Context, gameID string, hostID string, playerID string) error {
+	return s.err
+}
+
+func TestKickPlayerHandler(t *testing.T) {
+	gameID, playerID := uuid.New().String(), uuid.New().String()
+
+	tests := []struct {
+		playerID string
+		err      error
+		code     int
+	}{
+		{playerID, nil, http.StatusNoContent},
+		{playerID, ErrNotHost, http.StatusForbidden},
+		{playerID, ErrCannotKickHost, http.StatusConflict},
+		{playerID, ErrPlayerNotFound, http.StatusNotFound},
+		{"not-a-uuid", nil, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		h := NewHandler(kickStub{err: tt.err})
+		req := httptest.NewRequest(http.MethodPost, "/games/"+gameID+"/kick/"+tt.playerID, nil)
+		req = req.WithContext(auth.SetUserIDInContext(req.Context(), "host"))
+		rec := httptest.NewRecorder()
+
+		h.KickPlayer(rec, req, httprouter.Params{{Key: "gameID", Value: gameID}, {Key: "playerID", Value: tt.playerID}})
+
+		assert.Equal(t, tt.code, rec.Code)
+	}
+}
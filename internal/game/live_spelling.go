@@ -0,0 +1,182 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrLiveSpellingDisabled = errors.New("letter-by-letter spelling is not enabled for this game")
+	ErrNotSpelling          = errors.New("no spelling is in progress")
+	ErrInvalidLetter        = errors.New("must be a single letter, hyphen or apostrophe")
+	ErrSpellingTooLong      = errors.New("spelling is too long")
+)
+
+// LiveSpellingSettings lets players spell aloud, sending one letter at a
+// time over the game WebSocket the way a spelling bee is run
+type LiveSpellingSettings struct {
+	Enabled bool `json:"enabled"`
+	// StopAtFirstMistake ends the attempt on the first wrong letter instead
+	// of waiting for the player to finish
+	StopAtFirstMistake bool `json:"stop_at_first_mistake"`
+}
+
+// LiveSpelling is an attempt being spelled out letter by letter
+type LiveSpelling struct {
+	PlayerID string
+	Letters  []rune
+}
+
+func (l *LiveSpelling) text() string {
+	return string(l.Letters)
+}
+
+// validLetter reports whether letter is one character a word can be spelled
+// with
+func validLetter(letter string) (rune, bool) {
+	r, size := utf8.DecodeRuneInString(letter)
+	if size == 0 || size != len(letter) {
+		return 0, false
+	}
+	return r, unicode.IsLetter(r) || r == '-' || r == '\''
+}
+
+// AddLetter appends letter to playerID's spelling of the current word and
+// reports whether what they have spelled so far could still be right
+func (g *GameEngine) AddLetter(playerID string, letter rune) (bool, error) {
+	if g.CurrentWord == nil {
+		return false, ErrNoWordSet
+	}
+	if g.TurnStartedAt == nil {
+		return false, ErrTurnNotActive
+	}
+
+	if g.Spelling == nil || g.Spelling.PlayerID != playerID {
+		g.Spelling = &LiveSpelling{PlayerID: playerID}
+	}
+	if len(g.Spelling.Letters) >= MaxTextAttempt {
+		return false, ErrSpellingTooLong
+	}
+	g.Spelling.Letters = append(g.Spelling.Letters, letter)
+
+	spelled := []rune(strings.ToLower(g.Spelling.text()))
+	for _, spelling := range g.CurrentWord.Spellings(g.Spellings) {
+		word := []rune(strings.ToLower(spelling))
+		if len(spelled) <= len(word) && string(word[:len(spelled)]) == string(spelled) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// takeSpelling clears playerID's spelling in progress and returns it
+func (s *gameService) takeSpelling(gameID, playerID string) *LiveSpelling {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engine := s.activeGames[gameID]
+	if engine == nil || engine.Spelling == nil || engine.Spelling.PlayerID != playerID {
+		return nil
+	}
+
+	spelling := engine.Spelling
+	engine.Spelling = nil
+	return spelling
+}
+
+// SpellLetter adds one letter to the player's spelling of the current word.
+// In games that stop at the first mistake, a wrong letter ends the attempt
+// there and then.
+func (s *gameService) SpellLetter(ctx context.Context, gameID, playerID, letter string) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	if !game.Settings.LiveSpelling.Enabled {
+		return ErrLiveSpellingDisabled
+	}
+	if game.Status == GameStatusPaused {
+		return ErrGamePaused
+	}
+	if game.Status != GameStatusActive {
+		return ErrInvalidGameState
+	}
+
+	r, ok := validLetter(letter)
+	if !ok {
+		return ErrInvalidLetter
+	}
+
+	engine := s.engine(gameID)
+	if engine == nil {
+		return ErrGameNotFound
+	}
+	if s.pendingReview(gameID) != nil {
+		return ErrReviewPending
+	}
+	if !engine.IsPlayerTurn(engine.relayFor(playerID)) {
+		return ErrNotPlayerTurn
+	}
+	if !engine.AcceptingAnswers() {
+		return ErrAnswerWindowClosed
+	}
+	if err := engine.CheckAnswerTime(time.Now()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	var onTrack bool
+	if engine.RelayLetters && engine.letterSpeller() != playerID {
+		// Teammates take the letters in turn
+		err = ErrNotPlayerTurn
+	} else {
+		onTrack, err = engine.AddLetter(engine.relayFor(playerID), r)
+	}
+	position := 0
+	if engine.Spelling != nil {
+		position = len(engine.Spelling.Letters)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Everyone hears the letter, but not whether it was right
+	s.emitEvent(EventTypeLetterSpelled, gameID, &playerID, map[string]any{
+		"letter":   string(r),
+		"position": position,
+	})
+
+	if !onTrack && game.Settings.LiveSpelling.StopAtFirstMistake {
+		return s.FinishSpelling(ctx, gameID, playerID)
+	}
+	return nil
+}
+
+// FinishSpelling submits the letters the player has spelled as their attempt.
+// In a letter relay any of the team can submit it, for whoever's turn it is.
+func (s *gameService) FinishSpelling(ctx context.Context, gameID, playerID string) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	if engine := s.engine(gameID); engine != nil {
+		playerID = engine.relayFor(playerID)
+	}
+	spelling := s.takeSpelling(gameID, playerID)
+	if spelling == nil {
+		return ErrNotSpelling
+	}
+
+	return s.MakeAttempt(ctx, gameID, playerID, &SpellingAttempt{
+		Type: AttemptTypeLetters,
+		Text: spelling.text(),
+	})
+}
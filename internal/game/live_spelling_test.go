@@ -0,0 +1,125 @@
+package game
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestAddLetterChecksPrefix(t *testing.T) {
+	now := time.Now()
+	engine := NewGameEngine("game-1", nil)
+	engine.CurrentWord = &Word{Word: "Bee"}
+	engine.TurnStartedAt = &now
+
+	for _, letter := range "be" {
+		onTrack, err := engine.AddLetter("ada", letter)
+		require.NoError(t, err)
+		assert.True(t, onTrack)
+	}
+
+	onTrack, err := engine.AddLetter("ada", 'a')
+	require.NoError(t, err)
+	assert.False(t, onTrack)
+	assert.Equal(t, "bea", engine.Spelling.text())
+
+	// Another player starts over, and a new turn clears the spelling
+	_, err = engine.AddLetter("bo", 'b')
+	require.NoError(t, err)
+	assert.Equal(t, "b", engine.Spelling.text())
+	engine.RestartTurn()
+	assert.Nil(t, engine.Spelling)
+}
+
+func TestValidLetter(t *testing.T) {
+	for _, letter := range []string{"a", "Z", "é", "-", "'"} {
+		_, ok := validLetter(letter)
+		assert.True(t, ok, letter)
+	}
+	for _, letter := range []string{"", "ab", "1", " "} {
+		_, ok := validLetter(letter)
+		assert.False(t, ok, letter)
+	}
+}
+
+type spellingStub struct {
+	GameService
+	letters chan string
+	events  chan GameEvent
+}
+
+func (s spellingStub) SpellLetter(ctx context.Context, gameID, playerID, letter string) error {
+	if letter == "1" {
+		return ErrInvalidLetter
+	}
+	s.letters <- playerID + ":" + letter
+	return nil
+}
+
+func (s spellingStub) FinishSpelling(ctx context.Context, gameID, playerID string) error {
+	s.letters <- playerID + ":done"
+	return nil
+}
+
+func (s spellingStub) GetGame(ctx context.Context, gameID string) (*Game, error) {
+	return &Game{ID: gameID}, nil
+}
+
+func (s spellingStub) Subscribe(gameID, viewerID string, after int64) *Subscription {
+	return &Subscription{Events: s.events, cancel: func() {}}
+}
+
+func TestSpellingOverWebSocket(t *testing.T) {
+	stub := spellingStub{letters: make(chan string, 4), events: make(chan GameEvent)}
+	h := NewHandler(stub)
+	gameID := "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.SetPrincipalInContext(r.Context(), &auth.Principal{UserID: "ada", Scopes: auth.UserScopes})
+		r = r.WithContext(auth.SetUserIDInContext(ctx, "ada"))
+		h.SubscribeToEvents(w, r, httprouter.Params{{Key: "gameID", Value: gameID}})
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageSnapshot, reply.Type)
+
+	require.NoError(t, conn.WriteJSON(ClientMessage{Type: ClientMessageLetter, Letter: "b"}))
+	require.NoError(t, conn.WriteJSON(ClientMessage{Type: ClientMessageSpellingDone}))
+	assert.Equal(t, "ada:b", <-stub.letters)
+	assert.Equal(t, "ada:done", <-stub.letters)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, conn.ReadJSON(&reply))
+		assert.Equal(t, ServerMessageAck, reply.Type)
+	}
+
+	// Rejected messages are answered without closing the connection
+	require.NoError(t, conn.WriteJSON(ClientMessage{Type: ClientMessageLetter, Letter: "1"}))
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageError, reply.Type)
+	assert.Equal(t, ErrInvalidLetter.Error(), reply.Error)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, errInvalidMessage.Error(), reply.Error)
+
+	stub.events <- GameEvent{Type: EventTypeLetterSpelled, GameID: gameID}
+	var event GameEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, EventTypeLetterSpelled, event.Type)
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// LobbySummary is the slimmed-down view of a game shown in the lobby browser
+type LobbySummary struct {
+	ID          string       `json:"id" db:"id"`
+	Type        GameType     `json:"type" db:"type"`
+	Status      GameStatus   `json:"status" db:"status"`
+	HostID      string       `json:"host_id" db:"host_id"`
+	HostName    string       `json:"host_name" db:"host_name"`
+	PlayerCount int          `json:"player_count" db:"player_count"`
+	Settings    GameSettings `json:"settings" db:"settings"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	ScheduledAt *time.Time   `json:"scheduled_at,omitempty" db:"scheduled_at"`
+	// Connected are the users connected to the game right now, players and
+	// spectators alike. It's only filled in with WithLobbyPresence.
+	Connected []string `json:"connected,omitempty" db:"-"`
+}
+
+// LobbyPresence reports who is connected to games right now
+type LobbyPresence interface {
+	Connected(ctx context.Context, gameIDs []string) (map[string][]string, error)
+}
+
+// WithLobbyPresence lists who is connected to each game in the lobby
+func WithLobbyPresence(presence LobbyPresence) ServiceOption {
+	return func(s *gameService) {
+		s.presence = presence
+	}
+}
+
+// Page describes where a page of results sits in the full listing
+type Page struct {
+	Number int `json:"page"`
+	Size   int `json:"page_size"`
+	Total  int `json:"total"`
+}
+
+// ListGames returns the page of games matching filter, newest first.
+// Private games are left out unless the filter asks for them.
+func (s *gameService) ListGames(ctx context.Context, filter GameFilter) ([]LobbySummary, int, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != nil {
+		where("g.status = $%d", *filter.Status)
+	}
+	if filter.Type != nil {
+		where("g.type = $%d", *filter.Type)
+	}
+	if filter.HostID != nil {
+		where("g.host_id = $%d", *filter.HostID)
+	}
+	if filter.PlayerID != nil {
+		where("EXISTS (SELECT 1 FROM players fp WHERE fp.game_id = g.id AND fp.player_id = $%d)", *filter.PlayerID)
+	}
+	if filter.Mode != nil {
+		where("g.settings->>'mode' = $%d", *filter.Mode)
+	}
+	if filter.WordLevel != nil {
+		where("(g.settings->>'word_level')::int = $%d", *filter.WordLevel)
+	}
+	if filter.IsRanked != nil {
+		where("COALESCE((g.settings->>'is_ranked')::boolean, false) = $%d", *filter.IsRanked)
+	}
+	if !filter.IncludePrivate {
+		conditions = append(conditions, "COALESCE((g.settings->>'is_private')::boolean, false) = false")
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.reads.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM games g `+whereClause, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count games: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT g.id, g.type, g.status, g.host_id, g.settings, g.created_at, g.scheduled_at,
+			COALESCE(u.username, '') AS host_name,
+			(SELECT COUNT(*) FROM players p
+				WHERE p.game_id = g.id AND p.status = 'active') AS player_count
+		FROM games g
+		LEFT JOIN users u ON u.id = g.host_id
+		%s
+		ORDER BY g.created_at DESC, g.id
+		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
+
+	games := []LobbySummary{}
+	if err := s.reads.SelectContext(ctx, &games, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list games: %w", err)
+	}
+
+	if s.presence != nil && len(games) > 0 {
+		ids := make([]string, len(games))
+		for i, game := range games {
+			ids[i] = game.ID
+		}
+		connected, err := s.presence.Connected(ctx, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range games {
+			games[i].Connected = connected[games[i].ID]
+		}
+	}
+
+	return games, total, nil
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"big-spella-go/internal/metrics"
+	"big-spella-go/internal/tracing"
+)
+
+var (
+	activeGamesGauge = metrics.NewGauge("spella_active_games",
+		"Games with an engine loaded on this server.")
+	socketsGauge = metrics.NewGauge("spella_websocket_connections",
+		"Open game event WebSockets.")
+	attemptsTotal = metrics.NewCounterVec("spella_attempts_total",
+		"Spelling attempts resolved, by game mode and outcome: correct, incorrect or timeout.", "mode", "outcome")
+	voiceAttemptsTotal = metrics.NewCounterVec("spella_voice_attempts_total",
+		"Voice attempts judged, by the provider that transcribed them and outcome: correct or incorrect.", "provider", "outcome")
+	transcriptionConfidence = metrics.NewHistogramVec("spella_transcription_confidence",
+		"Confidence of the transcriptions of voice attempts, by provider.", []float64{.1, .2, .3, .4, .5, .6, .7, .8, .9, 1}, "provider")
+	dictionaryDuration = metrics.NewHistogramVec("spella_dictionary_call_duration_seconds",
+		"Time spent calling the dictionary, TTS and hint providers, by operation and outcome.", nil, "operation", "outcome")
+)
+
+// countAttempt records how a turn ended in game
+func countAttempt(game *Game, outcome string) {
+	mode := string(game.Settings.Mode)
+	if mode == "" {
+		mode = "unset"
+	}
+	attemptsTotal.With(mode, outcome).Inc()
+}
+
+// countTranscription records how a voice attempt transcribed by provider
+// was judged, so each provider's accuracy can be compared. Transcriptions
+// not made through a stt.Failover have no provider.
+func countTranscription(provider string, confidence float64, correct bool) {
+	if provider == "" {
+		provider = "default"
+	}
+	voiceAttemptsTotal.With(provider, attemptOutcome(correct)).Inc()
+	transcriptionConfidence.With(provider).Observe(confidence)
+}
+
+func attemptOutcome(correct bool) string {
+	if correct {
+		return "correct"
+	}
+	return "incorrect"
+}
+
+// InstrumentDictionary times and traces dict's calls and counts their
+// failures
+func InstrumentDictionary(dict DictionaryService) DictionaryService {
+	return &instrumentedDictionary{next: dict}
+}
+
+type instrumentedDictionary struct {
+	next DictionaryService
+}
+
+// observeCall starts timing and tracing operation; the returned func ends it
+// with the call's error
+func observeCall(ctx context.Context, operation string, attrs ...tracing.Attr) (context.Context, func(error)) {
+	started := time.Now()
+	ctx, span := tracing.Start(ctx, "dictionary."+operation, attrs...)
+
+	return ctx, func(err error) {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+		dictionaryDuration.With(operation, outcome).Observe(time.Since(started).Seconds())
+		span.EndWith(&err)
+	}
+}
+
+func (d *instrumentedDictionary) GetWordInfo(ctx context.Context, word, language string) (*Word, error) {
+	ctx, done := observeCall(ctx, "word_info", tracing.String("word", word), tracing.String("language", language))
+	info, err := d.next.GetWordInfo(ctx, word, language)
+	done(err)
+	return info, err
+}
+
+func (d *instrumentedDictionary) GenerateAudio(ctx context.Context, text string) ([]byte, error) {
+	ctx, done := observeCall(ctx, "tts", tracing.String("word", text))
+	audio, err := d.next.GenerateAudio(ctx, text)
+	done(err)
+	return audio, err
+}
+
+func (d *instrumentedDictionary) SynthesizeSpeech(ctx context.Context, text, voice string, rate float64) ([]byte, error) {
+	ctx, done := observeCall(ctx, "tts", tracing.String("word", text), tracing.String("voice", voice), tracing.Float("rate", rate))
+	audio, err := d.next.SynthesizeSpeech(ctx, text, voice, rate)
+	done(err)
+	return audio, err
+}
+
+func (d *instrumentedDictionary) GetHint(ctx context.Context, word *Word, hintType HintType) (string, error) {
+	ctx, done := observeCall(ctx, "hint", tracing.String("hint.type", string(hintType)))
+	hint, err := d.next.GetHint(ctx, word, hintType)
+	done(err)
+	return hint, err
+}
@@ -0,0 +1,40 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/game/modes"
+)
+
+func TestInstrumentDictionary(t *testing.T) {
+	mockDict := new(MockDictionaryService)
+	mockDict.On("GenerateAudio", context.Background(), "bee").Return([]byte("mp3"), nil)
+	mockDict.On("GenerateAudio", context.Background(), "wasp").Return([]byte(nil), errors.New("rate limited"))
+
+	ok, failed := dictionaryDuration.With("tts", "ok").Count(), dictionaryDuration.With("tts", "error").Count()
+
+	dict := InstrumentDictionary(mockDict)
+	audio, err := dict.GenerateAudio(context.Background(), "bee")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("mp3"), audio)
+	_, err = dict.GenerateAudio(context.Background(), "wasp")
+	assert.EqualError(t, err, "rate limited")
+
+	assert.Equal(t, ok+1, dictionaryDuration.With("tts", "ok").Count())
+	assert.Equal(t, failed+1, dictionaryDuration.With("tts", "error").Count())
+}
+
+func TestCountAttempt(t *testing.T) {
+	game := &Game{Settings: GameSettings{Mode: modes.ModeRapidFire}}
+	before := attemptsTotal.With("rapid_fire", "timeout").Value()
+
+	countAttempt(game, "timeout")
+	countAttempt(&Game{}, attemptOutcome(true))
+
+	assert.Equal(t, before+1, attemptsTotal.With("rapid_fire", "timeout").Value())
+	assert.Positive(t, attemptsTotal.With("unset", "correct").Value())
+}
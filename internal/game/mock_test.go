@@ -2,9 +2,7 @@ package game
 
 import (
 	"context"
-	"database/sql"
-	"database/sql/driver"
-	"github.com/jmoiron/sqlx"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -13,8 +11,8 @@ type MockDictionaryService struct {
 	mock.Mock
 }
 
-func (m *MockDictionaryService) GetWordInfo(ctx context.Context, word string) (*Word, error) {
-	args := m.Called(ctx, word)
+func (m *MockDictionaryService) GetWordInfo(ctx context.Context, word, language string) (*Word, error) {
+	args := m.Called(ctx, word, language)
 	return args.Get(0).(*Word), args.Error(1)
 }
 
@@ -23,6 +21,11 @@ func (m *MockDictionaryService) GenerateAudio(ctx context.Context, word string)
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (m *MockDictionaryService) SynthesizeSpeech(ctx context.Context, text, voice string, rate float64) ([]byte, error) {
+	args := m.Called(ctx, text, voice, rate)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
 func (m *MockDictionaryService) GetHint(ctx context.Context, word *Word, hintType HintType) (string, error) {
 	args := m.Called(ctx, word, hintType)
 	return args.String(0), args.Error(1)
@@ -33,8 +36,8 @@ type MockWordService struct {
 	mock.Mock
 }
 
-func (m *MockWordService) GetRandomWord(ctx context.Context, level int, category *string) (*Word, error) {
-	args := m.Called(ctx, level, category)
+func (m *MockWordService) GetRandomWord(ctx context.Context, query WordQuery) (*Word, error) {
+	args := m.Called(ctx, query)
 	return args.Get(0).(*Word), args.Error(1)
 }
 
@@ -43,58 +46,7 @@ func (m *MockWordService) ValidateSpelling(ctx context.Context, word, attempt st
 	return args.Bool(0)
 }
 
-func (m *MockWordService) TranscribeVoice(ctx context.Context, voiceData []byte) (string, error) {
-	args := m.Called(ctx, voiceData)
+func (m *MockWordService) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	args := m.Called(ctx, voiceData, language)
 	return args.String(0), args.Error(1)
 }
-
-// MockDB is a mock implementation of the database interface
-type MockDB struct {
-	*sqlx.DB
-	mock.Mock
-}
-
-func NewMockDB() *MockDB {
-	return &MockDB{
-		DB: sqlx.NewDb(sql.OpenDB(mockConnector{}), "mock"),
-	}
-}
-
-// mockConnector implements driver.Connector interface
-type mockConnector struct{}
-
-func (m mockConnector) Connect(context.Context) (sql.Conn, error) {
-	return nil, nil
-}
-
-func (m mockConnector) Driver() driver.Driver {
-	return nil
-}
-
-func (m *MockDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	mockArgs := m.Called(ctx, query, args)
-	if mockArgs.Get(0) == nil {
-		return &sql.Row{}
-	}
-	return mockArgs.Get(0).(*sql.Row)
-}
-
-func (m *MockDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	mockArgs := m.Called(ctx, dest, query, args)
-	return mockArgs.Error(0)
-}
-
-func (m *MockDB) CreateGame(ctx context.Context, game *Game) error {
-	args := m.Called(ctx, game)
-	return args.Error(0)
-}
-
-func (m *MockDB) GetGame(ctx context.Context, gameID string) (*Game, error) {
-	args := m.Called(ctx, gameID)
-	return args.Get(0).(*Game), args.Error(1)
-}
-
-func (m *MockDB) UpdateGame(ctx context.Context, game *Game) error {
-	args := m.Called(ctx, game)
-	return args.Error(0)
-}
@@ -2,7 +2,10 @@ package game
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
+
+	"big-spella-go/internal/game/modes"
 )
 
 // EventType represents different types of game events
@@ -12,6 +15,7 @@ const (
 	EventTypeGameCreated     EventType = "game_created"
 	EventTypeGameStarted     EventType = "game_started"
 	EventTypeGameEnded      EventType = "game_ended"
+	EventTypeGameCancelled  EventType = "game_cancelled"
 	EventTypeAttemptSucceeded EventType = "attempt_succeeded"
 	EventTypeAttemptFailed   EventType = "attempt_failed"
 	EventTypePlayerJoined    EventType = "player_joined"
@@ -19,6 +23,44 @@ const (
 	EventTypeRoundStarted    EventType = "round_started"
 	EventTypeRoundEnded      EventType = "round_ended"
 	EventTypeHintRequested   EventType = "hint_requested"
+	EventTypeIntermissionStarted EventType = "intermission_started"
+	EventTypeIntermissionEnded   EventType = "intermission_ended"
+	// EventTypeRoundSummary is sent between round_ended and
+	// intermission_started with how everyone did in the round
+	EventTypeRoundSummary        EventType = "round_summary"
+	EventTypeTurnRecap           EventType = "turn_recap"
+	EventTypeReviewRequested     EventType = "review_requested"
+	EventTypeReviewResolved      EventType = "review_resolved"
+	EventTypeTurnChanged         EventType = "turn_changed"
+	EventTypeGamePaused          EventType = "game_paused"
+	EventTypeGameResumed         EventType = "game_resumed"
+	EventTypeHostTransferred     EventType = "host_transferred"
+	EventTypePlayerKicked        EventType = "player_kicked"
+	EventTypeLetterSpelled       EventType = "letter_spelled"
+	EventTypeWordPronounced      EventType = "word_pronounced"
+	EventTypeAppealFiled         EventType = "appeal_filed"
+	EventTypeAppealResolved      EventType = "appeal_resolved"
+	EventTypeChatMessage         EventType = "chat_message"
+	// EventTypePresenceChanged is sent as a user opens their first connection
+	// to a game and closes their last
+	EventTypePresenceChanged EventType = "presence_changed"
+	// EventTypeSpellingStatus is what a player is doing with their turn. It
+	// isn't numbered or kept for clients catching up.
+	EventTypeSpellingStatus EventType = "spelling_status"
+	// EventTypeConfirmationRequested asks a player to confirm how their voice
+	// attempt was transcribed, and EventTypeConfirmationResolved follows
+	// once they have or their time is up
+	EventTypeConfirmationRequested EventType = "confirmation_requested"
+	EventTypeConfirmationResolved  EventType = "confirmation_resolved"
+	// EventTypeReadyCheckStarted asks the lobby's players to confirm they're
+	// ready, EventTypePlayerReady follows as each does, and
+	// EventTypeReadyCheckEnded says who was dropped and whether the game is
+	// starting
+	EventTypeReadyCheckStarted EventType = "ready_check_started"
+	EventTypePlayerReady       EventType = "player_ready"
+	EventTypeReadyCheckEnded   EventType = "ready_check_ended"
+	// EventTypeLobbyOpened is sent as a scheduled game's lobby opens
+	EventTypeLobbyOpened EventType = "lobby_opened"
 )
 
 // HintType represents different types of hints
@@ -62,8 +104,11 @@ const (
 	GameStatusWaiting      GameStatus = "waiting"
 	GameStatusPlaying      GameStatus = "playing"
 	GameStatusActive       GameStatus = "active"
+	GameStatusPaused       GameStatus = "paused"
 	GameStatusFinished     GameStatus = "finished"
 	GameStatusCancelled    GameStatus = "cancelled"
+	// GameStatusScheduled games take sign-ups until their lobby opens
+	GameStatusScheduled GameStatus = "scheduled"
 )
 
 // Word represents a word and its associated information
@@ -75,6 +120,15 @@ type Word struct {
 	Etymology       string    `json:"etymology" db:"etymology"`
 	PartOfSpeech    string    `json:"part_of_speech" db:"part_of_speech"`
 	Pronunciation   string    `json:"pronunciation" db:"pronunciation"`
+	Respelling      string    `json:"respelling" db:"respelling"`
+	Source          string    `json:"source,omitempty" db:"source"`
+	Language        string    `json:"language" db:"language"`
+	EmpiricalLevel  *int      `json:"empirical_level,omitempty" db:"empirical_level"`
+	// Offensive is set on words the dictionary marks offensive or vulgar
+	Offensive       bool      `json:"offensive" db:"offensive"`
+	Status          WordStatus `json:"status,omitempty" db:"status"`
+	// AcceptedSpellings are the other ways the word may be spelled
+	AcceptedSpellings AcceptedSpellings `json:"accepted_spellings,omitempty" db:"accepted_spellings"`
 	AudioURL        string    `json:"audio_url" db:"audio_url"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
@@ -98,26 +152,85 @@ type Game struct {
 	RecordGame    bool            `json:"record_game" db:"record_game"`
 	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+	// Version counts the updates made to the game
+	Version       int             `json:"version" db:"version"`
 	TurnStartedAt *time.Time      `json:"turn_started_at,omitempty" db:"turn_started_at"`
+	PausedAt      *time.Time      `json:"paused_at,omitempty" db:"paused_at"`
 	HintsUsed     map[string][]string `json:"hints_used,omitempty" db:"hints_used"`
+	HintsRemaining map[string]int     `json:"hints_remaining,omitempty" db:"-"`
 	WordMasked    bool            `json:"word_masked" db:"word_masked"`
 	HostID        string          `json:"host_id" db:"host_id"`
+	InviteCode    *string         `json:"-" db:"invite_code"`
 	LastActivity  time.Time       `json:"last_activity" db:"last_activity"`
 	CurrentPlayer string          `json:"current_player" db:"current_player"`
+	TurnPhase     TurnPhase       `json:"turn_phase,omitempty" db:"-"`
+	// TurnRemainingMS is how long the current player has left to answer,
+	// so clients joining mid-turn count down with everyone else
+	TurnRemainingMS *int64        `json:"turn_remaining_ms,omitempty" db:"-"`
+	// ReadyCheck is set while the lobby is confirming it's ready to start
+	ReadyCheck    *ReadyCheck     `json:"ready_check,omitempty" db:"-"`
+	// ScheduledAt and LobbyOpensAt are kept from the settings of scheduled
+	// games so they can be looked up
+	ScheduledAt   *time.Time      `json:"-" db:"scheduled_at"`
+	LobbyOpensAt  *time.Time      `json:"-" db:"lobby_opens_at"`
 	Players       []*Player       `json:"players" db:"players"`
 }
 
 // GameSettings represents the settings for a game
 type GameSettings struct {
+	Mode        modes.GameMode `json:"mode,omitempty"`
+	MaxRounds   int           `json:"max_rounds,omitempty"`
 	MinPlayers  int           `json:"min_players"`
 	MaxPlayers  int           `json:"max_players"`
 	TimeLimit   time.Duration `json:"time_limit"`
 	Category    *string       `json:"category,omitempty"`
 	IsRanked    bool         `json:"is_ranked"`
+	IsTournament bool        `json:"is_tournament"`
+	IsPrivate   bool         `json:"is_private"`
 	Elimination bool         `json:"elimination"`
 	WordLevel   int          `json:"word_level"`
+	// Difficulty, when set, makes words harder each round from WordLevel;
+	// see modes.DifficultyCurve
+	Difficulty  *modes.DifficultyCurve `json:"difficulty,omitempty"`
+	EmpiricalDifficulty bool `json:"empirical_difficulty"`
 	HintsAllowed int         `json:"hints_allowed"`
+	HintPenalty  *int        `json:"hint_penalty,omitempty"`
+	AllowedHints []HintType  `json:"allowed_hints,omitempty"`
 	SpellStartTimeout time.Duration `json:"spell_start_timeout"`
+	Pacing       PacingSettings `json:"pacing"`
+	VoiceRetentionOptOut bool   `json:"voice_retention_opt_out"`
+	RevealPolicy RevealPolicy   `json:"reveal_policy,omitempty"`
+	Judging      JudgingSettings `json:"judging"`
+	Confirmation ConfirmationSettings `json:"confirmation"`
+	Scoring      ScoringSettings `json:"scoring"`
+	LiveSpelling LiveSpellingSettings `json:"live_spelling"`
+	Pronunciation PronunciationSettings `json:"pronunciation"`
+	Appeals      AppealSettings `json:"appeals"`
+	// Teams and Relay set up a team relay; see modes.ModeTeamRelay
+	Teams        int              `json:"teams,omitempty"`
+	Relay        modes.RelayStyle `json:"relay,omitempty"`
+	// Language is the ISO 639-1 code of the language the game's words are
+	// in, DefaultLanguage when empty
+	Language     string           `json:"language,omitempty"`
+	// WordFilter is how strictly offensive words are kept out of the game
+	WordFilter   WordFilter       `json:"word_filter,omitempty"`
+	// SpellingPolicy is which of a word's accepted spellings count,
+	// SpellingLenient when empty
+	SpellingPolicy SpellingPolicy `json:"spelling_policy,omitempty"`
+	// WordList is the ID of one of the host's word lists that a private
+	// game draws its words from instead of the word bank, in WordOrder
+	WordList     *string          `json:"word_list,omitempty"`
+	WordOrder    WordOrder        `json:"word_order,omitempty"`
+	// AnswerWindow is how long players have to answer each word, longer
+	// the longer and harder the word
+	AnswerWindow AnswerWindowSettings `json:"answer_window"`
+	// ReadyCheckTimeout is how long players have to confirm they're ready
+	// once the host runs a ready check. Zero means DefaultReadyCheckTimeout.
+	ReadyCheckTimeout time.Duration `json:"ready_check_timeout"`
+	// ScheduledAt, when set, is when the game starts on its own. Its lobby
+	// opens LobbyOpensBefore then, DefaultLobbyOpensBefore when zero.
+	ScheduledAt      *time.Time    `json:"scheduled_at,omitempty"`
+	LobbyOpensBefore time.Duration `json:"lobby_opens_before,omitempty"`
 }
 
 // Player represents a player in a game
@@ -131,12 +244,16 @@ type Player struct {
 	Attempts int       `json:"attempts" db:"attempts"`
 	Correct  int       `json:"correct" db:"correct"`
 	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
+	// Team numbers the player's team in a team relay, from 0
+	Team     *int      `json:"team,omitempty" db:"team"`
 }
 
 // Hint represents a hint provided during the game
 type Hint struct {
-	Type    HintType `json:"type"`
-	Content string   `json:"content"`
+	Type      HintType `json:"type"`
+	Content   string   `json:"content"`
+	Remaining int      `json:"remaining"`
+	Penalty   int      `json:"penalty,omitempty"`
 }
 
 // SpellingAttempt represents a player's attempt to spell a word
@@ -146,22 +263,72 @@ type SpellingAttempt struct {
 	PlayerID  string      `json:"player_id" db:"player_id"`
 	Word      string      `json:"word" db:"word"`
 	Type      AttemptType `json:"type" db:"type"`
-	VoiceData []byte      `json:"voice_data,omitempty" db:"voice_data"`
+	VoiceData []byte      `json:"-" db:"voice_data"`
 	Text      string      `json:"text,omitempty" db:"text"`
 	IsCorrect bool        `json:"is_correct" db:"is_correct"`
 	Timestamp time.Time   `json:"timestamp" db:"timestamp"`
+
+	// Voice attempts the recogniser wasn't sure about wait for a standby
+	// judge before they are ruled on
+	Status     AttemptStatus `json:"status" db:"status"`
+	Ruling     RulingSource  `json:"ruling,omitempty" db:"ruling"`
+	Confidence *float64      `json:"confidence,omitempty" db:"confidence"`
+	JudgeID    *string       `json:"judge_id,omitempty" db:"judge_id"`
+
+	// AnswerMS is how long after the turn started the attempt came in, and
+	// HintsUsed how many hints the player had taken on the word by then
+	AnswerMS  *int64 `json:"answer_ms,omitempty" db:"answer_ms"`
+	HintsUsed int    `json:"hints_used" db:"hints_used"`
+
+	// Match is how close the attempt came and Points what it scored
+	Match  AttemptMatch `json:"match,omitempty" db:"match"`
+	Points int          `json:"points" db:"points"`
+
+	// Raw audio is only ever referenced by its encrypted S3 object and is
+	// left out of JSON so it can't leak through events or exports
+	VoiceKey       *string    `json:"-" db:"voice_s3_key"`
+	VoiceExpiresAt *time.Time `json:"-" db:"voice_expires_at"`
 }
 
+// AttemptStatus is where an attempt is in judging
+type AttemptStatus string
+
+const (
+	AttemptStatusJudged        AttemptStatus = "judged"
+	AttemptStatusPendingReview AttemptStatus = "pending_review"
+	// AttemptStatusPendingConfirmation attempts wait on the player to
+	// confirm their transcription, and aren't recorded until they're judged
+	AttemptStatusPendingConfirmation AttemptStatus = "pending_confirmation"
+)
+
+// RulingSource records who decided an attempt
+type RulingSource string
+
+const (
+	RulingAutomatic RulingSource = "automatic"
+	RulingJudge     RulingSource = "judge"
+	// RulingFallback is the automatic ruling applied after no judge
+	// responded in time
+	RulingFallback RulingSource = "fallback"
+	// RulingAppeal is an incorrect ruling overturned on appeal
+	RulingAppeal RulingSource = "appeal"
+)
+
 // AttemptType represents the type of spelling attempt
 type AttemptType string
 
 const (
 	AttemptTypeText  AttemptType = "text"
 	AttemptTypeVoice AttemptType = "voice"
+	// AttemptTypeLetters attempts are spelled letter by letter over the
+	// game WebSocket
+	AttemptTypeLetters AttemptType = "letters"
 )
 
 // GameEvent represents an event that occurred during a game
 type GameEvent struct {
+	// Seq numbers the game's events in the order they happened, from 1
+	Seq       int64            `json:"seq"`
 	Type      EventType         `json:"type"`
 	GameID    string           `json:"game_id"`
 	PlayerID  *string          `json:"player_id,omitempty"`
@@ -171,20 +338,53 @@ type GameEvent struct {
 
 const (
 	DefaultHintsAllowed = 3
+	DefaultHintPenalty = modes.DefaultHintPenalty
 	DefaultSpellStartTimeout = 10 * time.Second
 )
 
+// HintBudget is the number of hints each player gets per round
+func (g GameSettings) HintBudget() int {
+	if g.HintsAllowed <= 0 {
+		return DefaultHintsAllowed
+	}
+	return g.HintsAllowed
+}
+
+// LevelFor is the word level of round, counting from 1. Games without a
+// difficulty curve stay at WordLevel.
+func (g GameSettings) LevelFor(round int) int {
+	if g.Difficulty == nil {
+		return g.WordLevel
+	}
+	return g.Difficulty.WithDefaults(g.Mode, g.WordLevel).Level(round)
+}
+
+// HintCost is the score deducted for each hint used. The penalty in the
+// scoring rules wins over the older top-level setting.
+func (g GameSettings) HintCost() int {
+	if g.Scoring.HintPenalty == nil && g.HintPenalty != nil {
+		return *g.HintPenalty
+	}
+	return *g.scoring().HintPenalty
+}
+
 // GameResult represents the outcome of a game for a player
 type GameResult struct {
 	ID                string    `json:"id" db:"id"`
 	GameID            string    `json:"game_id" db:"game_id"`
 	PlayerID          string    `json:"player_id" db:"player_id"`
+	// Placement is the team's placement in team games, which Team is set in
 	Placement         int       `json:"placement" db:"placement"`
+	Team              *int      `json:"team,omitempty" db:"team"`
 	PointsEarned      int       `json:"points_earned" db:"points_earned"`
 	PreviousRankPoints int      `json:"previous_rank_points" db:"previous_rank_points"`
 	NewRankPoints     int       `json:"new_rank_points" db:"new_rank_points"`
 	PreviousRankColor string    `json:"previous_rank_color" db:"previous_rank_color"`
 	NewRankColor      string    `json:"new_rank_color" db:"new_rank_color"`
+	// PreviousELO and NewELO are nil on results from before ranked games
+	// moved ELO
+	PreviousELO       *int      `json:"previous_elo" db:"previous_elo"`
+	NewELO            *int      `json:"new_elo" db:"new_elo"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -204,3 +404,12 @@ type GameRecording struct {
 func (g GameSettings) Value() (interface{}, error) {
 	return json.Marshal(g)
 }
+
+// Scan implements the sql.Scanner interface for GameSettings
+func (g *GameSettings) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, g)
+}
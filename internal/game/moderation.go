@@ -0,0 +1,58 @@
+package game
+
+import (
+	"context"
+
+	"big-spella-go/internal/audit"
+)
+
+// CancelGame lets an admin call off a game that hasn't finished, without
+// recording results for anyone
+func (s *gameService) CancelGame(ctx context.Context, gameID, reason string) (*Game, error) {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Status == GameStatusFinished || game.Status == GameStatusCancelled {
+		return nil, ErrInvalidGameState
+	}
+
+	if err := s.cancelGame(ctx, game, reason); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// EndGame lets an admin finish a game in progress early. Players are placed
+// on the points they have so far, and ranked games award ranking points as
+// if the game had played out.
+func (s *gameService) EndGame(ctx context.Context, gameID, reason string) (*Game, error) {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Status != GameStatusActive && game.Status != GameStatusPaused {
+		return nil, ErrInvalidGameState
+	}
+
+	previous := game.Status
+	if err := s.finishGame(ctx, game); err != nil {
+		return nil, err
+	}
+	s.record(ctx, audit.Event{
+		Action:     audit.ActionGameEnded,
+		TargetType: "game",
+		TargetID:   game.ID,
+		Before:     map[string]any{"status": previous},
+		After:      map[string]any{"status": game.Status, "reason": reason},
+	})
+	return game, nil
+}
@@ -0,0 +1,74 @@
+package modes
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxDifficultyIncrement caps how many levels harder each round can get
+const MaxDifficultyIncrement = 3
+
+// DifficultyCurve makes words harder round by round, as they get in a real
+// bee. Anything left unset takes the default for the game's mode.
+type DifficultyCurve struct {
+	// Start is the level of the first round, the game's word level when 0
+	Start int `json:"start,omitempty"`
+	// Increment is how many levels each round is harder than the last. A
+	// fraction goes up a level every few rounds, so 0.5 is every other
+	// round.
+	Increment float64 `json:"increment,omitempty"`
+	// Cap is the hardest level the curve climbs to
+	Cap int `json:"cap,omitempty"`
+}
+
+// DefaultDifficulty is the curve of each game mode. Round robins climb
+// like a bee; rapid fire, played against the clock, climbs slowest.
+func DefaultDifficulty(mode GameMode) DifficultyCurve {
+	switch mode {
+	case ModeRoundRobin:
+		return DifficultyCurve{Increment: 1, Cap: 10}
+	case ModeRapidFire:
+		return DifficultyCurve{Increment: 0.25, Cap: 6}
+	default:
+		return DifficultyCurve{Increment: 0.5, Cap: 8}
+	}
+}
+
+// WithDefaults fills in whatever c leaves unset from mode's defaults,
+// starting at wordLevel
+func (c DifficultyCurve) WithDefaults(mode GameMode, wordLevel int) DifficultyCurve {
+	defaults := DefaultDifficulty(mode)
+	if c.Start == 0 {
+		c.Start = wordLevel
+	}
+	if c.Increment == 0 {
+		c.Increment = defaults.Increment
+	}
+	if c.Cap == 0 {
+		c.Cap = max(defaults.Cap, c.Start)
+	}
+	return c
+}
+
+// Level is the word level of round, counting from 1
+func (c DifficultyCurve) Level(round int) int {
+	level := c.Start + int(math.Floor(c.Increment*float64(max(round-1, 0))))
+	return max(min(level, c.Cap), 1)
+}
+
+// Validate checks the curve's levels are levels and that it climbs
+func (c DifficultyCurve) Validate() error {
+	if c.Start != 0 && (c.Start < 1 || c.Start > 10) {
+		return fmt.Errorf("difficulty start must be between 1-10")
+	}
+	if c.Cap != 0 && (c.Cap < 1 || c.Cap > 10) {
+		return fmt.Errorf("difficulty cap must be between 1-10")
+	}
+	if c.Cap != 0 && c.Cap < c.Start {
+		return fmt.Errorf("difficulty cap must not be below its start")
+	}
+	if c.Increment < 0 || c.Increment > MaxDifficultyIncrement {
+		return fmt.Errorf("difficulty increment must be between 0-3 levels a round")
+	}
+	return nil
+}
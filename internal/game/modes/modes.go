@@ -12,6 +12,18 @@ const (
 	ModeRoundRobin GameMode = "round_robin" // Tournament default
 	ModeRapidFire  GameMode = "rapid_fire"  // 1v1 speed spelling
 	ModeTotalGame  GameMode = "total_game"  // Time/round-based points
+	ModeTeamRelay  GameMode = "team_relay"  // Teams relay words or letters
+)
+
+// RelayStyle is how a team's members share its turns in a team relay
+type RelayStyle string
+
+const (
+	// RelayWords has members take the team's words in turn
+	RelayWords RelayStyle = "words"
+	// RelayLetters has members take turns at the letters of each word,
+	// spelled one at a time
+	RelayLetters RelayStyle = "letters"
 )
 
 // GameSettings represents the configuration for a game
@@ -27,6 +39,14 @@ type GameSettings struct {
 	EnableVideo      bool           `json:"enable_video"`
 	EnableVoice      bool           `json:"enable_voice"`
 	RecordGame       bool           `json:"record_game"`
+	Scoring          ScoringConfig  `json:"scoring"`
+	// Teams and Relay are how a team relay's players are grouped and take
+	// turns
+	Teams            int            `json:"teams,omitempty"`
+	Relay            RelayStyle     `json:"relay,omitempty"`
+	// Difficulty, when set, makes words harder each round instead of
+	// keeping them at WordLevel
+	Difficulty       *DifficultyCurve `json:"difficulty,omitempty"`
 }
 
 // DefaultSettings returns default settings for each game mode
@@ -36,6 +56,7 @@ func DefaultSettings(mode GameMode) GameSettings {
 		WordLevel:     1,
 		EnableVoice:   true,
 		RecordGame:    false,
+		Scoring:       DefaultScoring(mode),
 	}
 
 	switch mode {
@@ -54,6 +75,12 @@ func DefaultSettings(mode GameMode) GameSettings {
 		base.MaxRounds = 20
 		base.TimeLimit = 30 * time.Minute
 		base.EnableVideo = true
+
+	case ModeTeamRelay:
+		base.MaxPlayers = 16
+		base.MaxRounds = 12
+		base.Teams = 2
+		base.Relay = RelayWords
 	}
 
 	return base
@@ -85,32 +112,44 @@ func ValidateSettings(settings GameSettings) error {
 		if settings.TimeLimit < 5*time.Minute || settings.TimeLimit > time.Hour {
 			return fmt.Errorf("total game time limit must be between 5-60 minutes")
 		}
+
+	case ModeTeamRelay:
+		if settings.Teams < 2 || settings.Teams > 8 {
+			return fmt.Errorf("team relay requires 2-8 teams")
+		}
+		if settings.MaxPlayers < 2*settings.Teams || settings.MaxPlayers > 32 {
+			return fmt.Errorf("team relay requires 2-32 players, at least 2 per team")
+		}
+		if settings.MaxRounds < 1 {
+			return fmt.Errorf("team relay requires at least 1 round")
+		}
+		if settings.Relay != RelayWords && settings.Relay != RelayLetters {
+			return fmt.Errorf("team relay must relay words or letters")
+		}
 	}
 
 	if settings.WordLevel < 1 || settings.WordLevel > 10 {
 		return fmt.Errorf("word level must be between 1-10")
 	}
 
-	return nil
+	if settings.Difficulty != nil {
+		if err := settings.Difficulty.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return settings.Scoring.Validate()
 }
 
-// CalculateScore calculates the score based on game mode and performance
+// CalculateScore calculates the score based on game mode and performance,
+// using the mode's default scoring rules
 func CalculateScore(mode GameMode, correctAttempts, totalAttempts int, averageTime float64) int {
-	baseScore := correctAttempts * 100
+	config := DefaultScoring(mode)
+	averageAnswer := time.Duration(averageTime * float64(time.Second))
 
-	switch mode {
-	case ModeRapidFire:
-		if averageTime < 5.0 {
-			return int(float64(baseScore) * 1.5) // Speed bonus
-		}
-	case ModeTotalGame:
-		accuracy := float64(correctAttempts) / float64(totalAttempts)
-		if accuracy >= 0.9 {
-			return int(float64(baseScore) * 1.3) // Accuracy bonus
-		}
-	}
-
-	return baseScore
+	return Apply(correctAttempts*config.PointsPerCorrect,
+		config.SpeedMultiplier(averageAnswer),
+		config.AccuracyMultiplier(correctAttempts, totalAttempts))
 }
 
 // IsCompetitive returns whether a game mode affects ranking
@@ -122,3 +161,36 @@ func IsCompetitive(settings GameSettings) bool {
 func RequiresRecording(settings GameSettings) bool {
 	return settings.RecordGame && !settings.IsPrivate && settings.IsTournament
 }
+
+// AssignTeams deals players into teams in the order given, so teams differ
+// in size by at most one
+func AssignTeams(players []string, teams int) [][]string {
+	assigned := make([][]string, teams)
+	for i, player := range players {
+		assigned[i%teams] = append(assigned[i%teams], player)
+	}
+	return assigned
+}
+
+// RelayOrder is the turn order of a team relay: teams take alternate words,
+// and each team's members take its words in turn. Members of a smaller team
+// take more turns, so every team spells as many words. Empty teams are
+// left out.
+func RelayOrder(teams [][]string) []string {
+	var playing [][]string
+	longest := 0
+	for _, team := range teams {
+		if len(team) > 0 {
+			playing = append(playing, team)
+			longest = max(longest, len(team))
+		}
+	}
+
+	order := make([]string, 0, longest*len(playing))
+	for turn := 0; turn < longest; turn++ {
+		for _, team := range playing {
+			order = append(order, team[turn%len(team)])
+		}
+	}
+	return order
+}
@@ -24,6 +24,7 @@ func TestDefaultSettings(t *testing.T) {
 				EnableVideo: true,
 				EnableVoice: true,
 				RecordGame: false,
+				Scoring:    DefaultScoring(ModeRoundRobin),
 			},
 		},
 		{
@@ -37,6 +38,7 @@ func TestDefaultSettings(t *testing.T) {
 				EnableVideo: true,
 				EnableVoice: true,
 				RecordGame: false,
+				Scoring:    DefaultScoring(ModeRapidFire),
 			},
 		},
 		{
@@ -51,6 +53,21 @@ func TestDefaultSettings(t *testing.T) {
 				EnableVideo: true,
 				EnableVoice: true,
 				RecordGame: false,
+				Scoring:    DefaultScoring(ModeTotalGame),
+			},
+		},
+		{
+			name: "Team Relay defaults",
+			mode: ModeTeamRelay,
+			want: GameSettings{
+				Mode:        ModeTeamRelay,
+				MaxPlayers:  16,
+				MaxRounds:   12,
+				WordLevel:   1,
+				EnableVoice: true,
+				Scoring:     DefaultScoring(ModeTeamRelay),
+				Teams:       2,
+				Relay:       RelayWords,
 			},
 		},
 	}
@@ -119,6 +136,74 @@ func TestValidateSettings(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Valid Team Relay",
+			settings: GameSettings{
+				Mode:       ModeTeamRelay,
+				MaxPlayers: 12,
+				MaxRounds:  6,
+				WordLevel:  2,
+				Teams:      3,
+				Relay:      RelayLetters,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Team Relay with too few players per team",
+			settings: GameSettings{
+				Mode:       ModeTeamRelay,
+				MaxPlayers: 5,
+				MaxRounds:  6,
+				WordLevel:  2,
+				Teams:      3,
+				Relay:      RelayWords,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Team Relay without a relay style",
+			settings: GameSettings{
+				Mode:       ModeTeamRelay,
+				MaxPlayers: 8,
+				MaxRounds:  6,
+				WordLevel:  2,
+				Teams:      2,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Round Robin climbing a level a round",
+			settings: GameSettings{
+				Mode:       ModeRoundRobin,
+				MaxPlayers: 16,
+				MaxRounds:  10,
+				WordLevel:  2,
+				Difficulty: &DifficultyCurve{Start: 2, Increment: 1, Cap: 9},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Difficulty capped below its start",
+			settings: GameSettings{
+				Mode:       ModeRoundRobin,
+				MaxPlayers: 16,
+				MaxRounds:  10,
+				WordLevel:  2,
+				Difficulty: &DifficultyCurve{Start: 6, Cap: 4},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Difficulty climbing too fast",
+			settings: GameSettings{
+				Mode:       ModeTotalGame,
+				MaxPlayers: 6,
+				TimeLimit:  30 * time.Minute,
+				WordLevel:  1,
+				Difficulty: &DifficultyCurve{Increment: 4},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Invalid word level",
 			settings: GameSettings{
@@ -143,6 +228,28 @@ func TestValidateSettings(t *testing.T) {
 	}
 }
 
+func TestDifficultyCurve(t *testing.T) {
+	curve := DifficultyCurve{}.WithDefaults(ModeRoundRobin, 3)
+	assert.Equal(t, DifficultyCurve{Start: 3, Increment: 1, Cap: 10}, curve)
+
+	levels := []int{}
+	for round := 1; round <= 10; round++ {
+		levels = append(levels, curve.Level(round))
+	}
+	assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 9, 10, 10, 10}, levels)
+
+	// Half a level a round goes up every other round
+	curve = DifficultyCurve{Increment: 0.5, Cap: 4}.WithDefaults(ModeTotalGame, 1)
+	levels = levels[:0]
+	for round := 1; round <= 8; round++ {
+		levels = append(levels, curve.Level(round))
+	}
+	assert.Equal(t, []int{1, 1, 2, 2, 3, 3, 4, 4}, levels)
+
+	// A game starting above the mode's cap stays at its level
+	assert.Equal(t, 9, DifficultyCurve{}.WithDefaults(ModeRapidFire, 9).Level(5))
+}
+
 func TestCalculateScore(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -158,7 +265,7 @@ func TestCalculateScore(t *testing.T) {
 			correctAttempts: 5,
 			totalAttempts:   7,
 			averageTime:     6.0,
-			expected:        500,
+			expected:        50,
 		},
 		{
 			name:            "Rapid Fire fast answers",
@@ -166,7 +273,7 @@ func TestCalculateScore(t *testing.T) {
 			correctAttempts: 5,
 			totalAttempts:   6,
 			averageTime:     3.0,
-			expected:        750, // 500 * 1.5 for speed bonus
+			expected:        75, // 50 * 1.5 for speed bonus
 		},
 		{
 			name:            "Total Game high accuracy",
@@ -174,7 +281,7 @@ func TestCalculateScore(t *testing.T) {
 			correctAttempts: 9,
 			totalAttempts:   10,
 			averageTime:     7.0,
-			expected:        1170, // 900 * 1.3 for accuracy bonus
+			expected:        117, // 90 * 1.3 for accuracy bonus
 		},
 	}
 
@@ -185,3 +292,53 @@ func TestCalculateScore(t *testing.T) {
 		})
 	}
 }
+
+func TestScoringConfig(t *testing.T) {
+	penalty := 0
+	config := ScoringConfig{
+		SpeedBonuses: []SpeedBonus{},
+		HintPenalty:  &penalty,
+	}.WithDefaults(ModeRapidFire)
+
+	assert.Equal(t, DefaultPointsPerCorrect, config.PointsPerCorrect)
+	assert.Empty(t, config.SpeedBonuses, "an empty list turns the mode's bonus off")
+	assert.Equal(t, 0, *config.HintPenalty)
+
+	config = DefaultScoring(ModeTotalGame)
+	assert.Equal(t, 1.0, config.StreakMultiplier(2))
+	assert.Equal(t, 1.2, config.StreakMultiplier(4))
+	assert.Equal(t, 1.5, config.StreakMultiplier(9))
+
+	config = DefaultScoring(ModeRapidFire)
+	assert.Equal(t, 1.5, config.SpeedMultiplier(3*time.Second))
+	assert.Equal(t, 1.0, config.SpeedMultiplier(6*time.Second))
+	assert.Equal(t, 18, Apply(10, 1.5, 1.2))
+}
+
+func TestValidateScoringConfig(t *testing.T) {
+	assert.NoError(t, DefaultScoring(ModeTotalGame).Validate())
+
+	penalty := -1
+	invalid := []ScoringConfig{
+		{PointsPerCorrect: -10},
+		{SpeedBonuses: []SpeedBonus{{Within: 0, Multiplier: 2}}},
+		{SpeedBonuses: []SpeedBonus{{Within: time.Second, Multiplier: 0.5}}},
+		{StreakMultipliers: []StreakMultiplier{{Streak: 1, Multiplier: 2}}},
+		{AccuracyBonus: &AccuracyBonus{Threshold: 1.5, Multiplier: 2}},
+		{HintPenalty: &penalty},
+	}
+	for _, config := range invalid {
+		assert.Error(t, config.Validate(), "%+v", config)
+	}
+}
+
+func TestAssignTeams(t *testing.T) {
+	teams := AssignTeams([]string{"ada", "bo", "cy", "dee", "eve"}, 2)
+	assert.Equal(t, [][]string{{"ada", "cy", "eve"}, {"bo", "dee"}}, teams)
+}
+
+func TestRelayOrder(t *testing.T) {
+	order := RelayOrder([][]string{{"ada", "cy", "eve"}, {"bo", "dee"}, {}})
+	// bo spells twice so both teams spell as many words
+	assert.Equal(t, []string{"ada", "bo", "cy", "dee", "eve", "bo"}, order)
+}
@@ -0,0 +1,167 @@
+package modes
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	DefaultPointsPerCorrect = 10
+	DefaultHintPenalty      = 10
+)
+
+// SpeedBonus multiplies the points for a word spelled within Within of the
+// answer window opening
+type SpeedBonus struct {
+	Within     time.Duration `json:"within"`
+	Multiplier float64       `json:"multiplier"`
+}
+
+// StreakMultiplier multiplies the points for a word once it makes Streak
+// words in a row a player has spelled correctly
+type StreakMultiplier struct {
+	Streak     int     `json:"streak"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// AccuracyBonus multiplies a player's final score when at least Threshold
+// of their attempts, from 0 to 1, were correct
+type AccuracyBonus struct {
+	Threshold  float64 `json:"threshold"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// ScoringConfig is the set of rules a game is scored by. Anything left
+// unset takes the default for the game's mode, so an empty list is needed
+// to turn a mode's bonuses off.
+type ScoringConfig struct {
+	PointsPerCorrect  int                `json:"points_per_correct,omitempty"`
+	SpeedBonuses      []SpeedBonus       `json:"speed_bonuses,omitempty"`
+	StreakMultipliers []StreakMultiplier `json:"streak_multipliers,omitempty"`
+	AccuracyBonus     *AccuracyBonus     `json:"accuracy_bonus,omitempty"`
+	// HintPenalty is the score deducted for each hint used
+	HintPenalty *int `json:"hint_penalty,omitempty"`
+}
+
+// DefaultScoring returns the scoring rules for each game mode
+func DefaultScoring(mode GameMode) ScoringConfig {
+	hintPenalty := DefaultHintPenalty
+	base := ScoringConfig{
+		PointsPerCorrect:  DefaultPointsPerCorrect,
+		SpeedBonuses:      []SpeedBonus{},
+		StreakMultipliers: []StreakMultiplier{},
+		HintPenalty:       &hintPenalty,
+	}
+
+	switch mode {
+	case ModeRapidFire:
+		base.SpeedBonuses = []SpeedBonus{{Within: 5 * time.Second, Multiplier: 1.5}}
+
+	case ModeTotalGame:
+		base.StreakMultipliers = []StreakMultiplier{{Streak: 3, Multiplier: 1.2}, {Streak: 5, Multiplier: 1.5}}
+		base.AccuracyBonus = &AccuracyBonus{Threshold: 0.9, Multiplier: 1.3}
+	}
+
+	return base
+}
+
+// WithDefaults fills in whatever c leaves unset from mode's defaults
+func (c ScoringConfig) WithDefaults(mode GameMode) ScoringConfig {
+	defaults := DefaultScoring(mode)
+	if c.PointsPerCorrect == 0 {
+		c.PointsPerCorrect = defaults.PointsPerCorrect
+	}
+	if c.SpeedBonuses == nil {
+		c.SpeedBonuses = defaults.SpeedBonuses
+	}
+	if c.StreakMultipliers == nil {
+		c.StreakMultipliers = defaults.StreakMultipliers
+	}
+	if c.AccuracyBonus == nil {
+		c.AccuracyBonus = defaults.AccuracyBonus
+	}
+	if c.HintPenalty == nil {
+		c.HintPenalty = defaults.HintPenalty
+	}
+	return c
+}
+
+// Validate checks the rules a player asked for
+func (c ScoringConfig) Validate() error {
+	if c.PointsPerCorrect < 0 || c.PointsPerCorrect > 1000 {
+		return fmt.Errorf("points per correct word must be between 0-1000")
+	}
+	for _, bonus := range c.SpeedBonuses {
+		if bonus.Within <= 0 {
+			return fmt.Errorf("speed bonus thresholds must be positive")
+		}
+		if bonus.Multiplier < 1 || bonus.Multiplier > 5 {
+			return fmt.Errorf("speed bonus multipliers must be between 1-5")
+		}
+	}
+	for _, streak := range c.StreakMultipliers {
+		if streak.Streak < 2 {
+			return fmt.Errorf("streaks must be at least 2 words long")
+		}
+		if streak.Multiplier < 1 || streak.Multiplier > 5 {
+			return fmt.Errorf("streak multipliers must be between 1-5")
+		}
+	}
+	if b := c.AccuracyBonus; b != nil {
+		if b.Threshold <= 0 || b.Threshold > 1 {
+			return fmt.Errorf("accuracy bonus threshold must be between 0-1")
+		}
+		if b.Multiplier < 1 || b.Multiplier > 5 {
+			return fmt.Errorf("accuracy bonus multiplier must be between 1-5")
+		}
+	}
+	if c.HintPenalty != nil && *c.HintPenalty < 0 {
+		return fmt.Errorf("hint penalty must not be negative")
+	}
+	return nil
+}
+
+// SpeedMultiplier is the best speed bonus a word spelled in answerTime earns
+func (c ScoringConfig) SpeedMultiplier(answerTime time.Duration) float64 {
+	multiplier := 1.0
+	for _, bonus := range c.SpeedBonuses {
+		if answerTime <= bonus.Within && bonus.Multiplier > multiplier {
+			multiplier = bonus.Multiplier
+		}
+	}
+	return multiplier
+}
+
+// StreakMultiplier is the best multiplier a run of streak correct words
+// reaches
+func (c ScoringConfig) StreakMultiplier(streak int) float64 {
+	multiplier := 1.0
+	for _, s := range c.StreakMultipliers {
+		if streak >= s.Streak && s.Multiplier > multiplier {
+			multiplier = s.Multiplier
+		}
+	}
+	return multiplier
+}
+
+// AccuracyMultiplier is what a player's final score is multiplied by for
+// spelling correct of their total attempts
+func (c ScoringConfig) AccuracyMultiplier(correct, total int) float64 {
+	if c.AccuracyBonus == nil || total == 0 {
+		return 1
+	}
+	if float64(correct)/float64(total) >= c.AccuracyBonus.Threshold {
+		return c.AccuracyBonus.Multiplier
+	}
+	return 1
+}
+
+// Apply multiplies points, rounding to the nearest whole point
+func Apply(points int, multipliers ...float64) int {
+	total := float64(points)
+	for _, m := range multipliers {
+		total *= m
+	}
+	return int(math.Round(total))
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const DefaultInterRoundDelay = 5 * time.Second
+
+// PacingSettings controls the pause between rounds
+type PacingSettings struct {
+	// InterRoundDelay is how long the intermission lasts before the next
+	// round starts on its own. Zero means DefaultInterRoundDelay.
+	InterRoundDelay time.Duration `json:"inter_round_delay"`
+	// RequireHostAdvance holds the intermission until the host advances it
+	RequireHostAdvance bool `json:"require_host_advance"`
+	// AutoAdvanceAfter advances a host-held intermission anyway once it
+	// elapses. Zero waits for the host indefinitely.
+	AutoAdvanceAfter time.Duration `json:"auto_advance_after"`
+}
+
+func (p PacingSettings) delay() time.Duration {
+	if p.RequireHostAdvance {
+		return p.AutoAdvanceAfter
+	}
+	if p.InterRoundDelay <= 0 {
+		return DefaultInterRoundDelay
+	}
+	return p.InterRoundDelay
+}
+
+// Intermission describes the break between two rounds
+type Intermission struct {
+	Round        int        `json:"round"`
+	StartedAt    time.Time  `json:"started_at"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	AwaitingHost bool       `json:"awaiting_host"`
+}
+
+func (s *gameService) beginIntermission(ctx context.Context, game *Game) {
+	engine := s.engine(game.ID)
+	if engine == nil {
+		return
+	}
+
+	pacing := game.Settings.Pacing
+	intermission := &Intermission{
+		Round:        game.Round,
+		StartedAt:    time.Now(),
+		AwaitingHost: pacing.RequireHostAdvance,
+	}
+
+	if delay := pacing.delay(); delay > 0 {
+		endsAt := intermission.StartedAt.Add(delay)
+		intermission.EndsAt = &endsAt
+		s.scheduleIntermission(game.ID, delay)
+	}
+
+	engine.Intermission = intermission
+
+	s.emitEvent(EventTypeRoundEnded, game.ID, nil, map[string]any{
+		"round": game.Round,
+	})
+	s.emitRoundSummary(ctx, game, engine, intermission.EndsAt)
+	if s.rounds != nil && game.Settings.IsTournament {
+		s.rounds.RoundCompleted(ctx, game)
+	}
+	s.emitEvent(EventTypeIntermissionStarted, game.ID, nil, map[string]any{
+		"intermission": intermission,
+	})
+}
+
+func (s *gameService) scheduleIntermission(gameID string, delay time.Duration) {
+	s.timers.Schedule(timerKey(gameID, "intermission"), delay, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		ctx, unlock := s.lockEngine(ctx, gameID)
+		defer unlock()
+		_ = s.endIntermission(ctx, gameID)
+	})
+}
+
+// AdvanceRound lets the host end the intermission early
+func (s *gameService) AdvanceRound(ctx context.Context, gameID string, userID string) error {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	if game.HostID != userID {
+		return ErrNotHost
+	}
+
+	if game.Status == GameStatusPaused {
+		return ErrGamePaused
+	}
+
+	return s.endIntermission(ctx, gameID)
+}
+
+func (s *gameService) endIntermission(ctx context.Context, gameID string) error {
+	engine := s.engine(gameID)
+	if engine == nil || engine.Intermission == nil {
+		return ErrInvalidGameState
+	}
+
+	s.timers.Cancel(timerKey(gameID, "intermission"))
+	engine.Intermission = nil
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	s.emitEvent(EventTypeIntermissionEnded, gameID, nil, map[string]any{
+		"round": game.Round,
+	})
+
+	if roundsComplete(game) {
+		return s.finishGame(ctx, game)
+	}
+
+	// A game that has spelled every word it could be served is over
+	err = s.nextTurn(ctx, game)
+	if errors.Is(err, ErrNoWordsAvailable) {
+		return s.finishGame(ctx, game)
+	}
+	return err
+}
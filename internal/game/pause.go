@@ -0,0 +1,128 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"big-spella-go/internal/queries"
+)
+
+var ErrGamePaused = errors.New("game is paused")
+
+// PauseGame lets the host freeze the game. Turn and intermission clocks stop
+// and attempts are rejected until the host resumes.
+func (s *gameService) PauseGame(ctx context.Context, gameID string, userID string) (*Game, error) {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.HostID != userID {
+		return nil, ErrNotHost
+	}
+
+	if game.Status != GameStatusActive {
+		return nil, ErrInvalidGameState
+	}
+
+	engine := s.engine(gameID)
+	if engine == nil {
+		return nil, ErrGameNotFound
+	}
+
+	s.timers.Cancel(timerKey(gameID, "turn"))
+	s.timers.Cancel(timerKey(gameID, "listening"))
+	s.timers.Cancel(timerKey(gameID, "intermission"))
+
+	now := time.Now()
+	engine.PausedAt = &now
+
+	if err := s.updateGame(ctx, game, func(latest *Game) bool {
+		return latest.Status == GameStatusActive
+	}, func() error {
+		return s.queries.PauseGame(ctx, game, queries.PauseGameParams{
+			Status: string(GameStatusPaused), Now: now, ID: game.ID, Version: game.Version,
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("failed to pause game: %w", err)
+	}
+
+	s.emitEvent(EventTypeGamePaused, gameID, &userID, map[string]any{
+		"paused_at": now,
+	})
+
+	return game, nil
+}
+
+// ResumeGame restarts a paused game, giving back whatever was left on the
+// turn or intermission clock when it was paused
+func (s *gameService) ResumeGame(ctx context.Context, gameID string, userID string) (*Game, error) {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.HostID != userID {
+		return nil, ErrNotHost
+	}
+
+	if game.Status != GameStatusPaused {
+		return nil, ErrInvalidGameState
+	}
+
+	engine := s.engine(gameID)
+	if engine == nil {
+		return nil, ErrGameNotFound
+	}
+
+	pausedAt := game.PausedAt
+	if engine.PausedAt != nil {
+		pausedAt = engine.PausedAt
+	}
+	var pausedFor time.Duration
+	if pausedAt != nil {
+		pausedFor = time.Since(*pausedAt)
+	}
+	engine.PausedAt = nil
+
+	if engine.TurnStartedAt != nil {
+		startedAt := engine.TurnStartedAt.Add(pausedFor)
+		engine.TurnStartedAt = &startedAt
+	}
+
+	if err := s.updateGame(ctx, game, func(latest *Game) bool {
+		return latest.Status == GameStatusPaused
+	}, func() error {
+		return s.queries.ResumeGame(ctx, game, queries.ResumeGameParams{
+			Status: string(GameStatusActive), TurnStartedAt: engine.TurnStartedAt, Now: time.Now(),
+			ID: game.ID, Version: game.Version,
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("failed to resume game: %w", err)
+	}
+
+	s.emitEvent(EventTypeGameResumed, gameID, &userID, map[string]any{
+		"paused_for": pausedFor,
+	})
+
+	if intermission := engine.Intermission; intermission != nil {
+		if intermission.EndsAt != nil {
+			endsAt := intermission.EndsAt.Add(pausedFor)
+			intermission.EndsAt = &endsAt
+			s.scheduleIntermission(gameID, time.Until(endsAt))
+		}
+		return game, nil
+	}
+
+	s.startPlayerTurn(game, engine)
+
+	return game, nil
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+type pauseStub struct {
+	GameService
+	err error
+}
+
+func (s pauseStub) PauseGame(ctx context.Context, gameID string, userID string) (*Game, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Game{ID: gameID, Status: GameStatusPaused}, nil
+}
+
+func TestPauseGameHandler(t *testing.T) {
+	gameID := uuid.New().String()
+	ps := httprouter.Params{{Key: "gameID", Value: gameID}}
+
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, http.StatusOK},
+		{ErrNotHost, http.StatusForbidden},
+		{ErrInvalidGameState, http.StatusConflict},
+		{fmt.Errorf("failed to pause game: %w", ErrGameChanged), http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		h := NewHandler(pauseStub{err: tt.err})
+		req := httptest.NewRequest(http.MethodPost, "/games/"+gameID+"/pause", nil)
+		req = req.WithContext(auth.SetUserIDInContext(req.Context(), "host"))
+		rec := httptest.NewRecorder()
+
+		h.PauseGame(rec, req, ps)
+
+		assert.Equal(t, tt.code, rec.Code)
+	}
+}
@@ -0,0 +1,295 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"big-spella-go/internal/tracing"
+)
+
+const (
+	DefaultListenTime = 5 * time.Second
+	DefaultMaxReplays = 2
+
+	// maxAudioSize caps how much of a dictionary recording is read
+	maxAudioSize = 5 << 20
+)
+
+var (
+	ErrAnswerWindowClosed = errors.New("the answer window is not open")
+	ErrNoReplaysLeft      = errors.New("no replays of the word are left this turn")
+)
+
+// TurnPhase is where a turn is in the bee's flow: the word is announced,
+// the player listens to it, answers, and the answer may go to a judge
+type TurnPhase string
+
+const (
+	PhaseAnnounce  TurnPhase = "announce"
+	PhaseListening TurnPhase = "listening"
+	PhaseAnswering TurnPhase = "answering"
+	PhaseJudging   TurnPhase = "judging"
+	// PhaseConfirming waits on the player to confirm how their voice attempt
+	// was transcribed
+	PhaseConfirming TurnPhase = "confirming"
+)
+
+// PronunciationSettings has the word read out to the current player before
+// their answer window opens
+type PronunciationSettings struct {
+	Enabled bool `json:"enabled"`
+	// ListenTime is how long the player has with the word before the answer
+	// window opens. Zero means DefaultListenTime.
+	ListenTime time.Duration `json:"listen_time"`
+	// MaxReplays is how many times a player can ask to hear the word again
+	// each turn. Zero means DefaultMaxReplays; a negative value allows none.
+	MaxReplays int `json:"max_replays"`
+}
+
+func (p PronunciationSettings) listenTime() time.Duration {
+	if p.ListenTime <= 0 {
+		return DefaultListenTime
+	}
+	return p.ListenTime
+}
+
+func (p PronunciationSettings) maxReplays() int {
+	switch {
+	case p.MaxReplays < 0:
+		return 0
+	case p.MaxReplays == 0:
+		return DefaultMaxReplays
+	default:
+		return p.MaxReplays
+	}
+}
+
+// openingPhase is the phase every turn starts in
+func (g *GameEngine) openingPhase() TurnPhase {
+	if g.Pronounce {
+		return PhaseAnnounce
+	}
+	return PhaseAnswering
+}
+
+// AcceptingAnswers reports whether the current player's answer window is open
+func (g *GameEngine) AcceptingAnswers() bool {
+	return g.Phase == PhaseAnswering
+}
+
+// OpenAnswerWindow ends the listening phase and starts the answer clock
+func (g *GameEngine) OpenAnswerWindow() {
+	now := time.Now()
+	g.TurnStartedAt = &now
+	g.Phase = PhaseAnswering
+}
+
+// audioClient fetches dictionary recordings of words
+var audioClient = &http.Client{Timeout: 10 * time.Second}
+
+// pronunciationPath is where the current player fetches the word's audio.
+// It is served through the game rather than linked directly, since
+// dictionary audio URLs are named after the word.
+func pronunciationPath(gameID string) string {
+	return fmt.Sprintf("/games/%s/pronunciation", gameID)
+}
+
+// wordAudio returns the current word's recording, generating speech when
+// the dictionary has none. It is kept for the rest of the word's turns.
+func (s *gameService) wordAudio(ctx context.Context, engine *GameEngine) (_ []byte, err error) {
+	s.mu.RLock()
+	word, audio := engine.CurrentWord, engine.audio
+	s.mu.RUnlock()
+	if audio != nil {
+		return audio, nil
+	}
+	if word == nil {
+		return nil, ErrNoWordSet
+	}
+
+	ctx, span := tracing.Start(ctx, "game.wordAudio",
+		tracing.String("word", word.Word), tracing.Bool("audio.cached", word.AudioURL != ""))
+	defer span.EndWith(&err)
+
+	switch {
+	case word.AudioURL != "":
+		audio, err = fetchAudio(ctx, word.AudioURL)
+	case languageOr(word.Language) != DefaultLanguage:
+		audio, err = s.dictService.SynthesizeSpeech(ctx, word.Word, LanguageVoice(word.Language), DefaultSpeechRate)
+	default:
+		audio, err = s.dictService.GenerateAudio(ctx, word.Word)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word audio: %w", err)
+	}
+	if word.AudioURL == "" && word.ID != "" && s.audioJobs != nil {
+		// A failed enqueue only costs another inline generation the next
+		// time the word is served
+		s.audioJobs.Enqueue(ctx, JobWordAudio, WordAudioJob{WordID: word.ID})
+	}
+
+	s.mu.Lock()
+	if engine.CurrentWord == word {
+		engine.audio = audio
+	}
+	s.mu.Unlock()
+	return audio, nil
+}
+
+func fetchAudio(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := audioClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("audio request returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxAudioSize))
+}
+
+// announceWord reads the word out to the current player and opens their
+// answer window once they have had the listening time with it
+func (s *gameService) announceWord(game *Game, engine *GameEngine) {
+	playerID, startedAt := engine.CurrentPlayer(), engine.TurnStartedAt
+	if engine.CurrentWord == nil {
+		return
+	}
+	gameID := game.ID
+	listenTime := game.Settings.Pronunciation.listenTime()
+
+	now := time.Now()
+	s.emitEvent(EventTypeTurnChanged, gameID, &playerID, withCountdown(map[string]any{
+		"player_id": playerID,
+		"round":     game.Round,
+		"phase":     PhaseAnnounce,
+	}, now, listenTime))
+
+	engine.Phase = PhaseListening
+	s.emitEvent(EventTypeWordPronounced, gameID, &playerID, withCountdown(map[string]any{
+		"player_id":    playerID,
+		"audio_path":   pronunciationPath(gameID),
+		"replays_left": game.Settings.Pronunciation.maxReplays() - engine.Replays,
+		"listen_until": now.Add(listenTime),
+	}, now, listenTime))
+
+	s.timers.Schedule(timerKey(gameID, "listening"), listenTime, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		ctx, unlock := s.lockEngine(ctx, gameID)
+		defer unlock()
+
+		engine := s.engine(gameID)
+		if engine == nil || engine.TurnStartedAt != startedAt || engine.Phase != PhaseListening {
+			return
+		}
+		_ = s.openAnswerWindow(ctx, gameID, engine)
+	})
+}
+
+func (s *gameService) openAnswerWindow(ctx context.Context, gameID string, engine *GameEngine) error {
+	engine.OpenAnswerWindow()
+
+	game := &Game{}
+	if err := s.db.GetContext(ctx, game, `
+		UPDATE games
+		SET turn_started_at = $1, updated_at = $1, version = version + 1
+		WHERE id = $2
+		RETURNING *`,
+		engine.TurnStartedAt, gameID); err != nil {
+		return fmt.Errorf("failed to open answer window: %w", err)
+	}
+
+	s.startPlayerTurn(game, engine)
+	return nil
+}
+
+// ReplayWord reads the word out to the current player again, up to the
+// game's replay limit for the turn
+func (s *gameService) ReplayWord(ctx context.Context, gameID, playerID string) (int, error) {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, engine, err := s.listeningTurn(ctx, gameID, playerID)
+	if err != nil {
+		return 0, err
+	}
+
+	maxReplays := game.Settings.Pronunciation.maxReplays()
+	s.mu.Lock()
+	allowed := engine.Replays < maxReplays
+	if allowed {
+		engine.Replays++
+	}
+	left := maxReplays - engine.Replays
+	s.mu.Unlock()
+	if !allowed {
+		return 0, ErrNoReplaysLeft
+	}
+
+	s.emitEvent(EventTypeWordPronounced, gameID, &playerID, map[string]any{
+		"player_id":    playerID,
+		"audio_path":   pronunciationPath(gameID),
+		"replays_left": left,
+		"replay":       true,
+	})
+
+	return left, nil
+}
+
+// PronunciationAudio serves the current word's recording to the player
+// whose turn it is, once it has been announced to them, read out the way
+// their audio preferences ask
+func (s *gameService) PronunciationAudio(ctx context.Context, gameID, playerID string) ([]byte, error) {
+	_, engine, err := s.listeningTurn(ctx, gameID, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.AudioPreferences(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if *prefs == DefaultAudioPreferences() {
+		return s.wordAudio(ctx, engine)
+	}
+	return s.personalAudio(ctx, engine, *prefs)
+}
+
+// listeningTurn checks that playerID's turn in a pronounced game has
+// reached the point where they can hear the word
+func (s *gameService) listeningTurn(ctx context.Context, gameID, playerID string) (*Game, *GameEngine, error) {
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if game.Status == GameStatusPaused {
+		return nil, nil, ErrGamePaused
+	}
+	if game.Status != GameStatusActive || !game.Settings.Pronunciation.Enabled {
+		return nil, nil, ErrInvalidGameState
+	}
+
+	engine := s.engine(gameID)
+	if engine == nil {
+		return nil, nil, ErrGameNotFound
+	}
+	if !engine.IsPlayerTurn(playerID) {
+		return nil, nil, ErrNotPlayerTurn
+	}
+	if engine.Phase != PhaseListening && engine.Phase != PhaseAnswering {
+		return nil, nil, ErrTurnNotActive
+	}
+	return game, engine, nil
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/jobs"
+)
+
+func TestTurnPhases(t *testing.T) {
+	mockDict := new(MockDictionaryService)
+	mockDict.On("GetWordInfo", context.Background(), "bee", "en").Return(&Word{Word: "bee"}, nil)
+
+	engine := NewGameEngine("game-1", mockDict)
+	engine.Pronounce = true
+	require.NoError(t, engine.StartTurn(context.Background(), "bee"))
+	assert.Equal(t, PhaseAnnounce, engine.Phase)
+	assert.False(t, engine.AcceptingAnswers(), "nobody answers before hearing the word")
+
+	announcedAt := engine.TurnStartedAt
+	engine.OpenAnswerWindow()
+	assert.True(t, engine.AcceptingAnswers())
+	assert.NotSame(t, announcedAt, engine.TurnStartedAt, "the answer clock starts when the window opens")
+
+	engine.Replays = 2
+	engine.RestartTurn()
+	assert.Equal(t, PhaseAnnounce, engine.Phase, "the next player hears the word too")
+	assert.Zero(t, engine.Replays)
+
+	engine.EndTurn()
+	assert.False(t, engine.AcceptingAnswers())
+
+	engine.Pronounce = false
+	engine.RestartTurn()
+	assert.True(t, engine.AcceptingAnswers(), "games without pronunciation open straight away")
+}
+
+func TestPronunciationDefaults(t *testing.T) {
+	assert.Equal(t, DefaultListenTime, PronunciationSettings{}.listenTime())
+	assert.Equal(t, DefaultMaxReplays, PronunciationSettings{}.maxReplays())
+	assert.Equal(t, 0, PronunciationSettings{MaxReplays: -1}.maxReplays())
+	assert.Equal(t, 4, PronunciationSettings{MaxReplays: 4}.maxReplays())
+}
+
+func TestWordAudioIsFetchedOncePerWord(t *testing.T) {
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write([]byte("mp3"))
+	}))
+	defer srv.Close()
+
+	s := &gameService{activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	engine.CurrentWord = &Word{Word: "bee", AudioURL: srv.URL}
+
+	for i := 0; i < 2; i++ {
+		audio, err := s.wordAudio(context.Background(), engine)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("mp3"), audio)
+	}
+	assert.Equal(t, 1, fetches)
+}
+
+type queueStub struct {
+	kinds    []string
+	payloads []any
+}
+
+func (q *queueStub) Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error) {
+	q.kinds = append(q.kinds, kind)
+	q.payloads = append(q.payloads, payload)
+	return &jobs.Job{Kind: kind}, nil
+}
+
+func TestWordAudioQueuesCachingOnMiss(t *testing.T) {
+	mockDict := new(MockDictionaryService)
+	mockDict.On("GenerateAudio", context.Background(), "bee").Return([]byte("tts"), nil)
+
+	queue := &queueStub{}
+	s := &gameService{dictService: mockDict, audioJobs: queue, activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	engine.CurrentWord = &Word{ID: "word-1", Word: "bee"}
+
+	audio, err := s.wordAudio(context.Background(), engine)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("tts"), audio)
+	assert.Equal(t, []string{JobWordAudio}, queue.kinds)
+	assert.Equal(t, []any{WordAudioJob{WordID: "word-1"}}, queue.payloads)
+
+	_, err = s.wordAudio(context.Background(), engine)
+	require.NoError(t, err)
+	assert.Len(t, queue.kinds, 1, "the word's audio is kept for its other turns")
+}
+
+func TestWordAudioIsReadInTheWordsLanguage(t *testing.T) {
+	mockDict := new(MockDictionaryService)
+	mockDict.On("SynthesizeSpeech", context.Background(), "abeja", LanguageVoice("es"), DefaultSpeechRate).Return([]byte("tts"), nil)
+
+	s := &gameService{dictService: mockDict, activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	engine.CurrentWord = &Word{Word: "abeja", Language: "es"}
+
+	audio, err := s.wordAudio(context.Background(), engine)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("tts"), audio)
+	mockDict.AssertExpectations(t)
+}
+
+func TestPersonalAudio(t *testing.T) {
+	mockDict := new(MockDictionaryService)
+	mockDict.On("GenerateAudio", context.Background(), "bee").Return([]byte("word"), nil).Once()
+	mockDict.On("SynthesizeSpeech", context.Background(), "bee", DefaultVoice, slowRepeatRate).Return([]byte("-slow"), nil).Once()
+	mockDict.On("SynthesizeSpeech", context.Background(), "bee", "nova", MaxSpeechRate).Return([]byte("fast"), nil).Once()
+
+	s := &gameService{dictService: mockDict, activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	engine.CurrentWord = &Word{Word: "bee"}
+
+	// A slow repeat follows the word as everyone else hears it
+	slowRepeat := AudioPreferences{Rate: DefaultSpeechRate, SlowRepeat: true}
+	audio, err := s.personalAudio(context.Background(), engine, slowRepeat)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("word-slow"), audio)
+	assert.Equal(t, []byte("word"), engine.audio, "the shared recording is left alone")
+
+	audio, err = s.personalAudio(context.Background(), engine, AudioPreferences{Voice: "nova", Rate: MaxSpeechRate})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("fast"), audio)
+
+	// Replays are served from what was generated for the word
+	audio, err = s.personalAudio(context.Background(), engine, slowRepeat)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("word-slow"), audio)
+	mockDict.AssertExpectations(t)
+}
+
+type replayStub struct {
+	GameService
+	err error
+}
+
+func (s replayStub) ReplayWord(ctx context.Context, gameID, playerID string) (int, error) {
+	return 1, s.err
+}
+
+func TestReplayWordErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, http.StatusOK},
+		{ErrNoReplaysLeft, http.StatusTooManyRequests},
+		{ErrNotPlayerTurn, http.StatusForbidden},
+		{ErrTurnNotActive, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		h := NewHandler(replayStub{err: tt.err})
+		req := httptest.NewRequest(http.MethodPost, "/games/x/replay", nil)
+		req = req.WithContext(auth.SetUserIDInContext(req.Context(), "ada"))
+		rec := httptest.NewRecorder()
+
+		h.ReplayWord(rec, req, httprouter.Params{{Key: "gameID", Value: "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d"}})
+
+		assert.Equal(t, tt.code, rec.Code, tt.err)
+	}
+}
@@ -0,0 +1,73 @@
+package ranking
+
+import "math"
+
+// ELO is the competitive rating matchmaking pairs players by. Unlike rank
+// points, which are capped at the top of the Red rank and reset each
+// season, it has no ceiling and carries over from season to season.
+const (
+	StartingELO = 1200
+	// MinELO is as low as an ELO falls
+	MinELO = 100
+	// EloK is the most a two-player game moves an ELO. In bigger games it's
+	// shared out between the opponents.
+	EloK = 32
+	// ProvisionalEloK is EloK during a player's placement games, so their
+	// ELO finds its level quickly
+	ProvisionalEloK = 64
+
+	// PlacementGames is how many ranked games a new player plays before
+	// their rating is settled enough to show
+	PlacementGames = 10
+)
+
+// KFactor is how far a game moves the ELO of a player who has played
+// gamesPlayed ranked games
+func KFactor(gamesPlayed int) int {
+	if gamesPlayed < PlacementGames {
+		return ProvisionalEloK
+	}
+	return EloK
+}
+
+// ExpectedScore is how likely a player rated a is to beat one rated b
+func ExpectedScore(a, b int) float64 {
+	return 1 / (1 + math.Pow(10, float64(b-a)/400))
+}
+
+// EloChanges scores a game as if each player had played every other on
+// their own: beating those placed below them, losing to those above and
+// drawing with those placed alongside, such as teammates. placements, elos
+// and ks, each player's K factor, are in the same order, and the change to
+// each player's ELO is returned in that order too.
+func EloChanges(placements, elos, ks []int) []int {
+	changes := make([]int, len(placements))
+	if len(placements) < 2 {
+		return changes
+	}
+
+	opponents := float64(len(placements) - 1)
+	for i := range placements {
+		var delta float64
+		for j := range placements {
+			if i == j {
+				continue
+			}
+			actual := 0.5
+			switch {
+			case placements[i] < placements[j]:
+				actual = 1
+			case placements[i] > placements[j]:
+				actual = 0
+			}
+			delta += actual - ExpectedScore(elos[i], elos[j])
+		}
+		changes[i] = int(math.Round(float64(ks[i]) / opponents * delta))
+	}
+	return changes
+}
+
+// NewELO applies change to elo, no lower than MinELO
+func NewELO(elo, change int) int {
+	return max(elo+change, MinELO)
+}
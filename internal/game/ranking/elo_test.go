@@ -0,0 +1,63 @@
+package ranking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEloChanges(t *testing.T) {
+	ks := []int{EloK, EloK, EloK}
+	assert.Equal(t, []int{16, -16}, EloChanges([]int{1, 2}, []int{1200, 1200}, ks))
+	assert.Equal(t, []int{0, 0}, EloChanges([]int{1, 1}, []int{1200, 1200}, ks), "evenly matched teammates draw")
+	assert.Equal(t, []int{0}, EloChanges([]int{1}, []int{1200}, ks), "nobody to beat")
+
+	upset := EloChanges([]int{1, 2}, []int{1000, 1400}, ks)
+	assert.Greater(t, upset[0], 16, "beating a stronger player is worth more")
+	assert.Equal(t, -upset[0], upset[1])
+
+	changes := EloChanges([]int{1, 2, 3}, []int{1200, 1200, 1200}, ks)
+	assert.Equal(t, []int{16, 0, -16}, changes, "K is shared between opponents")
+
+	changes = EloChanges([]int{1, 2}, []int{1200, 1200}, []int{ProvisionalEloK, EloK})
+	assert.Equal(t, []int{32, -16}, changes, "provisional players move further")
+}
+
+func TestKFactor(t *testing.T) {
+	assert.Equal(t, ProvisionalEloK, KFactor(0))
+	assert.Equal(t, ProvisionalEloK, KFactor(PlacementGames-1))
+	assert.Equal(t, EloK, KFactor(PlacementGames))
+}
+
+func TestNewELO(t *testing.T) {
+	assert.Equal(t, 1216, NewELO(1200, 16))
+	assert.Equal(t, MinELO, NewELO(110, -16))
+}
+
+func TestApplyResults(t *testing.T) {
+	ratings := []Rating{
+		{UserID: "ada", Points: 290, Color: "Gray", ELO: 1200, GamesPlayed: 4, GamesWon: 1},
+		{UserID: "bo", Points: 1190, Color: "Red", ELO: 1500, GamesPlayed: 40},
+	}
+	results := []Result{{UserID: "ada", Placement: 1}, {UserID: "bo", Placement: 2}, {UserID: "gone", Placement: 3}}
+
+	changes := applyResults(results, ratings, 3, false)
+	assert.Len(t, changes, 2, "deleted players are skipped")
+
+	ada := changes[0]
+	assert.Equal(t, CalculatePoints(1, 3, false), ada.PointsEarned)
+	assert.Equal(t, ratings[0], ada.Previous)
+	assert.Equal(t, 290+ada.PointsEarned, ada.Current.Points)
+	assert.Equal(t, GetRankByPoints(ada.Current.Points).Color, ada.Current.Color)
+	assert.Greater(t, ada.EloChange, 0)
+	assert.Equal(t, 1200+ada.EloChange, ada.Current.ELO)
+	assert.Equal(t, 5, ada.Current.GamesPlayed)
+	assert.Equal(t, 2, ada.Current.GamesWon)
+	assert.True(t, ada.Won())
+
+	bo := changes[1]
+	assert.Equal(t, 1200, bo.Current.Points, "rank points are capped")
+	assert.Equal(t, -ada.EloChange/2, bo.EloChange, "only ada is still in placements")
+	assert.Equal(t, 0, bo.Current.GamesWon)
+	assert.False(t, bo.Won())
+}
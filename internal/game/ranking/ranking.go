@@ -78,3 +78,14 @@ func CalculateNewRating(currentRating, pointsEarned int) int {
 	}
 	return newRating
 }
+
+// SeasonBaseline is the rating a new season's soft reset pulls everyone
+// towards
+const SeasonBaseline = 600
+
+// SoftReset is a player's rating going into a new season: halfway between
+// where they finished the last one and SeasonBaseline, so strong players
+// keep an edge without the ladder staying frozen
+func SoftReset(rating int) int {
+	return SeasonBaseline + (rating-SeasonBaseline)/2
+}
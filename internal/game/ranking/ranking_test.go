@@ -83,3 +83,10 @@ func TestCalculateNewRating(t *testing.T) {
 		})
 	}
 }
+
+func TestSoftReset(t *testing.T) {
+	assert.Equal(t, 900, SoftReset(1200))
+	assert.Equal(t, 600, SoftReset(600))
+	assert.Equal(t, 300, SoftReset(0))
+	assert.Equal(t, "Yellow", GetRankByPoints(SoftReset(1200)).Color)
+}
@@ -0,0 +1,328 @@
+package ranking
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/notifications"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// Rating is where a player stands on both ladders: rank points and their
+// color on the casual one, and ELO on the competitive one
+type Rating struct {
+	UserID      string `json:"user_id" db:"id"`
+	Points      int    `json:"points" db:"rank_points"`
+	Color       string `json:"color" db:"rank_color"`
+	ELO         int    `json:"elo" db:"elo"`
+	GamesPlayed int    `json:"games_played" db:"games_played"`
+	GamesWon    int    `json:"games_won" db:"games_won"`
+}
+
+// Placement is how far a player is through their placement games
+type Placement struct {
+	GamesPlayed int  `json:"games_played"`
+	Games       int  `json:"games"`
+	Complete    bool `json:"complete"`
+}
+
+// NewPlacement is the placement progress of a player who has played
+// gamesPlayed ranked games
+func NewPlacement(gamesPlayed int) Placement {
+	return Placement{
+		GamesPlayed: min(gamesPlayed, PlacementGames),
+		Games:       PlacementGames,
+		Complete:    gamesPlayed >= PlacementGames,
+	}
+}
+
+// Placement is how far the player is through their placement games
+func (r Rating) Placement() Placement {
+	return NewPlacement(r.GamesPlayed)
+}
+
+// Provisional is whether the player is still playing their placement games.
+// Their rating is kept from them, and from everyone else, until they finish.
+func (r Rating) Provisional() bool {
+	return r.GamesPlayed < PlacementGames
+}
+
+// MarshalJSON leaves out provisional ratings' points, color and ELO
+func (r Rating) MarshalJSON() ([]byte, error) {
+	rating := struct {
+		UserID      string    `json:"user_id"`
+		Points      *int      `json:"points,omitempty"`
+		Color       string    `json:"color,omitempty"`
+		ELO         *int      `json:"elo,omitempty"`
+		GamesPlayed int       `json:"games_played"`
+		GamesWon    int       `json:"games_won"`
+		Provisional bool      `json:"provisional"`
+		Placement   Placement `json:"placement"`
+	}{
+		UserID:      r.UserID,
+		GamesPlayed: r.GamesPlayed,
+		GamesWon:    r.GamesWon,
+		Provisional: r.Provisional(),
+		Placement:   r.Placement(),
+	}
+	if !rating.Provisional {
+		rating.Points, rating.Color, rating.ELO = &r.Points, r.Color, &r.ELO
+	}
+	return json.Marshal(rating)
+}
+
+// Result is where a player placed in a ranked game
+type Result struct {
+	UserID    string
+	Placement int
+}
+
+// Change is how a ranked game or an adjustment moved a player's rating.
+// Placement is zero for adjustments.
+type Change struct {
+	UserID       string `json:"user_id"`
+	Placement    int    `json:"placement,omitempty"`
+	PointsEarned int    `json:"points_earned"`
+	EloChange    int    `json:"elo_change"`
+	Previous     Rating `json:"previous"`
+	Current      Rating `json:"current"`
+}
+
+// Won is whether the change came from winning a game
+func (c Change) Won() bool {
+	return c.Placement == 1
+}
+
+// Notify tells the player through notifier when the change moved them to
+// another rank color, or finished their placement games. Rank changes during
+// placements aren't told, as the rank is hidden.
+func (c Change) Notify(ctx context.Context, notifier notifications.Notifier) {
+	switch {
+	case notifier == nil, c.Current.Provisional():
+	case c.Previous.Provisional():
+		notifier.Notify(ctx, c.UserID, notifications.PlacementsComplete(c.Current.Color))
+	case c.Previous.Color != c.Current.Color:
+		notifier.Notify(ctx, c.UserID, notifications.RankChanged(c.Previous.Color, c.Current.Color, c.Current.Points > c.Previous.Points))
+	}
+}
+
+// Listener is told of the changes a ranked game made to ratings, in the
+// transaction making them, so whatever it keeps alongside ratings moves
+// with them or not at all
+type Listener interface {
+	RatingsChanged(ctx context.Context, tx *sqlx.Tx, gameID string, changes []Change) error
+}
+
+type ServiceOption func(*Service)
+
+// WithNotifier tells players when a game moves them to a new rank color
+func WithNotifier(notifier notifications.Notifier) ServiceOption {
+	return func(s *Service) {
+		s.notifier = notifier
+	}
+}
+
+// WithListener has listener told of every ranked game's changes
+func WithListener(listener Listener) ServiceOption {
+	return func(s *Service) {
+		s.listeners = append(s.listeners, listener)
+	}
+}
+
+// Service keeps players' ratings. Everything that reads or moves a rating
+// goes through it, or through Adjust for changes made in a caller's
+// transaction.
+type Service struct {
+	db        *sqlx.DB
+	notifier  notifications.Notifier
+	listeners []Listener
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+const ratingColumns = `id, rank_points, rank_color, elo, games_played, games_won`
+
+// GetRating returns userID's rating
+func (s *Service) GetRating(ctx context.Context, userID string) (*Rating, error) {
+	rating := &Rating{}
+	if err := s.db.GetContext(ctx, rating, `
+		SELECT `+ratingColumns+` FROM users WHERE id = $1`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get rating: %w", err)
+	}
+	return rating, nil
+}
+
+// ApplyResult moves the ratings of everyone in a ranked game together: rank
+// points for their placement among field placings, and ELO for how they did
+// against each other. Players whose accounts are gone are skipped.
+func (s *Service) ApplyResult(ctx context.Context, gameID string, results []Result, field int, isTournament bool) ([]Change, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.UserID
+	}
+	// Locking in ID order keeps two games with the same players from
+	// deadlocking
+	var ratings []Rating
+	if err := tx.SelectContext(ctx, &ratings, `
+		SELECT `+ratingColumns+` FROM users
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE`, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to get ratings: %w", err)
+	}
+
+	changes := applyResults(results, ratings, field, isTournament)
+	for _, change := range changes {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET rank_points = $1, rank_color = $2, elo = $3, games_played = $4, games_won = $5,
+				last_ranked_at = NOW()
+			WHERE id = $6`,
+			change.Current.Points, change.Current.Color, change.Current.ELO,
+			change.Current.GamesPlayed, change.Current.GamesWon, change.UserID); err != nil {
+			return nil, fmt.Errorf("failed to update rating: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO game_results (id, game_id, player_id, placement, points_earned,
+				previous_rank_points, new_rank_points, previous_rank_color, new_rank_color,
+				previous_elo, new_elo)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			uuid.New().String(), gameID, change.UserID, change.Placement, change.PointsEarned,
+			change.Previous.Points, change.Current.Points, change.Previous.Color, change.Current.Color,
+			change.Previous.ELO, change.Current.ELO); err != nil {
+			return nil, fmt.Errorf("failed to record game result: %w", err)
+		}
+	}
+
+	for _, listener := range s.listeners {
+		if err := listener.RatingsChanged(ctx, tx, gameID, changes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit game result: %w", err)
+	}
+
+	for _, change := range changes {
+		change.Notify(ctx, s.notifier)
+	}
+	return changes, nil
+}
+
+// CreditActivity counts at as the last time each of userIDs played a ranked
+// game, unless they have played one since, so a ranked game called off
+// through no fault of theirs keeps their rating from decaying as if it had
+// finished
+func (s *Service) CreditActivity(ctx context.Context, userIDs []string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE users SET last_ranked_at = $2
+		WHERE id = ANY($1) AND last_ranked_at < $2`, pq.Array(userIDs), at); err != nil {
+		return fmt.Errorf("failed to credit ranked activity: %w", err)
+	}
+	return nil
+}
+
+// applyResults works out how results move ratings, the ratings of the
+// players still around
+func applyResults(results []Result, ratings []Rating, field int, isTournament bool) []Change {
+	var placed []Result
+	var current []Rating
+	for _, result := range results {
+		i := slices.IndexFunc(ratings, func(r Rating) bool { return r.UserID == result.UserID })
+		if i < 0 {
+			continue
+		}
+		placed = append(placed, result)
+		current = append(current, ratings[i])
+	}
+
+	placements := make([]int, len(placed))
+	elos := make([]int, len(placed))
+	ks := make([]int, len(placed))
+	for i := range placed {
+		placements[i] = placed[i].Placement
+		elos[i] = current[i].ELO
+		ks[i] = KFactor(current[i].GamesPlayed)
+	}
+	eloChanges := EloChanges(placements, elos, ks)
+
+	changes := make([]Change, len(placed))
+	for i, result := range placed {
+		points := CalculatePoints(result.Placement, field, isTournament)
+		next := current[i]
+		next.Points = CalculateNewRating(next.Points, points)
+		next.Color = GetRankByPoints(next.Points).Color
+		next.ELO = NewELO(next.ELO, eloChanges[i])
+		next.GamesPlayed++
+		if result.Placement == 1 {
+			next.GamesWon++
+		}
+
+		changes[i] = Change{
+			UserID:       result.UserID,
+			Placement:    result.Placement,
+			PointsEarned: points,
+			EloChange:    next.ELO - current[i].ELO,
+			Previous:     current[i],
+			Current:      next,
+		}
+	}
+	return changes
+}
+
+// Adjust moves userID's rank points by points, within the bounds games keep
+// them in, as part of tx so the caller can record why alongside it. ELO is
+// only moved by games.
+func Adjust(ctx context.Context, tx *sqlx.Tx, userID string, points int) (*Change, error) {
+	previous := Rating{}
+	if err := tx.GetContext(ctx, &previous, `
+		SELECT `+ratingColumns+` FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get rating: %w", err)
+	}
+
+	current := previous
+	current.Points = CalculateNewRating(previous.Points, points)
+	current.Color = GetRankByPoints(current.Points).Color
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET rank_points = $1, rank_color = $2 WHERE id = $3`,
+		current.Points, current.Color, userID); err != nil {
+		return nil, fmt.Errorf("failed to update rating: %w", err)
+	}
+
+	return &Change{
+		UserID:       userID,
+		PointsEarned: points,
+		Previous:     previous,
+		Current:      current,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package ranking
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/notifications"
+)
+
+type notifier []notifications.Notification
+
+func (n *notifier) Notify(ctx context.Context, userID string, notification notifications.Notification) {
+	*n = append(*n, notification)
+}
+
+func TestRatingJSON(t *testing.T) {
+	rating := Rating{UserID: "ada", Points: 420, Color: "Violet", ELO: 1350, GamesPlayed: 3, GamesWon: 2}
+
+	var got map[string]any
+	data, err := json.Marshal(rating)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, true, got["provisional"])
+	assert.NotContains(t, got, "points", "ratings are hidden until placements are done")
+	assert.NotContains(t, got, "color")
+	assert.NotContains(t, got, "elo")
+	assert.Equal(t, map[string]any{"games_played": 3.0, "games": float64(PlacementGames), "complete": false}, got["placement"])
+
+	rating.GamesPlayed = PlacementGames + 5
+	got = nil
+	data, err = json.Marshal(rating)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, false, got["provisional"])
+	assert.Equal(t, 420.0, got["points"])
+	assert.Equal(t, "Violet", got["color"])
+	assert.Equal(t, 1350.0, got["elo"])
+	assert.Equal(t, float64(PlacementGames), got["placement"].(map[string]any)["games_played"])
+}
+
+func TestChangeNotify(t *testing.T) {
+	placing := Change{
+		UserID:   "ada",
+		Previous: Rating{Color: "Gray", GamesPlayed: PlacementGames - 2},
+		Current:  Rating{Color: "Violet", GamesPlayed: PlacementGames - 1},
+	}
+	var sent notifier
+	placing.Notify(context.Background(), &sent)
+	assert.Empty(t, sent, "rank changes during placements aren't told")
+
+	placed := Change{
+		UserID:   "ada",
+		Previous: Rating{Color: "Violet", GamesPlayed: PlacementGames - 1},
+		Current:  Rating{Color: "Violet", GamesPlayed: PlacementGames},
+	}
+	placed.Notify(context.Background(), &sent)
+	require.Len(t, sent, 1)
+	assert.Equal(t, notifications.KindPlacementsComplete, sent[0].Kind)
+
+	promoted := Change{
+		UserID:   "ada",
+		Previous: Rating{Points: 290, Color: "Gray", GamesPlayed: 20},
+		Current:  Rating{Points: 320, Color: "Violet", GamesPlayed: 21},
+	}
+	promoted.Notify(context.Background(), &sent)
+	require.Len(t, sent, 2)
+	assert.Equal(t, notifications.KindRankChanged, sent[1].Kind)
+}
@@ -0,0 +1,191 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultReadyCheckTimeout = 30 * time.Second
+	MaxReadyCheckTimeout     = 2 * time.Minute
+)
+
+var (
+	ErrReadyCheckPending = errors.New("a ready check is under way")
+	ErrNoReadyCheck      = errors.New("no ready check is under way")
+)
+
+// ReadyCheck is the host asking the lobby's players to confirm they're
+// there before the game starts. Players who haven't by Deadline are dropped
+// to spectators, and the game starts if enough are left.
+type ReadyCheck struct {
+	Players  []string  `json:"players"`
+	Ready    []string  `json:"ready"`
+	Deadline time.Time `json:"deadline"`
+}
+
+func (c *ReadyCheck) clone() *ReadyCheck {
+	return &ReadyCheck{Players: slices.Clone(c.Players), Ready: slices.Clone(c.Ready), Deadline: c.Deadline}
+}
+
+func (s GameSettings) readyCheckTimeout() time.Duration {
+	if s.ReadyCheckTimeout <= 0 {
+		return DefaultReadyCheckTimeout
+	}
+	return s.ReadyCheckTimeout
+}
+
+// readyCheck returns a copy of the game's ready check, if one is under way
+func (s *gameService) readyCheck(gameID string) *ReadyCheck {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if engine := s.activeGames[gameID]; engine != nil && engine.ReadyCheck != nil {
+		return engine.ReadyCheck.clone()
+	}
+	return nil
+}
+
+// StartReadyCheck lets the host ask everyone in the lobby to confirm
+// they're ready. The host counts as ready, so a host playing alone starts
+// straight away.
+func (s *gameService) StartReadyCheck(ctx context.Context, gameID string, userID string) (*Game, error) {
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.HostID != userID {
+		return nil, ErrNotHost
+	}
+
+	if game.Status != GameStatusWaiting {
+		return nil, ErrInvalidGameState
+	}
+
+	now := time.Now()
+	timeout := game.Settings.readyCheckTimeout()
+	check := &ReadyCheck{Players: turnOrder(game), Ready: []string{}, Deadline: now.Add(timeout)}
+	if slices.Contains(check.Players, userID) {
+		check.Ready = append(check.Ready, userID)
+	}
+
+	if s.engine(gameID) == nil {
+		s.setEngine(gameID, s.newEngine(game))
+	}
+	s.mu.Lock()
+	engine := s.activeGames[gameID]
+	if engine.ReadyCheck != nil {
+		s.mu.Unlock()
+		return nil, ErrReadyCheckPending
+	}
+	engine.ReadyCheck = check
+	game.ReadyCheck = check.clone()
+	s.mu.Unlock()
+
+	s.timers.Schedule(timerKey(gameID, "ready_check"), timeout, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_, _ = s.finishReadyCheck(ctx, gameID, check)
+	})
+
+	s.emitEvent(EventTypeReadyCheckStarted, gameID, &userID, withCountdown(map[string]any{
+		"players":  game.ReadyCheck.Players,
+		"ready":    game.ReadyCheck.Ready,
+		"deadline": check.Deadline,
+	}, now, timeout))
+
+	if len(game.ReadyCheck.Ready) == len(game.ReadyCheck.Players) {
+		return s.finishReadyCheck(ctx, gameID, check)
+	}
+	return game, nil
+}
+
+// ConfirmReady confirms playerID is ready to play. The check ends as soon
+// as every player has.
+func (s *gameService) ConfirmReady(ctx context.Context, gameID string, playerID string) error {
+	s.mu.Lock()
+	engine := s.activeGames[gameID]
+	if engine == nil || engine.ReadyCheck == nil {
+		s.mu.Unlock()
+		return ErrNoReadyCheck
+	}
+	check := engine.ReadyCheck
+	if !slices.Contains(check.Players, playerID) {
+		s.mu.Unlock()
+		return ErrPlayerNotFound
+	}
+	if !slices.Contains(check.Ready, playerID) {
+		check.Ready = append(check.Ready, playerID)
+	}
+	ready, everyone := slices.Clone(check.Ready), len(check.Ready) == len(check.Players)
+	s.mu.Unlock()
+
+	s.emitEvent(EventTypePlayerReady, gameID, &playerID, map[string]any{
+		"player_id": playerID,
+		"ready":     ready,
+	})
+
+	if everyone {
+		_, err := s.finishReadyCheck(ctx, gameID, check)
+		return err
+	}
+	return nil
+}
+
+// finishReadyCheck ends check, once everyone is ready or time is up. Players
+// who didn't confirm leave the lobby but can keep watching, and the game
+// starts if at least MinPlayers are left.
+func (s *gameService) finishReadyCheck(ctx context.Context, gameID string, check *ReadyCheck) (*Game, error) {
+	// Exactly one of the last player to confirm and the timer gets to finish
+	// the check
+	s.mu.Lock()
+	engine := s.activeGames[gameID]
+	if engine == nil || engine.ReadyCheck != check {
+		s.mu.Unlock()
+		return nil, ErrNoReadyCheck
+	}
+	engine.ReadyCheck = nil
+	ready := slices.Clone(check.Ready)
+	s.mu.Unlock()
+	s.timers.Cancel(timerKey(gameID, "ready_check"))
+
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game.Status != GameStatusWaiting {
+		return nil, ErrInvalidGameState
+	}
+
+	// The host may have changed while the check was under way, and the host
+	// is never dropped
+	spectators := []string{}
+	for _, playerID := range turnOrder(game) {
+		if playerID == game.HostID || slices.Contains(ready, playerID) {
+			continue
+		}
+		if err := s.removePlayer(ctx, game, playerID, "left"); err != nil {
+			return nil, err
+		}
+		spectators = append(spectators, playerID)
+	}
+
+	// turnOrder falls back on the host when nobody is left, who isn't
+	// necessarily playing
+	players := activePlayers(game)
+	starting := players > 0 && players >= game.Settings.MinPlayers
+
+	s.emitEvent(EventTypeReadyCheckEnded, gameID, nil, map[string]any{
+		"ready":      ready,
+		"spectators": spectators,
+		"starting":   starting,
+	})
+
+	if !starting {
+		return game, nil
+	}
+	return s.StartGame(ctx, gameID, game.HostID)
+}
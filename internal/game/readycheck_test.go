@@ -0,0 +1,91 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestConfirmingReady(t *testing.T) {
+	s := &gameService{events: newEventLog(), timers: newTimerSet(), activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	s.activeGames["game-1"] = engine
+
+	err := s.ConfirmReady(context.Background(), "game-1", "ada")
+	assert.ErrorIs(t, err, ErrNoReadyCheck)
+
+	engine.ReadyCheck = &ReadyCheck{Players: []string{"ada", "bo", "cy"}, Ready: []string{"ada"}, Deadline: time.Now().Add(time.Minute)}
+	sub := s.Subscribe("game-1", "ada", 0)
+	defer sub.Cancel()
+
+	err = s.ConfirmReady(context.Background(), "game-1", "dee")
+	assert.ErrorIs(t, err, ErrPlayerNotFound, "only the players in the check can confirm")
+
+	require.NoError(t, s.ConfirmReady(context.Background(), "game-1", "bo"))
+	require.NoError(t, s.ConfirmReady(context.Background(), "game-1", "bo"))
+	assert.Equal(t, []string{"ada", "bo"}, engine.ReadyCheck.Ready, "confirming twice counts once")
+
+	event := <-sub.Events
+	assert.Equal(t, EventTypePlayerReady, event.Type)
+	assert.Equal(t, "bo", event.Payload["player_id"])
+
+	check := s.readyCheck("game-1")
+	check.Ready = append(check.Ready, "cy")
+	assert.Len(t, engine.ReadyCheck.Ready, 2, "the check handed out is a copy")
+}
+
+func TestFinishingReadyCheckOnce(t *testing.T) {
+	s := &gameService{events: newEventLog(), timers: newTimerSet(), activeGames: map[string]*GameEngine{}}
+	engine := NewGameEngine("game-1", nil)
+	s.activeGames["game-1"] = engine
+
+	stale := &ReadyCheck{Players: []string{"ada"}}
+	engine.ReadyCheck = &ReadyCheck{Players: []string{"ada"}}
+	_, err := s.finishReadyCheck(context.Background(), "game-1", stale)
+	assert.ErrorIs(t, err, ErrNoReadyCheck, "a check that has been replaced isn't finished")
+	assert.NotNil(t, engine.ReadyCheck)
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	assert.Equal(t, DefaultReadyCheckTimeout, GameSettings{}.readyCheckTimeout())
+	assert.Equal(t, time.Minute, GameSettings{ReadyCheckTimeout: time.Minute}.readyCheckTimeout())
+}
+
+type readyStub struct {
+	GameService
+	ready chan string
+}
+
+func (s readyStub) GetGame(ctx context.Context, gameID string) (*Game, error) {
+	return &Game{ID: gameID, Round: 2}, nil
+}
+
+func (s readyStub) ConfirmReady(ctx context.Context, gameID, playerID string) error {
+	s.ready <- playerID
+	return nil
+}
+
+func (s readyStub) StartReadyCheck(ctx context.Context, gameID, userID string) (*Game, error) {
+	return nil, ErrNotHost
+}
+
+func (s readyStub) Subscribe(gameID, viewerID string, after int64) *Subscription {
+	return &Subscription{cancel: func() {}}
+}
+
+func TestReadyOverWebSocket(t *testing.T) {
+	stub := readyStub{ready: make(chan string, 1)}
+	conn := dialGame(t, stub, auth.UserScopes...)
+
+	reply := send(t, conn, ClientMessage{Type: ClientMessageReadyCheck, ID: "1"})
+	assert.Equal(t, ErrNotHost.Error(), reply.Error)
+
+	reply = send(t, conn, ClientMessage{Type: ClientMessageReady, ID: "2"})
+	assert.Equal(t, ServerMessageAck, reply.Type)
+	assert.Equal(t, "ada", <-stub.ready)
+}
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultAbandonAfter is how long a game can go without anything happening
+// in it before it's taken as abandoned
+const DefaultAbandonAfter = time.Hour
+
+// openStatuses are those of games that haven't finished or been called off
+var openStatuses = []GameStatus{
+	GameStatusCreated, GameStatusInitializing, GameStatusWaiting,
+	GameStatusPlaying, GameStatusActive, GameStatusPaused,
+}
+
+// MeetingReleaser ends a game's video meeting. chime.MeetingService
+// implements it.
+type MeetingReleaser interface {
+	DeleteMeeting(ctx context.Context, meetingID string) error
+}
+
+// WithMeetings ends the meetings of games once they are over, when the
+// reaper next runs
+func WithMeetings(meetings MeetingReleaser) ServiceOption {
+	return func(s *gameService) {
+		s.meetings = meetings
+	}
+}
+
+// ReapAbandoned cancels the games, waiting or under way, that nothing has
+// happened in for inactiveAfter. Lobbies waiting for their scheduled start
+// are left alone. Players of an abandoned ranked game that had started are
+// credited with ranked activity up to when it went quiet, so they don't
+// lose rating to decay over it. It then ends the meetings of games that are
+// over and deletes chat older than ChatRetention. It returns how many games
+// it cancelled.
+func (s *gameService) ReapAbandoned(ctx context.Context, inactiveAfter time.Duration) (int, error) {
+	cutoff := time.Now().Add(-inactiveAfter)
+
+	var stale []string
+	if err := s.db.SelectContext(ctx, &stale, `
+		SELECT id FROM games
+		WHERE status = ANY($1) AND GREATEST(last_activity, updated_at) < $2
+			AND (scheduled_at IS NULL OR scheduled_at < NOW())
+		ORDER BY updated_at`, pq.Array(openStatuses), cutoff); err != nil {
+		return 0, fmt.Errorf("failed to find abandoned games: %w", err)
+	}
+
+	reaped := 0
+	for _, gameID := range stale {
+		ok, err := s.reap(ctx, gameID, cutoff)
+		if err != nil {
+			return reaped, err
+		}
+		if ok {
+			reaped++
+		}
+	}
+
+	return reaped, errors.Join(s.releaseMeetings(ctx), s.purgeChat(ctx))
+}
+
+// reap cancels gameID unless it has seen activity since cutoff that the
+// database hasn't, in which case that activity is saved instead
+func (s *gameService) reap(ctx context.Context, gameID string, cutoff time.Time) (bool, error) {
+	if latest := s.events.latest(gameID); latest.After(cutoff) {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE games SET last_activity = $1
+			WHERE id = $2`, latest, gameID); err != nil {
+			return false, fmt.Errorf("failed to save game activity: %w", err)
+		}
+		return false, nil
+	}
+
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+	game, err := s.GetGame(ctx, gameID)
+	if errors.Is(err, ErrGameNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if game.Status == GameStatusFinished || game.Status == GameStatusCancelled {
+		return false, nil
+	}
+	started := game.Status == GameStatusActive || game.Status == GameStatusPaused || game.Status == GameStatusPlaying
+
+	if err := s.cancelGame(ctx, game, "abandoned"); err != nil {
+		return false, err
+	}
+
+	if started && game.Settings.IsRanked && s.ranks != nil {
+		var players []string
+		for _, player := range game.Players {
+			if player != nil && player.Status == "active" && !player.IsBot {
+				players = append(players, player.UserID)
+			}
+		}
+		quiet := game.UpdatedAt
+		if game.LastActivity.After(quiet) {
+			quiet = game.LastActivity
+		}
+		if len(players) > 0 {
+			if err := s.ranks.CreditActivity(ctx, players, quiet); err != nil {
+				return true, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// releaseMeetings ends the meetings still held by games that are over
+func (s *gameService) releaseMeetings(ctx context.Context) error {
+	if s.meetings == nil {
+		return nil
+	}
+
+	var held []struct {
+		ID        string `db:"id"`
+		MeetingID string `db:"meeting_id"`
+	}
+	if err := s.db.SelectContext(ctx, &held, `
+		SELECT id, meeting_id FROM games
+		WHERE status = ANY($1) AND meeting_id IS NOT NULL`,
+		pq.Array([]GameStatus{GameStatusFinished, GameStatusCancelled})); err != nil {
+		return fmt.Errorf("failed to find meetings to release: %w", err)
+	}
+
+	for _, game := range held {
+		if err := s.meetings.DeleteMeeting(ctx, game.MeetingID); err != nil {
+			return fmt.Errorf("failed to release meeting of game %s: %w", game.ID, err)
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE games SET meeting_id = NULL
+			WHERE id = $1`, game.ID); err != nil {
+			return fmt.Errorf("failed to release meeting: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reaper cancels abandoned games in the background
+type Reaper struct {
+	games         GameService
+	inactiveAfter time.Duration
+}
+
+// NewReaper reaps the games games holds that nothing has happened in for
+// inactiveAfter
+func NewReaper(games GameService, inactiveAfter time.Duration) *Reaper {
+	return &Reaper{games: games, inactiveAfter: inactiveAfter}
+}
+
+// Run calls ReapAbandoned every interval until ctx is cancelled
+func (r *Reaper) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.games.ReapAbandoned(ctx, r.inactiveAfter); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
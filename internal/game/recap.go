@@ -0,0 +1,152 @@
+package game
+
+import (
+	neturl "net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DiffOp describes how one character of an attempt lines up with the word
+type DiffOp string
+
+const (
+	DiffOpMatch      DiffOp = "match"
+	DiffOpSubstitute DiffOp = "substitute"
+	DiffOpInsert     DiffOp = "insert"
+	DiffOpDelete     DiffOp = "delete"
+)
+
+// CharDiff is one step of the alignment between the word and an attempt.
+// Expected is empty for inserted characters and Actual for missing ones.
+type CharDiff struct {
+	Op       DiffOp `json:"op"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// SourceAttribution credits the dictionary a word's content came from
+type SourceAttribution struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// TurnRecap is everything a client needs to show once a turn resolves.
+// BuildTurnRecap is the only place it is assembled so every surface shows
+// the same information. Recaps of misses must pass through RevealRecap
+// before they leave the server.
+type TurnRecap struct {
+	GameID     string             `json:"game_id"`
+	Round      int                `json:"round"`
+	PlayerID   string             `json:"player_id"`
+	Word       string             `json:"word"`
+	Attempt    string             `json:"attempt"`
+	Correct    bool               `json:"correct"`
+	Diff       []CharDiff         `json:"diff"`
+	HintsUsed  []HintType         `json:"hints_used"`
+	Definition string             `json:"definition,omitempty"`
+	Respelling string             `json:"respelling,omitempty"`
+	AudioURL   string             `json:"audio_url,omitempty"`
+	Source     *SourceAttribution `json:"source,omitempty"`
+	Revealed   bool               `json:"revealed"`
+	ResolvedAt time.Time          `json:"resolved_at"`
+}
+
+// BuildTurnRecap assembles the recap for attempt at word
+func BuildTurnRecap(game *Game, word *Word, attempt *SpellingAttempt, hintsUsed []HintType) *TurnRecap {
+	hints := append([]HintType{}, hintsUsed...)
+
+	return &TurnRecap{
+		GameID:     game.ID,
+		Round:      game.Round,
+		PlayerID:   attempt.PlayerID,
+		Word:       word.Word,
+		Attempt:    attempt.Text,
+		Correct:    attempt.IsCorrect,
+		Diff:       DiffSpelling(word.Word, attempt.Text),
+		HintsUsed:  hints,
+		Definition: word.Definition,
+		Respelling: word.Respelling,
+		AudioURL:   word.AudioURL,
+		Source:     sourceAttribution(word),
+		ResolvedAt: attempt.Timestamp,
+	}
+}
+
+// DiffSpelling aligns actual against expected with the fewest edits,
+// ignoring case and surrounding whitespace. Extra and missing letters are
+// preferred over substitutions when both are equally short, since doubled
+// letters are the most common misspelling.
+func DiffSpelling(expected, actual string) []CharDiff {
+	want := []rune(strings.TrimSpace(expected))
+	got := []rune(strings.TrimSpace(actual))
+
+	// dist[i][j] is the edit distance between want[i:] and got[j:]
+	dist := make([][]int, len(want)+1)
+	for i := range dist {
+		dist[i] = make([]int, len(got)+1)
+	}
+	for i := len(want); i >= 0; i-- {
+		for j := len(got); j >= 0; j-- {
+			switch {
+			case i == len(want):
+				dist[i][j] = len(got) - j
+			case j == len(got):
+				dist[i][j] = len(want) - i
+			default:
+				sub := dist[i+1][j+1]
+				if !sameLetter(want[i], got[j]) {
+					sub++
+				}
+				dist[i][j] = min(sub, dist[i+1][j]+1, dist[i][j+1]+1)
+			}
+		}
+	}
+
+	diff := make([]CharDiff, 0, max(len(want), len(got)))
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && sameLetter(want[i], got[j]) && dist[i][j] == dist[i+1][j+1]:
+			diff = append(diff, CharDiff{Op: DiffOpMatch, Expected: string(want[i]), Actual: string(got[j])})
+			i, j = i+1, j+1
+		case j < len(got) && dist[i][j] == dist[i][j+1]+1:
+			diff = append(diff, CharDiff{Op: DiffOpInsert, Actual: string(got[j])})
+			j++
+		case i < len(want) && dist[i][j] == dist[i+1][j]+1:
+			diff = append(diff, CharDiff{Op: DiffOpDelete, Expected: string(want[i])})
+			i++
+		default:
+			diff = append(diff, CharDiff{Op: DiffOpSubstitute, Expected: string(want[i]), Actual: string(got[j])})
+			i, j = i+1, j+1
+		}
+	}
+	return diff
+}
+
+func sameLetter(a, b rune) bool {
+	return unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+var dictionarySources = map[string]struct {
+	name    string
+	urlBase string
+}{
+	"merriam_webster": {"Merriam-Webster's Collegiate Dictionary", "https://www.merriam-webster.com/dictionary/"},
+	"wordnik":         {"Wordnik", "https://www.wordnik.com/words/"},
+	"wiktionary":      {"Wiktionary", "https://en.wiktionary.org/wiki/"},
+	"local":           {"Big Spella word list", ""},
+}
+
+func sourceAttribution(word *Word) *SourceAttribution {
+	source, ok := dictionarySources[word.Source]
+	if !ok {
+		return nil
+	}
+
+	attribution := &SourceAttribution{Name: source.name}
+	if source.urlBase != "" {
+		attribution.URL = source.urlBase + neturl.PathEscape(strings.ToLower(word.Word))
+	}
+	return attribution
+}
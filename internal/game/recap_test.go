@@ -0,0 +1,47 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSpelling(t *testing.T) {
+	diff := DiffSpelling("necessary", "Neccesary")
+
+	var ops []DiffOp
+	for _, d := range diff {
+		ops = append(ops, d.Op)
+	}
+	assert.Equal(t, []DiffOp{
+		DiffOpMatch, DiffOpMatch, DiffOpMatch, DiffOpInsert,
+		DiffOpMatch, DiffOpMatch, DiffOpDelete, DiffOpMatch,
+		DiffOpMatch, DiffOpMatch,
+	}, ops)
+	assert.Equal(t, CharDiff{Op: DiffOpInsert, Actual: "c"}, diff[3])
+	assert.Equal(t, CharDiff{Op: DiffOpDelete, Expected: "s"}, diff[6])
+
+	diff = DiffSpelling("cat", "cut")
+	assert.Equal(t, CharDiff{Op: DiffOpSubstitute, Expected: "a", Actual: "u"}, diff[1])
+
+	assert.Len(t, DiffSpelling("word", ""), 4)
+}
+
+func TestBuildTurnRecap(t *testing.T) {
+	now := time.Now()
+	game := &Game{ID: "game-1", Round: 3}
+	word := &Word{Word: "heron", Definition: "A wading bird", Source: "wordnik", AudioURL: "https://cdn.example/heron.mp3"}
+	attempt := &SpellingAttempt{PlayerID: "player-1", Text: "herron", Timestamp: now}
+
+	recap := BuildTurnRecap(game, word, attempt, []HintType{HintTypeDefinition})
+
+	assert.Equal(t, "heron", recap.Word)
+	assert.Equal(t, "herron", recap.Attempt)
+	assert.False(t, recap.Correct)
+	assert.Equal(t, 3, recap.Round)
+	assert.Equal(t, []HintType{HintTypeDefinition}, recap.HintsUsed)
+	assert.Equal(t, "https://cdn.example/heron.mp3", recap.AudioURL)
+	assert.Equal(t, &SourceAttribution{Name: "Wordnik", URL: "https://www.wordnik.com/words/heron"}, recap.Source)
+	assert.Equal(t, now, recap.ResolvedAt)
+}
@@ -0,0 +1,362 @@
+// Package respell turns dictionary pronunciations into reader-friendly
+// respellings such as "fuh-NET-ik".
+//
+// Both IPA ("fəˈnɛtɪk") and Merriam-Webster's notation ("fə-ˈne-tik") are
+// accepted. Output follows the usual respelling conventions: syllables are
+// joined with hyphens and the syllable carrying primary stress is upper case.
+package respell
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type stress int
+
+const (
+	unstressed stress = iota
+	primary
+	secondary
+)
+
+type phone struct {
+	sym   string
+	vowel bool
+	// moved is set on a consonant pulled back from the next syllable to
+	// close a short vowel
+	moved bool
+}
+
+type syllable struct {
+	phones []phone
+	stress stress
+}
+
+// Respell converts a pronunciation to a respelling. Only the first variant
+// is used when several are listed. It returns "" for input it can't read.
+func Respell(pronunciation string) string {
+	pron := firstVariant(pronunciation)
+	if pron == "" {
+		return ""
+	}
+
+	table := ipaTable
+	if isMerriamWebster(pronunciation) {
+		table = mwTable
+	}
+
+	var words []string
+	for _, w := range strings.Fields(pron) {
+		if r := respellWord(w, table); r != "" {
+			words = append(words, r)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func firstVariant(pron string) string {
+	if i := strings.IndexAny(pron, ",;"); i >= 0 {
+		pron = pron[:i]
+	}
+	pron = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', '[', ']', '(', ')', 'ː', 'ˑ':
+			return -1
+		}
+		return r
+	}, pron)
+	return strings.TrimSpace(pron)
+}
+
+// isMerriamWebster reports whether pron uses Merriam-Webster's notation
+// rather than IPA. MW separates syllables with hyphens and uses diacritics
+// and ASCII digraphs where IPA has dedicated letters.
+func isMerriamWebster(pron string) bool {
+	switch {
+	case strings.ContainsAny(pron, "-āäȧēīōȯüᵊ͟") || strings.Contains(pron, "u̇"):
+		return true
+	case strings.ContainsAny(pron, "ɪɛæʌʊɒɔɑɜɝɚɐᵻʃʒθðɹɡɾːˑ"):
+		return false
+	}
+	for _, digraph := range []string{"ch", "sh", "th", "zh", "j"} {
+		if strings.Contains(pron, digraph) {
+			return true
+		}
+	}
+	return false
+}
+
+func respellWord(word string, table []mapping) string {
+	syllables := syllabify(tokenize(word, table))
+	if len(syllables) == 0 {
+		return ""
+	}
+
+	for i := range syllables {
+		// A stressed schwa in MW notation is the vowel of "cut"
+		if syllables[i].stress != unstressed {
+			for j, p := range syllables[i].phones {
+				if p.sym == "ə" {
+					syllables[i].phones[j].sym = "ʌ"
+				}
+			}
+		}
+	}
+
+	closeShortVowels(syllables)
+
+	parts := make([]string, len(syllables))
+	for i, s := range syllables {
+		text := render(s)
+		if s.stress == primary || len(syllables) == 1 {
+			text = strings.ToUpper(text)
+		}
+		parts[i] = text
+	}
+	return strings.Join(parts, "-")
+}
+
+// token is either a phone or a syllable break, optionally marking stress
+type token struct {
+	phone   phone
+	isBreak bool
+	stress  stress
+}
+
+func tokenize(word string, table []mapping) []token {
+	var tokens []token
+	for len(word) > 0 {
+		switch {
+		case strings.HasPrefix(word, "ˈ"):
+			tokens = append(tokens, token{isBreak: true, stress: primary})
+			word = word[len("ˈ"):]
+			continue
+		case word[0] == '\'':
+			tokens = append(tokens, token{isBreak: true, stress: primary})
+			word = word[1:]
+			continue
+		case strings.HasPrefix(word, "ˌ"):
+			tokens = append(tokens, token{isBreak: true, stress: secondary})
+			word = word[len("ˌ"):]
+			continue
+		case word[0] == '.' || word[0] == '-':
+			tokens = append(tokens, token{isBreak: true})
+			word = word[1:]
+			continue
+		}
+
+		m, ok := longestMatch(word, table)
+		if !ok {
+			// Diacritics and symbols we have no respelling for are skipped
+			_, size := utf8.DecodeRuneInString(word)
+			word = word[size:]
+			continue
+		}
+		word = word[len(m.from):]
+
+		// A syllabic consonant, as in "bʌtn̩", carries its own schwa
+		if strings.HasPrefix(word, "̩") || strings.HasPrefix(word, "̍") {
+			tokens = append(tokens, token{phone: phone{sym: "ə", vowel: true}})
+			word = word[len("̩"):]
+		}
+
+		for _, sym := range m.to {
+			tokens = append(tokens, token{phone: phone{sym: sym, vowel: vowels[sym]}})
+		}
+	}
+	return tokens
+}
+
+func longestMatch(s string, table []mapping) (mapping, bool) {
+	var best mapping
+	found := false
+	for _, m := range table {
+		if len(m.from) > len(best.from) && strings.HasPrefix(s, m.from) {
+			best = m
+			found = true
+		}
+	}
+	return best, found
+}
+
+// syllabify splits tokens into syllables at explicit breaks and stress
+// marks, and then between any vowels left in the same chunk
+func syllabify(tokens []token) []syllable {
+	var chunks []syllable
+	current := syllable{}
+	for _, t := range tokens {
+		if t.isBreak {
+			if len(current.phones) > 0 {
+				chunks = append(chunks, current)
+			}
+			current = syllable{stress: t.stress}
+			continue
+		}
+		current.phones = append(current.phones, t.phone)
+	}
+	if len(current.phones) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	var syllables []syllable
+	for _, chunk := range chunks {
+		split := splitChunk(chunk)
+		if len(split) == 0 {
+			// Consonants with no vowel belong to a neighbouring syllable
+			if n := len(syllables); n > 0 {
+				syllables[n-1].phones = append(syllables[n-1].phones, chunk.phones...)
+			} else {
+				syllables = append(syllables, chunk)
+			}
+			continue
+		}
+		if n := len(syllables); n > 0 && !hasVowel(syllables[n-1].phones) {
+			split[0].phones = append(syllables[n-1].phones, split[0].phones...)
+			syllables = syllables[:n-1]
+		}
+		syllables = append(syllables, split...)
+	}
+	return syllables
+}
+
+func splitChunk(chunk syllable) []syllable {
+	var nuclei []int
+	for i, p := range chunk.phones {
+		if p.vowel {
+			nuclei = append(nuclei, i)
+		}
+	}
+	if len(nuclei) == 0 {
+		return nil
+	}
+
+	phones := append([]phone(nil), chunk.phones...)
+	var out []syllable
+	start := 0
+	for k := 0; k+1 < len(nuclei); k++ {
+		a, b := nuclei[k], nuclei[k+1]
+		consonants := b - a - 1
+
+		var boundary int
+		switch {
+		case consonants == 0:
+			boundary = b
+		case consonants == 1 && lax[phones[a].sym]:
+			phones[a+1].moved = true
+			boundary = b
+		case consonants == 1:
+			boundary = a + 1
+		case onsets[phones[b-2].sym+phones[b-1].sym]:
+			boundary = b - 2
+		default:
+			boundary = b - 1
+		}
+
+		out = append(out, syllable{phones: phones[start:boundary:boundary]})
+		start = boundary
+	}
+	out = append(out, syllable{phones: phones[start:]})
+	out[0].stress = chunk.stress
+	return out
+}
+
+// closeShortVowels moves a single onset consonant back across an explicit
+// break when the syllable before ends in a short vowel, so "ne-tik" reads
+// "net-ik". Stressed syllables keep their onset.
+func closeShortVowels(syllables []syllable) {
+	for i := 0; i+1 < len(syllables); i++ {
+		left, right := &syllables[i], &syllables[i+1]
+		if right.stress != unstressed || len(left.phones) == 0 || len(right.phones) < 2 {
+			continue
+		}
+		last := left.phones[len(left.phones)-1]
+		if !last.vowel || !lax[last.sym] || right.phones[0].vowel || !right.phones[1].vowel {
+			continue
+		}
+
+		moved := right.phones[0]
+		moved.moved = true
+		left.phones = append(left.phones, moved)
+		right.phones = right.phones[1:]
+	}
+}
+
+func hasVowel(phones []phone) bool {
+	for _, p := range phones {
+		if p.vowel {
+			return true
+		}
+	}
+	return false
+}
+
+func render(s syllable) string {
+	var b strings.Builder
+	phones := s.phones
+
+	for i := 0; i < len(phones); i++ {
+		p := phones[i]
+		if !p.vowel {
+			b.WriteString(renderConsonant(phones, i))
+			continue
+		}
+
+		rest := phones[i+1:]
+		closed := len(rest) > 0
+
+		// A vowel before a coda r takes its r-coloured spelling
+		if len(rest) > 0 && rest[0].sym == "r" && !rest[0].moved && (len(rest) == 1 || !rest[1].vowel) {
+			if spelling, ok := rhotic[p.sym]; ok {
+				b.WriteString(spelling)
+				i++
+				continue
+			}
+		}
+
+		b.WriteString(renderVowel(p.sym, i == 0, closed))
+	}
+	return b.String()
+}
+
+func renderVowel(sym string, alone, closed bool) string {
+	switch sym {
+	case "aɪ":
+		if alone {
+			return "eye"
+		}
+		return "y"
+	case "ɪ":
+		if closed {
+			return "i"
+		}
+		return "ih"
+	case "ɛ":
+		if closed {
+			return "e"
+		}
+		return "eh"
+	case "ʌ":
+		if closed {
+			return "u"
+		}
+		return "uh"
+	}
+	return vowelSpellings[sym]
+}
+
+func renderConsonant(phones []phone, i int) string {
+	p := phones[i]
+	switch {
+	case p.sym == "r" && p.moved:
+		return "rr"
+	case p.sym == "g" && i+1 < len(phones) && phones[i+1].vowel:
+		// "g" before e, i or y would read as a "j"
+		if spelling := renderVowel(phones[i+1].sym, false, i+2 < len(phones)); strings.ContainsAny(spelling[:1], "eiy") {
+			return "gh"
+		}
+	case p.sym == "s" && i == len(phones)-1 && i > 0 && phones[i-1].vowel:
+		// A lone final "s" would read as a "z"
+		return "ss"
+	}
+	return consonantSpellings[p.sym]
+}
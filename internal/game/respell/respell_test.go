@@ -0,0 +1,66 @@
+package respell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// corpus pairs dictionary pronunciations with the respelling a human editor
+// would write for them
+var corpus = []struct {
+	word          string
+	pronunciation string
+	want          string
+}{
+	// IPA
+	{"phonetic", "fəˈnɛtɪk", "fuh-NET-ik"},
+	{"cat", "/ˈkæt/", "KAT"},
+	{"button", "ˈbʌtn̩", "BUT-uhn"},
+	{"event", "ɪˈvɛnt", "ih-VENT"},
+	{"water", "ˈwɔːtər", "WAW-ter"},
+	{"ice cream", "ˈaɪsˌkriːm", "EYESS-kreem"},
+	{"rhythm", "ˈrɪðəm", "RIDH-uhm"},
+	{"very", "ˈvɛri", "VERR-ee"},
+	{"butter", "ˈbʌtər", "BUT-er"},
+	{"car", "ˈkɑːr", "KAR"},
+	{"fire", "ˈfaɪər", "FY-er"},
+	{"gift", "ˈɡɪft", "GHIFT"},
+	{"beauty", "ˈbjuːti", "BYOO-tee"},
+	{"understand", "ˌʌndərˈstænd", "un-der-STAND"},
+	{"mother", "ˈmʌðər", "MUDH-er"},
+	{"thought", "ˈθɔːt", "THAWT"},
+	{"chapter", "ˈtʃæptər", "CHAP-ter"},
+	{"measure", "ˈmɛʒər", "MEZH-er"},
+	{"boy", "ˈbɔɪ", "BOY"},
+	{"cow", "ˈkaʊ", "KOW"},
+	{"home", "ˈhoʊm", "HOHM"},
+	{"apple", "ˈæpəl", "AP-uhl"},
+	{"banana", "bəˈnænə", "buh-NAN-uh"},
+
+	// Merriam-Webster
+	{"phonetic", "fə-ˈne-tik", "fuh-NET-ik"},
+	{"family", "ˈfa-mə-lē", "FAM-uh-lee"},
+	{"church", "ˈchərch", "CHURCH"},
+	{"thousand", "ˈthau̇-zᵊn(d)", "THOW-zuhnd"},
+	{"tomato", "tə-ˈmā-(ˌ)tō", "tuh-MAY-toh"},
+	{"judge", "ˈjəj", "JUJ"},
+	{"either", "ˈē-t͟hər, ˈī-", "EE-dher"},
+	{"shoe", "ˈshü", "SHOO"},
+	{"book", "ˈbu̇k", "BUUK"},
+	{"father", "ˈfä-t͟hər", "FAH-dher"},
+	{"alike", "ə-ˈlīk", "uh-LYK"},
+}
+
+func TestRespellCorpus(t *testing.T) {
+	for _, tc := range corpus {
+		t.Run(tc.word, func(t *testing.T) {
+			assert.Equal(t, tc.want, Respell(tc.pronunciation), tc.pronunciation)
+		})
+	}
+}
+
+func TestRespellEmpty(t *testing.T) {
+	assert.Empty(t, Respell(""))
+	assert.Empty(t, Respell("ˈ"))
+}
@@ -0,0 +1,89 @@
+package respell
+
+// mapping rewrites a notation-specific symbol into one or more canonical
+// phones. Canonical phones are IPA, with diphthongs and affricates kept as
+// single phones.
+type mapping struct {
+	from string
+	to   []string
+}
+
+func m(from string, to ...string) mapping {
+	return mapping{from: from, to: to}
+}
+
+var ipaTable = []mapping{
+	// Vowels
+	m("æ", "æ"), m("a", "æ"), m("ɑ", "ɑ"), m("ɒ", "ɒ"), m("ɔ", "ɔ"),
+	m("ɛ", "ɛ"), m("e", "ɛ"), m("eɪ", "eɪ"), m("ɪ", "ɪ"), m("ᵻ", "ɪ"),
+	m("i", "i"), m("aɪ", "aɪ"), m("o", "oʊ"), m("oʊ", "oʊ"), m("əʊ", "oʊ"),
+	m("ɔɪ", "ɔɪ"), m("aʊ", "aʊ"), m("ʊ", "ʊ"), m("u", "u"), m("ʌ", "ʌ"),
+	m("ɐ", "ʌ"), m("ə", "ə"), m("ɜ", "ɜ"), m("ɝ", "ɜ"), m("ɚ", "ə", "r"),
+	m("ɪə", "ɪ", "r"), m("ɛə", "ɛ", "r"), m("eə", "ɛ", "r"), m("ʊə", "ʊ", "r"),
+
+	// Consonants
+	m("p", "p"), m("b", "b"), m("t", "t"), m("d", "d"), m("k", "k"),
+	m("g", "g"), m("ɡ", "g"), m("f", "f"), m("v", "v"), m("θ", "θ"),
+	m("ð", "ð"), m("s", "s"), m("z", "z"), m("ʃ", "ʃ"), m("ʒ", "ʒ"),
+	m("h", "h"), m("tʃ", "tʃ"), m("t͡ʃ", "tʃ"), m("dʒ", "dʒ"), m("d͡ʒ", "dʒ"),
+	m("m", "m"), m("n", "n"), m("ŋ", "ŋ"), m("l", "l"), m("ɫ", "l"),
+	m("r", "r"), m("ɹ", "r"), m("ɾ", "t"), m("w", "w"), m("j", "j"),
+	m("x", "x"), m("ʍ", "w"),
+}
+
+var mwTable = []mapping{
+	// Vowels
+	m("ə", "ə"), m("ᵊ", "ə"), m("ər", "ə", "r"), m("a", "æ"), m("ȧ", "æ"),
+	m("ā", "eɪ"), m("ä", "ɑ"), m("au̇", "aʊ"), m("e", "ɛ"), m("ē", "i"),
+	m("i", "ɪ"), m("ī", "aɪ"), m("ō", "oʊ"), m("ȯ", "ɔ"), m("ȯi", "ɔɪ"),
+	m("ü", "u"), m("u̇", "ʊ"),
+
+	// Consonants
+	m("b", "b"), m("ch", "tʃ"), m("d", "d"), m("f", "f"), m("g", "g"),
+	m("h", "h"), m("hw", "w"), m("j", "dʒ"), m("k", "k"), m("l", "l"),
+	m("m", "m"), m("n", "n"), m("ŋ", "ŋ"), m("p", "p"), m("r", "r"),
+	m("s", "s"), m("sh", "ʃ"), m("t", "t"), m("th", "θ"), m("t͟h", "ð"),
+	m("v", "v"), m("w", "w"), m("y", "j"), m("z", "z"), m("zh", "ʒ"),
+	m("k̟", "x"),
+}
+
+var vowels = map[string]bool{
+	"æ": true, "ɑ": true, "ɒ": true, "ɔ": true, "ɛ": true, "eɪ": true,
+	"ɪ": true, "i": true, "aɪ": true, "oʊ": true, "ɔɪ": true, "aʊ": true,
+	"ʊ": true, "u": true, "ʌ": true, "ə": true, "ɜ": true,
+}
+
+// lax vowels can't end a syllable in a respelling without being misread,
+// so a following consonant is pulled back to close them
+var lax = map[string]bool{
+	"æ": true, "ɛ": true, "ɪ": true, "ɒ": true, "ʊ": true, "ʌ": true,
+}
+
+var vowelSpellings = map[string]string{
+	"æ": "a", "ɑ": "ah", "ɒ": "o", "ɔ": "aw", "eɪ": "ay", "i": "ee",
+	"oʊ": "oh", "ɔɪ": "oy", "aʊ": "ow", "ʊ": "uu", "u": "oo", "ə": "uh",
+	"ɜ": "ur",
+}
+
+// rhotic spells a vowel followed by a coda r
+var rhotic = map[string]string{
+	"ɑ": "ar", "ɔ": "or", "oʊ": "or", "ɛ": "air", "eɪ": "air", "æ": "arr",
+	"ɪ": "eer", "i": "eer", "ʊ": "oor", "u": "oor", "ə": "er", "ʌ": "ur",
+	"ɜ": "ur", "aɪ": "yr", "aʊ": "owr",
+}
+
+var consonantSpellings = map[string]string{
+	"p": "p", "b": "b", "t": "t", "d": "d", "k": "k", "g": "g", "f": "f",
+	"v": "v", "θ": "th", "ð": "dh", "s": "s", "z": "z", "ʃ": "sh",
+	"ʒ": "zh", "h": "h", "tʃ": "ch", "dʒ": "j", "m": "m", "n": "n",
+	"ŋ": "ng", "l": "l", "r": "r", "w": "w", "j": "y", "x": "kh",
+}
+
+// onsets are two-consonant clusters that can begin an English syllable
+var onsets = map[string]bool{
+	"pl": true, "pr": true, "bl": true, "br": true, "tr": true, "dr": true,
+	"kl": true, "kr": true, "kw": true, "gl": true, "gr": true, "fl": true,
+	"fr": true, "θr": true, "ʃr": true, "sp": true, "st": true, "sk": true,
+	"sl": true, "sm": true, "sn": true, "sw": true, "tw": true, "dw": true,
+	"pj": true, "bj": true, "kj": true, "fj": true, "mj": true, "hj": true,
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"errors"
+	"slices"
+)
+
+// RevealPolicy decides when the correct spelling of a missed word is shown
+type RevealPolicy string
+
+const (
+	// RevealAlways shows the spelling as soon as the miss is judged
+	RevealAlways RevealPolicy = "always"
+	// RevealEndOfGame withholds the spelling until the game has finished,
+	// so a word that comes back later can't be copied
+	RevealEndOfGame RevealPolicy = "end_of_game"
+	// RevealNever never shows the spelling of a missed word
+	RevealNever RevealPolicy = "never"
+)
+
+var ErrInvalidRevealPolicy = errors.New("invalid reveal policy")
+
+// Valid reports whether p is a known policy. The empty policy is valid and
+// means the default.
+func (p RevealPolicy) Valid() bool {
+	switch p {
+	case "", RevealAlways, RevealEndOfGame, RevealNever:
+		return true
+	}
+	return false
+}
+
+// Reveal returns the policy in effect for the game. Tournaments are locked
+// to RevealEndOfGame whatever the host asked for.
+func (g GameSettings) Reveal() RevealPolicy {
+	if g.IsTournament {
+		return RevealEndOfGame
+	}
+	if g.RevealPolicy == "" {
+		return RevealAlways
+	}
+	return g.RevealPolicy
+}
+
+// revealsMiss reports whether a missed word may be shown in a game that is
+// currently in status
+func (p RevealPolicy) revealsMiss(status GameStatus) bool {
+	switch p {
+	case RevealNever:
+		return false
+	case RevealEndOfGame:
+		return status == GameStatusFinished
+	default:
+		return true
+	}
+}
+
+// RevealRecap returns recap as it may be shown for game. Every surface that
+// shows recaps (events, replays, digests) goes through here. Correct
+// attempts spell the word themselves and are never redacted.
+func RevealRecap(game *Game, recap *TurnRecap) *TurnRecap {
+	if recap.Correct || game.Settings.Reveal().revealsMiss(game.Status) {
+		revealed := *recap
+		revealed.Revealed = true
+		return &revealed
+	}
+
+	// Which letters matched gives the spelling away as surely as the word
+	redacted := *recap
+	redacted.Word = ""
+	redacted.Diff = nil
+	redacted.Revealed = false
+	if recap.Source != nil {
+		// Dictionary links name the word in their URL
+		redacted.Source = &SourceAttribution{Name: recap.Source.Name}
+	}
+	return &redacted
+}
+
+// revealAttempt returns attempt as it may be shown for game
+func revealAttempt(game *Game, attempt *SpellingAttempt) *SpellingAttempt {
+	if attempt.IsCorrect || game.Settings.Reveal().revealsMiss(game.Status) {
+		return attempt
+	}
+
+	redacted := *attempt
+	redacted.Word = ""
+	return &redacted
+}
+
+// attemptDiff lines attempt up against the word, or returns nil when the
+// miss mustn't be revealed yet
+func attemptDiff(game *Game, attempt *SpellingAttempt) []CharDiff {
+	if !attempt.IsCorrect && !game.Settings.Reveal().revealsMiss(game.Status) {
+		return nil
+	}
+	return DiffSpelling(attempt.Word, attempt.Text)
+}
+
+// onlyFor is an event payload value only some recipients may see, such as
+// the word for the judge ruling on an attempt. The rest get the event
+// without it.
+type onlyFor struct {
+	viewers []string
+	value   any
+}
+
+// maskedWord returns what may be shown of word while it's being spelled
+func maskedWord(word *Word) *Word {
+	if word == nil {
+		return nil
+	}
+	return &Word{ID: word.ID}
+}
+
+// maskedGame returns game with its current word masked while it is
+func maskedGame(game *Game) *Game {
+	if game == nil || game.CurrentWord == nil || !game.WordMasked {
+		return game
+	}
+	masked := *game
+	masked.CurrentWord = maskedWord(game.CurrentWord)
+	return &masked
+}
+
+// eventFor returns event as viewerID may see it. The event log passes every
+// event through here on its way to a subscriber, so words in a payload are
+// always masked and games carry their masked current word; spellings are
+// only revealed once judged, through RevealRecap and revealAttempt.
+func eventFor(event GameEvent, viewerID string) GameEvent {
+	if len(event.Payload) == 0 {
+		return event
+	}
+
+	payload := make(map[string]any, len(event.Payload))
+	for key, value := range event.Payload {
+		switch v := value.(type) {
+		case *Word:
+			payload[key] = maskedWord(v)
+		case *Game:
+			payload[key] = maskedGame(v)
+		case onlyFor:
+			if viewerID != "" && slices.Contains(v.viewers, viewerID) {
+				payload[key] = v.value
+			}
+		default:
+			payload[key] = value
+		}
+	}
+	event.Payload = payload
+	return event
+}
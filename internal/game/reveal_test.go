@@ -0,0 +1,94 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevealPolicyDefaults(t *testing.T) {
+	assert.Equal(t, RevealAlways, GameSettings{}.Reveal())
+	assert.Equal(t, RevealNever, GameSettings{RevealPolicy: RevealNever}.Reveal())
+	assert.Equal(t, RevealEndOfGame, GameSettings{IsTournament: true, RevealPolicy: RevealAlways}.Reveal())
+
+	assert.True(t, RevealPolicy("").Valid())
+	assert.False(t, RevealPolicy("sometimes").Valid())
+}
+
+func TestRevealRecap(t *testing.T) {
+	word := &Word{Word: "heron", Source: "wordnik"}
+	miss := BuildTurnRecap(&Game{}, word, &SpellingAttempt{Text: "herron"}, nil)
+	hit := BuildTurnRecap(&Game{}, word, &SpellingAttempt{Text: "heron", IsCorrect: true}, nil)
+
+	tests := []struct {
+		name     string
+		policy   RevealPolicy
+		status   GameStatus
+		revealed bool
+	}{
+		{"always", RevealAlways, GameStatusActive, true},
+		{"end of game while active", RevealEndOfGame, GameStatusActive, false},
+		{"end of game once finished", RevealEndOfGame, GameStatusFinished, true},
+		{"never", RevealNever, GameStatusFinished, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &Game{Status: tt.status, Settings: GameSettings{RevealPolicy: tt.policy}}
+
+			recap := RevealRecap(game, miss)
+			assert.Equal(t, tt.revealed, recap.Revealed)
+			if tt.revealed {
+				assert.Equal(t, "heron", recap.Word)
+				assert.NotEmpty(t, recap.Diff)
+			} else {
+				assert.Empty(t, recap.Word)
+				assert.Empty(t, recap.Diff)
+				assert.Empty(t, recap.Source.URL)
+				assert.Equal(t, "herron", recap.Attempt)
+			}
+
+			assert.Equal(t, "heron", RevealRecap(game, hit).Word)
+		})
+	}
+
+	// Redaction works on a copy
+	assert.Equal(t, "heron", miss.Word)
+}
+
+func TestEventsNeverCarryTheWord(t *testing.T) {
+	word := &Word{ID: "word-1", Word: "heron", Definition: "a wading bird"}
+	game := &Game{ID: "game-1", CurrentWord: word, WordMasked: true}
+	event := GameEvent{Type: EventTypeRoundStarted, Payload: map[string]any{
+		"game":  game,
+		"word":  word,
+		"round": 2,
+	}}
+
+	for _, viewer := range []string{"", "speller", "spectator"} {
+		shaped := eventFor(event, viewer)
+		assert.Equal(t, &Word{ID: "word-1"}, shaped.Payload["word"])
+		assert.Equal(t, &Word{ID: "word-1"}, shaped.Payload["game"].(*Game).CurrentWord)
+		assert.Equal(t, 2, shaped.Payload["round"])
+	}
+	assert.Equal(t, "heron", word.Word, "the published event is left alone")
+	assert.Same(t, word, game.CurrentWord)
+
+	// Only a masked word is hidden from a game
+	game.WordMasked = false
+	assert.Same(t, game, maskedGame(game))
+}
+
+func TestOnlySomeRecipientsSeeAValue(t *testing.T) {
+	event := GameEvent{Type: EventTypeReviewRequested, Payload: map[string]any{
+		"attempt_id": "attempt-1",
+		"word":       onlyFor{viewers: []string{"judge"}, value: "heron"},
+	}}
+
+	assert.Equal(t, "heron", eventFor(event, "judge").Payload["word"])
+	for _, viewer := range []string{"", "speller"} {
+		shaped := eventFor(event, viewer)
+		assert.NotContains(t, shaped.Payload, "word")
+		assert.Equal(t, "attempt-1", shaped.Payload["attempt_id"])
+	}
+}
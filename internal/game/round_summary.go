@@ -0,0 +1,135 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RoundSummary is how everyone did in the round just played, sent as the
+// intermission starts so clients can show a scoreboard until the next round
+type RoundSummary struct {
+	Round int `json:"round"`
+	// Word is the round's word, which was spelled to end it
+	Word    string             `json:"word"`
+	Players []RoundPerformance `json:"players"`
+	// Eliminated are the players out of the game since the last round,
+	// whether they left, were kicked or were dropped for not playing
+	Eliminated []string `json:"eliminated"`
+}
+
+// RoundPerformance is one player's round. Players are listed by Score, the
+// points they have so far, highest first.
+type RoundPerformance struct {
+	PlayerID string `json:"player_id"`
+	Attempts int    `json:"attempts"`
+	Correct  int    `json:"correct"`
+	// AnswerMS is how long their correct answer took, if they gave one
+	AnswerMS  *int64 `json:"answer_ms,omitempty"`
+	HintsUsed int    `json:"hints_used"`
+	// ScoreDelta is the points they earned this round
+	ScoreDelta int `json:"score_delta"`
+	Score      int `json:"score"`
+}
+
+// roundTally is a player's judged attempts over the game and the round
+type roundTally struct {
+	PlayerID   string `db:"player_id"`
+	Attempts   int    `db:"attempts"`
+	Correct    int    `db:"correct"`
+	AnswerMS   *int64 `db:"answer_ms"`
+	HintsUsed  int    `db:"hints_used"`
+	ScoreDelta int    `db:"score_delta"`
+	Score      int    `db:"score"`
+}
+
+// roundSummary sums up the round game has just played
+func (s *gameService) roundSummary(ctx context.Context, game *Game, engine *GameEngine) (*RoundSummary, error) {
+	var tallies []roundTally
+	if err := s.db.SelectContext(ctx, &tallies, `
+		SELECT player_id,
+			COUNT(*) FILTER (WHERE timestamp >= $3) AS attempts,
+			COUNT(*) FILTER (WHERE timestamp >= $3 AND is_correct) AS correct,
+			MIN(answer_ms) FILTER (WHERE timestamp >= $3 AND is_correct) AS answer_ms,
+			COALESCE(SUM(hints_used) FILTER (WHERE timestamp >= $3), 0) AS hints_used,
+			COALESCE(SUM(points) FILTER (WHERE timestamp >= $3), 0) AS score_delta,
+			COALESCE(SUM(points), 0) AS score
+		FROM spelling_attempts
+		WHERE game_id = $1 AND status = $2
+		GROUP BY player_id`, game.ID, AttemptStatusJudged, engine.RoundStartedAt); err != nil {
+		return nil, fmt.Errorf("failed to tally round: %w", err)
+	}
+
+	var players []*Player
+	if err := s.db.SelectContext(ctx, &players, `
+		SELECT player_id, status FROM players WHERE game_id = $1`, game.ID); err != nil {
+		return nil, fmt.Errorf("failed to get players: %w", err)
+	}
+
+	var word string
+	if engine.CurrentWord != nil {
+		word = engine.CurrentWord.Word
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return summarizeRound(game.Round, word, tallies, players, engine), nil
+}
+
+// summarizeRound builds the summary of round from each player's tally,
+// reporting the players who are no longer active and haven't been reported
+// out by an earlier round
+func summarizeRound(round int, word string, tallies []roundTally, players []*Player, engine *GameEngine) *RoundSummary {
+	summary := &RoundSummary{Round: round, Word: word, Players: []RoundPerformance{}, Eliminated: []string{}}
+
+	byPlayer := make(map[string]roundTally, len(tallies))
+	for _, tally := range tallies {
+		byPlayer[tally.PlayerID] = tally
+	}
+
+	if engine.reportedOut == nil {
+		engine.reportedOut = make(map[string]bool)
+	}
+	for _, player := range players {
+		if player == nil {
+			continue
+		}
+		if player.Status != "active" {
+			if !engine.reportedOut[player.UserID] {
+				engine.reportedOut[player.UserID] = true
+				summary.Eliminated = append(summary.Eliminated, player.UserID)
+			}
+			continue
+		}
+
+		tally := byPlayer[player.UserID]
+		summary.Players = append(summary.Players, RoundPerformance{
+			PlayerID:   player.UserID,
+			Attempts:   tally.Attempts,
+			Correct:    tally.Correct,
+			AnswerMS:   tally.AnswerMS,
+			HintsUsed:  tally.HintsUsed,
+			ScoreDelta: tally.ScoreDelta,
+			Score:      tally.Score,
+		})
+	}
+
+	sort.SliceStable(summary.Players, func(i, j int) bool {
+		return summary.Players[i].Score > summary.Players[j].Score
+	})
+	return summary
+}
+
+// emitRoundSummary sends the summary of the round game has just played. The
+// round has still ended if it can't be summed up, so the intermission goes
+// ahead without one.
+func (s *gameService) emitRoundSummary(ctx context.Context, game *Game, engine *GameEngine, endsAt *time.Time) {
+	summary, err := s.roundSummary(ctx, game, engine)
+	if err != nil {
+		return
+	}
+	s.emitEvent(EventTypeRoundSummary, game.ID, nil, map[string]any{
+		"summary": summary,
+		"next_at": endsAt,
+	})
+}
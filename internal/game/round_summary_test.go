@@ -0,0 +1,39 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeRound(t *testing.T) {
+	answerMS := int64(4200)
+	tallies := []roundTally{
+		{PlayerID: "ada", Attempts: 1, Correct: 1, AnswerMS: &answerMS, HintsUsed: 1, ScoreDelta: 8, Score: 18},
+		{PlayerID: "ben", Attempts: 1, ScoreDelta: 0, Score: 20},
+		{PlayerID: "cy", Attempts: 2, Score: 5},
+	}
+	players := []*Player{
+		{UserID: "ada", Status: "active"},
+		{UserID: "ben", Status: "active"},
+		{UserID: "cy", Status: "left"},
+		{UserID: "dee", Status: "active"},
+	}
+	engine := NewGameEngine("game-1", nil)
+
+	summary := summarizeRound(3, "rhythm", tallies, players, engine)
+
+	assert.Equal(t, 3, summary.Round)
+	assert.Equal(t, "rhythm", summary.Word)
+	assert.Equal(t, []string{"cy"}, summary.Eliminated)
+	assert.Equal(t, []RoundPerformance{
+		{PlayerID: "ben", Attempts: 1, Score: 20},
+		{PlayerID: "ada", Attempts: 1, Correct: 1, AnswerMS: &answerMS, HintsUsed: 1, ScoreDelta: 8, Score: 18},
+		{PlayerID: "dee"},
+	}, summary.Players)
+
+	// Players are only reported out in the round they went
+	summary = summarizeRound(4, "queue", nil, players, engine)
+	assert.Empty(t, summary.Eliminated)
+	assert.Len(t, summary.Players, 3)
+}
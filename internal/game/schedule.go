@@ -0,0 +1,247 @@
+package game
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/notifications"
+)
+
+const (
+	DefaultLobbyOpensBefore = 15 * time.Minute
+	MaxLobbyOpensBefore     = time.Hour
+	// MaxScheduleAhead is how far ahead games can be scheduled
+	MaxScheduleAhead = 90 * 24 * time.Hour
+)
+
+// calendarEventLength is how long a game is put in calendars for, since
+// games don't have a set length
+const calendarEventLength = time.Hour
+
+// upcomingStatuses are those of scheduled games that haven't started yet
+var upcomingStatuses = []GameStatus{GameStatusScheduled, GameStatusWaiting}
+
+func (s GameSettings) lobbyOpensBefore() time.Duration {
+	if s.LobbyOpensBefore <= 0 {
+		return DefaultLobbyOpensBefore
+	}
+	return s.LobbyOpensBefore
+}
+
+// activePlayers counts the game's players who haven't left or been dropped
+func activePlayers(game *Game) int {
+	players := 0
+	for _, player := range game.Players {
+		if player != nil && player.Status == "active" {
+			players++
+		}
+	}
+	return players
+}
+
+// UpcomingGames lists the scheduled games that haven't started yet, soonest
+// first. Private games are only listed for their host and players.
+func (s *gameService) UpcomingGames(ctx context.Context, userID string) ([]LobbySummary, error) {
+	games := []LobbySummary{}
+	if err := s.reads.SelectContext(ctx, &games, `
+		SELECT g.id, g.type, g.status, g.host_id, g.settings, g.created_at, g.scheduled_at,
+			COALESCE(u.username, '') AS host_name,
+			(SELECT COUNT(*) FROM players p
+				WHERE p.game_id = g.id AND p.status = 'active') AS player_count
+		FROM games g
+		LEFT JOIN users u ON u.id = g.host_id
+		WHERE g.status = ANY($1) AND g.scheduled_at > NOW()
+			AND (COALESCE((g.settings->>'is_private')::boolean, false) = false
+				OR g.host_id = $2
+				OR EXISTS (SELECT 1 FROM players fp WHERE fp.game_id = g.id AND fp.player_id = $2))
+		ORDER BY g.scheduled_at, g.id
+		LIMIT $3`, pq.Array(upcomingStatuses), userID, MaxPageSize); err != nil {
+		return nil, fmt.Errorf("failed to list upcoming games: %w", err)
+	}
+	return games, nil
+}
+
+// AdvanceSchedule opens the lobbies of scheduled games that are due to
+// open, reminding their players, then starts the games whose time has come.
+// Games without enough players to start are cancelled. Each lobby is only
+// opened by one caller, so running it on every instance is safe.
+func (s *gameService) AdvanceSchedule(ctx context.Context) error {
+	var opened []struct {
+		ID          string    `db:"id"`
+		ScheduledAt time.Time `db:"scheduled_at"`
+	}
+	if err := s.db.SelectContext(ctx, &opened, `
+		UPDATE games
+		SET status = $1, updated_at = NOW(), version = version + 1
+		WHERE status = $2 AND lobby_opens_at <= NOW()
+		RETURNING id, scheduled_at`, GameStatusWaiting, GameStatusScheduled); err != nil {
+		return fmt.Errorf("failed to open lobbies: %w", err)
+	}
+
+	var errs []error
+	for _, lobby := range opened {
+		if err := s.openLobby(ctx, lobby.ID, lobby.ScheduledAt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var due []string
+	if err := s.db.SelectContext(ctx, &due, `
+		SELECT id FROM games
+		WHERE status = $1 AND scheduled_at <= NOW()
+		ORDER BY scheduled_at`, GameStatusWaiting); err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to find games due to start: %w", err))...)
+	}
+	for _, gameID := range due {
+		if err := s.startScheduled(ctx, gameID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start scheduled game %s: %w", gameID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// openLobby lets the game's clients know its lobby has opened and reminds
+// its host and the players signed up that it starts at start
+func (s *gameService) openLobby(ctx context.Context, gameID string, start time.Time) error {
+	s.emitEvent(EventTypeLobbyOpened, gameID, nil, map[string]any{
+		"status":       GameStatusWaiting,
+		"scheduled_at": start,
+	})
+
+	if s.notifier == nil {
+		return nil
+	}
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	reminder := notifications.GameStarting(gameID, time.Until(start))
+	s.notifier.Notify(ctx, game.HostID, reminder)
+	for _, player := range game.Players {
+		if player != nil && player.Status == "active" && !player.IsBot && player.UserID != game.HostID {
+			s.notifier.Notify(ctx, player.UserID, reminder)
+		}
+	}
+	return nil
+}
+
+// startScheduled starts a game whose scheduled time has come, or cancels it
+// if too few players turned up. A lobby running a ready check is left to
+// the check.
+func (s *gameService) startScheduled(ctx context.Context, gameID string) error {
+	game, err := s.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	if game.Status != GameStatusWaiting || game.ReadyCheck != nil {
+		return nil
+	}
+
+	if players := activePlayers(game); players == 0 || players < game.Settings.MinPlayers {
+		return s.cancelGame(ctx, game, "not enough players")
+	}
+
+	// The host may have started it already, here or on another instance
+	_, err = s.StartGame(ctx, gameID, game.HostID)
+	if errors.Is(err, ErrInvalidGameState) || errors.Is(err, ErrReadyCheckPending) || errors.Is(err, ErrGameChanged) {
+		return nil
+	}
+	return err
+}
+
+// Scheduler opens, starts and cancels scheduled games in the background
+type Scheduler struct {
+	games GameService
+}
+
+// NewScheduler keeps the games games holds to their schedule
+func NewScheduler(games GameService) *Scheduler {
+	return &Scheduler{games: games}
+}
+
+// Run calls AdvanceSchedule every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.games.AdvanceSchedule(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Calendar renders games as an iCalendar feed that calendar apps can
+// import, with an event for each at its scheduled time
+func Calendar(games []LobbySummary, now time.Time) []byte {
+	var b bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+	stamp := func(t time.Time) string {
+		return t.UTC().Format("20060102T150405Z")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Big Spella//Upcoming games//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	for _, game := range games {
+		if game.ScheduledAt == nil {
+			continue
+		}
+		summary := "Big Spella game"
+		if game.Settings.IsTournament {
+			summary = "Big Spella tournament game"
+		}
+		if game.HostName != "" {
+			summary += " hosted by " + game.HostName
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", game.ID+"@bigspella")
+		line("DTSTAMP", stamp(now))
+		line("DTSTART", stamp(*game.ScheduledAt))
+		line("DTEND", stamp(game.ScheduledAt.Add(calendarEventLength)))
+		line("SUMMARY", escapeText(summary))
+		line("DESCRIPTION", escapeText(fmt.Sprintf("Word level %d. %d of %d players signed up. The lobby opens %d minutes before the start.",
+			game.Settings.WordLevel, game.PlayerCount, game.Settings.MaxPlayers, int(game.Settings.lobbyOpensBefore().Minutes()))))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+// escapeText escapes the characters iCalendar gives a meaning to in text
+var escapeText = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// writeFolded writes an iCalendar content line, folded so no line is longer
+// than 75 bytes, without splitting a UTF-8 character
+func writeFolded(b *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The space starting a continuation counts towards its length
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
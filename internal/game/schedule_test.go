@@ -0,0 +1,89 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGameRequestChecksSchedule(t *testing.T) {
+	settings := validSettings()
+	start := time.Now().Add(2 * time.Hour)
+	settings.ScheduledAt = &start
+	settings.LobbyOpensBefore = 30 * time.Minute
+
+	req := CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = CreateGameRequest{Type: GameTypeSolo, Settings: settings}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "settings.scheduled_at", "solo games can't be scheduled")
+
+	settings.LobbyOpensBefore = 2 * time.Hour
+	req = CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "settings.lobby_opens_before")
+
+	settings.LobbyOpensBefore = 0
+	for _, start := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(MaxScheduleAhead + time.Hour)} {
+		settings.ScheduledAt = &start
+		req = CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+		req.validate()
+		assert.Contains(t, req.Validator.FieldErrors, "settings.scheduled_at", start)
+	}
+}
+
+func TestLobbyOpensBefore(t *testing.T) {
+	assert.Equal(t, DefaultLobbyOpensBefore, GameSettings{}.lobbyOpensBefore())
+	assert.Equal(t, 5*time.Minute, GameSettings{LobbyOpensBefore: 5 * time.Minute}.lobbyOpensBefore())
+}
+
+func TestActivePlayers(t *testing.T) {
+	game := &Game{Players: []*Player{{UserID: "ada", Status: "active"}, nil, {UserID: "bo", Status: "kicked"}, {UserID: "cy", Status: "active"}}}
+	assert.Equal(t, 2, activePlayers(game))
+}
+
+func TestCalendar(t *testing.T) {
+	start := time.Date(2026, 11, 2, 18, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	games := []LobbySummary{
+		{ID: "game-1", HostName: "ada; the, bee", PlayerCount: 3, ScheduledAt: &start,
+			Settings: GameSettings{MaxPlayers: 8, WordLevel: 4, IsTournament: true}},
+		{ID: "game-2"},
+	}
+
+	ics := string(Calendar(games, now))
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 1, strings.Count(ics, "BEGIN:VEVENT"), "games without a start time are left out")
+	assert.Contains(t, ics, "UID:game-1@bigspella\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20261015T120000Z\r\n")
+	assert.Contains(t, ics, "DTSTART:20261102T233000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20261103T003000Z\r\n")
+	assert.Contains(t, ics, `SUMMARY:Big Spella tournament game hosted by ada\; the\, bee`)
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, "DESCRIPTION:Word level 4. 3 of 8 players signed up. The lobby opens 15 minutes before the start.\r\n")
+}
+
+func TestWriteFoldedKeepsCharactersWhole(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 50)
+	var b bytes.Buffer
+	writeFolded(&b, line)
+
+	folded := b.String()
+	for _, part := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(part), 75)
+		assert.True(t, utf8.ValidString(part), part)
+	}
+	assert.Equal(t, line+"\r\n", strings.ReplaceAll(folded, "\r\n ", ""))
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"big-spella-go/internal/game/modes"
+)
+
+// WordPoints is what spelling a word correctly scores before any bonuses,
+// unless the game sets its own points per word
+const WordPoints = modes.DefaultPointsPerCorrect
+
+// AttemptMatch classifies how close an attempt came to the word
+type AttemptMatch string
+
+const (
+	MatchExact     AttemptMatch = "exact"
+	MatchNear      AttemptMatch = "near"
+	MatchIncorrect AttemptMatch = "incorrect"
+)
+
+// ScoringSettings are the rules a game is scored by: its mode's rules with
+// any the host overrides, plus partial credit for near misses
+type ScoringSettings struct {
+	modes.ScoringConfig
+	// NearMissCredit is the share of a word's points, from 0 to 1, a near
+	// miss scores. Ranked games never award it.
+	NearMissCredit float64 `json:"near_miss_credit"`
+}
+
+// scoring returns the game's scoring rules with its mode's defaults filled in
+func (g GameSettings) scoring() modes.ScoringConfig {
+	return g.Scoring.ScoringConfig.WithDefaults(g.Mode)
+}
+
+// nearMissDistance is the most edits an attempt at word can be off by and
+// still count as a near miss. Short words have no near misses, since one
+// letter changes too much of them.
+func nearMissDistance(word string) int {
+	switch n := utf8.RuneCountInString(strings.TrimSpace(word)); {
+	case n < 4:
+		return 0
+	case n <= 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance counts the edits in diff. Two neighbouring letters swapped
+// count as one edit, since that is how people make the mistake.
+func editDistance(diff []CharDiff) int {
+	edits := 0
+	for i := 0; i < len(diff); i++ {
+		if diff[i].Op == DiffOpMatch {
+			continue
+		}
+		edits++
+		i += swapLength(diff[i:]) - 1
+	}
+	return edits
+}
+
+// swapLength returns how many steps at the start of diff make up a swap of
+// neighbouring letters, or 1 when they don't. DiffSpelling shows a swap
+// either as two substitutions or as a letter moved past its neighbour.
+func swapLength(diff []CharDiff) int {
+	if len(diff) >= 2 && diff[0].Op == DiffOpSubstitute && diff[1].Op == DiffOpSubstitute &&
+		strings.EqualFold(diff[0].Expected, diff[1].Actual) && strings.EqualFold(diff[0].Actual, diff[1].Expected) {
+		return 2
+	}
+	if len(diff) >= 3 && diff[1].Op == DiffOpMatch {
+		moved := diff[0].Op == DiffOpInsert && diff[2].Op == DiffOpDelete && strings.EqualFold(diff[0].Actual, diff[2].Expected) ||
+			diff[0].Op == DiffOpDelete && diff[2].Op == DiffOpInsert && strings.EqualFold(diff[0].Expected, diff[2].Actual)
+		if moved {
+			return 3
+		}
+	}
+	return 1
+}
+
+// ClassifyAttempt reports how close attempt came to word
+func ClassifyAttempt(word, attempt string) AttemptMatch {
+	edits := editDistance(DiffSpelling(word, attempt))
+	switch {
+	case edits == 0:
+		return MatchExact
+	case edits <= nearMissDistance(word):
+		return MatchNear
+	default:
+		return MatchIncorrect
+	}
+}
+
+// points is what an attempt classified as match scores in a game with these
+// settings, before any bonuses
+func (g GameSettings) points(match AttemptMatch) int {
+	perWord := g.scoring().PointsPerCorrect
+	switch {
+	case match == MatchExact:
+		return perWord
+	case match == MatchNear && !g.IsRanked:
+		return int(math.Round(g.Scoring.NearMissCredit * float64(perWord)))
+	default:
+		return 0
+	}
+}
+
+// scoreAttempt classifies a ruled attempt and sets what it scores. A ruling
+// always wins over the classification: an attempt ruled correct is exact
+// and one ruled incorrect is at best near. Correct attempts earn the game's
+// speed bonus and, with streak the words the player had already spelled in
+// a row, its streak multiplier.
+func scoreAttempt(game *Game, attempt *SpellingAttempt, streak int) {
+	attempt.Match = ClassifyAttempt(attempt.Word, attempt.Text)
+	switch {
+	case attempt.IsCorrect:
+		attempt.Match = MatchExact
+	case attempt.Match == MatchExact:
+		attempt.Match = MatchIncorrect
+	}
+	attempt.Points = game.Settings.points(attempt.Match)
+
+	if attempt.Match == MatchExact {
+		config := game.Settings.scoring()
+		speed := 1.0
+		if attempt.AnswerMS != nil {
+			speed = config.SpeedMultiplier(time.Duration(*attempt.AnswerMS) * time.Millisecond)
+		}
+		attempt.Points = modes.Apply(attempt.Points, speed, config.StreakMultiplier(streak+1))
+	}
+}
@@ -0,0 +1,79 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/game/modes"
+)
+
+func TestClassifyAttempt(t *testing.T) {
+	assert.Equal(t, MatchExact, ClassifyAttempt("Accommodate", "accommodate"))
+	assert.Equal(t, MatchNear, ClassifyAttempt("accommodate", "accomodate"))
+	assert.Equal(t, MatchNear, ClassifyAttempt("accommodate", "acommodat"))
+	assert.Equal(t, MatchIncorrect, ClassifyAttempt("accommodate", "acomodat"))
+	assert.Equal(t, MatchIncorrect, ClassifyAttempt("cat", "kat"), "short words have no near misses")
+}
+
+func TestScoreAttempt(t *testing.T) {
+	game := &Game{Settings: GameSettings{Scoring: ScoringSettings{NearMissCredit: 0.5}}}
+
+	near := &SpellingAttempt{Word: "receive", Text: "recieve"}
+	scoreAttempt(game, near, 0)
+	assert.Equal(t, MatchNear, near.Match)
+	assert.Equal(t, 5, near.Points)
+
+	game.Settings.IsRanked = true
+	scoreAttempt(game, near, 0)
+	assert.Equal(t, 0, near.Points, "ranked games give no partial credit")
+
+	// A judge's ruling wins over the transcription
+	overruled := &SpellingAttempt{Word: "receive", Text: "receive", IsCorrect: false}
+	scoreAttempt(game, overruled, 0)
+	assert.Equal(t, MatchIncorrect, overruled.Match)
+
+	upheld := &SpellingAttempt{Word: "receive", Text: "recieve", IsCorrect: true}
+	scoreAttempt(game, upheld, 0)
+	assert.Equal(t, MatchExact, upheld.Match)
+	assert.Equal(t, WordPoints, upheld.Points)
+}
+
+func TestScoreAttemptBonuses(t *testing.T) {
+	game := &Game{Settings: GameSettings{Mode: modes.ModeRapidFire}}
+
+	fast, slow := int64(2000), int64(8000)
+	quick := &SpellingAttempt{Word: "receive", Text: "receive", IsCorrect: true, AnswerMS: &fast}
+	scoreAttempt(game, quick, 0)
+	assert.Equal(t, 15, quick.Points, "rapid fire pays a speed bonus by default")
+
+	game.Settings.Scoring.PointsPerCorrect = 20
+	game.Settings.Scoring.SpeedBonuses = []modes.SpeedBonus{}
+	game.Settings.Scoring.StreakMultipliers = []modes.StreakMultiplier{{Streak: 3, Multiplier: 2}}
+
+	steady := &SpellingAttempt{Word: "receive", Text: "receive", IsCorrect: true, AnswerMS: &slow}
+	scoreAttempt(game, steady, 1)
+	assert.Equal(t, 20, steady.Points)
+	scoreAttempt(game, steady, 2)
+	assert.Equal(t, 40, steady.Points, "the third word in a row doubles")
+
+	missed := &SpellingAttempt{Word: "receive", Text: "recieve"}
+	scoreAttempt(game, missed, 2)
+	assert.Equal(t, 0, missed.Points, "streaks only multiply correct words")
+}
+
+func TestHintCost(t *testing.T) {
+	legacy, configured := 4, 2
+	assert.Equal(t, DefaultHintPenalty, GameSettings{}.HintCost())
+	assert.Equal(t, legacy, GameSettings{HintPenalty: &legacy}.HintCost())
+
+	settings := GameSettings{HintPenalty: &legacy}
+	settings.Scoring.HintPenalty = &configured
+	assert.Equal(t, configured, settings.HintCost())
+}
+
+func TestEditDistanceCountsSwapsOnce(t *testing.T) {
+	assert.Equal(t, 1, editDistance(DiffSpelling("receive", "recieve")))
+	assert.Equal(t, 1, editDistance(DiffSpelling("weird", "wierd")))
+	assert.Equal(t, 2, editDistance(DiffSpelling("receive", "recievd")))
+}
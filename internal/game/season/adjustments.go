@@ -0,0 +1,124 @@
+package season
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/game/ranking"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// ReasonCode says why an admin adjusted a player's rating
+type ReasonCode string
+
+const (
+	ReasonCheating         ReasonCode = "cheating"
+	ReasonAppealCorrection ReasonCode = "appeal_correction"
+	ReasonBugCompensation  ReasonCode = "bug_compensation"
+	ReasonAbandonment      ReasonCode = "abandonment"
+	ReasonOther            ReasonCode = "other"
+)
+
+var ReasonCodes = []ReasonCode{ReasonCheating, ReasonAppealCorrection, ReasonBugCompensation, ReasonAbandonment, ReasonOther}
+
+// Adjustment is a manual correction to a player's rating
+type Adjustment struct {
+	ID                 string     `json:"id" db:"id"`
+	UserID             string     `json:"user_id" db:"user_id"`
+	Points             int        `json:"points" db:"points"`
+	ReasonCode         ReasonCode `json:"reason_code" db:"reason_code"`
+	Note               string     `json:"note" db:"note"`
+	PreviousRankPoints int        `json:"previous_rank_points" db:"previous_rank_points"`
+	NewRankPoints      int        `json:"new_rank_points" db:"new_rank_points"`
+	AdjustedBy         string     `json:"adjusted_by" db:"adjusted_by"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AdjustRating moves a player's rating by points, within the same bounds
+// game results keep it in, and carries the new rating to their standing in
+// the current season if they have one
+func (s *Service) AdjustRating(ctx context.Context, userID string, points int, reason ReasonCode, note, adjustedBy string) (*Adjustment, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	adjustment, change, err := s.adjust(ctx, tx, userID, points, reason, note, adjustedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit adjustment: %w", err)
+	}
+
+	s.adjusted(ctx, adjustment, change)
+	return adjustment, nil
+}
+
+// adjust makes and records an adjustment in tx
+func (s *Service) adjust(ctx context.Context, tx *sqlx.Tx, userID string, points int, reason ReasonCode, note, adjustedBy string, now time.Time) (*Adjustment, *ranking.Change, error) {
+	change, err := ranking.Adjust(ctx, tx, userID, points)
+	if err != nil {
+		if errors.Is(err, ranking.ErrUserNotFound) {
+			return nil, nil, ErrUserNotFound
+		}
+		return nil, nil, err
+	}
+	rating := change.Current.Points
+
+	season := &Season{}
+	err = tx.GetContext(ctx, season, currentSeasonQuery, now)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Between seasons only the rating moves
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to get current season: %w", err)
+	default:
+		// Players who haven't played this season have no standing to move
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE season_standings
+			SET points = $1, updated_at = $2
+			WHERE season_id = $3 AND user_id = $4`,
+			rating, now, season.ID, userID); err != nil {
+			return nil, nil, fmt.Errorf("failed to update season standing: %w", err)
+		}
+	}
+
+	adjustment := &Adjustment{}
+	if err := tx.GetContext(ctx, adjustment, `
+		INSERT INTO ranking_adjustments (id, user_id, points, reason_code, note,
+			previous_rank_points, new_rank_points, adjusted_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING *`,
+		uuid.New().String(), userID, points, reason, note, change.Previous.Points, rating, adjustedBy, now); err != nil {
+		return nil, nil, fmt.Errorf("failed to record adjustment: %w", err)
+	}
+	return adjustment, change, nil
+}
+
+// adjusted audits a committed adjustment and tells the player if it moved
+// their rank
+func (s *Service) adjusted(ctx context.Context, adjustment *Adjustment, change *ranking.Change) {
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{
+			Action:     audit.ActionRatingAdjusted,
+			ActorID:    adjustment.AdjustedBy,
+			TargetType: "user",
+			TargetID:   adjustment.UserID,
+			Before:     map[string]any{"rank_points": change.Previous.Points},
+			After:      adjustment,
+		})
+	}
+
+	change.Notify(ctx, s.notifier)
+}
@@ -0,0 +1,206 @@
+package season
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/notifications"
+)
+
+// ReasonDecay marks the adjustments decay makes. Admins can't make them.
+const ReasonDecay ReasonCode = "decay"
+
+// DecayEvery is how often an inactive player's rating decays
+const DecayEvery = 7 * 24 * time.Hour
+
+// Decay takes Points off the rating of players above Threshold every
+// DecayEvery once they go InactiveAfter without playing a ranked game, but
+// never takes them below Threshold. They are warned Warning before it
+// starts.
+type Decay struct {
+	Threshold     int
+	InactiveAfter time.Duration
+	Points        int
+	Warning       time.Duration
+}
+
+// loss is how many points a week's decay takes off rating
+func (d Decay) loss(rating int) int {
+	return max(min(d.Points, rating-d.Threshold), 0)
+}
+
+// daysLeft is how many days, rounded up, a player who last played a ranked
+// game at lastRanked has before their rating starts to decay
+func (d Decay) daysLeft(lastRanked, now time.Time) int {
+	left := lastRanked.Add(d.InactiveAfter).Sub(now)
+	return max(int(math.Ceil(left.Hours()/24)), 1)
+}
+
+// DefaultDecay decays Yellow players and above after four weeks away
+var DefaultDecay = Decay{Threshold: 900, InactiveAfter: 28 * 24 * time.Hour, Points: 25, Warning: 3 * 24 * time.Hour}
+
+// WithDecay replaces DefaultDecay. Zero Points turns decay off.
+func WithDecay(policy Decay) ServiceOption {
+	return func(s *Service) {
+		s.decay = policy
+	}
+}
+
+// decayDue matches players whose rating is due to decay: those above the
+// threshold ($1), who last played before $2, were warned since then but
+// before $3, and haven't decayed since they last played or since $4
+const decayDue = `
+	rank_points > $1 AND NOT rank_decay_exempt
+	AND last_ranked_at <= $2
+	AND rank_decay_warned_at >= last_ranked_at AND rank_decay_warned_at <= $3
+	AND (rank_decayed_at IS NULL OR rank_decayed_at < last_ranked_at OR rank_decayed_at <= $4)`
+
+// decayDueArgs are decayDue's parameters at now. Players are given the
+// whole Warning however late they were warned.
+func (d Decay) decayDueArgs(now time.Time) []any {
+	return []any{d.Threshold, now.Add(-d.InactiveAfter), now.Add(-d.Warning), now.Add(-DecayEvery)}
+}
+
+// DecayInactive warns players whose rating is about to decay, and decays
+// the ratings of those whose warning has run out. It returns how many
+// ratings decayed.
+func (s *Service) DecayInactive(ctx context.Context) (int, error) {
+	policy := s.decay
+	if policy.Points <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+
+	if err := s.warnDecay(ctx, policy, now); err != nil {
+		return 0, err
+	}
+
+	var due []string
+	if err := s.db.SelectContext(ctx, &due, `
+		SELECT id FROM users WHERE`+decayDue, policy.decayDueArgs(now)...); err != nil {
+		return 0, fmt.Errorf("failed to find ratings due to decay: %w", err)
+	}
+
+	decayed := 0
+	for _, userID := range due {
+		ok, err := s.decayRating(ctx, policy, userID, now)
+		if err != nil {
+			return decayed, err
+		}
+		if ok {
+			decayed++
+		}
+	}
+	return decayed, nil
+}
+
+// warnDecay warns each player once per spell away that their rating is
+// about to decay
+func (s *Service) warnDecay(ctx context.Context, policy Decay, now time.Time) error {
+	var warned []struct {
+		ID           string    `db:"id"`
+		RankColor    string    `db:"rank_color"`
+		LastRankedAt time.Time `db:"last_ranked_at"`
+	}
+	if err := s.db.SelectContext(ctx, &warned, `
+		UPDATE users SET rank_decay_warned_at = $1
+		WHERE rank_points > $2 AND NOT rank_decay_exempt
+			AND last_ranked_at <= $3
+			AND (rank_decay_warned_at IS NULL OR rank_decay_warned_at < last_ranked_at)
+		RETURNING id, rank_color, last_ranked_at`,
+		now, policy.Threshold, now.Add(policy.Warning-policy.InactiveAfter)); err != nil {
+		return fmt.Errorf("failed to warn of rank decay: %w", err)
+	}
+
+	if s.notifier == nil {
+		return nil
+	}
+	for _, user := range warned {
+		s.notifier.Notify(ctx, user.ID, notifications.RankDecayWarning(user.RankColor, policy.daysLeft(user.LastRankedAt, now)))
+	}
+	return nil
+}
+
+// decayRating takes a week's decay off userID's rating if it is still due
+// once they are locked, as they may have played since they were found
+func (s *Service) decayRating(ctx context.Context, policy Decay, userID string, now time.Time) (bool, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var rating int
+	if err := tx.GetContext(ctx, &rating, `
+		UPDATE users SET rank_decayed_at = $5
+		WHERE id = $6 AND`+decayDue+`
+		RETURNING rank_points`,
+		append(policy.decayDueArgs(now), now, userID)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim rank decay: %w", err)
+	}
+
+	adjustment, change, err := s.adjust(ctx, tx, userID, -policy.loss(rating), ReasonDecay, "", "system", now)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit rank decay: %w", err)
+	}
+
+	s.adjusted(ctx, adjustment, change)
+	return true, nil
+}
+
+// SetDecayExempt exempts userID from rank decay, or makes their rating
+// decay again
+func (s *Service) SetDecayExempt(ctx context.Context, userID string, exempt bool) error {
+	// previous is the row as it was before the update
+	var before bool
+	if err := s.db.GetContext(ctx, &before, `
+		UPDATE users u SET rank_decay_exempt = $1
+		FROM users previous
+		WHERE u.id = $2 AND previous.id = u.id
+		RETURNING previous.rank_decay_exempt`, exempt, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to set decay exemption: %w", err)
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{
+			Action:     audit.ActionDecayExemption,
+			TargetType: "user",
+			TargetID:   userID,
+			Before:     map[string]any{"rank_decay_exempt": before},
+			After:      map[string]any{"rank_decay_exempt": exempt},
+		})
+	}
+	return nil
+}
+
+// RunDecay calls DecayInactive every interval until ctx is cancelled
+func (s *Service) RunDecay(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DecayInactive(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package season
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+
+	// currentSeason stands in for the running season's ID in paths
+	currentSeason = "current"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type CreateRequest struct {
+	Name      string              `json:"name"`
+	StartsAt  time.Time           `json:"starts_at"`
+	EndsAt    time.Time           `json:"ends_at"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *CreateRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Name), "name", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(r.Name, 100), "name", "Must not be more than 100 characters")
+	r.Validator.CheckField(!r.StartsAt.IsZero(), "starts_at", "Must be provided")
+	r.Validator.CheckField(r.EndsAt.After(r.StartsAt), "ends_at", "Must be after starts_at")
+}
+
+type StandingsRequest struct {
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseStandingsRequest(query url.Values) StandingsRequest {
+	req := StandingsRequest{Page: 1, PageSize: DefaultPageSize}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *StandingsRequest) validate() {
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+// List serves every season, latest first
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	seasons, err := h.service.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"seasons": seasons})
+}
+
+// Get serves a season, or the running one for the "current" ID
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	season, ok := h.season(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(season)
+}
+
+// Standings serves a page of a season's leaderboard
+func (h *Handler) Standings(w http.ResponseWriter, r *http.Request) {
+	req := parseStandingsRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	season, ok := h.season(w, r)
+	if !ok {
+		return
+	}
+
+	standings, total, err := h.service.Standings(r.Context(), season.ID, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"season":    season,
+		"standings": standings,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	season, err := h.service.Create(r.Context(), req.Name, req.StartsAt, req.EndsAt)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(season)
+}
+
+// Finalize closes an ended season and serves the rewards it handed out
+func (h *Handler) Finalize(w http.ResponseWriter, r *http.Request) {
+	seasonID := httprouter.ParamsFromContext(r.Context()).ByName("seasonID")
+	if !validID(w, seasonID) {
+		return
+	}
+
+	rewards, err := h.service.Finalize(r.Context(), seasonID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"rewards": rewards})
+}
+
+// season looks up the season named in the path
+func (h *Handler) season(w http.ResponseWriter, r *http.Request) (*Season, bool) {
+	seasonID := httprouter.ParamsFromContext(r.Context()).ByName("seasonID")
+
+	var season *Season
+	var err error
+	if seasonID == currentSeason {
+		season, err = h.service.Current(r.Context())
+	} else {
+		if !validID(w, seasonID) {
+			return nil, false
+		}
+		season, err = h.service.Get(r.Context(), seasonID)
+	}
+	if err != nil {
+		serviceError(w, err)
+		return nil, false
+	}
+	return season, true
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrSeasonNotFound), errors.Is(err, ErrNoActiveSeason):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSeasonOverlap), errors.Is(err, ErrSeasonNotOver), errors.Is(err, ErrSeasonFinalized):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// validID checks the season ID path parameter and responds with 422 when it
+// isn't a UUID
+func validID(w http.ResponseWriter, id string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, "season_id", "Must be a valid ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return false
+	}
+	return true
+}
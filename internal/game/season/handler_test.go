@@ -0,0 +1,71 @@
+package season
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRequestValidation(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	req := CreateRequest{Name: "Winter", StartsAt: start, EndsAt: start.AddDate(0, 3, 0)}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = CreateRequest{StartsAt: start, EndsAt: start}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "name")
+	assert.Contains(t, req.Validator.FieldErrors, "ends_at")
+}
+
+func TestStandingsRequestValidation(t *testing.T) {
+	req := parseStandingsRequest(url.Values{})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, DefaultPageSize, req.PageSize)
+
+	req = parseStandingsRequest(url.Values{"page": {"0"}, "page_size": {"lots"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "page")
+	assert.Contains(t, req.Validator.FieldErrors, "page_size")
+}
+
+func TestRewardFor(t *testing.T) {
+	season := &Season{ID: "s1"}
+
+	_, ok := rewardFor(season, Standing{Placement: 1, GamesPlayed: MinGamesForRewards - 1})
+	assert.False(t, ok, "too few games earns nothing")
+
+	reward, ok := rewardFor(season, Standing{UserID: "ada", Placement: 1, Points: 1200, GamesPlayed: 20})
+	assert.True(t, ok)
+	assert.Equal(t, Reward{SeasonID: "s1", UserID: "ada", Badge: "champion", PremiumTrialDays: ChampionTrialDays}, reward)
+
+	reward, _ = rewardFor(season, Standing{Placement: 7, GamesPlayed: 20})
+	assert.Equal(t, "top-10", reward.Badge)
+	assert.Equal(t, TopTenTrialDays, reward.PremiumTrialDays)
+
+	reward, _ = rewardFor(season, Standing{Placement: 40, Points: 620, GamesPlayed: 20})
+	assert.Equal(t, "blue", reward.Badge)
+	assert.Zero(t, reward.PremiumTrialDays)
+}
+
+func TestFinalizeChecksID(t *testing.T) {
+	h := NewHandler(nil)
+	req := httptest.NewRequest(http.MethodPost, "/seasons/x/finalize", nil)
+	req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+		{Key: "seasonID", Value: "x"},
+	}))
+	rec := httptest.NewRecorder()
+
+	h.Finalize(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "season_id")
+}
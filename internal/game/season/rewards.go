@@ -0,0 +1,46 @@
+package season
+
+import (
+	"strings"
+
+	"big-spella-go/internal/game/ranking"
+)
+
+const (
+	// MinGamesForRewards is how many ranked games a player needs in a season
+	// to earn anything at its end
+	MinGamesForRewards = 5
+
+	ChampionTrialDays = 30
+	TopTenTrialDays   = 7
+)
+
+// Reward is what a player earned for how they finished a season
+type Reward struct {
+	SeasonID         string `json:"season_id" db:"season_id"`
+	UserID           string `json:"user_id" db:"user_id"`
+	Badge            string `json:"badge" db:"badge"`
+	PremiumTrialDays int    `json:"premium_trial_days" db:"premium_trial_days"`
+}
+
+// rewardFor works out a finished standing's reward. The top ten get their
+// own badges and a premium trial; everyone else who played enough gets a
+// badge for the rank they finished at.
+func rewardFor(season *Season, standing Standing) (Reward, bool) {
+	if standing.GamesPlayed < MinGamesForRewards {
+		return Reward{}, false
+	}
+
+	reward := Reward{SeasonID: season.ID, UserID: standing.UserID}
+	switch {
+	case standing.Placement == 1:
+		reward.Badge = "champion"
+		reward.PremiumTrialDays = ChampionTrialDays
+	case standing.Placement <= 10:
+		reward.Badge = "top-10"
+		reward.PremiumTrialDays = TopTenTrialDays
+	default:
+		reward.Badge = strings.ToLower(ranking.GetRankByPoints(standing.Points).Color)
+	}
+	return reward, true
+}
@@ -0,0 +1,411 @@
+package season
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/game/ranking"
+	"big-spella-go/internal/notifications"
+)
+
+var (
+	ErrSeasonNotFound  = errors.New("season not found")
+	ErrNoActiveSeason  = errors.New("no season is running")
+	ErrSeasonOverlap   = errors.New("season overlaps another season")
+	ErrSeasonNotOver   = errors.New("season has not ended yet")
+	ErrSeasonFinalized = errors.New("season has already been finalized")
+)
+
+// Season is a stretch of ranked play. Ratings earned in it are soft reset
+// when it is finalized.
+type Season struct {
+	ID          string     `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	StartsAt    time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt      time.Time  `json:"ends_at" db:"ends_at"`
+	FinalizedAt *time.Time `json:"finalized_at,omitempty" db:"finalized_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Standing is one player's place on a season's leaderboard. Placements are
+// live while the season runs and fixed once it is finalized.
+type Standing struct {
+	Placement   int    `json:"placement" db:"placement"`
+	UserID      string `json:"user_id" db:"user_id"`
+	Username    string `json:"username" db:"username"`
+	Points      int    `json:"points" db:"points"`
+	RankColor   string `json:"rank_color" db:"-"`
+	GamesPlayed int    `json:"games_played" db:"games_played"`
+	GamesWon    int    `json:"games_won" db:"games_won"`
+
+	// Badge and PremiumTrialDays are what the player was rewarded with
+	// when the season was finalized
+	Badge            *string `json:"badge,omitempty" db:"badge"`
+	PremiumTrialDays *int    `json:"premium_trial_days,omitempty" db:"premium_trial_days"`
+}
+
+type ServiceOption func(*Service)
+
+// WithNotifier tells players when a result moves them to a new rank color
+func WithNotifier(notifier notifications.Notifier) ServiceOption {
+	return func(s *Service) {
+		s.notifier = notifier
+	}
+}
+
+// WithAuditLog records rating adjustments in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *Service) {
+		s.audit = log
+	}
+}
+
+// Reader runs read-only queries, such as against read replicas
+type Reader interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// WithReadReplicas reads leaderboards from reads, where a standing a
+// moment out of date does no harm
+func WithReadReplicas(reads Reader) ServiceOption {
+	return func(s *Service) {
+		s.reads = reads
+	}
+}
+
+// RewardPublisher shares the rewards players earn when a season ends, such
+// as on their activity feeds
+type RewardPublisher interface {
+	PublishReward(ctx context.Context, reward Reward)
+}
+
+// WithRewardPublisher hands every reward granted by Finalize to publisher
+func WithRewardPublisher(publisher RewardPublisher) ServiceOption {
+	return func(s *Service) {
+		s.rewards = publisher
+	}
+}
+
+type Service struct {
+	db       *sqlx.DB
+	notifier notifications.Notifier
+	audit    audit.Recorder
+	rewards  RewardPublisher
+	reads    Reader
+	decay    Decay
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db, decay: DefaultDecay}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.reads == nil {
+		s.reads = db
+	}
+	return s
+}
+
+// Create schedules a season. Seasons can't overlap.
+func (s *Service) Create(ctx context.Context, name string, startsAt, endsAt time.Time) (*Season, error) {
+	var overlaps bool
+	if err := s.db.GetContext(ctx, &overlaps, `
+		SELECT EXISTS(SELECT 1 FROM seasons WHERE starts_at < $2 AND ends_at > $1)`,
+		startsAt, endsAt); err != nil {
+		return nil, fmt.Errorf("failed to check season dates: %w", err)
+	}
+	if overlaps {
+		return nil, ErrSeasonOverlap
+	}
+
+	season := &Season{}
+	if err := s.db.GetContext(ctx, season, `
+		INSERT INTO seasons (id, name, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *`,
+		uuid.New().String(), name, startsAt, endsAt, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to create season: %w", err)
+	}
+	return season, nil
+}
+
+// List returns every season, latest first
+func (s *Service) List(ctx context.Context) ([]Season, error) {
+	seasons := []Season{}
+	if err := s.db.SelectContext(ctx, &seasons, `SELECT * FROM seasons ORDER BY starts_at DESC`); err != nil {
+		return nil, fmt.Errorf("failed to list seasons: %w", err)
+	}
+	return seasons, nil
+}
+
+func (s *Service) Get(ctx context.Context, seasonID string) (*Season, error) {
+	season := &Season{}
+	if err := s.db.GetContext(ctx, season, `SELECT * FROM seasons WHERE id = $1`, seasonID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, fmt.Errorf("failed to get season: %w", err)
+	}
+	return season, nil
+}
+
+// Current returns the season being played now
+func (s *Service) Current(ctx context.Context) (*Season, error) {
+	season := &Season{}
+	if err := s.db.GetContext(ctx, season, currentSeasonQuery, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoActiveSeason
+		}
+		return nil, fmt.Errorf("failed to get current season: %w", err)
+	}
+	return season, nil
+}
+
+const currentSeasonQuery = `
+	SELECT * FROM seasons
+	WHERE starts_at <= $1 AND ends_at > $1 AND finalized_at IS NULL
+	ORDER BY starts_at DESC
+	LIMIT 1`
+
+// Standings returns a page of the season's leaderboard and how many players
+// are on it
+func (s *Service) Standings(ctx context.Context, seasonID string, limit, offset int) ([]Standing, int, error) {
+	if _, err := s.Get(ctx, seasonID); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	// Players still playing their placement games aren't ranked against
+	// anyone until they finish
+	if err := s.reads.GetContext(ctx, &total, `
+		SELECT COUNT(*)
+		FROM season_standings ss
+		JOIN users u ON u.id = ss.user_id
+		WHERE ss.season_id = $1 AND u.games_played >= $2`, seasonID, ranking.PlacementGames); err != nil {
+		return nil, 0, fmt.Errorf("failed to count standings: %w", err)
+	}
+
+	standings := []Standing{}
+	if err := s.reads.SelectContext(ctx, &standings, `
+		SELECT COALESCE(ss.final_placement, RANK() OVER (ORDER BY ss.points DESC)) AS placement,
+			ss.user_id, u.username, ss.points, ss.games_played, ss.games_won,
+			sr.badge, sr.premium_trial_days
+		FROM season_standings ss
+		JOIN users u ON u.id = ss.user_id
+		LEFT JOIN season_rewards sr ON sr.season_id = ss.season_id AND sr.user_id = ss.user_id
+		WHERE ss.season_id = $1 AND u.games_played >= $4
+		ORDER BY placement, u.username
+		LIMIT $2 OFFSET $3`, seasonID, limit, offset, ranking.PlacementGames); err != nil {
+		return nil, 0, fmt.Errorf("failed to get standings: %w", err)
+	}
+
+	for i := range standings {
+		standings[i].RankColor = ranking.GetRankByPoints(standings[i].Points).Color
+	}
+	return standings, total, nil
+}
+
+// RatingsChanged carries a ranked game's rating changes into the players'
+// standings in the current season, if one is running, in the transaction
+// moving their ratings
+func (s *Service) RatingsChanged(ctx context.Context, tx *sqlx.Tx, gameID string, changes []ranking.Change) error {
+	now := time.Now()
+	season := &Season{}
+	err := tx.GetContext(ctx, season, currentSeasonQuery, now)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Between seasons only the rating moves
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get current season: %w", err)
+	}
+
+	for _, change := range changes {
+		won := 0
+		if change.Won() {
+			won = 1
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO season_standings (season_id, user_id, points, games_played, games_won, updated_at)
+			VALUES ($1, $2, $3, 1, $4, $5)
+			ON CONFLICT (season_id, user_id) DO UPDATE
+			SET points = EXCLUDED.points,
+				games_played = season_standings.games_played + 1,
+				games_won = season_standings.games_won + EXCLUDED.games_won,
+				updated_at = EXCLUDED.updated_at`,
+			season.ID, change.UserID, change.Current.Points, won, now); err != nil {
+			return fmt.Errorf("failed to update season standing: %w", err)
+		}
+	}
+	return nil
+}
+
+// Finalize closes a season that has ended: it fixes the final placements,
+// hands out rewards and soft resets every player's rating for the next one
+func (s *Service) Finalize(ctx context.Context, seasonID string) ([]Reward, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	season := &Season{}
+	if err := tx.GetContext(ctx, season, `SELECT * FROM seasons WHERE id = $1 FOR UPDATE`, seasonID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, fmt.Errorf("failed to get season: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case season.FinalizedAt != nil:
+		return nil, ErrSeasonFinalized
+	case now.Before(season.EndsAt):
+		return nil, ErrSeasonNotOver
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE season_standings ss
+		SET final_placement = ranked.placement
+		FROM (
+			SELECT user_id, RANK() OVER (ORDER BY points DESC) AS placement
+			FROM season_standings
+			WHERE season_id = $1
+		) ranked
+		WHERE ss.season_id = $1 AND ss.user_id = ranked.user_id`, seasonID); err != nil {
+		return nil, fmt.Errorf("failed to place players: %w", err)
+	}
+
+	var standings []Standing
+	if err := tx.SelectContext(ctx, &standings, `
+		SELECT final_placement AS placement, user_id, points, games_played, games_won
+		FROM season_standings
+		WHERE season_id = $1`, seasonID); err != nil {
+		return nil, fmt.Errorf("failed to get standings: %w", err)
+	}
+
+	rewards := []Reward{}
+	for _, standing := range standings {
+		reward, ok := rewardFor(season, standing)
+		if !ok {
+			continue
+		}
+		if err := grantReward(ctx, tx, reward, now); err != nil {
+			return nil, err
+		}
+		rewards = append(rewards, reward)
+	}
+
+	if err := softReset(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE seasons SET finalized_at = $1 WHERE id = $2`, now, seasonID); err != nil {
+		return nil, fmt.Errorf("failed to finalize season: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit season results: %w", err)
+	}
+
+	if s.rewards != nil {
+		for _, reward := range rewards {
+			s.rewards.PublishReward(ctx, reward)
+		}
+	}
+	return rewards, nil
+}
+
+func grantReward(ctx context.Context, tx *sqlx.Tx, reward Reward, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO season_rewards (season_id, user_id, badge, premium_trial_days, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (season_id, user_id) DO NOTHING`,
+		reward.SeasonID, reward.UserID, reward.Badge, reward.PremiumTrialDays, now); err != nil {
+		return fmt.Errorf("failed to record reward: %w", err)
+	}
+
+	if reward.PremiumTrialDays == 0 {
+		return nil
+	}
+
+	// Subscribers with no end date already have premium for good
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET is_premium = TRUE,
+			premium_until = GREATEST(COALESCE(premium_until, $2), $2) + $3 * INTERVAL '1 day'
+		WHERE id = $1 AND (NOT is_premium OR premium_until IS NOT NULL)`,
+		reward.UserID, now, reward.PremiumTrialDays); err != nil {
+		return fmt.Errorf("failed to grant premium trial: %w", err)
+	}
+	return nil
+}
+
+// softReset pulls every player's rating towards the baseline for the next
+// season
+func softReset(ctx context.Context, tx *sqlx.Tx) error {
+	var users []struct {
+		ID     string `db:"id"`
+		Rating int    `db:"rank_points"`
+	}
+	if err := tx.SelectContext(ctx, &users, `
+		SELECT id, rank_points FROM users WHERE rank_points <> $1`, ranking.SeasonBaseline); err != nil {
+		return fmt.Errorf("failed to get ratings: %w", err)
+	}
+
+	for _, user := range users {
+		rating := ranking.SoftReset(user.Rating)
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users SET rank_points = $1, rank_color = $2 WHERE id = $3`,
+			rating, ranking.GetRankByPoints(rating).Color, user.ID); err != nil {
+			return fmt.Errorf("failed to reset rating: %w", err)
+		}
+	}
+	return nil
+}
+
+// FinalizeEnded finalizes every season that has ended and returns how many
+// there were
+func (s *Service) FinalizeEnded(ctx context.Context) (int, error) {
+	var ended []string
+	if err := s.db.SelectContext(ctx, &ended, `
+		SELECT id FROM seasons
+		WHERE ends_at <= $1 AND finalized_at IS NULL
+		ORDER BY ends_at`, time.Now()); err != nil {
+		return 0, fmt.Errorf("failed to list ended seasons: %w", err)
+	}
+
+	for i, seasonID := range ended {
+		if _, err := s.Finalize(ctx, seasonID); err != nil && !errors.Is(err, ErrSeasonFinalized) {
+			return i, err
+		}
+	}
+	return len(ended), nil
+}
+
+// Run calls FinalizeEnded every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.FinalizeEnded(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
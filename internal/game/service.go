@@ -5,10 +5,19 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/game/ranking"
+	"big-spella-go/internal/game/stt"
+	"big-spella-go/internal/jobs"
+	"big-spella-go/internal/notifications"
+	"big-spella-go/internal/queries"
+	"big-spella-go/internal/tracing"
 )
 
 var (
@@ -17,43 +26,399 @@ var (
 	ErrInvalidGameState = errors.New("invalid game state")
 	ErrNotPlayerTurn    = errors.New("not player's turn")
 	ErrPlayerNotFound   = errors.New("player not found")
+	ErrNotHost          = errors.New("only the host can do that")
+	ErrUnknownCategory  = errors.New("category does not exist")
+	ErrNotEnoughPlayers = errors.New("not enough players to start")
 )
 
 type GameService interface {
 	CreateGame(ctx context.Context, hostID string, gameType GameType, settings GameSettings) (*Game, error)
-	JoinGame(ctx context.Context, gameID string, playerID string) (*Game, error)
+	JoinGame(ctx context.Context, gameID string, playerID string, inviteCode string) (*Game, error)
+	JoinGameByCode(ctx context.Context, inviteCode string, playerID string) (*Game, error)
+	JoinGameByInvitation(ctx context.Context, gameID, playerID, token string) (*Game, error)
+	LeaveGame(ctx context.Context, gameID string, playerID string) error
+	KickPlayer(ctx context.Context, gameID string, hostID string, playerID string) error
 	StartGame(ctx context.Context, gameID string, userID string) (*Game, error)
+	// StartReadyCheck has the lobby confirm it's ready before the game
+	// starts, and ConfirmReady confirms a player is
+	StartReadyCheck(ctx context.Context, gameID string, userID string) (*Game, error)
+	ConfirmReady(ctx context.Context, gameID string, playerID string) error
 	MakeAttempt(ctx context.Context, gameID string, playerID string, attempt *SpellingAttempt) error
 	GetGame(ctx context.Context, gameID string) (*Game, error)
-	GetHint(ctx context.Context, gameID string, playerID string) (*Hint, error)
-	Events() <-chan GameEvent
+	ListGames(ctx context.Context, filter GameFilter) ([]LobbySummary, int, error)
+	// UpcomingGames lists the scheduled games userID can see, soonest first
+	UpcomingGames(ctx context.Context, userID string) ([]LobbySummary, error)
+	GetHint(ctx context.Context, gameID string, playerID string, hintType HintType) (*Hint, error)
+	AdvanceRound(ctx context.Context, gameID string, userID string) error
+	PauseGame(ctx context.Context, gameID string, userID string) (*Game, error)
+	ResumeGame(ctx context.Context, gameID string, userID string) (*Game, error)
+	RuleOnAttempt(ctx context.Context, gameID, attemptID, judgeID string, correct bool) error
+	// ConfirmAttempt confirms or rejects the transcription of playerID's
+	// voice attempt, when the game asked them to
+	ConfirmAttempt(ctx context.Context, gameID, playerID, attemptID string, confirmed bool) error
+	SpellLetter(ctx context.Context, gameID, playerID, letter string) error
+	ReplayWord(ctx context.Context, gameID, playerID string) (int, error)
+	PronunciationAudio(ctx context.Context, gameID, playerID string) ([]byte, error)
+	AudioPreferences(ctx context.Context, userID string) (*AudioPreferences, error)
+	UpdateAudioPreferences(ctx context.Context, userID string, prefs AudioPreferences) error
+	FinishSpelling(ctx context.Context, gameID, playerID string) error
+	FileAppeal(ctx context.Context, gameID, attemptID, playerID, reason string) (*Appeal, error)
+	ListAppeals(ctx context.Context, status AppealStatus) ([]Appeal, error)
+	DecideAppeal(ctx context.Context, appealID, reviewer string, overturn bool, note string) (*Appeal, error)
+	CancelGame(ctx context.Context, gameID, reason string) (*Game, error)
+	EndGame(ctx context.Context, gameID, reason string) (*Game, error)
+	// ReapAbandoned cancels games nothing has happened in for inactiveAfter
+	ReapAbandoned(ctx context.Context, inactiveAfter time.Duration) (int, error)
+	// AdvanceSchedule opens the lobbies of scheduled games when it's time,
+	// and starts or cancels the games that are due
+	AdvanceSchedule(ctx context.Context) error
+	ReleaseRankedResults(ctx context.Context, gameID string) error
+	SendChat(ctx context.Context, gameID, playerID, message string) error
+	// SetSpellingStatus tells the game's other players and spectators what
+	// playerID is doing with their turn
+	SetSpellingStatus(gameID, playerID string, status SpellingStatus) error
+	// PresenceChanged announces that userID has connected to a game or
+	// disconnected from it
+	PresenceChanged(gameID, userID string, online bool)
+	// Subscribe follows a game's events as viewerID may see them, first
+	// replaying those after the one numbered after when it's above zero
+	Subscribe(gameID, viewerID string, after int64) *Subscription
 }
 
 type gameService struct {
 	db           *sqlx.DB
+	queries      *queries.Queries
 	wordService  WordService
 	dictService  DictionaryService
-	eventChan    chan GameEvent
+	events       *eventLog
+	timers       *timerSet
+	stt          *stt.Pool
+	voice        *VoiceArchive
+	ranks        RankRecorder
+	notifier     notifications.Notifier
+	audioJobs    JobQueue
+	audit        audit.Recorder
+	results      []ResultPublisher
+	rounds       RoundReporter
+	reads        Reader
+	readQueries  *queries.Queries
+	cheats       CheatScreen
+	meetings     MeetingReleaser
+	forwarders   []EventForwarder
+	presence     LobbyPresence
+	statuses     *statusThrottle
+	mutes        MuteChecker
+
+	mu           sync.RWMutex
 	activeGames  map[string]*GameEngine
 }
 
+// WordQuery selects the words a game can be served
+type WordQuery struct {
+	Level    int
+	Category *string
+	// Language is the language words are picked in, DefaultLanguage when
+	// empty
+	Language string
+	// Empirical matches Level against the calibrated difficulty where a
+	// word has one, instead of the level it was catalogued at
+	Empirical bool
+	// Exclude holds the IDs of words the game has already served
+	Exclude []string
+	// Filter is how strictly offensive words are kept out,
+	// WordFilterStandard when empty
+	Filter WordFilter
+	// WordList, when set, is the word list words are drawn from in
+	// WordOrder, whatever their level or category
+	WordList  *string
+	WordOrder WordOrder
+}
+
+func wordQuery(game *Game, served []string, round int) WordQuery {
+	return WordQuery{
+		Level:     game.Settings.LevelFor(round),
+		Category:  game.Settings.Category,
+		Language:  game.Settings.Language,
+		Empirical: game.Settings.EmpiricalDifficulty,
+		Exclude:   served,
+		Filter:    game.Settings.WordFilter,
+		WordList:  game.Settings.WordList,
+		WordOrder: game.Settings.WordOrder,
+	}
+}
+
 type WordService interface {
-	GetRandomWord(ctx context.Context, level int, category *string) (*Word, error)
+	GetRandomWord(ctx context.Context, query WordQuery) (*Word, error)
 	ValidateSpelling(ctx context.Context, word, attempt string) bool
-	TranscribeVoice(ctx context.Context, voiceData []byte) (string, error)
+	TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error)
+}
+
+// ServiceOption configures optional gameService dependencies
+type ServiceOption func(*gameService)
+
+// WithTranscriptionPool routes voice attempts through pool instead of a
+// default-sized one
+func WithTranscriptionPool(pool *stt.Pool) ServiceOption {
+	return func(s *gameService) {
+		s.stt = pool
+	}
+}
+
+// WithVoiceArchive keeps voice attempt audio according to the archive's
+// policy. Without one, audio is discarded once transcribed.
+func WithVoiceArchive(archive *VoiceArchive) ServiceOption {
+	return func(s *gameService) {
+		s.voice = archive
+	}
+}
+
+// RankRecorder applies ranked game results to players' ratings.
+// ranking.Service implements it.
+type RankRecorder interface {
+	ApplyResult(ctx context.Context, gameID string, results []ranking.Result, field int, isTournament bool) ([]ranking.Change, error)
+	CreditActivity(ctx context.Context, userIDs []string, at time.Time) error
+}
+
+// WithRankRecorder has ranked games award ranking points when they finish.
+// Without one, ranked games only record scores in the players' history.
+func WithRankRecorder(ranks RankRecorder) ServiceOption {
+	return func(s *gameService) {
+		s.ranks = ranks
+	}
+}
+
+// WithNotifier sends players push notifications, such as when their turn
+// comes round
+func WithNotifier(notifier notifications.Notifier) ServiceOption {
+	return func(s *gameService) {
+		s.notifier = notifier
+	}
+}
+
+// JobQueue queues work to run in the background
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error)
+}
+
+// WithAudioJobs queues a JobWordAudio job whenever a word without cached
+// audio has to be spoken with inline TTS, so it is cached for next time
+func WithAudioJobs(queue JobQueue) ServiceOption {
+	return func(s *gameService) {
+		s.audioJobs = queue
+	}
+}
+
+// WithAuditLog records game cancellations and appeal decisions in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *gameService) {
+		s.audit = log
+	}
+}
+
+// Reader runs read-only queries, such as against read replicas
+type Reader = queries.Reader
+
+// WithQueries runs the service's games, players and attempts queries
+// through q, such as one whose statements are prepared, rather than
+// straight against the database
+func WithQueries(q *queries.Queries) ServiceOption {
+	return func(s *gameService) {
+		s.queries = q
+	}
+}
+
+// WithReadReplicas sends lobby listings, and lookups of games marked with
+// ReadOnly, to reads. Everything else reads the primary, since replicas lag
+// behind it.
+func WithReadReplicas(reads Reader) ServiceOption {
+	return func(s *gameService) {
+		s.reads = reads
+	}
+}
+
+// ResultPublisher shares finished games' results, such as on the players'
+// activity feeds
+type ResultPublisher interface {
+	PublishResults(ctx context.Context, game *Game, results []PlayerResult)
+}
+
+// WithResultPublisher hands every finished game's results to publisher.
+// It can be given more than once to share results in several places.
+func WithResultPublisher(publisher ResultPublisher) ServiceOption {
+	return func(s *gameService) {
+		s.results = append(s.results, publisher)
+	}
+}
+
+// RoundReporter is told as each round of a tournament game is completed,
+// such as to keep outside tournament software up to date
+type RoundReporter interface {
+	RoundCompleted(ctx context.Context, game *Game)
+}
+
+// WithRoundReporter reports tournament games' completed rounds to reporter
+func WithRoundReporter(reporter RoundReporter) ServiceOption {
+	return func(s *gameService) {
+		s.rounds = reporter
+	}
+}
+
+// CheatScreen looks over a finished game for signs of cheating, reporting
+// whether it flagged anyone
+type CheatScreen interface {
+	Screen(ctx context.Context, gameID string) (bool, error)
+}
+
+// WithCheatScreen screens ranked games as they finish, holding back the
+// ranking points of flagged games until they are reviewed and released
+// with ReleaseRankedResults
+func WithCheatScreen(screen CheatScreen) ServiceOption {
+	return func(s *gameService) {
+		s.cheats = screen
+	}
+}
+
+// EventForwarder passes game events on beyond the server, such as onto an
+// event bus for analytics. Forward is called as each event happens, so it
+// mustn't block.
+type EventForwarder interface {
+	Forward(event GameEvent)
 }
 
-func NewGameService(db *sqlx.DB, wordService WordService, dictService DictionaryService) GameService {
-	return &gameService{
+// WithEventForwarder forwards every game event to forwarder, as a spectator
+// would see it. It can be given more than once.
+func WithEventForwarder(forwarder EventForwarder) ServiceOption {
+	return func(s *gameService) {
+		s.forwarders = append(s.forwarders, forwarder)
+	}
+}
+
+func NewGameService(db *sqlx.DB, wordService WordService, dictService DictionaryService, opts ...ServiceOption) GameService {
+	s := &gameService{
 		db:          db,
 		wordService: wordService,
 		dictService: dictService,
-		eventChan:   make(chan GameEvent, 100),
+		events:      newEventLog(),
+		timers:      newTimerSet(),
 		activeGames: make(map[string]*GameEngine),
 	}
+	s.statuses = newStatusThrottle(s.events, StatusInterval)
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.stt == nil {
+		s.stt = stt.NewPool(stt.SpelledOut(wordService, stt.NewLetters(nil)), stt.DefaultWorkers, stt.DefaultQueueDepth)
+	}
+	if s.queries == nil {
+		s.queries = queries.New(db)
+	}
+	if s.reads == nil {
+		s.reads = db
+	}
+	s.readQueries = queries.ReadOnly(s.reads)
+
+	return s
+}
+
+func (s *gameService) record(ctx context.Context, event audit.Event) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event)
+	}
+}
+
+func (s *gameService) engine(gameID string) *GameEngine {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.activeGames[gameID]
+}
+
+// streak is how many words playerID has spelled correctly in a row
+func (s *gameService) streak(gameID, playerID string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if engine := s.activeGames[gameID]; engine != nil {
+		return engine.Streaks[playerID]
+	}
+	return 0
+}
+
+func (s *gameService) newEngine(game *Game) *GameEngine {
+	engine := NewGameEngine(game.ID, s.dictService)
+	engine.HintsAllowed = game.Settings.HintBudget()
+	engine.AllowedHints = game.Settings.AllowedHints
+	engine.Pronounce = game.Settings.Pronunciation.Enabled
+	engine.Language = languageOr(game.Settings.Language)
+	engine.Window = game.Settings.AnswerWindow.withDefaults(game.Settings.Mode)
+	engine.Level = game.Settings.LevelFor(game.Round)
+	engine.Spellings = game.Settings.SpellingPolicy
+	return engine
+}
+
+func (s *gameService) setEngine(gameID string, engine *GameEngine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeGames[gameID] = engine
+	activeGamesGauge.Set(float64(len(s.activeGames)))
+}
+
+// engineLockKey marks a context whose caller holds the game's engine lock
+type engineLockKey struct{ gameID string }
+
+// lockEngine takes the lock on the game's engine, so whatever the caller
+// checks about the turn still holds when it acts on it. Calls made with the
+// returned context don't take it again. It's a no-op for games without an
+// engine.
+func (s *gameService) lockEngine(ctx context.Context, gameID string) (context.Context, func()) {
+	if ctx.Value(engineLockKey{gameID}) != nil {
+		return ctx, func() {}
+	}
+	engine := s.engine(gameID)
+	if engine == nil {
+		return ctx, func() {}
+	}
+	engine.mu.Lock()
+	return context.WithValue(ctx, engineLockKey{gameID}, engine), engine.mu.Unlock
 }
 
 func (s *gameService) CreateGame(ctx context.Context, hostID string, gameType GameType, settings GameSettings) (*Game, error) {
+	if !settings.RevealPolicy.Valid() {
+		return nil, ErrInvalidRevealPolicy
+	}
+	settings.RevealPolicy = settings.Reveal()
+
+	if settings.Category != nil {
+		var exists bool
+		if err := s.db.GetContext(ctx, &exists, `
+			SELECT EXISTS (SELECT 1 FROM categories WHERE slug = $1)`, *settings.Category); err != nil {
+			return nil, fmt.Errorf("failed to look up category: %w", err)
+		}
+		if !exists {
+			return nil, ErrUnknownCategory
+		}
+	}
+
+	if settings.WordList != nil {
+		var owned bool
+		if err := s.db.GetContext(ctx, &owned, `
+			SELECT EXISTS (SELECT 1 FROM word_lists WHERE id = $1 AND owner_id = $2)`, *settings.WordList, hostID); err != nil {
+			return nil, fmt.Errorf("failed to look up word list: %w", err)
+		}
+		if !owned {
+			return nil, ErrUnknownWordList
+		}
+	}
+
+	// Games are played in the host's language unless they pick another
+	if settings.Language == "" {
+		language, err := s.preferredLanguage(ctx, hostID)
+		if err != nil {
+			return nil, err
+		}
+		settings.Language = language
+	}
+
 	id := uuid.New().String()
 	game := &Game{
 		ID:        id,
@@ -65,19 +430,34 @@ func (s *gameService) CreateGame(ctx context.Context, hostID string, gameType Ga
 		UpdatedAt: time.Now(),
 	}
 
-	query := `
-		INSERT INTO games (id, host_id, type, status, settings, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id`
+	// Scheduled games take sign-ups until their lobby opens
+	if settings.ScheduledAt != nil {
+		start := settings.ScheduledAt.UTC()
+		opens := start.Add(-settings.lobbyOpensBefore())
+		settings.ScheduledAt = &start
+		game.Settings = settings
+		game.Status = GameStatusScheduled
+		game.ScheduledAt, game.LobbyOpensAt = &start, &opens
+	}
+
+	if settings.IsPrivate {
+		code, err := newInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		game.InviteCode = &code
+	}
 
-	if err := s.db.QueryRowContext(ctx, query,
-		game.ID, game.HostID, game.Type, game.Status, game.Settings,
-		game.CreatedAt, game.UpdatedAt).Scan(&game.ID); err != nil {
+	if err := s.queries.CreateGame(ctx, queries.CreateGameParams{
+		ID: game.ID, HostID: game.HostID, Type: string(game.Type), Status: string(game.Status),
+		Settings: game.Settings, CreatedAt: game.CreatedAt, InviteCode: game.InviteCode,
+		ScheduledAt: game.ScheduledAt, LobbyOpensAt: game.LobbyOpensAt,
+	}); err != nil {
 		return nil, fmt.Errorf("failed to create game: %w", err)
 	}
 
 	// Create game engine
-	s.activeGames[game.ID] = NewGameEngine(game.ID, s.dictService)
+	s.setEngine(game.ID, s.newEngine(game))
 
 	s.emitEvent(EventTypeGameCreated, game.ID, nil, map[string]any{
 		"game": game,
@@ -86,20 +466,48 @@ func (s *gameService) CreateGame(ctx context.Context, hostID string, gameType Ga
 	return game, nil
 }
 
-func (s *gameService) JoinGame(ctx context.Context, gameID string, playerID string) (*Game, error) {
+// preferredLanguage returns the language userID chose in their preferences,
+// if games can be played in it, and DefaultLanguage otherwise
+func (s *gameService) preferredLanguage(ctx context.Context, userID string) (string, error) {
+	var language string
+	if err := s.db.GetContext(ctx, &language, `
+		SELECT COALESCE((SELECT language FROM user_preferences WHERE user_id = $1), '')`, userID); err != nil {
+		return "", fmt.Errorf("failed to get preferred language: %w", err)
+	}
+	if !IsSupportedLanguage(language) {
+		return DefaultLanguage, nil
+	}
+	return language, nil
+}
+
+func (s *gameService) JoinGame(ctx context.Context, gameID string, playerID string, inviteCode string) (*Game, error) {
 	game, err := s.GetGame(ctx, gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	if game.Status != GameStatusWaiting {
+	if game.Status != GameStatusWaiting && game.Status != GameStatusScheduled {
 		return nil, ErrInvalidGameState
 	}
 
+	if !canJoin(game, playerID, inviteCode) {
+		return nil, ErrInviteRequired
+	}
+
+	// Players joining mid-check couldn't confirm in it
+	if game.ReadyCheck != nil {
+		return nil, ErrReadyCheckPending
+	}
+
+	for _, player := range game.Players {
+		if player != nil && player.UserID == playerID && player.Status == "kicked" {
+			return nil, ErrPlayerKicked
+		}
+	}
+
 	// Check if player count is within limits
 	var playerCount int
-	if err := s.db.GetContext(ctx, &playerCount,
-		"SELECT COUNT(*) FROM players WHERE game_id = $1", gameID); err != nil {
+	if err := s.queries.CountPlayers(ctx, &playerCount, gameID); err != nil {
 		return nil, fmt.Errorf("failed to count players: %w", err)
 	}
 
@@ -116,14 +524,10 @@ func (s *gameService) JoinGame(ctx context.Context, gameID string, playerID stri
 		JoinedAt: time.Now(),
 	}
 
-	query := `
-		INSERT INTO players (id, game_id, user_id, status, joined_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id`
-
-	if err := s.db.QueryRowContext(ctx, query,
-		player.ID, player.GameID, player.UserID, player.Status,
-		player.JoinedAt).Scan(&player.ID); err != nil {
+	if err := s.queries.AddPlayer(ctx, queries.AddPlayerParams{
+		ID: player.ID, GameID: player.GameID, PlayerID: player.UserID,
+		Status: player.Status, JoinedAt: player.JoinedAt,
+	}); err != nil {
 		return nil, fmt.Errorf("failed to add player: %w", err)
 	}
 
@@ -134,7 +538,12 @@ func (s *gameService) JoinGame(ctx context.Context, gameID string, playerID stri
 	return game, nil
 }
 
-func (s *gameService) StartGame(ctx context.Context, gameID string, userID string) (*Game, error) {
+func (s *gameService) StartGame(ctx context.Context, gameID string, userID string) (_ *Game, err error) {
+	ctx, span := tracing.Start(ctx, "game.StartGame", tracing.String("game.id", gameID))
+	defer span.EndWith(&err)
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
 	game, err := s.GetGame(ctx, gameID)
 	if err != nil {
 		return nil, err
@@ -144,35 +553,49 @@ func (s *gameService) StartGame(ctx context.Context, gameID string, userID strin
 		return nil, ErrInvalidGameState
 	}
 
+	if game.ReadyCheck != nil {
+		return nil, ErrReadyCheckPending
+	}
+
+	if players := activePlayers(game); players == 0 || players < game.Settings.MinPlayers {
+		return nil, ErrNotEnoughPlayers
+	}
+
 	// Get first word
-	word, err := s.wordService.GetRandomWord(ctx, game.Settings.WordLevel, game.Settings.Category)
+	word, err := s.wordService.GetRandomWord(ctx, wordQuery(game, nil, 1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get word: %w", err)
 	}
 
 	// Start game engine
-	engine := s.activeGames[gameID]
+	engine := s.engine(gameID)
 	if engine == nil {
-		engine = NewGameEngine(gameID, s.dictService)
-		s.activeGames[gameID] = engine
+		engine = s.newEngine(game)
+		s.setEngine(gameID, engine)
 	}
 
-	if err := engine.StartTurn(ctx, word.Word); err != nil {
+	if err := engine.StartWord(ctx, word); err != nil {
 		return nil, fmt.Errorf("failed to start turn: %w", err)
 	}
+	engine.MarkServed(word.ID)
+	if game.Settings.isTeamRelay() {
+		if err := s.startTeams(ctx, game, engine); err != nil {
+			return nil, err
+		}
+	} else {
+		engine.SetTurnOrder(turnOrder(game))
+	}
 
 	// Update game status
-	query := `
-		UPDATE games
-		SET status = $1, current_word_id = $2, updated_at = $3,
-			turn_started_at = $4, word_masked = $5
-		WHERE id = $6
-		RETURNING *`
-
 	now := time.Now()
-	if err := s.db.GetContext(ctx, game, query,
-		GameStatusActive, word.ID, now,
-		now, true, gameID); err != nil {
+	if err := s.updateGame(ctx, game, func(latest *Game) bool {
+		return latest.Status == GameStatusWaiting
+	}, func() error {
+		return s.queries.StartGame(ctx, game, queries.StartGameParams{
+			Status: string(GameStatusActive), WordID: word.ID, CurrentTurn: engine.CurrentPlayer(),
+			Now: now, ID: game.ID, Version: game.Version,
+		})
+	}); err != nil {
 		return nil, fmt.Errorf("failed to update game: %w", err)
 	}
 
@@ -180,63 +603,203 @@ func (s *gameService) StartGame(ctx context.Context, gameID string, userID strin
 		"game": game,
 		"word": word,
 	})
+	s.remindTurn(ctx, game, engine)
+	s.startPlayerTurn(game, engine)
 
 	return game, nil
 }
 
-func (s *gameService) MakeAttempt(ctx context.Context, gameID string, playerID string, attempt *SpellingAttempt) error {
+func (s *gameService) MakeAttempt(ctx context.Context, gameID string, playerID string, attempt *SpellingAttempt) (err error) {
+	ctx, span := tracing.Start(ctx, "game.MakeAttempt",
+		tracing.String("game.id", gameID), tracing.String("attempt.type", string(attempt.Type)))
+	defer span.EndWith(&err)
+
+	// The answer is timed from when it arrived, not from when it has been
+	// transcribed
+	received := time.Now()
+
+	// Voice attempts are transcribed before the engine is locked, so the
+	// game isn't held up on the recogniser, and the turn is checked again
+	// once it is
+	var provider string
+	if attempt.Type == AttemptTypeVoice {
+		game, _, err := s.attemptTurn(ctx, gameID, playerID, received)
+		if err != nil {
+			return err
+		}
+
+		transcription, err := s.stt.TranscribeWithConfidence(ctx, attempt.VoiceData, languageOr(game.Settings.Language), s.transcriptionPriority(ctx, game.ID))
+		if err != nil {
+			return fmt.Errorf("failed to transcribe attempt: %w", err)
+		}
+		attempt.Text = transcription.Text
+		attempt.Confidence = &transcription.Confidence
+		provider = transcription.Provider
+	}
+
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
+	game, engine, err := s.attemptTurn(ctx, gameID, playerID, received)
+	if err != nil {
+		return err
+	}
+
+	attempt.ID = uuid.New().String()
+	attempt.GameID = gameID
+	attempt.PlayerID = playerID
+	attempt.Timestamp = received
+
+	if needsConfirmation(game, attempt) {
+		s.requestConfirmation(game, engine, attempt, provider)
+		return nil
+	}
+	return s.judgeAttempt(ctx, game, engine, attempt, provider, false)
+}
+
+// transcriptionPriority is the priority game's voice attempts are
+// transcribed with. Only games played as a tournament's matches go first;
+// the is_tournament setting is the host's to choose, so it doesn't count.
+// Should the lookup fail the attempt is transcribed as a casual one rather
+// than not at all.
+func (s *gameService) transcriptionPriority(ctx context.Context, gameID string) stt.Priority {
+	var match bool
+	if err := s.db.GetContext(ctx, &match, `
+		SELECT EXISTS (SELECT 1 FROM tournament_matches WHERE game_id = $1)`, gameID); err != nil || !match {
+		return stt.PriorityCasual
+	}
+	return stt.PriorityTournament
+}
+
+// attemptTurn checks that playerID may answer an attempt that arrived at
+// received, returning the game and its engine
+func (s *gameService) attemptTurn(ctx context.Context, gameID, playerID string, received time.Time) (*Game, *GameEngine, error) {
 	game, err := s.GetGame(ctx, gameID)
 	if err != nil {
-		return fmt.Errorf("failed to get game: %w", err)
+		return nil, nil, fmt.Errorf("failed to get game: %w", err)
+	}
+
+	if game.Status == GameStatusPaused {
+		return nil, nil, ErrGamePaused
 	}
 
 	if game.Status != GameStatusActive {
-		return ErrInvalidGameState
+		return nil, nil, ErrInvalidGameState
 	}
 
-	engine := s.activeGames[gameID]
+	engine := s.engine(gameID)
 	if engine == nil {
-		return ErrGameNotFound
+		return nil, nil, ErrGameNotFound
 	}
 
+	if s.pendingReview(gameID) != nil {
+		return nil, nil, ErrReviewPending
+	}
+
+	if !engine.IsPlayerTurn(playerID) {
+		return nil, nil, ErrNotPlayerTurn
+	}
+
+	if s.pendingConfirmation(gameID) != nil {
+		return nil, nil, ErrConfirmationPending
+	}
+
+	if !engine.AcceptingAnswers() {
+		return nil, nil, ErrAnswerWindowClosed
+	}
+
+	if err := engine.CheckAnswerTime(received); err != nil {
+		countAttempt(game, "late")
+		return nil, nil, err
+	}
+
+	return game, engine, nil
+}
+
+// judgeAttempt rules on an attempt automatically, sending it on to a judge
+// when the recogniser wasn't sure of a voice attempt the player hasn't
+// confirmed
+func (s *gameService) judgeAttempt(ctx context.Context, game *Game, engine *GameEngine, attempt *SpellingAttempt, provider string, confirmed bool) error {
+	gameID, playerID := game.ID, attempt.PlayerID
+
 	// Validate attempt
 	isCorrect, err := engine.ValidateAttempt(attempt.Text)
 	if err != nil {
 		return fmt.Errorf("failed to validate attempt: %w", err)
 	}
+	if attempt.Type == AttemptTypeVoice {
+		countTranscription(provider, *attempt.Confidence, isCorrect)
+	}
 
-	// Update game state based on result
-	now := time.Now()
-	var query string
-	var args []interface{}
+	attempt.Word = engine.CurrentWord.Word
+	attempt.IsCorrect = isCorrect
+	attempt.Status = AttemptStatusJudged
+	attempt.Ruling = RulingAutomatic
+	if engine.TurnStartedAt != nil {
+		answerMS := attempt.Timestamp.Sub(*engine.TurnStartedAt).Milliseconds()
+		attempt.AnswerMS = &answerMS
+	}
+	attempt.HintsUsed = len(engine.HintsUsed[playerID])
+
+	// A judge rules on voice attempts the recogniser wasn't sure about; the
+	// automatic ruling above stands if they don't answer in time
+	judgeID, review := needsReview(game, attempt)
+	review = review && !confirmed
+	if review {
+		attempt.Status = AttemptStatusPendingReview
+		attempt.JudgeID = &judgeID
+	}
+
+	scoreAttempt(game, attempt, s.streak(gameID, playerID))
 
+	if s.voice != nil {
+		if err := s.voice.Archive(ctx, game, attempt); err != nil {
+			return err
+		}
+	}
+	attempt.VoiceData = nil
+
+	if err := s.recordAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	if review {
+		s.requestReview(game, attempt, judgeID)
+		return nil
+	}
+
+	return s.resolveAttempt(ctx, game, engine, attempt)
+}
+
+// resolveAttempt applies a judged attempt to the game: scoring, events and
+// moving on to the next word when it was spelled correctly
+func (s *gameService) resolveAttempt(ctx context.Context, game *Game, engine *GameEngine, attempt *SpellingAttempt) error {
+	gameID, playerID, isCorrect := game.ID, attempt.PlayerID, attempt.IsCorrect
+
+	// Update game state based on result. Scores are added to rather than
+	// overwritten, so the update can be retried if the game changed since it
+	// was read, as long as it is still this player's turn.
+	stillTheirTurn := func(latest *Game) bool {
+		return latest.Status == GameStatusActive && latest.CurrentTurn != nil && *latest.CurrentTurn == playerID
+	}
+	now := time.Now()
+	var err error
 	if isCorrect {
 		// Player succeeded - update score and move to next word
-		query = `
-			UPDATE games
-			SET current_word_id = NULL,
-				updated_at = $1,
-				turn_started_at = NULL,
-				word_masked = false,
-				scores = jsonb_set(
-					scores,
-					array[$2],
-					(COALESCE((scores->$2)::int, 0) + 1)::text::jsonb
-				)
-			WHERE id = $4
-			RETURNING *`
-		args = []interface{}{now, playerID, gameID}
+		err = s.updateGame(ctx, game, stillTheirTurn, func() error {
+			return s.queries.ScoreTurn(ctx, game, queries.ScoreTurnParams{
+				Now: now, PlayerID: playerID, ID: game.ID, Version: game.Version,
+			})
+		})
 	} else {
 		// Player failed - just update timestamp
-		query = `
-			UPDATE games
-			SET updated_at = $1
-			WHERE id = $2
-			RETURNING *`
-		args = []interface{}{now, gameID}
+		err = s.updateGame(ctx, game, stillTheirTurn, func() error {
+			return s.queries.TouchGame(ctx, game, queries.TouchGameParams{
+				Now: now, ID: game.ID, Version: game.Version,
+			})
+		})
 	}
-
-	if err := s.db.GetContext(ctx, game, query, args...); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to update game: %w", err)
 	}
 
@@ -247,42 +810,88 @@ func (s *gameService) MakeAttempt(ctx context.Context, gameID string, playerID s
 	}
 
 	s.emitEvent(eventType, gameID, &playerID, map[string]any{
-		"attempt": attempt,
+		"attempt": revealAttempt(game, attempt),
 		"correct": isCorrect,
+		"match":   attempt.Match,
+		"points":  attempt.Points,
+		"diff":    attemptDiff(game, attempt),
 	})
 
+	recap := BuildTurnRecap(game, engine.CurrentWord, attempt, engine.HintsUsed[playerID])
+	s.emitEvent(EventTypeTurnRecap, gameID, &playerID, map[string]any{
+		"recap": RevealRecap(game, recap),
+	})
+
+	s.mu.Lock()
+	engine.RecordResult(playerID, isCorrect)
+	s.mu.Unlock()
+	countAttempt(game, attemptOutcome(isCorrect))
+
+	if !isCorrect {
+		return s.passTurn(ctx, gameID, engine)
+	}
+
+	s.timers.Cancel(timerKey(gameID, "turn"))
+	engine.EndTurn()
+	s.beginIntermission(ctx, game)
+
 	return nil
 }
 
-func (s *gameService) nextTurn(ctx context.Context, game *Game) error {
-	// Get next word
-	word, err := s.wordService.GetRandomWord(ctx, game.Settings.WordLevel, game.Settings.Category)
-	if err != nil {
-		return fmt.Errorf("failed to get next word: %w", err)
+func (s *gameService) recordAttempt(ctx context.Context, attempt *SpellingAttempt) error {
+	if err := s.queries.RecordAttempt(ctx, queries.RecordAttemptParams{
+		ID:             attempt.ID,
+		GameID:         attempt.GameID,
+		PlayerID:       attempt.PlayerID,
+		Word:           attempt.Word,
+		Type:           string(attempt.Type),
+		Text:           attempt.Text,
+		IsCorrect:      attempt.IsCorrect,
+		Timestamp:      attempt.Timestamp,
+		VoiceS3Key:     attempt.VoiceKey,
+		VoiceExpiresAt: attempt.VoiceExpiresAt,
+		Status:         string(attempt.Status),
+		Ruling:         string(attempt.Ruling),
+		Confidence:     attempt.Confidence,
+		JudgeID:        attempt.JudgeID,
+		AnswerMS:       attempt.AnswerMS,
+		Match:          string(attempt.Match),
+		Points:         attempt.Points,
+		HintsUsed:      attempt.HintsUsed,
+	}); err != nil {
+		return fmt.Errorf("failed to record attempt: %w", err)
 	}
 
-	engine := s.activeGames[game.ID]
+	return nil
+}
+
+func (s *gameService) nextTurn(ctx context.Context, game *Game) (err error) {
+	ctx, span := tracing.Start(ctx, "game.nextTurn", tracing.String("game.id", game.ID))
+	defer span.EndWith(&err)
+
+	engine := s.engine(game.ID)
 	if engine == nil {
 		return ErrGameNotFound
 	}
 
-	if err := engine.StartTurn(ctx, word.Word); err != nil {
+	// Get next word, as hard as the game's difficulty curve has climbed to
+	query := wordQuery(game, engine.Served, game.Round+1)
+	word, err := s.wordService.GetRandomWord(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to get next word: %w", err)
+	}
+	engine.Level = query.Level
+
+	if err := engine.StartWord(ctx, word); err != nil {
 		return fmt.Errorf("failed to start turn: %w", err)
 	}
+	engine.MarkServed(word.ID)
+	engine.AdvancePlayer()
 
 	// Update game state
-	now := time.Now()
-	query := `
-		UPDATE games
-		SET current_word_id = $1,
-			updated_at = $2,
-			turn_started_at = $3,
-			word_masked = true,
-			round = round + 1
-		WHERE id = $4
-		RETURNING *`
-
-	if err := s.db.GetContext(ctx, game, query, word.ID, now, now, game.ID); err != nil {
+	if err := s.queries.NextRound(ctx, game, queries.NextRoundParams{
+		WordID: word.ID, Now: time.Now(), CurrentTurn: engine.CurrentPlayer(), ID: game.ID,
+	}); err != nil {
 		return fmt.Errorf("failed to update game: %w", err)
 	}
 
@@ -290,20 +899,32 @@ func (s *gameService) nextTurn(ctx context.Context, game *Game) error {
 		"game": game,
 		"word": word,
 	})
+	s.remindTurn(ctx, game, engine)
+	s.startPlayerTurn(game, engine)
 
 	return nil
 }
 
-func (s *gameService) GetGame(ctx context.Context, gameID string) (*Game, error) {
-	query := `
-		SELECT g.*, array_agg(p.*) as players
-		FROM games g
-		LEFT JOIN players p ON p.game_id = g.id
-		WHERE g.id = $1
-		GROUP BY g.id`
+type readOnlyKey struct{}
 
+// ReadOnly marks ctx as looking a game up only to show it, so GetGame may
+// read it from a replica. Games looked up to be changed come from the
+// primary.
+func ReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// reader is where GetGame reads from for ctx
+func (s *gameService) reader(ctx context.Context) *queries.Queries {
+	if readOnly, _ := ctx.Value(readOnlyKey{}).(bool); readOnly {
+		return s.readQueries
+	}
+	return s.queries
+}
+
+func (s *gameService) GetGame(ctx context.Context, gameID string) (*Game, error) {
 	var game Game
-	if err := s.db.GetContext(ctx, &game, query, gameID); err != nil {
+	if err := s.reader(ctx).GetGame(ctx, &game, gameID); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrGameNotFound
 		}
@@ -311,16 +932,49 @@ func (s *gameService) GetGame(ctx context.Context, gameID string) (*Game, error)
 	}
 
 	// Get active game engine if exists
-	if engine, ok := s.activeGames[gameID]; ok {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+	if engine := s.engine(gameID); engine != nil {
 		game.CurrentWord = engine.CurrentWord
 		game.WordMasked = engine.WordMasked
 		game.TurnStartedAt = engine.TurnStartedAt
+		game.CurrentPlayer = engine.CurrentPlayer()
+		game.TurnPhase = engine.Phase
+		game.ReadyCheck = s.readyCheck(gameID)
+		if engine.AcceptingAnswers() {
+			// A paused turn's clock stopped when it was paused
+			now := time.Now()
+			if engine.PausedAt != nil {
+				now = *engine.PausedAt
+			}
+			remaining := engine.TurnRemaining(now).Milliseconds()
+			game.TurnRemainingMS = &remaining
+		}
+
+		game.HintsUsed = make(map[string][]string, len(engine.HintsUsed))
+		for playerID, used := range engine.HintsUsed {
+			for _, hintType := range used {
+				game.HintsUsed[playerID] = append(game.HintsUsed[playerID], string(hintType))
+			}
+		}
+
+		game.HintsRemaining = make(map[string]int, len(game.Players))
+		for _, player := range game.Players {
+			if player != nil {
+				game.HintsRemaining[player.UserID] = engine.HintsRemaining(player.UserID)
+			}
+		}
 	}
 
 	return &game, nil
 }
 
-func (s *gameService) GetHint(ctx context.Context, gameID string, playerID string) (*Hint, error) {
+// GetHint serves the requested hint type, falling back through HintOrder
+// when hintType is empty or has no content for the current word
+func (s *gameService) GetHint(ctx context.Context, gameID string, playerID string, hintType HintType) (*Hint, error) {
+	ctx, unlock := s.lockEngine(ctx, gameID)
+	defer unlock()
+
 	game, err := s.GetGame(ctx, gameID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get game: %w", err)
@@ -330,37 +984,35 @@ func (s *gameService) GetHint(ctx context.Context, gameID string, playerID strin
 		return nil, ErrInvalidGameState
 	}
 
-	engine := s.activeGames[gameID]
+	engine := s.engine(gameID)
 	if engine == nil {
 		return nil, ErrGameNotFound
 	}
 
-	// Get a random hint type
-	hintTypes := []HintType{
-		HintTypeDefinition,
-		HintTypeExampleSentence,
-		HintTypeEtymology,
-		HintTypePartOfSpeech,
-		HintTypePronunciation,
-	}
-	hintType := hintTypes[time.Now().UnixNano()%int64(len(hintTypes))]
-
-	hint, err := engine.GetHint(ctx, hintType)
+	hint, err := engine.GetHint(ctx, playerID, hintType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get hint: %w", err)
 	}
 
+	penalty := game.Settings.HintCost()
+	if penalty > 0 {
+		if err := s.queries.ChargeHint(ctx, queries.ChargeHintParams{
+			Penalty: penalty, GameID: gameID, PlayerID: playerID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to apply hint penalty: %w", err)
+		}
+	}
+
+	hint.Remaining = engine.HintsRemaining(playerID)
+	hint.Penalty = penalty
+
 	s.emitEvent(EventTypeHintRequested, gameID, &playerID, map[string]any{
-		"hint": &Hint{
-			Type:    hintType,
-			Content: hint,
-		},
+		"requested_type": hintType,
+		"hint":           hint,
+		"remaining":      hint.Remaining,
 	})
 
-	return &Hint{
-		Type:    hintType,
-		Content: hint,
-	}, nil
+	return hint, nil
 }
 
 func (s *gameService) emitEvent(eventType EventType, gameID string, playerID *string, payload map[string]any) {
@@ -371,9 +1023,19 @@ func (s *gameService) emitEvent(eventType EventType, gameID string, playerID *st
 		Timestamp: time.Now(),
 		Payload:   payload,
 	}
-	s.eventChan <- event
+	event = s.events.publish(event)
+	for _, forwarder := range s.forwarders {
+		forwarder.Forward(eventFor(event, ""))
+	}
+}
+
+func (s *gameService) PresenceChanged(gameID, userID string, online bool) {
+	s.emitEvent(EventTypePresenceChanged, gameID, &userID, map[string]any{
+		"user_id": userID,
+		"online":  online,
+	})
 }
 
-func (s *gameService) Events() <-chan GameEvent {
-	return s.eventChan
+func (s *gameService) Subscribe(gameID, viewerID string, after int64) *Subscription {
+	return s.events.subscribe(gameID, viewerID, after)
 }
@@ -3,106 +3,191 @@ package game
 import (
 	"context"
 	"testing"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/game/stt"
+	"big-spella-go/internal/reports"
 )
 
 func TestCreateGame(t *testing.T) {
-	mockDB := &MockDB{}
-	mockWordService := new(MockWordService)
-	mockDictService := new(MockDictionaryService)
-	service := NewGameService(mockDB, mockWordService, mockDictService)
+	db := setupTestDB(t)
+	service := NewGameService(db, new(MockWordService), new(MockDictionaryService))
 
 	ctx := context.Background()
-	gameID := uuid.New().String()
+	hostID := createTestUser(t, db, "host")
 	settings := GameSettings{
 		MinPlayers: 2,
 		MaxPlayers: 4,
-		TimeLimit:  300,
+		WordLevel:  3,
 	}
 
-	mockWordService.On("GetRandomWord", ctx, mock.Anything, mock.Anything).Return(&Word{
-		Word:       "TESTING",
-		Definition: "A test word",
-	}, nil)
-	mockDB.On("CreateGame", ctx, mock.AnythingOfType("*game.Game")).Return(nil)
-
-	game, err := service.CreateGame(ctx, gameID, GameTypeSolo, settings)
-	assert.NoError(t, err)
-	assert.NotNil(t, game)
-	assert.Equal(t, gameID, game.ID)
-	assert.Equal(t, GameTypeSolo, game.Type)
+	game, err := service.CreateGame(ctx, hostID, GameTypeMulti, settings)
+	require.NoError(t, err)
+	assert.Equal(t, hostID, game.HostID)
+	assert.Equal(t, GameTypeMulti, game.Type)
 	assert.Equal(t, GameStatusInitializing, game.Status)
 
-	mockDB.AssertExpectations(t)
-	mockWordService.AssertExpectations(t)
+	saved, err := service.GetGame(ctx, game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, game.Status, saved.Status)
+	assert.Equal(t, DefaultLanguage, saved.Settings.Language)
 }
 
 func TestJoinGame(t *testing.T) {
-	mockDB := &MockDB{}
-	mockWordService := new(MockWordService)
-	mockDictService := new(MockDictionaryService)
-	service := NewGameService(mockDB, mockWordService, mockDictService)
+	db := setupTestDB(t)
+	service := NewGameService(db, new(MockWordService), new(MockDictionaryService))
 
 	ctx := context.Background()
-	gameID := uuid.New().String()
-	playerID := uuid.New().String()
-	existingGame := &Game{
-		ID:      gameID,
-		Type:    GameTypeSolo,
-		Status:  GameStatusWaiting,
-		Players: []*Player{},
-		Settings: GameSettings{
-			MinPlayers: 2,
-			MaxPlayers: 4,
-		},
-	}
+	hostID := createTestUser(t, db, "host")
+	playerID := createTestUser(t, db, "player")
 
-	mockDB.On("GetGame", ctx, gameID).Return(existingGame, nil)
-	mockDB.On("UpdateGame", ctx, mock.AnythingOfType("*game.Game")).Return(nil)
+	game, err := service.CreateGame(ctx, hostID, GameTypeMulti, GameSettings{MinPlayers: 2, MaxPlayers: 4, WordLevel: 3})
+	require.NoError(t, err)
 
-	game, err := service.JoinGame(ctx, gameID, playerID)
-	assert.NoError(t, err)
-	assert.NotNil(t, game)
-	assert.Contains(t, game.Players, &Player{ID: playerID})
+	_, err = service.JoinGame(ctx, game.ID, playerID, "")
+	assert.ErrorIs(t, err, ErrInvalidGameState, "the lobby isn't open yet")
 
-	mockDB.AssertExpectations(t)
+	openTestLobby(t, db, game.ID)
+	_, err = service.JoinGame(ctx, game.ID, playerID, "")
+	require.NoError(t, err)
+
+	joined, err := service.GetGame(ctx, game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{playerID}, turnOrder(joined))
+}
+
+// startTestGame starts a game between a host and one other player in the
+// test database, serving them "testing"
+func startTestGame(t *testing.T) (db *sqlx.DB, service *gameService, game *Game) {
+	t.Helper()
+
+	db = setupTestDB(t)
+	words := new(MockWordService)
+	service = NewGameService(db, words, new(MockDictionaryService)).(*gameService)
+
+	ctx := context.Background()
+	hostID := createTestUser(t, db, "host")
+	playerID := createTestUser(t, db, "player")
+	words.On("GetRandomWord", mock.Anything, mock.Anything).Return(createTestWord(t, db, "testing"), nil)
+
+	game, err := service.CreateGame(ctx, hostID, GameTypeMulti, GameSettings{MinPlayers: 2, MaxPlayers: 4, WordLevel: 3})
+	require.NoError(t, err)
+	openTestLobby(t, db, game.ID)
+	for _, userID := range []string{hostID, playerID} {
+		_, err = service.JoinGame(ctx, game.ID, userID, "")
+		require.NoError(t, err)
+	}
+
+	game, err = service.StartGame(ctx, game.ID, hostID)
+	require.NoError(t, err)
+	t.Cleanup(func() { service.cancelGame(context.Background(), game, "test over") })
+	return db, service, game
 }
 
 func TestStartGame(t *testing.T) {
-	mockDB := &MockDB{}
-	mockWordService := new(MockWordService)
-	mockDictService := new(MockDictionaryService)
-	service := NewGameService(mockDB, mockWordService, mockDictService)
+	_, service, game := startTestGame(t)
+
+	started, err := service.GetGame(context.Background(), game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, GameStatusActive, started.Status)
+	assert.Equal(t, "testing", started.CurrentWord.Word)
+	assert.Equal(t, game.HostID, started.CurrentPlayer)
+}
+
+func TestStartGameNeedsEnoughPlayers(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewGameService(db, new(MockWordService), new(MockDictionaryService))
 
 	ctx := context.Background()
-	gameID := uuid.New().String()
-	player1ID := uuid.New().String()
-	player2ID := uuid.New().String()
-
-	existingGame := &Game{
-		ID:     gameID,
-		Type:   GameTypeSolo,
-		Status: GameStatusWaiting,
-		Players: []*Player{
-			{ID: player1ID},
-			{ID: player2ID},
-		},
-		Settings: GameSettings{
-			MinPlayers: 2,
-			MaxPlayers: 4,
-		},
+	hostID := createTestUser(t, db, "host")
+	game, err := service.CreateGame(ctx, hostID, GameTypeMulti, GameSettings{MinPlayers: 2, MaxPlayers: 4, WordLevel: 3})
+	require.NoError(t, err)
+	openTestLobby(t, db, game.ID)
+
+	_, err = service.StartGame(ctx, game.ID, hostID)
+	assert.ErrorIs(t, err, ErrNotEnoughPlayers, "nobody has joined")
+
+	_, err = service.JoinGame(ctx, game.ID, hostID, "")
+	require.NoError(t, err)
+	_, err = service.StartGame(ctx, game.ID, hostID)
+	assert.ErrorIs(t, err, ErrNotEnoughPlayers, "the host can't play alone when two are needed")
+}
+
+func TestMakeAttemptRecordsAttempt(t *testing.T) {
+	db, service, game := startTestGame(t)
+
+	err := service.MakeAttempt(context.Background(), game.ID, game.HostID, &SpellingAttempt{Type: AttemptTypeText, Text: "tseting"})
+	require.NoError(t, err)
+
+	var attempts []SpellingAttempt
+	require.NoError(t, db.Select(&attempts, `
+		SELECT id, game_id, player_id, word, type, text, is_correct, timestamp
+		FROM spelling_attempts WHERE game_id = $1`, game.ID))
+	require.Len(t, attempts, 1)
+	assert.Equal(t, game.HostID, attempts[0].PlayerID, "attempts are recorded against the user who made them")
+	assert.Equal(t, "testing", attempts[0].Word)
+	assert.False(t, attempts[0].IsCorrect)
+}
+
+func TestTranscriptionPriority(t *testing.T) {
+	db, service, game := startTestGame(t)
+	ctx := context.Background()
+
+	_, err := db.Exec(`UPDATE games SET settings = settings || '{"is_tournament": true}' WHERE id = $1`, game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stt.PriorityCasual, service.transcriptionPriority(ctx, game.ID), "hosts can't jump the queue by calling their game a tournament")
+
+	_, err = db.Exec(`
+		INSERT INTO tournament_matches (game_id, round, match_number, status)
+		VALUES ($1, 1, 1, 'active')`, game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stt.PriorityTournament, service.transcriptionPriority(ctx, game.ID))
+}
+
+func TestMissAsTurnRunsOutPassesTurnOnce(t *testing.T) {
+	_, service, game := startTestGame(t)
+	engine := service.engine(game.ID)
+	playerID := engine.TurnOrder[1]
+
+	// The host misses, moving the turn on, just as their clock runs out
+	ctx, unlock := service.lockEngine(context.Background(), game.ID)
+	expired := time.Now().Add(-time.Hour)
+	engine.TurnStartedAt = &expired
+	service.startPlayerTurn(game, engine)
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, service.passTurn(ctx, game.ID, engine))
+	unlock()
+	time.Sleep(50 * time.Millisecond)
+
+	latest, err := service.GetGame(context.Background(), game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, playerID, latest.CurrentPlayer, "the timeout doesn't pass the turn again")
+}
+
+type muteStub map[string]bool
+
+func (m muteStub) Muted(ctx context.Context, userID string) (*reports.Mute, error) {
+	if m[userID] {
+		return &reports.Mute{UserID: userID}, nil
 	}
+	return nil, nil
+}
 
-	mockDB.On("GetGame", ctx, gameID).Return(existingGame, nil)
-	mockDB.On("UpdateGame", ctx, mock.AnythingOfType("*game.Game")).Return(nil)
+func TestSendChatKeepsMessagesFromUnmutedPlayers(t *testing.T) {
+	db, service, game := startTestGame(t)
+	playerID := turnOrder(game)[1]
+	service.mutes = muteStub{game.HostID: true}
 
-	game, err := service.StartGame(ctx, gameID, player1ID)
-	assert.NoError(t, err)
-	assert.NotNil(t, game)
-	assert.Equal(t, GameStatusPlaying, game.Status)
+	ctx := context.Background()
+	assert.ErrorIs(t, service.SendChat(ctx, game.ID, game.HostID, "hello"), ErrMuted)
+	require.NoError(t, service.SendChat(ctx, game.ID, playerID, "hello"))
 
-	mockDB.AssertExpectations(t)
+	var senders []string
+	require.NoError(t, db.Select(&senders, `SELECT sender_id FROM chat_messages WHERE game_id = $1`, game.ID))
+	assert.Equal(t, []string{playerID}, senders)
 }
@@ -0,0 +1,389 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/validator"
+)
+
+var (
+	errInvalidMessage        = errors.New("unknown or malformed message")
+	errSocketUnauthenticated = errors.New("sign in to spell over this connection")
+	errFailedValidation      = errors.New("failed validation")
+	// Children's accounts don't record their voice or chat
+	errChildVoice = errors.New("children's accounts can't spell by voice")
+	errChildChat  = errors.New("children's accounts can't chat")
+)
+
+// Read limits on the game WebSocket. Until it signs in, a client can only
+// send a small auth message. After, the largest message is a whole voice
+// answer as base64 JSON, so the limit leaves room for MaxVoiceStream of
+// audio once encoded.
+const (
+	maxAuthMessage   = 16 << 10
+	maxSocketMessage = MaxVoiceStream/3*4 + 64<<10
+)
+
+// Messages clients send over the game WebSocket. Together they let a client
+// play a whole game over the one connection.
+const (
+	// ClientMessageLetter spells one letter of the player's attempt
+	ClientMessageLetter = "letter"
+	// ClientMessageSpellingDone submits the letters spelled so far
+	ClientMessageSpellingDone = "spelling_done"
+	// ClientMessageReplayWord asks to hear the word again
+	ClientMessageReplayWord = "replay_word"
+	// ClientMessageJoin joins the game, with its invite code if it's private
+	ClientMessageJoin = "join"
+	// ClientMessageReadyCheck has the host ask the lobby to confirm it's
+	// ready, and ClientMessageReady confirms the player is
+	ClientMessageReadyCheck = "ready_check"
+	ClientMessageReady      = "ready"
+	// ClientMessageAttempt spells the whole word, typed or spoken
+	ClientMessageAttempt = "attempt"
+	// ClientMessageVoiceStart starts a voice answer, whose audio follows in
+	// binary frames
+	ClientMessageVoiceStart = "voice_start"
+	// ClientMessageVoiceEnd submits the voice answer's audio as an attempt
+	ClientMessageVoiceEnd = "voice_end"
+	// ClientMessageVoiceCancel throws the voice answer away
+	ClientMessageVoiceCancel = "voice_cancel"
+	// ClientMessageConfirmAttempt confirms or rejects the transcription of
+	// the voice attempt with AttemptID
+	ClientMessageConfirmAttempt = "confirm_attempt"
+	// ClientMessageHint asks for a hint of HintType
+	ClientMessageHint = "hint"
+	// ClientMessageChat says something to everyone in the game
+	ClientMessageChat = "chat"
+	// ClientMessageStatus tells the others what the player is doing with
+	// their turn, as a SpellingStatus
+	ClientMessageStatus = "status"
+	// ClientMessagePing checks the connection is alive
+	ClientMessagePing = "ping"
+	// ClientMessageSync asks for a fresh snapshot of the game
+	ClientMessageSync = "sync"
+	// ClientMessageAuth signs in with Token. It's only accepted as the first
+	// message on a connection opened without a token.
+	ClientMessageAuth = "auth"
+)
+
+// Messages the server sends over the game WebSocket, besides game events
+const (
+	// ServerMessageAck answers a command that succeeded
+	ServerMessageAck = "ack"
+	// ServerMessageError answers a command that was rejected
+	ServerMessageError = "error"
+	// ServerMessagePong answers a ping
+	ServerMessagePong = "pong"
+	// ServerMessageSnapshot carries the whole game, on connecting and when
+	// asked for with sync
+	ServerMessageSnapshot = "snapshot"
+)
+
+// ClientMessage is a message a player sends over the game WebSocket. ID is
+// chosen by the client and echoed on the reply, so it can tell which
+// command an ack or error answers.
+type ClientMessage struct {
+	Type        string         `json:"type"`
+	ID          string         `json:"id,omitempty"`
+	Letter      string         `json:"letter,omitempty"`
+	InviteCode  string         `json:"invite_code,omitempty"`
+	AttemptType AttemptType    `json:"attempt_type,omitempty"`
+	Text        string         `json:"text,omitempty"`
+	VoiceData   []byte         `json:"voice_data,omitempty"`
+	HintType    HintType       `json:"hint_type,omitempty"`
+	Token       string         `json:"token,omitempty"`
+	Status      SpellingStatus `json:"status,omitempty"`
+	AttemptID   string         `json:"attempt_id,omitempty"`
+	Confirmed   *bool          `json:"confirmed,omitempty"`
+}
+
+// ServerMessage replies to a ClientMessage, or carries a snapshot
+type ServerMessage struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Code identifies errors clients handle specially, such as
+	// ErrCodeAnswerTooLate
+	Code string `json:"code,omitempty"`
+	Data any    `json:"data,omitempty"`
+	// Seq is the seq of the last event sent before a snapshot
+	Seq int64 `json:"seq,omitempty"`
+}
+
+// commandScopes are the scopes each command needs. Holding the connection
+// open only takes events:read, so commands are checked one by one against
+// what the token was granted.
+var commandScopes = map[string]auth.Scope{
+	ClientMessageLetter:         auth.ScopeGamesWrite,
+	ClientMessageSpellingDone:   auth.ScopeGamesWrite,
+	ClientMessageReplayWord:     auth.ScopeGamesWrite,
+	ClientMessageJoin:           auth.ScopeGamesWrite,
+	ClientMessageReadyCheck:     auth.ScopeGamesWrite,
+	ClientMessageReady:          auth.ScopeGamesWrite,
+	ClientMessageAttempt:        auth.ScopeGamesWrite,
+	ClientMessageVoiceStart:     auth.ScopeGamesWrite,
+	ClientMessageVoiceEnd:       auth.ScopeGamesWrite,
+	ClientMessageVoiceCancel:    auth.ScopeGamesWrite,
+	ClientMessageConfirmAttempt: auth.ScopeGamesWrite,
+	ClientMessageHint:           auth.ScopeGamesWrite,
+	ClientMessageChat:           auth.ScopeGamesWrite,
+	ClientMessageStatus:         auth.ScopeGamesWrite,
+	ClientMessagePing:           auth.ScopeEventsRead,
+	ClientMessageSync:           auth.ScopeEventsRead,
+}
+
+// socket serialises writes to a WebSocket connection, which allows only one
+// writer at a time, between the event stream and replies to the client
+type socket struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+	// seq is the seq of the last event sent, or of the game's latest event
+	// when the client subscribed
+	seq int64
+	// child is set for children's accounts, which aren't sent the chat
+	child bool
+	// voice is the voice answer being streamed, if any. Only the goroutine
+	// reading messages uses it.
+	voice *voiceStream
+}
+
+func (s *socket) writeJSON(v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteJSON(v)
+}
+
+func (s *socket) writeEvent(event GameEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Unnumbered events, such as spelling statuses, can't be resumed from
+	if event.Seq > 0 {
+		s.seq = event.Seq
+	}
+	if s.child && event.Type == EventTypeChatMessage {
+		return nil
+	}
+	return s.conn.WriteJSON(event)
+}
+
+// close tells the client why the connection is being closed
+func (s *socket) close(code int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+}
+
+// closeUnloaded tells the client the game couldn't be loaded, with err, and
+// closes the connection
+func (s *socket) closeUnloaded(err error) {
+	if s.writeError("", err) != nil {
+		return
+	}
+	code := websocket.CloseInternalServerErr
+	if errors.Is(err, ErrGameNotFound) {
+		code = websocket.ClosePolicyViolation
+	}
+	s.close(code, "the game couldn't be loaded")
+}
+
+// writeError tells the client the message with id was rejected
+func (s *socket) writeError(id string, err error) error {
+	return s.writeJSON(ServerMessage{Type: ServerMessageError, ID: id, Error: err.Error(), Code: errorCode(err)})
+}
+
+// writeInvalid tells the client the message with id failed validation,
+// with the problem with each field
+func (s *socket) writeInvalid(id string, v validator.Validator) error {
+	return s.writeJSON(ServerMessage{Type: ServerMessageError, ID: id, Error: errFailedValidation.Error(), Data: v.FieldErrors})
+}
+
+// writeSnapshot sends game as userID sees it, with the seq of the last event
+// sent so the client can resume from it. Events sent after the snapshot may
+// already be reflected in it.
+func (s *socket) writeSnapshot(id string, game *Game, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteJSON(ServerMessage{Type: ServerMessageSnapshot, ID: id, Data: viewFor(game, userID), Seq: s.seq})
+}
+
+// readMessages handles the player's messages until the connection closes.
+// Each command is answered with an ack or an error carrying its id; rejected
+// messages don't close the connection.
+func (h *Handler) readMessages(ctx context.Context, ws *socket, gameID, userID string) {
+	principal := auth.GetPrincipal(ctx)
+	for {
+		// Read errors are final, so only a message that fails to decode is
+		// answered and skipped
+		msgType, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if msgType == websocket.BinaryMessage {
+			if err := ws.writeVoice(data); err != nil {
+				if ws.writeError("", err) != nil {
+					return
+				}
+			}
+			continue
+		}
+
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			if ws.writeError("", errInvalidMessage) != nil {
+				return
+			}
+			continue
+		}
+
+		if err := h.handleMessage(ctx, ws, principal, gameID, userID, msg); err != nil {
+			return
+		}
+	}
+}
+
+// handleMessage carries out one command, returning an error only when the
+// reply couldn't be written
+func (h *Handler) handleMessage(ctx context.Context, ws *socket, principal *auth.Principal, gameID, userID string, msg ClientMessage) error {
+	scope, ok := commandScopes[msg.Type]
+	if !ok {
+		return ws.writeError(msg.ID, errInvalidMessage)
+	}
+	if userID == "" {
+		return ws.writeError(msg.ID, errSocketUnauthenticated)
+	}
+	if !principal.HasScope(scope) {
+		return ws.writeError(msg.ID, auth.ErrInsufficientScope)
+	}
+	if msg.Type == ClientMessageChat && principal.Child {
+		return ws.writeError(msg.ID, errChildChat)
+	}
+	if msg.Type == ClientMessageVoiceStart && principal.Child {
+		return ws.writeError(msg.ID, errChildVoice)
+	}
+
+	var (
+		data any
+		err  error
+	)
+	switch msg.Type {
+	case ClientMessagePing:
+		return ws.writeJSON(ServerMessage{Type: ServerMessagePong, ID: msg.ID})
+	case ClientMessageSync:
+		game, err := h.service.GetGame(ctx, gameID)
+		if err != nil {
+			return ws.writeError(msg.ID, err)
+		}
+		return ws.writeSnapshot(msg.ID, game, userID)
+	case ClientMessageLetter:
+		err = h.service.SpellLetter(ctx, gameID, userID, msg.Letter)
+	case ClientMessageSpellingDone:
+		err = h.service.FinishSpelling(ctx, gameID, userID)
+	case ClientMessageReplayWord:
+		var left int
+		left, err = h.service.ReplayWord(ctx, gameID, userID)
+		data = map[string]int{"replays_left": left}
+	case ClientMessageJoin:
+		var game *Game
+		if game, err = h.service.JoinGame(ctx, gameID, userID, msg.InviteCode); err == nil {
+			data = viewFor(game, userID)
+		}
+	case ClientMessageReadyCheck:
+		var game *Game
+		if game, err = h.service.StartReadyCheck(ctx, gameID, userID); err == nil {
+			data = viewFor(game, userID)
+		}
+	case ClientMessageReady:
+		err = h.service.ConfirmReady(ctx, gameID, userID)
+	case ClientMessageAttempt:
+		req := msg.attemptRequest()
+		if req.validate(); req.Validator.HasErrors() {
+			return ws.writeInvalid(msg.ID, req.Validator)
+		}
+		if req.Type == AttemptTypeVoice && principal.Child {
+			return ws.writeError(msg.ID, errChildVoice)
+		}
+		data, err = h.makeAttempt(ctx, gameID, userID, req)
+	case ClientMessageVoiceStart:
+		// Starting again throws away the answer streamed so far
+		ws.voice = &voiceStream{}
+	case ClientMessageVoiceEnd:
+		if ws.voice == nil {
+			return ws.writeError(msg.ID, ErrNoVoiceStream)
+		}
+		req := ws.voice.attemptRequest()
+		ws.voice = nil
+		if req.validate(); req.Validator.HasErrors() {
+			return ws.writeInvalid(msg.ID, req.Validator)
+		}
+		data, err = h.makeAttempt(ctx, gameID, userID, req)
+	case ClientMessageVoiceCancel:
+		ws.voice = nil
+	case ClientMessageConfirmAttempt:
+		req := ConfirmationRequest{Confirmed: msg.Confirmed}
+		if req.validate(msg.AttemptID); req.Validator.HasErrors() {
+			return ws.writeInvalid(msg.ID, req.Validator)
+		}
+		err = h.service.ConfirmAttempt(ctx, gameID, userID, msg.AttemptID, *req.Confirmed)
+	case ClientMessageHint:
+		req := HintRequest{Type: msg.HintType}
+		if req.validate(); req.Validator.HasErrors() {
+			return ws.writeInvalid(msg.ID, req.Validator)
+		}
+		var hint *Hint
+		if hint, err = h.service.GetHint(ctx, gameID, userID, req.Type); err == nil {
+			data = hint
+		}
+	case ClientMessageChat:
+		err = h.service.SendChat(ctx, gameID, userID, msg.Text)
+	case ClientMessageStatus:
+		err = h.service.SetSpellingStatus(gameID, userID, msg.Status)
+	}
+
+	if err != nil {
+		return ws.writeError(msg.ID, err)
+	}
+	return ws.writeJSON(ServerMessage{Type: ServerMessageAck, ID: msg.ID, Data: data})
+}
+
+// makeAttempt submits a checked attempt request, returning what the ack
+// carries
+func (h *Handler) makeAttempt(ctx context.Context, gameID, userID string, req MakeAttemptRequest) (any, error) {
+	attempt := req.attempt()
+	if err := h.service.MakeAttempt(ctx, gameID, userID, attempt); err != nil {
+		return nil, err
+	}
+	if data := pendingAttempt(attempt); data != nil {
+		return data, nil
+	}
+	return nil, nil
+}
+
+// writeVoice adds a binary frame's audio to the voice answer being streamed
+func (s *socket) writeVoice(chunk []byte) error {
+	if s.voice == nil {
+		return ErrNoVoiceStream
+	}
+	if err := s.voice.write(chunk); err != nil {
+		s.voice = nil
+		return err
+	}
+	return nil
+}
+
+// attemptRequest reads an attempt message as the request the HTTP endpoint
+// takes, so both are checked the same way
+func (msg ClientMessage) attemptRequest() MakeAttemptRequest {
+	req := MakeAttemptRequest{Type: msg.AttemptType, VoiceData: msg.VoiceData}
+	if msg.AttemptType == AttemptTypeText {
+		req.Text = &msg.Text
+	}
+	return req
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"big-spella-go/internal/auth"
+)
+
+const (
+	// accessTokenParam carries a token in the game WebSocket's URL
+	accessTokenParam = "access_token"
+	// authTimeout is how long a client has to sign in after connecting
+	authTimeout = 10 * time.Second
+)
+
+var errAuthExpected = errors.New("sign in with an auth message first")
+
+// Authenticator checks a token, adding who it identifies to ctx. It's
+// satisfied by auth.Service.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (context.Context, error)
+}
+
+// checkOrigin lets the API's own pages and the allowed origins open the game
+// WebSocket. Clients that aren't browsers send no Origin and are let in; they
+// still have to sign in.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(h.allowedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (h *Handler) authenticate(ctx context.Context, token string) (context.Context, error) {
+	if h.authenticator == nil {
+		return nil, auth.ErrInvalidToken
+	}
+	return h.authenticator.Authenticate(ctx, token)
+}
+
+// awaitAuth signs in a client that connected without a token, from the auth
+// message it must send first. It's acked like any other command.
+func (h *Handler) awaitAuth(ctx context.Context, ws *socket) (context.Context, error) {
+	ws.conn.SetReadDeadline(time.Now().Add(authTimeout))
+	defer ws.conn.SetReadDeadline(time.Time{})
+
+	_, data, err := ws.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	var msg ClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != ClientMessageAuth {
+		return nil, errAuthExpected
+	}
+
+	if ctx, err = h.authenticate(ctx, msg.Token); err != nil {
+		ws.writeError(msg.ID, err)
+		return nil, err
+	}
+	if !auth.HasScope(ctx, auth.ScopeEventsRead) {
+		ws.writeError(msg.ID, auth.ErrInsufficientScope)
+		return nil, auth.ErrInsufficientScope
+	}
+	return ctx, ws.writeJSON(ServerMessage{Type: ServerMessageAck, ID: msg.ID})
+}
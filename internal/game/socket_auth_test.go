@@ -0,0 +1,161 @@
+package game
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+// tokenAuth signs in ada with their token, kid with a child's token, and a
+// bot whose token can't read events
+type tokenAuth struct{}
+
+func (tokenAuth) Authenticate(ctx context.Context, token string) (context.Context, error) {
+	switch token {
+	case "ada-token":
+		ctx = auth.SetPrincipalInContext(ctx, &auth.Principal{UserID: "ada", Scopes: auth.UserScopes})
+		return auth.SetUserIDInContext(ctx, "ada"), nil
+	case "kid-token":
+		ctx = auth.SetPrincipalInContext(ctx, &auth.Principal{UserID: "kid", Scopes: auth.UserScopes, Child: true})
+		return auth.SetUserIDInContext(ctx, "kid"), nil
+	case "bot-token":
+		return auth.SetPrincipalInContext(ctx, &auth.Principal{Service: "bot", Scopes: []auth.Scope{auth.ScopeGamesRead}}), nil
+	}
+	return nil, auth.ErrInvalidToken
+}
+
+// dialUnsigned connects to a game's WebSocket without an Authorization
+// header, returning the handshake's response when it's refused
+func dialUnsigned(t *testing.T, h *Handler, query string) (*websocket.Conn, *http.Response) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.SubscribeToEvents(w, r, httprouter.Params{{Key: "gameID", Value: socketGameID}})
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+query, nil)
+	if err != nil {
+		return nil, resp
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, resp
+}
+
+func TestSigningInWithTheFirstMessage(t *testing.T) {
+	h := NewHandler(commandStub{}, WithAuthenticator(tokenAuth{}))
+	conn, _ := dialUnsigned(t, h, "")
+	require.NotNil(t, conn)
+
+	reply := send(t, conn, ClientMessage{Type: ClientMessageAuth, ID: "hello", Token: "ada-token"})
+	assert.Equal(t, ServerMessageAck, reply.Type)
+
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageSnapshot, reply.Type)
+
+	// The connection acts as ada from now on
+	assert.Equal(t, ServerMessagePong, send(t, conn, ClientMessage{Type: ClientMessagePing, ID: "1"}).Type)
+}
+
+func TestConnectionsMustSignInFirst(t *testing.T) {
+	h := NewHandler(commandStub{}, WithAuthenticator(tokenAuth{}))
+
+	for name, first := range map[string]ClientMessage{
+		"another command": {Type: ClientMessagePing},
+		"a bad token":     {Type: ClientMessageAuth, Token: "forged"},
+		"too few scopes":  {Type: ClientMessageAuth, Token: "bot-token"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conn, _ := dialUnsigned(t, h, "")
+			require.NotNil(t, conn)
+			require.NoError(t, conn.WriteJSON(first))
+
+			var err error
+			for err == nil {
+				_, _, err = conn.ReadMessage()
+			}
+			assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+		})
+	}
+}
+
+func TestMessagesBeforeSigningInAreSmall(t *testing.T) {
+	h := NewHandler(commandStub{}, WithAuthenticator(tokenAuth{}))
+	conn, _ := dialUnsigned(t, h, "")
+	require.NotNil(t, conn)
+
+	require.NoError(t, conn.WriteJSON(ClientMessage{Type: ClientMessageAuth, Token: strings.Repeat("a", maxAuthMessage)}))
+
+	var err error
+	for err == nil {
+		_, _, err = conn.ReadMessage()
+	}
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), err)
+}
+
+func TestSigningInWithTheURL(t *testing.T) {
+	h := NewHandler(commandStub{}, WithAuthenticator(tokenAuth{}))
+
+	conn, _ := dialUnsigned(t, h, "?access_token=ada-token")
+	require.NotNil(t, conn)
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageSnapshot, reply.Type)
+
+	_, resp := dialUnsigned(t, h, "?access_token=forged")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	_, resp = dialUnsigned(t, h, "?access_token=bot-token")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Without an authenticator only an Authorization header signs in
+	_, resp = dialUnsigned(t, NewHandler(commandStub{}), "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestCheckOrigin(t *testing.T) {
+	h := NewHandler(commandStub{}, WithAllowedOrigins([]string{"https://play.bigspella.com"}))
+	origin := func(origin string) bool {
+		r := httptest.NewRequest(http.MethodGet, "https://api.bigspella.com/games", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return h.checkOrigin(r)
+	}
+
+	assert.True(t, origin(""), "clients that aren't browsers")
+	assert.True(t, origin("https://api.bigspella.com"))
+	assert.True(t, origin("https://play.bigspella.com"))
+	assert.False(t, origin("https://evil.example"))
+	assert.False(t, origin("https://play.bigspella.com.evil.example"))
+}
+
+func TestChildrenCantChatOrUseTheirVoice(t *testing.T) {
+	stub := commandStub{chats: make(chan string, 1), log: newEventLog()}
+	h := NewHandler(stub, WithAuthenticator(tokenAuth{}))
+	conn, _ := dialUnsigned(t, h, "?access_token=kid-token")
+	require.NotNil(t, conn)
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	require.Equal(t, ServerMessageSnapshot, reply.Type)
+
+	reply = send(t, conn, ClientMessage{Type: ClientMessageChat, ID: "1", Text: "hi"})
+	assert.Equal(t, errChildChat.Error(), reply.Error)
+	reply = send(t, conn, ClientMessage{Type: ClientMessageAttempt, ID: "2", AttemptType: AttemptTypeVoice, VoiceData: []byte("audio")})
+	assert.Equal(t, errChildVoice.Error(), reply.Error)
+	assert.Empty(t, stub.chats)
+
+	// Other players' chat isn't passed on either
+	stub.log.publish(GameEvent{Type: EventTypeChatMessage, GameID: socketGameID})
+	stub.log.publish(GameEvent{Type: EventTypeTurnChanged, GameID: socketGameID})
+	var event GameEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, EventTypeTurnChanged, event.Type)
+	assert.Equal(t, int64(2), event.Seq)
+}
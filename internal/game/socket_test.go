@@ -0,0 +1,266 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+type commandStub struct {
+	GameService
+	chats chan string
+	voice chan []byte
+	log   *eventLog
+}
+
+func (s commandStub) GetGame(ctx context.Context, gameID string) (*Game, error) {
+	return &Game{ID: gameID, Round: 2}, nil
+}
+
+func (s commandStub) JoinGame(ctx context.Context, gameID, playerID, inviteCode string) (*Game, error) {
+	if inviteCode != "BEES" {
+		return nil, ErrInvalidInviteCode
+	}
+	return &Game{ID: gameID, Players: []*Player{{UserID: playerID}}}, nil
+}
+
+func (s commandStub) SendChat(ctx context.Context, gameID, playerID, message string) error {
+	s.chats <- playerID + ": " + message
+	return nil
+}
+
+func (s commandStub) MakeAttempt(ctx context.Context, gameID, playerID string, attempt *SpellingAttempt) error {
+	s.voice <- attempt.VoiceData
+	return nil
+}
+
+func (s commandStub) Subscribe(gameID, viewerID string, after int64) *Subscription {
+	if s.log == nil {
+		return &Subscription{cancel: func() {}}
+	}
+	return s.log.subscribe(gameID, viewerID, after)
+}
+
+const socketGameID = "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d"
+
+// connect opens a game's WebSocket as ada holding scopes, with query added
+// to the URL
+func connect(t *testing.T, service GameService, query string, scopes ...auth.Scope) *websocket.Conn {
+	h := NewHandler(service)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := auth.SetPrincipalInContext(r.Context(), &auth.Principal{UserID: "ada", Scopes: scopes})
+		r = r.WithContext(auth.SetUserIDInContext(ctx, "ada"))
+		h.SubscribeToEvents(w, r, httprouter.Params{{Key: "gameID", Value: socketGameID}})
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+query, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// dialGame connects to a game's WebSocket as ada holding scopes, and reads
+// the snapshot sent on connecting
+func dialGame(t *testing.T, service GameService, scopes ...auth.Scope) *websocket.Conn {
+	conn := connect(t, service, "", scopes...)
+
+	var snapshot struct {
+		Type string   `json:"type"`
+		Data GameView `json:"data"`
+	}
+	require.NoError(t, conn.ReadJSON(&snapshot))
+	assert.Equal(t, ServerMessageSnapshot, snapshot.Type)
+	assert.Equal(t, 2, snapshot.Data.Round)
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg ClientMessage) ServerMessage {
+	require.NoError(t, conn.WriteJSON(msg))
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, msg.ID, reply.ID, "replies carry the command's id")
+	return reply
+}
+
+func TestCommandsOverWebSocket(t *testing.T) {
+	stub := commandStub{chats: make(chan string, 1)}
+	conn := dialGame(t, stub, auth.UserScopes...)
+
+	assert.Equal(t, ServerMessagePong, send(t, conn, ClientMessage{Type: ClientMessagePing, ID: "1"}).Type)
+
+	reply := send(t, conn, ClientMessage{Type: ClientMessageJoin, ID: "2", InviteCode: "WASPS"})
+	assert.Equal(t, ServerMessageError, reply.Type)
+	assert.Equal(t, ErrInvalidInviteCode.Error(), reply.Error)
+
+	reply = send(t, conn, ClientMessage{Type: ClientMessageJoin, ID: "3", InviteCode: "BEES"})
+	assert.Equal(t, ServerMessageAck, reply.Type)
+	assert.NotNil(t, reply.Data)
+
+	reply = send(t, conn, ClientMessage{Type: ClientMessageChat, ID: "4", Text: "good luck"})
+	assert.Equal(t, ServerMessageAck, reply.Type)
+	assert.Equal(t, "ada: good luck", <-stub.chats)
+
+	// Commands are checked as their HTTP requests would be
+	reply = send(t, conn, ClientMessage{Type: ClientMessageAttempt, ID: "5", AttemptType: AttemptTypeText})
+	assert.Equal(t, errFailedValidation.Error(), reply.Error)
+	assert.Contains(t, reply.Data, "text")
+
+	reply = send(t, conn, ClientMessage{Type: ClientMessageHint, ID: "6", HintType: "horoscope"})
+	assert.Equal(t, errFailedValidation.Error(), reply.Error)
+
+	reply = send(t, conn, ClientMessage{Type: "teleport", ID: "7"})
+	assert.Equal(t, errInvalidMessage.Error(), reply.Error)
+
+	assert.Equal(t, ServerMessageSnapshot, send(t, conn, ClientMessage{Type: ClientMessageSync, ID: "8"}).Type)
+}
+
+func TestCommandsNeedTheirScope(t *testing.T) {
+	conn := dialGame(t, commandStub{}, auth.ScopeEventsRead)
+
+	reply := send(t, conn, ClientMessage{Type: ClientMessageChat, ID: "1", Text: "hi"})
+	assert.Equal(t, auth.ErrInsufficientScope.Error(), reply.Error)
+
+	assert.Equal(t, ServerMessagePong, send(t, conn, ClientMessage{Type: ClientMessagePing, ID: "2"}).Type)
+}
+
+func TestStreamingAVoiceAnswer(t *testing.T) {
+	stub := commandStub{voice: make(chan []byte, 1)}
+	conn := dialGame(t, stub, auth.UserScopes...)
+
+	// Audio sent before a voice answer is started is turned away
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("um")))
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ErrNoVoiceStream.Error(), reply.Error)
+
+	assert.Equal(t, ServerMessageAck, send(t, conn, ClientMessage{Type: ClientMessageVoiceStart, ID: "1"}).Type)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("bum")))
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("ble")))
+	assert.Equal(t, ServerMessageAck, send(t, conn, ClientMessage{Type: ClientMessageVoiceEnd, ID: "2"}).Type)
+	assert.Equal(t, []byte("bumble"), <-stub.voice)
+
+	// The answer is gone once submitted or cancelled
+	assert.Equal(t, ErrNoVoiceStream.Error(), send(t, conn, ClientMessage{Type: ClientMessageVoiceEnd, ID: "3"}).Error)
+
+	send(t, conn, ClientMessage{Type: ClientMessageVoiceStart, ID: "4"})
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("bee")))
+	assert.Equal(t, ServerMessageAck, send(t, conn, ClientMessage{Type: ClientMessageVoiceCancel, ID: "5"}).Type)
+	assert.Equal(t, ErrNoVoiceStream.Error(), send(t, conn, ClientMessage{Type: ClientMessageVoiceEnd, ID: "6"}).Error)
+
+	// An answer with no audio fails validation like an empty upload
+	send(t, conn, ClientMessage{Type: ClientMessageVoiceStart, ID: "7"})
+	reply = send(t, conn, ClientMessage{Type: ClientMessageVoiceEnd, ID: "8"})
+	assert.Equal(t, errFailedValidation.Error(), reply.Error)
+	assert.Contains(t, reply.Data, "voice_data")
+}
+
+func TestVoiceStreamSize(t *testing.T) {
+	var v voiceStream
+	require.NoError(t, v.write(make([]byte, MaxVoiceStream)))
+	assert.ErrorIs(t, v.write([]byte{0}), ErrVoiceStreamSize)
+}
+
+func TestInGame(t *testing.T) {
+	game := &Game{Players: []*Player{
+		{UserID: "ada", Status: "active"},
+		{UserID: "bo", Status: "left"},
+		{UserID: "cy", Status: "kicked"},
+	}}
+	assert.True(t, inGame(game, "ada"))
+	assert.False(t, inGame(game, "bo"))
+	assert.False(t, inGame(game, "cy"))
+	assert.False(t, inGame(game, "dee"))
+}
+
+func TestResumingAfterReconnecting(t *testing.T) {
+	stub := commandStub{log: newEventLog()}
+	for _, eventType := range []EventType{EventTypeGameStarted, EventTypeRoundStarted, EventTypeTurnChanged} {
+		stub.log.publish(GameEvent{Type: eventType, GameID: socketGameID})
+	}
+
+	// Missed events are replayed rather than sending a snapshot
+	conn := connect(t, stub, "?resume_from=1", auth.UserScopes...)
+	var event GameEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, int64(2), event.Seq)
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, EventTypeTurnChanged, event.Type)
+
+	// A client too far behind, such as after the server restarted, gets a
+	// snapshot to carry on from
+	conn = connect(t, stub, "?resume_from=900", auth.UserScopes...)
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageSnapshot, reply.Type)
+	assert.Equal(t, int64(3), reply.Seq)
+
+	stub.log.publish(GameEvent{Type: EventTypeTurnChanged, GameID: socketGameID})
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, int64(4), event.Seq)
+}
+
+type missingGameStub struct {
+	commandStub
+}
+
+func (s missingGameStub) GetGame(ctx context.Context, gameID string) (*Game, error) {
+	return nil, ErrGameNotFound
+}
+
+func TestUnknownGamesCloseTheSocket(t *testing.T) {
+	stub := missingGameStub{commandStub{log: newEventLog()}}
+	conn := connect(t, stub, "", auth.UserScopes...)
+
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageError, reply.Type)
+	assert.Equal(t, ErrGameNotFound.Error(), reply.Error)
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+	assert.Empty(t, stub.log.games, "nothing is subscribed to a game that doesn't exist")
+}
+
+// flakyGameStub loads the game once, then fails to
+type flakyGameStub struct {
+	commandStub
+	loads *atomic.Int32
+}
+
+func (s flakyGameStub) GetGame(ctx context.Context, gameID string) (*Game, error) {
+	if s.loads.Add(1) > 1 {
+		return nil, errors.New("database unavailable")
+	}
+	return s.commandStub.GetGame(ctx, gameID)
+}
+
+func TestSnapshotFailureClosesTheSocket(t *testing.T) {
+	conn := connect(t, flakyGameStub{loads: new(atomic.Int32)}, "", auth.UserScopes...)
+
+	var reply ServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, ServerMessageError, reply.Type)
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseInternalServerErr), err)
+}
+
+func TestResumeFromIsValidated(t *testing.T) {
+	h := NewHandler(commandStub{})
+	req := httptest.NewRequest(http.MethodGet, "/games/"+socketGameID+"/events?resume_from=-1", nil)
+	rec := httptest.NewRecorder()
+	h.SubscribeToEvents(rec, req, httprouter.Params{{Key: "gameID", Value: socketGameID}})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -0,0 +1,230 @@
+package solo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/infrastructure/aws/dynamodb"
+)
+
+const (
+	// DrillWords is how many words a drill has unless the player asks for
+	// more or fewer, up to MaxDrillWords
+	DrillWords    = 10
+	MaxDrillWords = 25
+)
+
+var ErrNothingToDrill = errors.New("you haven't missed any words to drill")
+
+// MissedWord is a word a player has got wrong, counting their attempts in
+// multiplayer games and in solo games and drills
+type MissedWord struct {
+	WordID   string `json:"word_id" db:"word_id"`
+	Word     string `json:"word" db:"word"`
+	Level    int    `json:"level" db:"level"`
+	Misses   int    `json:"misses" db:"misses"`
+	Attempts int    `json:"attempts" db:"attempts"`
+}
+
+// Drill is how far a player has got through a drill. Results has the words
+// finished so far, or every word once the drill is over.
+type Drill struct {
+	Words   int         `json:"words"`
+	Current int         `json:"current"`
+	Results []DrillWord `json:"results"`
+}
+
+// DrillWord is how a player did on one of a drill's words
+type DrillWord struct {
+	WordID   string `json:"word_id"`
+	Word     string `json:"word"`
+	Solved   bool   `json:"solved"`
+	Attempts int    `json:"attempts"`
+}
+
+// MissedWords returns up to limit of the words userID misses most. With
+// unmastered set, words they have since spelled at least as often as they
+// missed are left out, as there's no need to drill them.
+func (s *Service) MissedWords(ctx context.Context, userID string, limit int, unmastered bool) ([]MissedWord, error) {
+	words := []MissedWord{}
+	if err := s.db.SelectContext(ctx, &words, `
+		WITH history AS (
+			SELECT w.id AS word_id,
+				COUNT(*) FILTER (WHERE NOT a.is_correct) AS misses,
+				COUNT(*) AS attempts
+			FROM spelling_attempts a
+			JOIN games g ON g.id = a.game_id
+			JOIN words w ON LOWER(w.word) = LOWER(a.word)
+				AND w.language = COALESCE(NULLIF(g.settings->>'language', ''), $4)
+			WHERE a.player_id = $1 AND a.status = 'judged'
+			GROUP BY w.id
+			UNION ALL
+			SELECT word_id, incorrect_attempts, correct_attempts + incorrect_attempts
+			FROM user_word_history
+			WHERE user_id = $1
+		)
+		SELECT w.id AS word_id, w.word, w.level,
+			SUM(h.misses) AS misses, SUM(h.attempts) AS attempts
+		FROM history h
+		JOIN words w ON w.id = h.word_id
+		GROUP BY w.id, w.word, w.level
+		HAVING SUM(h.misses) > 0 AND (NOT $3 OR 2 * SUM(h.misses) > SUM(h.attempts))
+		ORDER BY misses DESC, attempts, w.word
+		LIMIT $2`, userID, limit, unmastered, game.DefaultLanguage); err != nil {
+		return nil, fmt.Errorf("failed to get missed words: %w", err)
+	}
+	return words, nil
+}
+
+// StartDrill gives userID a game of up to size of the words they miss most,
+// to be spelled one after another
+func (s *Service) StartDrill(ctx context.Context, userID string, size int) (*Game, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	missed, err := s.MissedWords(ctx, userID, size, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(missed) == 0 {
+		return nil, ErrNothingToDrill
+	}
+
+	ids := make([]string, len(missed))
+	for i, w := range missed {
+		ids[i] = w.WordID
+	}
+	now := s.now()
+	g := &dynamodb.SoloGame{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    StatusInProgress,
+		WordID:    ids[0],
+		WordIDs:   ids,
+		StartedAt: now,
+		CreatedAt: now,
+	}
+	if err := s.store.CreateSoloGame(ctx, g); err != nil {
+		return nil, err
+	}
+	return s.view(ctx, g)
+}
+
+// isDrill is whether g is a drill. Daily challenge plays also have a list
+// of words, but are kept for a day.
+func isDrill(g *dynamodb.SoloGame) bool {
+	return len(g.WordIDs) > 0 && g.ChallengeDate == ""
+}
+
+// drillRounds splits a drill's attempts by the word they were at. A word is
+// done once it's spelled or MaxAttempts are used up, so the last round is
+// still being played when it's neither.
+func drillRounds(attempts []dynamodb.Attempt) (rounds [][]dynamodb.Attempt, done int) {
+	start := 0
+	for i, a := range attempts {
+		if a.IsCorrect || i+1-start >= MaxAttempts {
+			rounds = append(rounds, attempts[start:i+1])
+			start = i + 1
+		}
+	}
+	done = len(rounds)
+	if start < len(attempts) {
+		rounds = append(rounds, attempts[start:])
+	}
+	return rounds, done
+}
+
+// drillScore adds up the points for each of a drill's words
+func drillScore(attempts []dynamodb.Attempt) int {
+	rounds, _ := drillRounds(attempts)
+	total := 0
+	for _, round := range rounds {
+		total += score(round)
+	}
+	return total
+}
+
+// spellings looks up the words with ids. A word deleted since the drill
+// started is missing, and can't be spelled.
+func (s *Service) spellings(ctx context.Context, ids []string) (map[string]string, error) {
+	var words []struct {
+		ID   string `db:"id"`
+		Word string `db:"word"`
+	}
+	if err := s.db.SelectContext(ctx, &words, `
+		SELECT id, word FROM words WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to get drill words: %w", err)
+	}
+	spellings := make(map[string]string, len(words))
+	for _, w := range words {
+		spellings[w.ID] = w.Word
+	}
+	return spellings, nil
+}
+
+// view is g as its player sees it, with the clue to the word they are on
+// and, for drills, the spellings of the words they are done with
+func (s *Service) view(ctx context.Context, g *dynamodb.SoloGame) (*Game, error) {
+	view := newGame(g)
+	var err error
+	if g.Status == StatusInProgress && view.WordID != "" {
+		if view.Clue, err = s.clue(ctx, view.WordID); err != nil {
+			return nil, err
+		}
+	}
+	if view.Drill != nil && len(view.Drill.Results) > 0 {
+		ids := make([]string, len(view.Drill.Results))
+		for i, result := range view.Drill.Results {
+			ids[i] = result.WordID
+		}
+		spellings, err := s.spellings(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range view.Drill.Results {
+			view.Drill.Results[i].Word = spellings[view.Drill.Results[i].WordID]
+		}
+	}
+	return view, nil
+}
+
+// drillProgress fills in view, made from the drill g, with the word being
+// spelled and how the player did on those before it
+func drillProgress(view *Game, g *dynamodb.SoloGame) {
+	rounds, done := drillRounds(g.Attempts)
+	drill := &Drill{Words: len(g.WordIDs), Current: min(done, len(g.WordIDs)), Results: []DrillWord{}}
+
+	finished := min(done, len(g.WordIDs))
+	if g.Status != StatusInProgress {
+		finished = len(g.WordIDs)
+	}
+	solved := 0
+	for i, id := range g.WordIDs[:finished] {
+		result := DrillWord{WordID: id}
+		if i < len(rounds) {
+			result.Attempts = len(rounds[i])
+			result.Solved = rounds[i][len(rounds[i])-1].IsCorrect
+		}
+		if result.Solved {
+			solved++
+		}
+		drill.Results = append(drill.Results, result)
+	}
+
+	view.Drill = drill
+	view.Solved = solved == len(g.WordIDs)
+	view.WordID, view.AttemptsLeft = "", 0
+	if g.Status == StatusInProgress && done < len(g.WordIDs) {
+		view.WordID = g.WordIDs[done]
+		view.AttemptsLeft = MaxAttempts
+		if done < len(rounds) {
+			view.AttemptsLeft -= len(rounds[done])
+		}
+	}
+}
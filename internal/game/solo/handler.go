@@ -0,0 +1,333 @@
+package solo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type StartRequest struct {
+	Level     int                 `json:"level"`
+	Category  *string             `json:"category,omitempty"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *StartRequest) validate() {
+	r.Validator.CheckField(validator.Between(r.Level, game.MinWordLevel, game.MaxWordLevel), "level", "Must be between 1 and 10")
+	r.Validator.CheckField(r.Category == nil || validator.NotBlank(*r.Category), "category", "Must not be blank")
+}
+
+type AttemptRequest struct {
+	Type      game.AttemptType    `json:"type"`
+	Text      *string             `json:"text,omitempty"`
+	VoiceData []byte              `json:"voice_data,omitempty"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *AttemptRequest) validate() {
+	v := &r.Validator
+	v.CheckField(validator.In(r.Type, game.AttemptTypeText, game.AttemptTypeVoice), "type", "Must be text or voice")
+
+	switch r.Type {
+	case game.AttemptTypeText:
+		v.CheckField(r.Text != nil && validator.NotBlank(*r.Text), "text", "Text is required")
+		v.CheckField(r.Text == nil || validator.MaxRunes(*r.Text, game.MaxTextAttempt), "text", "Must not be more than 100 characters")
+	case game.AttemptTypeVoice:
+		v.CheckField(len(r.VoiceData) > 0, "voice_data", "Voice data is required")
+	}
+}
+
+type DrillRequest struct {
+	Words     int                 `json:"words"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *DrillRequest) validate() {
+	if r.Words == 0 {
+		r.Words = DrillWords
+	}
+	r.Validator.CheckField(validator.Between(r.Words, 1, MaxDrillWords), "words", "Must be between 1 and 25")
+}
+
+type ListRequest struct {
+	Before    time.Time
+	Limit     int
+	Validator validator.Validator
+}
+
+func parseListRequest(query url.Values) ListRequest {
+	req := ListRequest{Limit: DefaultPageSize}
+	if raw := query.Get("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339Nano, raw)
+		req.Validator.CheckField(err == nil, "before", "Must be an RFC 3339 timestamp")
+		req.Before = before
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		req.Validator.CheckField(err == nil, "limit", "Must be a whole number")
+		req.Limit = n
+	}
+	return req
+}
+
+func (r *ListRequest) validate() {
+	r.Validator.CheckField(validator.Between(r.Limit, 1, MaxPageSize), "limit", "Must be between 1 and 100")
+}
+
+// Start begins a new game for the caller
+func (h *Handler) Start(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	g, err := h.service.Start(r.Context(), userID, req.Level, req.Category)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(g)
+}
+
+// List serves a page of the caller's games, newest first. The created_at
+// of the last game is the before of the next page.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := parseListRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	games, err := h.service.List(r.Context(), userID, req.Before, req.Limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"games": games})
+}
+
+// Get serves one of the caller's games
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, gameID, ok := gameRequest(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.service.Get(r.Context(), userID, gameID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g)
+}
+
+// Attempt grades the caller's try at spelling their game's word
+func (h *Handler) Attempt(w http.ResponseWriter, r *http.Request) {
+	userID, gameID, ok := gameRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req AttemptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	attempt := &game.SpellingAttempt{Type: req.Type, VoiceData: req.VoiceData}
+	if req.Text != nil {
+		attempt.Text = *req.Text
+	}
+	g, err := h.service.Attempt(r.Context(), userID, gameID, attempt)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g)
+}
+
+// Complete ends the caller's game without another attempt
+func (h *Handler) Complete(w http.ResponseWriter, r *http.Request) {
+	userID, gameID, ok := gameRequest(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.service.Complete(r.Context(), userID, gameID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g)
+}
+
+// Delete removes one of the caller's games from their history
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, gameID, ok := gameRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID, gameID); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MissedWords serves the words the caller misses most
+func (h *Handler) MissedWords(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := parseListRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	words, err := h.service.MissedWords(r.Context(), userID, req.Limit, false)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"words": words})
+}
+
+// StartDrill begins a game of the words the caller misses most and hasn't
+// since got the hang of
+func (h *Handler) StartDrill(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req DrillRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	g, err := h.service.StartDrill(r.Context(), userID, req.Words)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(g)
+}
+
+// gameRequest reads the caller and the game ID in the path, writing the
+// response itself when either is missing or malformed
+func gameRequest(w http.ResponseWriter, r *http.Request) (userID, gameID string, ok bool) {
+	userID = auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	gameID = httprouter.ParamsFromContext(r.Context()).ByName("gameID")
+	var v validator.Validator
+	_, err := uuid.Parse(gameID)
+	v.CheckField(err == nil, "game_id", "Must be a valid game ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return "", "", false
+	}
+	return userID, gameID, true
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrGameNotFound), errors.Is(err, ErrNothingToDrill):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, game.ErrNoWordsAvailable):
+		http.Error(w, "no words match this level and category", http.StatusNotFound)
+	case errors.Is(err, ErrGameOver):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,270 @@
+package solo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/infrastructure/aws/dynamodb"
+)
+
+// PostgresStore keeps solo games in Postgres, for servers without DynamoDB.
+// Per-word stats go to user_word_history. It also stores daily challenge
+// plays.
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const uniqueViolation = "23505"
+
+// pgAttempt is how an attempt is kept in the attempts column
+type pgAttempt struct {
+	Word      string    `json:"word"`
+	Type      string    `json:"type"`
+	IsCorrect bool      `json:"is_correct"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+const soloGameColumns = `id, user_id, status, COALESCE(word_id::text, ''), word, attempts,
+	hints_used, score, started_at, completed_at, created_at,
+	COALESCE(to_char(challenge_date, 'YYYY-MM-DD'), ''), level, word_ids, COALESCE(daily_board, '')`
+
+func (s *PostgresStore) CreateSoloGame(ctx context.Context, g *dynamodb.SoloGame) error {
+	attempts, err := marshalAttempts(g.Attempts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO solo_games (id, user_id, status, word_id, word, attempts, hints_used, score,
+			started_at, completed_at, created_at, challenge_date, level, word_ids, daily_board)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10, $11,
+			NULLIF($12, '')::date, $13, $14, NULLIF($15, ''))`,
+		g.ID, g.UserID, g.Status, g.WordID, g.Word, attempts, g.HintsUsed, g.Score,
+		g.StartedAt, nullTime(g.CompletedAt), g.CreatedAt, g.ChallengeDate, g.Level,
+		pq.Array(nonNil(g.WordIDs)), g.DailyBoard); err != nil {
+		if hasCode(err, uniqueViolation) {
+			return dynamodb.ErrSoloGameExists
+		}
+		return fmt.Errorf("failed to create solo game: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetSoloGame(ctx context.Context, id string) (*dynamodb.SoloGame, error) {
+	g, err := scanSoloGame(s.db.QueryRowxContext(ctx, `
+		SELECT `+soloGameColumns+` FROM solo_games WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dynamodb.ErrSoloGameNotFound
+	}
+	return g, err
+}
+
+func (s *PostgresStore) ReplaceSoloGame(ctx context.Context, g *dynamodb.SoloGame, fromStatus string) error {
+	attempts, err := marshalAttempts(g.Attempts)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE solo_games SET
+			status = $2, word_id = NULLIF($3, '')::uuid, word = $4, attempts = $5, hints_used = $6,
+			score = $7, started_at = $8, completed_at = $9, challenge_date = NULLIF($10, '')::date,
+			level = $11, word_ids = $12, daily_board = NULLIF($13, '')
+		WHERE id = $1 AND status = $14`,
+		g.ID, g.Status, g.WordID, g.Word, attempts, g.HintsUsed, g.Score, g.StartedAt,
+		nullTime(g.CompletedAt), g.ChallengeDate, g.Level, pq.Array(nonNil(g.WordIDs)), g.DailyBoard, fromStatus)
+	if err != nil {
+		return fmt.Errorf("failed to update solo game: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return dynamodb.ErrSoloGameChanged
+	}
+	return nil
+}
+
+func (s *PostgresStore) AppendSoloAttempt(ctx context.Context, id string, attempt dynamodb.Attempt, inStatus string) (*dynamodb.SoloGame, error) {
+	added, err := marshalAttempts([]dynamodb.Attempt{attempt})
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := scanSoloGame(s.db.QueryRowxContext(ctx, `
+		UPDATE solo_games SET attempts = attempts || $2::jsonb
+		WHERE id = $1 AND status = $3
+		RETURNING `+soloGameColumns, id, added, inStatus))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dynamodb.ErrSoloGameChanged
+	}
+	return g, err
+}
+
+func (s *PostgresStore) FinishSoloGame(ctx context.Context, id, fromStatus, status string, score int, at time.Time) (*dynamodb.SoloGame, error) {
+	g, err := scanSoloGame(s.db.QueryRowxContext(ctx, `
+		UPDATE solo_games SET status = $2, score = $3, completed_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+soloGameColumns, id, status, score, at, fromStatus))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, dynamodb.ErrSoloGameChanged
+	}
+	return g, err
+}
+
+func (s *PostgresStore) DeleteSoloGame(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM solo_games WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete solo game: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return dynamodb.ErrSoloGameNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) SoloGamesByUser(ctx context.Context, userID string, before time.Time, limit int) ([]dynamodb.SoloGame, error) {
+	query := `SELECT ` + soloGameColumns + ` FROM solo_games WHERE user_id = $1`
+	args := []interface{}{userID, limit}
+	if !before.IsZero() {
+		query += ` AND created_at < $3`
+		args = append(args, before)
+	}
+	query += ` ORDER BY created_at DESC, id LIMIT $2`
+
+	return s.selectSoloGames(ctx, "user's solo games", query, args...)
+}
+
+func (s *PostgresStore) TopDailyScores(ctx context.Context, board string, limit int) ([]dynamodb.SoloGame, error) {
+	return s.selectSoloGames(ctx, "daily leaderboard", `
+		SELECT `+soloGameColumns+` FROM solo_games
+		WHERE daily_board = $1
+		ORDER BY score DESC, completed_at, id
+		LIMIT $2`, board, limit)
+}
+
+func (s *PostgresStore) RecordWordAttempt(ctx context.Context, userID, wordID string, correct bool, at time.Time) (*dynamodb.UserWordStats, error) {
+	correctAttempts, incorrectAttempts := 0, 1
+	if correct {
+		correctAttempts, incorrectAttempts = 1, 0
+	}
+
+	stats := &dynamodb.UserWordStats{}
+	var nextReview sql.NullTime
+	if err := s.db.QueryRowxContext(ctx, `
+		INSERT INTO user_word_history (user_id, word_id, status, correct_attempts, incorrect_attempts, last_attempt_at)
+		VALUES ($1, $2, 'learning', $3, $4, $5)
+		ON CONFLICT (user_id, word_id) DO UPDATE SET
+			correct_attempts = user_word_history.correct_attempts + EXCLUDED.correct_attempts,
+			incorrect_attempts = user_word_history.incorrect_attempts + EXCLUDED.incorrect_attempts,
+			last_attempt_at = EXCLUDED.last_attempt_at,
+			updated_at = NOW()
+		RETURNING user_id, word_id, correct_attempts, incorrect_attempts, last_attempt_at, next_review_at`,
+		userID, wordID, correctAttempts, incorrectAttempts, at,
+	).Scan(&stats.UserID, &stats.WordID, &stats.CorrectAttempts, &stats.IncorrectAttempts, &stats.LastAttemptAt, &nextReview); err != nil {
+		return nil, fmt.Errorf("failed to record word attempt: %w", err)
+	}
+	stats.NextReviewAt = nextReview.Time
+	return stats, nil
+}
+
+func (s *PostgresStore) ScheduleWordReview(ctx context.Context, userID, wordID string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE user_word_history SET next_review_at = $3, updated_at = NOW()
+		WHERE user_id = $1 AND word_id = $2`, userID, wordID, at); err != nil {
+		return fmt.Errorf("failed to schedule word review: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) PurgeUser(ctx context.Context, userID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM solo_games WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user's solo games: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_word_history WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user's word history: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) selectSoloGames(ctx context.Context, what, query string, args ...interface{}) ([]dynamodb.SoloGame, error) {
+	rows, err := s.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	games := []dynamodb.SoloGame{}
+	for rows.Next() {
+		g, err := scanSoloGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	return games, nil
+}
+
+func scanSoloGame(row interface{ Scan(...interface{}) error }) (*dynamodb.SoloGame, error) {
+	g := &dynamodb.SoloGame{}
+	var attempts []byte
+	var completedAt sql.NullTime
+	if err := row.Scan(&g.ID, &g.UserID, &g.Status, &g.WordID, &g.Word, &attempts,
+		&g.HintsUsed, &g.Score, &g.StartedAt, &completedAt, &g.CreatedAt,
+		&g.ChallengeDate, &g.Level, pq.Array(&g.WordIDs), &g.DailyBoard); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan solo game: %w", err)
+	}
+	g.CompletedAt = completedAt.Time
+
+	var stored []pgAttempt
+	if err := json.Unmarshal(attempts, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode solo game %s attempts: %w", g.ID, err)
+	}
+	for _, a := range stored {
+		g.Attempts = append(g.Attempts, dynamodb.Attempt(a))
+	}
+	return g, nil
+}
+
+func marshalAttempts(attempts []dynamodb.Attempt) ([]byte, error) {
+	stored := make([]pgAttempt, len(attempts))
+	for i, a := range attempts {
+		stored[i] = pgAttempt(a)
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attempts: %w", err)
+	}
+	return data, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// nonNil keeps a nil slice from being stored as NULL
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
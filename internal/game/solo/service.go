@@ -0,0 +1,412 @@
+// Package solo runs practice games: one player, one word, a few tries to
+// spell it. Games are kept in Postgres or DynamoDB along with how each
+// player has done on every word they have practised, which schedules when
+// to review it.
+package solo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/respell"
+	"big-spella-go/internal/infrastructure/aws/dynamodb"
+)
+
+const (
+	// MaxAttempts is how many tries a player has at a word
+	MaxAttempts = 3
+
+	pointsPerWord = 100
+	// missPenalty is taken off a solved word's points for each miss first
+	missPenalty = 30
+
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+)
+
+var (
+	ErrDisabled     = errors.New("solo games are not configured")
+	ErrGameNotFound = errors.New("game not found")
+	ErrGameOver     = errors.New("this game is over")
+)
+
+// SoloGameStore keeps solo games and per-word stats. DynamoDBService and
+// PostgresStore both implement it.
+type SoloGameStore interface {
+	CreateSoloGame(ctx context.Context, game *dynamodb.SoloGame) error
+	GetSoloGame(ctx context.Context, id string) (*dynamodb.SoloGame, error)
+	AppendSoloAttempt(ctx context.Context, id string, attempt dynamodb.Attempt, inStatus string) (*dynamodb.SoloGame, error)
+	FinishSoloGame(ctx context.Context, id, fromStatus, status string, score int, at time.Time) (*dynamodb.SoloGame, error)
+	DeleteSoloGame(ctx context.Context, id string) error
+	SoloGamesByUser(ctx context.Context, userID string, before time.Time, limit int) ([]dynamodb.SoloGame, error)
+	RecordWordAttempt(ctx context.Context, userID, wordID string, correct bool, at time.Time) (*dynamodb.UserWordStats, error)
+	ScheduleWordReview(ctx context.Context, userID, wordID string, at time.Time) error
+	// PurgeUser deletes every game and word stat kept for userID
+	PurgeUser(ctx context.Context, userID string) error
+}
+
+// Clue is everything about a game's word but its spelling
+type Clue struct {
+	Definition      string `json:"definition" db:"definition"`
+	ExampleSentence string `json:"example_sentence" db:"example_sentence"`
+	PartOfSpeech    string `json:"part_of_speech" db:"part_of_speech"`
+	Pronunciation   string `json:"pronunciation" db:"pronunciation"`
+	Respelling      string `json:"respelling" db:"respelling"`
+	AudioURL        string `json:"audio_url" db:"audio_url"`
+}
+
+type Attempt struct {
+	Text      string    `json:"text"`
+	Type      string    `json:"type"`
+	Correct   bool      `json:"correct"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Game is a solo game as its player sees it. The word is only given away
+// once the game is over.
+type Game struct {
+	ID            string     `json:"id"`
+	Status        string     `json:"status"`
+	Level         int        `json:"level,omitempty"`
+	WordID        string     `json:"word_id,omitempty"`
+	Word          string     `json:"word,omitempty"`
+	Clue          *Clue      `json:"clue,omitempty"`
+	Attempts      []Attempt  `json:"attempts"`
+	AttemptsLeft  int        `json:"attempts_left"`
+	Solved        bool       `json:"solved"`
+	Score         int        `json:"score"`
+	ChallengeDate string     `json:"challenge_date,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	// Drill is set for drills, which have a word to spell after another
+	Drill *Drill `json:"drill,omitempty"`
+}
+
+type Service struct {
+	db    *sqlx.DB
+	store SoloGameStore
+	words game.WordService
+	now   func() time.Time
+}
+
+// NewService keeps games in store. With a nil store every call fails with
+// ErrDisabled.
+func NewService(db *sqlx.DB, store SoloGameStore, words game.WordService) *Service {
+	return &Service{db: db, store: store, words: words, now: time.Now}
+}
+
+// Start gives userID a new game with a word at level from category, or from
+// any category when it is nil
+func (s *Service) Start(ctx context.Context, userID string, level int, category *string) (*Game, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	word, err := s.words.GetRandomWord(ctx, game.WordQuery{Level: level, Category: category})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	g := &dynamodb.SoloGame{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    StatusInProgress,
+		WordID:    word.ID,
+		Word:      word.Word,
+		Level:     level,
+		StartedAt: now,
+		CreatedAt: now,
+	}
+	if err := s.store.CreateSoloGame(ctx, g); err != nil {
+		return nil, err
+	}
+
+	view := newGame(g)
+	view.Clue = &Clue{
+		Definition:      word.Definition,
+		ExampleSentence: word.ExampleSentence,
+		PartOfSpeech:    word.PartOfSpeech,
+		Pronunciation:   word.Pronunciation,
+		Respelling:      word.Respelling,
+		AudioURL:        word.AudioURL,
+	}
+	return view, nil
+}
+
+// Get returns one of userID's games with its word's clue
+func (s *Service) Get(ctx context.Context, userID, gameID string) (*Game, error) {
+	g, err := s.get(ctx, userID, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDrill(g) {
+		return s.view(ctx, g)
+	}
+
+	view := newGame(g)
+	if g.WordID != "" {
+		if view.Clue, err = s.clue(ctx, g.WordID); err != nil {
+			return nil, err
+		}
+	}
+	return view, nil
+}
+
+// List returns up to limit of userID's games, newest first, starting with
+// those created before before when it isn't zero
+func (s *Service) List(ctx context.Context, userID string, before time.Time, limit int) ([]Game, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	games, err := s.store.SoloGamesByUser(ctx, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]Game, len(games))
+	for i := range games {
+		views[i] = *newGame(&games[i])
+	}
+	return views, nil
+}
+
+// Purge deletes all of userID's games and what they've practised, for when
+// they delete their account
+func (s *Service) Purge(ctx context.Context, userID string) error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.PurgeUser(ctx, userID)
+}
+
+// Attempt records userID's try at spelling their game's word, ending the
+// game once it is spelled or the tries run out
+func (s *Service) Attempt(ctx context.Context, userID, gameID string, attempt *game.SpellingAttempt) (*Game, error) {
+	g, err := s.get(ctx, userID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if g.Status != StatusInProgress || g.WordID == "" {
+		return nil, ErrGameOver
+	}
+
+	// A drill's word is whichever its attempts have got to
+	word, wordID := g.Word, g.WordID
+	if isDrill(g) {
+		_, done := drillRounds(g.Attempts)
+		if done >= len(g.WordIDs) {
+			return nil, ErrGameOver
+		}
+		wordID = g.WordIDs[done]
+		spellings, err := s.spellings(ctx, []string{wordID})
+		if err != nil {
+			return nil, err
+		}
+		word = spellings[wordID]
+	}
+
+	text := attempt.Text
+	if attempt.Type == game.AttemptTypeVoice {
+		if text, err = s.words.TranscribeVoice(ctx, attempt.VoiceData, game.DefaultLanguage); err != nil {
+			return nil, fmt.Errorf("failed to transcribe attempt: %w", err)
+		}
+	}
+
+	now := s.now()
+	correct := word != "" && s.words.ValidateSpelling(ctx, word, text)
+	g, err = s.store.AppendSoloAttempt(ctx, gameID, dynamodb.Attempt{
+		Word:      text,
+		Type:      string(attempt.Type),
+		IsCorrect: correct,
+		Timestamp: now,
+	}, StatusInProgress)
+	if err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameChanged) {
+			return nil, ErrGameOver
+		}
+		return nil, err
+	}
+
+	if err := s.recordStats(ctx, userID, wordID, correct, now); err != nil {
+		return nil, err
+	}
+
+	if isDrill(g) {
+		if _, done := drillRounds(g.Attempts); done >= len(g.WordIDs) {
+			return s.finish(ctx, g)
+		}
+		return s.view(ctx, g)
+	}
+	if correct || len(g.Attempts) >= MaxAttempts {
+		return s.finish(ctx, g)
+	}
+	return newGame(g), nil
+}
+
+// Complete ends userID's game before they have spelled its word, as when
+// they give up on it
+func (s *Service) Complete(ctx context.Context, userID, gameID string) (*Game, error) {
+	g, err := s.get(ctx, userID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if g.Status != StatusInProgress {
+		return nil, ErrGameOver
+	}
+	return s.finish(ctx, g)
+}
+
+// Delete removes one of userID's games from their history
+func (s *Service) Delete(ctx context.Context, userID, gameID string) error {
+	if _, err := s.get(ctx, userID, gameID); err != nil {
+		return err
+	}
+	if err := s.store.DeleteSoloGame(ctx, gameID); err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameNotFound) {
+			return ErrGameNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) get(ctx context.Context, userID, gameID string) (*dynamodb.SoloGame, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+
+	g, err := s.store.GetSoloGame(ctx, gameID)
+	if err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameNotFound) {
+			return nil, ErrGameNotFound
+		}
+		return nil, err
+	}
+	// Other players' games are hidden rather than forbidden
+	if g.UserID != userID {
+		return nil, ErrGameNotFound
+	}
+	return g, nil
+}
+
+func (s *Service) finish(ctx context.Context, g *dynamodb.SoloGame) (*Game, error) {
+	points := score(g.Attempts)
+	if isDrill(g) {
+		points = drillScore(g.Attempts)
+	}
+	finished, err := s.store.FinishSoloGame(ctx, g.ID, StatusInProgress, StatusCompleted, points, s.now())
+	if err != nil {
+		if errors.Is(err, dynamodb.ErrSoloGameChanged) {
+			return nil, ErrGameOver
+		}
+		return nil, err
+	}
+	if isDrill(finished) {
+		return s.view(ctx, finished)
+	}
+	return newGame(finished), nil
+}
+
+// recordStats counts an attempt towards userID's stats for a word and
+// schedules when they should next review it
+func (s *Service) recordStats(ctx context.Context, userID, wordID string, correct bool, at time.Time) error {
+	stats, err := s.store.RecordWordAttempt(ctx, userID, wordID, correct, at)
+	if err != nil {
+		return err
+	}
+	return s.store.ScheduleWordReview(ctx, userID, wordID, nextReview(stats, correct, at))
+}
+
+// nextReview brings a missed word back the next day, and leaves a word
+// spelled correctly for twice as long with each more correct attempt than
+// misses, up to a month
+func nextReview(stats *dynamodb.UserWordStats, correct bool, at time.Time) time.Time {
+	if !correct {
+		return at.Add(24 * time.Hour)
+	}
+	lead := stats.CorrectAttempts - stats.IncorrectAttempts
+	if lead < 1 {
+		lead = 1
+	}
+	if lead > 6 {
+		lead = 6
+	}
+	days := 1 << (lead - 1)
+	if days > 30 {
+		days = 30
+	}
+	return at.Add(time.Duration(days) * 24 * time.Hour)
+}
+
+// score awards a solved word's points less a penalty for each miss before
+// it, and nothing for a word never spelled
+func score(attempts []dynamodb.Attempt) int {
+	for i, a := range attempts {
+		if a.IsCorrect {
+			return pointsPerWord - i*missPenalty
+		}
+	}
+	return 0
+}
+
+func (s *Service) clue(ctx context.Context, wordID string) (*Clue, error) {
+	clue := &Clue{}
+	if err := s.db.GetContext(ctx, clue, `
+		SELECT definition,
+			COALESCE(example_sentence, '') AS example_sentence,
+			COALESCE(part_of_speech, '') AS part_of_speech,
+			COALESCE(pronunciation, '') AS pronunciation,
+			COALESCE(respelling, '') AS respelling,
+			COALESCE(audio_url, '') AS audio_url
+		FROM words WHERE id = $1`, wordID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The word was deleted since the game started
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get word: %w", err)
+	}
+	if clue.Respelling == "" {
+		clue.Respelling = respell.Respell(clue.Pronunciation)
+	}
+	return clue, nil
+}
+
+func newGame(g *dynamodb.SoloGame) *Game {
+	view := &Game{
+		ID:            g.ID,
+		Status:        g.Status,
+		Level:         g.Level,
+		WordID:        g.WordID,
+		Attempts:      make([]Attempt, len(g.Attempts)),
+		Score:         g.Score,
+		ChallengeDate: g.ChallengeDate,
+		StartedAt:     g.StartedAt,
+		CreatedAt:     g.CreatedAt,
+	}
+	for i, a := range g.Attempts {
+		view.Attempts[i] = Attempt{Text: a.Word, Type: a.Type, Correct: a.IsCorrect, Timestamp: a.Timestamp}
+		view.Solved = view.Solved || a.IsCorrect
+	}
+	if isDrill(g) {
+		drillProgress(view, g)
+	} else if g.Status == StatusInProgress && g.WordID != "" {
+		view.AttemptsLeft = MaxAttempts - len(g.Attempts)
+	} else if g.Status != StatusInProgress {
+		view.Word = g.Word
+	}
+	if !g.CompletedAt.IsZero() {
+		completedAt := g.CompletedAt
+		view.CompletedAt = &completedAt
+	}
+	return view
+}
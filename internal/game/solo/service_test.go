@@ -0,0 +1,333 @@
+package solo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/infrastructure/aws/dynamodb"
+)
+
+type memoryStore struct {
+	games map[string]dynamodb.SoloGame
+	stats map[string]*dynamodb.UserWordStats
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{games: map[string]dynamodb.SoloGame{}, stats: map[string]*dynamodb.UserWordStats{}}
+}
+
+func (m *memoryStore) CreateSoloGame(_ context.Context, g *dynamodb.SoloGame) error {
+	if _, ok := m.games[g.ID]; ok {
+		return dynamodb.ErrSoloGameExists
+	}
+	m.games[g.ID] = *g
+	return nil
+}
+
+func (m *memoryStore) GetSoloGame(_ context.Context, id string) (*dynamodb.SoloGame, error) {
+	g, ok := m.games[id]
+	if !ok {
+		return nil, dynamodb.ErrSoloGameNotFound
+	}
+	return &g, nil
+}
+
+func (m *memoryStore) AppendSoloAttempt(_ context.Context, id string, attempt dynamodb.Attempt, inStatus string) (*dynamodb.SoloGame, error) {
+	g, ok := m.games[id]
+	if !ok || g.Status != inStatus {
+		return nil, dynamodb.ErrSoloGameChanged
+	}
+	g.Attempts = append(g.Attempts, attempt)
+	m.games[id] = g
+	return &g, nil
+}
+
+func (m *memoryStore) FinishSoloGame(_ context.Context, id, fromStatus, status string, score int, at time.Time) (*dynamodb.SoloGame, error) {
+	g, ok := m.games[id]
+	if !ok || g.Status != fromStatus {
+		return nil, dynamodb.ErrSoloGameChanged
+	}
+	g.Status, g.Score, g.CompletedAt = status, score, at
+	m.games[id] = g
+	return &g, nil
+}
+
+func (m *memoryStore) DeleteSoloGame(_ context.Context, id string) error {
+	if _, ok := m.games[id]; !ok {
+		return dynamodb.ErrSoloGameNotFound
+	}
+	delete(m.games, id)
+	return nil
+}
+
+func (m *memoryStore) SoloGamesByUser(_ context.Context, userID string, _ time.Time, _ int) ([]dynamodb.SoloGame, error) {
+	var games []dynamodb.SoloGame
+	for _, g := range m.games {
+		if g.UserID == userID {
+			games = append(games, g)
+		}
+	}
+	return games, nil
+}
+
+func (m *memoryStore) RecordWordAttempt(_ context.Context, userID, wordID string, correct bool, at time.Time) (*dynamodb.UserWordStats, error) {
+	key := userID + "/" + wordID
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &dynamodb.UserWordStats{UserID: userID, WordID: wordID}
+		m.stats[key] = stats
+	}
+	if correct {
+		stats.CorrectAttempts++
+	} else {
+		stats.IncorrectAttempts++
+	}
+	stats.LastAttemptAt = at
+	copied := *stats
+	return &copied, nil
+}
+
+func (m *memoryStore) ScheduleWordReview(_ context.Context, userID, wordID string, at time.Time) error {
+	m.stats[userID+"/"+wordID].NextReviewAt = at
+	return nil
+}
+
+func (m *memoryStore) PurgeUser(_ context.Context, userID string) error {
+	for id, g := range m.games {
+		if g.UserID == userID {
+			delete(m.games, id)
+		}
+	}
+	for key, stats := range m.stats {
+		if stats.UserID == userID {
+			delete(m.stats, key)
+		}
+	}
+	return nil
+}
+
+type fixedWords struct {
+	word *game.Word
+}
+
+func (f fixedWords) GetRandomWord(context.Context, game.WordQuery) (*game.Word, error) {
+	return f.word, nil
+}
+
+func (f fixedWords) ValidateSpelling(_ context.Context, word, attempt string) bool {
+	return strings.EqualFold(word, strings.TrimSpace(attempt))
+}
+
+func (f fixedWords) TranscribeVoice(context.Context, []byte, string) (string, error) {
+	return "", nil
+}
+
+func newTestService() (*Service, *memoryStore) {
+	store := newMemoryStore()
+	words := fixedWords{word: &game.Word{ID: "word-1", Word: "rhythm", Definition: "a strong, regular repeated pattern"}}
+	return NewService(nil, store, words), store
+}
+
+func textAttempt(text string) *game.SpellingAttempt {
+	return &game.SpellingAttempt{Type: game.AttemptTypeText, Text: text}
+}
+
+func TestSolvingAGame(t *testing.T) {
+	s, store := newTestService()
+	ctx := context.Background()
+
+	g, err := s.Start(ctx, "user-1", 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, StatusInProgress, g.Status)
+	assert.Empty(t, g.Word, "the word isn't given away while playing")
+	assert.Equal(t, "a strong, regular repeated pattern", g.Clue.Definition)
+	assert.Equal(t, MaxAttempts, g.AttemptsLeft)
+
+	g, err = s.Attempt(ctx, "user-1", g.ID, textAttempt("rythm"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusInProgress, g.Status)
+	assert.Equal(t, MaxAttempts-1, g.AttemptsLeft)
+
+	g, err = s.Attempt(ctx, "user-1", g.ID, textAttempt("rhythm"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, g.Status)
+	assert.True(t, g.Solved)
+	assert.Equal(t, "rhythm", g.Word)
+	assert.Equal(t, pointsPerWord-missPenalty, g.Score)
+
+	_, err = s.Attempt(ctx, "user-1", g.ID, textAttempt("rhythm"))
+	assert.ErrorIs(t, err, ErrGameOver)
+
+	stats := store.stats["user-1/word-1"]
+	assert.Equal(t, 1, stats.CorrectAttempts)
+	assert.Equal(t, 1, stats.IncorrectAttempts)
+	assert.True(t, stats.NextReviewAt.After(stats.LastAttemptAt))
+}
+
+func TestRunningOutOfAttempts(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+
+	g, err := s.Start(ctx, "user-1", 4, nil)
+	require.NoError(t, err)
+	for i := 0; i < MaxAttempts; i++ {
+		g, err = s.Attempt(ctx, "user-1", g.ID, textAttempt("rhythem"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, StatusCompleted, g.Status)
+	assert.False(t, g.Solved)
+	assert.Equal(t, 0, g.Score)
+}
+
+func TestOtherPlayersGamesAreHidden(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+
+	g, err := s.Start(ctx, "user-1", 4, nil)
+	require.NoError(t, err)
+
+	_, err = s.Attempt(ctx, "user-2", g.ID, textAttempt("rhythm"))
+	assert.ErrorIs(t, err, ErrGameNotFound)
+	_, err = s.Complete(ctx, "user-2", g.ID)
+	assert.ErrorIs(t, err, ErrGameNotFound)
+	assert.ErrorIs(t, s.Delete(ctx, "user-2", g.ID), ErrGameNotFound)
+
+	g, err = s.Complete(ctx, "user-1", g.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, g.Status)
+	assert.Equal(t, 0, g.Score)
+}
+
+func TestPurge(t *testing.T) {
+	s, store := newTestService()
+	ctx := context.Background()
+
+	g, err := s.Start(ctx, "user-1", 4, nil)
+	require.NoError(t, err)
+	_, err = s.Attempt(ctx, "user-1", g.ID, textAttempt("rhythm"))
+	require.NoError(t, err)
+	other, err := s.Start(ctx, "user-2", 4, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Purge(ctx, "user-1"))
+	assert.NotContains(t, store.games, g.ID)
+	assert.Empty(t, store.stats)
+	assert.Contains(t, store.games, other.ID, "other players' games are kept")
+
+	assert.NoError(t, NewService(nil, nil, nil).Purge(ctx, "user-1"), "there's nothing to purge without a store")
+}
+
+func TestNextReview(t *testing.T) {
+	at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	assert.Equal(t, at.Add(day), nextReview(&dynamodb.UserWordStats{CorrectAttempts: 5}, false, at))
+	assert.Equal(t, at.Add(day), nextReview(&dynamodb.UserWordStats{CorrectAttempts: 1, IncorrectAttempts: 2}, true, at))
+	assert.Equal(t, at.Add(4*day), nextReview(&dynamodb.UserWordStats{CorrectAttempts: 3}, true, at))
+	assert.Equal(t, at.Add(30*day), nextReview(&dynamodb.UserWordStats{CorrectAttempts: 20}, true, at))
+}
+
+func TestDisabled(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	_, err := s.Start(context.Background(), "user-1", 1, nil)
+	assert.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestAttemptRequestValidation(t *testing.T) {
+	text := "rhythm"
+	req := AttemptRequest{Type: game.AttemptTypeText, Text: &text}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = AttemptRequest{Type: game.AttemptTypeVoice}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "voice_data")
+
+	req = AttemptRequest{Type: "morse"}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "type")
+}
+
+func TestStoresAreInterchangeable(t *testing.T) {
+	var _ SoloGameStore = (*PostgresStore)(nil)
+	var _ SoloGameStore = (*dynamodb.DynamoDBService)(nil)
+}
+
+func TestAttemptEncoding(t *testing.T) {
+	at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	attempts := []dynamodb.Attempt{
+		{Word: "rythm", Type: "text", Timestamp: at},
+		{Word: "rhythm", Type: "voice", IsCorrect: true, Timestamp: at.Add(time.Minute)},
+	}
+
+	data, err := marshalAttempts(attempts)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"word": "rythm", "type": "text", "is_correct": false, "timestamp": "2026-10-15T12:00:00Z"},
+		{"word": "rhythm", "type": "voice", "is_correct": true, "timestamp": "2026-10-15T12:01:00Z"}
+	]`, string(data))
+
+	data, err = marshalAttempts(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+}
+
+func TestDrillRounds(t *testing.T) {
+	miss, hit := dynamodb.Attempt{}, dynamodb.Attempt{IsCorrect: true}
+	attempts := []dynamodb.Attempt{hit, miss, miss, miss, miss, hit, miss}
+
+	rounds, done := drillRounds(attempts)
+	assert.Equal(t, 3, done, "a word is done once it's spelled or its tries run out")
+	require.Len(t, rounds, 4)
+	assert.Len(t, rounds[1], MaxAttempts)
+	assert.Len(t, rounds[3], 1, "the last word is still being spelled")
+
+	assert.Equal(t, pointsPerWord+pointsPerWord-missPenalty, drillScore(attempts))
+}
+
+func TestDrillProgress(t *testing.T) {
+	miss, hit := dynamodb.Attempt{}, dynamodb.Attempt{IsCorrect: true}
+	g := &dynamodb.SoloGame{
+		Status:   StatusInProgress,
+		WordID:   "word-1",
+		WordIDs:  []string{"word-1", "word-2", "word-3"},
+		Attempts: []dynamodb.Attempt{hit, miss},
+	}
+
+	view := newGame(g)
+	require.NotNil(t, view.Drill)
+	assert.Equal(t, "word-2", view.WordID)
+	assert.Equal(t, MaxAttempts-1, view.AttemptsLeft)
+	assert.Equal(t, 1, view.Drill.Current)
+	assert.Equal(t, []DrillWord{{WordID: "word-1", Solved: true, Attempts: 1}}, view.Drill.Results)
+	assert.False(t, view.Solved)
+
+	g.Status = StatusCompleted
+	view = newGame(g)
+	assert.Empty(t, view.WordID)
+	assert.Len(t, view.Drill.Results, 3, "every word is given away once the drill is over")
+	assert.Empty(t, view.Word)
+}
+
+func TestDailyPlaysAreNotDrills(t *testing.T) {
+	assert.False(t, isDrill(&dynamodb.SoloGame{WordIDs: []string{"word-1"}, ChallengeDate: "2026-10-15"}))
+	assert.False(t, isDrill(&dynamodb.SoloGame{WordID: "word-1"}))
+	assert.True(t, isDrill(&dynamodb.SoloGame{WordIDs: []string{"word-1"}}))
+}
+
+func TestDrillRequestValidation(t *testing.T) {
+	req := DrillRequest{}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, DrillWords, req.Words)
+
+	req = DrillRequest{Words: MaxDrillWords + 1}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "words")
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// StatusInterval is the least time between a player's spelling statuses.
+// Statuses sent faster are folded together, the latest winning.
+const StatusInterval = 250 * time.Millisecond
+
+var ErrInvalidSpellingStatus = errors.New("must be listening, typing, recording or idle")
+
+// SpellingStatus is what a player is doing with their turn, as their client
+// reports it, so the others can watch them at it
+type SpellingStatus string
+
+const (
+	SpellingStatusListening SpellingStatus = "listening"
+	SpellingStatusTyping    SpellingStatus = "typing"
+	SpellingStatusRecording SpellingStatus = "recording"
+	SpellingStatusIdle      SpellingStatus = "idle"
+)
+
+func (s SpellingStatus) valid() bool {
+	switch s {
+	case SpellingStatusListening, SpellingStatusTyping, SpellingStatusRecording, SpellingStatusIdle:
+		return true
+	}
+	return false
+}
+
+// SetSpellingStatus passes playerID's status on to everyone following the
+// game while a word is up and it's their turn, or their team's. Statuses
+// are checked against the game in memory only, since clients send them
+// often.
+func (s *gameService) SetSpellingStatus(gameID, playerID string, status SpellingStatus) error {
+	if !status.valid() {
+		return ErrInvalidSpellingStatus
+	}
+
+	s.mu.RLock()
+	engine := s.activeGames[gameID]
+	var err error
+	switch {
+	case engine == nil:
+		err = ErrGameNotFound
+	case engine.PausedAt != nil:
+		err = ErrGamePaused
+	case engine.CurrentWord == nil || engine.Intermission != nil:
+		err = ErrTurnNotActive
+	case !engine.IsPlayerTurn(engine.relayFor(playerID)):
+		err = ErrNotPlayerTurn
+	case len(engine.TurnOrder) > 0 && !slices.Contains(engine.TurnOrder, engine.relayFor(playerID)):
+		err = ErrPlayerNotFound
+	}
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	s.statuses.set(gameID, playerID, status)
+	return nil
+}
+
+// statusThrottle sends each player's statuses at most once an interval.
+// One that comes in sooner is held until the interval is up, replacing any
+// already held, so the last status a player reports is always sent.
+type statusThrottle struct {
+	events   *eventLog
+	interval time.Duration
+
+	mu      sync.Mutex
+	players map[statusKey]*heldStatus
+}
+
+type statusKey struct {
+	gameID, playerID string
+}
+
+// heldStatus is a player's status throttling. timer runs until the
+// interval since their last status sent is up.
+type heldStatus struct {
+	sent  SpellingStatus
+	held  SpellingStatus
+	timer *time.Timer
+}
+
+func newStatusThrottle(events *eventLog, interval time.Duration) *statusThrottle {
+	return &statusThrottle{
+		events:   events,
+		interval: interval,
+		players:  make(map[statusKey]*heldStatus),
+	}
+}
+
+func (t *statusThrottle) set(gameID, playerID string, status SpellingStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := statusKey{gameID, playerID}
+	p := t.players[key]
+	if p == nil {
+		p = &heldStatus{}
+		t.players[key] = p
+	}
+	if p.timer != nil {
+		p.held = status
+		return
+	}
+	t.send(key, p, status)
+}
+
+// send broadcasts status and holds the player's next one back for the
+// interval. t.mu is held.
+func (t *statusThrottle) send(key statusKey, p *heldStatus, status SpellingStatus) {
+	p.sent, p.held = status, ""
+	t.events.broadcast(GameEvent{
+		Type:      EventTypeSpellingStatus,
+		GameID:    key.gameID,
+		PlayerID:  &key.playerID,
+		Timestamp: time.Now(),
+		Payload:   map[string]any{"player_id": key.playerID, "status": status},
+	})
+
+	p.timer = time.AfterFunc(t.interval, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		p.timer = nil
+		if p.held != "" && p.held != p.sent {
+			t.send(key, p, p.held)
+			return
+		}
+		// Nothing to send, so the player is forgotten until their next one
+		delete(t.players, key)
+	})
+}
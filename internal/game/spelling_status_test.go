@@ -0,0 +1,78 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextStatus(t *testing.T, sub *Subscription) SpellingStatus {
+	t.Helper()
+	select {
+	case event := <-sub.Events:
+		require.Equal(t, EventTypeSpellingStatus, event.Type)
+		assert.Zero(t, event.Seq, "statuses aren't numbered")
+		return event.Payload["status"].(SpellingStatus)
+	case <-time.After(time.Second):
+		t.Fatal("no status was sent")
+		return ""
+	}
+}
+
+func TestStatusesAreThrottled(t *testing.T) {
+	log := newEventLog()
+	sub := log.subscribe("game-1", "bo", 0)
+	defer sub.Cancel()
+	throttle := newStatusThrottle(log, 20*time.Millisecond)
+
+	throttle.set("game-1", "ada", SpellingStatusListening)
+	throttle.set("game-1", "ada", SpellingStatusTyping)
+	throttle.set("game-1", "ada", SpellingStatusRecording)
+
+	assert.Equal(t, SpellingStatusListening, nextStatus(t, sub))
+	assert.Empty(t, sub.Events, "statuses sent within the interval are held")
+	assert.Equal(t, SpellingStatusRecording, nextStatus(t, sub), "the latest held status is sent")
+
+	// Once the intervals are up the player is forgotten
+	require.Eventually(t, func() bool {
+		throttle.mu.Lock()
+		defer throttle.mu.Unlock()
+		return len(throttle.players) == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, sub.Events)
+	assert.Zero(t, log.games["game-1"].seq, "statuses aren't kept for resuming")
+}
+
+func TestHeldStatusMatchingTheLastIsDropped(t *testing.T) {
+	log := newEventLog()
+	sub := log.subscribe("game-1", "bo", 0)
+	defer sub.Cancel()
+	throttle := newStatusThrottle(log, 20*time.Millisecond)
+
+	throttle.set("game-1", "ada", SpellingStatusTyping)
+	throttle.set("game-1", "ada", SpellingStatusIdle)
+	throttle.set("game-1", "ada", SpellingStatusTyping)
+	assert.Equal(t, SpellingStatusTyping, nextStatus(t, sub))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Empty(t, sub.Events)
+}
+
+func TestStatusesNeedAWordInPlay(t *testing.T) {
+	s := &gameService{activeGames: map[string]*GameEngine{}, events: newEventLog()}
+	s.statuses = newStatusThrottle(s.events, time.Millisecond)
+
+	assert.ErrorIs(t, s.SetSpellingStatus("game-1", "ada", "dancing"), ErrInvalidSpellingStatus)
+	assert.ErrorIs(t, s.SetSpellingStatus("game-1", "ada", SpellingStatusTyping), ErrGameNotFound)
+
+	engine := NewGameEngine("game-1", nil)
+	engine.TurnOrder = []string{"ada", "bo"}
+	s.activeGames["game-1"] = engine
+	assert.ErrorIs(t, s.SetSpellingStatus("game-1", "ada", SpellingStatusTyping), ErrTurnNotActive)
+
+	engine.CurrentWord = &Word{ID: "word-1", Word: "rhythm"}
+	assert.ErrorIs(t, s.SetSpellingStatus("game-1", "bo", SpellingStatusTyping), ErrNotPlayerTurn)
+	assert.NoError(t, s.SetSpellingStatus("game-1", "ada", SpellingStatusTyping))
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// SpellingPolicy decides which of a word's accepted spellings count in a
+// game
+type SpellingPolicy string
+
+const (
+	// SpellingLenient accepts every spelling of a word
+	SpellingLenient SpellingPolicy = "lenient"
+	// SpellingStrictUS accepts American spellings and those right in both
+	// varieties, so "theater" but not "theatre"
+	SpellingStrictUS SpellingPolicy = "strict_us"
+	// SpellingStrictUK accepts British spellings and those right in both
+	// varieties
+	SpellingStrictUK SpellingPolicy = "strict_uk"
+)
+
+// Varieties of English a spelling can belong to
+const (
+	VarietyUS = "us"
+	VarietyUK = "uk"
+)
+
+// Valid reports whether p is a known policy. The empty policy is valid and
+// means SpellingLenient.
+func (p SpellingPolicy) Valid() bool {
+	switch p {
+	case "", SpellingLenient, SpellingStrictUS, SpellingStrictUK:
+		return true
+	}
+	return false
+}
+
+// allows reports whether a spelling of variety counts under p
+func (p SpellingPolicy) allows(variety string) bool {
+	switch p {
+	case SpellingStrictUS:
+		return variety == "" || variety == VarietyUS
+	case SpellingStrictUK:
+		return variety == "" || variety == VarietyUK
+	default:
+		return true
+	}
+}
+
+// AcceptedSpelling is another way a word may be spelled, like "judgement"
+// for "judgment". Variety is us or uk when the spelling belongs to one
+// variety of English, and empty when it's right in both.
+type AcceptedSpelling struct {
+	Spelling string `json:"spelling"`
+	Variety  string `json:"variety,omitempty"`
+}
+
+// AcceptedSpellings are stored as a JSON array
+type AcceptedSpellings []AcceptedSpelling
+
+// Value implements the driver.Valuer interface for AcceptedSpellings. The
+// JSON is sent as text, which lib/pq passes to a jsonb column as it is.
+func (a AcceptedSpellings) Value() (interface{}, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface for AcceptedSpellings
+func (a *AcceptedSpellings) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, a)
+}
+
+// Spellings returns the spellings of w that policy accepts. The word itself
+// is one unless it's also listed among its accepted spellings under a
+// variety policy rules out, which is how the variety of the word itself is
+// given. Should policy rule out every spelling, the word itself stands.
+func (w *Word) Spellings(policy SpellingPolicy) []string {
+	headword := true
+	var spellings []string
+	for _, accepted := range w.AcceptedSpellings {
+		if strings.EqualFold(accepted.Spelling, w.Word) {
+			headword = policy.allows(accepted.Variety)
+			continue
+		}
+		if policy.allows(accepted.Variety) {
+			spellings = append(spellings, accepted.Spelling)
+		}
+	}
+	if headword || len(spellings) == 0 {
+		spellings = append([]string{w.Word}, spellings...)
+	}
+	return spellings
+}
+
+// Accepts reports whether attempt is a spelling of w that policy accepts
+func (w *Word) Accepts(attempt string, policy SpellingPolicy) bool {
+	attempt = strings.TrimSpace(attempt)
+	for _, spelling := range w.Spellings(policy) {
+		if strings.EqualFold(attempt, strings.TrimSpace(spelling)) {
+			return true
+		}
+	}
+	return false
+}
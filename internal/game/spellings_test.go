@@ -0,0 +1,69 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordSpellings(t *testing.T) {
+	theater := &Word{Word: "theater", AcceptedSpellings: AcceptedSpellings{
+		{Spelling: "theater", Variety: VarietyUS},
+		{Spelling: "theatre", Variety: VarietyUK},
+	}}
+	assert.Equal(t, []string{"theater", "theatre"}, theater.Spellings(SpellingLenient))
+	assert.Equal(t, []string{"theater", "theatre"}, theater.Spellings(""))
+	assert.Equal(t, []string{"theater"}, theater.Spellings(SpellingStrictUS))
+	assert.Equal(t, []string{"theatre"}, theater.Spellings(SpellingStrictUK), "listing the word itself gives its variety")
+
+	judgment := &Word{Word: "judgment", AcceptedSpellings: AcceptedSpellings{{Spelling: "judgement"}}}
+	assert.True(t, judgment.Accepts("Judgement ", SpellingStrictUS), "spellings right in both varieties always count")
+	assert.True(t, judgment.Accepts("judgment", SpellingStrictUK))
+	assert.False(t, judgment.Accepts("jugement", SpellingLenient))
+
+	colour := &Word{Word: "colour", AcceptedSpellings: AcceptedSpellings{{Spelling: "colour", Variety: VarietyUK}}}
+	assert.Equal(t, []string{"colour"}, colour.Spellings(SpellingStrictUS), "the word itself stands when nothing else would")
+
+	assert.Equal(t, []string{"bee"}, (&Word{Word: "bee"}).Spellings(SpellingStrictUK))
+}
+
+func TestValidateAttemptAcceptsVariants(t *testing.T) {
+	now := time.Now()
+	engine := NewGameEngine("game-1", nil)
+	engine.CurrentWord = &Word{Word: "theater", AcceptedSpellings: AcceptedSpellings{{Spelling: "theatre", Variety: VarietyUK}}}
+	engine.TurnStartedAt = &now
+
+	correct, err := engine.ValidateAttempt("theatre")
+	require.NoError(t, err)
+	assert.True(t, correct)
+
+	engine.Spellings = SpellingStrictUS
+	correct, err = engine.ValidateAttempt("theatre")
+	require.NoError(t, err)
+	assert.False(t, correct)
+
+	// Live spelling stays on track along any accepted spelling
+	engine.Spellings = SpellingLenient
+	for _, letter := range "theatr" {
+		onTrack, err := engine.AddLetter("ada", letter)
+		require.NoError(t, err)
+		assert.True(t, onTrack)
+	}
+}
+
+func TestAcceptedSpellingsColumn(t *testing.T) {
+	var none AcceptedSpellings
+	value, err := none.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "[]", value)
+
+	spellings := AcceptedSpellings{{Spelling: "theatre", Variety: VarietyUK}}
+	value, err = spellings.Value()
+	require.NoError(t, err)
+
+	var scanned AcceptedSpellings
+	require.NoError(t, scanned.Scan([]byte(value.(string))))
+	assert.Equal(t, spellings, scanned)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+
+	"big-spella-go/internal/game/modes"
 )
 
 // GameStore defines the interface for game persistence operations
@@ -34,6 +36,12 @@ type GameFilter struct {
 	Type      *GameType
 	HostID    *uuid.UUID
 	PlayerID  *uuid.UUID
+	Mode      *modes.GameMode
+	WordLevel *int
+	IsRanked  *bool
+	// IncludePrivate lists private games too. Public listings leave it
+	// unset so invite-only games stay hidden.
+	IncludePrivate bool
 	Limit     int
 	Offset    int
 }
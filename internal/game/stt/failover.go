@@ -0,0 +1,214 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"big-spella-go/internal/metrics"
+)
+
+// DownAfter is how many failures in a row mark a provider down
+const DownAfter = 3
+
+var (
+	transcriptionsTotal = metrics.NewCounterVec("spella_transcriptions_total",
+		"Transcription calls, by provider and outcome: ok or error.", "provider", "outcome")
+	transcriptionDuration = metrics.NewHistogramVec("spella_transcription_duration_seconds",
+		"Time spent transcribing voice attempts, by provider.", nil, "provider")
+)
+
+// Checker is implemented by transcribers that can tell whether they're up
+// without transcribing anything
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Provider is a transcriber a Failover can fall back to, named for its
+// health and metrics
+type Provider struct {
+	Name        string
+	Transcriber Transcriber
+}
+
+// ProviderHealth is a snapshot of how a provider has been behaving
+type ProviderHealth struct {
+	Name                string        `json:"name"`
+	Down                bool          `json:"down"`
+	Successes           int64         `json:"successes"`
+	Failures            int64         `json:"failures"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastError           string        `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time    `json:"last_failure_at,omitempty"`
+	LastLatency         time.Duration `json:"last_latency"`
+}
+
+// Failover transcribes with the first of its providers that succeeds. A
+// provider is down after DownAfter failures in a row, or a failed health
+// check, and is then tried only once those that are up have failed. It's
+// back up as soon as a transcription or check succeeds.
+type Failover struct {
+	providers []Provider
+
+	mu     sync.Mutex
+	health map[string]*ProviderHealth
+}
+
+func NewFailover(providers ...Provider) *Failover {
+	f := &Failover{
+		providers: providers,
+		health:    make(map[string]*ProviderHealth, len(providers)),
+	}
+	for _, p := range providers {
+		f.health[p.Name] = &ProviderHealth{Name: p.Name}
+	}
+	return f
+}
+
+func (f *Failover) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	t, err := f.TranscribeWithConfidence(ctx, voiceData, language)
+	return t.Text, err
+}
+
+// TranscribeWithConfidence transcribes with each provider in turn until one
+// succeeds, noting which did on the transcription
+func (f *Failover) TranscribeWithConfidence(ctx context.Context, voiceData []byte, language string) (Transcription, error) {
+	var errs []error
+	for _, p := range f.ordered() {
+		start := time.Now()
+		t, err := transcribe(ctx, p.Transcriber, voiceData, language)
+		if ctx.Err() != nil {
+			// The caller gave up, which says nothing about the provider
+			return Transcription{}, ctx.Err()
+		}
+		f.record(p.Name, time.Since(start), err)
+
+		if err == nil {
+			t.Provider = p.Name
+			return t, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+	return Transcription{}, fmt.Errorf("all transcription providers failed: %w", errors.Join(errs...))
+}
+
+// transcribe transcribes with t, treating transcribers that can't report a
+// confidence as certain
+func transcribe(ctx context.Context, t Transcriber, data []byte, language string) (Transcription, error) {
+	if ct, ok := t.(ConfidenceTranscriber); ok {
+		return ct.TranscribeWithConfidence(ctx, data, language)
+	}
+	text, err := t.TranscribeVoice(ctx, data, language)
+	return Transcription{Text: text, Confidence: 1}, err
+}
+
+// ordered is the providers that are up, then those that are down, each in
+// the order given
+func (f *Failover) ordered() []Provider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	up := make([]Provider, 0, len(f.providers))
+	var down []Provider
+	for _, p := range f.providers {
+		if f.health[p.Name].Down {
+			down = append(down, p)
+		} else {
+			up = append(up, p)
+		}
+	}
+	return append(up, down...)
+}
+
+func (f *Failover) record(name string, latency time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	transcriptionsTotal.With(name, outcome).Inc()
+	transcriptionDuration.With(name).Observe(latency.Seconds())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h := f.health[name]
+	h.LastLatency = latency
+	if err == nil {
+		h.Successes++
+		h.ConsecutiveFailures = 0
+		h.Down = false
+		return
+	}
+	h.Failures++
+	h.fail(err)
+	if h.ConsecutiveFailures >= DownAfter {
+		h.Down = true
+	}
+}
+
+func (h *ProviderHealth) fail(err error) {
+	now := time.Now()
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	h.LastFailureAt = &now
+}
+
+// Check runs the health check of each provider that has one. A provider
+// that fails it is down until it passes one or transcribes successfully.
+func (f *Failover) Check(ctx context.Context) error {
+	var errs []error
+	for _, p := range f.providers {
+		checker, ok := p.Transcriber.(Checker)
+		if !ok {
+			continue
+		}
+		err := checker.Check(ctx)
+
+		f.mu.Lock()
+		h := f.health[p.Name]
+		if err == nil {
+			h.ConsecutiveFailures = 0
+			h.Down = false
+		} else {
+			h.fail(err)
+			h.Down = true
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		}
+		f.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Health returns a snapshot for every provider in fallback order
+func (f *Failover) Health() []ProviderHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]ProviderHealth, 0, len(f.providers))
+	for _, p := range f.providers {
+		out = append(out, *f.health[p.Name])
+	}
+	return out
+}
+
+// Run checks the providers every interval until ctx is done, reporting the
+// ones that fail to onError
+func (f *Failover) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			if err := f.Check(checkCtx); err != nil && onError != nil {
+				onError(err)
+			}
+			cancel()
+		}
+	}
+}
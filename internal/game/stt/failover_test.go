@@ -0,0 +1,84 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider hears "bee" unless it's failing
+type fakeProvider struct {
+	failing  bool
+	calls    int
+	checkErr error
+}
+
+func (p *fakeProvider) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	p.calls++
+	if p.failing {
+		return "", errors.New("outage")
+	}
+	return "bee", nil
+}
+
+func (p *fakeProvider) Check(ctx context.Context) error {
+	return p.checkErr
+}
+
+func TestFailoverFallsBack(t *testing.T) {
+	openai, local := &fakeProvider{failing: true}, &fakeProvider{}
+	f := NewFailover(Provider{"openai", openai}, Provider{"whisper-server", local})
+
+	for i := 0; i < DownAfter; i++ {
+		tr, err := f.TranscribeWithConfidence(context.Background(), []byte("audio"), "en")
+		require.NoError(t, err)
+		assert.Equal(t, Transcription{Text: "bee", Confidence: 1, Provider: "whisper-server"}, tr)
+	}
+	health := f.Health()
+	assert.True(t, health[0].Down)
+	assert.Equal(t, int64(DownAfter), health[0].Failures)
+	assert.Equal(t, "outage", health[0].LastError)
+	assert.Equal(t, int64(DownAfter), health[1].Successes)
+
+	// A provider that's down is tried last
+	_, err := f.TranscribeVoice(context.Background(), []byte("audio"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, DownAfter, openai.calls)
+
+	// and is back up once it passes a health check
+	require.NoError(t, f.Check(context.Background()))
+	assert.False(t, f.Health()[0].Down)
+}
+
+func TestFailoverFailsWhenEveryProviderDoes(t *testing.T) {
+	f := NewFailover(Provider{"openai", &fakeProvider{failing: true}}, Provider{"whisper-server", &fakeProvider{failing: true}})
+	_, err := f.TranscribeVoice(context.Background(), []byte("audio"), "en")
+	assert.ErrorContains(t, err, "openai: outage")
+	assert.ErrorContains(t, err, "whisper-server: outage")
+}
+
+func TestFailoverCheckMarksProvidersDown(t *testing.T) {
+	local := &fakeProvider{checkErr: errors.New("connection refused")}
+	f := NewFailover(Provider{"whisper-server", local}, Provider{"openai", &fakeProvider{}})
+
+	assert.ErrorContains(t, f.Check(context.Background()), "whisper-server: connection refused")
+	assert.True(t, f.Health()[0].Down)
+
+	tr, err := f.TranscribeWithConfidence(context.Background(), []byte("audio"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, "openai", tr.Provider)
+	assert.Zero(t, local.calls)
+}
+
+func TestFailoverDoesNotBlameProvidersForCancelledCalls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := NewFailover(Provider{"openai", &fakeProvider{failing: true}})
+
+	_, err := f.TranscribeVoice(ctx, []byte("audio"), "en")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, f.Health()[0].Failures)
+}
@@ -0,0 +1,163 @@
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// letterNames are the ways recognisers write the names of letters spoken
+// on their own, as when a player spells a word aloud
+var letterNames = map[string]string{
+	"ay": "a", "eh": "a",
+	"be": "b", "bee": "b",
+	"see": "c", "sea": "c", "cee": "c",
+	"dee": "d",
+	"ee": "e",
+	"ef": "f", "eff": "f",
+	"gee": "g", "jee": "g",
+	"aitch": "h", "haitch": "h",
+	"eye": "i", "aye": "i",
+	"jay": "j",
+	"kay": "k", "cay": "k",
+	"el": "l", "ell": "l",
+	"em": "m",
+	"en": "n",
+	"oh": "o", "owe": "o",
+	"pee": "p", "pea": "p",
+	"cue": "q", "queue": "q", "que": "q",
+	"ar": "r", "are": "r",
+	"es": "s", "ess": "s",
+	"tee": "t", "tea": "t",
+	"you": "u", "yu": "u", "ew": "u",
+	"vee": "v",
+	"double u": "w", "double you": "w", "doubleu": "w", "dubya": "w",
+	"ex": "x",
+	"why": "y", "wye": "y",
+	"zee": "z", "zed": "z",
+}
+
+// Letters turns transcriptions of words spelled aloud, like "c, a, t" or
+// "see-ay-tee", into the letters spelled, "cat". A transcription is
+// rewritten only when it's two or more parts that are all letters, letter
+// names or "double" and a letter, so a word that was said rather than
+// spelled is left as it is.
+type Letters struct {
+	names map[string]string
+	// longest is the most words in any name
+	longest int
+}
+
+// NewLetters normalizes with the built-in letter names and custom, which
+// maps further names, such as those of another language, to the letters
+// they stand for. custom's entries win over the built-in ones.
+func NewLetters(custom map[string]string) *Letters {
+	l := &Letters{names: make(map[string]string, len(letterNames)+len(custom)), longest: 1}
+	for _, names := range []map[string]string{letterNames, custom} {
+		for name, letters := range names {
+			key := strings.Join(splitLetters(name), " ")
+			l.names[key] = strings.ToLower(letters)
+			l.longest = max(l.longest, strings.Count(key, " ")+1)
+		}
+	}
+	return l
+}
+
+// LoadLetterNames reads a custom letter name table for NewLetters from a
+// JSON object file, such as {"aitch": "h", "double l": "ll"}
+func LoadLetterNames(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse letter names: %w", err)
+	}
+	for name, letters := range names {
+		if len(splitLetters(name)) == 0 {
+			return nil, fmt.Errorf("letter name %q has no words", name)
+		}
+		if letters == "" || strings.IndexFunc(letters, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+			return nil, fmt.Errorf("letter name %q must stand for one or more letters, not %q", name, letters)
+		}
+	}
+	return names, nil
+}
+
+// splitLetters splits text at anything that can't be part of a word, such
+// as the spaces, commas and hyphens recognisers put between letters
+func splitLetters(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+}
+
+// Normalize returns the letters spelled in text, or text unchanged when it
+// isn't a word spelled out
+func (l *Letters) Normalize(text string) string {
+	parts := splitLetters(text)
+	if len(parts) < 2 {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(parts); {
+		letters, n := l.letters(parts[i:])
+		if n == 0 {
+			return text
+		}
+		b.WriteString(letters)
+		i += n
+	}
+	return b.String()
+}
+
+// letters returns the letters at the start of parts and how many parts
+// they took up, or 0 parts when parts doesn't start with a letter
+func (l *Letters) letters(parts []string) (string, int) {
+	// The longest name wins, so "double u" is a w rather than two u's
+	for n := min(l.longest, len(parts)); n > 0; n-- {
+		if letters, ok := l.names[strings.Join(parts[:n], " ")]; ok {
+			return letters, n
+		}
+	}
+	if parts[0] == "double" && len(parts) > 1 {
+		if letter, n := l.letters(parts[1:]); n > 0 && len([]rune(letter)) == 1 {
+			return letter + letter, n + 1
+		}
+	}
+	if len([]rune(parts[0])) == 1 {
+		return parts[0], 1
+	}
+	return "", 0
+}
+
+// SpelledOut has t's transcriptions normalized by letters
+func SpelledOut(t Transcriber, letters *Letters) *SpelledOutTranscriber {
+	return &SpelledOutTranscriber{transcriber: t, letters: letters}
+}
+
+// SpelledOutTranscriber is a Transcriber whose transcriptions of words
+// spelled aloud are normalized to the letters spelled
+type SpelledOutTranscriber struct {
+	transcriber Transcriber
+	letters     *Letters
+}
+
+func (s *SpelledOutTranscriber) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	t, err := s.TranscribeWithConfidence(ctx, voiceData, language)
+	return t.Text, err
+}
+
+func (s *SpelledOutTranscriber) TranscribeWithConfidence(ctx context.Context, voiceData []byte, language string) (Transcription, error) {
+	t, err := transcribe(ctx, s.transcriber, voiceData, language)
+	if err != nil {
+		return Transcription{}, err
+	}
+	t.Text = s.letters.Normalize(t.Text)
+	return t, nil
+}
@@ -0,0 +1,84 @@
+package stt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLettersNormalize(t *testing.T) {
+	letters := NewLetters(nil)
+	for text, want := range map[string]string{
+		"c, a, t":                "cat",
+		"c a t":                  "cat",
+		"c-a-t":                  "cat",
+		"C. A. T.":               "cat",
+		"see-ay-tee":             "cat",
+		"see ay tee":             "cat",
+		"bee, oh, oh, kay":       "book",
+		"b double o k":           "book",
+		"double u, oh, are, dee": "word",
+		"double you eye en":      "win",
+		"kay, en, oh, double u":  "know",
+		"why, ee, es":            "yes",
+		"zed ee are oh":          "zero",
+		// Words said rather than spelled are left alone
+		"cat":            "cat",
+		"bee":            "bee",
+		"a cat":          "a cat",
+		"bumble bee":     "bumble bee",
+		"double trouble": "double trouble",
+		"":               "",
+	} {
+		assert.Equal(t, want, letters.Normalize(text), text)
+	}
+}
+
+func TestLettersCustomNames(t *testing.T) {
+	letters := NewLetters(map[string]string{
+		"ha":       "h",
+		"double l": "LL",
+		// Overrides the built-in reading
+		"tea": "tea",
+	})
+
+	assert.Equal(t, "hello", letters.Normalize("ha, ee, double l, oh"))
+	assert.Equal(t, "teaa", letters.Normalize("tea ay"))
+	assert.Equal(t, "cat", letters.Normalize("see ay tee"), "built-in names still apply")
+}
+
+func TestLoadLetterNames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "letters.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"ha": "h", "double l": "ll"}`), 0o600))
+	names, err := LoadLetterNames(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ha": "h", "double l": "ll"}, names)
+
+	for _, bad := range []string{`{"ha": ""}`, `{"ha": "h1"}`, `{"--": "h"}`, `["h"]`} {
+		require.NoError(t, os.WriteFile(path, []byte(bad), 0o600))
+		_, err := LoadLetterNames(path)
+		assert.Error(t, err, bad)
+	}
+}
+
+// heard transcribes everything as itself
+type heard string
+
+func (h heard) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	return string(h), nil
+}
+
+func TestSpelledOut(t *testing.T) {
+	tr, err := SpelledOut(heard("bee, ee, ee"), NewLetters(nil)).TranscribeWithConfidence(context.Background(), []byte("audio"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, Transcription{Text: "bee", Confidence: 1}, tr)
+
+	_, err = SpelledOut(&fakeProvider{failing: true}, NewLetters(nil)).TranscribeVoice(context.Background(), []byte("audio"), "en")
+	assert.Error(t, err)
+}
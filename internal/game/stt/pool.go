@@ -0,0 +1,220 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultWorkers    = 8
+	DefaultQueueDepth = 32
+
+	// DefaultRetryAfter is the back-off clients are told to wait after a
+	// shed request
+	DefaultRetryAfter = 5 * time.Second
+)
+
+// ErrOverloaded is returned when the queue for a priority is full and the
+// request was shed rather than queued
+var ErrOverloaded = errors.New("transcription capacity exhausted")
+
+// Transcriber turns audio recorded in language, an ISO 639-1 code, into
+// text
+type Transcriber interface {
+	TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error)
+}
+
+// Transcription is recognised text along with how sure the recogniser was
+// of it, from 0 to 1, and the provider that recognised it when a Failover
+// chose one
+type Transcription struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+	Provider   string  `json:"provider,omitempty"`
+}
+
+// ConfidenceTranscriber is implemented by transcribers that can report a
+// confidence. Results from other transcribers are treated as certain.
+type ConfidenceTranscriber interface {
+	TranscribeWithConfidence(ctx context.Context, voiceData []byte, language string) (Transcription, error)
+}
+
+// Priority decides which queue a request waits in. High priority work is
+// always picked up first and has its own queue so casual traffic can't
+// crowd it out.
+type Priority int
+
+const (
+	PriorityCasual Priority = iota
+	PriorityTournament
+)
+
+type job struct {
+	ctx      context.Context
+	data     []byte
+	language string
+	result   chan result
+}
+
+type result struct {
+	transcription Transcription
+	err           error
+}
+
+// Stats is a snapshot of pool activity
+type Stats struct {
+	Queued      map[Priority]int   `json:"queued"`
+	Processed   int64              `json:"processed"`
+	Failed      int64              `json:"failed"`
+	Shed        map[Priority]int64 `json:"shed"`
+	ActiveCalls int64              `json:"active_calls"`
+}
+
+// Pool runs transcriptions on a fixed number of workers with bounded queues
+type Pool struct {
+	transcriber Transcriber
+	queues      map[Priority]chan *job
+
+	processed atomic.Int64
+	failed    atomic.Int64
+	active    atomic.Int64
+	shed      map[Priority]*atomic.Int64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewPool(transcriber Transcriber, workers, queueDepth int) *Pool {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if queueDepth <= 0 {
+		queueDepth = DefaultQueueDepth
+	}
+
+	p := &Pool{
+		transcriber: transcriber,
+		queues: map[Priority]chan *job{
+			PriorityCasual:     make(chan *job, queueDepth),
+			PriorityTournament: make(chan *job, queueDepth),
+		},
+		shed: map[Priority]*atomic.Int64{
+			PriorityCasual:     new(atomic.Int64),
+			PriorityTournament: new(atomic.Int64),
+		},
+		stop: make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+// Transcribe queues voiceData, spoken in language, and waits for the
+// result. It returns ErrOverloaded immediately when the queue for priority
+// is full.
+func (p *Pool) Transcribe(ctx context.Context, voiceData []byte, language string, priority Priority) (string, error) {
+	t, err := p.TranscribeWithConfidence(ctx, voiceData, language, priority)
+	return t.Text, err
+}
+
+// TranscribeWithConfidence is Transcribe, also returning the confidence
+func (p *Pool) TranscribeWithConfidence(ctx context.Context, voiceData []byte, language string, priority Priority) (Transcription, error) {
+	queue, ok := p.queues[priority]
+	if !ok {
+		queue = p.queues[PriorityCasual]
+		priority = PriorityCasual
+	}
+
+	j := &job{
+		ctx:      ctx,
+		data:     voiceData,
+		language: language,
+		result:   make(chan result, 1),
+	}
+
+	select {
+	case queue <- j:
+	default:
+		p.shed[priority].Add(1)
+		return Transcription{}, ErrOverloaded
+	}
+
+	select {
+	case res := <-j.result:
+		return res.transcription, res.err
+	case <-ctx.Done():
+		return Transcription{}, ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	high := p.queues[PriorityTournament]
+	low := p.queues[PriorityCasual]
+
+	for {
+		var j *job
+		select {
+		case j = <-high:
+		default:
+			select {
+			case j = <-high:
+			case j = <-low:
+			case <-p.stop:
+				return
+			}
+		}
+		p.run(j)
+	}
+}
+
+func (p *Pool) run(j *job) {
+	if err := j.ctx.Err(); err != nil {
+		j.result <- result{err: err}
+		return
+	}
+
+	p.active.Add(1)
+	t, err := transcribe(j.ctx, p.transcriber, j.data, j.language)
+	p.active.Add(-1)
+
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.processed.Add(1)
+	}
+	j.result <- result{transcription: t, err: err}
+}
+
+// Stats returns current queue depths and counters
+func (p *Pool) Stats() Stats {
+	stats := Stats{
+		Queued:      make(map[Priority]int, len(p.queues)),
+		Shed:        make(map[Priority]int64, len(p.shed)),
+		Processed:   p.processed.Load(),
+		Failed:      p.failed.Load(),
+		ActiveCalls: p.active.Load(),
+	}
+	for priority, queue := range p.queues {
+		stats.Queued[priority] = len(queue)
+	}
+	for priority, counter := range p.shed {
+		stats.Shed[priority] = counter.Load()
+	}
+	return stats
+}
+
+// Stop terminates the workers once they finish their current job. Queued
+// requests are left to time out with their contexts.
+func (p *Pool) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
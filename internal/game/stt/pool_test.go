@@ -0,0 +1,97 @@
+package stt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type blockingTranscriber struct {
+	release chan struct{}
+	started chan struct{}
+}
+
+func (b *blockingTranscriber) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return string(voiceData), nil
+}
+
+func TestPoolShedsWhenQueueFull(t *testing.T) {
+	tr := &blockingTranscriber{release: make(chan struct{}), started: make(chan struct{}, 10)}
+	pool := NewPool(tr, 1, 1)
+	defer pool.Stop()
+
+	ctx := context.Background()
+	results := make(chan string, 2)
+
+	// First request occupies the only worker
+	go func() {
+		text, _ := pool.Transcribe(ctx, []byte("cat"), "en", PriorityCasual)
+		results <- text
+	}()
+	<-tr.started
+
+	// Second request fills the casual queue
+	go func() {
+		text, _ := pool.Transcribe(ctx, []byte("dog"), "en", PriorityCasual)
+		results <- text
+	}()
+	require.Eventually(t, func() bool {
+		return pool.Stats().Queued[PriorityCasual] == 1
+	}, time.Second, time.Millisecond)
+
+	// Third casual request is shed, tournament still has room
+	_, err := pool.Transcribe(ctx, []byte("eel"), "en", PriorityCasual)
+	assert.ErrorIs(t, err, ErrOverloaded)
+	assert.Equal(t, int64(1), pool.Stats().Shed[PriorityCasual])
+	assert.Equal(t, int64(0), pool.Stats().Shed[PriorityTournament])
+
+	close(tr.release)
+	assert.ElementsMatch(t, []string{"cat", "dog"}, []string{<-results, <-results})
+}
+
+func TestPoolPrefersTournamentWork(t *testing.T) {
+	tr := &blockingTranscriber{release: make(chan struct{}), started: make(chan struct{}, 10)}
+	pool := NewPool(tr, 1, 4)
+	defer pool.Stop()
+
+	ctx := context.Background()
+	order := make(chan string, 3)
+
+	go func() {
+		text, _ := pool.Transcribe(ctx, []byte("first"), "en", PriorityCasual)
+		order <- text
+	}()
+	<-tr.started
+
+	go func() {
+		text, _ := pool.Transcribe(ctx, []byte("casual"), "en", PriorityCasual)
+		order <- text
+	}()
+	require.Eventually(t, func() bool {
+		return pool.Stats().Queued[PriorityCasual] == 1
+	}, time.Second, time.Millisecond)
+
+	go func() {
+		text, _ := pool.Transcribe(ctx, []byte("tournament"), "en", PriorityTournament)
+		order <- text
+	}()
+	require.Eventually(t, func() bool {
+		return pool.Stats().Queued[PriorityTournament] == 1
+	}, time.Second, time.Millisecond)
+
+	tr.release <- struct{}{}
+	assert.Equal(t, "first", <-order)
+
+	<-tr.started
+	tr.release <- struct{}{}
+	assert.Equal(t, "tournament", <-order)
+
+	<-tr.started
+	tr.release <- struct{}{}
+	assert.Equal(t, "casual", <-order)
+}
@@ -0,0 +1,120 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// WhisperServer transcribes with a self-hosted whisper.cpp server, so voice
+// attempts keep working through OpenAI outages. The server is run with the
+// same model on the same network as the API, as in
+//
+//	whisper-server -m models/ggml-base.en.bin --host 0.0.0.0 --port 8178
+type WhisperServer struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWhisperServer sends audio to the whisper.cpp server at url, such as
+// http://whisper:8178
+func NewWhisperServer(url string, httpClient *http.Client) *WhisperServer {
+	return &WhisperServer{url: strings.TrimRight(url, "/"), httpClient: httpClient}
+}
+
+// whisperResponse is whisper.cpp's verbose_json response, which follows
+// OpenAI's
+type whisperResponse struct {
+	Text     string `json:"text"`
+	Segments []struct {
+		AvgLogprob   float64 `json:"avg_logprob"`
+		NoSpeechProb float64 `json:"no_speech_prob"`
+	} `json:"segments"`
+}
+
+func (s *WhisperServer) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	t, err := s.TranscribeWithConfidence(ctx, voiceData, language)
+	return t.Text, err
+}
+
+// TranscribeWithConfidence transcribes voiceData, spoken in language. The
+// confidence is worked out from the segments as it is for OpenAI's Whisper.
+func (s *WhisperServer) TranscribeWithConfidence(ctx context.Context, voiceData []byte, language string) (Transcription, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", "audio.wav")
+	if err != nil {
+		return Transcription{}, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(voiceData); err != nil {
+		return Transcription{}, fmt.Errorf("failed to copy voice data: %w", err)
+	}
+	writer.WriteField("language", language)
+	writer.WriteField("prompt", "This is a spelling bee game. The audio will contain a single word spelled out.")
+	writer.WriteField("response_format", "verbose_json")
+	writer.WriteField("temperature", "0.2")
+	if err := writer.Close(); err != nil {
+		return Transcription{}, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/inference", body)
+	if err != nil {
+		return Transcription{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return Transcription{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Transcription{}, fmt.Errorf("whisper server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result whisperResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Transcription{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	text := strings.ToLower(strings.TrimSpace(result.Text))
+	text = strings.NewReplacer(".", "", ",", "", "!", "", "?", "").Replace(text)
+
+	confidence := 0.0
+	if len(result.Segments) > 0 {
+		var logprob, noSpeech float64
+		for _, seg := range result.Segments {
+			logprob += seg.AvgLogprob
+			noSpeech = math.Max(noSpeech, seg.NoSpeechProb)
+		}
+		confidence = math.Exp(logprob/float64(len(result.Segments))) * (1 - noSpeech)
+	}
+	return Transcription{Text: text, Confidence: confidence}, nil
+}
+
+// Check reports whether the server is up. whisper.cpp serves its upload
+// page at the root.
+func (s *WhisperServer) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("whisper server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
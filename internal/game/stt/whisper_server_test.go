@@ -0,0 +1,36 @@
+package stt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhisperServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			return
+		}
+		assert.Equal(t, "/inference", r.URL.Path)
+		assert.Equal(t, "en", r.FormValue("language"))
+		assert.Equal(t, "verbose_json", r.FormValue("response_format"))
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		audio, _ := io.ReadAll(file)
+		assert.Equal(t, "audio", string(audio))
+
+		w.Write([]byte(`{"text": " B-E-E. ", "segments": [{"avg_logprob": 0, "no_speech_prob": 0.25}]}`))
+	}))
+	defer srv.Close()
+
+	whisper := NewWhisperServer(srv.URL+"/", srv.Client())
+	tr, err := whisper.TranscribeWithConfidence(context.Background(), []byte("audio"), "en")
+	require.NoError(t, err)
+	assert.Equal(t, Transcription{Text: "b-e-e", Confidence: 0.75}, tr)
+	assert.NoError(t, whisper.Check(context.Background()))
+}
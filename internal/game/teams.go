@@ -0,0 +1,162 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"big-spella-go/internal/game/modes"
+	"big-spella-go/internal/queries"
+)
+
+var ErrTeamsUnfilled = errors.New("there aren't enough players for every team")
+
+// TeamResult is how one team finished a team relay
+type TeamResult struct {
+	Team      int      `json:"team"`
+	Score     int      `json:"score"`
+	Placement int      `json:"placement"`
+	Members   []string `json:"members"`
+}
+
+// isTeamRelay reports whether the game is played in teams
+func (g GameSettings) isTeamRelay() bool {
+	return g.Mode == modes.ModeTeamRelay
+}
+
+// startTeams deals a team relay's players into its teams, saves which team
+// each joined and seats the teams to take turns
+func (s *gameService) startTeams(ctx context.Context, game *Game, engine *GameEngine) error {
+	players := turnOrder(game)
+	if len(players) < game.Settings.Teams {
+		return ErrTeamsUnfilled
+	}
+
+	teams := modes.AssignTeams(players, game.Settings.Teams)
+	for t, members := range teams {
+		if err := s.queries.AssignTeam(ctx, queries.AssignTeamParams{
+			Team: t, GameID: game.ID, PlayerIDs: members,
+		}); err != nil {
+			return fmt.Errorf("failed to assign teams: %w", err)
+		}
+		for _, player := range game.Players {
+			if player != nil && slices.Contains(members, player.UserID) {
+				team := t
+				player.Team = &team
+			}
+		}
+	}
+
+	engine.Teams = teams
+	engine.RelayLetters = game.Settings.Relay == modes.RelayLetters
+	engine.SetTurnOrder(modes.RelayOrder(teams))
+	return nil
+}
+
+// team returns the members of playerID's team, or nil outside team relays
+func (g *GameEngine) team(playerID string) []string {
+	for _, team := range g.Teams {
+		if slices.Contains(team, playerID) {
+			return team
+		}
+	}
+	return nil
+}
+
+// relayFor returns whose turn playerID is acting in. In a letter relay the
+// whole team spells for whichever member's turn it is.
+func (g *GameEngine) relayFor(playerID string) string {
+	current := g.CurrentPlayer()
+	if g.RelayLetters && slices.Contains(g.team(current), playerID) {
+		return current
+	}
+	return playerID
+}
+
+// letterSpeller is who spells the next letter in a letter relay: the team
+// takes the letters in turn, starting with the member whose turn it is
+func (g *GameEngine) letterSpeller() string {
+	current := g.CurrentPlayer()
+	team := g.team(current)
+	if len(team) == 0 {
+		return current
+	}
+
+	spelled := 0
+	if g.Spelling != nil && g.Spelling.PlayerID == current {
+		spelled = len(g.Spelling.Letters)
+	}
+	return team[(slices.Index(team, current)+spelled)%len(team)]
+}
+
+// rankTeams places each player of a team relay as their team finished.
+// Players who left before they were dealt a team are placed after every
+// team.
+func rankTeams(results []PlayerResult, players []*Player) []PlayerResult {
+	for _, player := range players {
+		if player == nil || player.Team == nil {
+			continue
+		}
+		for i := range results {
+			if results[i].PlayerID == player.UserID {
+				results[i].Team = player.Team
+			}
+		}
+	}
+
+	teams := teamResults(results)
+	placements := make(map[int]int, len(teams))
+	for _, team := range teams {
+		placements[team.Team] = team.Placement
+	}
+	for i := range results {
+		results[i].Placement = len(teams) + 1
+		if results[i].Team != nil {
+			results[i].Placement = placements[*results[i].Team]
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Placement != results[j].Placement {
+			return results[i].Placement < results[j].Placement
+		}
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// teamResults totals the scores of each team in results and places the
+// teams, with tied teams sharing a placement
+func teamResults(results []PlayerResult) []TeamResult {
+	var teams []TeamResult
+	index := make(map[int]int)
+	for _, result := range results {
+		if result.Team == nil {
+			continue
+		}
+		i, ok := index[*result.Team]
+		if !ok {
+			i = len(teams)
+			index[*result.Team] = i
+			teams = append(teams, TeamResult{Team: *result.Team})
+		}
+		teams[i].Score += result.Score
+		teams[i].Members = append(teams[i].Members, result.PlayerID)
+	}
+
+	sort.SliceStable(teams, func(i, j int) bool {
+		if teams[i].Score != teams[j].Score {
+			return teams[i].Score > teams[j].Score
+		}
+		return teams[i].Team < teams[j].Team
+	})
+	for i := range teams {
+		teams[i].Placement = i + 1
+		if i > 0 && teams[i].Score == teams[i-1].Score {
+			teams[i].Placement = teams[i-1].Placement
+		}
+	}
+	return teams
+}
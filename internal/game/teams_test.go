@@ -0,0 +1,87 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLetterRelay(t *testing.T) {
+	now := time.Now()
+	engine := NewGameEngine("game", nil)
+	engine.CurrentWord = &Word{Word: "bee"}
+	engine.TurnStartedAt = &now
+	engine.Teams = [][]string{{"ada", "cy"}, {"bo"}}
+	engine.RelayLetters = true
+	engine.SetTurnOrder([]string{"ada", "bo", "cy", "bo"})
+
+	// ada's team spells for ada, taking the letters in turn
+	assert.Equal(t, "ada", engine.relayFor("cy"))
+	assert.Equal(t, "bo", engine.relayFor("bo"))
+	for _, speller := range []string{"ada", "cy", "ada"} {
+		assert.Equal(t, speller, engine.letterSpeller())
+		_, err := engine.AddLetter(engine.relayFor(speller), 'b')
+		require.NoError(t, err)
+	}
+
+	// On cy's turn cy starts
+	engine.AdvancePlayer()
+	engine.AdvancePlayer()
+	engine.Spelling = nil
+	assert.Equal(t, "cy", engine.letterSpeller())
+}
+
+func TestRemovePlayerFromRelay(t *testing.T) {
+	engine := NewGameEngine("game", nil)
+	engine.Teams = [][]string{{"ada", "cy"}, {"bo"}}
+	engine.SetTurnOrder([]string{"ada", "bo", "cy", "bo"})
+	engine.AdvancePlayer()
+
+	assert.True(t, engine.RemovePlayer("bo"), "bo's turn passes on")
+	assert.Equal(t, []string{"ada", "cy"}, engine.TurnOrder, "every one of bo's seats goes")
+	assert.Equal(t, "cy", engine.CurrentPlayer())
+	assert.Equal(t, [][]string{{"ada", "cy"}, {}}, engine.Teams)
+}
+
+func TestRankTeams(t *testing.T) {
+	red, blue := 0, 1
+	players := []*Player{
+		{UserID: "ada", Team: &red},
+		{UserID: "bo", Team: &blue},
+		{UserID: "cy", Team: &red},
+		{UserID: "dee", Team: &blue},
+		{UserID: "eve"},
+	}
+	results := rankTeams([]PlayerResult{
+		{PlayerID: "ada", Score: 2},
+		{PlayerID: "bo", Score: 5},
+		{PlayerID: "cy", Score: 2},
+		{PlayerID: "dee", Score: 0},
+		{PlayerID: "eve", Score: 9},
+	}, players)
+
+	placements := map[string]int{}
+	for _, result := range results {
+		placements[result.PlayerID] = result.Placement
+	}
+	assert.Equal(t, map[string]int{"bo": 1, "dee": 1, "ada": 2, "cy": 2, "eve": 3}, placements,
+		"players place with their team, and players without one after every team")
+
+	teams := teamResults(results)
+	assert.Equal(t, []TeamResult{
+		{Team: blue, Score: 5, Placement: 1, Members: []string{"bo", "dee"}},
+		{Team: red, Score: 4, Placement: 2, Members: []string{"ada", "cy"}},
+	}, teams)
+}
+
+func TestTiedTeamsSharePlacement(t *testing.T) {
+	red, blue := 0, 1
+	teams := teamResults([]PlayerResult{
+		{PlayerID: "ada", Score: 3, Team: &red},
+		{PlayerID: "bo", Score: 3, Team: &blue},
+	})
+	assert.Equal(t, 1, teams[0].Placement)
+	assert.Equal(t, 1, teams[1].Placement)
+}
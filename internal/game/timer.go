@@ -0,0 +1,61 @@
+package game
+
+import (
+	"sync"
+	"time"
+)
+
+// timerSet tracks pending per-game timers by key so they can be replaced or
+// cancelled when the game moves on before they fire
+type timerSet struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newTimerSet() *timerSet {
+	return &timerSet{
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+func timerKey(gameID, name string) string {
+	return gameID + ":" + name
+}
+
+// Schedule runs fn after d, replacing any timer already pending under key
+func (t *timerSet) Schedule(key string, d time.Duration, fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if existing, ok := t.timers[key]; ok {
+		existing.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		t.mu.Lock()
+		if t.timers[key] != timer {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.timers, key)
+		t.mu.Unlock()
+
+		fn()
+	})
+	t.timers[key] = timer
+}
+
+// Cancel stops the timer pending under key and reports whether there was one
+func (t *timerSet) Cancel(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, ok := t.timers[key]
+	if !ok {
+		return false
+	}
+	timer.Stop()
+	delete(t.timers, key)
+	return true
+}
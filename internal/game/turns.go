@@ -0,0 +1,203 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"big-spella-go/internal/notifications"
+)
+
+// SetTurnOrder seats players in the order they'll spell, starting with the
+// first
+func (g *GameEngine) SetTurnOrder(players []string) {
+	g.TurnOrder = players
+	g.turnIndex = 0
+}
+
+// CurrentPlayer is the player whose turn it is, or empty when the game has
+// no turn order
+func (g *GameEngine) CurrentPlayer() string {
+	if len(g.TurnOrder) == 0 {
+		return ""
+	}
+	return g.TurnOrder[g.turnIndex]
+}
+
+// AdvancePlayer passes the turn to the next player in order and returns them
+func (g *GameEngine) AdvancePlayer() string {
+	if len(g.TurnOrder) == 0 {
+		return ""
+	}
+	g.turnIndex = (g.turnIndex + 1) % len(g.TurnOrder)
+	return g.TurnOrder[g.turnIndex]
+}
+
+// RemovePlayer takes playerID out of the turn order, and their team, and
+// reports whether it was their turn, in which case the turn now sits with
+// the next player. A team relay can seat a player more than once.
+func (g *GameEngine) RemovePlayer(playerID string) bool {
+	wasCurrent := false
+	for i := 0; i < len(g.TurnOrder); {
+		if g.TurnOrder[i] != playerID {
+			i++
+			continue
+		}
+
+		wasCurrent = wasCurrent || i == g.turnIndex
+		g.TurnOrder = append(g.TurnOrder[:i:i], g.TurnOrder[i+1:]...)
+		if i < g.turnIndex {
+			g.turnIndex--
+		}
+		if g.turnIndex >= len(g.TurnOrder) {
+			g.turnIndex = 0
+		}
+	}
+
+	for t, team := range g.Teams {
+		g.Teams[t] = slices.DeleteFunc(team, func(id string) bool { return id == playerID })
+	}
+	return wasCurrent
+}
+
+// IsPlayerTurn reports whether playerID may answer the current word. Anyone
+// may when the game has no turn order.
+func (g *GameEngine) IsPlayerTurn(playerID string) bool {
+	current := g.CurrentPlayer()
+	return current == "" || current == playerID
+}
+
+// RestartTurn gives the current player a fresh answer window on the same word
+func (g *GameEngine) RestartTurn() {
+	now := time.Now()
+	g.TurnStartedAt = &now
+	g.Spelling = nil
+	g.Phase = g.openingPhase()
+	g.Replays = 0
+}
+
+// TurnDeadline is when the current player's answer window closes. It
+// carries TurnStartedAt's monotonic clock reading, so time left until it is
+// measured by that clock rather than the adjustable wall clock.
+func (g *GameEngine) TurnDeadline() *time.Time {
+	if g.TurnStartedAt == nil {
+		return nil
+	}
+	deadline := g.TurnStartedAt.Add(g.answerWindow())
+	return &deadline
+}
+
+// TurnRemaining is how long the current player has left to answer at now,
+// read from the server's clock. It's never negative, and zero when no
+// answer window is open.
+func (g *GameEngine) TurnRemaining(now time.Time) time.Duration {
+	deadline := g.TurnDeadline()
+	if deadline == nil {
+		return 0
+	}
+	return max(deadline.Sub(now), 0)
+}
+
+// turnOrder seats a game's active players in the order they joined. A game
+// nobody has joined is the host's alone.
+func turnOrder(game *Game) []string {
+	var players []*Player
+	for _, player := range game.Players {
+		if player != nil && player.Status == "active" {
+			players = append(players, player)
+		}
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].JoinedAt.Before(players[j].JoinedAt)
+	})
+
+	order := make([]string, 0, len(players))
+	for _, player := range players {
+		order = append(order, player.UserID)
+	}
+	if len(order) == 0 && game.HostID != "" {
+		order = append(order, game.HostID)
+	}
+	return order
+}
+
+// startPlayerTurn announces the current player and schedules their turn to
+// pass on if they don't answer by the deadline
+func (s *gameService) startPlayerTurn(game *Game, engine *GameEngine) {
+	playerID, startedAt := engine.CurrentPlayer(), engine.TurnStartedAt
+	if playerID == "" || startedAt == nil {
+		return
+	}
+
+	// The answer clock doesn't start until the player has heard the word
+	if engine.Phase == PhaseAnnounce || engine.Phase == PhaseListening {
+		s.announceWord(game, engine)
+		return
+	}
+
+	gameID := game.ID
+	now := time.Now()
+	s.timers.Schedule(timerKey(gameID, "turn"), engine.TurnRemaining(now), func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		ctx, unlock := s.lockEngine(ctx, gameID)
+		defer unlock()
+
+		// An attempt made as the clock ran out may have moved the turn on
+		engine := s.engine(gameID)
+		if engine == nil || engine.TurnStartedAt != startedAt || s.pendingReview(gameID) != nil || s.pendingConfirmation(gameID) != nil {
+			return
+		}
+
+		countAttempt(game, "timeout")
+		_ = s.passTurn(ctx, gameID, engine)
+	})
+
+	s.emitEvent(EventTypeTurnChanged, gameID, &playerID, withCountdown(map[string]any{
+		"player_id": playerID,
+		"round":     game.Round,
+		"phase":     PhaseAnswering,
+		"deadline":  engine.TurnDeadline(),
+	}, now, engine.TurnRemaining(now)))
+}
+
+// remindTurn lets the player whose turn has just begun know, in case they
+// are away from the game
+func (s *gameService) remindTurn(ctx context.Context, game *Game, engine *GameEngine) {
+	if s.notifier == nil {
+		return
+	}
+	if playerID := engine.CurrentPlayer(); playerID != "" {
+		s.notifier.Notify(ctx, playerID, notifications.TurnReminder(game.ID))
+	}
+}
+
+// passTurn hands the current word to the next player after a miss or a
+// timeout
+func (s *gameService) passTurn(ctx context.Context, gameID string, engine *GameEngine) error {
+	engine.AdvancePlayer()
+	return s.handOverTurn(ctx, gameID, engine)
+}
+
+// handOverTurn starts a fresh answer window on the current word for
+// whoever's turn it now is
+func (s *gameService) handOverTurn(ctx context.Context, gameID string, engine *GameEngine) error {
+	engine.RestartTurn()
+
+	game := &Game{}
+	if err := s.db.GetContext(ctx, game, `
+		UPDATE games
+		SET current_turn = $1, turn_started_at = $2, updated_at = $2, version = version + 1
+		WHERE id = $3
+		RETURNING *`,
+		engine.CurrentPlayer(), engine.TurnStartedAt, gameID); err != nil {
+		return fmt.Errorf("failed to pass turn: %w", err)
+	}
+
+	s.remindTurn(ctx, game, engine)
+	s.startPlayerTurn(game, engine)
+	return nil
+}
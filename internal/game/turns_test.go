@@ -0,0 +1,126 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/notifications"
+)
+
+type notifierStub struct {
+	sent map[string]notifications.Notification
+}
+
+func (n *notifierStub) Notify(ctx context.Context, userID string, notification notifications.Notification) {
+	n.sent[userID] = notification
+}
+
+func TestRemindTurn(t *testing.T) {
+	notifier := &notifierStub{sent: map[string]notifications.Notification{}}
+	s := &gameService{notifier: notifier}
+	engine := NewGameEngine("game", nil)
+	engine.SetTurnOrder([]string{"ada", "bo"})
+
+	s.remindTurn(context.Background(), &Game{ID: "game"}, engine)
+
+	assert.Len(t, notifier.sent, 1)
+	assert.Equal(t, notifications.KindTurnReminder, notifier.sent["ada"].Kind)
+	assert.Equal(t, "game", notifier.sent["ada"].Data["game_id"])
+
+	// Without a notifier there is nobody to tell
+	(&gameService{}).remindTurn(context.Background(), &Game{ID: "game"}, engine)
+}
+
+func TestTurnRotation(t *testing.T) {
+	engine := NewGameEngine("game", nil)
+	assert.True(t, engine.IsPlayerTurn("anyone"), "anyone may answer without a turn order")
+
+	engine.SetTurnOrder([]string{"ada", "bo", "cy"})
+	assert.Equal(t, "ada", engine.CurrentPlayer())
+	assert.True(t, engine.IsPlayerTurn("ada"))
+	assert.False(t, engine.IsPlayerTurn("bo"))
+
+	assert.Equal(t, "bo", engine.AdvancePlayer())
+	assert.Equal(t, "cy", engine.AdvancePlayer())
+	assert.Equal(t, "ada", engine.AdvancePlayer(), "turn order wraps around")
+}
+
+func TestTurnOrderFollowsJoinOrder(t *testing.T) {
+	now := time.Now()
+	game := &Game{
+		HostID: "host",
+		Players: []*Player{
+			{UserID: "late", Status: "active", JoinedAt: now.Add(time.Minute)},
+			nil,
+			{UserID: "early", Status: "active", JoinedAt: now},
+			{UserID: "gone", Status: "left", JoinedAt: now},
+		},
+	}
+	assert.Equal(t, []string{"early", "late"}, turnOrder(game))
+
+	assert.Equal(t, []string{"host"}, turnOrder(&Game{HostID: "host"}))
+}
+
+func TestTurnDeadline(t *testing.T) {
+	engine := NewGameEngine("game", nil)
+	assert.Nil(t, engine.TurnDeadline())
+
+	engine.RestartTurn()
+	assert.Equal(t, engine.TurnStartedAt.Add(TurnTimeout), *engine.TurnDeadline())
+}
+
+func TestTurnRemaining(t *testing.T) {
+	engine := NewGameEngine("game", nil)
+	assert.Zero(t, engine.TurnRemaining(time.Now()))
+
+	engine.RestartTurn()
+	started := *engine.TurnStartedAt
+	assert.Equal(t, TurnTimeout, engine.TurnRemaining(started))
+	assert.Equal(t, time.Second, engine.TurnRemaining(started.Add(TurnTimeout-time.Second)))
+	assert.Zero(t, engine.TurnRemaining(started.Add(TurnTimeout+time.Second)))
+}
+
+func TestRemovePlayerSkipsTheirTurns(t *testing.T) {
+	engine := NewGameEngine("game", nil)
+	engine.SetTurnOrder([]string{"ada", "bo", "cy"})
+	engine.AdvancePlayer()
+
+	assert.False(t, engine.RemovePlayer("ada"))
+	assert.Equal(t, "bo", engine.CurrentPlayer(), "removing an earlier player keeps the turn in place")
+
+	assert.True(t, engine.RemovePlayer("bo"))
+	assert.Equal(t, "cy", engine.CurrentPlayer(), "the turn moves on when its player leaves")
+
+	assert.True(t, engine.RemovePlayer("cy"))
+	assert.Empty(t, engine.CurrentPlayer())
+	assert.False(t, engine.RemovePlayer("nobody"))
+}
+
+func TestLockEngine(t *testing.T) {
+	s := &gameService{activeGames: map[string]*GameEngine{"game": NewGameEngine("game", nil)}}
+
+	ctx, unlock := s.lockEngine(context.Background(), "game")
+	_, again := s.lockEngine(ctx, "game")
+	again()
+
+	locked := make(chan struct{})
+	go func() {
+		_, unlock := s.lockEngine(context.Background(), "game")
+		defer unlock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("the engine was locked twice")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+
+	_, unlock = s.lockEngine(context.Background(), "no-such-game")
+	unlock()
+}
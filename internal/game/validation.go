@@ -0,0 +1,292 @@
+package game
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"big-spella-go/internal/game/modes"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	MaxPlayersLimit = 32
+	MaxHintsLimit   = 10
+	MaxTextAttempt  = 100
+	MaxReplaysLimit = 5
+	MaxAppealReason = 500
+	MaxAppealWindow = 24 * time.Hour
+)
+
+// failedValidation responds with 422 and the per-field errors in v
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// validGameID checks the game ID path parameter and responds with 422 when
+// it isn't a UUID
+func validGameID(w http.ResponseWriter, gameID string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(gameID)
+	v.CheckField(err == nil, "game_id", "Must be a valid game ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return false
+	}
+	return true
+}
+
+// validPlayerID checks the player ID path parameter and responds with 422
+// when it isn't a UUID
+func validPlayerID(w http.ResponseWriter, playerID string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(playerID)
+	v.CheckField(err == nil, "player_id", "Must be a valid player ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return false
+	}
+	return true
+}
+
+func (r *CreateGameRequest) validate() {
+	v := &r.Validator
+	v.CheckField(validator.In(r.Type, GameTypeSolo, GameTypeMulti, GameTypePractice), "type", "Must be one of solo, multi or practice")
+	v.CheckField(r.Settings.ScheduledAt == nil || r.Type == GameTypeMulti, "settings.scheduled_at", "Only multiplayer games can be scheduled")
+	validateSettings(v, r.Settings)
+}
+
+func validateSettings(v *validator.Validator, s GameSettings) {
+	v.CheckField(s.MinPlayers >= 0, "settings.min_players", "Must not be negative")
+	v.CheckField(validator.Between(s.MaxPlayers, 1, MaxPlayersLimit), "settings.max_players", "Must be between 1 and 32")
+	v.CheckField(s.MinPlayers <= s.MaxPlayers, "settings.min_players", "Must not exceed max_players")
+	v.CheckField(s.TimeLimit >= 0, "settings.time_limit", "Must not be negative")
+	v.CheckField(validator.Between(s.WordLevel, 1, 10), "settings.word_level", "Must be between 1 and 10")
+	v.CheckField(s.Language == "" || IsSupportedLanguage(s.Language), "settings.language", "Must be a supported language")
+	v.CheckField(s.WordFilter.Valid(), "settings.word_filter", "Must be one of standard, strict or off")
+	v.CheckField(s.SpellingPolicy.Valid(), "settings.spelling_policy", "Must be one of lenient, strict_us or strict_uk")
+	v.CheckField(validator.Between(s.HintsAllowed, 0, MaxHintsLimit), "settings.hints_allowed", "Must be between 0 and 10")
+	v.CheckField(s.HintPenalty == nil || *s.HintPenalty >= 0, "settings.hint_penalty", "Must not be negative")
+	v.CheckField(validator.AllIn(s.AllowedHints, HintOrder...), "settings.allowed_hints", "Contains an unknown hint type")
+	v.CheckField(validator.NoDuplicates(s.AllowedHints), "settings.allowed_hints", "Must not contain duplicates")
+	v.CheckField(s.SpellStartTimeout >= 0, "settings.spell_start_timeout", "Must not be negative")
+	v.CheckField(s.Pacing.InterRoundDelay >= 0, "settings.pacing.inter_round_delay", "Must not be negative")
+	v.CheckField(s.Pacing.AutoAdvanceAfter >= 0, "settings.pacing.auto_advance_after", "Must not be negative")
+	v.CheckField(s.RevealPolicy.Valid(), "settings.reveal_policy", "Must be one of always, end_of_game or never")
+	v.CheckField(s.Judging.ConfidenceThreshold >= 0 && s.Judging.ConfidenceThreshold <= 1, "settings.judging.confidence_threshold", "Must be between 0 and 1")
+	v.CheckField(s.Judging.Timeout >= 0, "settings.judging.timeout", "Must not be negative")
+	v.CheckField(s.Confirmation.Threshold >= 0 && s.Confirmation.Threshold <= 1, "settings.confirmation.threshold", "Must be between 0 and 1")
+	v.CheckField(s.Confirmation.Timeout >= 0 && s.Confirmation.Timeout <= MaxConfirmationTimeout, "settings.confirmation.timeout", "Must be between 0 and 1 minute")
+	v.CheckField(s.ReadyCheckTimeout >= 0 && s.ReadyCheckTimeout <= MaxReadyCheckTimeout, "settings.ready_check_timeout", "Must be between 0 and 2 minutes")
+	if s.ScheduledAt != nil {
+		ahead := time.Until(*s.ScheduledAt)
+		v.CheckField(ahead > 0 && ahead <= MaxScheduleAhead, "settings.scheduled_at", "Must be in the future and within 90 days")
+	}
+	v.CheckField(s.LobbyOpensBefore >= 0 && s.LobbyOpensBefore <= MaxLobbyOpensBefore, "settings.lobby_opens_before", "Must be between 0 and 1 hour")
+	v.CheckField(s.AnswerWindow.Base >= 0 && s.AnswerWindow.PerLetter >= 0 && s.AnswerWindow.PerLevel >= 0, "settings.answer_window", "Must not be negative")
+	v.CheckField(s.AnswerWindow.Base <= MaxAnswerWindow && s.AnswerWindow.Max <= MaxAnswerWindow, "settings.answer_window", "Must not be more than 2 minutes")
+	v.CheckField(s.AnswerWindow.Max >= 0 && (s.AnswerWindow.Max == 0 || s.AnswerWindow.Max >= s.AnswerWindow.Base), "settings.answer_window.max", "Must not be less than the base window")
+	v.CheckField(s.Pronunciation.ListenTime >= 0, "settings.pronunciation.listen_time", "Must not be negative")
+	v.CheckField(s.Pronunciation.MaxReplays <= MaxReplaysLimit, "settings.pronunciation.max_replays", "Must not be more than 5")
+	v.CheckField(s.Appeals.Window <= MaxAppealWindow, "settings.appeals.window", "Must not be more than 24 hours")
+	v.CheckField(s.Scoring.NearMissCredit >= 0 && s.Scoring.NearMissCredit <= 1, "settings.scoring.near_miss_credit", "Must be between 0 and 1")
+	v.CheckField(!s.IsRanked || s.Scoring.NearMissCredit == 0, "settings.scoring.near_miss_credit", "Must be 0 in ranked games")
+	v.CheckField(s.WordOrder.Valid(), "settings.word_order", "Must be one of in_order or random")
+	if s.WordList != nil {
+		_, err := uuid.Parse(*s.WordList)
+		v.CheckField(err == nil, "settings.word_list", "Must be a valid word list ID")
+		v.CheckField(s.IsPrivate, "settings.word_list", "Word lists can only be used in private games")
+		v.CheckField(!s.IsRanked, "settings.word_list", "Word lists can't be used in ranked games")
+		v.CheckField(s.Category == nil, "settings.category", "Must not be set with a word list")
+	}
+	v.CheckField(validator.NoDuplicates(s.Judging.Judges), "settings.judging.judges", "Must not contain duplicates")
+	for _, judgeID := range s.Judging.Judges {
+		_, err := uuid.Parse(judgeID)
+		v.CheckField(err == nil, "settings.judging.judges", "Must be valid user IDs")
+	}
+
+	if s.Mode != "" {
+		v.CheckField(validator.In(s.Mode, modes.ModeRoundRobin, modes.ModeRapidFire, modes.ModeTotalGame, modes.ModeTeamRelay), "settings.mode", "Must be one of round_robin, rapid_fire, total_game or team_relay")
+		v.CheckField(s.Relay != modes.RelayLetters || s.LiveSpelling.Enabled, "settings.relay", "Letter relays need live spelling enabled")
+		if err := modes.ValidateSettings(s.modeSettings()); err != nil {
+			v.AddFieldError("settings.mode", err.Error())
+		}
+	} else {
+		if err := s.Scoring.Validate(); err != nil {
+			v.AddFieldError("settings.scoring", err.Error())
+		}
+		if s.Difficulty != nil {
+			if err := s.Difficulty.Validate(); err != nil {
+				v.AddFieldError("settings.difficulty", err.Error())
+			}
+		}
+	}
+}
+
+// modeSettings maps the settings onto the shape the modes package checks
+func (g GameSettings) modeSettings() modes.GameSettings {
+	settings := modes.GameSettings{
+		Mode:         g.Mode,
+		MaxPlayers:   g.MaxPlayers,
+		MaxRounds:    g.MaxRounds,
+		TimeLimit:    g.TimeLimit,
+		WordLevel:    g.WordLevel,
+		IsTournament: g.IsTournament,
+		IsPrivate:    g.IsPrivate,
+		Scoring:      g.Scoring.ScoringConfig,
+		Teams:        g.Teams,
+		Relay:        g.Relay,
+		Difficulty:   g.Difficulty,
+	}
+	if g.Category != nil {
+		settings.Category = *g.Category
+	}
+	return settings
+}
+
+func (r *MakeAttemptRequest) validate() {
+	v := &r.Validator
+	v.CheckField(validator.In(r.Type, AttemptTypeText, AttemptTypeVoice), "type", "Must be text or voice")
+
+	switch r.Type {
+	case AttemptTypeText:
+		v.CheckField(r.Text != nil && validator.NotBlank(*r.Text), "text", "Text is required")
+		v.CheckField(r.Text == nil || validator.MaxRunes(*r.Text, MaxTextAttempt), "text", "Must not be more than 100 characters")
+	case AttemptTypeVoice:
+		v.CheckField(len(r.VoiceData) > 0, "voice_data", "Voice data is required")
+	}
+}
+
+func (r *AudioPreferencesRequest) validate() {
+	v := &r.Validator
+	v.CheckField(r.Voice != nil || r.Rate != nil || r.SlowRepeat != nil, "preferences", "Must change at least one preference")
+	v.CheckField(r.Voice == nil || *r.Voice == "" || validator.In(*r.Voice, Voices...), "voice", "Must be one of alloy, echo, fable, onyx, nova or shimmer")
+	v.CheckField(r.Rate == nil || (*r.Rate >= MinSpeechRate && *r.Rate <= MaxSpeechRate), "rate", "Must be between 0.5 and 2")
+}
+
+// apply sets the preferences r changes on prefs
+func (r *AudioPreferencesRequest) apply(prefs *AudioPreferences) {
+	if r.Voice != nil {
+		prefs.Voice = *r.Voice
+	}
+	if r.Rate != nil {
+		prefs.Rate = *r.Rate
+	}
+	if r.SlowRepeat != nil {
+		prefs.SlowRepeat = *r.SlowRepeat
+	}
+}
+
+func (r *HintRequest) validate() {
+	r.Validator.CheckField(r.Type == "" || isSupportedHintType(normalizeHintType(r.Type)), "type", "Unknown hint type")
+}
+
+func (r *ConfirmationRequest) validate(attemptID string) {
+	_, err := uuid.Parse(attemptID)
+	r.Validator.CheckField(err == nil, "attempt_id", "Must be a valid attempt ID")
+	r.Validator.CheckField(r.Confirmed != nil, "confirmed", "Must be true or false")
+}
+
+func (r *RulingRequest) validate(attemptID string) {
+	_, err := uuid.Parse(attemptID)
+	r.Validator.CheckField(err == nil, "attempt_id", "Must be a valid attempt ID")
+	r.Validator.CheckField(r.Correct != nil, "correct", "Ruling is required")
+}
+
+func (r *AppealRequest) validate(attemptID string) {
+	_, err := uuid.Parse(attemptID)
+	r.Validator.CheckField(err == nil, "attempt_id", "Must be a valid attempt ID")
+	r.Validator.CheckField(validator.MaxRunes(r.Reason, MaxAppealReason), "reason", "Must not be more than 500 characters")
+}
+
+func (r *AppealDecisionRequest) validate(appealID string) {
+	_, err := uuid.Parse(appealID)
+	r.Validator.CheckField(err == nil, "appeal_id", "Must be a valid appeal ID")
+	r.Validator.CheckField(r.Overturn != nil, "overturn", "Decision is required")
+	r.Validator.CheckField(validator.MaxRunes(r.Note, MaxAppealReason), "note", "Must not be more than 500 characters")
+}
+
+func (r *JoinGameRequest) validate() {
+	r.Validator.CheckField(validator.MaxRunes(r.InviteCode, 32), "invite_code", "Must not be more than 32 characters")
+	r.Validator.CheckField(validator.MaxRunes(r.InviteToken, 64), "invite_token", "Must not be more than 64 characters")
+}
+
+func (r *JoinByCodeRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.InviteCode), "invite_code", "Invite code is required")
+	r.Validator.CheckField(validator.MaxRunes(r.InviteCode, 32), "invite_code", "Must not be more than 32 characters")
+}
+
+// parseListGamesRequest reads the lobby browser's query string, recording
+// values that aren't numbers or booleans as field errors
+func parseListGamesRequest(query url.Values) ListGamesRequest {
+	req := ListGamesRequest{
+		Status:   query.Get("status"),
+		Type:     query.Get("type"),
+		Mode:     query.Get("mode"),
+		Page:     1,
+		PageSize: DefaultPageSize,
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("level", &req.Level)
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	if raw := query.Get("ranked"); raw != "" {
+		ranked, err := strconv.ParseBool(raw)
+		req.Validator.CheckField(err == nil, "ranked", "Must be true or false")
+		req.Ranked = &ranked
+	}
+
+	return req
+}
+
+func (r *ListGamesRequest) validate() {
+	v := &r.Validator
+	v.CheckField(r.Status == "" || validator.In(GameStatus(r.Status), GameStatusScheduled, GameStatusWaiting, GameStatusActive, GameStatusPaused, GameStatusFinished, GameStatusCancelled), "status", "Must be one of scheduled, waiting, active, paused, finished or cancelled")
+	v.CheckField(r.Type == "" || validator.In(GameType(r.Type), GameTypeSolo, GameTypeMulti, GameTypePractice), "type", "Must be one of solo, multi or practice")
+	v.CheckField(r.Mode == "" || validator.In(modes.GameMode(r.Mode), modes.ModeRoundRobin, modes.ModeRapidFire, modes.ModeTotalGame, modes.ModeTeamRelay), "mode", "Must be one of round_robin, rapid_fire, total_game or team_relay")
+	v.CheckField(r.Level == 0 || validator.Between(r.Level, 1, 10), "level", "Must be between 1 and 10")
+	v.CheckField(r.Page >= 1, "page", "Must be greater than zero")
+	v.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 100")
+}
+
+// filter turns a valid request into the GameFilter for its page
+func (r *ListGamesRequest) filter() GameFilter {
+	filter := NewGameFilter()
+	filter.Limit = r.PageSize
+	filter.Offset = (r.Page - 1) * r.PageSize
+	filter.IsRanked = r.Ranked
+
+	if r.Status != "" {
+		status := GameStatus(r.Status)
+		filter.Status = &status
+	}
+	if r.Type != "" {
+		gameType := GameType(r.Type)
+		filter.Type = &gameType
+	}
+	if r.Mode != "" {
+		mode := modes.GameMode(r.Mode)
+		filter.Mode = &mode
+	}
+	if r.Level != 0 {
+		level := r.Level
+		filter.WordLevel = &level
+	}
+	return filter
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/game/modes"
+)
+
+func validSettings() GameSettings {
+	return GameSettings{MinPlayers: 2, MaxPlayers: 4, WordLevel: 3}
+}
+
+func TestCreateGameRequestValidation(t *testing.T) {
+	req := CreateGameRequest{Type: GameTypeMulti, Settings: validSettings()}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	penalty := -1
+	bad := validSettings()
+	bad.MaxPlayers = 64
+	bad.WordLevel = 0
+	bad.Language = "tlh"
+	bad.HintPenalty = &penalty
+	bad.AllowedHints = []HintType{HintTypeDefinition, "riddle"}
+	bad.RevealPolicy = "sometimes"
+	bad.WordFilter = "lax"
+	bad.SpellingPolicy = "strict_au"
+	bad.IsRanked = true
+	bad.Scoring.NearMissCredit = 0.5
+	bad.Scoring.PointsPerCorrect = -5
+	bad.AnswerWindow = AnswerWindowSettings{Base: 20 * time.Second, Max: 15 * time.Second}
+
+	req = CreateGameRequest{Type: "arcade", Settings: bad}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "type")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.max_players")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.word_level")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.language")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.answer_window.max")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.hint_penalty")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.allowed_hints")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.reveal_policy")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.word_filter")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.spelling_policy")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.scoring.near_miss_credit")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.scoring")
+}
+
+func TestCreateGameRequestChecksWordList(t *testing.T) {
+	list := uuid.New().String()
+	settings := validSettings()
+	settings.IsPrivate = true
+	settings.WordList = &list
+	settings.WordOrder = WordOrderInOrder
+
+	req := CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors(), req.Validator.FieldErrors)
+
+	// Public games draw from the word bank
+	settings.IsPrivate = false
+	settings.WordOrder = "alphabetical"
+	req = CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "settings.word_list")
+	assert.Contains(t, req.Validator.FieldErrors, "settings.word_order")
+}
+
+func TestCreateGameRequestChecksDifficulty(t *testing.T) {
+	settings := validSettings()
+	settings.Difficulty = &modes.DifficultyCurve{Start: 2, Increment: 0.5, Cap: 6}
+
+	req := CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors(), req.Validator.FieldErrors)
+
+	settings.Difficulty = &modes.DifficultyCurve{Start: 7, Cap: 3}
+	req = CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "settings.difficulty")
+}
+
+func TestLevelFor(t *testing.T) {
+	settings := GameSettings{WordLevel: 4}
+	assert.Equal(t, 4, settings.LevelFor(1))
+	assert.Equal(t, 4, settings.LevelFor(12))
+
+	// Round robins climb a level a round by default
+	settings.Mode = modes.ModeRoundRobin
+	settings.Difficulty = &modes.DifficultyCurve{Cap: 6}
+	assert.Equal(t, 4, settings.LevelFor(1))
+	assert.Equal(t, 5, settings.LevelFor(2))
+	assert.Equal(t, 6, settings.LevelFor(12))
+}
+
+func TestCreateGameRequestChecksMode(t *testing.T) {
+	settings := validSettings()
+	settings.Mode = modes.ModeRapidFire
+	settings.MaxPlayers = 2
+	settings.TimeLimit = 10 * time.Second
+
+	req := CreateGameRequest{Type: GameTypeMulti, Settings: settings}
+	req.validate()
+	assert.Equal(t, "rapid fire time limit must be between 1-30 minutes", req.Validator.FieldErrors["settings.mode"])
+}
+
+func TestAudioPreferencesRequest(t *testing.T) {
+	voice, rate, slow := "nova", 0.75, true
+	req := AudioPreferencesRequest{Voice: &voice, Rate: &rate}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	prefs := AudioPreferences{Rate: DefaultSpeechRate, SlowRepeat: true}
+	req.apply(&prefs)
+	assert.Equal(t, AudioPreferences{Voice: "nova", Rate: 0.75, SlowRepeat: true}, prefs, "preferences left out are kept")
+
+	req = AudioPreferencesRequest{SlowRepeat: &slow}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = AudioPreferencesRequest{}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "preferences")
+
+	voice, rate = "hal", 3
+	req = AudioPreferencesRequest{Voice: &voice, Rate: &rate}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "voice")
+	assert.Contains(t, req.Validator.FieldErrors, "rate")
+}
+
+func TestMakeAttemptRequestValidation(t *testing.T) {
+	blank := "  "
+	req := MakeAttemptRequest{Type: AttemptTypeText, Text: &blank}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "text")
+
+	req = MakeAttemptRequest{Type: AttemptTypeVoice}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "voice_data")
+}
+
+func TestInvalidGameIDIsUnprocessable(t *testing.T) {
+	h := NewHandler(nil)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/games/not-a-uuid", nil)
+
+	h.GetGame(rec, req, httprouter.Params{{Key: "gameID", Value: "not-a-uuid"}})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "game_id")
+}
+
+func TestListGamesRequest(t *testing.T) {
+	req := parseListGamesRequest(url.Values{
+		"status": {"waiting"}, "type": {"multi"}, "mode": {"rapid_fire"},
+		"level": {"4"}, "ranked": {"true"}, "page": {"3"}, "page_size": {"10"},
+	})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	filter := req.filter()
+	assert.Equal(t, GameStatusWaiting, *filter.Status)
+	assert.Equal(t, GameTypeMulti, *filter.Type)
+	assert.Equal(t, modes.ModeRapidFire, *filter.Mode)
+	assert.Equal(t, 4, *filter.WordLevel)
+	assert.True(t, *filter.IsRanked)
+	assert.False(t, filter.IncludePrivate)
+	assert.Equal(t, 10, filter.Limit)
+	assert.Equal(t, 20, filter.Offset)
+
+	req = parseListGamesRequest(url.Values{"page": {"x"}, "page_size": {"500"}, "ranked": {"maybe"}, "status": {"lost"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "page")
+	assert.Contains(t, req.Validator.FieldErrors, "page_size")
+	assert.Contains(t, req.Validator.FieldErrors, "ranked")
+	assert.Contains(t, req.Validator.FieldErrors, "status")
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrGameChanged means another request updated a game between it being read
+// and written, and the write no longer applies to it
+var ErrGameChanged = errors.New("the game changed while your request was being handled")
+
+// updateRetries is how many times an update that lost a race is tried again
+// against the newer version of the game
+const updateRetries = 3
+
+// updateGame runs update, which writes game at game.Version and reads the
+// updated row back into it. The queries it uses only match the version
+// they're given, and bump it.
+//
+// When another update got in first, the update is retried at the newer
+// version as long as still accepts the game as it now is; with a nil still
+// it always is. Otherwise it fails with ErrGameChanged.
+func (s *gameService) updateGame(ctx context.Context, game *Game, still func(*Game) bool, update func() error) error {
+	for try := 0; ; try++ {
+		err := update()
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if try == updateRetries {
+			return ErrGameChanged
+		}
+
+		latest := &Game{}
+		if err := s.queries.GetGameRow(ctx, latest, game.ID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrGameNotFound
+			}
+			return fmt.Errorf("failed to get game: %w", err)
+		}
+		if still != nil && !still(latest) {
+			return ErrGameChanged
+		}
+		game.Version = latest.Version
+	}
+}
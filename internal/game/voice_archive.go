@@ -0,0 +1,146 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const DefaultVoiceRetention = 30 * 24 * time.Hour
+
+// VoicePolicy decides whether and for how long attempt audio is kept
+type VoicePolicy struct {
+	// Retention is how long stored audio is kept before it is purged. Zero
+	// means DefaultVoiceRetention; a negative value disables retention.
+	Retention time.Duration
+	// KMSKeyID is the key S3 uses to envelope-encrypt stored audio
+	KMSKeyID string
+	// ExportAudio includes links to retained audio in personal data exports
+	ExportAudio bool
+}
+
+func (p VoicePolicy) retention() time.Duration {
+	if p.Retention == 0 {
+		return DefaultVoiceRetention
+	}
+	return p.Retention
+}
+
+// VoiceStore keeps encrypted voice recordings
+type VoiceStore interface {
+	PutEncrypted(ctx context.Context, key string, data []byte, contentType, kmsKeyID string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// VoiceArchive applies VoicePolicy to voice attempts. Raw audio never goes
+// into Postgres: it is either encrypted into the voice store with an
+// expiry, or dropped.
+type VoiceArchive struct {
+	db     *sqlx.DB
+	store  VoiceStore
+	policy VoicePolicy
+}
+
+func NewVoiceArchive(db *sqlx.DB, store VoiceStore, policy VoicePolicy) *VoiceArchive {
+	return &VoiceArchive{
+		db:     db,
+		store:  store,
+		policy: policy,
+	}
+}
+
+// Policy returns the policy the archive enforces
+func (a *VoiceArchive) Policy() VoicePolicy {
+	return a.policy
+}
+
+// Archive stores the attempt's audio if both the policy and the game allow
+// it, then clears VoiceData from the attempt
+func (a *VoiceArchive) Archive(ctx context.Context, game *Game, attempt *SpellingAttempt) error {
+	defer func() { attempt.VoiceData = nil }()
+
+	if len(attempt.VoiceData) == 0 || a.policy.retention() < 0 || game.Settings.VoiceRetentionOptOut {
+		return nil
+	}
+
+	key := fmt.Sprintf("voice/%s/%s.wav", game.ID, attempt.ID)
+	if err := a.store.PutEncrypted(ctx, key, attempt.VoiceData, "audio/wav", a.policy.KMSKeyID); err != nil {
+		return fmt.Errorf("failed to archive voice data: %w", err)
+	}
+
+	expiresAt := time.Now().Add(a.policy.retention())
+	attempt.VoiceKey = &key
+	attempt.VoiceExpiresAt = &expiresAt
+
+	return nil
+}
+
+// Load returns an attempt's archived audio, or nil if none was kept or it
+// has since been purged
+func (a *VoiceArchive) Load(ctx context.Context, attempt *SpellingAttempt) ([]byte, error) {
+	if attempt.VoiceKey == nil {
+		return nil, nil
+	}
+
+	data, err := a.store.Get(ctx, *attempt.VoiceKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load voice data: %w", err)
+	}
+	return data, nil
+}
+
+// Purge deletes every recording past its expiry and returns how many were
+// removed
+func (a *VoiceArchive) Purge(ctx context.Context) (int, error) {
+	var expired []struct {
+		ID  string `db:"id"`
+		Key string `db:"voice_s3_key"`
+	}
+	if err := a.db.SelectContext(ctx, &expired, `
+		SELECT id, voice_s3_key FROM spelling_attempts
+		WHERE voice_s3_key IS NOT NULL AND voice_expires_at < NOW()`); err != nil {
+		return 0, fmt.Errorf("failed to list expired voice data: %w", err)
+	}
+
+	for i, row := range expired {
+		if err := a.store.Delete(ctx, row.Key); err != nil {
+			return i, err
+		}
+		if _, err := a.db.ExecContext(ctx, `
+			UPDATE spelling_attempts
+			SET voice_s3_key = NULL, voice_expires_at = NULL
+			WHERE id = $1`, row.ID); err != nil {
+			return i, fmt.Errorf("failed to clear voice data: %w", err)
+		}
+	}
+
+	// Rows written before audio moved to S3 still carry it inline
+	if _, err := a.db.ExecContext(ctx, `
+		UPDATE spelling_attempts SET voice_data = NULL
+		WHERE voice_data IS NOT NULL AND timestamp < $1`,
+		time.Now().Add(-a.policy.retention())); err != nil {
+		return len(expired), fmt.Errorf("failed to purge inline voice data: %w", err)
+	}
+
+	return len(expired), nil
+}
+
+// RunPurge calls Purge every interval until ctx is cancelled
+func (a *VoiceArchive) RunPurge(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.Purge(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
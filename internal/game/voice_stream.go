@@ -0,0 +1,41 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+)
+
+// MaxVoiceStream caps the audio of one streamed voice answer, well under
+// the 25MB Whisper takes
+const MaxVoiceStream = 10 << 20
+
+var (
+	ErrNoVoiceStream   = errors.New("start a voice answer before sending its audio")
+	ErrVoiceStreamSize = errors.New("the voice answer is too long")
+)
+
+// voiceStream buffers a voice answer sent over the game WebSocket as it's
+// recorded, in binary frames between voice_start and voice_end. The audio
+// is uploaded while the player speaks, so the answer goes to transcription
+// as soon as they finish rather than after a whole recording is sent.
+// Chunks are joined in the order they arrive, so they must be pieces of
+// one recording, as MediaRecorder and AVAudioRecorder produce.
+type voiceStream struct {
+	buf bytes.Buffer
+}
+
+// write adds a chunk to the answer. An answer that grows past
+// MaxVoiceStream is thrown away, and the player starts again.
+func (v *voiceStream) write(chunk []byte) error {
+	if v.buf.Len()+len(chunk) > MaxVoiceStream {
+		return ErrVoiceStreamSize
+	}
+	v.buf.Write(chunk)
+	return nil
+}
+
+// attemptRequest is the finished answer, as the request the HTTP endpoint
+// takes
+func (v *voiceStream) attemptRequest() MakeAttemptRequest {
+	return MakeAttemptRequest{Type: AttemptTypeVoice, VoiceData: v.buf.Bytes()}
+}
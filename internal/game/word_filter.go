@@ -0,0 +1,57 @@
+package game
+
+// WordFilter decides how strictly offensive words are kept out of a game.
+// Words on the blocklist are never served whatever the filter.
+type WordFilter string
+
+const (
+	// WordFilterStandard keeps out words the dictionary marks offensive,
+	// except those on the allowlist
+	WordFilterStandard WordFilter = "standard"
+	// WordFilterStrict keeps out every word the dictionary marks offensive,
+	// even those on the allowlist
+	WordFilterStrict WordFilter = "strict"
+	// WordFilterOff lets offensive words be served, for games among adults
+	// who have asked for them
+	WordFilterOff WordFilter = "off"
+)
+
+// WordLists are the lists admins keep of words to serve or not whatever
+// the dictionary says of them
+const (
+	WordListBlock = "block"
+	WordListAllow = "allow"
+)
+
+// Valid reports whether f is a known filter. The empty filter is valid and
+// means WordFilterStandard.
+func (f WordFilter) Valid() bool {
+	switch f {
+	case "", WordFilterStandard, WordFilterStrict, WordFilterOff:
+		return true
+	}
+	return false
+}
+
+// listed matches words on list in the word_filters table
+func listed(list string) string {
+	return `EXISTS (
+				SELECT 1 FROM word_filters f
+				WHERE f.word = lower(words.word) AND f.language = words.language AND f.list = '` + list + `')`
+}
+
+// clause is the condition a word must meet to be served under f
+func (f WordFilter) clause() string {
+	clause := `
+			AND NOT ` + listed(WordListBlock)
+	switch f {
+	case WordFilterStrict:
+		clause += `
+			AND NOT words.offensive`
+	case WordFilterOff:
+	default:
+		clause += `
+			AND (NOT words.offensive OR ` + listed(WordListAllow) + `)`
+	}
+	return clause
+}
@@ -0,0 +1,26 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordFilterClause(t *testing.T) {
+	blocked := listed(WordListBlock)
+	allowed := listed(WordListAllow)
+
+	standard := WordFilter("").clause()
+	assert.Equal(t, WordFilterStandard.clause(), standard, "the standard filter is the default")
+	assert.Contains(t, standard, "NOT "+blocked)
+	assert.Contains(t, standard, "NOT words.offensive OR "+allowed)
+
+	strict := WordFilterStrict.clause()
+	assert.Contains(t, strict, "NOT "+blocked)
+	assert.Contains(t, strict, "NOT words.offensive")
+	assert.NotContains(t, strict, allowed, "the allowlist doesn't let offensive words into strict games")
+
+	off := WordFilterOff.clause()
+	assert.Contains(t, off, "NOT "+blocked, "blocked words are never served")
+	assert.NotContains(t, off, "offensive")
+}
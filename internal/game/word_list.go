@@ -0,0 +1,36 @@
+package game
+
+import "errors"
+
+// ErrUnknownWordList means a game was set to draw from a word list that
+// doesn't exist or that its host doesn't own
+var ErrUnknownWordList = errors.New("word list does not exist")
+
+// WordOrder is the order a game draws words from its word list in
+type WordOrder string
+
+const (
+	// WordOrderInOrder serves the list's words in the order they were
+	// entered
+	WordOrderInOrder WordOrder = "in_order"
+	// WordOrderRandom serves the list's words in a random order
+	WordOrderRandom WordOrder = "random"
+)
+
+// Valid reports whether o is a known order. The empty order is valid and
+// means WordOrderRandom.
+func (o WordOrder) Valid() bool {
+	switch o {
+	case "", WordOrderInOrder, WordOrderRandom:
+		return true
+	}
+	return false
+}
+
+// orderBy is the ORDER BY of a draw from a word list in o
+func (o WordOrder) orderBy() string {
+	if o == WordOrderInOrder {
+		return "e.position"
+	}
+	return "RANDOM()"
+}
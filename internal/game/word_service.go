@@ -3,15 +3,23 @@ package game
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game/respell"
+	"big-spella-go/internal/game/stt"
+	"big-spella-go/internal/tracing"
 )
 
 type wordService struct {
@@ -28,32 +36,135 @@ func NewWordService(db *sqlx.DB, apiKey string) WordService {
 	}
 }
 
-func (s *wordService) GetRandomWord(ctx context.Context, level int, category *string) (*Word, error) {
+// ErrNoWordsAvailable means every word matching the game's category, or on
+// its word list, has already been served
+var ErrNoWordsAvailable = errors.New("no unserved words left for this game")
+
+// GetRandomWord picks a word at the query's level that the game hasn't
+// served yet, moving out to the nearest levels once that one is used up
+func (s *wordService) GetRandomWord(ctx context.Context, q WordQuery) (_ *Word, err error) {
+	ctx, span := tracing.Start(ctx, "words.GetRandomWord",
+		tracing.Int("word.level", q.Level), tracing.Int("words.excluded", len(q.Exclude)))
+	defer span.EndWith(&err)
+
+	// A nil array would be sent as NULL and exclude every word
+	exclude := q.Exclude
+	if exclude == nil {
+		exclude = []string{}
+	}
+
+	if q.WordList != nil {
+		return s.listWord(ctx, q, exclude)
+	}
+
+	level := "level"
+	if q.Empirical {
+		level = "COALESCE(empirical_level, level)"
+	}
+
 	query := `
 		SELECT * FROM words
-		WHERE level = $1`
-	args := []interface{}{level}
+		WHERE ` + level + ` = $1
+			AND NOT (id = ANY($2::uuid[]))
+			AND language = $3
+			AND status = 'published'` + q.Filter.clause()
 
-	if category != nil {
-		query += " AND category = $2"
-		args = append(args, *category)
-	}
+	for _, candidate := range adjacentLevels(q.Level) {
+		args := []interface{}{candidate, pq.Array(exclude), languageOr(q.Language)}
+		levelQuery := query
 
-	query += `
+		if q.Category != nil {
+			levelQuery += `
+			AND id IN (
+				SELECT wc.word_id
+				FROM word_categories wc
+				JOIN categories c ON c.id = wc.category_id
+				WHERE c.slug = $4)`
+			args = append(args, *q.Category)
+		}
+
+		levelQuery += `
 		ORDER BY RANDOM()
 		LIMIT 1`
 
+		word := &Word{}
+		err := s.db.GetContext(ctx, word, levelQuery, args...)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get random word: %w", err)
+		}
+
+		if word.Respelling == "" {
+			word.Respelling = respell.Respell(word.Pronunciation)
+		}
+
+		return word, nil
+	}
+
+	return nil, ErrNoWordsAvailable
+}
+
+// listWord picks the next word the game hasn't served from its word list.
+// Hosts chose the list's words, so they're served whatever their level and
+// whether or not they've been reviewed, though not once retired.
+func (s *wordService) listWord(ctx context.Context, q WordQuery, exclude []string) (*Word, error) {
 	word := &Word{}
-	err := s.db.GetContext(ctx, word, query, args...)
+	err := s.db.GetContext(ctx, word, `
+		SELECT words.* FROM word_list_entries e
+		JOIN words ON words.id = e.word_id
+		WHERE e.list_id = $1
+			AND NOT (words.id = ANY($2::uuid[]))
+			AND words.status <> 'retired'`+q.Filter.clause()+`
+		ORDER BY `+q.WordOrder.orderBy()+`
+		LIMIT 1`, *q.WordList, pq.Array(exclude))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoWordsAvailable
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get random word: %w", err)
+		return nil, fmt.Errorf("failed to get word from list: %w", err)
 	}
 
+	if word.Respelling == "" {
+		word.Respelling = respell.Respell(word.Pronunciation)
+	}
 	return word, nil
 }
 
+// adjacentLevels lists every word level ordered by distance from level,
+// trying the harder of two equally distant levels first
+func adjacentLevels(level int) []int {
+	levels := []int{}
+	if level >= MinWordLevel && level <= MaxWordLevel {
+		levels = append(levels, level)
+	}
+	for d := 1; d < MaxWordLevel-MinWordLevel+1; d++ {
+		if level+d >= MinWordLevel && level+d <= MaxWordLevel {
+			levels = append(levels, level+d)
+		}
+		if level-d >= MinWordLevel && level-d <= MaxWordLevel {
+			levels = append(levels, level-d)
+		}
+	}
+	return levels
+}
+
+// ValidateSpelling reports whether attempt is word or, leniently, one of
+// the other spellings accepted for it
 func (s *wordService) ValidateSpelling(ctx context.Context, word, attempt string) bool {
-	return strings.EqualFold(strings.TrimSpace(word), strings.TrimSpace(attempt))
+	if strings.EqualFold(strings.TrimSpace(word), strings.TrimSpace(attempt)) {
+		return true
+	}
+
+	w := &Word{Word: word}
+	if err := s.db.GetContext(ctx, &w.AcceptedSpellings, `
+		SELECT accepted_spellings FROM words
+		WHERE lower(word) = lower($1)
+		LIMIT 1`, strings.TrimSpace(word)); err != nil {
+		return false
+	}
+	return w.Accepts(attempt, SpellingLenient)
 }
 
 type TranscriptionRequest struct {
@@ -66,10 +177,36 @@ type TranscriptionRequest struct {
 }
 
 type TranscriptionResponse struct {
-	Text string `json:"text"`
+	Text     string `json:"text"`
+	Segments []struct {
+		AvgLogprob   float64 `json:"avg_logprob"`
+		NoSpeechProb float64 `json:"no_speech_prob"`
+	} `json:"segments"`
 }
 
-func (s *wordService) TranscribeVoice(ctx context.Context, voiceData []byte) (string, error) {
+// confidence estimates how likely the transcription is right from the mean
+// segment log probability, discounted by the chance there was no speech
+func (r TranscriptionResponse) confidence() float64 {
+	if len(r.Segments) == 0 {
+		return 0
+	}
+
+	var logprob, noSpeech float64
+	for _, seg := range r.Segments {
+		logprob += seg.AvgLogprob
+		noSpeech = math.Max(noSpeech, seg.NoSpeechProb)
+	}
+	return math.Exp(logprob/float64(len(r.Segments))) * (1 - noSpeech)
+}
+
+func (s *wordService) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	t, err := s.TranscribeWithConfidence(ctx, voiceData, language)
+	return t.Text, err
+}
+
+// TranscribeWithConfidence transcribes voiceData, spoken in language, and
+// reports how confident the recogniser was
+func (s *wordService) TranscribeWithConfidence(ctx context.Context, voiceData []byte, language string) (stt.Transcription, error) {
 	url := "https://api.openai.com/v1/audio/transcriptions"
 
 	// Create multipart form data
@@ -79,29 +216,30 @@ func (s *wordService) TranscribeVoice(ctx context.Context, voiceData []byte) (st
 	// Add the audio file
 	part, err := writer.CreateFormFile("file", "audio.wav")
 	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %w", err)
+		return stt.Transcription{}, fmt.Errorf("failed to create form file: %w", err)
 	}
 	_, err = io.Copy(part, bytes.NewReader(voiceData))
 	if err != nil {
-		return "", fmt.Errorf("failed to copy voice data: %w", err)
+		return stt.Transcription{}, fmt.Errorf("failed to copy voice data: %w", err)
 	}
 
 	// Add other fields
 	writer.WriteField("model", "whisper-1")
-	writer.WriteField("language", "en")
+	// Whisper takes ISO 639-1 codes, as words are tagged with
+	writer.WriteField("language", languageOr(language))
 	writer.WriteField("prompt", "This is a spelling bee game. The audio will contain a single word spelled out.")
-	writer.WriteField("response_format", "json")
+	writer.WriteField("response_format", "verbose_json")
 	writer.WriteField("temperature", "0.2")
 
 	err = writer.Close()
 	if err != nil {
-		return "", fmt.Errorf("failed to close writer: %w", err)
+		return stt.Transcription{}, fmt.Errorf("failed to close writer: %w", err)
 	}
 
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return stt.Transcription{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+s.apiKey)
@@ -110,19 +248,19 @@ func (s *wordService) TranscribeVoice(ctx context.Context, voiceData []byte) (st
 	// Send request
 	resp, err := s.apiClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return stt.Transcription{}, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return stt.Transcription{}, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Parse response
 	var result TranscriptionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return stt.Transcription{}, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Clean up the transcribed text
@@ -133,5 +271,39 @@ func (s *wordService) TranscribeVoice(ctx context.Context, voiceData []byte) (st
 	text = strings.ReplaceAll(text, "!", "")
 	text = strings.ReplaceAll(text, "?", "")
 
-	return text, nil
+	return stt.Transcription{Text: text, Confidence: result.confidence()}, nil
+}
+
+// Check reports whether OpenAI is up and the key can use Whisper
+func (s *wordService) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.openai.com/v1/models/whisper-1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// TranscribeWith has words transcribe voice attempts with transcriber, such
+// as a stt.Failover, and look words up as before
+func TranscribeWith(words WordService, transcriber stt.Transcriber) WordService {
+	return &transcribingWords{WordService: words, transcriber: transcriber}
+}
+
+type transcribingWords struct {
+	WordService
+	transcriber stt.Transcriber
+}
+
+func (w *transcribingWords) TranscribeVoice(ctx context.Context, voiceData []byte, language string) (string, error) {
+	return w.transcriber.TranscribeVoice(ctx, voiceData, language)
 }
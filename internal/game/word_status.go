@@ -0,0 +1,38 @@
+package game
+
+import (
+	"errors"
+	"slices"
+)
+
+// WordStatus is where a word is in curation. Words are imported or
+// enriched as pending, checked by an admin, then published to players, and
+// retired when they shouldn't be served any more. Only published words are
+// picked for games.
+type WordStatus string
+
+const (
+	WordStatusPending   WordStatus = "pending"
+	WordStatusReviewed  WordStatus = "reviewed"
+	WordStatusPublished WordStatus = "published"
+	WordStatusRetired   WordStatus = "retired"
+)
+
+var WordStatuses = []WordStatus{WordStatusPending, WordStatusReviewed, WordStatusPublished, WordStatusRetired}
+
+var ErrInvalidWordTransition = errors.New("word can't move to that status from its current one")
+
+// wordTransitions are the statuses each status can move to. A reviewed word
+// can be sent back for another look, and a retired one brought back through
+// review.
+var wordTransitions = map[WordStatus][]WordStatus{
+	WordStatusPending:   {WordStatusReviewed, WordStatusRetired},
+	WordStatusReviewed:  {WordStatusPublished, WordStatusPending, WordStatusRetired},
+	WordStatusPublished: {WordStatusRetired},
+	WordStatusRetired:   {WordStatusPending},
+}
+
+// CanMoveTo reports whether a word can move from s to status
+func (s WordStatus) CanMoveTo(status WordStatus) bool {
+	return slices.Contains(wordTransitions[s], status)
+}
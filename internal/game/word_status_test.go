@@ -0,0 +1,20 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordStatusTransitions(t *testing.T) {
+	assert.True(t, WordStatusPending.CanMoveTo(WordStatusReviewed))
+	assert.True(t, WordStatusReviewed.CanMoveTo(WordStatusPublished))
+	assert.True(t, WordStatusReviewed.CanMoveTo(WordStatusPending), "a reviewed word can be sent back")
+	assert.True(t, WordStatusPublished.CanMoveTo(WordStatusRetired))
+	assert.True(t, WordStatusRetired.CanMoveTo(WordStatusPending))
+
+	assert.False(t, WordStatusPending.CanMoveTo(WordStatusPublished), "words are reviewed before they're published")
+	assert.False(t, WordStatusRetired.CanMoveTo(WordStatusPublished), "retired words go through review again")
+	assert.False(t, WordStatusPublished.CanMoveTo(WordStatusPublished))
+	assert.False(t, WordStatus("draft").CanMoveTo(WordStatusReviewed))
+}
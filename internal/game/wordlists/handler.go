@@ -0,0 +1,286 @@
+package wordlists
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	// MaxWordRunes caps how long each word on a list can be
+	MaxWordRunes = 50
+	// MaxUploadBytes caps the size of an uploaded word list
+	MaxUploadBytes = 64 << 10
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type CreateRequest struct {
+	Name string `json:"name"`
+	// Language is the language the words are in, game.DefaultLanguage when
+	// empty
+	Language  string              `json:"language"`
+	Words     []string            `json:"words"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *CreateRequest) validate() {
+	checkName(&r.Validator, r.Name)
+	r.Validator.CheckField(r.Language == "" || game.IsSupportedLanguage(r.Language), "language", "Must be a supported language")
+	checkWords(&r.Validator, r.Words)
+}
+
+type RenameRequest struct {
+	Name      string              `json:"name"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *RenameRequest) validate() {
+	checkName(&r.Validator, r.Name)
+}
+
+type WordsRequest struct {
+	Words     []string            `json:"words"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *WordsRequest) validate() {
+	checkWords(&r.Validator, r.Words)
+}
+
+func checkName(v *validator.Validator, name string) {
+	v.CheckField(validator.NotBlank(name), "name", "Must be provided")
+	v.CheckField(validator.MaxRunes(name, 100), "name", "Must not be more than 100 characters")
+}
+
+func checkWords(v *validator.Validator, words []string) {
+	v.CheckField(len(words) > 0, "words", "Must contain at least one word")
+	v.CheckField(len(words) <= MaxWords, "words", "Must not contain more than 500 words")
+	v.CheckField(validator.NoDuplicates(lower(words)), "words", "Must not contain duplicates")
+	for _, word := range words {
+		if !validWord(word) {
+			v.AddFieldError("words", "Must only contain words of letters, hyphens and apostrophes, up to 50 characters")
+			break
+		}
+	}
+}
+
+// validWord reports whether word could be a dictionary word
+func validWord(word string) bool {
+	if !validator.NotBlank(word) || !validator.MaxRunes(word, MaxWordRunes) {
+		return false
+	}
+	return strings.IndexFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-' && r != '\'' && r != ' '
+	}) < 0
+}
+
+// List serves the caller's word lists
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"word_lists": lists})
+}
+
+// Get serves one of the caller's word lists with its words
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := listParams(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.Get(r.Context(), userID, listID)
+	if err != nil {
+		serviceError(w, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Create adds a word list for the caller from words entered by hand
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	language := req.Language
+	if language == "" {
+		language = game.DefaultLanguage
+	}
+	list, err := h.service.Create(r.Context(), userID, req.Name, language, req.Words)
+	if err != nil {
+		serviceError(w, err, &req.Validator)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+}
+
+// Rename changes the name of one of the caller's word lists
+func (h *Handler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := listParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req RenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	list, err := h.service.Update(r.Context(), userID, listID, req.Name, nil)
+	if err != nil {
+		serviceError(w, err, &req.Validator)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// SetWords replaces the words on one of the caller's word lists. They're
+// sent as JSON, or uploaded as a text/plain or text/csv file for ParseWords.
+func (h *Handler) SetWords(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := listParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req WordsRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/plain", "text/csv":
+		text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxUploadBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		req.Words = ParseWords(string(text))
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	list, err := h.service.Update(r.Context(), userID, listID, "", req.Words)
+	if err != nil {
+		serviceError(w, err, &req.Validator)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Delete removes one of the caller's word lists
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := listParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID, listID); err != nil {
+		serviceError(w, err, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listParams returns the caller and the list ID path parameter, responding
+// with 401 or 422 when either is missing or invalid
+func listParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", "", false
+	}
+
+	listID := httprouter.ParamsFromContext(r.Context()).ByName("listID")
+	var v validator.Validator
+	_, err := uuid.Parse(listID)
+	v.CheckField(err == nil, "list_id", "Must be a valid ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return "", "", false
+	}
+	return userID, listID, true
+}
+
+// serviceError responds to err from the service. Words the dictionary
+// doesn't know are reported against the words field of v.
+func serviceError(w http.ResponseWriter, err error, v *validator.Validator) {
+	switch {
+	case errors.Is(err, ErrListNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrUnknownWords) && v != nil:
+		v.AddFieldError("words", err.Error())
+		failedValidation(w, *v)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
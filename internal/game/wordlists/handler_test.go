@@ -0,0 +1,53 @@
+package wordlists
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestCreateRequestValidation(t *testing.T) {
+	req := CreateRequest{Name: "Week 3 spellings", Words: []string{"necessary", "mischievous", "o'clock"}}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = CreateRequest{Language: "tlh", Words: []string{"Rhythm", "rhythm"}}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "name")
+	assert.Contains(t, req.Validator.FieldErrors, "language")
+	assert.Contains(t, req.Validator.FieldErrors, "words")
+}
+
+func TestWordsRequestValidation(t *testing.T) {
+	for _, words := range [][]string{nil, {"cat", "c4t"}, {"cat", " "}, {strings.Repeat("a", MaxWordRunes+1)}} {
+		req := WordsRequest{Words: words}
+		req.validate()
+		assert.Contains(t, req.Validator.FieldErrors, "words", words)
+	}
+}
+
+func TestParseWords(t *testing.T) {
+	text := "# Week 3\nnecessary\r\n\n\"mischievous\", rhythm;\tqueue\n  ice cream  \n"
+	assert.Equal(t, []string{"necessary", "mischievous", "rhythm", "queue", "ice cream"}, ParseWords(text))
+	assert.Empty(t, ParseWords("\n # nothing here\n"))
+}
+
+func TestGetChecksListID(t *testing.T) {
+	h := NewHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/word-lists/x", nil)
+	ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "listID", Value: "x"}})
+	req = req.WithContext(auth.SetUserIDInContext(ctx, "user-1"))
+	rec := httptest.NewRecorder()
+
+	h.Get(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "list_id")
+}
@@ -0,0 +1,294 @@
+package wordlists
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/game"
+)
+
+// MaxWords caps how many words one list can hold
+const MaxWords = 500
+
+var (
+	ErrListNotFound = errors.New("word list not found")
+	ErrUnknownWords = errors.New("words not in the dictionary")
+)
+
+// Dictionary looks up the words hosts put on their lists
+type Dictionary interface {
+	GetWordInfo(ctx context.Context, word, language string) (*game.Word, error)
+}
+
+// List is a host's own list of words for their private games to draw from
+// instead of the word bank
+type List struct {
+	ID        string `json:"id" db:"id"`
+	OwnerID   string `json:"owner_id" db:"owner_id"`
+	Name      string `json:"name" db:"name"`
+	Language  string `json:"language" db:"language"`
+	WordCount int    `json:"word_count" db:"word_count"`
+	// Words are the list's words in the order they were entered. They're
+	// left out when lists are listed.
+	Words     []string  `json:"words,omitempty" db:"-"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Service struct {
+	db   *sqlx.DB
+	dict Dictionary
+}
+
+func NewService(db *sqlx.DB, dict Dictionary) *Service {
+	return &Service{db: db, dict: dict}
+}
+
+const listColumns = `l.id, l.owner_id, l.name, l.language, l.created_at, l.updated_at,
+			(SELECT COUNT(*) FROM word_list_entries e WHERE e.list_id = l.id) AS word_count`
+
+// List returns ownerID's word lists, most recently changed first
+func (s *Service) List(ctx context.Context, ownerID string) ([]List, error) {
+	lists := []List{}
+	if err := s.db.SelectContext(ctx, &lists, `
+		SELECT `+listColumns+`
+		FROM word_lists l
+		WHERE l.owner_id = $1
+		ORDER BY l.updated_at DESC`, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to list word lists: %w", err)
+	}
+	return lists, nil
+}
+
+// Get returns ownerID's word list with id and its words. Other users' lists
+// are not found.
+func (s *Service) Get(ctx context.Context, ownerID, id string) (*List, error) {
+	var list List
+	if err := s.db.GetContext(ctx, &list, `
+		SELECT `+listColumns+`
+		FROM word_lists l
+		WHERE l.id = $1 AND l.owner_id = $2`, id, ownerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrListNotFound
+		}
+		return nil, fmt.Errorf("failed to get word list: %w", err)
+	}
+
+	list.Words = []string{}
+	if err := s.db.SelectContext(ctx, &list.Words, `
+		SELECT w.word
+		FROM word_list_entries e
+		JOIN words w ON w.id = e.word_id
+		WHERE e.list_id = $1
+		ORDER BY e.position`, id); err != nil {
+		return nil, fmt.Errorf("failed to get word list words: %w", err)
+	}
+	return &list, nil
+}
+
+// Create adds a word list for ownerID holding words, in that order
+func (s *Service) Create(ctx context.Context, ownerID, name, language string, words []string) (*List, error) {
+	ids, err := s.wordIDs(ctx, words, language)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id string
+	if err := tx.GetContext(ctx, &id, `
+		INSERT INTO word_lists (owner_id, name, language)
+		VALUES ($1, $2, $3)
+		RETURNING id`, ownerID, name, language); err != nil {
+		return nil, fmt.Errorf("failed to create word list: %w", err)
+	}
+	if err := setEntries(ctx, tx, id, ids); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit word list: %w", err)
+	}
+
+	return s.Get(ctx, ownerID, id)
+}
+
+// Update renames ownerID's word list and, when words isn't nil, replaces
+// its words with them
+func (s *Service) Update(ctx context.Context, ownerID, id, name string, words []string) (*List, error) {
+	list, err := s.Get(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if words != nil {
+		if ids, err = s.wordIDs(ctx, words, list.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE word_lists
+		SET name = COALESCE(NULLIF($1, ''), name), updated_at = NOW()
+		WHERE id = $2`, name, id); err != nil {
+		return nil, fmt.Errorf("failed to update word list: %w", err)
+	}
+	if words != nil {
+		if err := setEntries(ctx, tx, id, ids); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit word list: %w", err)
+	}
+
+	return s.Get(ctx, ownerID, id)
+}
+
+// Delete removes ownerID's word list. Games already drawing from it run out
+// of words.
+func (s *Service) Delete(ctx context.Context, ownerID, id string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM word_lists WHERE id = $1 AND owner_id = $2`, id, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete word list: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrListNotFound
+	}
+	return nil
+}
+
+// setEntries replaces the words on list id with the words with ids, in
+// order
+func setEntries(ctx context.Context, tx *sqlx.Tx, id string, ids []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM word_list_entries WHERE list_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to clear word list: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO word_list_entries (list_id, word_id, position)
+		SELECT $1, w.id, w.position
+		FROM UNNEST($2::uuid[]) WITH ORDINALITY AS w(id, position)`,
+		id, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to add words to word list: %w", err)
+	}
+	return nil
+}
+
+// wordIDs returns the IDs of words in language, in order. Words the bank
+// doesn't have yet are looked up in the dictionary and added to it pending
+// review, and those the dictionary doesn't know fail the whole list with
+// ErrUnknownWords.
+func (s *Service) wordIDs(ctx context.Context, words []string, language string) ([]string, error) {
+	var known []struct {
+		ID   string `db:"id"`
+		Word string `db:"word"`
+	}
+	if err := s.db.SelectContext(ctx, &known, `
+		SELECT DISTINCT ON (lower(word)) id, lower(word) AS word
+		FROM words
+		WHERE lower(word) = ANY($1) AND language = $2
+		ORDER BY lower(word), status = 'published' DESC`,
+		pq.Array(lower(words)), language); err != nil {
+		return nil, fmt.Errorf("failed to look up words: %w", err)
+	}
+	ids := make(map[string]string, len(words))
+	for _, w := range known {
+		ids[w.Word] = w.ID
+	}
+
+	var unknown []string
+	for _, word := range words {
+		if ids[strings.ToLower(word)] != "" {
+			continue
+		}
+		info, err := s.dict.GetWordInfo(ctx, word, language)
+		if errors.Is(err, game.ErrWordNotFound) {
+			unknown = append(unknown, word)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up %q: %w", word, err)
+		}
+		id, err := s.addWord(ctx, word, language, info)
+		if err != nil {
+			return nil, err
+		}
+		ids[strings.ToLower(word)] = id
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWords, strings.Join(unknown, ", "))
+	}
+
+	list := make([]string, len(words))
+	for i, word := range words {
+		list[i] = ids[strings.ToLower(word)]
+	}
+	return list, nil
+}
+
+// addWord adds word to the bank from what the dictionary has on it, pending
+// review like any other new word, and returns its ID
+func (s *Service) addWord(ctx context.Context, word, language string, info *game.Word) (string, error) {
+	var id string
+	if err := s.db.GetContext(ctx, &id, `
+		INSERT INTO words (word, definition, example_sentence, etymology, part_of_speech,
+			pronunciation, respelling, audio_url, source, offensive, language)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (word, language) DO UPDATE SET updated_at = words.updated_at
+		RETURNING id`,
+		word, info.Definition, info.ExampleSentence, info.Etymology, info.PartOfSpeech,
+		info.Pronunciation, info.Respelling, info.AudioURL, info.Source, info.Offensive, language); err != nil {
+		return "", fmt.Errorf("failed to add %q to the word bank: %w", word, err)
+	}
+	return id, nil
+}
+
+func lower(words []string) []string {
+	lowered := make([]string, len(words))
+	for i, word := range words {
+		lowered[i] = strings.ToLower(word)
+	}
+	return lowered
+}
+
+// ParseWords splits an uploaded word list into its words. Words may be one
+// to a line or separated by commas, semicolons or tabs, as in a spreadsheet
+// exported as CSV; blank entries and lines starting with # are skipped.
+func ParseWords(text string) []string {
+	var words []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ';' || r == '\t'
+		}) {
+			if word := strings.TrimFunc(field, func(r rune) bool {
+				return unicode.IsSpace(r) || r == '"'
+			}); word != "" {
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
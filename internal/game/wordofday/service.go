@@ -0,0 +1,354 @@
+// Package wordofday picks a word for everyone each day, looks it up in the
+// dictionary and reads it out once, and sends it to the players who asked
+// for it in the morning
+package wordofday
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/respell"
+	"big-spella-go/internal/notifications"
+	"big-spella-go/internal/smtp"
+)
+
+const (
+	// Email is the template the word is emailed with
+	Email = "word_of_the_day.tmpl"
+
+	// DateLayout is how days are written, always in UTC
+	DateLayout = "2006-01-02"
+
+	// DefaultSendHour is the hour, in UTC, the day's word goes out at
+	DefaultSendHour = 8
+
+	// repeatAfterDays is how long a word has to wait to be picked again
+	repeatAfterDays = 365
+)
+
+var ErrNoWords = errors.New("there are no words to pick the word of the day from")
+
+// Dictionary enriches the day's word with whatever the words table is
+// missing
+type Dictionary interface {
+	GetWordInfo(ctx context.Context, word, language string) (*game.Word, error)
+}
+
+// AudioSource caches a word's pronunciation and points its AudioURL at it.
+// game.AudioCache implements it.
+type AudioSource interface {
+	EnsureWordAudio(ctx context.Context, word *game.Word) error
+}
+
+// WordOfTheDay is a day's word with everything the dictionary has on it
+type WordOfTheDay struct {
+	Date  string     `json:"date"`
+	Level int        `json:"level"`
+	Word  *game.Word `json:"word"`
+}
+
+// Subscription is how a player wants to be sent the word of the day. Both
+// are off until they ask for them.
+type Subscription struct {
+	Push  bool `json:"push" db:"word_of_the_day_push"`
+	Email bool `json:"email" db:"word_of_the_day_email"`
+}
+
+type ServiceOption func(*Service)
+
+// WithAudio caches each day's pronunciation through audio
+func WithAudio(audio AudioSource) ServiceOption {
+	return func(s *Service) {
+		s.audio = audio
+	}
+}
+
+// WithNotifier sends the morning push notification through notifier
+func WithNotifier(notifier notifications.Notifier) ServiceOption {
+	return func(s *Service) {
+		s.notifier = notifier
+	}
+}
+
+// WithEmail sends the morning email with send
+func WithEmail(send smtp.SendEmail) ServiceOption {
+	return func(s *Service) {
+		s.send = send
+	}
+}
+
+type Service struct {
+	db       *sqlx.DB
+	dict     Dictionary
+	audio    AudioSource
+	notifier notifications.Notifier
+	send     smtp.SendEmail
+	onError  func(error)
+	now      func() time.Time
+
+	mu    sync.Mutex
+	today *WordOfTheDay
+}
+
+// NewService reports failures that don't stop the day's word being served,
+// such as a failed dictionary lookup, to onError
+func NewService(db *sqlx.DB, dict Dictionary, onError func(error), opts ...ServiceOption) *Service {
+	s := &Service{db: db, dict: dict, onError: onError, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Today returns the day's word, picking and enriching it if nobody has
+// asked for it yet today
+func (s *Service) Today(ctx context.Context) (*WordOfTheDay, error) {
+	date := s.now().UTC().Format(DateLayout)
+
+	s.mu.Lock()
+	today := s.today
+	s.mu.Unlock()
+	if today != nil && today.Date == date {
+		return today, nil
+	}
+
+	today, err := s.forDate(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.today = today
+	s.mu.Unlock()
+	return today, nil
+}
+
+// forDate returns the word saved for date, or picks, enriches and saves one.
+// Two servers picking at once agree, as the pick is a hash of the day and
+// only the first save is kept.
+func (s *Service) forDate(ctx context.Context, date string) (*WordOfTheDay, error) {
+	saved, err := s.saved(ctx, date)
+	if err == nil {
+		return saved, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var picked struct {
+		game.Word
+		Level int `db:"level"`
+	}
+	if err := s.db.GetContext(ctx, &picked, `
+		SELECT id, word, definition, language, level,
+			COALESCE(example_sentence, '') AS example_sentence,
+			COALESCE(etymology, '') AS etymology,
+			COALESCE(part_of_speech, '') AS part_of_speech,
+			COALESCE(pronunciation, '') AS pronunciation,
+			COALESCE(respelling, '') AS respelling,
+			COALESCE(audio_url, '') AS audio_url
+		FROM words
+		WHERE language = $2 AND status = 'published' AND id NOT IN (
+			SELECT word_id FROM words_of_the_day
+			WHERE word_id IS NOT NULL AND date > $1::date - $3::int)
+		ORDER BY md5(id::text || $1), id
+		LIMIT 1`, date, game.DefaultLanguage, repeatAfterDays); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoWords
+		}
+		return nil, fmt.Errorf("failed to pick the word of the day: %w", err)
+	}
+
+	word := &picked.Word
+	s.enrich(ctx, word)
+
+	data, err := json.Marshal(word)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the word of the day: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO words_of_the_day (date, word_id, level, word)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (date) DO NOTHING`, date, word.ID, picked.Level, data); err != nil {
+		return nil, fmt.Errorf("failed to save the word of the day: %w", err)
+	}
+	return s.saved(ctx, date)
+}
+
+func (s *Service) saved(ctx context.Context, date string) (*WordOfTheDay, error) {
+	var row struct {
+		Level int    `db:"level"`
+		Word  []byte `db:"word"`
+	}
+	if err := s.db.GetContext(ctx, &row, `
+		SELECT level, word FROM words_of_the_day WHERE date = $1`, date); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get the word of the day: %w", err)
+	}
+
+	today := &WordOfTheDay{Date: date, Level: row.Level, Word: &game.Word{}}
+	if err := json.Unmarshal(row.Word, today.Word); err != nil {
+		return nil, fmt.Errorf("failed to decode the word of the day: %w", err)
+	}
+	return today, nil
+}
+
+// enrich fills in what word is missing from the dictionary and caches its
+// audio. A lookup that fails leaves the word as it is in the words table.
+func (s *Service) enrich(ctx context.Context, word *game.Word) {
+	if s.dict != nil {
+		info, err := s.dict.GetWordInfo(ctx, word.Word, word.Language)
+		if err != nil {
+			s.report(fmt.Errorf("failed to look up the word of the day %q: %w", word.Word, err))
+		} else {
+			fill(word, info)
+		}
+	}
+	if word.Respelling == "" && word.Language == game.DefaultLanguage {
+		word.Respelling = respell.Respell(word.Pronunciation)
+	}
+
+	if s.audio != nil {
+		if err := s.audio.EnsureWordAudio(ctx, word); err != nil {
+			s.report(fmt.Errorf("failed to cache the word of the day's audio: %w", err))
+		}
+	}
+}
+
+// fill copies into word the parts of info it doesn't have
+func fill(word, info *game.Word) {
+	for _, field := range []struct{ to, from *string }{
+		{&word.Definition, &info.Definition},
+		{&word.ExampleSentence, &info.ExampleSentence},
+		{&word.Etymology, &info.Etymology},
+		{&word.PartOfSpeech, &info.PartOfSpeech},
+		{&word.Pronunciation, &info.Pronunciation},
+		{&word.Respelling, &info.Respelling},
+		{&word.AudioURL, &info.AudioURL},
+	} {
+		if *field.to == "" {
+			*field.to = *field.from
+		}
+	}
+	if word.Source == "" {
+		word.Source = info.Source
+	}
+}
+
+func (s *Service) report(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// Subscription returns how userID wants to be sent the word of the day
+func (s *Service) Subscription(ctx context.Context, userID string) (*Subscription, error) {
+	sub := &Subscription{}
+	if err := s.db.GetContext(ctx, sub, `
+		SELECT
+			COALESCE((SELECT word_of_the_day_push FROM user_preferences WHERE user_id = $1), FALSE) AS word_of_the_day_push,
+			COALESCE((SELECT word_of_the_day_email FROM user_preferences WHERE user_id = $1), FALSE) AS word_of_the_day_email`,
+		userID); err != nil {
+		return nil, fmt.Errorf("failed to get word of the day subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (s *Service) Subscribe(ctx context.Context, userID string, sub Subscription) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_preferences (user_id, word_of_the_day_push, word_of_the_day_email)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET word_of_the_day_push = EXCLUDED.word_of_the_day_push,
+			word_of_the_day_email = EXCLUDED.word_of_the_day_email,
+			updated_at = NOW()`, userID, sub.Push, sub.Email); err != nil {
+		return fmt.Errorf("failed to update word of the day subscription: %w", err)
+	}
+	return nil
+}
+
+// SendToday sends the day's word to its subscribers once sendHour has come,
+// once a day however many servers try
+func (s *Service) SendToday(ctx context.Context, sendHour int) error {
+	now := s.now().UTC()
+	if now.Hour() < sendHour {
+		return nil
+	}
+
+	today, err := s.Today(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE words_of_the_day SET sent_at = NOW()
+		WHERE date = $1 AND sent_at IS NULL`, today.Date)
+	if err != nil {
+		return fmt.Errorf("failed to claim the word of the day: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	var subscribers []struct {
+		ID       string `db:"id"`
+		Email    string `db:"email"`
+		Username string `db:"username"`
+		Push     bool   `db:"word_of_the_day_push"`
+		Mail     bool   `db:"word_of_the_day_email"`
+	}
+	if err := s.db.SelectContext(ctx, &subscribers, `
+		SELECT u.id, u.email, u.username, p.word_of_the_day_push, p.word_of_the_day_email
+		FROM user_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.word_of_the_day_push OR p.word_of_the_day_email`); err != nil {
+		return fmt.Errorf("failed to get word of the day subscribers: %w", err)
+	}
+
+	var errs []error
+	for _, sub := range subscribers {
+		if sub.Push && s.notifier != nil {
+			s.notifier.Notify(ctx, sub.ID, notifications.WordOfTheDay(today.Date, today.Word.Word, today.Word.Definition))
+		}
+		if sub.Mail && s.send != nil {
+			if err := s.send(sub.Email, map[string]any{
+				"Username":   sub.Username,
+				"Word":       today.Word.Word,
+				"Definition": today.Word.Definition,
+				"Example":    today.Word.ExampleSentence,
+				"AudioURL":   today.Word.AudioURL,
+			}, Email); err != nil {
+				errs = append(errs, fmt.Errorf("failed to email the word of the day: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run sends the day's word every morning at sendHour, checking every
+// interval, until ctx is done
+func (s *Service) Run(ctx context.Context, interval time.Duration, sendHour int, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SendToday(ctx, sendHour); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
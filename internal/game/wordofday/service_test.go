@@ -0,0 +1,47 @@
+package wordofday
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/game"
+)
+
+func TestFillKeepsWhatTheWordHas(t *testing.T) {
+	word := &game.Word{Word: "rhythm", Definition: "a strong, regular repeated pattern"}
+	fill(word, &game.Word{
+		Definition:    "regular movement",
+		Etymology:     "from Greek rhuthmos",
+		Pronunciation: "ˈri-t͟həm",
+		AudioURL:      "https://example.com/rhythm.mp3",
+		Source:        "merriam_webster",
+	})
+
+	assert.Equal(t, "a strong, regular repeated pattern", word.Definition)
+	assert.Equal(t, "from Greek rhuthmos", word.Etymology)
+	assert.Equal(t, "ˈri-t͟həm", word.Pronunciation)
+	assert.Equal(t, "https://example.com/rhythm.mp3", word.AudioURL)
+	assert.Equal(t, "merriam_webster", word.Source)
+}
+
+func TestTodayIsCachedForTheDay(t *testing.T) {
+	now := time.Date(2026, 10, 15, 23, 0, 0, 0, time.UTC)
+	s := NewService(nil, nil, nil)
+	s.now = func() time.Time { return now }
+	s.today = &WordOfTheDay{Date: "2026-10-15", Word: &game.Word{Word: "rhythm"}}
+
+	today, err := s.Today(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "rhythm", today.Word.Word)
+}
+
+func TestNothingIsSentBeforeTheSendHour(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	s.now = func() time.Time { return time.Date(2026, 10, 15, 7, 59, 0, 0, time.UTC) }
+
+	assert.NoError(t, s.SendToday(context.Background(), DefaultSendHour))
+}
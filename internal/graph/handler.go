@@ -0,0 +1,97 @@
+// Package graph serves a GraphQL endpoint over games, profiles, leaderboards
+// and word history, so clients fetch only the fields they show instead of
+// whole REST resources
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/daily"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/profile"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/user"
+)
+
+//go:embed schema.graphql
+var schema string
+
+const (
+	// MaxDepth is how deeply queries may nest fields
+	MaxDepth = 8
+	// MaxPageSize is the most items a list field returns at once
+	MaxPageSize = 100
+)
+
+// Games are the live games queries read
+type Games interface {
+	GetGame(ctx context.Context, gameID string) (*game.Game, error)
+	ListGames(ctx context.Context, filter game.GameFilter) ([]game.LobbySummary, int, error)
+}
+
+// Profiles looks up players in batches
+type Profiles interface {
+	GetMany(ctx context.Context, userIDs []string) (map[string]*profile.Profile, error)
+	Usernames(ctx context.Context, userIDs []string) (map[string]string, error)
+}
+
+// History is players' game and word history
+type History interface {
+	ListGameHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]user.GameHistory, int, error)
+	ListWordHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]user.WordHistory, int, error)
+}
+
+// Seasons serves ranked seasons' leaderboards
+type Seasons interface {
+	Current(ctx context.Context) (*season.Season, error)
+	Get(ctx context.Context, seasonID string) (*season.Season, error)
+	Standings(ctx context.Context, seasonID string, limit, offset int) ([]season.Standing, int, error)
+}
+
+// Daily serves the daily challenge's leaderboards
+type Daily interface {
+	Today() string
+	Leaderboard(ctx context.Context, date string, level, limit int) ([]daily.Entry, error)
+}
+
+type Handler struct {
+	schema   *graphql.Schema
+	profiles Profiles
+}
+
+func NewHandler(games Games, profiles Profiles, history History, seasons Seasons, daily Daily) *Handler {
+	resolver := &Resolver{games: games, history: history, seasons: seasons, daily: daily}
+	return &Handler{
+		schema:   graphql.MustParseSchema(schema, resolver, graphql.MaxDepth(MaxDepth)),
+		profiles: profiles,
+	}
+}
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP runs a query. Each request gets its own loaders, so players
+// named across a query are looked up together, once.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(h.profiles))
+	result := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	if err := response.JSON(w, http.StatusOK, result); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
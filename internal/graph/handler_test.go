@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/daily"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/profile"
+	"big-spella-go/internal/user"
+)
+
+var (
+	hostID  = uuid.NewString()
+	guestID = uuid.NewString()
+	gameID  = uuid.NewString()
+)
+
+type fakeGames struct{}
+
+func (fakeGames) GetGame(_ context.Context, id string) (*game.Game, error) {
+	if id != gameID {
+		return nil, game.ErrGameNotFound
+	}
+	return &game.Game{
+		ID:     id,
+		HostID: hostID,
+		Status: game.GameStatusWaiting,
+		Players: []*game.Player{
+			{UserID: hostID, Score: 3},
+			{UserID: guestID, Score: 5},
+		},
+	}, nil
+}
+
+func (fakeGames) ListGames(context.Context, game.GameFilter) ([]game.LobbySummary, int, error) {
+	return nil, 0, nil
+}
+
+// fakeProfiles counts the lookups it's asked for
+type fakeProfiles struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (p *fakeProfiles) GetMany(_ context.Context, ids []string) (map[string]*profile.Profile, error) {
+	return map[string]*profile.Profile{}, nil
+}
+
+func (p *fakeProfiles) Usernames(_ context.Context, ids []string) (map[string]string, error) {
+	p.mu.Lock()
+	p.batches = append(p.batches, ids)
+	p.mu.Unlock()
+	names := map[string]string{}
+	for _, id := range ids {
+		names[id] = "player-" + id[:4]
+	}
+	return names, nil
+}
+
+type fakeHistory struct{}
+
+func (fakeHistory) ListGameHistory(context.Context, uuid.UUID, int, int) ([]user.GameHistory, int, error) {
+	return nil, 0, nil
+}
+
+func (fakeHistory) ListWordHistory(context.Context, uuid.UUID, int, int) ([]user.WordHistory, int, error) {
+	return []user.WordHistory{{Word: "rhythm", Status: "learning"}}, 1, nil
+}
+
+type fakeSeasons struct{}
+
+func (fakeSeasons) Current(context.Context) (*season.Season, error) {
+	return nil, season.ErrNoActiveSeason
+}
+
+func (fakeSeasons) Get(context.Context, string) (*season.Season, error) {
+	return nil, season.ErrSeasonNotFound
+}
+
+func (fakeSeasons) Standings(context.Context, string, int, int) ([]season.Standing, int, error) {
+	return nil, 0, nil
+}
+
+type fakeDaily struct{}
+
+func (fakeDaily) Today() string { return "2026-10-15" }
+
+func (fakeDaily) Leaderboard(context.Context, string, int, int) ([]daily.Entry, error) {
+	return nil, nil
+}
+
+type result struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, h *Handler, principal *auth.Principal, q string) result {
+	t.Helper()
+	body, _ := json.Marshal(Request{Query: q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	ctx := auth.SetPrincipalInContext(req.Context(), principal)
+	if principal.UserID != "" {
+		ctx = auth.SetUserIDInContext(ctx, principal.UserID)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req.WithContext(ctx))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return res
+}
+
+func TestPlayersAreLoadedTogether(t *testing.T) {
+	profiles := &fakeProfiles{}
+	h := NewHandler(fakeGames{}, profiles, fakeHistory{}, fakeSeasons{}, fakeDaily{})
+	player := &auth.Principal{UserID: hostID, Scopes: auth.UserScopes}
+
+	res := query(t, h, player, `{ game(id: "`+gameID+`") { host { username } players { score user { username } } } }`)
+	require.Empty(t, res.Errors)
+	assert.Contains(t, string(res.Data), `"username": "player-`+hostID[:4]+`"`)
+	assert.Contains(t, string(res.Data), `"username": "player-`+guestID[:4]+`"`)
+
+	require.Len(t, profiles.batches, 1, "the host and players are looked up in one batch")
+	assert.ElementsMatch(t, []string{hostID, guestID}, profiles.batches[0])
+
+	res = query(t, h, player, `{ game(id: "`+uuid.NewString()+`") { id } }`)
+	require.Empty(t, res.Errors)
+	assert.JSONEq(t, `{"game": null}`, string(res.Data))
+}
+
+func TestScopesAndValidation(t *testing.T) {
+	h := NewHandler(fakeGames{}, &fakeProfiles{}, fakeHistory{}, fakeSeasons{}, fakeDaily{})
+	player := &auth.Principal{UserID: hostID, Scopes: auth.UserScopes}
+
+	res := query(t, h, player, `{ me { words { total words { word } } } }`)
+	require.Empty(t, res.Errors)
+	assert.JSONEq(t, `{"me": {"words": {"total": 1, "words": [{"word": "rhythm"}]}}}`, string(res.Data))
+
+	res = query(t, h, player, `{ user(id: "`+guestID+`") { words { total } } }`)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, errOwnWordsOnly.Error(), res.Errors[0].Message)
+
+	res = query(t, h, player, `{ lobby(first: 500, status: "sleeping") { total } }`)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "INVALID_ARGUMENTS", res.Errors[0].Extensions["code"])
+	assert.Contains(t, res.Errors[0].Extensions["fields"], "first")
+	assert.Contains(t, res.Errors[0].Extensions["fields"], "status")
+
+	// API keys read leaderboards but not players
+	key := &auth.Principal{Scopes: []auth.Scope{auth.ScopeLeaderboardsRead}}
+	res = query(t, h, key, `{ dailyLeaderboard { rank } }`)
+	require.Empty(t, res.Errors)
+	res = query(t, h, key, `{ me { id } }`)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, auth.ErrInsufficientScope.Error(), res.Errors[0].Message)
+}
@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+
+	"big-spella-go/internal/profile"
+)
+
+// loaderWait is how long a loader gathers keys before looking them up, long
+// enough for the fields resolved alongside each other to join the batch
+const loaderWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch the lookups a query makes for the players it names
+type loaders struct {
+	profiles  *dataloader.Loader[string, *profile.Profile]
+	usernames *dataloader.Loader[string, string]
+}
+
+func newLoaders(profiles Profiles) *loaders {
+	return &loaders{
+		profiles: dataloader.NewBatchedLoader(batch(profiles.GetMany),
+			dataloader.WithWait[string, *profile.Profile](loaderWait),
+			dataloader.WithBatchCapacity[string, *profile.Profile](MaxPageSize)),
+		usernames: dataloader.NewBatchedLoader(batch(profiles.Usernames),
+			dataloader.WithWait[string, string](loaderWait),
+			dataloader.WithBatchCapacity[string, string](MaxPageSize)),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// batch adapts a lookup by many keys to a loader's batch function. Keys
+// the lookup doesn't find load as V's zero value.
+func batch[V any](lookup func(ctx context.Context, keys []string) (map[string]V, error)) dataloader.BatchFunc[string, V] {
+	return func(ctx context.Context, keys []string) []*dataloader.Result[V] {
+		found, err := lookup(ctx, keys)
+		results := make([]*dataloader.Result[V], len(keys))
+		for i, key := range keys {
+			results[i] = &dataloader.Result[V]{Data: found[key], Error: err}
+		}
+		return results
+	}
+}
@@ -0,0 +1,567 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/game/daily"
+	"big-spella-go/internal/game/ranking"
+	"big-spella-go/internal/game/season"
+	"big-spella-go/internal/profile"
+	"big-spella-go/internal/user"
+	"big-spella-go/internal/validator"
+)
+
+var (
+	errAuthRequired = errors.New("authentication required")
+	errOwnWordsOnly = errors.New("players can only read their own word history")
+)
+
+// lobbyStatuses are the statuses the lobby can be filtered by
+var lobbyStatuses = []string{
+	string(game.GameStatusWaiting), string(game.GameStatusPlaying), string(game.GameStatusActive),
+	string(game.GameStatusPaused), string(game.GameStatusFinished), string(game.GameStatusCancelled),
+}
+
+// invalidArgs reports the arguments a field was given that failed
+// validation, listing them by name in the error's extensions
+type invalidArgs struct {
+	fields map[string]string
+}
+
+func (e *invalidArgs) Error() string {
+	return "failed validation"
+}
+
+func (e *invalidArgs) Extensions() map[string]any {
+	return map[string]any{"code": "INVALID_ARGUMENTS", "fields": e.fields}
+}
+
+func checkArgs(v validator.Validator) error {
+	if v.HasErrors() {
+		return &invalidArgs{fields: v.FieldErrors}
+	}
+	return nil
+}
+
+// requireScope checks the caller holds one of scopes, as the routes of the
+// REST API the fields mirror do
+func requireScope(ctx context.Context, scopes ...auth.Scope) error {
+	principal := auth.GetPrincipal(ctx)
+	if principal == nil {
+		return errAuthRequired
+	}
+	for _, scope := range scopes {
+		if principal.HasScope(scope) {
+			return nil
+		}
+	}
+	return auth.ErrInsufficientScope
+}
+
+type pageArgs struct {
+	First  int32
+	Offset int32
+}
+
+func (a pageArgs) check(v *validator.Validator) {
+	v.CheckField(validator.Between(int(a.First), 1, MaxPageSize), "first", "Must be between 1 and 100")
+	v.CheckField(a.Offset >= 0, "offset", "Must not be negative")
+}
+
+// Resolver answers the schema's queries
+type Resolver struct {
+	games   Games
+	history History
+	seasons Seasons
+	daily   Daily
+}
+
+func (r *Resolver) Me(ctx context.Context) (*userResolver, error) {
+	if err := requireScope(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	userID := auth.GetUserIDFromContext(ctx)
+	if userID == "" {
+		return nil, nil
+	}
+	return r.user(userID, ""), nil
+}
+
+func (r *Resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	if err := requireScope(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	var v validator.Validator
+	_, err := uuid.Parse(string(args.ID))
+	v.CheckField(err == nil, "id", "Must be a valid user ID")
+	if err := checkArgs(v); err != nil {
+		return nil, err
+	}
+
+	username, err := loadersFrom(ctx).usernames.Load(ctx, string(args.ID))()
+	if err != nil || username == "" {
+		return nil, err
+	}
+	return r.user(string(args.ID), username), nil
+}
+
+func (r *Resolver) Game(ctx context.Context, args struct{ ID graphql.ID }) (*gameResolver, error) {
+	if err := requireScope(ctx, auth.ScopeGamesRead); err != nil {
+		return nil, err
+	}
+	var v validator.Validator
+	_, err := uuid.Parse(string(args.ID))
+	v.CheckField(err == nil, "id", "Must be a valid game ID")
+	if err := checkArgs(v); err != nil {
+		return nil, err
+	}
+
+	g, err := r.games.GetGame(game.ReadOnly(ctx), string(args.ID))
+	if err != nil {
+		if errors.Is(err, game.ErrGameNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &gameResolver{r: r, view: game.ViewFor(g, auth.GetUserIDFromContext(ctx))}, nil
+}
+
+func (r *Resolver) Lobby(ctx context.Context, args struct {
+	Status *string
+	pageArgs
+}) (*lobbyPageResolver, error) {
+	if err := requireScope(ctx, auth.ScopeGamesRead); err != nil {
+		return nil, err
+	}
+	var v validator.Validator
+	args.check(&v)
+	v.CheckField(args.Status == nil || validator.In(*args.Status, lobbyStatuses...), "status", "Must be a game status")
+	if err := checkArgs(v); err != nil {
+		return nil, err
+	}
+
+	filter := game.NewGameFilter()
+	filter.Limit, filter.Offset = int(args.First), int(args.Offset)
+	if args.Status != nil {
+		status := game.GameStatus(*args.Status)
+		filter.Status = &status
+	}
+	games, total, err := r.games.ListGames(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &lobbyPageResolver{total: int32(total)}
+	for _, g := range games {
+		page.games = append(page.games, &lobbyGameResolver{r: r, game: g})
+	}
+	return page, nil
+}
+
+func (r *Resolver) SeasonLeaderboard(ctx context.Context, args struct {
+	SeasonID *graphql.ID
+	pageArgs
+}) (*seasonLeaderboardResolver, error) {
+	if err := requireScope(ctx, auth.ScopeGamesRead, auth.ScopeLeaderboardsRead); err != nil {
+		return nil, err
+	}
+	var v validator.Validator
+	args.check(&v)
+	if args.SeasonID != nil {
+		_, err := uuid.Parse(string(*args.SeasonID))
+		v.CheckField(err == nil, "seasonID", "Must be a valid season ID")
+	}
+	if err := checkArgs(v); err != nil {
+		return nil, err
+	}
+
+	var s *season.Season
+	var err error
+	if args.SeasonID != nil {
+		s, err = r.seasons.Get(ctx, string(*args.SeasonID))
+	} else {
+		s, err = r.seasons.Current(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	standings, total, err := r.seasons.Standings(ctx, s.ID, int(args.First), int(args.Offset))
+	if err != nil {
+		return nil, err
+	}
+	board := &seasonLeaderboardResolver{season: s, total: int32(total)}
+	for _, standing := range standings {
+		board.standings = append(board.standings, &standingResolver{r: r, standing: standing})
+	}
+	return board, nil
+}
+
+func (r *Resolver) DailyLeaderboard(ctx context.Context, args struct {
+	Date  *string
+	Level int32
+	First int32
+}) ([]*dailyEntryResolver, error) {
+	if err := requireScope(ctx, auth.ScopeGamesRead, auth.ScopeLeaderboardsRead); err != nil {
+		return nil, err
+	}
+	date := r.daily.Today()
+	if args.Date != nil {
+		date = *args.Date
+	}
+	var v validator.Validator
+	_, err := time.Parse(daily.DateLayout, date)
+	v.CheckField(err == nil, "date", "Must be a date like 2006-01-02")
+	v.CheckField(validator.Between(int(args.Level), game.MinWordLevel, game.MaxWordLevel), "level", "Must be between 1 and 10")
+	v.CheckField(validator.Between(int(args.First), 1, daily.MaxLeaderboardSize), "first", "Must be between 1 and 100")
+	if err := checkArgs(v); err != nil {
+		return nil, err
+	}
+
+	entries, err := r.daily.Leaderboard(ctx, date, int(args.Level), int(args.First))
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*dailyEntryResolver, len(entries))
+	for i, entry := range entries {
+		resolvers[i] = &dailyEntryResolver{r: r, entry: entry}
+	}
+	return resolvers, nil
+}
+
+// user resolves the player userID, whose username is loaded with the rest
+// of the query's players unless it's already known
+func (r *Resolver) user(userID, username string) *userResolver {
+	return &userResolver{r: r, id: userID, username: username}
+}
+
+type userResolver struct {
+	r        *Resolver
+	id       string
+	username string
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(u.id)
+}
+
+func (u *userResolver) Username(ctx context.Context) (string, error) {
+	if u.username != "" {
+		return u.username, nil
+	}
+	return loadersFrom(ctx).usernames.Load(ctx, u.id)()
+}
+
+func (u *userResolver) Profile(ctx context.Context) (*profileResolver, error) {
+	if err := requireScope(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	p, err := loadersFrom(ctx).profiles.Load(ctx, u.id)()
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return &profileResolver{p}, nil
+}
+
+func (u *userResolver) Games(ctx context.Context, args pageArgs) (*gameHistoryPageResolver, error) {
+	if err := requireScope(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	var v validator.Validator
+	if args.check(&v); v.HasErrors() {
+		return nil, checkArgs(v)
+	}
+
+	history, total, err := u.r.history.ListGameHistory(ctx, uuid.MustParse(u.id), int(args.First), int(args.Offset))
+	if err != nil {
+		return nil, err
+	}
+	page := &gameHistoryPageResolver{total: int32(total)}
+	for _, h := range history {
+		page.games = append(page.games, &gameHistoryResolver{h})
+	}
+	return page, nil
+}
+
+func (u *userResolver) Words(ctx context.Context, args pageArgs) (*wordHistoryPageResolver, error) {
+	if err := requireScope(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	if auth.GetUserIDFromContext(ctx) != u.id {
+		return nil, errOwnWordsOnly
+	}
+	var v validator.Validator
+	if args.check(&v); v.HasErrors() {
+		return nil, checkArgs(v)
+	}
+
+	history, total, err := u.r.history.ListWordHistory(ctx, uuid.MustParse(u.id), int(args.First), int(args.Offset))
+	if err != nil {
+		return nil, err
+	}
+	page := &wordHistoryPageResolver{total: int32(total)}
+	for _, h := range history {
+		page.words = append(page.words, &wordHistoryResolver{h})
+	}
+	return page, nil
+}
+
+type profileResolver struct {
+	p *profile.Profile
+}
+
+func (p *profileResolver) Bio() string             { return p.p.Bio }
+func (p *profileResolver) AvatarURL() string       { return p.p.ProfileImageURL }
+func (p *profileResolver) CurrentStreak() int32    { return int32(p.p.CurrentStreak) }
+func (p *profileResolver) LongestStreak() int32    { return int32(p.p.LongestStreak) }
+func (p *profileResolver) CreatedAt() graphql.Time { return graphql.Time{Time: p.p.CreatedAt} }
+
+func (p *profileResolver) Placement() *placementResolver {
+	return &placementResolver{ranking.NewPlacement(p.p.RankedGames)}
+}
+
+type placementResolver struct {
+	p ranking.Placement
+}
+
+func (p *placementResolver) GamesPlayed() int32 { return int32(p.p.GamesPlayed) }
+func (p *placementResolver) Games() int32       { return int32(p.p.Games) }
+func (p *placementResolver) Complete() bool     { return p.p.Complete }
+
+type gameResolver struct {
+	r    *Resolver
+	view game.GameView
+}
+
+func (g *gameResolver) ID() graphql.ID      { return graphql.ID(g.view.ID) }
+func (g *gameResolver) Type() string        { return string(g.view.Type) }
+func (g *gameResolver) Status() string      { return string(g.view.Status) }
+func (g *gameResolver) Mode() string        { return g.view.Mode }
+func (g *gameResolver) Round() int32        { return int32(g.view.Round) }
+func (g *gameResolver) Version() int32      { return int32(g.view.Version) }
+func (g *gameResolver) Host() *userResolver { return g.r.user(g.view.HostID, "") }
+
+func (g *gameResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: g.view.UpdatedAt}
+}
+
+func (g *gameResolver) MaxRounds() *int32 {
+	if g.view.MaxRounds == nil {
+		return nil
+	}
+	n := int32(*g.view.MaxRounds)
+	return &n
+}
+
+func (g *gameResolver) CurrentTurn() *graphql.ID {
+	if g.view.CurrentTurn == nil {
+		return nil
+	}
+	id := graphql.ID(*g.view.CurrentTurn)
+	return &id
+}
+
+func (g *gameResolver) InviteCode() *string {
+	if g.view.InviteCode == "" {
+		return nil
+	}
+	return &g.view.InviteCode
+}
+
+func (g *gameResolver) Settings() *settingsResolver {
+	return &settingsResolver{g.view.Settings}
+}
+
+func (g *gameResolver) CurrentWord() *wordResolver {
+	if g.view.CurrentWord == nil {
+		return nil
+	}
+	return &wordResolver{g.view.CurrentWord}
+}
+
+func (g *gameResolver) Players() []*playerResolver {
+	players := make([]*playerResolver, len(g.view.Players))
+	for i, p := range g.view.Players {
+		players[i] = &playerResolver{r: g.r, p: p}
+	}
+	return players
+}
+
+type settingsResolver struct {
+	s game.GameSettings
+}
+
+func (s *settingsResolver) Mode() string      { return string(s.s.Mode) }
+func (s *settingsResolver) MaxRounds() int32  { return int32(s.s.MaxRounds) }
+func (s *settingsResolver) MinPlayers() int32 { return int32(s.s.MinPlayers) }
+func (s *settingsResolver) MaxPlayers() int32 { return int32(s.s.MaxPlayers) }
+func (s *settingsResolver) WordLevel() int32  { return int32(s.s.WordLevel) }
+func (s *settingsResolver) IsRanked() bool    { return s.s.IsRanked }
+func (s *settingsResolver) IsPrivate() bool   { return s.s.IsPrivate }
+func (s *settingsResolver) Category() *string { return s.s.Category }
+
+func (s *settingsResolver) Language() string {
+	if s.s.Language == "" {
+		return game.DefaultLanguage
+	}
+	return s.s.Language
+}
+
+type playerResolver struct {
+	r *Resolver
+	p *game.Player
+}
+
+func (p *playerResolver) User() *userResolver { return p.r.user(p.p.UserID, "") }
+func (p *playerResolver) Score() int32        { return int32(p.p.Score) }
+func (p *playerResolver) Status() string      { return p.p.Status }
+func (p *playerResolver) IsBot() bool         { return p.p.IsBot }
+func (p *playerResolver) Attempts() int32     { return int32(p.p.Attempts) }
+func (p *playerResolver) Correct() int32      { return int32(p.p.Correct) }
+
+func (p *playerResolver) Team() *int32 {
+	if p.p.Team == nil {
+		return nil
+	}
+	team := int32(*p.p.Team)
+	return &team
+}
+
+type wordResolver struct {
+	w *game.Word
+}
+
+func (w *wordResolver) ID() graphql.ID        { return graphql.ID(w.w.ID) }
+func (w *wordResolver) Word() string          { return w.w.Word }
+func (w *wordResolver) Definition() string    { return w.w.Definition }
+func (w *wordResolver) PartOfSpeech() string  { return w.w.PartOfSpeech }
+func (w *wordResolver) Pronunciation() string { return w.w.Pronunciation }
+
+type lobbyPageResolver struct {
+	games []*lobbyGameResolver
+	total int32
+}
+
+func (p *lobbyPageResolver) Games() []*lobbyGameResolver { return p.games }
+func (p *lobbyPageResolver) Total() int32                { return p.total }
+
+type lobbyGameResolver struct {
+	r    *Resolver
+	game game.LobbySummary
+}
+
+func (g *lobbyGameResolver) ID() graphql.ID      { return graphql.ID(g.game.ID) }
+func (g *lobbyGameResolver) Type() string        { return string(g.game.Type) }
+func (g *lobbyGameResolver) Status() string      { return string(g.game.Status) }
+func (g *lobbyGameResolver) Host() *userResolver { return g.r.user(g.game.HostID, g.game.HostName) }
+func (g *lobbyGameResolver) PlayerCount() int32  { return int32(g.game.PlayerCount) }
+
+func (g *lobbyGameResolver) Settings() *settingsResolver {
+	return &settingsResolver{g.game.Settings}
+}
+
+func (g *lobbyGameResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: g.game.CreatedAt}
+}
+
+type gameHistoryPageResolver struct {
+	games []*gameHistoryResolver
+	total int32
+}
+
+func (p *gameHistoryPageResolver) Games() []*gameHistoryResolver { return p.games }
+func (p *gameHistoryPageResolver) Total() int32                  { return p.total }
+
+type gameHistoryResolver struct {
+	h user.GameHistory
+}
+
+func (h *gameHistoryResolver) GameID() graphql.ID     { return graphql.ID(h.h.GameID.String()) }
+func (h *gameHistoryResolver) GameType() string       { return h.h.GameType }
+func (h *gameHistoryResolver) Score() int32           { return int32(h.h.Score) }
+func (h *gameHistoryResolver) Position() int32        { return int32(h.h.Position) }
+func (h *gameHistoryResolver) WordsSpelled() []string { return h.h.WordsSpelled }
+func (h *gameHistoryResolver) Duration() int32        { return int32(h.h.Duration) }
+func (h *gameHistoryResolver) PlayedAt() graphql.Time { return graphql.Time{Time: h.h.CreatedAt} }
+
+type wordHistoryPageResolver struct {
+	words []*wordHistoryResolver
+	total int32
+}
+
+func (p *wordHistoryPageResolver) Words() []*wordHistoryResolver { return p.words }
+func (p *wordHistoryPageResolver) Total() int32                  { return p.total }
+
+type wordHistoryResolver struct {
+	h user.WordHistory
+}
+
+func (h *wordHistoryResolver) WordID() graphql.ID           { return graphql.ID(h.h.WordID.String()) }
+func (h *wordHistoryResolver) Word() string                 { return h.h.Word }
+func (h *wordHistoryResolver) Definition() string           { return h.h.Definition }
+func (h *wordHistoryResolver) Level() int32                 { return int32(h.h.Level) }
+func (h *wordHistoryResolver) Status() string               { return h.h.Status }
+func (h *wordHistoryResolver) CorrectAttempts() int32       { return int32(h.h.CorrectAttempts) }
+func (h *wordHistoryResolver) IncorrectAttempts() int32     { return int32(h.h.IncorrectAttempts) }
+func (h *wordHistoryResolver) LastAttemptAt() *graphql.Time { return optionalTime(h.h.LastAttemptAt) }
+func (h *wordHistoryResolver) NextReviewAt() *graphql.Time  { return optionalTime(h.h.NextReviewAt) }
+
+func optionalTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+type seasonLeaderboardResolver struct {
+	season    *season.Season
+	standings []*standingResolver
+	total     int32
+}
+
+func (b *seasonLeaderboardResolver) Season() *seasonResolver        { return &seasonResolver{b.season} }
+func (b *seasonLeaderboardResolver) Standings() []*standingResolver { return b.standings }
+func (b *seasonLeaderboardResolver) Total() int32                   { return b.total }
+
+type seasonResolver struct {
+	s *season.Season
+}
+
+func (s *seasonResolver) ID() graphql.ID         { return graphql.ID(s.s.ID) }
+func (s *seasonResolver) Name() string           { return s.s.Name }
+func (s *seasonResolver) StartsAt() graphql.Time { return graphql.Time{Time: s.s.StartsAt} }
+func (s *seasonResolver) EndsAt() graphql.Time   { return graphql.Time{Time: s.s.EndsAt} }
+func (s *seasonResolver) Finalized() bool        { return s.s.FinalizedAt != nil }
+
+type standingResolver struct {
+	r        *Resolver
+	standing season.Standing
+}
+
+func (s *standingResolver) Placement() int32 { return int32(s.standing.Placement) }
+func (s *standingResolver) User() *userResolver {
+	return s.r.user(s.standing.UserID, s.standing.Username)
+}
+func (s *standingResolver) Points() int32      { return int32(s.standing.Points) }
+func (s *standingResolver) RankColor() string  { return s.standing.RankColor }
+func (s *standingResolver) GamesPlayed() int32 { return int32(s.standing.GamesPlayed) }
+func (s *standingResolver) GamesWon() int32    { return int32(s.standing.GamesWon) }
+
+type dailyEntryResolver struct {
+	r     *Resolver
+	entry daily.Entry
+}
+
+func (e *dailyEntryResolver) Rank() int32         { return int32(e.entry.Rank) }
+func (e *dailyEntryResolver) User() *userResolver { return e.r.user(e.entry.UserID, e.entry.Username) }
+func (e *dailyEntryResolver) Score() int32        { return int32(e.entry.Score) }
+func (e *dailyEntryResolver) Correct() int32      { return int32(e.entry.Correct) }
+func (e *dailyEntryResolver) CompletedAt() graphql.Time {
+	return graphql.Time{Time: e.entry.CompletedAt}
+}
@@ -0,0 +1,218 @@
+// Package idempotency makes retried requests safe. A client sending the same
+// Idempotency-Key again gets the response to its first request replayed
+// instead of the request being served twice.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/infrastructure/redis"
+)
+
+const (
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from an earlier request
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultTTL is how long a key is remembered
+	DefaultTTL = 24 * time.Hour
+	// MaxKeyLength bounds the keys clients may send
+	MaxKeyLength = 255
+	// MaxBodyBytes bounds the requests read to fingerprint them, which
+	// leaves room for voice attempts
+	MaxBodyBytes = 10 << 20
+
+	// pendingTTL is how long a key stays claimed by a request that never
+	// finishes, such as when the server dies serving it
+	pendingTTL = time.Minute
+)
+
+// Redis is the part of the Redis client that keys are kept with
+type Redis interface {
+	Do(ctx context.Context, args ...string) (any, error)
+	String(ctx context.Context, args ...string) (string, error)
+}
+
+// record is what is kept under a key: the fingerprint of the request that
+// claimed it and, once that request is served, its response
+type record struct {
+	Hash    string      `json:"hash"`
+	Done    bool        `json:"done"`
+	Status  int         `json:"status,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    []byte      `json:"body,omitempty"`
+}
+
+// Store remembers the responses to requests sent with an Idempotency-Key
+type Store struct {
+	redis   Redis
+	ttl     time.Duration
+	onError func(error)
+}
+
+// NewStore remembers responses for ttl. Redis failures are passed to
+// onError and the request is served as if it had no key.
+func NewStore(client Redis, ttl time.Duration, onError func(error)) *Store {
+	return &Store{redis: client, ttl: ttl, onError: onError}
+}
+
+// Middleware serves the first request with each caller's key, and replays
+// its response to later requests with that key. A key reused for a
+// different request is rejected, as is one whose first request is still
+// being served. Server errors aren't remembered, so they can be retried.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		userID := auth.GetUserIDFromContext(r.Context())
+		if key == "" || userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength {
+			http.Error(w, fmt.Sprintf("%s must not be more than %d characters", Header, MaxKeyLength), http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		redisKey := "idempotency:" + userID + ":" + key
+		hash := fingerprint(r, body)
+
+		claimed, err := s.claim(ctx, redisKey, hash)
+		if err != nil {
+			s.onError(err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !claimed {
+			s.replay(w, r, redisKey, hash, next)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The request is done whether or not the client is still there
+		ctx = context.WithoutCancel(ctx)
+		if rec.status >= http.StatusInternalServerError {
+			if _, err := s.redis.Do(ctx, "DEL", redisKey); err != nil {
+				s.onError(fmt.Errorf("failed to release idempotency key: %w", err))
+			}
+			return
+		}
+		if err := s.save(ctx, redisKey, record{
+			Hash:    hash,
+			Done:    true,
+			Status:  rec.status,
+			Headers: rec.Header().Clone(),
+			Body:    rec.body.Bytes(),
+		}); err != nil {
+			s.onError(err)
+		}
+	})
+}
+
+// claim takes a key for a request with hash, reporting false when an
+// earlier request already has it
+func (s *Store) claim(ctx context.Context, redisKey, hash string) (bool, error) {
+	data, err := json.Marshal(record{Hash: hash})
+	if err != nil {
+		return false, err
+	}
+	reply, err := s.redis.Do(ctx, "SET", redisKey, string(data), "NX", "PX", strconv.FormatInt(pendingTTL.Milliseconds(), 10))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return reply != nil, nil
+}
+
+func (s *Store) save(ctx context.Context, redisKey string, rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+	if _, err := s.redis.Do(ctx, "SET", redisKey, string(data), "PX", strconv.FormatInt(s.ttl.Milliseconds(), 10)); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// replay answers a request whose key was already claimed
+func (s *Store) replay(w http.ResponseWriter, r *http.Request, redisKey, hash string, next http.Handler) {
+	data, err := s.redis.String(r.Context(), "GET", redisKey)
+	if errors.Is(err, redis.ErrNil) {
+		// Released after a server error between the claim and now
+		next.ServeHTTP(w, r)
+		return
+	}
+	if err != nil {
+		s.onError(fmt.Errorf("failed to get idempotent response: %w", err))
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	var rec record
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		s.onError(fmt.Errorf("failed to decode idempotent response: %w", err))
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case rec.Hash != hash:
+		http.Error(w, fmt.Sprintf("%s was already used for a different request", Header), http.StatusUnprocessableEntity)
+	case !rec.Done:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, fmt.Sprintf("a request with this %s is still being served", Header), http.StatusConflict)
+	default:
+		for name, values := range rec.Headers {
+			w.Header()[name] = values
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(rec.Status)
+		w.Write(rec.Body)
+	}
+}
+
+// fingerprint identifies a request by its method, path and body, so a key
+// can't be reused for a different one
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes a response through while keeping a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
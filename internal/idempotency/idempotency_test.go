@@ -0,0 +1,172 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/infrastructure/redis"
+)
+
+// fakeRedis understands the SET, GET and DEL commands the store sends
+type fakeRedis struct {
+	values map[string]string
+	err    error
+}
+
+func (f *fakeRedis) Do(_ context.Context, args ...string) (any, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	switch args[0] {
+	case "SET":
+		nx := len(args) > 3 && args[3] == "NX"
+		if _, ok := f.values[args[1]]; ok && nx {
+			return nil, nil
+		}
+		f.values[args[1]] = args[2]
+		return "OK", nil
+	case "DEL":
+		delete(f.values, args[1])
+		return int64(1), nil
+	}
+	return nil, errors.New("unexpected command " + args[0])
+}
+
+func (f *fakeRedis) String(_ context.Context, args ...string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	v, ok := f.values[args[1]]
+	if !ok {
+		return "", redis.ErrNil
+	}
+	return v, nil
+}
+
+func newTestStore() (*Store, *fakeRedis, *[]error) {
+	client := &fakeRedis{values: map[string]string{}}
+	var errs []error
+	return NewStore(client, time.Hour, func(err error) { errs = append(errs, err) }), client, &errs
+}
+
+// counter creates a new resource each time it is served
+func counter(served *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*served++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"n":` + strconv.Itoa(*served) + `}`))
+	})
+}
+
+func send(h http.Handler, userID, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	req = req.WithContext(auth.SetUserIDInContext(req.Context(), userID))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRetriesAreReplayed(t *testing.T) {
+	store, _, errs := newTestStore()
+	served := 0
+	h := store.Middleware(counter(&served, http.StatusCreated))
+
+	first := send(h, "user-1", "key-1", `{"type":"quick"}`)
+	retry := send(h, "user-1", "key-1", `{"type":"quick"}`)
+
+	assert.Equal(t, 1, served)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+	assert.Empty(t, first.Header().Get(ReplayedHeader))
+	assert.Empty(t, *errs)
+}
+
+func TestKeysBelongToEachCaller(t *testing.T) {
+	store, _, _ := newTestStore()
+	served := 0
+	h := store.Middleware(counter(&served, http.StatusCreated))
+
+	send(h, "user-1", "key-1", `{}`)
+	send(h, "user-2", "key-1", `{}`)
+	send(h, "user-1", "", `{}`)
+	send(h, "user-1", "", `{}`)
+	assert.Equal(t, 4, served)
+}
+
+func TestReusingAKeyForAnotherRequest(t *testing.T) {
+	store, _, _ := newTestStore()
+	served := 0
+	h := store.Middleware(counter(&served, http.StatusCreated))
+
+	send(h, "user-1", "key-1", `{"type":"quick"}`)
+	rec := send(h, "user-1", "key-1", `{"type":"ranked"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 1, served)
+}
+
+func TestRequestsStillBeingServed(t *testing.T) {
+	store, client, _ := newTestStore()
+	served := 0
+	var retry *httptest.ResponseRecorder
+	var h http.Handler
+	h = store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		if served == 1 {
+			retry = send(h, "user-1", "key-1", `{}`)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send(h, "user-1", "key-1", `{}`)
+	assert.Equal(t, 1, served)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Len(t, client.values, 1)
+}
+
+func TestServerErrorsCanBeRetried(t *testing.T) {
+	store, client, _ := newTestStore()
+	served := 0
+	h := store.Middleware(counter(&served, http.StatusInternalServerError))
+
+	send(h, "user-1", "key-1", `{}`)
+	send(h, "user-1", "key-1", `{}`)
+	assert.Equal(t, 2, served)
+	assert.Empty(t, client.values)
+}
+
+func TestRedisFailuresServeTheRequest(t *testing.T) {
+	store, client, errs := newTestStore()
+	client.err = errors.New("connection refused")
+	served := 0
+	h := store.Middleware(counter(&served, http.StatusCreated))
+
+	rec := send(h, "user-1", "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, served)
+	assert.Len(t, *errs, 1)
+}
+
+func TestLongKeysAreRejected(t *testing.T) {
+	store, _, _ := newTestStore()
+	served := 0
+	h := store.Middleware(counter(&served, http.StatusCreated))
+
+	rec := send(h, "user-1", strings.Repeat("k", MaxKeyLength+1), `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, 0, served)
+}
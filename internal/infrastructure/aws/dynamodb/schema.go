@@ -23,6 +23,13 @@ type SoloGame struct {
 	StartedAt     time.Time `dynamodbav:"started_at"`
 	CompletedAt   time.Time `dynamodbav:"completed_at,omitempty"`
 	CreatedAt     time.Time `dynamodbav:"created_at"`
+
+	// Daily challenges set these: the day and level played, the words
+	// served, and DailyBoard keying the day's leaderboard for the level
+	ChallengeDate string   `dynamodbav:"challenge_date,omitempty"`
+	Level         int      `dynamodbav:"level,omitempty"`
+	WordIDs       []string `dynamodbav:"word_ids,omitempty"`
+	DailyBoard    string   `dynamodbav:"daily_board,omitempty"`
 }
 
 type Attempt struct {
@@ -73,6 +80,14 @@ func (s *DynamoDBService) CreateTables(ctx context.Context) error {
 					AttributeName: aws.String("created_at"),
 					AttributeType: types.ScalarAttributeTypeS,
 				},
+				{
+					AttributeName: aws.String("daily_board"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("score"),
+					AttributeType: types.ScalarAttributeTypeN,
+				},
 			},
 			keySchema: []types.KeySchemaElement{
 				{
@@ -82,7 +97,7 @@ func (s *DynamoDBService) CreateTables(ctx context.Context) error {
 			},
 			gsi: []types.GlobalSecondaryIndex{
 				{
-					IndexName: aws.String("user_games"),
+					IndexName: aws.String(UserGamesIndex),
 					KeySchema: []types.KeySchemaElement{
 						{
 							AttributeName: aws.String("user_id"),
@@ -97,6 +112,24 @@ func (s *DynamoDBService) CreateTables(ctx context.Context) error {
 						ProjectionType: types.ProjectionTypeAll,
 					},
 				},
+				{
+					// Only completed daily challenges have a board and
+					// score, so the index holds nothing else
+					IndexName: aws.String(DailyLeaderboardIndex),
+					KeySchema: []types.KeySchemaElement{
+						{
+							AttributeName: aws.String("daily_board"),
+							KeyType:      types.KeyTypeHash,
+						},
+						{
+							AttributeName: aws.String("score"),
+							KeyType:      types.KeyTypeRange,
+						},
+					},
+					Projection: &types.Projection{
+						ProjectionType: types.ProjectionTypeAll,
+					},
+				},
 			},
 		},
 		{
@@ -0,0 +1,412 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	SoloGamesTable = "solo_games"
+	// UserGamesIndex lists each player's games by when they were created
+	UserGamesIndex = "user_games"
+	// DailyLeaderboardIndex ranks completed daily challenges by score
+	// within each day and level
+	DailyLeaderboardIndex = "daily_leaderboard"
+)
+
+var (
+	ErrSoloGameExists   = errors.New("solo game already exists")
+	ErrSoloGameNotFound = errors.New("solo game not found")
+	// ErrSoloGameChanged is returned when a game isn't in the status an
+	// update expected, such as when it was completed concurrently
+	ErrSoloGameChanged = errors.New("solo game was changed by someone else")
+)
+
+// CreateSoloGame stores a new game, failing with ErrSoloGameExists if there
+// is already one with its ID
+func (s *DynamoDBService) CreateSoloGame(ctx context.Context, game *SoloGame) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(SoloGamesTable),
+		Item:                marshalSoloGame(game),
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrSoloGameExists
+		}
+		return fmt.Errorf("failed to create solo game: %w", err)
+	}
+	return nil
+}
+
+// GetSoloGame returns the game with id
+func (s *DynamoDBService) GetSoloGame(ctx context.Context, id string) (*SoloGame, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(SoloGamesTable),
+		Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get solo game: %w", err)
+	}
+	if out.Item == nil {
+		return nil, ErrSoloGameNotFound
+	}
+	return unmarshalSoloGame(out.Item)
+}
+
+// ReplaceSoloGame overwrites a stored game, failing with ErrSoloGameChanged
+// unless it is still in fromStatus
+func (s *DynamoDBService) ReplaceSoloGame(ctx context.Context, game *SoloGame, fromStatus string) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(SoloGamesTable),
+		Item:                      marshalSoloGame(game),
+		ConditionExpression:       aws.String("#status = :from"),
+		ExpressionAttributeNames:  map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":from": &types.AttributeValueMemberS{Value: fromStatus}},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrSoloGameChanged
+		}
+		return fmt.Errorf("failed to update solo game: %w", err)
+	}
+	return nil
+}
+
+// AppendSoloAttempt adds an attempt to a game and returns the game with it,
+// failing with ErrSoloGameChanged unless the game is still in inStatus
+func (s *DynamoDBService) AppendSoloAttempt(ctx context.Context, id string, attempt Attempt, inStatus string) (*SoloGame, error) {
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(SoloGamesTable),
+		Key:                      map[string]types.AttributeValue{"id": str(id)},
+		UpdateExpression:         aws.String("SET attempts = list_append(if_not_exists(attempts, :empty), :attempt)"),
+		ConditionExpression:      aws.String("#status = :in"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":attempt": &types.AttributeValueMemberL{Value: []types.AttributeValue{marshalAttempt(attempt)}},
+			":empty":   &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+			":in":      str(inStatus),
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, ErrSoloGameChanged
+		}
+		return nil, fmt.Errorf("failed to add solo game attempt: %w", err)
+	}
+	return unmarshalSoloGame(out.Attributes)
+}
+
+// FinishSoloGame moves a game from fromStatus to status with its final
+// score, failing with ErrSoloGameChanged if it has already moved on
+func (s *DynamoDBService) FinishSoloGame(ctx context.Context, id, fromStatus, status string, score int, at time.Time) (*SoloGame, error) {
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(SoloGamesTable),
+		Key:                      map[string]types.AttributeValue{"id": str(id)},
+		UpdateExpression:         aws.String("SET #status = :status, score = :score, completed_at = :at"),
+		ConditionExpression:      aws.String("#status = :from"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": str(status),
+			":score":  num(score),
+			":at":     timestamp(at),
+			":from":   str(fromStatus),
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, ErrSoloGameChanged
+		}
+		return nil, fmt.Errorf("failed to finish solo game: %w", err)
+	}
+	return unmarshalSoloGame(out.Attributes)
+}
+
+// DeleteSoloGame removes the game with id, failing with ErrSoloGameNotFound
+// if there isn't one
+func (s *DynamoDBService) DeleteSoloGame(ctx context.Context, id string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(SoloGamesTable),
+		Key:                 map[string]types.AttributeValue{"id": str(id)},
+		ConditionExpression: aws.String("attribute_exists(id)"),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrSoloGameNotFound
+		}
+		return fmt.Errorf("failed to delete solo game: %w", err)
+	}
+	return nil
+}
+
+// PurgeUser deletes all of userID's solo games and word stats
+func (s *DynamoDBService) PurgeUser(ctx context.Context, userID string) error {
+	games := &dynamodb.QueryInput{
+		TableName:                 aws.String(SoloGamesTable),
+		IndexName:                 aws.String(UserGamesIndex),
+		KeyConditionExpression:    aws.String("user_id = :user"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":user": str(userID)},
+		ProjectionExpression:      aws.String("id"),
+	}
+	if err := s.deleteQueried(ctx, games, SoloGamesTable, "id"); err != nil {
+		return fmt.Errorf("failed to delete user's solo games: %w", err)
+	}
+
+	stats := &dynamodb.QueryInput{
+		TableName:                 aws.String(UserWordStatsTable),
+		KeyConditionExpression:    aws.String("user_id = :user"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":user": str(userID)},
+		ProjectionExpression:      aws.String("user_id, word_id"),
+	}
+	if err := s.deleteQueried(ctx, stats, UserWordStatsTable, "user_id", "word_id"); err != nil {
+		return fmt.Errorf("failed to delete user's word stats: %w", err)
+	}
+	return nil
+}
+
+// deleteQueried deletes from table every item query finds, page by page,
+// keyed by the key attributes
+func (s *DynamoDBService) deleteQueried(ctx context.Context, query *dynamodb.QueryInput, table string, key ...string) error {
+	for {
+		out, err := s.client.Query(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, item := range out.Items {
+			itemKey := make(map[string]types.AttributeValue, len(key))
+			for _, name := range key {
+				itemKey[name] = item[name]
+			}
+			if _, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+				TableName: aws.String(table),
+				Key:       itemKey,
+			}); err != nil {
+				return err
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		query.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// SoloGamesByUser returns up to limit of userID's games, newest first,
+// starting with those created before before when it isn't zero
+func (s *DynamoDBService) SoloGamesByUser(ctx context.Context, userID string, before time.Time, limit int) ([]SoloGame, error) {
+	condition := "user_id = :user"
+	values := map[string]types.AttributeValue{":user": str(userID)}
+	if !before.IsZero() {
+		condition += " AND created_at < :before"
+		values[":before"] = timestamp(before)
+	}
+
+	out, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(SoloGamesTable),
+		IndexName:                 aws.String(UserGamesIndex),
+		KeyConditionExpression:    aws.String(condition),
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user's solo games: %w", err)
+	}
+	return unmarshalSoloGames(out.Items)
+}
+
+// TopDailyScores returns up to limit of the best completed games on a daily
+// board, highest score first
+func (s *DynamoDBService) TopDailyScores(ctx context.Context, board string, limit int) ([]SoloGame, error) {
+	out, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(SoloGamesTable),
+		IndexName:                 aws.String(DailyLeaderboardIndex),
+		KeyConditionExpression:    aws.String("daily_board = :board"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":board": &types.AttributeValueMemberS{Value: board}},
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily leaderboard: %w", err)
+	}
+	return unmarshalSoloGames(out.Items)
+}
+
+// marshalSoloGame encodes a game as the item its dynamodbav tags describe
+func marshalSoloGame(g *SoloGame) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		"id":         str(g.ID),
+		"user_id":    str(g.UserID),
+		"status":     str(g.Status),
+		"word_id":    str(g.WordID),
+		"word":       str(g.Word),
+		"hints_used": num(g.HintsUsed),
+		"score":      num(g.Score),
+		"started_at": timestamp(g.StartedAt),
+		"created_at": timestamp(g.CreatedAt),
+	}
+
+	attempts := make([]types.AttributeValue, len(g.Attempts))
+	for i, a := range g.Attempts {
+		attempts[i] = marshalAttempt(a)
+	}
+	item["attempts"] = &types.AttributeValueMemberL{Value: attempts}
+
+	if !g.CompletedAt.IsZero() {
+		item["completed_at"] = timestamp(g.CompletedAt)
+	}
+	if g.ChallengeDate != "" {
+		item["challenge_date"] = str(g.ChallengeDate)
+	}
+	if g.Level != 0 {
+		item["level"] = num(g.Level)
+	}
+	if len(g.WordIDs) > 0 {
+		ids := make([]types.AttributeValue, len(g.WordIDs))
+		for i, id := range g.WordIDs {
+			ids[i] = str(id)
+		}
+		item["word_ids"] = &types.AttributeValueMemberL{Value: ids}
+	}
+	if g.DailyBoard != "" {
+		item["daily_board"] = str(g.DailyBoard)
+	}
+	return item
+}
+
+func marshalAttempt(a Attempt) types.AttributeValue {
+	return &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"word":       str(a.Word),
+		"type":       str(a.Type),
+		"is_correct": &types.AttributeValueMemberBOOL{Value: a.IsCorrect},
+		"timestamp":  timestamp(a.Timestamp),
+	}}
+}
+
+func unmarshalSoloGames(items []map[string]types.AttributeValue) ([]SoloGame, error) {
+	games := make([]SoloGame, 0, len(items))
+	for _, item := range items {
+		game, err := unmarshalSoloGame(item)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *game)
+	}
+	return games, nil
+}
+
+func unmarshalSoloGame(item map[string]types.AttributeValue) (*SoloGame, error) {
+	var err error
+	readTime := func(v types.AttributeValue) time.Time {
+		if err != nil {
+			return time.Time{}
+		}
+		var t time.Time
+		t, err = getTime(v)
+		return t
+	}
+	readInt := func(v types.AttributeValue) int {
+		if err != nil {
+			return 0
+		}
+		var n int
+		n, err = getNum(v)
+		return n
+	}
+
+	g := &SoloGame{
+		ID:            getStr(item["id"]),
+		UserID:        getStr(item["user_id"]),
+		Status:        getStr(item["status"]),
+		WordID:        getStr(item["word_id"]),
+		Word:          getStr(item["word"]),
+		HintsUsed:     readInt(item["hints_used"]),
+		Score:         readInt(item["score"]),
+		StartedAt:     readTime(item["started_at"]),
+		CompletedAt:   readTime(item["completed_at"]),
+		CreatedAt:     readTime(item["created_at"]),
+		ChallengeDate: getStr(item["challenge_date"]),
+		Level:         readInt(item["level"]),
+		DailyBoard:    getStr(item["daily_board"]),
+	}
+
+	if list, ok := item["attempts"].(*types.AttributeValueMemberL); ok {
+		for _, v := range list.Value {
+			m, ok := v.(*types.AttributeValueMemberM)
+			if !ok {
+				continue
+			}
+			correct, _ := m.Value["is_correct"].(*types.AttributeValueMemberBOOL)
+			g.Attempts = append(g.Attempts, Attempt{
+				Word:      getStr(m.Value["word"]),
+				Type:      getStr(m.Value["type"]),
+				IsCorrect: correct != nil && correct.Value,
+				Timestamp: readTime(m.Value["timestamp"]),
+			})
+		}
+	}
+	if list, ok := item["word_ids"].(*types.AttributeValueMemberL); ok {
+		for _, v := range list.Value {
+			g.WordIDs = append(g.WordIDs, getStr(v))
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode solo game %s: %w", g.ID, err)
+	}
+	return g, nil
+}
+
+func str(s string) types.AttributeValue { return &types.AttributeValueMemberS{Value: s} }
+
+func num(n int) types.AttributeValue { return &types.AttributeValueMemberN{Value: strconv.Itoa(n)} }
+
+// timeLayout is RFC 3339 with every fractional digit kept, so timestamps
+// used as sort keys order the same as strings as they do as times
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func timestamp(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: t.UTC().Format(timeLayout)}
+}
+
+func getStr(v types.AttributeValue) string {
+	s, ok := v.(*types.AttributeValueMemberS)
+	if !ok {
+		return ""
+	}
+	return s.Value
+}
+
+// getNum reads a number attribute, treating a missing one as 0
+func getNum(v types.AttributeValue) (int, error) {
+	n, ok := v.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.Atoi(n.Value)
+}
+
+// getTime reads a timestamp attribute, treating a missing one as the zero
+// time
+func getTime(v types.AttributeValue) (time.Time, error) {
+	s := getStr(v)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
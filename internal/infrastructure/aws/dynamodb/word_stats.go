@@ -0,0 +1,77 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const UserWordStatsTable = "user_word_stats"
+
+// RecordWordAttempt counts one of userID's attempts at a word, creating
+// their stats for it on the first, and returns the updated stats
+func (s *DynamoDBService) RecordWordAttempt(ctx context.Context, userID, wordID string, correct bool, at time.Time) (*UserWordStats, error) {
+	counter := "incorrect_attempts"
+	if correct {
+		counter = "correct_attempts"
+	}
+
+	out, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(UserWordStatsTable),
+		Key:              wordStatsKey(userID, wordID),
+		UpdateExpression: aws.String("ADD " + counter + " :one SET last_attempt_at = :at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": num(1),
+			":at":  timestamp(at),
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record word attempt: %w", err)
+	}
+	return unmarshalWordStats(out.Attributes)
+}
+
+// ScheduleWordReview sets when userID should next practise a word
+func (s *DynamoDBService) ScheduleWordReview(ctx context.Context, userID, wordID string, at time.Time) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(UserWordStatsTable),
+		Key:                       wordStatsKey(userID, wordID),
+		UpdateExpression:          aws.String("SET next_review_at = :at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":at": timestamp(at)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule word review: %w", err)
+	}
+	return nil
+}
+
+func wordStatsKey(userID, wordID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"user_id": str(userID), "word_id": str(wordID)}
+}
+
+func unmarshalWordStats(item map[string]types.AttributeValue) (*UserWordStats, error) {
+	stats := &UserWordStats{
+		UserID: getStr(item["user_id"]),
+		WordID: getStr(item["word_id"]),
+	}
+
+	var err error
+	if stats.CorrectAttempts, err = getNum(item["correct_attempts"]); err != nil {
+		return nil, fmt.Errorf("failed to decode word stats: %w", err)
+	}
+	if stats.IncorrectAttempts, err = getNum(item["incorrect_attempts"]); err != nil {
+		return nil, fmt.Errorf("failed to decode word stats: %w", err)
+	}
+	if stats.LastAttemptAt, err = getTime(item["last_attempt_at"]); err != nil {
+		return nil, fmt.Errorf("failed to decode word stats: %w", err)
+	}
+	if stats.NextReviewAt, err = getTime(item["next_review_at"]); err != nil {
+		return nil, fmt.Errorf("failed to decode word stats: %w", err)
+	}
+	return stats, nil
+}
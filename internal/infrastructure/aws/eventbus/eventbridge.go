@@ -0,0 +1,64 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"big-spella-go/internal/game"
+)
+
+// Source is the source of the events put on an EventBridge bus, for rules
+// to match on. Each event's detail type is its game event type, such as
+// "game_ended", and its detail the event as JSON.
+const Source = "big-spella.game"
+
+// EventBridge puts events on an event bus
+type EventBridge struct {
+	client *eventbridge.Client
+	bus    string
+}
+
+// NewEventBridge puts events on bus, given by name or ARN
+func NewEventBridge(cfg aws.Config, bus string) *EventBridge {
+	return &EventBridge{
+		client: eventbridge.NewFromConfig(cfg),
+		bus:    bus,
+	}
+}
+
+func (b *EventBridge) Send(ctx context.Context, events []game.GameEvent) error {
+	entries := make([]types.PutEventsRequestEntry, 0, len(events))
+	for _, event := range events {
+		detail, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event of game %s: %w", event.Type, event.GameID, err)
+		}
+		entries = append(entries, types.PutEventsRequestEntry{
+			EventBusName: aws.String(b.bus),
+			Source:       aws.String(Source),
+			DetailType:   aws.String(string(event.Type)),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(event.Timestamp),
+		})
+	}
+
+	out, err := b.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		return fmt.Errorf("failed to put %d events on %s: %w", len(entries), b.bus, err)
+	}
+	if out.FailedEntryCount > 0 {
+		for _, entry := range out.Entries {
+			if entry.ErrorCode != nil {
+				return fmt.Errorf("%d of %d events weren't put on %s: %s: %s", out.FailedEntryCount, len(entries), b.bus,
+					aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+			}
+		}
+		return fmt.Errorf("%d of %d events weren't put on %s", out.FailedEntryCount, len(entries), b.bus)
+	}
+	return nil
+}
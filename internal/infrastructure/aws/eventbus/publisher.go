@@ -0,0 +1,108 @@
+// Package eventbus publishes game events to EventBridge or SNS, so that
+// analytics, fraud detection and notification lambdas can follow games
+// without calling the API servers.
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"big-spella-go/internal/game"
+)
+
+const (
+	// MaxBatch is the most events EventBridge and SNS take in one request
+	MaxBatch = 10
+	// DefaultQueueSize is how many events can wait to be published before
+	// more are dropped
+	DefaultQueueSize = 4096
+
+	// flushInterval is the longest an event waits for a batch to fill
+	flushInterval = time.Second
+	// drainTimeout is how long events still queued at shutdown have to be
+	// published
+	drainTimeout = 5 * time.Second
+)
+
+// Target is where events are published: an EventBridge bus or an SNS topic
+type Target interface {
+	// Send publishes up to MaxBatch events
+	Send(ctx context.Context, events []game.GameEvent) error
+}
+
+// Publisher queues game events as they happen, so games never wait on AWS,
+// and publishes them to its target in batches. It's a game.EventForwarder.
+type Publisher struct {
+	target  Target
+	queue   chan game.GameEvent
+	dropped atomic.Int64
+}
+
+func NewPublisher(target Target, queueSize int) *Publisher {
+	return &Publisher{
+		target: target,
+		queue:  make(chan game.GameEvent, queueSize),
+	}
+}
+
+// Forward queues event to be published. Events are dropped while the queue
+// is full, and Run reports how many.
+func (p *Publisher) Forward(event game.GameEvent) {
+	select {
+	case p.queue <- event:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// Run publishes queued events until ctx is done, then publishes those still
+// queued. Batches that can't be published are reported to onError and not
+// retried beyond the AWS client's own retries.
+func (p *Publisher) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	// Batches being sent aren't cut short when ctx is done, so they aren't
+	// lost
+	sendCtx := context.WithoutCancel(ctx)
+	batch := make([]game.GameEvent, 0, MaxBatch)
+	flush := func(ctx context.Context) {
+		if dropped := p.dropped.Swap(0); dropped > 0 {
+			onError(fmt.Errorf("dropped %d game events: the queue was full", dropped))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.target.Send(ctx, batch); err != nil {
+			onError(err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event := <-p.queue:
+			if batch = append(batch, event); len(batch) == MaxBatch {
+				flush(sendCtx)
+			}
+		case <-ticker.C:
+			flush(sendCtx)
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			defer cancel()
+			for {
+				select {
+				case event := <-p.queue:
+					if batch = append(batch, event); len(batch) == MaxBatch {
+						flush(ctx)
+					}
+				default:
+					flush(ctx)
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/game"
+)
+
+type recordingTarget struct {
+	mu      sync.Mutex
+	batches [][]game.GameEvent
+}
+
+func (t *recordingTarget) Send(ctx context.Context, events []game.GameEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.batches = append(t.batches, append([]game.GameEvent(nil), events...))
+	return nil
+}
+
+func TestPublisherBatchesAndDrains(t *testing.T) {
+	target := &recordingTarget{}
+	p := NewPublisher(target, 32)
+	for i := 1; i <= 25; i++ {
+		p.Forward(game.GameEvent{GameID: "game-1", Seq: int64(i)})
+	}
+
+	// Cancelled before it starts, Run publishes everything queued and returns
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var errs []error
+	p.Run(ctx, func(err error) { errs = append(errs, err) })
+
+	assert.Empty(t, errs)
+	if assert.Len(t, target.batches, 3) {
+		assert.Len(t, target.batches[0], MaxBatch)
+		assert.Len(t, target.batches[1], MaxBatch)
+		assert.Len(t, target.batches[2], 5)
+		assert.Equal(t, int64(25), target.batches[2][4].Seq)
+	}
+}
+
+func TestPublisherReportsDropped(t *testing.T) {
+	target := &recordingTarget{}
+	p := NewPublisher(target, 2)
+	for i := 0; i < 5; i++ {
+		p.Forward(game.GameEvent{GameID: "game-1"})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var errs []error
+	p.Run(ctx, func(err error) { errs = append(errs, err) })
+
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "dropped 3 game events")
+	}
+	assert.Len(t, target.batches, 1)
+}
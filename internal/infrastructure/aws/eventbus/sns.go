@@ -0,0 +1,72 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	"big-spella-go/internal/game"
+)
+
+// SNS publishes events to a topic, each message being the event as JSON.
+// Messages carry event_type and game_id attributes for subscriptions to
+// filter on.
+type SNS struct {
+	client *sns.Client
+	topic  string
+	// fifo topics keep each game's events in order, and need every message
+	// to say which game it belongs to
+	fifo bool
+}
+
+// NewSNS publishes events to the topic with ARN topicARN
+func NewSNS(cfg aws.Config, topicARN string) *SNS {
+	return &SNS{
+		client: sns.NewFromConfig(cfg),
+		topic:  topicARN,
+		fifo:   strings.HasSuffix(topicARN, ".fifo"),
+	}
+}
+
+func (s *SNS) Send(ctx context.Context, events []game.GameEvent) error {
+	entries := make([]types.PublishBatchRequestEntry, 0, len(events))
+	for i, event := range events {
+		message, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event of game %s: %w", event.Type, event.GameID, err)
+		}
+		entry := types.PublishBatchRequestEntry{
+			Id:      aws.String(strconv.Itoa(i)),
+			Message: aws.String(string(message)),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"event_type": {DataType: aws.String("String"), StringValue: aws.String(string(event.Type))},
+				"game_id":    {DataType: aws.String("String"), StringValue: aws.String(event.GameID)},
+			},
+		}
+		if s.fifo {
+			entry.MessageGroupId = aws.String(event.GameID)
+			entry.MessageDeduplicationId = aws.String(fmt.Sprintf("%s-%d", event.GameID, event.Seq))
+		}
+		entries = append(entries, entry)
+	}
+
+	out, err := s.client.PublishBatch(ctx, &sns.PublishBatchInput{
+		TopicArn:                   aws.String(s.topic),
+		PublishBatchRequestEntries: entries,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %d events to %s: %w", len(entries), s.topic, err)
+	}
+	if len(out.Failed) > 0 {
+		failed := out.Failed[0]
+		return fmt.Errorf("%d of %d events weren't published to %s: %s: %s", len(out.Failed), len(entries), s.topic,
+			aws.ToString(failed.Code), aws.ToString(failed.Message))
+	}
+	return nil
+}
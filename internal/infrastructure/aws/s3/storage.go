@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// MaxPresignExpiry is the longest lifetime S3 allows for a SigV4 presigned URL
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+type Storage struct {
+	client     *s3.Client
+	presigner  *s3.PresignClient
+	bucket     string
+	cdnBaseURL string
+}
+
+func NewStorage(cfg aws.Config, bucket, cdnBaseURL string) *Storage {
+	client := s3.NewFromConfig(cfg)
+	return &Storage{
+		client:     client,
+		presigner:  s3.NewPresignClient(client),
+		bucket:     bucket,
+		cdnBaseURL: strings.TrimRight(cdnBaseURL, "/"),
+	}
+}
+
+// Exists reports whether an object is stored under key
+func (s *Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to head object %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Put uploads data under key
+func (s *Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	return nil
+}
+
+// PutEncrypted uploads data under key with SSE-KMS, so S3 envelope-encrypts
+// it with a data key protected by kmsKeyID, or by the account's S3 key when
+// it's empty
+func (s *Storage) PutEncrypted(ctx context.Context, key string, data []byte, contentType, kmsKeyID string) error {
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+		BucketKeyEnabled:     aws.Bool(true),
+	}
+	if kmsKeyID != "" {
+		input.SSEKMSKeyId = aws.String(kmsKeyID)
+	}
+	_, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to put encrypted object %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get downloads the object stored under key. Objects encrypted with SSE-KMS
+// are decrypted by S3 for callers allowed to use the key.
+func (s *Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete removes the object stored under key
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	return nil
+}
+
+// PresignPut returns a URL that lets its holder upload an object of
+// contentType under key until expiry passes
+func (s *Storage) PresignPut(ctx context.Context, key, contentType string, expiry time.Duration) (string, error) {
+	req, err := s.presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// PresignGet returns a URL that lets its holder download the object under
+// key until expiry passes, for objects that mustn't go through the CDN
+func (s *Storage) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(min(expiry, MaxPresignExpiry)))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// URL returns a public URL for key, going through the CDN when one is
+// configured and falling back to a presigned GET otherwise
+func (s *Storage) URL(ctx context.Context, key string) (string, error) {
+	if s.cdnBaseURL != "" {
+		return s.cdnBaseURL + "/" + key, nil
+	}
+
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(MaxPresignExpiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object %s: %w", key, err)
+	}
+	return req.URL, nil
+}
@@ -0,0 +1,62 @@
+// Package sms texts players through Amazon SNS
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	"big-spella-go/internal/notifications"
+)
+
+// SNS sends texts as transactional SMS, which carriers deliver ahead of
+// marketing messages
+type SNS struct {
+	client *sns.Client
+	// senderID is shown in place of a number where carriers allow it
+	senderID string
+}
+
+// NewSNS texts from senderID, if it isn't empty
+func NewSNS(cfg aws.Config, senderID string) *SNS {
+	return &SNS{client: sns.NewFromConfig(cfg), senderID: senderID}
+}
+
+// SendSMS returns notifications.ErrPhoneOptedOut for numbers that have
+// replied STOP, which SNS would otherwise quietly not deliver to
+func (s *SNS) SendSMS(ctx context.Context, phoneNumber, message string) error {
+	optedOut, err := s.client.CheckIfPhoneNumberIsOptedOut(ctx, &sns.CheckIfPhoneNumberIsOptedOutInput{
+		PhoneNumber: aws.String(phoneNumber),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check whether number opted out: %w", err)
+	}
+	if optedOut.IsOptedOut {
+		return notifications.ErrPhoneOptedOut
+	}
+
+	attributes := map[string]types.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {DataType: aws.String("String"), StringValue: aws.String("Transactional")},
+	}
+	if s.senderID != "" {
+		attributes["AWS.SNS.SMS.SenderID"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(s.senderID)}
+	}
+
+	_, err = s.client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(phoneNumber),
+		Message:           aws.String(message),
+		MessageAttributes: attributes,
+	})
+	var optedOutErr *types.OptedOutException
+	if errors.As(err, &optedOutErr) {
+		return notifications.ErrPhoneOptedOut
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send text: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package redis is a minimal Redis client speaking RESP2, covering the plain
+// request/reply commands the API needs
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultPoolSize    = 10
+)
+
+// ErrNil is returned by the typed helpers when a key doesn't exist
+var ErrNil = errors.New("redis: nil reply")
+
+// Error is an error reply from the server
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// Client runs commands over a small pool of connections
+type Client struct {
+	addr     string
+	password string
+	db       int
+	pool     chan *conn
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Open parses a redis://[:password@]host:port[/db] URL. Connections are made
+// as commands need them.
+func Open(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid redis URL scheme %q", u.Scheme)
+	}
+
+	c := &Client{addr: u.Host, pool: make(chan *conn, defaultPoolSize)}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+	return c, nil
+}
+
+// Close closes the pooled connections
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Do runs a command and returns its reply: a string, int64, []any, or nil
+// for a nil reply. Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection's state is unknown after a network error
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Int runs a command with an integer reply
+func (c *Client) Int(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case nil:
+		return 0, ErrNil
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply %T for %s", reply, args[0])
+	}
+}
+
+// String runs a command with a string reply
+func (c *Client) String(ctx context.Context, args ...string) (string, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	switch v := reply.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", ErrNil
+	default:
+		return "", fmt.Errorf("redis: unexpected reply %T for %s", reply, args[0])
+	}
+}
+
+// Strings runs a command whose reply is an array of strings
+func (c *Client) Strings(ctx context.Context, args ...string) ([]string, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected reply %T for %s", reply, args[0])
+	}
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("redis: unexpected reply %T in array for %s", item, args[0])
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: defaultDialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if c.password != "" {
+		if _, err := cn.do(ctx, []string{"AUTH", c.password}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("failed to authenticate with redis: %w", err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.do(ctx, []string{"SELECT", strconv.Itoa(c.db)}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("failed to select redis database: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+func (cn *conn) do(ctx context.Context, args []string) (any, error) {
+	// No deadline on ctx clears any left over from an earlier command
+	deadline, _ := ctx.Deadline()
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := cn.Write(encodeCommand(args)); err != nil {
+		return nil, fmt.Errorf("failed to send redis command: %w", err)
+	}
+	return readReply(cn.r)
+}
+
+// encodeCommand writes args as a RESP array of bulk strings
+func encodeCommand(args []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(b.String())
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis reply: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read redis reply: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := readReply(r)
+			var replyErr Error
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			if err != nil {
+				item = replyErr
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	queue *Queue
+}
+
+func NewHandler(queue *Queue) *Handler {
+	return &Handler{queue: queue}
+}
+
+// List serves jobs by ?status, dead lettered ones by default
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	status := Status(r.URL.Query().Get("status"))
+	if status == "" {
+		status = StatusDead
+	}
+
+	var v validator.Validator
+	v.CheckField(validator.In(status, StatusPending, StatusRunning, StatusCompleted, StatusDead), "status", "Must be one of pending, running, completed or dead")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	jobs, err := h.queue.List(r.Context(), status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"jobs": jobs})
+}
+
+// Stats serves how many jobs of each kind are in each status
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.queue.Stats(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"counts": counts})
+}
+
+// Retry puts a dead lettered job back in the queue
+func (h *Handler) Retry(w http.ResponseWriter, r *http.Request) {
+	jobID := httprouter.ParamsFromContext(r.Context()).ByName("jobID")
+
+	var v validator.Validator
+	_, err := uuid.Parse(jobID)
+	v.CheckField(err == nil, "job_id", "Must be a valid ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	job, err := h.queue.Retry(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrJobNotDead):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1:  15 * time.Second,
+		2:  30 * time.Second,
+		4:  2 * time.Minute,
+		9:  time.Hour,
+		64: time.Hour,
+	} {
+		got := Backoff(attempt)
+		assert.GreaterOrEqual(t, got, want, "attempt %d", attempt)
+		assert.LessOrEqual(t, got, want+want/5, "attempt %d", attempt)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("template missing")
+	err := Permanent(cause)
+
+	var permanent permanentError
+	assert.True(t, errors.As(err, &permanent))
+	assert.ErrorIs(t, err, cause)
+	assert.False(t, errors.As(cause, &permanent))
+}
+
+func TestDecodeFailureIsPermanent(t *testing.T) {
+	job := &Job{Kind: "send_email", Payload: []byte(`{"recipient":`)}
+
+	var payload struct{ Recipient string }
+	err := job.Decode(&payload)
+
+	var permanent permanentError
+	assert.True(t, errors.As(err, &permanent), "a malformed payload won't decode on retry either")
+}
+
+func TestWorkerRecoversPanics(t *testing.T) {
+	w := NewWorker(nil, 0, nil)
+	assert.Equal(t, DefaultConcurrency, w.concurrency)
+
+	w.Register("explode", func(ctx context.Context, job *Job) error {
+		panic("boom")
+	})
+	err := w.run(context.Background(), &Job{Kind: "explode"})
+	assert.EqualError(t, err, "job panicked: boom")
+}
+
+func TestHandlerValidation(t *testing.T) {
+	h := NewHandler(nil)
+
+	rec := httptest.NewRecorder()
+	h.List(rec, httptest.NewRequest(http.MethodGet, "/jobs?status=lost", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "status")
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/nope/retry", nil)
+	req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "jobID", Value: "nope"}}))
+	rec = httptest.NewRecorder()
+	h.Retry(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "job_id")
+}
@@ -0,0 +1,254 @@
+// Package jobs is a Postgres-backed queue for work that shouldn't hold up a
+// request, run by a pool of workers with retries and a dead letter status
+// for jobs that keep failing
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const DefaultMaxAttempts = 5
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobNotDead  = errors.New("only dead jobs can be retried")
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	// StatusDead is the dead letter status of jobs that ran out of attempts
+	// or failed permanently. They stay until an admin retries them.
+	StatusDead Status = "dead"
+)
+
+type Job struct {
+	ID          string          `json:"id" db:"id"`
+	Kind        string          `json:"kind" db:"kind"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	Status      Status          `json:"status" db:"status"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"max_attempts" db:"max_attempts"`
+	RunAt       time.Time       `json:"run_at" db:"run_at"`
+	LastError   *string         `json:"last_error,omitempty" db:"last_error"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty" db:"locked_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Decode reads the job's payload into v
+func (j *Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("failed to decode %s payload: %w", j.Kind, err))
+	}
+	return nil
+}
+
+type EnqueueOption func(*Job)
+
+// WithMaxAttempts overrides how many times the job is tried before it is
+// dead lettered
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(j *Job) {
+		j.MaxAttempts = n
+	}
+}
+
+// WithDelay holds the job back for d
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = j.RunAt.Add(d)
+	}
+}
+
+type Queue struct {
+	db *sqlx.DB
+}
+
+func NewQueue(db *sqlx.DB) *Queue {
+	return &Queue{db: db}
+}
+
+// Enqueue adds a job of kind, with payload encoded as JSON
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts ...EnqueueOption) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", kind, err)
+	}
+
+	job := &Job{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := q.db.GetContext(ctx, job, `
+		INSERT INTO jobs (id, kind, payload, status, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *`,
+		job.ID, job.Kind, string(job.Payload), StatusPending, job.MaxAttempts, job.RunAt); err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+	}
+
+	metrics.Add(kind+".enqueued", 1)
+	return job, nil
+}
+
+// claim locks the next due job of one of kinds for a worker
+func (q *Queue) claim(ctx context.Context, kinds []string) (*Job, error) {
+	job := &Job{}
+	err := q.db.GetContext(ctx, job, `
+		UPDATE jobs
+		SET status = $1, attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = $2 AND run_at <= NOW() AND kind = ANY($3)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING *`, StatusRunning, StatusPending, pq.Array(kinds))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+func (q *Queue) complete(ctx context.Context, job *Job) error {
+	if _, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = $1, locked_at = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $2`, StatusCompleted, job.ID); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// retry puts a failed job back in the queue to run again at runAt
+func (q *Queue) retry(ctx context.Context, job *Job, runAt time.Time, cause error) error {
+	if _, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = $1, run_at = $2, last_error = $3, locked_at = NULL, updated_at = NOW()
+		WHERE id = $4`, StatusPending, runAt, cause.Error(), job.ID); err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+	return nil
+}
+
+func (q *Queue) bury(ctx context.Context, job *Job, cause error) error {
+	if _, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = $1, last_error = $2, locked_at = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $3`, StatusDead, cause.Error(), job.ID); err != nil {
+		return fmt.Errorf("failed to dead letter job: %w", err)
+	}
+	return nil
+}
+
+// rescue returns jobs locked for longer than timeout, whose worker must have
+// died, to the queue. Their attempt still counts.
+func (q *Queue) rescue(ctx context.Context, timeout time.Duration) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = $1, locked_at = NULL, last_error = 'worker stopped responding', updated_at = NOW()
+		WHERE status = $2 AND locked_at < $3`,
+		StatusPending, StatusRunning, time.Now().Add(-timeout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to rescue stuck jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// prune deletes completed jobs finished before cutoff
+func (q *Queue) prune(ctx context.Context, cutoff time.Time) error {
+	if _, err := q.db.ExecContext(ctx, `
+		DELETE FROM jobs WHERE status = $1 AND finished_at < $2`, StatusCompleted, cutoff); err != nil {
+		return fmt.Errorf("failed to prune completed jobs: %w", err)
+	}
+	return nil
+}
+
+// List returns up to 100 jobs with status, most recently updated first
+func (q *Queue) List(ctx context.Context, status Status) ([]Job, error) {
+	jobs := []Job{}
+	if err := q.db.SelectContext(ctx, &jobs, `
+		SELECT * FROM jobs WHERE status = $1
+		ORDER BY updated_at DESC
+		LIMIT 100`, status); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Retry gives a dead job a fresh set of attempts
+func (q *Queue) Retry(ctx context.Context, jobID string) (*Job, error) {
+	tx, err := q.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	job := &Job{}
+	if err := tx.GetContext(ctx, job, `SELECT * FROM jobs WHERE id = $1 FOR UPDATE`, jobID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.Status != StatusDead {
+		return nil, ErrJobNotDead
+	}
+
+	if err := tx.GetContext(ctx, job, `
+		UPDATE jobs
+		SET status = $1, attempts = 0, run_at = NOW(), finished_at = NULL, updated_at = NOW()
+		WHERE id = $2
+		RETURNING *`, StatusPending, jobID); err != nil {
+		return nil, fmt.Errorf("failed to retry job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return job, nil
+}
+
+// Count is how many jobs of a kind have a status
+type Count struct {
+	Kind   string `json:"kind" db:"kind"`
+	Status Status `json:"status" db:"status"`
+	Count  int    `json:"count" db:"count"`
+}
+
+// Stats counts the jobs in the queue by kind and status
+func (q *Queue) Stats(ctx context.Context) ([]Count, error) {
+	counts := []Count{}
+	if err := q.db.SelectContext(ctx, &counts, `
+		SELECT kind, status, COUNT(*) AS count
+		FROM jobs
+		GROUP BY kind, status
+		ORDER BY kind, status`); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	return counts, nil
+}
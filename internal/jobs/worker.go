@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultConcurrency  = 4
+	DefaultPollInterval = time.Second
+	DefaultJobTimeout   = 5 * time.Minute
+
+	// CompletedRetention is how long finished jobs are kept for the stats
+	CompletedRetention = 7 * 24 * time.Hour
+
+	baseBackoff = 15 * time.Second
+	maxBackoff  = time.Hour
+
+	maintenanceInterval = time.Minute
+)
+
+// metrics counts each kind's jobs by outcome, published under "jobs" on
+// /debug/vars
+var metrics = expvar.NewMap("jobs")
+
+// HandlerFunc does a job's work. Returning an error retries the job with
+// backoff unless it is Permanent.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying won't fix, so the job goes straight to
+// the dead letter status
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Backoff is how long to wait before trying a job again after its attempt'th
+// failure: exponential from 15 seconds, capped at an hour, with up to 20%
+// jitter so jobs that failed together don't retry together
+func Backoff(attempt int) time.Duration {
+	d := maxBackoff
+	if attempt < 20 {
+		d = min(baseBackoff<<max(attempt-1, 0), maxBackoff)
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// Worker runs queued jobs with a fixed number of goroutines
+type Worker struct {
+	queue        *Queue
+	handlers     map[string]HandlerFunc
+	concurrency  int
+	pollInterval time.Duration
+	jobTimeout   time.Duration
+	onError      func(job *Job, err error)
+}
+
+// NewWorker runs up to concurrency jobs at once. Failed attempts are
+// reported to onError, with a nil job for the worker's own errors.
+func NewWorker(queue *Queue, concurrency int, onError func(job *Job, err error)) *Worker {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &Worker{
+		queue:        queue,
+		handlers:     make(map[string]HandlerFunc),
+		concurrency:  concurrency,
+		pollInterval: DefaultPollInterval,
+		jobTimeout:   DefaultJobTimeout,
+		onError:      onError,
+	}
+}
+
+// Register has the worker run jobs of kind with handler. It is meant to be
+// called before Run.
+func (w *Worker) Register(kind string, handler HandlerFunc) {
+	w.handlers[kind] = handler
+}
+
+func (w *Worker) kinds() []string {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Run works through the queue until ctx is done, then waits for the jobs in
+// progress to finish
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	kinds := w.kinds()
+
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx, kinds)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.maintain(ctx)
+	}()
+
+	wg.Wait()
+}
+
+func (w *Worker) poll(ctx context.Context, kinds []string) {
+	for {
+		job, err := w.queue.claim(ctx, kinds)
+		if err != nil && ctx.Err() == nil {
+			w.report(nil, err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+
+		w.process(job)
+	}
+}
+
+// process runs a claimed job to the end even if the worker is stopping, so
+// shutdown doesn't leave it locked
+func (w *Worker) process(job *Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.jobTimeout)
+	started := time.Now()
+	err := w.run(ctx, job)
+	cancel()
+	metrics.Add(job.Kind+".duration_ms", time.Since(started).Milliseconds())
+
+	// A job that timed out has used up ctx, so the outcome is recorded with
+	// a fresh one
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var permanent permanentError
+	switch {
+	case err == nil:
+		metrics.Add(job.Kind+".succeeded", 1)
+		err = w.queue.complete(ctx, job)
+
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		metrics.Add(job.Kind+".dead", 1)
+		w.report(job, err)
+		err = w.queue.bury(ctx, job, err)
+
+	default:
+		metrics.Add(job.Kind+".retried", 1)
+		w.report(job, err)
+		err = w.queue.retry(ctx, job, time.Now().Add(Backoff(job.Attempts)), err)
+	}
+
+	if err != nil {
+		w.report(job, err)
+	}
+}
+
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return w.handlers[job.Kind](ctx, job)
+}
+
+// maintain rescues jobs from workers that died and prunes old completed ones
+func (w *Worker) maintain(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := w.queue.rescue(ctx, 2*w.jobTimeout); err != nil {
+				w.report(nil, err)
+			} else if n > 0 {
+				metrics.Add("rescued", n)
+			}
+			if err := w.queue.prune(ctx, time.Now().Add(-CompletedRetention)); err != nil {
+				w.report(nil, err)
+			}
+		}
+	}
+}
+
+func (w *Worker) report(job *Job, err error) {
+	if w.onError != nil {
+		w.onError(job, err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package metrics keeps counters, gauges and histograms and serves them in
+// the Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets suit latencies in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type sample struct {
+	suffix string
+	labels []string // name, value pairs
+	value  float64
+}
+
+type family interface {
+	kind() string
+	samples() []sample
+}
+
+type registered struct {
+	help string
+	family
+}
+
+// Registry holds metrics for exposition
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]registered
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]registered)}
+}
+
+// Default is the registry the package level constructors register with and
+// Handler serves
+var Default = NewRegistry()
+
+// register panics on a duplicate name, since metrics are declared once at
+// startup and a clash is a programming error
+func (r *Registry) register(name, help string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.families[name] = registered{help: help, family: f}
+}
+
+// Handler serves every metric in the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.write(w)
+	})
+}
+
+func (r *Registry) write(w http.ResponseWriter) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := r.families
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+	defer buf.Flush()
+
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, escapeHelp(f.help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, f.kind())
+		for _, s := range f.samples() {
+			buf.WriteString(name + s.suffix)
+			if len(s.labels) > 0 {
+				buf.WriteByte('{')
+				for i := 0; i < len(s.labels); i += 2 {
+					if i > 0 {
+						buf.WriteByte(',')
+					}
+					fmt.Fprintf(buf, `%s="%s"`, s.labels[i], escapeLabel(s.labels[i+1]))
+				}
+				buf.WriteByte('}')
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(formatFloat(s.value))
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// Handler serves the Default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// atomicFloat is a float64 updated without locks
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) { f.bits.Store(math.Float64bits(v)) }
+func (f *atomicFloat) load() float64 { return math.Float64frombits(f.bits.Load()) }
+
+// vec holds one child metric per combination of label values
+type vec[T any] struct {
+	labels   []string
+	newChild func() T
+
+	mu       sync.RWMutex
+	children map[string]*child[T]
+}
+
+type child[T any] struct {
+	values []string
+	metric T
+}
+
+func newVec[T any](labels []string, newChild func() T) *vec[T] {
+	return &vec[T]{labels: labels, newChild: newChild, children: make(map[string]*child[T])}
+}
+
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for %d labels", len(values), len(v.labels)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok {
+		return c.metric
+	}
+	c = &child[T]{values: append([]string(nil), values...), metric: v.newChild()}
+	v.children[key] = c
+	return c.metric
+}
+
+// each visits the children ordered by their label values
+func (v *vec[T]) each(fn func(labels []string, metric T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	children := make([]*child[T], 0, len(keys))
+	sort.Strings(keys)
+	for _, key := range keys {
+		children = append(children, v.children[key])
+	}
+	v.mu.RUnlock()
+
+	for _, c := range children {
+		pairs := make([]string, 0, 2*len(v.labels))
+		for i, name := range v.labels {
+			pairs = append(pairs, name, c.values[i])
+		}
+		fn(pairs, c.metric)
+	}
+}
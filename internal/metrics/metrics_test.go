@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("http_requests_total", "Requests served.", "method", "status")
+	requests.With("GET", "200").Inc()
+	requests.With("GET", "200").Add(2)
+	requests.With("POST", "500").Inc()
+
+	games := r.NewGauge("active_games", "Games in progress.")
+	games.Inc()
+	games.Inc()
+	games.Dec()
+
+	conns := r.NewGaugeVec("db_connections", "Pooled connections.", "state")
+	conns.With("idle").Set(4)
+	conns.With("in_use").Set(2)
+	conns.With("idle").Dec()
+
+	latency := r.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	latency.With(`/say "hi"`).Observe(0.05)
+	latency.With(`/say "hi"`).Observe(0.5)
+	latency.With(`/say "hi"`).Observe(3)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, `# HELP active_games Games in progress.
+# TYPE active_games gauge
+active_games 1
+# HELP db_connections Pooled connections.
+# TYPE db_connections gauge
+db_connections{state="idle"} 3
+db_connections{state="in_use"} 2
+# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",status="200"} 3
+http_requests_total{method="POST",status="500"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/say \"hi\"",le="0.1"} 1
+latency_seconds_bucket{route="/say \"hi\"",le="1"} 2
+latency_seconds_bucket{route="/say \"hi\"",le="+Inf"} 3
+latency_seconds_sum{route="/say \"hi\""} 3.55
+latency_seconds_count{route="/say \"hi\""} 3
+`, rec.Body.String())
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("active_games", "")
+	assert.Panics(t, func() { r.NewGauge("active_games", "") })
+}
+
+func TestWrongLabelCountPanics(t *testing.T) {
+	r := NewRegistry()
+	v := r.NewCounterVec("attempts_total", "", "mode", "outcome")
+	assert.Panics(t, func() { v.With("rapid_fire") })
+}
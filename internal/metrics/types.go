@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// Counter only goes up
+type Counter struct {
+	value atomicFloat
+}
+
+func (c *Counter) Inc() { c.value.add(1) }
+
+func (c *Counter) Value() float64 { return c.value.load() }
+
+// Add increases the counter by v, ignoring negative values
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		c.value.add(v)
+	}
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	*vec[*Counter]
+}
+
+// NewCounterVec registers a counter vector with r
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newVec(labels, func() *Counter { return &Counter{} })}
+	r.register(name, help, v)
+	return v
+}
+
+// NewCounterVec registers a counter vector with the Default registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// With returns the counter for the label values, in the order the labels
+// were declared
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.with(values)
+}
+
+func (v *CounterVec) kind() string { return "counter" }
+
+func (v *CounterVec) samples() []sample {
+	var samples []sample
+	v.each(func(labels []string, c *Counter) {
+		samples = append(samples, sample{labels: labels, value: c.value.load()})
+	})
+	return samples
+}
+
+// Gauge goes up and down
+type Gauge struct {
+	value atomicFloat
+}
+
+// NewGauge registers a gauge with r
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	r.register(name, help, g)
+	return g
+}
+
+// NewGauge registers a gauge with the Default registry
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+func (g *Gauge) Set(v float64)  { g.value.set(v) }
+func (g *Gauge) Inc()           { g.value.add(1) }
+func (g *Gauge) Dec()           { g.value.add(-1) }
+func (g *Gauge) Value() float64 { return g.value.load() }
+
+func (g *Gauge) kind() string { return "gauge" }
+
+func (g *Gauge) samples() []sample {
+	return []sample{{value: g.value.load()}}
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	*vec[*Gauge]
+}
+
+// NewGaugeVec registers a gauge vector with r
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{newVec(labels, func() *Gauge { return &Gauge{} })}
+	r.register(name, help, v)
+	return v
+}
+
+// NewGaugeVec registers a gauge vector with the Default registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// With returns the gauge for the label values, in the order the labels
+// were declared
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.with(values)
+}
+
+func (v *GaugeVec) kind() string { return "gauge" }
+
+func (v *GaugeVec) samples() []sample {
+	var samples []sample
+	v.each(func(labels []string, g *Gauge) {
+		samples = append(samples, sample{labels: labels, value: g.value.load()})
+	})
+	return samples
+}
+
+// Histogram counts observations into buckets by upper bound
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64
+	count       atomic.Uint64
+	sum         atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upperBounds: buckets, counts: make([]atomic.Uint64, len(buckets))}
+}
+
+func (h *Histogram) Observe(v float64) {
+	if i := sort.SearchFloat64s(h.upperBounds, v); i < len(h.upperBounds) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	h.sum.add(v)
+}
+
+// Count is how many values have been observed
+func (h *Histogram) Count() uint64 { return h.count.Load() }
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	*vec[*Histogram]
+}
+
+// NewHistogramVec registers a histogram vector with r. Buckets must be
+// sorted; nil uses DefaultBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	v := &HistogramVec{newVec(labels, func() *Histogram { return newHistogram(buckets) })}
+	r.register(name, help, v)
+	return v
+}
+
+// NewHistogramVec registers a histogram vector with the Default registry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// With returns the histogram for the label values, in the order the labels
+// were declared
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.with(values)
+}
+
+func (v *HistogramVec) kind() string { return "histogram" }
+
+func (v *HistogramVec) samples() []sample {
+	var samples []sample
+	v.each(func(labels []string, h *Histogram) {
+		var cumulative uint64
+		for i, bound := range h.upperBounds {
+			cumulative += h.counts[i].Load()
+			samples = append(samples, sample{suffix: "_bucket", labels: withLabel(labels, "le", formatFloat(bound)), value: float64(cumulative)})
+		}
+		count := h.count.Load()
+		samples = append(samples,
+			sample{suffix: "_bucket", labels: withLabel(labels, "le", "+Inf"), value: float64(count)},
+			sample{suffix: "_sum", labels: labels, value: h.sum.load()},
+			sample{suffix: "_count", labels: labels, value: float64(count)},
+		)
+	})
+	return samples
+}
+
+func withLabel(labels []string, name, value string) []string {
+	return append(append(make([]string, 0, len(labels)+2), labels...), name, value)
+}
@@ -0,0 +1,146 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	APNsProductionHost = "https://api.push.apple.com"
+	APNsSandboxHost    = "https://api.sandbox.push.apple.com"
+
+	// Apple rejects provider tokens older than an hour and throttles ones
+	// refreshed more often than every 20 minutes
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNsConfig holds the token-based credentials from an Apple developer
+// account
+type APNsConfig struct {
+	// Key is the contents of the .p8 signing key
+	Key    []byte
+	KeyID  string
+	TeamID string
+	// Topic is the app's bundle ID
+	Topic string
+	Host  string
+}
+
+// APNs sends notifications to iOS devices
+type APNs struct {
+	client *http.Client
+	config APNsConfig
+	key    *ecdsa.PrivateKey
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNs(config APNsConfig, client *http.Client) (*APNs, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(config.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse APNs key: %w", err)
+	}
+	if config.Host == "" {
+		config.Host = APNsProductionHost
+	}
+	return &APNs{client: client, config: config, key: key}, nil
+}
+
+type apnsPayload struct {
+	Aps struct {
+		Alert struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"alert"`
+		Sound string `json:"sound"`
+	} `json:"aps"`
+	Kind Kind              `json:"kind"`
+	Data map[string]string `json:"data,omitempty"`
+}
+
+func (a *APNs) Send(ctx context.Context, token string, n Notification) error {
+	var payload apnsPayload
+	payload.Aps.Alert.Title = n.Title
+	payload.Aps.Alert.Body = n.Body
+	payload.Aps.Sound = "default"
+	payload.Kind = n.Kind
+	payload.Data = n.Data
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode APNs payload: %w", err)
+	}
+
+	bearer, err := a.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.Host+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+bearer)
+	req.Header.Set("apns-topic", a.config.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach APNs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&failure)
+
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		failure.Reason == "BadDeviceToken", failure.Reason == "Unregistered", failure.Reason == "DeviceTokenNotForTopic":
+		return ErrInvalidToken
+	default:
+		return fmt.Errorf("APNs returned %s: %s", resp.Status, failure.Reason)
+	}
+}
+
+// providerToken returns the signed JWT APNs authenticates requests with,
+// reusing it until it nears its lifetime
+func (a *APNs) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Since(a.issuedAt) < apnsTokenLifetime {
+		return a.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.config.TeamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = a.config.KeyID
+
+	signed, err := token.SignedString(a.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs token: %w", err)
+	}
+	a.token, a.issuedAt = signed, now
+	return signed, nil
+}
@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	FCMHost = "https://fcm.googleapis.com"
+
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCMCredentials is the part of a Google service account key file FCM
+// needs
+type FCMCredentials struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCM sends notifications to Android devices through the FCM HTTP v1 API
+type FCM struct {
+	client *http.Client
+	host   string
+	creds  FCMCredentials
+	key    *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCM reads a service account key file's contents
+func NewFCM(credentials []byte, client *http.Client) (*FCM, error) {
+	var creds FCMCredentials
+	if err := json.Unmarshal(credentials, &creds); err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+	if creds.ProjectID == "" || creds.ClientEmail == "" || creds.TokenURI == "" {
+		return nil, fmt.Errorf("FCM credentials are missing project_id, client_email or token_uri")
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(creds.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM key: %w", err)
+	}
+	return &FCM{client: client, host: FCMHost, creds: creds, key: key}, nil
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string `json:"token"`
+		Notification struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"notification"`
+		Data map[string]string `json:"data"`
+	} `json:"message"`
+}
+
+func (f *FCM) Send(ctx context.Context, token string, n Notification) error {
+	var msg fcmMessage
+	msg.Message.Token = token
+	msg.Message.Notification.Title = n.Title
+	msg.Message.Notification.Body = n.Body
+	msg.Message.Data = map[string]string{"kind": string(n.Kind)}
+	for k, v := range n.Data {
+		msg.Message.Data[k] = v
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode FCM message: %w", err)
+	}
+
+	accessToken, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/v1/projects/%s/messages:send", f.host, f.creds.ProjectID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach FCM: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&failure)
+
+	if resp.StatusCode == http.StatusNotFound || failure.Error.Status == "NOT_FOUND" {
+		return ErrInvalidToken
+	}
+	for _, detail := range failure.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrInvalidToken
+		}
+	}
+	return fmt.Errorf("FCM returned %s: %s", resp.Status, failure.Error.Message)
+}
+
+// token returns an OAuth access token for the service account, exchanging
+// a signed assertion for a new one shortly before the last expires
+func (f *FCM) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.accessToken != "" && time.Until(f.expiresAt) > time.Minute {
+		return f.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.creds.ClientEmail,
+		"scope": fcmScope,
+		"aud":   f.creds.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(f.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.creds.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get FCM access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("FCM token exchange returned %s", resp.Status)
+	}
+
+	var grant struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&grant); err != nil {
+		return "", fmt.Errorf("failed to read FCM access token: %w", err)
+	}
+
+	f.accessToken = grant.AccessToken
+	f.expiresAt = now.Add(time.Duration(grant.ExpiresIn) * time.Second)
+	return f.accessToken, nil
+}
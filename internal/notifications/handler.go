@@ -0,0 +1,385 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+// MaxTokenLength is well past the longest token APNs or FCM hand out
+const MaxTokenLength = 4096
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type DeviceRequest struct {
+	Token     string              `json:"token"`
+	Platform  Platform            `json:"platform"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *DeviceRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Token), "token", "Must be provided")
+	r.Validator.CheckField(validator.MaxRunes(r.Token, MaxTokenLength), "token", "Must not be more than 4096 characters")
+	r.Validator.CheckField(validator.In(r.Platform, PlatformIOS, PlatformAndroid), "platform", "Must be ios or android")
+}
+
+// PreferencesRequest changes the settings it has. Channels are set over the
+// player's others, so only the ones changing need sending.
+type PreferencesRequest struct {
+	NotificationsOn *bool               `json:"notifications_on"`
+	Channels        ChannelSettings     `json:"channels"`
+	Validator       validator.Validator `json:"-"`
+}
+
+func (r *PreferencesRequest) validate() {
+	r.Validator.CheckField(r.NotificationsOn != nil || r.Channels != nil, "notifications_on", "Must be provided unless channels are")
+	r.Validator.CheckField(r.Channels.Valid(), "channels", "Must only set the channels each kind of notification is sent on")
+}
+
+func (r *PreferencesRequest) apply(prefs *Preferences) {
+	if r.NotificationsOn != nil {
+		prefs.NotificationsOn = *r.NotificationsOn
+	}
+	prefs.Channels = prefs.Channels.Merge(r.Channels)
+}
+
+type PhoneRequest struct {
+	PhoneNumber string              `json:"phone_number"`
+	Validator   validator.Validator `json:"-"`
+}
+
+func (r *PhoneRequest) validate() {
+	r.PhoneNumber = strings.Join(strings.FieldsFunc(r.PhoneNumber, func(c rune) bool {
+		return c == ' ' || c == '-' || c == '(' || c == ')' || c == '.'
+	}), "")
+	r.Validator.CheckField(validator.Matches(r.PhoneNumber, PhoneNumberPattern), "phone_number", "Must be a phone number with its country code, like +14155550123")
+}
+
+type VerifyPhoneRequest struct {
+	Code      string              `json:"code"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *VerifyPhoneRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Code), "code", "Must be provided")
+}
+
+type SMSConsentRequest struct {
+	Consent   *bool               `json:"consent"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *SMSConsentRequest) validate() {
+	r.Validator.CheckField(r.Consent != nil, "consent", "Must be provided")
+}
+
+// RegisterDevice starts sending the user's notifications to a device
+func (h *Handler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	device, err := h.service.RegisterDevice(r.Context(), userID, req.Token, req.Platform)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(device)
+}
+
+func (h *Handler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	token := httprouter.ParamsFromContext(r.Context()).ByName("token")
+	if err := h.service.UnregisterDevice(r.Context(), userID, token); err != nil {
+		switch {
+		case errors.Is(err, ErrDeviceNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) Preferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.service.Preferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req PreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	prefs, err := h.service.Preferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.apply(prefs)
+
+	if err := h.service.UpdatePreferences(r.Context(), userID, *prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// unsubscribePage asks people who followed an unsubscribe link to confirm,
+// so mail scanners that open links don't unsubscribe anyone
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!doctype html>
+<html>
+  <head>
+    <meta name="viewport" content="width=device-width" />
+    <title>Unsubscribe from Big Spella emails</title>
+  </head>
+  <body>
+    <form method="post">
+      <input type="hidden" name="token" value="{{.}}" />
+      <p>Stop getting these emails from Big Spella?</p>
+      <button type="submit">Unsubscribe</button>
+    </form>
+  </body>
+</html>
+`))
+
+// ConfirmUnsubscribe serves the page an unsubscribe link opens
+func (h *Handler) ConfirmUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, r.URL.Query().Get("token"))
+}
+
+// Unsubscribe turns off the kind of email the signed token in the request
+// came in. Mail clients post to it for one-click unsubscribes, and the page
+// ConfirmUnsubscribe serves posts the token as a form.
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.PostFormValue("token")
+	}
+
+	kind, err := h.service.Unsubscribe(r.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidUnsubscribeToken):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"unsubscribed": kind, "channel": ChannelEmail})
+}
+
+func (h *Handler) Phone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	phone, err := h.service.Phone(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(phone)
+}
+
+// UpdatePhone texts a verification code to the number the player wants to
+// be texted on
+func (h *Handler) UpdatePhone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req PhoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	if err := h.service.StartPhoneVerification(r.Context(), userID, req.PhoneNumber); err != nil {
+		phoneError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"pending_phone_number": req.PhoneNumber})
+}
+
+// VerifyPhone takes the code texted by UpdatePhone
+func (h *Handler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req VerifyPhoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	phone, err := h.service.VerifyPhone(r.Context(), userID, req.Code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVerificationCode) {
+			req.Validator.AddFieldError("code", "Is wrong or has expired")
+			failedValidation(w, req.Validator)
+			return
+		}
+		phoneError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(phone)
+}
+
+// UpdateSMSConsent gives or withdraws the player's consent to being texted
+// on their verified number
+func (h *Handler) UpdateSMSConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req SMSConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	phone, err := h.service.SetSMSConsent(r.Context(), userID, *req.Consent, ConsentSourceSettings)
+	if err != nil {
+		phoneError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(phone)
+}
+
+func (h *Handler) RemovePhone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemovePhone(r.Context(), userID); err != nil {
+		phoneError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func phoneError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNoPhoneNumber):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrPhoneNumberTaken), errors.Is(err, ErrPhoneNotVerified):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrSMSRateLimited):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, ErrSMSDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,157 @@
+// Package notifications sends push notifications to players' registered
+// devices through APNs and FCM, and texts time-sensitive ones to players who
+// consent
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned by a Sender when the push service no longer
+// accepts a device token. The token is forgotten.
+var ErrInvalidToken = errors.New("device token is no longer valid")
+
+type Kind string
+
+const (
+	KindTurnReminder       Kind = "turn_reminder"
+	KindFriendChallenge    Kind = "friend_challenge"
+	KindGameInvitation     Kind = "game_invitation"
+	KindTournamentStarting Kind = "tournament_starting"
+	KindRankChanged        Kind = "rank_changed"
+	KindPlacementsComplete Kind = "placements_complete"
+	KindRankDecayWarning   Kind = "rank_decay_warning"
+	KindWordOfTheDay       Kind = "word_of_the_day"
+	KindGameStarting       Kind = "game_starting"
+
+	// Kinds only sent by email
+	KindGameReport   Kind = "game_report"
+	KindWeeklyDigest Kind = "weekly_digest"
+)
+
+// Notification is one push message. Data is handed to the app alongside
+// the alert so it can open the right screen.
+type Notification struct {
+	Kind  Kind              `json:"kind"`
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"`
+}
+
+// Sender delivers a notification to one device
+type Sender interface {
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// Notifier is what the rest of the API sends notifications through
+type Notifier interface {
+	Notify(ctx context.Context, userID string, n Notification)
+}
+
+func TurnReminder(gameID string) Notification {
+	return Notification{
+		Kind:  KindTurnReminder,
+		Title: "It's your turn",
+		Body:  "Your word is waiting. Spell it before the clock runs out!",
+		Data:  map[string]string{"game_id": gameID},
+	}
+}
+
+func FriendChallenge(challenger, gameID, inviteCode string) Notification {
+	return Notification{
+		Kind:  KindFriendChallenge,
+		Title: "You've been challenged",
+		Body:  fmt.Sprintf("%s challenged you to a Rapid Fire game", challenger),
+		Data:  map[string]string{"game_id": gameID, "invite_code": inviteCode},
+	}
+}
+
+// GameInvitation invites a player to host's game through the link that
+// joins them to it
+func GameInvitation(host, gameID, link string) Notification {
+	return Notification{
+		Kind:  KindGameInvitation,
+		Title: "You're invited to a game",
+		Body:  fmt.Sprintf("%s invited you to play", host),
+		Data:  map[string]string{"game_id": gameID, "link": link},
+	}
+}
+
+func TournamentStarting(tournamentID, name string) Notification {
+	return Notification{
+		Kind:  KindTournamentStarting,
+		Title: "Your tournament is starting",
+		Body:  fmt.Sprintf("%s starts soon. Get ready to spell!", name),
+		Data:  map[string]string{"tournament_id": tournamentID},
+	}
+}
+
+// GameStarting tells a player signed up for a scheduled game that its lobby
+// has opened and it starts in startsIn
+func GameStarting(gameID string, startsIn time.Duration) Notification {
+	when := "any minute now"
+	if minutes := int(startsIn.Round(time.Minute).Minutes()); minutes > 1 {
+		when = fmt.Sprintf("in %d minutes", minutes)
+	}
+	return Notification{
+		Kind:  KindGameStarting,
+		Title: "Your game is starting",
+		Body:  fmt.Sprintf("Your scheduled game starts %s. The lobby is open!", when),
+		Data:  map[string]string{"game_id": gameID},
+	}
+}
+
+// RankChanged tells a player they moved up or down a rank color
+func RankChanged(previous, current string, promoted bool) Notification {
+	title, verb := "You ranked up!", "up"
+	if !promoted {
+		title, verb = "Your rank changed", "down"
+	}
+	return Notification{
+		Kind:  KindRankChanged,
+		Title: title,
+		Body:  fmt.Sprintf("You moved %s from %s to %s", verb, strings.ToLower(previous), strings.ToLower(current)),
+		Data:  map[string]string{"previous_rank": previous, "rank": current},
+	}
+}
+
+// PlacementsComplete tells a new player the rank their placement games
+// earned them
+func PlacementsComplete(rank string) Notification {
+	return Notification{
+		Kind:  KindPlacementsComplete,
+		Title: "Placements complete",
+		Body:  fmt.Sprintf("You've been placed in %s", strings.ToLower(rank)),
+		Data:  map[string]string{"rank": rank},
+	}
+}
+
+// RankDecayWarning tells a player who has stopped playing ranked games that
+// their rating starts to decay in days
+func RankDecayWarning(rank string, days int) Notification {
+	when := fmt.Sprintf("in the next %d days", days)
+	if days == 1 {
+		when = "today"
+	}
+	return Notification{
+		Kind:  KindRankDecayWarning,
+		Title: "Your rank is about to decay",
+		Body:  fmt.Sprintf("Play a ranked game %s to keep your %s rank from decaying", when, strings.ToLower(rank)),
+		Data:  map[string]string{"rank": rank, "days": strconv.Itoa(days)},
+	}
+}
+
+// WordOfTheDay sends a subscriber the day's word
+func WordOfTheDay(date, word, definition string) Notification {
+	return Notification{
+		Kind:  KindWordOfTheDay,
+		Title: fmt.Sprintf("Word of the day: %s", word),
+		Body:  definition,
+		Data:  map[string]string{"date": date},
+	}
+}
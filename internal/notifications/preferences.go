@@ -0,0 +1,172 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe link")
+
+// Channel is a way of reaching a player
+type Channel string
+
+const (
+	ChannelPush  Channel = "push"
+	ChannelEmail Channel = "email"
+	// ChannelSMS is only for time-sensitive alerts, and only reaches players
+	// who have verified their number and consented to texts
+	ChannelSMS Channel = "sms"
+)
+
+// Kinds are the kinds of notification players can turn on and off, with
+// the channels each is sent on
+var Kinds = map[Kind][]Channel{
+	KindTurnReminder:       {ChannelPush, ChannelSMS},
+	KindFriendChallenge:    {ChannelPush},
+	KindGameInvitation:     {ChannelPush, ChannelEmail},
+	KindTournamentStarting: {ChannelPush, ChannelEmail, ChannelSMS},
+	KindGameStarting:       {ChannelPush, ChannelSMS},
+	KindRankChanged:        {ChannelPush},
+	KindPlacementsComplete: {ChannelPush},
+	KindRankDecayWarning:   {ChannelPush},
+	KindWordOfTheDay:       {ChannelPush, ChannelEmail},
+	KindGameReport:         {ChannelEmail},
+	KindWeeklyDigest:       {ChannelEmail},
+}
+
+// SentOn reports whether kind is sent on channel
+func SentOn(kind Kind, channel Channel) bool {
+	return slices.Contains(Kinds[kind], channel)
+}
+
+// ChannelSettings are the channels a player has turned on or off for each
+// kind of notification. Channels they haven't set are on.
+type ChannelSettings map[Kind]map[Channel]bool
+
+// Allows reports whether kind may be sent on channel
+func (c ChannelSettings) Allows(kind Kind, channel Channel) bool {
+	on, ok := c[kind][channel]
+	return !ok || on
+}
+
+// Merge sets the channels in changes over c's
+func (c ChannelSettings) Merge(changes ChannelSettings) ChannelSettings {
+	merged := make(ChannelSettings, len(c)+len(changes))
+	for _, settings := range []ChannelSettings{c, changes} {
+		for kind, channels := range settings {
+			if merged[kind] == nil {
+				merged[kind] = make(map[Channel]bool, len(channels))
+			}
+			for channel, on := range channels {
+				merged[kind][channel] = on
+			}
+		}
+	}
+	return merged
+}
+
+// Valid reports whether every kind and channel in c is one that players can
+// set
+func (c ChannelSettings) Valid() bool {
+	for kind, channels := range c {
+		if _, ok := Kinds[kind]; !ok {
+			return false
+		}
+		for channel := range channels {
+			if !SentOn(kind, channel) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c ChannelSettings) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *ChannelSettings) Scan(value any) error {
+	data, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("can't scan %T into channel settings", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// EmailRecipient finds the player with email and reports whether they take
+// emails of kind. Addresses that aren't a player's, like the admin's, take
+// every email and have no player ID.
+func (s *Service) EmailRecipient(ctx context.Context, email string, kind Kind) (string, bool, error) {
+	var recipient struct {
+		ID       string          `db:"id"`
+		Channels ChannelSettings `db:"notification_channels"`
+	}
+	if err := s.db.GetContext(ctx, &recipient, `
+		SELECT u.id, COALESCE(p.notification_channels, '{}') AS notification_channels
+		FROM users u
+		LEFT JOIN user_preferences p ON p.user_id = u.id
+		WHERE lower(u.email) = lower($1)`, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", true, nil
+		}
+		return "", false, fmt.Errorf("failed to get email preferences: %w", err)
+	}
+	return recipient.ID, recipient.Channels.Allows(kind, ChannelEmail), nil
+}
+
+// UnsubscribeToken signs a token for the link in an email of kind that
+// stops userID being sent them. It doesn't expire.
+func (s *Service) UnsubscribeToken(userID string, kind Kind) string {
+	payload := userID + ":" + string(kind)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+func (s *Service) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.unsubscribeKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// Unsubscribe turns off the emails token was signed for, returning their
+// kind
+func (s *Service) Unsubscribe(ctx context.Context, token string) (Kind, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || len(s.unsubscribeKey) == 0 {
+		return "", ErrInvalidUnsubscribeToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidUnsubscribeToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(string(payload))) {
+		return "", ErrInvalidUnsubscribeToken
+	}
+	userID, kind, ok := strings.Cut(string(payload), ":")
+	if !ok || !SentOn(Kind(kind), ChannelEmail) {
+		return "", ErrInvalidUnsubscribeToken
+	}
+
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	prefs.Channels = prefs.Channels.Merge(ChannelSettings{Kind(kind): {ChannelEmail: false}})
+	if err := s.UpdatePreferences(ctx, userID, *prefs); err != nil {
+		return "", err
+	}
+	return Kind(kind), nil
+}
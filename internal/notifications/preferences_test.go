@@ -0,0 +1,73 @@
+package notifications
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelSettings(t *testing.T) {
+	var settings ChannelSettings
+	assert.True(t, settings.Allows(KindGameInvitation, ChannelEmail))
+
+	settings = settings.Merge(ChannelSettings{KindGameInvitation: {ChannelEmail: false}})
+	settings = settings.Merge(ChannelSettings{KindGameInvitation: {ChannelPush: true}})
+	assert.False(t, settings.Allows(KindGameInvitation, ChannelEmail))
+	assert.True(t, settings.Allows(KindGameInvitation, ChannelPush))
+	assert.True(t, settings.Allows(KindWordOfTheDay, ChannelEmail))
+
+	assert.True(t, settings.Valid())
+	assert.False(t, ChannelSettings{KindTurnReminder: {ChannelEmail: false}}.Valid())
+	assert.False(t, ChannelSettings{"newsletter": {ChannelEmail: false}}.Valid())
+
+	value, err := ChannelSettings(nil).Value()
+	require.NoError(t, err)
+	var scanned ChannelSettings
+	require.NoError(t, scanned.Scan(value))
+	assert.Empty(t, scanned)
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	s := NewService(nil, nil, WithUnsubscribeKey([]byte("secret")))
+	token := s.UnsubscribeToken("user-1", KindGameInvitation)
+	assert.Equal(t, token, s.UnsubscribeToken("user-1", KindGameInvitation))
+	assert.NotEqual(t, token, NewService(nil, nil, WithUnsubscribeKey([]byte("other"))).UnsubscribeToken("user-1", KindGameInvitation))
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	forged := s.UnsubscribeToken("user-2", KindGameInvitation)
+	_, forgedSignature, _ := strings.Cut(forged, ".")
+
+	for _, token := range []string{
+		"",
+		"nodot",
+		encoded + "." + forgedSignature,
+		encoded + ".!!",
+		// Turn reminders are never emailed
+		s.UnsubscribeToken("user-1", KindTurnReminder),
+	} {
+		_, err := s.Unsubscribe(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidUnsubscribeToken, token)
+	}
+
+	_, err := NewService(nil, nil).Unsubscribe(context.Background(), encoded+"."+signature)
+	assert.ErrorIs(t, err, ErrInvalidUnsubscribeToken)
+}
+
+func TestPreferencesRequestChannels(t *testing.T) {
+	req := PreferencesRequest{Channels: ChannelSettings{KindWeeklyDigest: {ChannelEmail: false}}}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	prefs := Preferences{NotificationsOn: true, Channels: ChannelSettings{KindGameReport: {ChannelEmail: false}}}
+	req.apply(&prefs)
+	assert.True(t, prefs.NotificationsOn)
+	assert.False(t, prefs.Channels.Allows(KindGameReport, ChannelEmail))
+	assert.False(t, prefs.Channels.Allows(KindWeeklyDigest, ChannelEmail))
+
+	req = PreferencesRequest{Channels: ChannelSettings{KindWeeklyDigest: {ChannelPush: false}}}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "channels")
+}
@@ -0,0 +1,176 @@
+package notifications
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPNsSend(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var got struct {
+		path, auth, topic string
+		payload           apnsPayload
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.auth, got.topic = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("apns-topic")
+		json.NewDecoder(r.Body).Decode(&got.payload)
+
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+		}
+	}))
+	defer server.Close()
+
+	apns, err := NewAPNs(APNsConfig{
+		Key:    pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		KeyID:  "KEY123",
+		TeamID: "TEAM123",
+		Topic:  "com.bigspella.app",
+		Host:   server.URL,
+	}, server.Client())
+	require.NoError(t, err)
+
+	err = apns.Send(context.Background(), "abc", TurnReminder("game-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "/3/device/abc", got.path)
+	assert.True(t, strings.HasPrefix(got.auth, "bearer "))
+	assert.Equal(t, "com.bigspella.app", got.topic)
+	assert.Equal(t, "It's your turn", got.payload.Aps.Alert.Title)
+	assert.Equal(t, "game-1", got.payload.Data["game_id"])
+
+	token := got.auth
+	err = apns.Send(context.Background(), "gone", TurnReminder("game-1"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, token, got.auth, "the provider token is reused")
+}
+
+func TestFCMSend(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	exchanges := 0
+	var message fcmMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			exchanges++
+			r.ParseForm()
+			assert.NotEmpty(t, r.Form.Get("assertion"))
+			w.Write([]byte(`{"access_token":"ya29.token","expires_in":3600}`))
+		case "/v1/projects/spella/messages:send":
+			assert.Equal(t, "Bearer ya29.token", r.Header.Get("Authorization"))
+			json.NewDecoder(r.Body).Decode(&message)
+			if message.Message.Token == "stale" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	credentials, _ := json.Marshal(FCMCredentials{
+		ProjectID:   "spella",
+		ClientEmail: "push@spella.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		TokenURI:    server.URL + "/token",
+	})
+	fcm, err := NewFCM(credentials, server.Client())
+	require.NoError(t, err)
+	fcm.host = server.URL
+
+	err = fcm.Send(context.Background(), "device", FriendChallenge("ada", "game-1", "ABCD2345"))
+	require.NoError(t, err)
+	assert.Equal(t, "device", message.Message.Token)
+	assert.Equal(t, "friend_challenge", message.Message.Data["kind"])
+	assert.Equal(t, "ABCD2345", message.Message.Data["invite_code"])
+
+	err = fcm.Send(context.Background(), "stale", FriendChallenge("ada", "game-1", "ABCD2345"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, 1, exchanges, "the access token is reused until it nears expiry")
+}
+
+func TestGameInvitation(t *testing.T) {
+	n := GameInvitation("ada", "game-1", "https://example.com/join?game=game-1&token=abc")
+	assert.Equal(t, KindGameInvitation, n.Kind)
+	assert.Equal(t, "ada invited you to play", n.Body)
+	assert.Equal(t, "https://example.com/join?game=game-1&token=abc", n.Data["link"])
+}
+
+func TestGameStarting(t *testing.T) {
+	n := GameStarting("game-1", 14*time.Minute+50*time.Second)
+	assert.Equal(t, KindGameStarting, n.Kind)
+	assert.Equal(t, "Your scheduled game starts in 15 minutes. The lobby is open!", n.Body)
+	assert.Equal(t, "game-1", n.Data["game_id"])
+
+	n = GameStarting("game-1", 20*time.Second)
+	assert.Equal(t, "Your scheduled game starts any minute now. The lobby is open!", n.Body)
+}
+
+func TestRankChanged(t *testing.T) {
+	n := RankChanged("Blue", "Green", true)
+	assert.Equal(t, "You ranked up!", n.Title)
+	assert.Equal(t, "You moved up from blue to green", n.Body)
+
+	n = RankChanged("Green", "Blue", false)
+	assert.Equal(t, "You moved down from green to blue", n.Body)
+}
+
+func TestPlacementsComplete(t *testing.T) {
+	n := PlacementsComplete("Green")
+	assert.Equal(t, KindPlacementsComplete, n.Kind)
+	assert.Equal(t, "You've been placed in green", n.Body)
+	assert.Equal(t, "Green", n.Data["rank"])
+}
+
+func TestRankDecayWarning(t *testing.T) {
+	n := RankDecayWarning("Yellow", 3)
+	assert.Equal(t, KindRankDecayWarning, n.Kind)
+	assert.Equal(t, "Play a ranked game in the next 3 days to keep your yellow rank from decaying", n.Body)
+	assert.Equal(t, "3", n.Data["days"])
+
+	n = RankDecayWarning("Yellow", 1)
+	assert.Equal(t, "Play a ranked game today to keep your yellow rank from decaying", n.Body)
+}
+
+func TestWordOfTheDay(t *testing.T) {
+	n := WordOfTheDay("2026-10-15", "rhythm", "a strong, regular repeated pattern")
+	assert.Equal(t, KindWordOfTheDay, n.Kind)
+	assert.Equal(t, "Word of the day: rhythm", n.Title)
+	assert.Equal(t, "2026-10-15", n.Data["date"])
+}
+
+func TestDeviceRequestValidation(t *testing.T) {
+	req := DeviceRequest{Token: "abc", Platform: PlatformIOS}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = DeviceRequest{Token: " ", Platform: "windows"}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "token")
+	assert.Contains(t, req.Validator.FieldErrors, "platform")
+
+	prefs := PreferencesRequest{}
+	prefs.validate()
+	assert.Contains(t, prefs.Validator.FieldErrors, "notifications_on")
+}
@@ -0,0 +1,250 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrDeviceNotFound = errors.New("device not registered")
+
+type Platform string
+
+const (
+	PlatformIOS     Platform = "ios"
+	PlatformAndroid Platform = "android"
+)
+
+const (
+	// TournamentStartLead is how long before a tournament starts its
+	// players are told
+	TournamentStartLead = 10 * time.Minute
+
+	sendTimeout = 30 * time.Second
+)
+
+type Device struct {
+	Token     string    `json:"token" db:"token"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Platform  Platform  `json:"platform" db:"platform"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Preferences are the settings from UserPreferences that decide whether a
+// player is sent notifications. NotificationsOn turns every push
+// notification on or off, and Channels each kind of notification on each
+// channel.
+type Preferences struct {
+	NotificationsOn bool            `json:"notifications_on" db:"notifications_on"`
+	Channels        ChannelSettings `json:"channels" db:"notification_channels"`
+}
+
+type ServiceOption func(*Service)
+
+// WithSender delivers notifications to platform's devices through sender.
+// Devices on a platform without a sender are skipped.
+func WithSender(platform Platform, sender Sender) ServiceOption {
+	return func(s *Service) {
+		s.senders[platform] = sender
+	}
+}
+
+// WithUnsubscribeKey signs the links that unsubscribe players from emails
+// with key. Without it no link is accepted.
+func WithUnsubscribeKey(key []byte) ServiceOption {
+	return func(s *Service) {
+		s.unsubscribeKey = key
+	}
+}
+
+type Service struct {
+	db             *sqlx.DB
+	senders        map[Platform]Sender
+	sms            SMSSender
+	unsubscribeKey []byte
+	onError        func(error)
+}
+
+// NewService reports failed deliveries, which happen in the background, to
+// onError
+func NewService(db *sqlx.DB, onError func(error), opts ...ServiceOption) *Service {
+	s := &Service{db: db, senders: map[Platform]Sender{}, onError: onError}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RegisterDevice adds a device to the user's, taking it over if another
+// account had registered it
+func (s *Service) RegisterDevice(ctx context.Context, userID, token string, platform Platform) (*Device, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	device := &Device{}
+	if err := tx.GetContext(ctx, device, `
+		INSERT INTO device_tokens (token, user_id, platform)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (token) DO UPDATE
+		SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, updated_at = NOW()
+		RETURNING *`, token, userID, platform); err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+
+	// A token only notifies its latest user, but every account signed in on
+	// the device is remembered so the cheat screen can spot multi-accounting
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO device_accounts (token, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (token, user_id) DO UPDATE SET last_seen_at = NOW()`, token, userID); err != nil {
+		return nil, fmt.Errorf("failed to record device account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit device: %w", err)
+	}
+	return device, nil
+}
+
+func (s *Service) UnregisterDevice(ctx context.Context, userID, token string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM device_tokens WHERE token = $1 AND user_id = $2`, token, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unregister device: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (s *Service) Preferences(ctx context.Context, userID string) (*Preferences, error) {
+	prefs := &Preferences{}
+	if err := s.db.GetContext(ctx, prefs, `
+		SELECT
+			COALESCE((SELECT notifications_on FROM user_preferences WHERE user_id = $1), TRUE) AS notifications_on,
+			COALESCE((SELECT notification_channels FROM user_preferences WHERE user_id = $1), '{}') AS notification_channels`,
+		userID); err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+func (s *Service) UpdatePreferences(ctx context.Context, userID string, prefs Preferences) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_preferences (user_id, notifications_on, notification_channels)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET notifications_on = EXCLUDED.notifications_on,
+			notification_channels = EXCLUDED.notification_channels,
+			updated_at = NOW()`,
+		userID, prefs.NotificationsOn, prefs.Channels); err != nil {
+		return fmt.Errorf("failed to update preferences: %w", err)
+	}
+	return nil
+}
+
+// Notify sends n to the user's devices in the background, unless they have
+// turned notifications, or n's kind of them, off
+func (s *Service) Notify(ctx context.Context, userID string, n Notification) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+	go func() {
+		defer cancel()
+		if err := s.Send(ctx, userID, n); err != nil && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+}
+
+// Send delivers n to each of the user's devices, forgetting tokens the push
+// services reject, and texts it to players who take it by SMS
+func (s *Service) Send(ctx context.Context, userID string, n Notification) error {
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{s.sendSMS(ctx, userID, n, prefs)}
+	if !prefs.NotificationsOn || !prefs.Channels.Allows(n.Kind, ChannelPush) {
+		return errors.Join(errs...)
+	}
+
+	var devices []Device
+	if err := s.db.SelectContext(ctx, &devices, `
+		SELECT * FROM device_tokens WHERE user_id = $1`, userID); err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to get devices: %w", err))...)
+	}
+
+	for _, device := range devices {
+		sender, ok := s.senders[device.Platform]
+		if !ok {
+			continue
+		}
+
+		err := sender.Send(ctx, device.Token, n)
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			if _, err := s.db.ExecContext(ctx, `DELETE FROM device_tokens WHERE token = $1`, device.Token); err != nil {
+				errs = append(errs, fmt.Errorf("failed to forget device: %w", err))
+			}
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to send %s notification to %s device: %w", n.Kind, device.Platform, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyTournamentStarts tells the players of tournaments starting within
+// TournamentStartLead, once per tournament
+func (s *Service) NotifyTournamentStarts(ctx context.Context) error {
+	var tournaments []struct {
+		ID   string `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := s.db.SelectContext(ctx, &tournaments, `
+		UPDATE tournaments
+		SET start_notified_at = NOW()
+		WHERE start_notified_at IS NULL
+			AND start_time > NOW() AND start_time <= $1
+			AND status NOT IN ('cancelled', 'completed', 'finished')
+		RETURNING id, name`, time.Now().Add(TournamentStartLead)); err != nil {
+		return fmt.Errorf("failed to claim starting tournaments: %w", err)
+	}
+
+	for _, tournament := range tournaments {
+		var players []string
+		if err := s.db.SelectContext(ctx, &players, `
+			SELECT player_id FROM tournament_players
+			WHERE tournament_id = $1 AND NOT eliminated`, tournament.ID); err != nil {
+			return fmt.Errorf("failed to get tournament players: %w", err)
+		}
+		for _, playerID := range players {
+			s.Notify(ctx, playerID, TournamentStarting(tournament.ID, tournament.Name))
+		}
+	}
+	return nil
+}
+
+// Run checks for starting tournaments every interval until ctx is done
+func (s *Service) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.NotifyTournamentStarts(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
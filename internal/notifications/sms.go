@@ -0,0 +1,359 @@
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+)
+
+var (
+	ErrSMSDisabled             = errors.New("text messages are not enabled")
+	ErrNoPhoneNumber           = errors.New("no phone number")
+	ErrPhoneNumberTaken        = errors.New("phone number belongs to another account")
+	ErrPhoneNotVerified        = errors.New("phone number has not been verified")
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+	ErrSMSRateLimited          = errors.New("too many text messages")
+	// ErrPhoneOptedOut is returned by an SMSSender when the number has
+	// replied STOP, or otherwise opted out with its carrier. The player's
+	// consent is withdrawn.
+	ErrPhoneOptedOut = errors.New("phone number has opted out of text messages")
+)
+
+const (
+	// SMS caps how many texts a player is sent, so a busy evening of games
+	// doesn't become a pile of messages. Verification codes count too.
+	SMSPerHour = 5
+	SMSPerDay  = 20
+	// VerificationCodesPerDay caps the codes a player can have texted, so
+	// verification can't be used to text strangers
+	VerificationCodesPerDay = 5
+
+	uniqueViolation = "23505"
+
+	verificationCodeExpiry   = 10 * time.Minute
+	verificationCodeAttempts = 5
+	smsKindVerification      = "phone_verification"
+)
+
+// Consent sources note how a player's consent to texts changed
+const (
+	ConsentSourceSettings      = "settings"
+	ConsentSourceNumberRemoved = "number_removed"
+	ConsentSourceCarrierOptOut = "carrier_opt_out"
+)
+
+// PhoneNumberPattern matches numbers in E.164 format, like +14155550123
+var PhoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// SMSSender texts a phone number
+type SMSSender interface {
+	SendSMS(ctx context.Context, phoneNumber, message string) error
+}
+
+// WithSMS texts time-sensitive notifications through sender to players who
+// have verified their number and consented. Without it phone numbers can't
+// be verified.
+func WithSMS(sender SMSSender) ServiceOption {
+	return func(s *Service) {
+		s.sms = sender
+	}
+}
+
+// Phone is a player's verified phone number and whether they consent to
+// being texted on it. PendingNumber is a number they've been sent a code
+// for but haven't verified yet. Deleted is set once they've deleted their
+// account.
+type Phone struct {
+	PhoneNumber   *string    `json:"phone_number" db:"phone_number"`
+	VerifiedAt    *time.Time `json:"verified_at" db:"phone_verified_at"`
+	SMSConsentAt  *time.Time `json:"sms_consent_at" db:"sms_consent_at"`
+	PendingNumber *string    `json:"pending_phone_number" db:"pending_phone_number"`
+	Deleted       bool       `json:"-" db:"deleted"`
+}
+
+func (s *Service) Phone(ctx context.Context, userID string) (*Phone, error) {
+	phone := &Phone{}
+	if err := s.db.GetContext(ctx, phone, `
+		SELECT u.phone_number, u.phone_verified_at, u.sms_consent_at, v.phone_number AS pending_phone_number,
+			u.deleted_at IS NOT NULL AS deleted
+		FROM users u
+		LEFT JOIN phone_verifications v ON v.user_id = u.id AND v.expires_at > NOW()
+		WHERE u.id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get phone number: %w", err)
+	}
+	return phone, nil
+}
+
+// StartPhoneVerification texts a code to number, which becomes the
+// player's once they send it back to VerifyPhone
+func (s *Service) StartPhoneVerification(ctx context.Context, userID, number string) error {
+	if s.sms == nil {
+		return ErrSMSDisabled
+	}
+
+	var taken bool
+	if err := s.db.GetContext(ctx, &taken, `
+		SELECT EXISTS (SELECT 1 FROM users WHERE phone_number = $1 AND id <> $2)`, number, userID); err != nil {
+		return fmt.Errorf("failed to check phone number: %w", err)
+	}
+	if taken {
+		return ErrPhoneNumberTaken
+	}
+
+	if err := s.checkSMSRate(ctx, userID, smsKindVerification, VerificationCodesPerDay); err != nil {
+		return err
+	}
+
+	code, err := verificationCode()
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO phone_verifications (user_id, phone_number, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET phone_number = EXCLUDED.phone_number, code_hash = EXCLUDED.code_hash,
+			attempts = 0, expires_at = EXCLUDED.expires_at, created_at = NOW()`,
+		userID, number, hashCode(code), time.Now().Add(verificationCodeExpiry)); err != nil {
+		return fmt.Errorf("failed to save verification code: %w", err)
+	}
+
+	message := fmt.Sprintf("Your Big Spella verification code is %s. It expires in %d minutes.", code, int(verificationCodeExpiry.Minutes()))
+	return s.text(ctx, userID, number, smsKindVerification, message)
+}
+
+// VerifyPhone makes the number the player was texted code for theirs. A
+// new number needs consent again before it is texted.
+func (s *Service) VerifyPhone(ctx context.Context, userID, code string) (*Phone, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var verification struct {
+		PhoneNumber string    `db:"phone_number"`
+		CodeHash    string    `db:"code_hash"`
+		Attempts    int       `db:"attempts"`
+		ExpiresAt   time.Time `db:"expires_at"`
+	}
+	if err := tx.GetContext(ctx, &verification, `
+		UPDATE phone_verifications SET attempts = attempts + 1
+		WHERE user_id = $1
+		RETURNING phone_number, code_hash, attempts, expires_at`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidVerificationCode
+		}
+		return nil, fmt.Errorf("failed to get verification code: %w", err)
+	}
+	// Wrong guesses are counted even though the code is rejected
+	if verification.Attempts > verificationCodeAttempts || time.Now().After(verification.ExpiresAt) ||
+		subtle.ConstantTimeCompare([]byte(hashCode(code)), []byte(verification.CodeHash)) != 1 {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to count verification attempt: %w", err)
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET sms_consent_at = CASE WHEN phone_number = $2 THEN sms_consent_at END,
+			phone_number = $2, phone_verified_at = NOW()
+		WHERE id = $1`, userID, verification.PhoneNumber); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil, ErrPhoneNumberTaken
+		}
+		return nil, fmt.Errorf("failed to verify phone number: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM phone_verifications WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to clear verification code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit phone number: %w", err)
+	}
+	return s.Phone(ctx, userID)
+}
+
+// SetSMSConsent records the player giving or withdrawing consent to texts
+// on their verified number, noting source and where the request came from
+func (s *Service) SetSMSConsent(ctx context.Context, userID string, consent bool, source string) (*Phone, error) {
+	phone, err := s.Phone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if phone.PhoneNumber == nil {
+		return nil, ErrNoPhoneNumber
+	}
+	if phone.VerifiedAt == nil {
+		return nil, ErrPhoneNotVerified
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET sms_consent_at = CASE WHEN $2 THEN COALESCE(sms_consent_at, NOW()) END
+		WHERE id = $1`, userID, consent); err != nil {
+		return nil, fmt.Errorf("failed to update consent: %w", err)
+	}
+	if err := recordConsent(ctx, tx, userID, *phone.PhoneNumber, consent, source); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit consent: %w", err)
+	}
+	return s.Phone(ctx, userID)
+}
+
+// RemovePhone forgets the player's number, withdrawing their consent to
+// texts on it
+func (s *Service) RemovePhone(ctx context.Context, userID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var number sql.NullString
+	if err := tx.GetContext(ctx, &number, `
+		SELECT phone_number FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return fmt.Errorf("failed to get phone number: %w", err)
+	}
+	if !number.Valid {
+		return ErrNoPhoneNumber
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET phone_number = NULL, phone_verified_at = NULL, sms_consent_at = NULL
+		WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to remove phone number: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM phone_verifications WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear verification code: %w", err)
+	}
+	if err := recordConsent(ctx, tx, userID, number.String, false, ConsentSourceNumberRemoved); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit phone number removal: %w", err)
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func recordConsent(ctx context.Context, db execer, userID, number string, consent bool, source string) error {
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO sms_consents (user_id, phone_number, consented, source, ip_address)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))`,
+		userID, number, consent, source, audit.IP(ctx)); err != nil {
+		return fmt.Errorf("failed to record consent: %w", err)
+	}
+	return nil
+}
+
+// sendSMS texts n to the player if it's a kind sent by text, they still
+// have an account, have consented to texts on a verified number, haven't turned n's kind off, and
+// are under the rate caps. Texts over the caps are dropped.
+func (s *Service) sendSMS(ctx context.Context, userID string, n Notification, prefs *Preferences) error {
+	if s.sms == nil || !SentOn(n.Kind, ChannelSMS) || !prefs.Channels.Allows(n.Kind, ChannelSMS) {
+		return nil
+	}
+
+	phone, err := s.Phone(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if phone.Deleted || phone.PhoneNumber == nil || phone.VerifiedAt == nil || phone.SMSConsentAt == nil {
+		return nil
+	}
+
+	if err := s.checkSMSRate(ctx, userID, "", SMSPerDay); errors.Is(err, ErrSMSRateLimited) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = s.text(ctx, userID, *phone.PhoneNumber, string(n.Kind), SMSText(n))
+	if errors.Is(err, ErrPhoneOptedOut) {
+		_, err = s.SetSMSConsent(ctx, userID, false, ConsentSourceCarrierOptOut)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to text %s notification: %w", n.Kind, err)
+	}
+	return nil
+}
+
+// checkSMSRate returns ErrSMSRateLimited if the player has been sent
+// perDay texts of kind in the last day, or of any kind if it's empty, or
+// SMSPerHour texts of any kind in the last hour
+func (s *Service) checkSMSRate(ctx context.Context, userID, kind string, perDay int) error {
+	var sent struct {
+		LastHour int `db:"last_hour"`
+		LastDay  int `db:"last_day"`
+	}
+	if err := s.db.GetContext(ctx, &sent, `
+		SELECT
+			COUNT(*) FILTER (WHERE sent_at > NOW() - INTERVAL '1 hour') AS last_hour,
+			COUNT(*) FILTER (WHERE $2 = '' OR kind = $2) AS last_day
+		FROM sms_messages
+		WHERE user_id = $1 AND sent_at > NOW() - INTERVAL '1 day'`, userID, kind); err != nil {
+		return fmt.Errorf("failed to count text messages: %w", err)
+	}
+	if sent.LastHour >= SMSPerHour || sent.LastDay >= perDay {
+		return ErrSMSRateLimited
+	}
+	return nil
+}
+
+// text sends message to number, counting it against the player's caps
+// whether or not it gets through
+func (s *Service) text(ctx context.Context, userID, number, kind, message string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO sms_messages (user_id, phone_number, kind) VALUES ($1, $2, $3)`,
+		userID, number, kind); err != nil {
+		return fmt.Errorf("failed to record text message: %w", err)
+	}
+	return s.sms.SendSMS(ctx, number, message)
+}
+
+// SMSText is the text message for n, which leads with the app's name since
+// texts don't show one
+func SMSText(n Notification) string {
+	return "Big Spella: " + strings.TrimSpace(n.Body) + " Reply STOP to opt out."
+}
+
+func verificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMSKinds(t *testing.T) {
+	assert.True(t, SentOn(KindTurnReminder, ChannelSMS))
+	assert.True(t, SentOn(KindTournamentStarting, ChannelSMS))
+	assert.True(t, SentOn(KindGameStarting, ChannelSMS))
+	assert.False(t, SentOn(KindWordOfTheDay, ChannelSMS))
+
+	assert.True(t, ChannelSettings{KindTurnReminder: {ChannelSMS: false}}.Valid())
+	assert.False(t, ChannelSettings{KindRankChanged: {ChannelSMS: true}}.Valid())
+}
+
+func TestSMSText(t *testing.T) {
+	assert.Equal(t,
+		"Big Spella: Spring Bee starts soon. Get ready to spell! Reply STOP to opt out.",
+		SMSText(TournamentStarting("t1", "Spring Bee")))
+}
+
+func TestVerificationCode(t *testing.T) {
+	code, err := verificationCode()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9]{6}$`), code)
+	assert.Equal(t, hashCode(code), hashCode(" "+code+"\n"))
+	assert.NotEqual(t, hashCode(code), hashCode("1"+code))
+}
+
+func TestPhoneRequestValidation(t *testing.T) {
+	req := PhoneRequest{PhoneNumber: "+1 (415) 555-0123"}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, "+14155550123", req.PhoneNumber)
+
+	for _, number := range []string{"", "4155550123", "+0123456789", "+1415555012a", "+1234567890123456"} {
+		req := PhoneRequest{PhoneNumber: number}
+		req.validate()
+		assert.Contains(t, req.Validator.FieldErrors, "phone_number", number)
+	}
+
+	consent := SMSConsentRequest{}
+	consent.validate()
+	assert.Contains(t, consent.Validator.FieldErrors, "consent")
+}
+
+func TestPhoneVerificationNeedsSMS(t *testing.T) {
+	err := NewService(nil, nil).StartPhoneVerification(context.Background(), "user-1", "+14155550123")
+	assert.ErrorIs(t, err, ErrSMSDisabled)
+}
@@ -0,0 +1,184 @@
+// Package openapi builds an OpenAPI 3 document of the API from its routes as
+// they're registered, and holds requests to the request bodies it describes,
+// so client SDKs are generated from the same contract the server enforces
+package openapi
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+// MaxBodyBytes is the largest request body that's checked against its
+// schema, the same limit the request package decodes up to
+const MaxBodyBytes = 1_048_576
+
+var (
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// Spec is the API's OpenAPI document
+type Spec struct {
+	doc *openapi3.T
+}
+
+func New(title, version string) *Spec {
+	apiKey := openapi3.NewSecurityScheme().WithType("apiKey").WithIn("header").WithName(auth.APIKeyHeader)
+	return &Spec{doc: &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: title, Version: version},
+		Paths:   openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"bearer": &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()},
+				"apiKey": &openapi3.SecuritySchemeRef{Value: apiKey},
+			},
+		},
+	}}
+}
+
+// Route describes the route for method and path, an httprouter pattern.
+// When body isn't nil the route takes a JSON body shaped like it, and the
+// handler returned refuses bodies that aren't before they reach next.
+// Like httprouter, it panics on routes it can't describe, since they're
+// registered as the server starts.
+func (s *Spec) Route(method, path string, body any, next http.Handler) http.Handler {
+	op := openapi3.NewOperation()
+	op.Responses = openapi3.NewResponses(openapi3.WithName("default", openapi3.NewResponse().WithDescription("The route's response")))
+
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if segments[0] != "" && !isParam(segments[0]) {
+		op.Tags = []string{segments[0]}
+	}
+	for i, segment := range segments {
+		if !isParam(segment) {
+			continue
+		}
+		name := segment[1:]
+		op.AddParameter(openapi3.NewPathParameter(name).WithSchema(openapi3.NewStringSchema()))
+		segments[i] = "{" + name + "}"
+	}
+
+	if body == nil {
+		s.doc.AddOperation("/"+strings.Join(segments, "/"), method, op)
+		return next
+	}
+
+	schema, err := openapi3gen.NewSchemaRefForValue(body, nil, openapi3gen.SchemaCustomizer(customize))
+	if err != nil {
+		panic("openapi: describing " + method + " " + path + ": " + err.Error())
+	}
+	op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchemaRef(schema)}
+	op.AddResponse(http.StatusUnprocessableEntity, openapi3.NewResponse().WithDescription("The body doesn't match its schema"))
+	s.doc.AddOperation("/"+strings.Join(segments, "/"), method, op)
+
+	return enforce(schema.Value, next)
+}
+
+// ServeHTTP serves the document as JSON
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := response.JSON(w, http.StatusOK, s.doc); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*")
+}
+
+// customize describes types that decode themselves by what they decode
+// from, so IDs and timestamps read as strings rather than byte arrays
+func customize(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	ptr := reflect.PointerTo(t)
+	switch {
+	case ptr.Implements(textUnmarshaler):
+		schema.Type = &openapi3.Types{openapi3.TypeString}
+		schema.Properties, schema.Items = nil, nil
+	case ptr.Implements(jsonUnmarshaler):
+		*schema = openapi3.Schema{Nullable: schema.Nullable}
+	}
+	return nil
+}
+
+// enforce checks bodies sent to next against schema. Empty bodies pass
+// through, for the routes whose body is optional.
+func enforce(schema *openapi3.Schema, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("body must not be larger than %d bytes", MaxBodyBytes), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if len(bytes.TrimSpace(data)) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			http.Error(w, "body contains badly-formed JSON", http.StatusBadRequest)
+			return
+		}
+		if err := schema.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+			var v validator.Validator
+			addErrors(&v, err)
+			if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// addErrors records the schema errors in err against the fields they're
+// about, naming nested fields with dots
+func addErrors(v *validator.Validator, err error) {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		for _, err := range multi {
+			addErrors(v, err)
+		}
+		return
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		v.AddError(err.Error())
+		return
+	}
+	field := strings.Join(schemaErr.JSONPointer(), ".")
+	if field == "" {
+		v.AddError(sentence(schemaErr.Reason))
+		return
+	}
+	v.AddFieldError(field, sentence(schemaErr.Reason))
+}
+
+// sentence capitalises reason to read like the validator's messages
+func sentence(reason string) string {
+	r, size := utf8.DecodeRuneInString(reason)
+	return string(unicode.ToUpper(r)) + reason[size:]
+}
@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/validator"
+)
+
+type settings struct {
+	MaxPlayers int     `json:"max_players"`
+	Category   *string `json:"category"`
+}
+
+type createRequest struct {
+	Type      string              `json:"type"`
+	Friends   []uuid.UUID         `json:"friends"`
+	StartsAt  time.Time           `json:"starts_at"`
+	Settings  settings            `json:"settings"`
+	Validator validator.Validator `json:"-"`
+}
+
+func echo(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Write(body)
+}
+
+func TestDocument(t *testing.T) {
+	spec := New("Big Spella", "1.0.0")
+	spec.Route(http.MethodPost, "/games", createRequest{}, http.HandlerFunc(echo))
+	spec.Route(http.MethodGet, "/games/:gameID/attempts/:attemptID", nil, http.HandlerFunc(echo))
+
+	rec := httptest.NewRecorder()
+	spec.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	doc, err := openapi3.NewLoader().LoadFromData(rec.Body.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	get := doc.Paths.Find("/games/{gameID}/attempts/{attemptID}").Get
+	require.NotNil(t, get)
+	assert.Equal(t, []string{"games"}, get.Tags)
+	assert.NotNil(t, get.Parameters.GetByInAndName("path", "attemptID"))
+
+	body := doc.Paths.Find("/games").Post.RequestBody.Value.Content.Get("application/json").Schema.Value
+	assert.NotContains(t, body.Properties, "Validator")
+	assert.True(t, body.Properties["starts_at"].Value.Type.Is("string"))
+	assert.True(t, body.Properties["friends"].Value.Items.Value.Type.Is("string"), "IDs are strings, not byte arrays")
+	assert.True(t, body.Properties["settings"].Value.Properties["category"].Value.Nullable)
+}
+
+func TestEnforce(t *testing.T) {
+	h := New("Big Spella", "1.0.0").Route(http.MethodPost, "/games", createRequest{}, http.HandlerFunc(echo))
+	send := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(body)))
+		return rec
+	}
+
+	valid := `{"type": "multi", "friends": ["` + uuid.NewString() + `"], "settings": {"max_players": 4, "category": null}, "extra": true}`
+	rec := send(valid)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, valid, rec.Body.String(), "the handler reads the body it was sent")
+
+	rec = send("")
+	assert.Equal(t, http.StatusOK, rec.Code, "empty bodies are left to the handler")
+
+	rec = send(`{"type": `)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = send(`{"type": 3, "settings": {"max_players": 2.5}}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	var v validator.Validator
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v))
+	assert.Contains(t, v.FieldErrors, "type")
+	assert.Contains(t, v.FieldErrors, "settings.max_players")
+
+	rec = send(`[]`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = send(`"` + strings.Repeat("a", MaxBodyBytes) + `"`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
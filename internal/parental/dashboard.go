@@ -0,0 +1,89 @@
+package parental
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Dashboard is how a child got on over the last Days days
+type Dashboard struct {
+	Child        Child   `json:"child"`
+	Days         int     `json:"days"`
+	GamesPlayed  int     `json:"games_played" db:"games_played"`
+	WordsSpelled int     `json:"words_spelled" db:"words_spelled"`
+	AverageScore float64 `json:"average_score" db:"average_score"`
+	BestScore    int     `json:"best_score" db:"best_score"`
+	// Playtime is in seconds, here and in Daily and RecentGames
+	Playtime    int           `json:"playtime" db:"playtime"`
+	Daily       []DayActivity `json:"daily"`
+	RecentGames []RecentGame  `json:"recent_games"`
+}
+
+// DayActivity is what a child played on one day. Days they didn't play are
+// left out.
+type DayActivity struct {
+	Date         string `json:"date" db:"date"`
+	GamesPlayed  int    `json:"games_played" db:"games_played"`
+	WordsSpelled int    `json:"words_spelled" db:"words_spelled"`
+	Playtime     int    `json:"playtime" db:"playtime"`
+}
+
+// RecentGame is one of the games a child finished most recently
+type RecentGame struct {
+	GameID       string    `json:"game_id" db:"game_id"`
+	GameType     string    `json:"game_type" db:"game_type"`
+	Score        int       `json:"score" db:"score"`
+	Position     int       `json:"position" db:"position"`
+	WordsSpelled int       `json:"words_spelled" db:"words_spelled"`
+	Playtime     int       `json:"playtime" db:"duration"`
+	PlayedAt     time.Time `json:"played_at" db:"created_at"`
+}
+
+// Dashboard sums up the games childID finished in the last days days, for
+// the parent who consented to their account
+func (s *Service) Dashboard(ctx context.Context, parentID, childID string, days int) (*Dashboard, error) {
+	child, err := s.child(ctx, s.db, parentID, childID)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	dashboard := &Dashboard{Child: *child, Days: days}
+	if err := s.db.GetContext(ctx, dashboard, `
+		SELECT COUNT(*) AS games_played,
+			COALESCE(SUM(cardinality(words_spelled)), 0) AS words_spelled,
+			COALESCE(AVG(score), 0) AS average_score,
+			COALESCE(MAX(score), 0) AS best_score,
+			COALESCE(SUM(duration), 0) AS playtime
+		FROM game_history
+		WHERE user_id = $1 AND created_at >= $2`, childID, since); err != nil {
+		return nil, fmt.Errorf("failed to sum up games: %w", err)
+	}
+
+	dashboard.Daily = []DayActivity{}
+	if err := s.db.SelectContext(ctx, &dashboard.Daily, `
+		SELECT to_char(created_at, 'YYYY-MM-DD') AS date,
+			COUNT(*) AS games_played,
+			SUM(cardinality(words_spelled)) AS words_spelled,
+			SUM(duration) AS playtime
+		FROM game_history
+		WHERE user_id = $1 AND created_at >= $2
+		GROUP BY 1
+		ORDER BY 1`, childID, since); err != nil {
+		return nil, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+
+	dashboard.RecentGames = []RecentGame{}
+	if err := s.db.SelectContext(ctx, &dashboard.RecentGames, `
+		SELECT game_id, game_type, score, position,
+			cardinality(words_spelled) AS words_spelled, duration, created_at
+		FROM game_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2`, childID, recentGames); err != nil {
+		return nil, fmt.Errorf("failed to get recent games: %w", err)
+	}
+
+	return dashboard, nil
+}
@@ -0,0 +1,174 @@
+package parental
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type ConsentRequest struct {
+	Token     string              `json:"token"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *ConsentRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Token), "token", "Must be provided")
+	r.Validator.CheckField(len(r.Token) <= 100, "token", "Must not be more than 100 characters")
+}
+
+// Consent grants consent to a child's account with the token from the email
+// their parent was sent
+func (h *Handler) Consent(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	child, err := h.service.Consent(r.Context(), userID, req.Token)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(child)
+}
+
+// Children lists the children's accounts the caller consented to
+func (h *Handler) Children(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	children, err := h.service.Children(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"children": children})
+}
+
+// Dashboard serves a child's progress and playtime over the last "days"
+// days, 30 by default
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var v validator.Validator
+	childID := httprouter.ParamsFromContext(r.Context()).ByName("childID")
+	_, err := uuid.Parse(childID)
+	v.CheckField(err == nil, "child_id", "Must be a valid ID")
+
+	days := DefaultDashboardDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		days, err = strconv.Atoi(raw)
+		v.CheckField(err == nil && validator.Between(days, 1, MaxDashboardDays), "days", "Must be between 1 and 365")
+	}
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	dashboard, err := h.service.Dashboard(r.Context(), userID, childID, days)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+// WithdrawConsent withdraws the caller's consent to a child's account
+func (h *Handler) WithdrawConsent(w http.ResponseWriter, r *http.Request) {
+	h.setWithdrawn(w, r, true)
+}
+
+// RestoreConsent gives back consent the caller withdrew
+func (h *Handler) RestoreConsent(w http.ResponseWriter, r *http.Request) {
+	h.setWithdrawn(w, r, false)
+}
+
+func (h *Handler) setWithdrawn(w http.ResponseWriter, r *http.Request, withdrawn bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var v validator.Validator
+	childID := httprouter.ParamsFromContext(r.Context()).ByName("childID")
+	_, err := uuid.Parse(childID)
+	v.CheckField(err == nil, "child_id", "Must be a valid ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	var child *Child
+	if withdrawn {
+		child, err = h.service.Withdraw(r.Context(), userID, childID)
+	} else {
+		child, err = h.service.Restore(r.Context(), userID, childID)
+	}
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(child)
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrConsentNotFound), errors.Is(err, ErrChildNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotParent), errors.Is(err, ErrChildConsent):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
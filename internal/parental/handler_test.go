@@ -0,0 +1,62 @@
+package parental
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestConsentRequestValidation(t *testing.T) {
+	req := ConsentRequest{Token: "abc"}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = ConsentRequest{Token: "  "}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "token")
+
+	req = ConsentRequest{Token: strings.Repeat("a", 101)}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "token")
+}
+
+func TestConsentTokens(t *testing.T) {
+	token, hash, err := newToken()
+	require.NoError(t, err)
+	assert.Len(t, token, 43)
+	assert.Equal(t, hash, hashToken(token))
+
+	other, _, err := newToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestDashboardValidation(t *testing.T) {
+	h := NewHandler(NewService(nil, nil, ""))
+	serve := func(childID, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/children/"+childID+"/dashboard"+query, nil)
+		ctx := auth.SetUserIDInContext(r.Context(), "6b0d5f3e-2c1a-4e8b-9f7d-1a2b3c4d5e6f")
+		ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "childID", Value: childID}})
+		w := httptest.NewRecorder()
+		h.Dashboard(w, r.WithContext(ctx))
+		return w
+	}
+
+	w := serve("ada", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "child_id")
+
+	for _, days := range []string{"0", "366", "week"} {
+		w = serve("3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d", "?days="+days)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, days)
+		assert.Contains(t, w.Body.String(), "days")
+	}
+}
@@ -0,0 +1,218 @@
+// Package parental asks parents to consent to their children's accounts,
+// which can't be used until they do, and shows parents how their children
+// are getting on
+package parental
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/smtp"
+)
+
+const (
+	// ConsentEmail is the template consent requests are emailed with
+	ConsentEmail = "parental_consent.tmpl"
+
+	DefaultDashboardDays = 30
+	MaxDashboardDays     = 365
+
+	// recentGames is how many of a child's latest games the dashboard lists
+	recentGames = 10
+)
+
+var (
+	ErrConsentNotFound = errors.New("no consent request matches that token")
+	ErrNotParent       = errors.New("this consent request was sent to a different email address")
+	ErrChildConsent    = errors.New("children's accounts can't consent to other accounts")
+	ErrChildNotFound   = errors.New("child not found")
+)
+
+type Service struct {
+	db         *sqlx.DB
+	send       smtp.SendEmail
+	consentURL string
+}
+
+// NewService emails consent requests with send, linking to consentURL with
+// the request's token added as the token parameter
+func NewService(db *sqlx.DB, send smtp.SendEmail, consentURL string) *Service {
+	return &Service{db: db, send: send, consentURL: consentURL}
+}
+
+// Child is a child's account a parent consented to
+type Child struct {
+	ID          string     `json:"id" db:"id"`
+	Username    string     `json:"username" db:"username"`
+	ConsentedAt time.Time  `json:"consented_at" db:"granted_at"`
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty" db:"revoked_at"`
+}
+
+// RequestConsent emails parentEmail a link to consent to child's account.
+// Asking again replaces the earlier request, whose link stops working.
+func (s *Service) RequestConsent(ctx context.Context, child *auth.User, parentEmail string) error {
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO parental_consents (child_id, parent_email, token_hash, requested_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (child_id) DO UPDATE
+		SET parent_email = EXCLUDED.parent_email, token_hash = EXCLUDED.token_hash,
+			requested_at = EXCLUDED.requested_at, parent_id = NULL, granted_at = NULL, revoked_at = NULL`,
+		child.ID, parentEmail, hash); err != nil {
+		return fmt.Errorf("failed to save consent request: %w", err)
+	}
+
+	link := s.consentURL + "?" + url.Values{"token": {token}}.Encode()
+	if err := s.send(parentEmail, map[string]any{
+		"ChildUsername": child.Username,
+		"ConsentURL":    link,
+	}, ConsentEmail); err != nil {
+		return fmt.Errorf("failed to email consent request: %w", err)
+	}
+	return nil
+}
+
+// Consent grants the consent request token was emailed with, on behalf of
+// parentID. The parent has to be signed in to an account with the address
+// the request went to, which then sees the child's dashboard.
+func (s *Service) Consent(ctx context.Context, parentID, token string) (*Child, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var parent struct {
+		Email   string `db:"email"`
+		IsChild bool   `db:"is_child"`
+	}
+	if err := tx.GetContext(ctx, &parent, `SELECT email, is_child FROM users WHERE id = $1`, parentID); err != nil {
+		return nil, fmt.Errorf("failed to get parent: %w", err)
+	}
+	if parent.IsChild {
+		return nil, ErrChildConsent
+	}
+
+	var request struct {
+		ChildID     string `db:"child_id"`
+		ParentEmail string `db:"parent_email"`
+	}
+	if err := tx.GetContext(ctx, &request, `
+		SELECT child_id, parent_email FROM parental_consents
+		WHERE token_hash = $1
+		FOR UPDATE`, hashToken(token)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrConsentNotFound
+		}
+		return nil, fmt.Errorf("failed to get consent request: %w", err)
+	}
+	if !strings.EqualFold(request.ParentEmail, parent.Email) {
+		return nil, ErrNotParent
+	}
+
+	// The link is spent once it's used
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE parental_consents
+		SET parent_id = $1, granted_at = NOW(), revoked_at = NULL, token_hash = NULL
+		WHERE child_id = $2`, parentID, request.ChildID); err != nil {
+		return nil, fmt.Errorf("failed to grant consent: %w", err)
+	}
+
+	child, err := s.child(ctx, tx, parentID, request.ChildID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit consent: %w", err)
+	}
+	return child, nil
+}
+
+// Withdraw withdraws parentID's consent to childID's account, which can't be
+// used again until they restore it
+func (s *Service) Withdraw(ctx context.Context, parentID, childID string) (*Child, error) {
+	return s.setWithdrawn(ctx, parentID, childID, true)
+}
+
+// Restore gives back the consent parentID withdrew from childID's account
+func (s *Service) Restore(ctx context.Context, parentID, childID string) (*Child, error) {
+	return s.setWithdrawn(ctx, parentID, childID, false)
+}
+
+func (s *Service) setWithdrawn(ctx context.Context, parentID, childID string, withdrawn bool) (*Child, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE parental_consents
+		SET revoked_at = CASE WHEN $3 THEN COALESCE(revoked_at, NOW()) END
+		WHERE child_id = $1 AND parent_id = $2 AND granted_at IS NOT NULL`,
+		childID, parentID, withdrawn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update consent: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to update consent: %w", err)
+	} else if n == 0 {
+		return nil, ErrChildNotFound
+	}
+	return s.child(ctx, s.db, parentID, childID)
+}
+
+// Children lists the children's accounts parentID consented to
+func (s *Service) Children(ctx context.Context, parentID string) ([]Child, error) {
+	children := []Child{}
+	if err := s.db.SelectContext(ctx, &children, `
+		SELECT u.id, u.username, c.granted_at, c.revoked_at
+		FROM parental_consents c
+		JOIN users u ON u.id = c.child_id
+		WHERE c.parent_id = $1 AND c.granted_at IS NOT NULL
+		ORDER BY u.username`, parentID); err != nil {
+		return nil, fmt.Errorf("failed to list children: %w", err)
+	}
+	return children, nil
+}
+
+// child returns childID's account if parentID consented to it
+func (s *Service) child(ctx context.Context, q sqlx.QueryerContext, parentID, childID string) (*Child, error) {
+	child := &Child{}
+	if err := sqlx.GetContext(ctx, q, child, `
+		SELECT u.id, u.username, c.granted_at, c.revoked_at
+		FROM parental_consents c
+		JOIN users u ON u.id = c.child_id
+		WHERE c.child_id = $1 AND c.parent_id = $2 AND c.granted_at IS NOT NULL`,
+		childID, parentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChildNotFound
+		}
+		return nil, fmt.Errorf("failed to get child: %w", err)
+	}
+	return child, nil
+}
+
+// newToken returns a random consent token and the hash it's stored as
+func newToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate consent token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
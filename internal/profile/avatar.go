@@ -0,0 +1,123 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	// MaxAvatarBytes is the largest avatar upload accepted
+	MaxAvatarBytes = 5 << 20
+	// AvatarSize is the longest side, in pixels, avatars are stored at
+	AvatarSize = 512
+	// maxAvatarPixels guards against images that are small on disk but
+	// enormous once decoded
+	maxAvatarPixels = 40_000_000
+
+	avatarQuality = 85
+)
+
+// AvatarTypes are the image types avatars can be uploaded as
+var AvatarTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+var (
+	ErrAvatarTooLarge  = fmt.Errorf("avatar must not be more than %d MB", MaxAvatarBytes>>20)
+	ErrAvatarType      = errors.New("avatar must be a JPEG, PNG or GIF image")
+	ErrAvatarCorrupted = errors.New("avatar image could not be read")
+)
+
+// processAvatar checks an uploaded image and shrinks it to fit within
+// AvatarSize, returning it re-encoded as a JPEG. Transparent areas are
+// filled with white.
+func processAvatar(data []byte) ([]byte, error) {
+	if len(data) > MaxAvatarBytes {
+		return nil, ErrAvatarTooLarge
+	}
+	if !isAvatarType(http.DetectContentType(data)) {
+		return nil, ErrAvatarType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrAvatarCorrupted
+	}
+	if config.Width*config.Height > maxAvatarPixels {
+		return nil, ErrAvatarTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrAvatarCorrupted
+	}
+
+	bounds := src.Bounds()
+	width, height := fitWithin(bounds.Dx(), bounds.Dy(), AvatarSize)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	scale(dst, src)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: avatarQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode avatar: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func isAvatarType(contentType string) bool {
+	for _, t := range AvatarTypes {
+		if contentType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// fitWithin scales width and height down, keeping their ratio, until neither
+// is more than size
+func fitWithin(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// scale draws src over dst, averaging the source pixels that fall within
+// each destination pixel so shrunk images don't alias
+func scale(dst *image.RGBA, src image.Image) {
+	sb, db := src.Bounds(), dst.Bounds()
+	for y := 0; y < db.Dy(); y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/db.Dy()
+		y1 := max(y0+1, sb.Min.Y+(y+1)*sb.Dy()/db.Dy())
+		for x := 0; x < db.Dx(); x++ {
+			x0 := sb.Min.X + x*sb.Dx()/db.Dx()
+			x1 := max(x0+1, sb.Min.X+(x+1)*sb.Dx()/db.Dx())
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// Blend the premultiplied average over the white background
+			bg := dst.RGBAAt(x, y)
+			blend := func(c uint64, under uint8) uint8 {
+				return uint8((c/n + uint64(under)*0x101*(0xffff-a/n)/0xffff) >> 8)
+			}
+			dst.SetRGBA(x, y, color.RGBA{blend(r, bg.R), blend(g, bg.G), blend(b, bg.B), 0xff})
+		}
+	}
+}
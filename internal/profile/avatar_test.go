@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProcessAvatarShrinksLargeImages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2048, 1024))
+	for y := 0; y < 1024; y++ {
+		for x := 0; x < 2048; x++ {
+			src.Set(x, y, color.RGBA{200, 30, 30, 255})
+		}
+	}
+
+	out, err := processAvatar(encodePNG(t, src))
+	require.NoError(t, err)
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, AvatarSize, AvatarSize/2), img.Bounds())
+
+	r, g, b, _ := img.At(100, 100).RGBA()
+	assert.InDelta(t, 200, r>>8, 8)
+	assert.InDelta(t, 30, g>>8, 8)
+	assert.InDelta(t, 30, b>>8, 8)
+}
+
+func TestProcessAvatarFillsTransparency(t *testing.T) {
+	out, err := processAvatar(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 64, 64))))
+	require.NoError(t, err)
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, 64, img.Bounds().Dx(), "small images aren't enlarged")
+
+	r, g, b, _ := img.At(10, 10).RGBA()
+	assert.Greater(t, r>>8, uint32(245))
+	assert.Greater(t, g>>8, uint32(245))
+	assert.Greater(t, b>>8, uint32(245))
+}
+
+func TestProcessAvatarRejectsBadUploads(t *testing.T) {
+	_, err := processAvatar([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"))
+	assert.ErrorIs(t, err, ErrAvatarType)
+
+	_, err = processAvatar(make([]byte, MaxAvatarBytes+1))
+	assert.ErrorIs(t, err, ErrAvatarTooLarge)
+
+	data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	_, err = processAvatar(data[:len(data)/2])
+	assert.ErrorIs(t, err, ErrAvatarCorrupted)
+}
+
+func TestFitWithin(t *testing.T) {
+	w, h := fitWithin(300, 200, 512)
+	assert.Equal(t, [2]int{300, 200}, [2]int{w, h})
+
+	w, h = fitWithin(1000, 4000, 512)
+	assert.Equal(t, [2]int{128, 512}, [2]int{w, h})
+
+	w, h = fitWithin(5000, 2, 512)
+	assert.Equal(t, [2]int{512, 1}, [2]int{w, h})
+}
@@ -0,0 +1,248 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game/ranking"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type UpdateRequest struct {
+	Bio         *string             `json:"bio"`
+	SocialLinks map[string]string   `json:"social_links"`
+	Validator   validator.Validator `json:"-"`
+}
+
+func (r *UpdateRequest) validate() {
+	if r.Bio != nil {
+		r.Validator.CheckField(validator.MaxRunes(*r.Bio, MaxBioRunes), "bio", "Must not be more than 500 characters")
+	}
+	for network, link := range r.SocialLinks {
+		r.Validator.CheckField(validator.In(network, SocialNetworks...), "social_links", "Must only link to twitter, instagram, tiktok, youtube, twitch or a website")
+		r.Validator.CheckField(validator.IsURL(link), "social_links", "Must be URLs")
+		r.Validator.CheckField(validator.MaxRunes(link, 200), "social_links", "Must not be more than 200 characters each")
+	}
+}
+
+type AvatarUploadRequest struct {
+	ContentType string              `json:"content_type"`
+	Validator   validator.Validator `json:"-"`
+}
+
+func (r *AvatarUploadRequest) validate() {
+	r.Validator.CheckField(validator.In(r.ContentType, AvatarTypes...), "content_type", "Must be image/jpeg, image/png or image/gif")
+}
+
+type CompleteUploadRequest struct {
+	Key       string              `json:"key"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *CompleteUploadRequest) validate() {
+	r.Validator.CheckField(validator.NotBlank(r.Key), "key", "Must be provided")
+}
+
+// Me serves the caller's profile
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	profile, err := h.service.Get(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writeProfile(w, profile)
+}
+
+// Get serves a player's profile
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	var v validator.Validator
+	_, err := uuid.Parse(userID)
+	v.CheckField(err == nil, "id", "Must be a valid user ID")
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	profile, err := h.service.Get(r.Context(), userID)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writeProfile(w, profile)
+}
+
+// Update changes the caller's bio and social links. Social links are
+// replaced as a whole.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	profile, err := h.service.Update(r.Context(), userID, Update{Bio: req.Bio, SocialLinks: req.SocialLinks})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writeProfile(w, profile)
+}
+
+// UploadAvatar sets the caller's avatar from the "avatar" file of a
+// multipart form
+func (h *Handler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the form's boundaries and headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, MaxAvatarBytes+64<<10)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, ErrAvatarTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "avatar file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxAvatarBytes+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.service.SetAvatar(r.Context(), userID, data)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writeProfile(w, profile)
+}
+
+// StartAvatarUpload returns a presigned URL the caller can upload an avatar
+// to directly
+func (h *Handler) StartAvatarUpload(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req AvatarUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	upload, err := h.service.StartAvatarUpload(r.Context(), userID, req.ContentType)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(upload)
+}
+
+// CompleteAvatarUpload sets the caller's avatar from an image they uploaded
+// through StartAvatarUpload
+func (h *Handler) CompleteAvatarUpload(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CompleteUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	profile, err := h.service.CompleteAvatarUpload(r.Context(), userID, req.Key)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	writeProfile(w, profile)
+}
+
+func writeProfile(w http.ResponseWriter, profile *Profile) {
+	profile.Placement = ranking.NewPlacement(profile.RankedGames)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrUploadNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrAvatarTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, ErrAvatarType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrAvatarCorrupted):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, ErrAvatarsDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,82 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestUpdateRequestValidation(t *testing.T) {
+	bio := "Spelling bee champion, 2019"
+	req := UpdateRequest{Bio: &bio, SocialLinks: map[string]string{"twitter": "https://twitter.com/spella"}}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	long := strings.Repeat("a", MaxBioRunes+1)
+	req = UpdateRequest{Bio: &long}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "bio")
+
+	req = UpdateRequest{SocialLinks: map[string]string{"myspace": "https://myspace.com/spella"}}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "social_links")
+
+	req = UpdateRequest{SocialLinks: map[string]string{"website": "spella dot com"}}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "social_links")
+}
+
+func TestGetRejectsBadIDs(t *testing.T) {
+	h := NewHandler(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/nope/profile", nil)
+	req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "nope"}}))
+	rec := httptest.NewRecorder()
+	h.Get(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestUploadAvatarWithoutStorage(t *testing.T) {
+	h := NewHandler(NewService(nil))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", "me.png")
+	require.NoError(t, err)
+	part.Write([]byte("not really a png"))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/profile/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(auth.SetUserIDInContext(req.Context(), uuid.New().String()))
+	rec := httptest.NewRecorder()
+	h.UploadAvatar(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/profile/avatar", strings.NewReader(""))
+	req = req.WithContext(auth.SetUserIDInContext(req.Context(), uuid.New().String()))
+	rec = httptest.NewRecorder()
+	h.UploadAvatar(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// unusedStore panics if storage is touched
+type unusedStore struct{ AvatarStore }
+
+func TestCompleteUploadChecksOwnership(t *testing.T) {
+	s := NewService(nil, WithAvatars(unusedStore{}))
+
+	_, err := s.CompleteAvatarUpload(context.Background(), "user-1", uploadPrefix+"user-2/abc")
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"big-spella-go/internal/game/ranking"
 )
 
 // Profile represents a user's extended profile information
@@ -14,6 +16,14 @@ type Profile struct {
 	ProfileImageURL      string         `json:"profile_image_url" db:"profile_image_url"`
 	SocialLinks         json.RawMessage `json:"social_links" db:"social_links"`
 	NotificationPrefs   json.RawMessage `json:"notification_preferences" db:"notification_preferences"`
+	// CurrentStreak counts days in a row with a daily challenge played, and
+	// is 0 once a day is missed
+	CurrentStreak       int             `json:"current_streak" db:"current_streak"`
+	LongestStreak       int             `json:"longest_streak" db:"longest_streak"`
+	// Placement is how far the player is through their placement games,
+	// filled in from RankedGames
+	Placement           ranking.Placement `json:"placement" db:"-"`
+	RankedGames         int             `json:"-" db:"games_played"`
 	CreatedAt           time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time       `json:"updated_at" db:"updated_at"`
 }
@@ -53,6 +63,7 @@ type PostInteraction struct {
 	UserID    uuid.UUID `json:"user_id" db:"user_id"`
 	Type      string    `json:"type" db:"type"`
 	Content   string    `json:"content,omitempty" db:"content"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -0,0 +1,250 @@
+// Package profile serves players' profiles: their bio, social links and
+// avatar
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	MaxBioRunes = 500
+
+	// uploadExpiry is how long a presigned avatar upload URL works for
+	uploadExpiry = 15 * time.Minute
+
+	avatarPrefix = "avatars/"
+	uploadPrefix = "avatar-uploads/"
+)
+
+// SocialNetworks are the sites a profile can link to
+var SocialNetworks = []string{"twitter", "instagram", "tiktok", "youtube", "twitch", "website"}
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrAvatarsDisabled = errors.New("avatar uploads are not configured")
+	ErrUploadNotFound  = errors.New("avatar upload not found")
+)
+
+// AvatarStore keeps avatar images, such as in an S3 bucket
+type AvatarStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	URL(ctx context.Context, key string) (string, error)
+	PresignPut(ctx context.Context, key, contentType string, expiry time.Duration) (string, error)
+}
+
+// Update holds the profile fields to change; nil fields are left alone
+type Update struct {
+	Bio         *string
+	SocialLinks map[string]string
+}
+
+// AvatarUpload is where a client uploads an avatar image directly to
+// storage before finishing it with CompleteAvatarUpload
+type AvatarUpload struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"upload_url"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ServiceOption func(*Service)
+
+// WithAvatars stores avatar uploads in store. Without it uploads are refused.
+func WithAvatars(store AvatarStore) ServiceOption {
+	return func(s *Service) {
+		s.avatars = store
+	}
+}
+
+type Service struct {
+	db      *sqlx.DB
+	avatars AvatarStore
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+const profileColumns = `
+	id AS user_id,
+	COALESCE(bio, '') AS bio,
+	COALESCE(profile_image_url, '') AS profile_image_url,
+	COALESCE(social_links, '{}') AS social_links,
+	COALESCE(notification_preferences, '{}') AS notification_preferences,
+	CASE WHEN last_daily_date >= (NOW() AT TIME ZONE 'UTC')::date - 1 THEN current_streak ELSE 0 END AS current_streak,
+	longest_streak,
+	games_played,
+	created_at, updated_at`
+
+// Get returns userID's profile
+func (s *Service) Get(ctx context.Context, userID string) (*Profile, error) {
+	profile := &Profile{}
+	if err := s.db.GetContext(ctx, profile, `SELECT `+profileColumns+` FROM users WHERE id = $1`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return profile, nil
+}
+
+// GetMany returns the profiles of those of userIDs that exist, keyed by user
+// ID, for callers that batch their lookups
+func (s *Service) GetMany(ctx context.Context, userIDs []string) (map[string]*Profile, error) {
+	profiles := []*Profile{}
+	if err := s.db.SelectContext(ctx, &profiles, `SELECT `+profileColumns+` FROM users WHERE id = ANY($1)`, pq.Array(userIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+	byID := make(map[string]*Profile, len(profiles))
+	for _, profile := range profiles {
+		byID[profile.UserID.String()] = profile
+	}
+	return byID, nil
+}
+
+// Usernames returns the usernames of those of userIDs that exist, keyed by
+// user ID
+func (s *Service) Usernames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	var users []struct {
+		ID       string `db:"id"`
+		Username string `db:"username"`
+	}
+	if err := s.db.SelectContext(ctx, &users, `SELECT id, username FROM users WHERE id = ANY($1)`, pq.Array(userIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get usernames: %w", err)
+	}
+	usernames := make(map[string]string, len(users))
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+	}
+	return usernames, nil
+}
+
+// Update changes userID's bio and social links
+func (s *Service) Update(ctx context.Context, userID string, update Update) (*Profile, error) {
+	var links *string
+	if update.SocialLinks != nil {
+		data, err := json.Marshal(update.SocialLinks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode social links: %w", err)
+		}
+		encoded := string(data)
+		links = &encoded
+	}
+
+	profile := &Profile{}
+	if err := s.db.GetContext(ctx, profile, `
+		UPDATE users
+		SET bio = COALESCE($1, bio), social_links = COALESCE($2, social_links), updated_at = $3
+		WHERE id = $4
+		RETURNING `+profileColumns,
+		update.Bio, links, time.Now(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+	return profile, nil
+}
+
+// SetAvatar checks and resizes an uploaded image, stores it and makes it
+// userID's avatar
+func (s *Service) SetAvatar(ctx context.Context, userID string, data []byte) (*Profile, error) {
+	if s.avatars == nil {
+		return nil, ErrAvatarsDisabled
+	}
+
+	avatar, err := processAvatar(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key := avatarPrefix + userID + "/" + uuid.New().String() + ".jpg"
+	if err := s.avatars.Put(ctx, key, avatar, "image/jpeg"); err != nil {
+		return nil, err
+	}
+	url, err := s.avatars.URL(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{}
+	if err := s.db.GetContext(ctx, profile, `
+		UPDATE users SET profile_image_url = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING `+profileColumns, url, time.Now(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to set avatar: %w", err)
+	}
+	return profile, nil
+}
+
+// StartAvatarUpload returns a URL userID can upload an image of
+// contentType to directly
+func (s *Service) StartAvatarUpload(ctx context.Context, userID, contentType string) (*AvatarUpload, error) {
+	if s.avatars == nil {
+		return nil, ErrAvatarsDisabled
+	}
+	if !isAvatarType(contentType) {
+		return nil, ErrAvatarType
+	}
+
+	key := uploadPrefix + userID + "/" + uuid.New().String()
+	url, err := s.avatars.PresignPut(ctx, key, contentType, uploadExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return &AvatarUpload{Key: key, URL: url, Method: "PUT", ExpiresAt: time.Now().Add(uploadExpiry)}, nil
+}
+
+// CompleteAvatarUpload makes an image uploaded through StartAvatarUpload
+// userID's avatar, then removes the original upload
+func (s *Service) CompleteAvatarUpload(ctx context.Context, userID, key string) (*Profile, error) {
+	if s.avatars == nil {
+		return nil, ErrAvatarsDisabled
+	}
+	if !strings.HasPrefix(key, uploadPrefix+userID+"/") {
+		return nil, ErrUploadNotFound
+	}
+
+	exists, err := s.avatars.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUploadNotFound
+	}
+
+	data, err := s.avatars.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.SetAvatar(ctx, userID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The upload is only a staging copy, so a failure here just leaves it
+	// for the bucket's lifecycle rules
+	s.avatars.Delete(ctx, key)
+	return profile, nil
+}
@@ -0,0 +1,9 @@
+-- name: RecordAttempt :exec
+-- RecordAttempt saves a spelling attempt as it was judged
+INSERT INTO spelling_attempts (id, game_id, player_id, word, type, text,
+    is_correct, timestamp, voice_s3_key, voice_expires_at,
+    status, ruling, confidence, judge_id, answer_ms, match, points, hints_used)
+VALUES (@id::uuid, @game_id::uuid, @player_id::uuid, @word::text, @type::text, @text::text,
+    @is_correct::boolean, @timestamp::timestamptz, @voice_s3_key?::text, @voice_expires_at?::timestamptz,
+    @status::text, @ruling::text, @confidence?::float8, @judge_id?::uuid, @answer_ms?::bigint,
+    @match::text, @points::int, @hints_used::int);
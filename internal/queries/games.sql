@@ -0,0 +1,107 @@
+-- name: CreateGame :exec
+-- CreateGame saves a new game
+INSERT INTO games (id, host_id, type, status, settings, created_at, updated_at, invite_code,
+    scheduled_at, lobby_opens_at)
+VALUES (@id::uuid, @host_id::uuid, @type::text, @status::text, @settings::jsonb,
+    @created_at::timestamptz, @created_at::timestamptz, @invite_code?::text,
+    @scheduled_at?::timestamptz, @lobby_opens_at?::timestamptz);
+
+-- name: GetGame :one
+-- GetGame reads a game with its players
+SELECT g.*, array_agg(p.*) AS players
+FROM games g
+LEFT JOIN players p ON p.game_id = g.id
+WHERE g.id = @id::uuid
+GROUP BY g.id;
+
+-- name: GetGameRow :one
+-- GetGameRow reads a game without its players
+SELECT * FROM games WHERE id = @id::uuid;
+
+-- The updates below only apply to the game at the version it was read at,
+-- and bump it, so an update made from a stale read finds no row
+
+-- name: StartGame :one
+-- StartGame serves the first word to the first player
+UPDATE games
+SET status = @status::text,
+    current_word_id = @word_id::uuid,
+    current_turn = @current_turn::uuid,
+    updated_at = @now::timestamptz,
+    turn_started_at = @now::timestamptz,
+    word_masked = true,
+    version = version + 1
+WHERE id = @id::uuid AND version = @version::int
+RETURNING *;
+
+-- name: PauseGame :one
+-- PauseGame stops a game's clocks from now
+UPDATE games
+SET status = @status::text,
+    paused_at = @now::timestamptz,
+    updated_at = @now::timestamptz,
+    version = version + 1
+WHERE id = @id::uuid AND version = @version::int
+RETURNING *;
+
+-- name: ResumeGame :one
+-- ResumeGame restarts a paused game's turn from turn_started_at
+UPDATE games
+SET status = @status::text,
+    paused_at = NULL,
+    turn_started_at = @turn_started_at?::timestamptz,
+    updated_at = @now::timestamptz,
+    version = version + 1
+WHERE id = @id::uuid AND version = @version::int
+RETURNING *;
+
+-- name: ScoreTurn :one
+-- ScoreTurn ends the turn of a player who spelled the word, adding a point
+-- to their score
+UPDATE games
+SET current_word_id = NULL,
+    updated_at = @now::timestamptz,
+    turn_started_at = NULL,
+    word_masked = false,
+    scores = jsonb_set(
+        scores,
+        array[@player_id::text],
+        (COALESCE((scores->>@player_id::text)::int, 0) + 1)::text::jsonb
+    ),
+    version = version + 1
+WHERE id = @id::uuid AND version = @version::int
+RETURNING *;
+
+-- name: TouchGame :one
+-- TouchGame notes that something happened in a game without changing it
+UPDATE games
+SET updated_at = @now::timestamptz,
+    version = version + 1
+WHERE id = @id::uuid AND version = @version::int
+RETURNING *;
+
+-- name: NextRound :one
+-- NextRound serves a new word to the next player, whatever version the game
+-- is at
+UPDATE games
+SET current_word_id = @word_id::uuid,
+    updated_at = @now::timestamptz,
+    turn_started_at = @now::timestamptz,
+    word_masked = true,
+    round = round + 1,
+    current_turn = @current_turn::uuid,
+    version = version + 1
+WHERE id = @id::uuid
+RETURNING *;
+
+-- name: TransferHost :exec
+-- TransferHost makes another player the host
+UPDATE games
+SET host_id = @host_id::uuid, updated_at = @now::timestamptz, version = version + 1
+WHERE id = @id::uuid;
+
+-- name: SetGameStatus :exec
+-- SetGameStatus moves a game to status, whatever version it is at
+UPDATE games
+SET status = @status::text, updated_at = @now::timestamptz, version = version + 1
+WHERE id = @id::uuid;
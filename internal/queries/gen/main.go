@@ -0,0 +1,118 @@
+// Command querygen generates the typed methods in package queries from the
+// annotated SQL next to them, checking each query against the tables the
+// migrations create. Run it with go generate ./internal/queries.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// statementsFile lists every query, so it can't share a name with a .sql file
+const statementsFile = "statements.sql.go"
+
+// reserved can't be parameter names, since the methods use them already
+var reserved = map[string]bool{"ctx": true, "dest": true, "arg": true, "q": true, "res": true, "err": true}
+
+func main() {
+	dir := flag.String("dir", ".", "directory holding the .sql files, where the Go files are written")
+	migrations := flag.String("migrations", "../../migrations", "directory holding the migrations")
+	flag.Parse()
+
+	if err := generate(*dir, *migrations); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(dir, migrations string) error {
+	schema, err := loadSchema(migrations)
+	if err != nil {
+		return fmt.Errorf("load schema: %w", err)
+	}
+
+	sources, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	files := map[string][]Query{}
+	declared := map[string]string{}
+	for _, path := range sources {
+		name := filepath.Base(path)
+		if name+".go" == statementsFile {
+			return fmt.Errorf("%s: the name is taken by %s", name, statementsFile)
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		queries, err := parseQueries(name, string(src))
+		if err != nil {
+			return err
+		}
+		for _, q := range queries {
+			if other, ok := declared[q.Name]; ok {
+				return fmt.Errorf("%s:%d: %s is also declared in %s", name, q.Line, q.Name, other)
+			}
+			declared[q.Name] = name
+			for _, p := range q.Params {
+				if reserved[p.Name] {
+					return fmt.Errorf("%s:%d: %s: @%s is reserved", name, q.Line, q.Name, p.Name)
+				}
+			}
+			if err := schema.check(q); err != nil {
+				return fmt.Errorf("%s:%d: %s: %w", name, q.Line, q.Name, err)
+			}
+		}
+		files[name] = queries
+	}
+
+	want := map[string][]byte{}
+	for name, queries := range files {
+		out, err := render(name, queries)
+		if err != nil {
+			return err
+		}
+		want[name+".go"] = out
+	}
+	statements, err := renderStatements(files)
+	if err != nil {
+		return err
+	}
+	want[statementsFile] = statements
+
+	// Files left from .sql files that have since gone are removed
+	existing, err := filepath.Glob(filepath.Join(dir, "*.sql.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range existing {
+		if _, ok := want[filepath.Base(path)]; !ok {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, want[name]) {
+			continue
+		}
+		if err := os.WriteFile(path, want[name], 0o644); err != nil {
+			return err
+		}
+		fmt.Println("wrote", strings.TrimPrefix(path, "./"))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerated fails when a .sql file in package queries changed without
+// go generate being run again
+func TestGenerated(t *testing.T) {
+	dir := t.TempDir()
+	sources, err := filepath.Glob("../*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, sources)
+	for _, path := range sources {
+		src, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.Base(path)), src, 0o644))
+	}
+
+	require.NoError(t, generate(dir, "../../../migrations"))
+
+	generated, err := filepath.Glob(filepath.Join(dir, "*.sql.go"))
+	require.NoError(t, err)
+	committed, err := filepath.Glob("../*.sql.go")
+	require.NoError(t, err)
+	assert.Len(t, committed, len(generated))
+	for _, path := range generated {
+		want, err := os.ReadFile(path)
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join("..", filepath.Base(path)))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), "%s is out of date; run go generate ./internal/queries", filepath.Base(path))
+	}
+}
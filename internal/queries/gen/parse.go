@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind is what a query returns
+type Kind string
+
+const (
+	// KindOne scans a single row into the caller's destination
+	KindOne Kind = "one"
+	// KindMany scans every row into the caller's slice
+	KindMany Kind = "many"
+	// KindExec returns only whether the statement failed
+	KindExec Kind = "exec"
+	// KindExecRows also returns how many rows the statement affected
+	KindExecRows Kind = "execrows"
+)
+
+// Query is one annotated statement from a .sql file
+type Query struct {
+	Name   string
+	Kind   Kind
+	Doc    []string
+	SQL    string
+	Params []Param
+	// Line is where the query's annotation is, for errors
+	Line int
+}
+
+// Param is a named parameter, numbered by where it first appears
+type Param struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+var (
+	nameLine = regexp.MustCompile(`^--\s*name:\s*([A-Z][A-Za-z0-9]*)\s+:(\w+)\s*$`)
+	// Parameters are written @name::type, or @name?::type when they may be
+	// NULL. The cast is kept, which also tells Postgres the type.
+	paramPattern = regexp.MustCompile(`@([a-z][a-z0-9_]*)(\?)?::([a-z][a-z0-9]*(?:\[\])?)`)
+	numbered     = regexp.MustCompile(`\$\d`)
+)
+
+// parseQueries reads the queries in a .sql file. Each starts with a
+// "-- name: Name :kind" line, and the comment lines straight after it
+// become its doc comment.
+func parseQueries(file, src string) ([]Query, error) {
+	var (
+		queries []Query
+		current *Query
+		body    strings.Builder
+		inDoc   bool
+	)
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		if err := current.setSQL(body.String()); err != nil {
+			return fmt.Errorf("%s:%d: %s: %w", file, current.Line, current.Name, err)
+		}
+		queries = append(queries, *current)
+		body.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if m := nameLine.FindStringSubmatch(trimmed); m != nil {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			kind := Kind(m[2])
+			switch kind {
+			case KindOne, KindMany, KindExec, KindExecRows:
+			default:
+				return nil, fmt.Errorf("%s:%d: %s: unknown kind :%s", file, n, m[1], m[2])
+			}
+			current, inDoc = &Query{Name: m[1], Kind: kind, Line: n}, true
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		// Other comments are notes for whoever reads the .sql file, and
+		// are left out of the query
+		if strings.HasPrefix(trimmed, "--") {
+			if inDoc {
+				current.Doc = append(current.Doc, strings.TrimSpace(strings.TrimPrefix(trimmed, "--")))
+			}
+			continue
+		}
+		inDoc = false
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, q := range queries {
+		if seen[q.Name] {
+			return nil, fmt.Errorf("%s:%d: %s is declared twice", file, q.Line, q.Name)
+		}
+		seen[q.Name] = true
+	}
+	return queries, nil
+}
+
+// setSQL numbers the query's parameters, replacing each @name with $n
+func (q *Query) setSQL(sql string) error {
+	sql = strings.TrimSpace(sql)
+	sql = strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+	if sql == "" {
+		return fmt.Errorf("no statement")
+	}
+	if strings.Contains(sql, "`") {
+		return fmt.Errorf("queries can't contain backquotes")
+	}
+	if numbered.MatchString(sql) {
+		return fmt.Errorf("use @name::type parameters rather than numbering them")
+	}
+
+	numbers := map[string]int{}
+	var err error
+	sql = paramPattern.ReplaceAllStringFunc(sql, func(match string) string {
+		m := paramPattern.FindStringSubmatch(match)
+		p := Param{Name: m[1], Nullable: m[2] == "?", Type: m[3]}
+		if _, ok := goTypes[strings.TrimSuffix(p.Type, "[]")]; !ok && err == nil {
+			err = fmt.Errorf("@%s has unsupported type %s", p.Name, p.Type)
+		}
+		n, ok := numbers[p.Name]
+		if !ok {
+			q.Params = append(q.Params, p)
+			n = len(q.Params)
+			numbers[p.Name] = n
+		} else if prev := q.Params[n-1]; prev != p && err == nil {
+			err = fmt.Errorf("@%s is used as both %s and %s", p.Name, prev.describe(), p.describe())
+		}
+		return fmt.Sprintf("$%d::%s", n, p.Type)
+	})
+	if err != nil {
+		return err
+	}
+	if i := strings.IndexByte(sql, '@'); i >= 0 && !strings.HasPrefix(sql[i:], "@>") {
+		return fmt.Errorf("parameter near %q needs a ::type", sql[i:min(len(sql), i+20)])
+	}
+	q.SQL = sql
+	return nil
+}
+
+func (p Param) describe() string {
+	if p.Nullable {
+		return "nullable " + p.Type
+	}
+	return p.Type
+}
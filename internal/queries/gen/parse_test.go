@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueries(t *testing.T) {
+	queries, err := parseQueries("games.sql", `
+-- name: ScoreTurn :one
+-- ScoreTurn adds a point
+UPDATE games
+SET scores = jsonb_set(scores, array[@player_id::text], '1'),
+    turn_started_at = @started_at?::timestamptz
+-- Only the version that was read
+WHERE id = @id::uuid AND version = @version::int AND @player_id::text <> ''
+RETURNING *;
+
+-- name: AssignTeam :exec
+UPDATE players SET team = @team::int WHERE player_id = ANY(@player_ids::uuid[]);
+`)
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+
+	score := queries[0]
+	assert.Equal(t, KindOne, score.Kind)
+	assert.Equal(t, []string{"ScoreTurn adds a point"}, score.Doc)
+	assert.Equal(t, []Param{
+		{Name: "player_id", Type: "text"},
+		{Name: "started_at", Type: "timestamptz", Nullable: true},
+		{Name: "id", Type: "uuid"},
+		{Name: "version", Type: "int"},
+	}, score.Params)
+	assert.Contains(t, score.SQL, "array[$1::text]")
+	assert.Contains(t, score.SQL, "AND $1::text <> ''", "a parameter used twice keeps its number")
+	assert.NotContains(t, score.SQL, "Only the version", "comments in the body are left out")
+	assert.False(t, strings.HasSuffix(score.SQL, ";"))
+
+	assert.Equal(t, []Param{{Name: "team", Type: "int"}, {Name: "player_ids", Type: "uuid[]"}}, queries[1].Params)
+}
+
+func TestParseQueriesErrors(t *testing.T) {
+	for name, src := range map[string]string{
+		"numbered":     "-- name: A :exec\nUPDATE games SET round = $1",
+		"untyped":      "-- name: A :exec\nUPDATE games SET round = @round",
+		"unsupported":  "-- name: A :exec\nUPDATE games SET round = @round::numeric",
+		"conflicting":  "-- name: A :exec\nUPDATE games SET round = @n::int WHERE id = @n::uuid",
+		"unknown kind": "-- name: A :row\nSELECT 1",
+		"empty":        "-- name: A :exec\n",
+		"twice":        "-- name: A :exec\nSELECT 1;\n-- name: A :exec\nSELECT 2",
+	} {
+		_, err := parseQueries("games.sql", src)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "PlayerIDs", goName("player_ids", true))
+	assert.Equal(t, "VoiceS3Key", goName("voice_s3_key", true))
+	assert.Equal(t, "answerMS", goName("answer_ms", false))
+	assert.Equal(t, "ID", goName("id", true))
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// goTypes maps the Postgres types parameters can be cast to onto the Go
+// types their methods take
+var goTypes = map[string]string{
+	"uuid":        "string",
+	"text":        "string",
+	"int":         "int",
+	"integer":     "int",
+	"bigint":      "int64",
+	"boolean":     "bool",
+	"bool":        "bool",
+	"timestamptz": "time.Time",
+	"real":        "float64",
+	"float8":      "float64",
+	"jsonb":       "any",
+}
+
+// initialisms are written in capitals in Go names
+var initialisms = map[string]string{
+	"id": "ID", "ids": "IDs", "url": "URL", "json": "JSON", "api": "API", "uuid": "UUID", "ms": "MS",
+}
+
+// goName turns a snake_case parameter name into a Go identifier, exported
+// or not
+func goName(name string, exported bool) string {
+	var b strings.Builder
+	for i, word := range strings.Split(name, "_") {
+		switch {
+		case i == 0 && !exported:
+			b.WriteString(word)
+		case initialisms[word] != "":
+			b.WriteString(initialisms[word])
+		default:
+			r := []rune(word)
+			r[0] = unicode.ToUpper(r[0])
+			b.WriteString(string(r))
+		}
+	}
+	return b.String()
+}
+
+// goType is the Go type a method takes p as
+func (p Param) goType() string {
+	if base, ok := strings.CutSuffix(p.Type, "[]"); ok {
+		return "[]" + goTypes[base]
+	}
+	t := goTypes[p.Type]
+	if p.Nullable && t != "any" {
+		return "*" + t
+	}
+	return t
+}
+
+// arg is the expression passing p to the driver, given the Go expression
+// holding it
+func (p Param) arg(expr string) string {
+	if strings.HasSuffix(p.Type, "[]") {
+		return "pq.Array(" + expr + ")"
+	}
+	return expr
+}
+
+// constName is the unexported constant holding q's text
+func (q Query) constName() string {
+	return strings.ToLower(q.Name[:1]) + q.Name[1:]
+}
+
+// render writes the Go file for the queries in a .sql file
+func render(source string, queries []Query) ([]byte, error) {
+	var body bytes.Buffer
+	imports := map[string]bool{"context": true}
+	for _, q := range queries {
+		renderQuery(&body, q, imports)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by querygen from %s. DO NOT EDIT.\n\npackage queries\n\n", source)
+	writeImports(&out, imports)
+	out.Write(body.Bytes())
+	return gofmt(source, out.Bytes())
+}
+
+func renderQuery(w *bytes.Buffer, q Query, imports map[string]bool) {
+	constName := q.constName()
+	fmt.Fprintf(w, "const %s = `-- name: %s :%s\n%s\n`\n\n", constName, q.Name, q.Kind, q.SQL)
+
+	for _, p := range q.Params {
+		switch t := p.goType(); {
+		case strings.Contains(t, "time."):
+			imports["time"] = true
+		case strings.HasPrefix(t, "[]"):
+			imports["github.com/lib/pq"] = true
+		}
+	}
+
+	// Queries with several parameters take them as a struct, so they can't
+	// be passed in the wrong order
+	params := "ctx context.Context"
+	if q.Kind == KindOne || q.Kind == KindMany {
+		params += ", dest any"
+	}
+	var args []string
+	switch len(q.Params) {
+	case 0:
+	case 1:
+		p := q.Params[0]
+		params += fmt.Sprintf(", %s %s", goName(p.Name, false), p.goType())
+		args = append(args, p.arg(goName(p.Name, false)))
+	default:
+		fmt.Fprintf(w, "type %sParams struct {\n", q.Name)
+		for _, p := range q.Params {
+			fmt.Fprintf(w, "\t%s %s\n", goName(p.Name, true), p.goType())
+		}
+		fmt.Fprintf(w, "}\n\n")
+		params += fmt.Sprintf(", arg %sParams", q.Name)
+		for _, p := range q.Params {
+			args = append(args, p.arg("arg."+goName(p.Name, true)))
+		}
+	}
+	call := strings.Join(append([]string{"ctx", constName}, args...), ", ")
+
+	for _, line := range q.Doc {
+		fmt.Fprintf(w, "// %s\n", line)
+	}
+	switch q.Kind {
+	case KindOne:
+		fmt.Fprintf(w, "func (q *Queries) %s(%s) error {\n\treturn q.get(%s)\n}\n\n",
+			q.Name, params, strings.Replace(call, "ctx, ", "ctx, dest, ", 1))
+	case KindMany:
+		fmt.Fprintf(w, "func (q *Queries) %s(%s) error {\n\treturn q.selectRows(%s)\n}\n\n",
+			q.Name, params, strings.Replace(call, "ctx, ", "ctx, dest, ", 1))
+	case KindExec:
+		fmt.Fprintf(w, "func (q *Queries) %s(%s) error {\n\t_, err := q.exec(%s)\n\treturn err\n}\n\n",
+			q.Name, params, call)
+	case KindExecRows:
+		fmt.Fprintf(w, "func (q *Queries) %s(%s) (int64, error) {\n\tres, err := q.exec(%s)\n"+
+			"\tif err != nil {\n\t\treturn 0, err\n\t}\n\treturn res.RowsAffected()\n}\n\n",
+			q.Name, params, call)
+	}
+}
+
+// renderStatements writes the file listing every query, which Prepare
+// prepares up front
+func renderStatements(files map[string][]Query) ([]byte, error) {
+	var names []string
+	for _, queries := range files {
+		for _, q := range queries {
+			names = append(names, q.constName())
+		}
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by querygen. DO NOT EDIT.\n\npackage queries\n\n")
+	out.WriteString("// statements are every query, for Prepare\nvar statements = []string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&out, "\t%s,\n", name)
+	}
+	out.WriteString("}\n")
+	return gofmt(statementsFile, out.Bytes())
+}
+
+func writeImports(w *bytes.Buffer, imports map[string]bool) {
+	var std, other []string
+	for path := range imports {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	w.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(w, "\t%q\n", path)
+	}
+	if len(other) > 0 {
+		w.WriteString("\n")
+		for _, path := range other {
+			fmt.Fprintf(w, "\t%q\n", path)
+		}
+	}
+	w.WriteString(")\n\n")
+}
+
+func gofmt(name string, src []byte) ([]byte, error) {
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: generated invalid Go: %w", name, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the tables the migrations create and the columns they end up
+// with. Some early migrations create the same table twice with different
+// columns, so a table has every column any of them gives it.
+type Schema map[string]map[string]bool
+
+var (
+	lineComment  = regexp.MustCompile(`--[^\n]*`)
+	dollarQuoted = regexp.MustCompile(`(?s)\$(\w*)\$.*?\$(\w*)\$`)
+	createTable  = regexp.MustCompile(`(?is)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?\s*\((.*)\)`)
+	alterTable   = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?"?(\w+)"?\s+(.*)$`)
+	dropTable    = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(.*?)(?:\s+CASCADE|\s+RESTRICT)?$`)
+	addColumn    = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?`)
+	dropColumn   = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?"?(\w+)"?`)
+	renameColumn = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?"?(\w+)"?\s+TO\s+"?(\w+)"?$`)
+	renameTable  = regexp.MustCompile(`(?is)^RENAME\s+TO\s+"?(\w+)"?$`)
+)
+
+// constraintWords start the parts of a table definition or ALTER TABLE
+// that aren't columns
+var constraintWords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "FOREIGN": true,
+	"CHECK": true, "EXCLUDE": true, "LIKE": true,
+}
+
+// loadSchema applies the migrations in dir in order
+func loadSchema(dir string) (Schema, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations in %s", dir)
+	}
+	sort.Strings(files)
+
+	schema := Schema{}
+	for _, file := range files {
+		if strings.HasSuffix(file, ".down.sql") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		schema.apply(string(src))
+	}
+	return schema, nil
+}
+
+func (s Schema) apply(src string) {
+	src = dollarQuoted.ReplaceAllString(src, "''")
+	src = lineComment.ReplaceAllString(src, "")
+	for _, statement := range strings.Split(src, ";") {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case createTable.MatchString(statement):
+			m := createTable.FindStringSubmatch(statement)
+			table := s.table(m[1])
+			for _, part := range splitTopLevel(m[2]) {
+				if column, ok := columnName(part); ok {
+					table[column] = true
+				}
+			}
+		case alterTable.MatchString(statement):
+			m := alterTable.FindStringSubmatch(statement)
+			s.alter(strings.ToLower(m[1]), m[2])
+		case dropTable.MatchString(statement):
+			for _, name := range strings.Split(dropTable.FindStringSubmatch(statement)[1], ",") {
+				delete(s, strings.ToLower(strings.Trim(strings.TrimSpace(name), `"`)))
+			}
+		}
+	}
+}
+
+func (s Schema) table(name string) map[string]bool {
+	name = strings.ToLower(name)
+	if s[name] == nil {
+		s[name] = map[string]bool{}
+	}
+	return s[name]
+}
+
+func (s Schema) alter(name, actions string) {
+	for _, action := range splitTopLevel(actions) {
+		action = strings.TrimSpace(action)
+		first := strings.ToUpper(firstWord(action))
+		rest := strings.TrimSpace(action[len(first):])
+		switch {
+		case first == "ADD" && !constraintWords[strings.ToUpper(firstWord(rest))]:
+			if m := addColumn.FindStringSubmatch(action); m != nil {
+				s.table(name)[strings.ToLower(m[1])] = true
+			}
+		case first == "DROP" && !strings.EqualFold(firstWord(rest), "CONSTRAINT"):
+			if m := dropColumn.FindStringSubmatch(action); m != nil {
+				delete(s.table(name), strings.ToLower(m[1]))
+			}
+		case renameTable.MatchString(action):
+			s[strings.ToLower(renameTable.FindStringSubmatch(action)[1])] = s.table(name)
+			delete(s, name)
+			return
+		case renameColumn.MatchString(action):
+			m := renameColumn.FindStringSubmatch(action)
+			table := s.table(name)
+			delete(table, strings.ToLower(m[1]))
+			table[strings.ToLower(m[2])] = true
+		}
+	}
+}
+
+// columnName returns the column a part of a table definition declares
+func columnName(part string) (string, bool) {
+	word := firstWord(part)
+	if word == "" || constraintWords[strings.ToUpper(word)] {
+		return "", false
+	}
+	return strings.ToLower(strings.Trim(word, `"`)), true
+}
+
+func firstWord(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	word, _, _ := strings.Cut(fields[0], "(")
+	return word
+}
+
+// splitTopLevel splits s on the commas that aren't inside brackets or
+// quotes
+func splitTopLevel(s string) []string {
+	var (
+		parts  []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var (
+	insertColumns = regexp.MustCompile(`(?is)\bINSERT\s+INTO\s+(\w+)\s*\(([^)]*)\)`)
+	updateSet     = regexp.MustCompile(`(?is)\bUPDATE\s+(\w+)(?:\s+(?:AS\s+)?\w+)?\s+SET\s+(.*?)(?:\bWHERE\b|\bRETURNING\b|\bFROM\b|$)`)
+	tableRefs     = regexp.MustCompile(`(?is)\b(?:FROM|JOIN|INTO|UPDATE)\s+(\w+)\b\s*(\()?`)
+	setTarget     = regexp.MustCompile(`(?is)^\s*"?(\w+)"?\s*=`)
+)
+
+// check finds tables the query uses and columns it writes that the schema
+// doesn't have. Columns read are left to Postgres, since working out which
+// table each belongs to needs a real parser.
+func (s Schema) check(q Query) error {
+	var problems []string
+	for _, m := range tableRefs.FindAllStringSubmatch(q.SQL, -1) {
+		table := strings.ToLower(m[1])
+		// Set-returning functions, like unnest(...), aren't tables
+		if m[2] != "" || sqlWords[strings.ToUpper(table)] {
+			continue
+		}
+		if s[table] == nil {
+			problems = append(problems, "no table "+table)
+		}
+	}
+
+	written := map[string][]string{}
+	for _, m := range insertColumns.FindAllStringSubmatch(q.SQL, -1) {
+		for _, column := range strings.Split(m[2], ",") {
+			written[strings.ToLower(m[1])] = append(written[strings.ToLower(m[1])], strings.TrimSpace(column))
+		}
+	}
+	for _, m := range updateSet.FindAllStringSubmatch(q.SQL, -1) {
+		for _, assignment := range splitTopLevel(m[2]) {
+			if target := setTarget.FindStringSubmatch(assignment); target != nil {
+				written[strings.ToLower(m[1])] = append(written[strings.ToLower(m[1])], target[1])
+			}
+		}
+	}
+	for table, columns := range written {
+		if s[table] == nil {
+			continue
+		}
+		for _, column := range columns {
+			column = strings.ToLower(strings.Trim(column, `"`))
+			if !s[table][column] {
+				problems = append(problems, fmt.Sprintf("no column %s.%s", table, column))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// sqlWords can follow FROM without naming a table
+var sqlWords = map[string]bool{"SELECT": true, "LATERAL": true, "ONLY": true}
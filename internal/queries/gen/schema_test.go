@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	schema := Schema{}
+	schema.apply(`
+-- Players in a game
+CREATE TABLE games (id UUID PRIMARY KEY, status TEXT NOT NULL, settings JSONB NOT NULL);
+CREATE TABLE IF NOT EXISTS players (
+    id UUID PRIMARY KEY,
+    player_id UUID NOT NULL,
+    score NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    UNIQUE(id, player_id)
+);
+ALTER TABLE games ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT games_status CHECK (status <> '');
+ALTER TABLE players RENAME COLUMN score TO points;
+CREATE FUNCTION touch() RETURNS trigger AS $$ BEGIN NEW.status = 'x'; RETURN NEW; END $$ LANGUAGE plpgsql;
+CREATE TABLE scratch (id INT);
+DROP TABLE scratch;
+`)
+	assert.Equal(t, Schema{
+		"games":   {"id": true, "status": true, "settings": true, "version": true},
+		"players": {"id": true, "player_id": true, "points": true},
+	}, schema)
+
+	check := func(sql string) error {
+		q := Query{Name: "Q"}
+		require.NoError(t, q.setSQL(sql))
+		return schema.check(q)
+	}
+	assert.NoError(t, check(`UPDATE games SET status = @status::text, version = version + 1 WHERE id = @id::uuid RETURNING *`))
+	assert.NoError(t, check(`SELECT g.* FROM games g LEFT JOIN players p ON p.id = g.id WHERE p.player_id = ANY(SELECT unnest(@ids::uuid[]))`))
+	assert.NoError(t, check(`INSERT INTO players (id, player_id, points) VALUES (@id::uuid, @player_id::uuid, 0)`))
+
+	assert.EqualError(t, check(`INSERT INTO players (id, user_id) VALUES (@id::uuid, @user_id::uuid)`), "no column players.user_id")
+	assert.EqualError(t, check(`UPDATE games SET scores = '{}' WHERE id = @id::uuid`), "no column games.scores")
+	assert.EqualError(t, check(`SELECT * FROM scratch`), "no table scratch")
+}
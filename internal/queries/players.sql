@@ -0,0 +1,28 @@
+-- name: CountPlayers :one
+-- CountPlayers counts the players in a game who haven't been kicked
+SELECT COUNT(*) FROM players WHERE game_id = @game_id::uuid AND status <> 'kicked';
+
+-- name: AddPlayer :exec
+-- AddPlayer seats a user in a game
+INSERT INTO players (id, game_id, player_id, status, joined_at)
+VALUES (@id::uuid, @game_id::uuid, @player_id::uuid, @status::text, @joined_at::timestamptz);
+
+-- name: RemovePlayer :exec
+-- RemovePlayer drops a player from a game altogether
+DELETE FROM players WHERE game_id = @game_id::uuid AND player_id = @player_id::uuid;
+
+-- name: SetPlayerStatus :exec
+-- SetPlayerStatus marks a player as having left or been kicked, keeping
+-- their seat
+UPDATE players SET status = @status::text
+WHERE game_id = @game_id::uuid AND player_id = @player_id::uuid;
+
+-- name: ChargeHint :exec
+-- ChargeHint takes the cost of a hint off a player's score
+UPDATE players SET score = score - @penalty::int
+WHERE game_id = @game_id::uuid AND player_id = @player_id::uuid;
+
+-- name: AssignTeam :exec
+-- AssignTeam puts players in a team relay's team
+UPDATE players SET team = @team::int
+WHERE game_id = @game_id::uuid AND player_id = ANY(@player_ids::uuid[]);
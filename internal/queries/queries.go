@@ -0,0 +1,122 @@
+// Package queries is the SQL the services share for games, players,
+// attempts and users, as typed methods. The methods are generated from the
+// annotated .sql files in this directory: parameters are written
+// @name::type and become arguments of that Go type, so a query can't be
+// called with its parameters missing or out of order. Each query is checked
+// against the tables the migrations create when it's generated.
+//
+// After changing a .sql file, run go generate ./internal/queries.
+package queries
+
+//go:generate go run ./gen
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrReadOnly means a statement that writes was run by Queries made with
+// ReadOnly
+var ErrReadOnly = errors.New("queries: statement run against a read-only database")
+
+// Reader runs read-only queries, such as against read replicas
+type Reader interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// DBTX runs queries, on the pool or in a transaction
+type DBTX interface {
+	Reader
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Queries runs the generated queries against a database, using prepared
+// statements for them once Prepare has made them
+type Queries struct {
+	db    Reader
+	tx    *sqlx.Tx
+	stmts map[string]*sqlx.Stmt
+}
+
+// New runs queries against db without preparing them
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+// ReadOnly runs queries against reads. Statements that write fail with
+// ErrReadOnly.
+func ReadOnly(reads Reader) *Queries {
+	return &Queries{db: reads}
+}
+
+// Prepare prepares every query on db, so their plans are reused rather
+// than worked out on each call. Close releases them.
+func Prepare(ctx context.Context, db *sqlx.DB) (*Queries, error) {
+	q := &Queries{db: db, stmts: make(map[string]*sqlx.Stmt, len(statements))}
+	for _, query := range statements {
+		stmt, err := db.PreparexContext(ctx, query)
+		if err != nil {
+			// The first line is the query's "-- name:" annotation
+			name, _, _ := strings.Cut(query, "\n")
+			return nil, errors.Join(fmt.Errorf("prepare %s: %w", name, err), q.Close())
+		}
+		q.stmts[query] = stmt
+	}
+	return q, nil
+}
+
+// WithTx runs the same queries in tx
+func (q *Queries) WithTx(tx *sqlx.Tx) *Queries {
+	return &Queries{db: tx, tx: tx, stmts: q.stmts}
+}
+
+// Close releases the prepared statements
+func (q *Queries) Close() error {
+	var errs []error
+	for _, stmt := range q.stmts {
+		errs = append(errs, stmt.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// stmt is the statement prepared for query, if there is one. Statements
+// prepared on the pool are prepared again in a transaction the first time
+// it uses them.
+func (q *Queries) stmt(ctx context.Context, query string) *sqlx.Stmt {
+	stmt := q.stmts[query]
+	if stmt != nil && q.tx != nil {
+		return q.tx.StmtxContext(ctx, stmt)
+	}
+	return stmt
+}
+
+func (q *Queries) get(ctx context.Context, dest any, query string, args ...any) error {
+	if stmt := q.stmt(ctx, query); stmt != nil {
+		return stmt.GetContext(ctx, dest, args...)
+	}
+	return q.db.GetContext(ctx, dest, query, args...)
+}
+
+func (q *Queries) selectRows(ctx context.Context, dest any, query string, args ...any) error {
+	if stmt := q.stmt(ctx, query); stmt != nil {
+		return stmt.SelectContext(ctx, dest, args...)
+	}
+	return q.db.SelectContext(ctx, dest, query, args...)
+}
+
+func (q *Queries) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt := q.stmt(ctx, query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	db, ok := q.db.(DBTX)
+	if !ok {
+		return nil, ErrReadOnly
+	}
+	return db.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,39 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReader remembers the last query it was asked to run
+type fakeReader struct {
+	query string
+	args  []any
+}
+
+func (r *fakeReader) GetContext(_ context.Context, _ any, query string, args ...any) error {
+	r.query, r.args = query, args
+	return nil
+}
+
+func (r *fakeReader) SelectContext(_ context.Context, _ any, query string, args ...any) error {
+	r.query, r.args = query, args
+	return nil
+}
+
+func TestReadOnly(t *testing.T) {
+	reads := &fakeReader{}
+	q := ReadOnly(reads)
+
+	var game struct{}
+	require.NoError(t, q.GetGame(context.Background(), &game, "game-1"))
+	assert.Equal(t, getGame, reads.query)
+	assert.Equal(t, []any{"game-1"}, reads.args)
+
+	err := q.SetGameStatus(context.Background(), SetGameStatusParams{Status: "cancelled", Now: time.Now(), ID: "game-1"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
@@ -0,0 +1,49 @@
+-- name: UserExists :one
+-- UserExists reports whether the email address or username is taken
+SELECT EXISTS (SELECT 1 FROM users WHERE email = @email::text OR username = @username::text);
+
+-- name: EmailTaken :one
+-- EmailTaken reports whether an account has the email address
+SELECT EXISTS (SELECT 1 FROM users WHERE email = @email::text);
+
+-- name: CreateUser :one
+-- CreateUser saves a new account, returning its id and timestamps
+INSERT INTO users (username, email, password_hash, elo, is_child)
+VALUES (@username::text, @email::text, @password_hash::text, @elo::int, @is_child::boolean)
+RETURNING id, created_at, updated_at;
+
+-- name: DeleteUser :exec
+-- DeleteUser removes an account outright
+DELETE FROM users WHERE id = @id::uuid;
+
+-- name: GetUserByEmail :one
+-- GetUserByEmail reads the account with the email address
+SELECT * FROM users WHERE email = @email::text;
+
+-- name: GetActiveUser :one
+-- GetActiveUser reads an account that hasn't been deleted
+SELECT * FROM users WHERE id = @id::uuid AND deleted_at IS NULL;
+
+-- name: GetPasswordHash :one
+-- GetPasswordHash reads the password hash of an account that hasn't been
+-- deleted
+SELECT password_hash FROM users WHERE id = @id::uuid AND deleted_at IS NULL;
+
+-- name: GetUsername :one
+-- GetUsername reads an account's username
+SELECT username FROM users WHERE id = @id::uuid;
+
+-- name: PasswordChanged :one
+-- PasswordChanged reports whether the user has changed their password since
+-- registering
+SELECT password_changed_at IS NOT NULL FROM users WHERE id = @id::uuid;
+
+-- name: UpdateEmail :execrows
+-- UpdateEmail changes the address of an account that hasn't been deleted
+UPDATE users SET email = @email::text, updated_at = NOW()
+WHERE id = @id::uuid AND deleted_at IS NULL;
+
+-- name: UpdatePassword :exec
+-- UpdatePassword sets an account's password hash, noting when it changed
+UPDATE users SET password_hash = @password_hash::text, password_changed_at = NOW(), updated_at = NOW()
+WHERE id = @id::uuid;
@@ -0,0 +1,223 @@
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type FileRequest struct {
+	TargetType TargetType          `json:"target_type"`
+	TargetID   string              `json:"target_id"`
+	Reason     Reason              `json:"reason"`
+	Details    string              `json:"details"`
+	Validator  validator.Validator `json:"-"`
+}
+
+func (r *FileRequest) validate() {
+	r.Validator.CheckField(validator.In(r.TargetType, TargetTypes...), "target_type", "Must be user, post or chat_message")
+	r.Validator.CheckField(validator.In(r.Reason, Reasons...), "reason", "Must be a known reason")
+	r.Validator.CheckField(validator.MaxRunes(r.Details, 1000), "details", "Must not be more than 1000 characters")
+	if r.Reason == ReasonOther {
+		r.Validator.CheckField(validator.NotBlank(r.Details), "details", "Must explain a report for another reason")
+	}
+
+	_, err := uuid.Parse(r.TargetID)
+	r.Validator.CheckField(err == nil, "target_id", "Must be a valid ID")
+}
+
+type QueueRequest struct {
+	Status    Status
+	Page      int
+	PageSize  int
+	Validator validator.Validator
+}
+
+func parseQueueRequest(query url.Values) QueueRequest {
+	req := QueueRequest{Status: StatusOpen, Page: 1, PageSize: DefaultPageSize}
+	if status := query.Get("status"); status != "" {
+		req.Status = Status(status)
+	}
+
+	readInt := func(key string, dst *int) {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			req.Validator.CheckField(err == nil, key, "Must be a whole number")
+			*dst = n
+		}
+	}
+	readInt("page", &req.Page)
+	readInt("page_size", &req.PageSize)
+
+	return req
+}
+
+func (r *QueueRequest) validate() {
+	r.Validator.CheckField(validator.In(r.Status, Statuses...), "status", "Must be open, resolved or dismissed")
+	r.Validator.CheckField(r.Page >= 1, "page", "Must be at least 1")
+	r.Validator.CheckField(validator.Between(r.PageSize, 1, MaxPageSize), "page_size", "Must be between 1 and 200")
+}
+
+type CloseRequest struct {
+	Note      string              `json:"note"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (r *CloseRequest) validate() {
+	r.Validator.CheckField(validator.MaxRunes(r.Note, 1000), "note", "Must not be more than 1000 characters")
+}
+
+// File flags a user, post or chat message for moderators
+func (h *Handler) File(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req FileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	report, err := h.service.File(r.Context(), userID, NewReport{
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+	})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Queue serves a page of reports for moderators, open ones by default
+func (h *Handler) Queue(w http.ResponseWriter, r *http.Request) {
+	req := parseQueueRequest(r.URL.Query())
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	reports, total, err := h.service.Queue(r.Context(), req.Status, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"reports":   reports,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"total":     total,
+	})
+}
+
+// Resolve closes a report a moderator acted on
+func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, StatusResolved)
+}
+
+// Dismiss closes a report a moderator found nothing in
+func (h *Handler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, StatusDismissed)
+}
+
+func (h *Handler) close(w http.ResponseWriter, r *http.Request, status Status) {
+	reportID := httprouter.ParamsFromContext(r.Context()).ByName("reportID")
+	if !validID(w, reportID) {
+		return
+	}
+
+	var req CloseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.validate(); req.Validator.HasErrors() {
+		failedValidation(w, req.Validator)
+		return
+	}
+
+	moderator := ""
+	if principal := auth.GetPrincipal(r.Context()); principal != nil {
+		moderator = principal.ActorID()
+	}
+
+	report, err := h.service.Close(r.Context(), reportID, status, moderator, req.Note)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTargetNotFound), errors.Is(err, ErrReportNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSelfReport):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrDuplicate), errors.Is(err, ErrReportClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// validID checks the report ID path parameter and responds with 422 when it
+// isn't a UUID
+func validID(w http.ResponseWriter, id string) bool {
+	var v validator.Validator
+	_, err := uuid.Parse(id)
+	v.CheckField(err == nil, "report_id", "Must be a valid ID")
+
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return false
+	}
+	return true
+}
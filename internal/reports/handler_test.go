@@ -0,0 +1,81 @@
+package reports
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestFileRequestValidation(t *testing.T) {
+	userID := uuid.New().String()
+
+	req := FileRequest{TargetType: TargetUser, TargetID: userID, Reason: ReasonHarassment}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+
+	req = FileRequest{TargetType: TargetPost, TargetID: "post-1", Reason: ReasonOther}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "target_id")
+	assert.Contains(t, req.Validator.FieldErrors, "details", "other reasons need explaining")
+
+	req = FileRequest{TargetType: "profile_picture", Reason: "rude"}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "target_type")
+	assert.Contains(t, req.Validator.FieldErrors, "reason")
+}
+
+func TestChatMessageReportsNameTheMessage(t *testing.T) {
+	req := FileRequest{TargetType: TargetChatMessage, TargetID: "msg-42", Reason: ReasonHateSpeech}
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "target_id")
+
+	req = FileRequest{TargetType: TargetChatMessage, TargetID: uuid.New().String(), Reason: ReasonHateSpeech}
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+}
+
+func TestQueueRequestValidation(t *testing.T) {
+	req := parseQueueRequest(url.Values{})
+	req.validate()
+	assert.False(t, req.Validator.HasErrors())
+	assert.Equal(t, StatusOpen, req.Status)
+
+	req = parseQueueRequest(url.Values{"status": {"pending"}, "page_size": {"0"}})
+	req.validate()
+	assert.Contains(t, req.Validator.FieldErrors, "status")
+	assert.Contains(t, req.Validator.FieldErrors, "page_size")
+}
+
+func TestFileRequiresAUser(t *testing.T) {
+	h := NewHandler(nil)
+
+	rec := httptest.NewRecorder()
+	h.File(rec, httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{"target_type":"user"}`))
+	req = req.WithContext(auth.SetUserIDInContext(req.Context(), uuid.New().String()))
+	rec = httptest.NewRecorder()
+	h.File(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestCloseRejectsBadIDs(t *testing.T) {
+	h := NewHandler(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/reports/nope/resolve", strings.NewReader(`{}`))
+	req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "reportID", Value: "nope"}}))
+	rec := httptest.NewRecorder()
+	h.Resolve(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "report_id")
+}
@@ -0,0 +1,330 @@
+// Package reports lets players flag other players, their posts and their
+// chat messages, queues the reports for moderators, and mutes players who
+// draw too many reports in a short time
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+)
+
+var (
+	ErrTargetNotFound = errors.New("reported content not found")
+	ErrSelfReport     = errors.New("you can't report yourself")
+	ErrDuplicate      = errors.New("you have already reported this")
+	ErrReportNotFound = errors.New("report not found")
+	ErrReportClosed   = errors.New("report has already been closed")
+)
+
+// Postgres error codes the service turns into its own errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// TargetType is the kind of thing a report flags
+type TargetType string
+
+const (
+	TargetUser TargetType = "user"
+	TargetPost TargetType = "post"
+	// TargetChatMessage is a message sent in a game's chat, which can be
+	// reported while the game keeps it. The report quotes it.
+	TargetChatMessage TargetType = "chat_message"
+)
+
+var TargetTypes = []TargetType{TargetUser, TargetPost, TargetChatMessage}
+
+type Reason string
+
+const (
+	ReasonHarassment    Reason = "harassment"
+	ReasonHateSpeech    Reason = "hate_speech"
+	ReasonSpam          Reason = "spam"
+	ReasonInappropriate Reason = "inappropriate_content"
+	ReasonImpersonation Reason = "impersonation"
+	ReasonCheating      Reason = "cheating"
+	ReasonOther         Reason = "other"
+)
+
+var Reasons = []Reason{ReasonHarassment, ReasonHateSpeech, ReasonSpam, ReasonInappropriate, ReasonImpersonation, ReasonCheating, ReasonOther}
+
+type Status string
+
+const (
+	StatusOpen      Status = "open"
+	StatusResolved  Status = "resolved"
+	StatusDismissed Status = "dismissed"
+)
+
+var Statuses = []Status{StatusOpen, StatusResolved, StatusDismissed}
+
+// Report is one player's flag on a user, post or chat message
+type Report struct {
+	ID             string     `json:"id" db:"id"`
+	ReporterID     string     `json:"reporter_id" db:"reporter_id"`
+	TargetType     TargetType `json:"target_type" db:"target_type"`
+	TargetID       string     `json:"target_id" db:"target_id"`
+	ReportedUserID string     `json:"reported_user_id" db:"reported_user_id"`
+	Reason         Reason     `json:"reason" db:"reason"`
+	Details        string     `json:"details" db:"details"`
+	Excerpt        *string    `json:"excerpt,omitempty" db:"excerpt"`
+	Status         Status     `json:"status" db:"status"`
+	ResolvedBy     *string    `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolutionNote *string    `json:"resolution_note,omitempty" db:"resolution_note"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NewReport is what a player files
+type NewReport struct {
+	TargetType TargetType
+	TargetID   string
+	Reason     Reason
+	Details    string
+}
+
+// Mute keeps a player from chatting and posting until EndsAt
+type Mute struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	EndsAt    time.Time `json:"ends_at" db:"ends_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AutoMute mutes a player for Duration once Threshold different players
+// have open reports against them filed within Window
+type AutoMute struct {
+	Threshold int
+	Window    time.Duration
+	Duration  time.Duration
+}
+
+var DefaultAutoMute = AutoMute{Threshold: 5, Window: 24 * time.Hour, Duration: 24 * time.Hour}
+
+type ServiceOption func(*Service)
+
+// WithAutoMute replaces DefaultAutoMute. A zero Threshold turns automatic
+// muting off.
+func WithAutoMute(policy AutoMute) ServiceOption {
+	return func(s *Service) {
+		s.autoMute = policy
+	}
+}
+
+// WithAuditLog records moderators closing reports and automatic mutes in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *Service) {
+		s.audit = log
+	}
+}
+
+type Service struct {
+	db       *sqlx.DB
+	autoMute AutoMute
+	audit    audit.Recorder
+}
+
+func NewService(db *sqlx.DB, opts ...ServiceOption) *Service {
+	s := &Service{db: db, autoMute: DefaultAutoMute}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Service) record(ctx context.Context, event audit.Event) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event)
+	}
+}
+
+// File stores a report from reporterID and mutes the reported player if it
+// takes them over the auto-mute threshold
+func (s *Service) File(ctx context.Context, reporterID string, report NewReport) (*Report, error) {
+	reportedUserID, excerpt, err := s.reportedUser(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	if reportedUserID == reporterID {
+		return nil, ErrSelfReport
+	}
+
+	filed := &Report{}
+	if err := s.db.GetContext(ctx, filed, `
+		INSERT INTO content_reports (id, reporter_id, target_type, target_id, reported_user_id,
+			reason, details, excerpt, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *`,
+		uuid.New().String(), reporterID, report.TargetType, report.TargetID, reportedUserID,
+		report.Reason, report.Details, excerpt, StatusOpen, time.Now()); err != nil {
+		switch {
+		case hasCode(err, uniqueViolation):
+			return nil, ErrDuplicate
+		case hasCode(err, foreignKeyViolation):
+			return nil, ErrTargetNotFound
+		}
+		return nil, fmt.Errorf("failed to file report: %w", err)
+	}
+
+	if err := s.checkAutoMute(ctx, reportedUserID); err != nil {
+		return nil, err
+	}
+	return filed, nil
+}
+
+// reportedUser finds who is responsible for the reported content, and the
+// message quoted when it's a chat message
+func (s *Service) reportedUser(ctx context.Context, report NewReport) (string, *string, error) {
+	var target struct {
+		UserID  string  `db:"user_id"`
+		Excerpt *string `db:"excerpt"`
+	}
+	var err error
+	switch report.TargetType {
+	case TargetUser:
+		err = s.db.GetContext(ctx, &target, `SELECT id AS user_id FROM users WHERE id = $1`, report.TargetID)
+	case TargetPost:
+		err = s.db.GetContext(ctx, &target, `SELECT user_id FROM posts WHERE id = $1`, report.TargetID)
+	case TargetChatMessage:
+		err = s.db.GetContext(ctx, &target, `
+			SELECT sender_id AS user_id, message AS excerpt
+			FROM chat_messages WHERE id = $1`, report.TargetID)
+	default:
+		return "", nil, ErrTargetNotFound
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, ErrTargetNotFound
+		}
+		return "", nil, fmt.Errorf("failed to find reported content: %w", err)
+	}
+	return target.UserID, target.Excerpt, nil
+}
+
+// checkAutoMute mutes userID when enough different players have reported
+// them within the window, unless they are muted already
+func (s *Service) checkAutoMute(ctx context.Context, userID string) error {
+	policy := s.autoMute
+	if policy.Threshold <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var reporters int
+	if err := s.db.GetContext(ctx, &reporters, `
+		SELECT COUNT(DISTINCT reporter_id) FROM content_reports
+		WHERE reported_user_id = $1 AND status = $2 AND created_at > $3`,
+		userID, StatusOpen, now.Add(-policy.Window)); err != nil {
+		return fmt.Errorf("failed to count reports: %w", err)
+	}
+	if reporters < policy.Threshold {
+		return nil
+	}
+
+	mute := &Mute{}
+	err := s.db.GetContext(ctx, mute, `
+		INSERT INTO user_mutes (id, user_id, reason, ends_at, created_at)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (SELECT 1 FROM user_mutes WHERE user_id = $2 AND ends_at > $5)
+		RETURNING *`,
+		uuid.New().String(), userID,
+		fmt.Sprintf("reported by %d players within %s", reporters, policy.Window), now.Add(policy.Duration), now)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Already muted
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to mute user: %w", err)
+	}
+
+	s.record(ctx, audit.Event{Action: audit.ActionUserMuted, ActorID: "system", TargetType: "user", TargetID: userID, After: mute})
+	return nil
+}
+
+// Muted returns the mute keeping userID from chatting and posting, or nil
+// when they aren't muted. Chat and posting should check it before accepting
+// anything from a player.
+func (s *Service) Muted(ctx context.Context, userID string) (*Mute, error) {
+	mute := &Mute{}
+	if err := s.db.GetContext(ctx, mute, `
+		SELECT * FROM user_mutes
+		WHERE user_id = $1 AND ends_at > NOW()
+		ORDER BY ends_at DESC
+		LIMIT 1`, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get mute: %w", err)
+	}
+	return mute, nil
+}
+
+// Queue returns reports with status, oldest first so moderators work
+// through them in order, along with how many there are
+func (s *Service) Queue(ctx context.Context, status Status, limit, offset int) ([]Report, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM content_reports WHERE status = $1`, status); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	reports := []Report{}
+	if err := s.db.SelectContext(ctx, &reports, `
+		SELECT * FROM content_reports
+		WHERE status = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3`, status, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to list reports: %w", err)
+	}
+	return reports, total, nil
+}
+
+// Close resolves or dismisses an open report
+func (s *Service) Close(ctx context.Context, reportID string, status Status, moderator, note string) (*Report, error) {
+	report := &Report{}
+	if err := s.db.GetContext(ctx, report, `
+		UPDATE content_reports
+		SET status = $1, resolved_by = $2, resolution_note = $3, resolved_at = $4
+		WHERE id = $5 AND status = $6
+		RETURNING *`,
+		status, moderator, note, time.Now(), reportID, StatusOpen); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to close report: %w", err)
+		}
+
+		var exists bool
+		if err := s.db.GetContext(ctx, &exists, `
+			SELECT EXISTS (SELECT 1 FROM content_reports WHERE id = $1)`, reportID); err != nil {
+			return nil, fmt.Errorf("failed to get report: %w", err)
+		}
+		if exists {
+			return nil, ErrReportClosed
+		}
+		return nil, ErrReportNotFound
+	}
+
+	s.record(ctx, audit.Event{
+		Action:     audit.ActionReportClosed,
+		TargetType: "content_report",
+		TargetID:   report.ID,
+		Before:     map[string]any{"status": StatusOpen},
+		After:      report,
+	})
+	return report, nil
+}
+
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
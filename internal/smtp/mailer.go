@@ -1,20 +1,20 @@
 package smtp
 
 import (
-	"bytes"
+	"fmt"
 	"time"
 
 	"big-spella-go/assets"
-	"big-spella-go/internal/funcs"
 
 	"github.com/wneessen/go-mail"
-
-	htmlTemplate "html/template"
-	textTemplate "text/template"
 )
 
 const defaultTimeout = 10 * time.Second
 
+// SendEmail sends the email rendered from templates to recipient. It's how
+// services that email players are handed a mailer.
+type SendEmail func(recipient string, data map[string]any, templates ...string) error
+
 type Mailer struct {
 	client mail.Client
 	from   string
@@ -35,55 +35,43 @@ func NewMailer(host string, port int, username, password, from string) (*Mailer,
 }
 
 func (m *Mailer) Send(recipient string, data any, patterns ...string) error {
-	for i := range patterns {
-		patterns[i] = "emails/" + patterns[i]
-	}
-	msg := mail.NewMsg()
-
-	err := msg.To(recipient)
+	email, err := Render(data, patterns...)
 	if err != nil {
 		return err
 	}
 
-	err = msg.From(m.from)
-	if err != nil {
-		return err
-	}
+	msg := mail.NewMsg()
 
-	ts, err := textTemplate.New("").Funcs(funcs.TemplateFuncs).ParseFS(assets.EmbeddedFiles, patterns...)
+	err = msg.To(recipient)
 	if err != nil {
 		return err
 	}
 
-	subject := new(bytes.Buffer)
-	err = ts.ExecuteTemplate(subject, "subject", data)
+	err = msg.From(m.from)
 	if err != nil {
 		return err
 	}
 
-	msg.Subject(subject.String())
-
-	plainBody := new(bytes.Buffer)
-	err = ts.ExecuteTemplate(plainBody, "plainBody", data)
-	if err != nil {
-		return err
+	msg.Subject(email.Subject)
+	if data, ok := data.(map[string]any); ok {
+		// Mail clients show their own unsubscribe button for these, which
+		// posts to the link without opening it
+		if link, ok := data["UnsubscribeURL"].(string); ok && link != "" {
+			msg.SetGenHeader(mail.HeaderListUnsubscribe, "<"+link+">")
+			msg.SetGenHeader(mail.HeaderListUnsubscribePost, "List-Unsubscribe=One-Click")
+		}
 	}
+	msg.SetBodyString(mail.TypeTextPlain, email.PlainBody)
 
-	msg.SetBodyString(mail.TypeTextPlain, plainBody.String())
-
-	if ts.Lookup("htmlBody") != nil {
-		ts, err := htmlTemplate.New("").Funcs(funcs.TemplateFuncs).ParseFS(assets.EmbeddedFiles, patterns...)
-		if err != nil {
-			return err
-		}
+	if email.HTMLBody != "" {
+		msg.AddAlternativeString(mail.TypeTextHTML, email.HTMLBody)
 
-		htmlBody := new(bytes.Buffer)
-		err = ts.ExecuteTemplate(htmlBody, "htmlBody", data)
-		if err != nil {
-			return err
+		for _, image := range email.Images {
+			err = msg.EmbedFromEmbedFS(imageDir+"/"+image, &assets.EmbeddedFiles)
+			if err != nil {
+				return fmt.Errorf("embed %s: %w", image, err)
+			}
 		}
-
-		msg.AddAlternativeString(mail.TypeTextHTML, htmlBody.String())
 	}
 
 	for i := 1; i <= 3; i++ {
@@ -0,0 +1,76 @@
+package smtp
+
+// PreviewData is made-up data to preview each email with, by template.
+// Emails missing from it are previewed without any.
+var PreviewData = map[string]map[string]any{
+	"welcome.tmpl": {
+		"Username": "spellingbee",
+		"PlayURL":  "https://bigspella.example/play",
+	},
+	"email_verification.tmpl": {
+		"Username":  "spellingbee",
+		"VerifyURL": "https://bigspella.example/verify-email?token=preview",
+		"ExpiresAt": "1 March 2026 12:00 UTC",
+	},
+	"password_reset.tmpl": {
+		"Username":  "spellingbee",
+		"ResetURL":  "https://bigspella.example/reset-password?token=preview",
+		"ExpiresAt": "1 March 2026 12:00 UTC",
+	},
+	"weekly_digest.tmpl": {
+		"Username":       "spellingbee",
+		"Week":           "23 February to 1 March",
+		"GamesPlayed":    12,
+		"WordsSpelled":   87,
+		"Accuracy":       81,
+		"BestWord":       "onomatopoeia",
+		"Rating":         1487,
+		"PlayURL":        "https://bigspella.example/play",
+		"UnsubscribeURL": "https://bigspella.example/settings/email",
+	},
+	"tournament_reminder.tmpl": {
+		"Username":   "spellingbee",
+		"Tournament": "Spring Open",
+		"StartsIn":   "in 1 hour",
+		"StartsAt":   "1 March 2026 18:00 UTC",
+		"JoinURL":    "https://bigspella.example/join?game=preview",
+	},
+	"email_change.tmpl": {
+		"Username":   "spellingbee",
+		"ConfirmURL": "https://bigspella.example/confirm-email?token=preview",
+	},
+	"game_invitation.tmpl": {
+		"Username":  "spellingbee",
+		"Host":      "wordsmith",
+		"JoinURL":   "https://bigspella.example/join?game=preview&token=preview",
+		"ExpiresAt": "1 March 2026 12:00 UTC",
+	},
+	"game_report.tmpl": {
+		"Username":  "wordsmith",
+		"GameID":    "00000000-0000-0000-0000-000000000000",
+		"PDFURL":    "https://reports.example/report.pdf",
+		"JSONURL":   "https://reports.example/report.json",
+		"ExpiresAt": "8 March 2026 12:00 UTC",
+	},
+	"parental_consent.tmpl": {
+		"ChildUsername": "littlebee",
+		"ConsentURL":    "https://bigspella.example/parental-consent?token=preview",
+	},
+	"word_of_the_day.tmpl": {
+		"Username":   "spellingbee",
+		"Word":       "quixotic",
+		"Definition": "Exceedingly idealistic; unrealistic and impractical.",
+		"Example":    "A quixotic quest to spell every word in the dictionary.",
+		"AudioURL":   "https://cdn.example/audio/quixotic.mp3",
+	},
+	"error-notification.tmpl": {
+		"BaseURL":       "http://localhost:4444",
+		"Message":       "something went wrong",
+		"RequestMethod": "GET",
+		"RequestURL":    "/games",
+		"Trace":         "goroutine 1 [running]:\nmain.main()",
+	},
+	"example.tmpl": {
+		"Name": "spellingbee",
+	},
+}
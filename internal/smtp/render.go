@@ -0,0 +1,161 @@
+package smtp
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"big-spella-go/assets"
+	"big-spella-go/internal/funcs"
+
+	htmlTemplate "html/template"
+	textTemplate "text/template"
+)
+
+const (
+	templateDir = "emails"
+	// partialsPattern matches the templates every email is parsed with, like
+	// the HTML layout
+	partialsPattern = templateDir + "/partials/*.tmpl"
+	// imageDir holds the images emails embed. HTML bodies show them with
+	// cid: URLs of their file names, like cid:logo.png.
+	imageDir = templateDir + "/images"
+)
+
+// Email is an email rendered from its templates, ready to send
+type Email struct {
+	Subject   string
+	PlainBody string
+	// HTMLBody is empty for plain text emails
+	HTMLBody string
+	// Images are the file names of the images in imageDir that HTMLBody
+	// shows
+	Images []string
+}
+
+// Render renders the email defined by the templates in patterns, relative
+// to the emails directory, for data. The templates define its "subject" and
+// at least one of "plainBody" and "htmlBody". An HTML body can be laid out
+// in the shared "layout" by defining its "content"; when there's no plain
+// text body, one is made from the HTML.
+func Render(data any, patterns ...string) (*Email, error) {
+	paths := []string{partialsPattern}
+	for _, pattern := range patterns {
+		paths = append(paths, templateDir+"/"+pattern)
+	}
+
+	ts, err := textTemplate.New("").Funcs(funcs.TemplateFuncs).ParseFS(assets.EmbeddedFiles, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	email := &Email{}
+	subject := new(bytes.Buffer)
+	err = ts.ExecuteTemplate(subject, "subject", data)
+	if err != nil {
+		return nil, err
+	}
+	email.Subject = strings.TrimSpace(subject.String())
+
+	if ts.Lookup("htmlBody") != nil {
+		ts, err := htmlTemplate.New("").Funcs(funcs.TemplateFuncs).ParseFS(assets.EmbeddedFiles, paths...)
+		if err != nil {
+			return nil, err
+		}
+
+		htmlBody := new(bytes.Buffer)
+		err = ts.ExecuteTemplate(htmlBody, "htmlBody", data)
+		if err != nil {
+			return nil, err
+		}
+		email.HTMLBody = htmlBody.String()
+
+		email.Images, err = embeddedImages(email.HTMLBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case ts.Lookup("plainBody") != nil:
+		plainBody := new(bytes.Buffer)
+		err = ts.ExecuteTemplate(plainBody, "plainBody", data)
+		if err != nil {
+			return nil, err
+		}
+		email.PlainBody = plainBody.String()
+	case email.HTMLBody != "":
+		email.PlainBody = PlainText(email.HTMLBody)
+	default:
+		return nil, fmt.Errorf("email %s has no body", strings.Join(patterns, ", "))
+	}
+
+	return email, nil
+}
+
+// Templates lists the emails that can be rendered, by the file names they
+// are sent with
+func Templates() ([]string, error) {
+	paths, err := fs.Glob(assets.EmbeddedFiles, templateDir+"/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range paths {
+		paths[i] = path.Base(p)
+	}
+	return paths, nil
+}
+
+// Image returns the image in imageDir named name, for previews to show
+func Image(name string) ([]byte, error) {
+	return fs.ReadFile(assets.EmbeddedFiles, path.Join(imageDir, path.Base(name)))
+}
+
+// embeddedImages returns the images in imageDir that body refers to
+func embeddedImages(body string) ([]string, error) {
+	entries, err := fs.ReadDir(assets.EmbeddedFiles, imageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, entry := range entries {
+		if strings.Contains(body, "cid:"+entry.Name()) {
+			images = append(images, entry.Name())
+		}
+	}
+	return images, nil
+}
+
+var (
+	headPattern      = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	linkPattern      = regexp.MustCompile(`(?is)<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	paragraphPattern = regexp.MustCompile(`(?i)</(p|h[1-6]|table)>`)
+	breakPattern     = regexp.MustCompile(`(?i)<br\s*/?>|</(div|li|tr)>`)
+	cellPattern      = regexp.MustCompile(`(?i)</t[dh]>`)
+	tagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// PlainText makes a plain text version of an HTML email body, for emails
+// whose templates don't define one. Links keep their URLs after their text.
+func PlainText(body string) string {
+	body = headPattern.ReplaceAllString(body, "")
+	body = linkPattern.ReplaceAllString(body, "$2 ($1)")
+	body = paragraphPattern.ReplaceAllString(body, "\n\n")
+	body = breakPattern.ReplaceAllString(body, "\n")
+	body = cellPattern.ReplaceAllString(body, " ")
+	body = tagPattern.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	body = blankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(body) + "\n"
+}
@@ -0,0 +1,42 @@
+package smtp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEveryTemplateRenders(t *testing.T) {
+	templates, err := Templates()
+	require.NoError(t, err)
+	require.Contains(t, templates, "welcome.tmpl")
+
+	for _, name := range templates {
+		email, err := Render(PreviewData[name], name)
+		require.NoError(t, err, name)
+		assert.NotEmpty(t, email.Subject, name)
+		assert.NotEmpty(t, email.PlainBody, name)
+		assert.NotContains(t, email.PlainBody, "<no value>", name)
+	}
+}
+
+func TestRenderLaysOutHTML(t *testing.T) {
+	email, err := Render(PreviewData["weekly_digest.tmpl"], "weekly_digest.tmpl")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Your week on Big Spella", email.Subject)
+	assert.Contains(t, email.HTMLBody, "onomatopoeia")
+	assert.Contains(t, email.HTMLBody, `href="https://bigspella.example/settings/email"`)
+	assert.Equal(t, []string{"logo.png"}, email.Images)
+}
+
+func TestPlainText(t *testing.T) {
+	body := `<html><head><title>Hi</title><style>p { color: red; }</style></head>
+<body><p>Hi   <strong>bee</strong>,</p>
+<p>Tom &amp; Jerry<br>spelled it.</p>
+<table><tr><td>Games</td><td>3</td></tr></table>
+<p><a class="button" href="https://example.com/?a=1&amp;b=2">Play</a></p></body></html>`
+
+	assert.Equal(t, "Hi bee,\n\nTom & Jerry\nspelled it.\n\nGames 3\n\nPlay (https://example.com/?a=1&b=2)\n", PlainText(body))
+}
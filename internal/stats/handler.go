@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+// Me is the path ID players use for themselves. Stats are served at
+// /users/:id/stats as the router can't have /users/me beside /users/:id.
+const Me = "me"
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Get serves the player's own stats, at /users/me/stats or under their ID
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if id != Me {
+		var v validator.Validator
+		_, err := uuid.Parse(id)
+		v.CheckField(err == nil, "id", "Must be a valid ID or \"me\"")
+		if v.HasErrors() {
+			failedValidation(w, v)
+			return
+		}
+		if id != userID {
+			http.Error(w, "players can only see their own stats", http.StatusForbidden)
+			return
+		}
+	}
+
+	stats, err := h.service.ForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, stats); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
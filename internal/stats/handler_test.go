@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"big-spella-go/internal/auth"
+)
+
+func TestGetChecksUserAndID(t *testing.T) {
+	h := NewHandler(nil)
+	self := "3f1c2a9e-8f5b-4a57-9a53-0a3b8f1f6c2d"
+
+	get := func(userID, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users/"+id+"/stats", nil)
+		ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: id}})
+		if userID != "" {
+			ctx = auth.SetUserIDInContext(ctx, userID)
+		}
+		rec := httptest.NewRecorder()
+		h.Get(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("", Me).Code)
+
+	rec := get(self, "ada")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "id")
+
+	assert.Equal(t, http.StatusForbidden, get(self, "6b0d5f3e-2c1a-4e8b-9f7d-1a2b3c4d5e6f").Code,
+		"players can't see each other's stats")
+}
+
+func TestAccuracyRate(t *testing.T) {
+	assert.Zero(t, Accuracy{}.withRate().Rate, "no attempts isn't a division by zero")
+	assert.Equal(t, 0.75, Accuracy{Attempts: 4, Correct: 3}.withRate().Rate)
+}
+
+func TestForUserServesCachedStats(t *testing.T) {
+	s := NewService(nil, nil)
+	cached := &Stats{UserID: "ada"}
+	s.cache["ada"] = cachedStats{stats: cached, expires: time.Now().Add(time.Minute)}
+
+	stats, err := s.ForUser(context.Background(), "ada")
+	assert.NoError(t, err)
+	assert.Same(t, cached, stats)
+}
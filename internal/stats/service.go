@@ -0,0 +1,252 @@
+// Package stats rolls up how a player has been spelling, for their stats
+// screen
+package stats
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"big-spella-go/internal/game/ranking"
+)
+
+const (
+	// CacheTTL is how long a player's stats are served before they are
+	// rolled up again
+	CacheTTL = 5 * time.Minute
+
+	// MostMissedLimit is how many of a player's most missed words are shown
+	// and RankHistoryLimit how many of their latest rating changes
+	MostMissedLimit  = 10
+	RankHistoryLimit = 100
+)
+
+// Accuracy counts a player's attempts and how many were correct
+type Accuracy struct {
+	Attempts int     `json:"attempts" db:"attempts"`
+	Correct  int     `json:"correct" db:"correct"`
+	Rate     float64 `json:"rate" db:"-"`
+}
+
+// LevelAccuracy is a player's accuracy on the words of one level
+type LevelAccuracy struct {
+	Level int `json:"level" db:"level"`
+	Accuracy
+}
+
+// CategoryAccuracy is a player's accuracy in games of one category
+type CategoryAccuracy struct {
+	Category string `json:"category" db:"category"`
+	Name     string `json:"name" db:"name"`
+	Accuracy
+}
+
+// HintUsage is how much a player leans on hints
+type HintUsage struct {
+	Total int `json:"total" db:"total"`
+	// Words counts the words the player took at least one hint on, and
+	// PerWord is the hints they took over all the words they attempted
+	Words   int     `json:"words" db:"words"`
+	PerWord float64 `json:"per_word" db:"per_word"`
+}
+
+// RankPoint is a player's rating after a ranked game or an adjustment
+type RankPoint struct {
+	At     time.Time `json:"at" db:"at"`
+	Points int       `json:"points" db:"points"`
+	// Source is "game" or "adjustment"
+	Source string `json:"source" db:"source"`
+}
+
+// MissedWord is a word a player keeps getting wrong
+type MissedWord struct {
+	Word     string `json:"word" db:"word"`
+	Misses   int    `json:"misses" db:"misses"`
+	Attempts int    `json:"attempts" db:"attempts"`
+}
+
+// Stats is everything on a player's stats screen
+type Stats struct {
+	UserID     string             `json:"user_id"`
+	Overall    Accuracy           `json:"overall"`
+	ByLevel    []LevelAccuracy    `json:"by_level"`
+	ByCategory []CategoryAccuracy `json:"by_category"`
+	// AverageResponseMS is how long the player takes to answer, from the
+	// answer window opening. It's nil until they have answered in time.
+	AverageResponseMS *float64        `json:"average_response_ms"`
+	Hints             HintUsage       `json:"hints"`
+	Rating            *ranking.Rating `json:"rating"`
+	RankHistory       []RankPoint     `json:"rank_history"`
+	MostMissed        []MissedWord    `json:"most_missed"`
+	GeneratedAt       time.Time       `json:"generated_at"`
+}
+
+type cachedStats struct {
+	stats   *Stats
+	expires time.Time
+}
+
+// Ratings looks up a player's current rating. ranking.Service implements it.
+type Ratings interface {
+	GetRating(ctx context.Context, userID string) (*ranking.Rating, error)
+}
+
+type Service struct {
+	db      *sqlx.DB
+	ratings Ratings
+
+	mu    sync.Mutex
+	cache map[string]cachedStats
+}
+
+func NewService(db *sqlx.DB, ratings Ratings) *Service {
+	return &Service{db: db, ratings: ratings, cache: make(map[string]cachedStats)}
+}
+
+// ForUser returns userID's stats, rolling them up if those cached are more
+// than CacheTTL old
+func (s *Service) ForUser(ctx context.Context, userID string) (*Stats, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.stats, nil
+	}
+
+	stats, err := s.rollUp(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for id, entry := range s.cache {
+		if now.After(entry.expires) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[userID] = cachedStats{stats: stats, expires: now.Add(CacheTTL)}
+	s.mu.Unlock()
+	return stats, nil
+}
+
+// judgedAttempts are userID's attempts that have been ruled on, with the
+// level and category of the game they were made in
+const judgedAttempts = `
+	SELECT a.word, a.is_correct, a.answer_ms, a.hints_used,
+		COALESCE((g.settings->>'word_level')::int, 0) AS level,
+		g.settings->>'category' AS category
+	FROM spelling_attempts a
+	JOIN games g ON g.id = a.game_id
+	WHERE a.player_id = $1 AND a.status = 'judged'`
+
+func (s *Service) rollUp(ctx context.Context, userID string, now time.Time) (*Stats, error) {
+	stats := &Stats{UserID: userID, GeneratedAt: now}
+
+	rating, err := s.ratings.GetRating(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Rating = rating
+
+	// The rollup's grand total, with no level, is the overall accuracy
+	var levels []struct {
+		Level *int `db:"level"`
+		Accuracy
+	}
+	if err := s.db.SelectContext(ctx, &levels, `
+		WITH attempts AS (`+judgedAttempts+`)
+		SELECT level, COUNT(*) AS attempts, COUNT(*) FILTER (WHERE is_correct) AS correct
+		FROM attempts
+		GROUP BY ROLLUP (level)
+		ORDER BY level NULLS FIRST`, userID); err != nil {
+		return nil, fmt.Errorf("failed to roll up accuracy by level: %w", err)
+	}
+	stats.ByLevel = []LevelAccuracy{}
+	for _, row := range levels {
+		if row.Level == nil {
+			stats.Overall = row.Accuracy.withRate()
+			continue
+		}
+		stats.ByLevel = append(stats.ByLevel, LevelAccuracy{Level: *row.Level, Accuracy: row.Accuracy.withRate()})
+	}
+
+	stats.ByCategory = []CategoryAccuracy{}
+	if err := s.db.SelectContext(ctx, &stats.ByCategory, `
+		WITH attempts AS (`+judgedAttempts+`)
+		SELECT a.category, COALESCE(c.name, a.category) AS name,
+			COUNT(*) AS attempts, COUNT(*) FILTER (WHERE a.is_correct) AS correct
+		FROM attempts a
+		LEFT JOIN categories c ON c.slug = a.category
+		WHERE a.category IS NOT NULL
+		GROUP BY a.category, c.name
+		ORDER BY COUNT(*) DESC, a.category`, userID); err != nil {
+		return nil, fmt.Errorf("failed to roll up accuracy by category: %w", err)
+	}
+	for i := range stats.ByCategory {
+		stats.ByCategory[i].Accuracy = stats.ByCategory[i].Accuracy.withRate()
+	}
+
+	var usage struct {
+		AverageResponseMS *float64 `db:"average_response_ms"`
+		HintUsage
+	}
+	if err := s.db.GetContext(ctx, &usage, `
+		WITH attempts AS (`+judgedAttempts+`)
+		SELECT AVG(answer_ms)::float8 AS average_response_ms,
+			COALESCE(SUM(hints_used), 0) AS total,
+			COUNT(*) FILTER (WHERE hints_used > 0) AS words,
+			COALESCE(AVG(hints_used), 0)::float8 AS per_word
+		FROM attempts`, userID); err != nil {
+		return nil, fmt.Errorf("failed to roll up response times and hints: %w", err)
+	}
+	stats.AverageResponseMS = usage.AverageResponseMS
+	stats.Hints = usage.HintUsage
+
+	// The history would give away a rating hidden during placements
+	stats.RankHistory = []RankPoint{}
+	if !rating.Provisional() {
+		if err := s.db.SelectContext(ctx, &stats.RankHistory, `
+			SELECT at, points, source FROM (
+				SELECT created_at AS at, new_rank_points AS points, 'game' AS source
+				FROM game_results
+				WHERE player_id = $1
+				UNION ALL
+				SELECT created_at, new_rank_points, 'adjustment'
+				FROM ranking_adjustments
+				WHERE user_id = $1
+			) changes
+			ORDER BY at DESC
+			LIMIT $2`, userID, RankHistoryLimit); err != nil {
+			return nil, fmt.Errorf("failed to get rank history: %w", err)
+		}
+		slices.Reverse(stats.RankHistory)
+	}
+
+	stats.MostMissed = []MissedWord{}
+	if err := s.db.SelectContext(ctx, &stats.MostMissed, `
+		WITH attempts AS (`+judgedAttempts+`)
+		SELECT LOWER(word) AS word, COUNT(*) FILTER (WHERE NOT is_correct) AS misses, COUNT(*) AS attempts
+		FROM attempts
+		GROUP BY LOWER(word)
+		HAVING COUNT(*) FILTER (WHERE NOT is_correct) > 0
+		ORDER BY misses DESC, attempts DESC, word
+		LIMIT $2`, userID, MostMissedLimit); err != nil {
+		return nil, fmt.Errorf("failed to get most missed words: %w", err)
+	}
+
+	return stats, nil
+}
+
+// withRate fills in the share of attempts that were correct
+func (a Accuracy) withRate() Accuracy {
+	if a.Attempts > 0 {
+		a.Rate = float64(a.Correct) / float64(a.Attempts)
+	}
+	return a
+}
@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// LogExporter writes spans to a logger, for local debugging
+type LogExporter struct {
+	logger *slog.Logger
+}
+
+func NewLogExporter(logger *slog.Logger) *LogExporter {
+	return &LogExporter{logger: logger}
+}
+
+func (e *LogExporter) Export(ctx context.Context, spans []SpanData) error {
+	for _, span := range spans {
+		attrs := []any{
+			"trace_id", span.TraceID.String(),
+			"span_id", span.SpanID.String(),
+			"duration", span.End.Sub(span.Start),
+		}
+		if span.ParentID.IsValid() {
+			attrs = append(attrs, "parent_id", span.ParentID.String())
+		}
+		for _, attr := range span.Attributes {
+			attrs = append(attrs, attr.Key, attr.Value)
+		}
+		if span.Error != "" {
+			attrs = append(attrs, "error", span.Error)
+		}
+		e.logger.InfoContext(ctx, "span "+span.Name, attrs...)
+	}
+	return nil
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP/HTTP in
+// its JSON encoding
+type OTLPExporter struct {
+	endpoint string
+	service  string
+	client   *http.Client
+}
+
+// NewOTLPExporter posts to endpoint's /v1/traces, such as
+// http://localhost:4318, naming the spans' resource service
+func NewOTLPExporter(endpoint, service string, client *http.Client) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: strings.TrimRight(endpoint, "/") + "/v1/traces",
+		service:  service,
+		client:   client,
+	}
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID      string     `json:"traceId"`
+	SpanID       string     `json:"spanId"`
+	ParentSpanID string     `json:"parentSpanId,omitempty"`
+	Name         string     `json:"name"`
+	Kind         SpanKind   `json:"kind"`
+	Start        string     `json:"startTimeUnixNano"`
+	End          string     `json:"endTimeUnixNano"`
+	Attributes   []otlpAttr `json:"attributes,omitempty"`
+	Status       struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func toOTLPAttr(attr Attr) otlpAttr {
+	var v otlpValue
+	switch value := attr.Value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case int:
+		s := strconv.Itoa(value)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return otlpAttr{Key: attr.Key, Value: v}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	var scope otlpScopeSpans
+	scope.Scope.Name = "big-spella-go/internal/tracing"
+
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID: span.TraceID.String(),
+			SpanID:  span.SpanID.String(),
+			Name:    span.Name,
+			Kind:    span.Kind,
+			Start:   strconv.FormatInt(span.Start.UnixNano(), 10),
+			End:     strconv.FormatInt(span.End.UnixNano(), 10),
+		}
+		if span.ParentID.IsValid() {
+			s.ParentSpanID = span.ParentID.String()
+		}
+		for _, attr := range span.Attributes {
+			s.Attributes = append(s.Attributes, toOTLPAttr(attr))
+		}
+		if span.Error != "" {
+			s.Status.Code = 2
+			s.Status.Message = span.Error
+		}
+		scope.Spans = append(scope.Spans, s)
+	}
+
+	var resource otlpResourceSpans
+	resource.Resource.Attributes = []otlpAttr{toOTLPAttr(String("service.name", e.service))}
+	resource.ScopeSpans = []otlpScopeSpans{scope}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{resource}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to reach collector: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %s: %s", resp.Status, msg)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const traceparentHeader = "traceparent"
+
+// Extract returns ctx carrying the span context the caller sent in a W3C
+// traceparent header, so spans started from it join the caller's trace
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := parseTraceparent(header.Get(traceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey, sc)
+}
+
+// Inject writes the span context in ctx to header for the next service
+func Inject(ctx context.Context, header http.Header) {
+	sc := spanContextFrom(ctx)
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	header.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags))
+}
+
+// parseTraceparent reads version 00 of the header:
+// 00-<32 hex trace id>-<16 hex parent id>-<2 hex flags>
+func parseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	if len(value) != 55 || value[:3] != "00-" || value[35] != '-' || value[52] != '-' {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(value[3:35])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(value[36:52])); err != nil {
+		return sc, false
+	}
+	flags, err := strconv.ParseUint(value[53:], 16, 8)
+	if err != nil || !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return sc, false
+	}
+	sc.Sampled = flags&1 == 1
+	return sc, true
+}
+
+// StartServer begins the span for an incoming request, continuing the
+// caller's trace when it sent one
+func StartServer(r *http.Request, name string, attrs ...Attr) (*http.Request, *Span) {
+	ctx, span := startKind(Extract(r.Context(), r.Header), name, KindServer, attrs)
+	return r.WithContext(ctx), span
+}
+
+// Transport traces outgoing requests and passes the trace on to the server
+// they go to
+type Transport struct {
+	// Base makes the request, http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := startKind(req.Context(), "HTTP "+req.Method+" "+req.URL.Host, KindClient, []Attr{
+		String("http.method", req.Method),
+		String("server.address", req.URL.Host),
+		String("url.path", req.URL.Path),
+	})
+	if span == nil {
+		return base.RoundTrip(req)
+	}
+	defer span.End()
+
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.RecordError(fmt.Errorf("%s", resp.Status))
+	}
+	return resp, nil
+}
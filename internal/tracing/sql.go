@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+)
+
+// WrapDriver traces the statements run through d's connections. It is meant
+// for sql.Register under a name of its own.
+func WrapDriver(d driver.Driver) driver.Driver {
+	return &tracedDriver{Driver: d}
+}
+
+type tracedDriver struct {
+	driver.Driver
+}
+
+func (d *tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn}, nil
+}
+
+// tracedConn passes everything through to the driver's connection, which
+// must support contexts, and spans the statements it runs
+type tracedConn struct {
+	driver.Conn
+}
+
+func startStatement(ctx context.Context, query string) (context.Context, *Span) {
+	operation := strings.ToUpper(strings.Fields(query + " ?")[0])
+	return startKind(ctx, "db "+operation, KindClient, []Attr{
+		String("db.system", "postgresql"),
+		String("db.statement", query),
+	})
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		span.RecordError(err)
+	}
+	return result, err
+}
+
+// QueryContext's span covers running the query, not reading its rows
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		span.RecordError(err)
+	}
+	return rows, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
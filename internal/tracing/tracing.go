@@ -0,0 +1,322 @@
+// Package tracing records spans of work and propagates them across service
+// boundaries with W3C trace context headers, so a slow request can be broken
+// down into its HTTP, dictionary, TTS and database calls.
+//
+// Until SetTracer installs a Tracer, Start returns a nil *Span whose methods
+// do nothing, so instrumented code costs next to nothing with tracing off.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	batchSize     = 512
+	queueSize     = 4096
+	flushInterval = 5 * time.Second
+)
+
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+type SpanKind int
+
+// The values match OTLP's span kinds
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Attr is a key and a string, bool, int, int64 or float64 value describing
+// a span
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attr        { return Attr{key, value} }
+func Int(key string, value int) Attr       { return Attr{key, int64(value)} }
+func Bool(key string, value bool) Attr     { return Attr{key, value} }
+func Float(key string, value float64) Attr { return Attr{key, value} }
+func Duration(key string, d time.Duration) Attr {
+	return Attr{key, d.Milliseconds()}
+}
+
+// SpanContext identifies a span, here or in another service
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// SpanData is a finished span as handed to an Exporter
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	TraceID    TraceID
+	SpanID     SpanID
+	ParentID   SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes []Attr
+	Error      string
+}
+
+// Span is a unit of work in progress. A nil Span is valid and does nothing.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	ended  atomic.Bool
+
+	mu   sync.Mutex
+	data SpanData
+}
+
+// Context identifies the span for propagation
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError marks the span failed. A nil err is ignored so callers can
+// pass whatever they are about to return.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	s.data.Error = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil || !s.ended.CompareAndSwap(false, true) || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.enqueue(data)
+}
+
+// EndWith records *err, if any, and ends the span. It is meant to be
+// deferred by functions with a named error result.
+func (s *Span) EndWith(err *error) {
+	if err != nil {
+		s.RecordError(*err)
+	}
+	s.End()
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Tracer samples new traces and batches finished spans to an Exporter
+type Tracer struct {
+	exporter    Exporter
+	sampleRatio float64
+	onError     func(error)
+
+	queue    chan SpanData
+	dropped  atomic.Int64
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTracer keeps sampleRatio of new traces, between 0 and 1; traces started
+// elsewhere follow the caller's sampling decision. Failed exports are
+// reported to onError.
+func NewTracer(exporter Exporter, sampleRatio float64, onError func(error)) *Tracer {
+	t := &Tracer{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		onError:     onError,
+		queue:       make(chan SpanData, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *Tracer) enqueue(span SpanData) {
+	select {
+	case t.queue <- span:
+	default:
+		// Tracing must never hold up the work it describes
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, batchSize)
+	flush := func() {
+		if dropped := t.dropped.Swap(0); dropped > 0 {
+			t.report(fmt.Errorf("dropped %d spans with the export queue full", dropped))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			t.report(fmt.Errorf("failed to export %d spans: %w", len(batch), err))
+		}
+		batch = make([]SpanData, 0, batchSize)
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			if batch = append(batch, span); len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) report(err error) {
+	if t.onError != nil {
+		t.onError(err)
+	}
+}
+
+// Shutdown exports the spans still queued, giving up when ctx is done
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind SpanKind, attrs []Attr) (context.Context, *Span) {
+	parent := spanContextFrom(ctx)
+
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+	if !parent.TraceID.IsValid() {
+		rand.Read(sc.TraceID[:])
+		sc.Sampled = mathrand.Float64() < t.sampleRatio
+	}
+	rand.Read(sc.SpanID[:])
+
+	span := &Span{tracer: t, sc: sc}
+	if sc.Sampled {
+		span.data = SpanData{
+			Name:       name,
+			Kind:       kind,
+			TraceID:    sc.TraceID,
+			SpanID:     sc.SpanID,
+			ParentID:   parent.SpanID,
+			Start:      time.Now(),
+			Attributes: append([]Attr(nil), attrs...),
+		}
+	}
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+var (
+	globalMu sync.RWMutex
+	global   *Tracer
+)
+
+// SetTracer has Start record spans with t. It is meant to be called once at
+// startup; nil turns tracing off.
+func SetTracer(t *Tracer) {
+	globalMu.Lock()
+	global = t
+	globalMu.Unlock()
+}
+
+func tracer() *Tracer {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return global
+}
+
+type contextKey int
+
+const (
+	spanKey contextKey = iota
+	remoteKey
+)
+
+// Start begins a span named name as a child of the span in ctx. The caller
+// must End it.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return startKind(ctx, name, KindInternal, attrs)
+}
+
+func startKind(ctx context.Context, name string, kind SpanKind, attrs []Attr) (context.Context, *Span) {
+	t := tracer()
+	if t == nil {
+		return ctx, nil
+	}
+	return t.start(ctx, name, kind, attrs)
+}
+
+// SpanFromContext returns the span in progress in ctx, if any
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// spanContextFrom is the parent for a span started in ctx: the span in
+// progress or, failing that, one propagated from another service
+func spanContextFrom(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc
+	}
+	sc, _ := ctx.Value(remoteKey).(SpanContext)
+	return sc
+}
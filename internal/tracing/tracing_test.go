@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *recordingExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func useTracer(t *testing.T, ratio float64) *recordingExporter {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter, ratio, nil)
+	SetTracer(tracer)
+	t.Cleanup(func() {
+		SetTracer(nil)
+		tracer.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestStartIsNoopWithoutTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "work")
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(errors.New("boom"))
+	span.End()
+}
+
+func TestChildSpansJoinTheTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter, 1, nil)
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	ctx, parent := Start(context.Background(), "game.StartGame", String("game.id", "g1"))
+	_, child := Start(ctx, "words.GetRandomWord")
+	err := errors.New("no words")
+	child.EndWith(&err)
+	parent.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	require.Len(t, exporter.spans, 2)
+	c, p := exporter.spans[0], exporter.spans[1]
+	assert.Equal(t, p.TraceID, c.TraceID)
+	assert.Equal(t, p.SpanID, c.ParentID)
+	assert.False(t, p.ParentID.IsValid())
+	assert.Equal(t, "no words", c.Error)
+	assert.Equal(t, []Attr{String("game.id", "g1")}, p.Attributes)
+}
+
+func TestUnsampledTracesStillPropagate(t *testing.T) {
+	exporter := useTracer(t, 0)
+
+	ctx, span := Start(context.Background(), "work")
+	require.NotNil(t, span)
+	header := http.Header{}
+	Inject(ctx, header)
+	span.End()
+
+	assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-00$`, header.Get("traceparent"))
+	assert.Empty(t, exporter.spans)
+}
+
+func TestTraceparent(t *testing.T) {
+	sc, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+
+	for _, bad := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		_, ok := parseTraceparent(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestServerAndClientSpansCrossServices(t *testing.T) {
+	exporter := useTracer(t, 0)
+
+	var received string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+
+	incoming := httptest.NewRequest(http.MethodPost, "/games/g1/start", nil)
+	incoming.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r, server := StartServer(incoming, "POST /games/:gameID/start")
+
+	client := &http.Client{Transport: &Transport{}}
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL+"/tts", nil)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	server.End()
+
+	assert.Empty(t, req.Header.Get("traceparent"), "the caller's request is left alone")
+	sc, ok := parseTraceparent(received)
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.True(t, sc.Sampled, "the caller's sampling decision wins over the ratio")
+
+	require.NoError(t, tracer().Shutdown(context.Background()))
+	require.Len(t, exporter.spans, 2)
+	assert.Equal(t, KindClient, exporter.spans[0].Kind)
+	assert.Equal(t, KindServer, exporter.spans[1].Kind)
+	assert.Equal(t, exporter.spans[0].SpanID, sc.SpanID)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]any
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer collector.Close()
+
+	start := time.Unix(1700000000, 0)
+	exporter := NewOTLPExporter(collector.URL+"/", "big-spella-api", collector.Client())
+	err := exporter.Export(context.Background(), []SpanData{{
+		Name:       "db SELECT",
+		Kind:       KindClient,
+		TraceID:    TraceID{1},
+		SpanID:     SpanID{2},
+		Start:      start,
+		End:        start.Add(time.Millisecond),
+		Attributes: []Attr{String("db.system", "postgresql"), Int("rows", 3)},
+		Error:      "timeout",
+	}})
+	require.NoError(t, err)
+
+	resource := body["resourceSpans"].([]any)[0].(map[string]any)
+	span := resource["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	assert.Equal(t, "01000000000000000000000000000000", span["traceId"])
+	assert.Equal(t, "1700000000000000000", span["startTimeUnixNano"])
+	assert.Equal(t, float64(KindClient), span["kind"])
+	assert.Equal(t, map[string]any{"code": float64(2), "message": "timeout"}, span["status"])
+	assert.Contains(t, span["attributes"], map[string]any{"key": "rows", "value": map[string]any{"intValue": "3"}})
+}
@@ -0,0 +1,63 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	history *HistoryStore
+}
+
+func NewHandler(history *HistoryStore) *Handler {
+	return &Handler{history: history}
+}
+
+// GameHistory serves a page of a user's finished games
+func (h *Handler) GameHistory(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+
+	userID, err := uuid.Parse(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	v.CheckField(err == nil, "id", "Must be a valid user ID")
+
+	page, pageSize := 1, DefaultHistoryPageSize
+	query := r.URL.Query()
+	if raw := query.Get("page"); raw != "" {
+		page, err = strconv.Atoi(raw)
+		v.CheckField(err == nil && page >= 1, "page", "Must be a whole number greater than zero")
+	}
+	if raw := query.Get("page_size"); raw != "" {
+		pageSize, err = strconv.Atoi(raw)
+		v.CheckField(err == nil && validator.Between(pageSize, 1, MaxHistoryPageSize), "page_size", "Must be between 1 and 100")
+	}
+
+	if v.HasErrors() {
+		if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	history, total, err := h.history.ListGameHistory(r.Context(), userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"games": history,
+		"page": map[string]int{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	DefaultHistoryPageSize = 20
+	MaxHistoryPageSize     = 100
+)
+
+type HistoryStore struct {
+	db *sqlx.DB
+}
+
+func NewHistoryStore(db *sqlx.DB) *HistoryStore {
+	return &HistoryStore{db: db}
+}
+
+// ListGameHistory returns a page of the games userID finished, most recent
+// first, along with how many there are in total
+func (s *HistoryStore) ListGameHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]GameHistory, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM game_history
+		WHERE user_id = $1`, userID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count game history: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, game_id, game_type, score, position, words_spelled, duration, created_at
+		FROM game_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get game history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []GameHistory{}
+	for rows.Next() {
+		var h GameHistory
+		if err := rows.Scan(&h.ID, &h.UserID, &h.GameID, &h.GameType, &h.Score, &h.Position,
+			pq.Array(&h.WordsSpelled), &h.Duration, &h.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan game history: %w", err)
+		}
+		history = append(history, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read game history: %w", err)
+	}
+
+	return history, total, nil
+}
+
+// ListWordHistory returns a page of the words userID has spelled, most
+// recently attempted first, along with how many there are in total
+func (s *HistoryStore) ListWordHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]WordHistory, int, error) {
+	var total int
+	if err := s.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM user_word_history
+		WHERE user_id = $1`, userID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count word history: %w", err)
+	}
+
+	history := []WordHistory{}
+	if err := s.db.SelectContext(ctx, &history, `
+		SELECT h.word_id, w.word, w.definition, w.level, h.status,
+			h.correct_attempts, h.incorrect_attempts, h.last_attempt_at, h.next_review_at
+		FROM user_word_history h
+		JOIN words w ON w.id = h.word_id
+		WHERE h.user_id = $1
+		ORDER BY h.last_attempt_at DESC NULLS LAST, h.word_id
+		LIMIT $2 OFFSET $3`, userID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to get word history: %w", err)
+	}
+	return history, total, nil
+}
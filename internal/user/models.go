@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"big-spella-go/internal/notifications"
 )
 
 // Profile represents a user's profile in the system
@@ -61,6 +63,19 @@ type GameHistory struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// WordHistory is how a user has got on with a word they've spelled
+type WordHistory struct {
+	WordID            uuid.UUID  `json:"word_id" db:"word_id"`
+	Word              string     `json:"word" db:"word"`
+	Definition        string     `json:"definition" db:"definition"`
+	Level             int        `json:"level" db:"level"`
+	Status            string     `json:"status" db:"status"`
+	CorrectAttempts   int        `json:"correct_attempts" db:"correct_attempts"`
+	IncorrectAttempts int        `json:"incorrect_attempts" db:"incorrect_attempts"`
+	LastAttemptAt     *time.Time `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	NextReviewAt      *time.Time `json:"next_review_at,omitempty" db:"next_review_at"`
+}
+
 // UserAchievement tracks user achievements
 type UserAchievement struct {
 	ID           uuid.UUID `json:"id" db:"id"`
@@ -78,5 +93,11 @@ type UserPreferences struct {
 	Language        string    `json:"language" db:"language"`
 	SoundEffects    bool      `json:"sound_effects" db:"sound_effects"`
 	Music          bool      `json:"music" db:"music"`
+	TTSVoice       *string   `json:"tts_voice,omitempty" db:"tts_voice"`
+	SpeechRate     float64   `json:"speech_rate" db:"speech_rate"`
+	SlowRepeat     bool      `json:"slow_repeat" db:"slow_repeat"`
+	// NotificationChannels are the channels the user has turned on or off
+	// for each kind of notification
+	NotificationChannels notifications.ChannelSettings `json:"notification_channels" db:"notification_channels"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
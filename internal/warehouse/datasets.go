@@ -0,0 +1,141 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Game is a game as it was at the end of a day it changed on. A game that
+// changes over several days is in each of their partitions, and its latest
+// row is its current state.
+type Game struct {
+	ID        string    `db:"id" parquet:"id"`
+	Type      string    `db:"type" parquet:"type,dict"`
+	Mode      string    `db:"mode" parquet:"mode,dict"`
+	Status    string    `db:"status" parquet:"status,dict"`
+	Round     int       `db:"round" parquet:"round"`
+	HostID    *string   `db:"host_id" parquet:"host_id,optional"`
+	Settings  string    `db:"settings" parquet:"settings"`
+	CreatedAt time.Time `db:"created_at" parquet:"created_at,timestamp(millisecond)"`
+	UpdatedAt time.Time `db:"updated_at" parquet:"updated_at,timestamp(millisecond)"`
+}
+
+// Attempt is a spelling attempt, in the partition of the day it was made
+type Attempt struct {
+	ID          string    `db:"id" parquet:"id"`
+	GameID      string    `db:"game_id" parquet:"game_id"`
+	PlayerID    string    `db:"player_id" parquet:"player_id"`
+	Word        string    `db:"word" parquet:"word"`
+	Type        string    `db:"type" parquet:"type,dict"`
+	Text        string    `db:"text" parquet:"text"`
+	IsCorrect   bool      `db:"is_correct" parquet:"is_correct"`
+	Status      string    `db:"status" parquet:"status,dict"`
+	Ruling      *string   `db:"ruling" parquet:"ruling,optional"`
+	Match       *string   `db:"match" parquet:"match,optional"`
+	Points      int       `db:"points" parquet:"points"`
+	HintsUsed   int       `db:"hints_used" parquet:"hints_used"`
+	AnswerMS    *int      `db:"answer_ms" parquet:"answer_ms,optional"`
+	AttemptedAt time.Time `db:"timestamp" parquet:"attempted_at,timestamp(millisecond)"`
+}
+
+// Result is a player's result in a game they finished, in the partition of
+// the day the game finished
+type Result struct {
+	ID         string    `db:"id" parquet:"id"`
+	UserID     string    `db:"user_id" parquet:"user_id"`
+	GameID     string    `db:"game_id" parquet:"game_id"`
+	GameType   string    `db:"game_type" parquet:"game_type,dict"`
+	Score      int       `db:"score" parquet:"score"`
+	Position   int       `db:"position" parquet:"position"`
+	Team       *int      `db:"team" parquet:"team,optional"`
+	Duration   int       `db:"duration" parquet:"duration_seconds"`
+	FinishedAt time.Time `db:"created_at" parquet:"finished_at,timestamp(millisecond)"`
+}
+
+// dataset is a table exported a day at a time. Its version goes up
+// whenever its columns change, and a new version is exported again from
+// the first day so every partition of it has the same columns.
+type dataset struct {
+	name    string
+	version int
+	// table and column are where the dataset's rows are, and the time
+	// assigning each to a day
+	table, column string
+	// export encodes the rows from the day starting at from
+	export func(ctx context.Context, db sqlx.QueryerContext, from, to time.Time) ([]byte, int, error)
+}
+
+var datasets = []dataset{
+	datasetOf[Game]("games", 1, "games", "updated_at", `
+		SELECT id, type, mode, status, round, host_id, settings, created_at, updated_at
+		FROM games
+		WHERE updated_at >= $1 AND updated_at < $2
+		ORDER BY updated_at`),
+	datasetOf[Attempt]("attempts", 1, "spelling_attempts", "timestamp", `
+		SELECT id, game_id, player_id, word, type, text, is_correct, status, ruling, match,
+			points, hints_used, answer_ms, timestamp
+		FROM spelling_attempts
+		WHERE timestamp >= $1 AND timestamp < $2
+		ORDER BY timestamp`),
+	datasetOf[Result]("results", 1, "game_history", "created_at", `
+		SELECT id, user_id, game_id, game_type, score, position, team, duration, created_at
+		FROM game_history
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at`),
+}
+
+func datasetOf[T any](name string, version int, table, column, query string) dataset {
+	return dataset{
+		name:    name,
+		version: version,
+		table:   table,
+		column:  column,
+		export: func(ctx context.Context, db sqlx.QueryerContext, from, to time.Time) ([]byte, int, error) {
+			var rows []T
+			if err := sqlx.SelectContext(ctx, db, &rows, query, from, to); err != nil {
+				return nil, 0, fmt.Errorf("failed to get %s from %s: %w", name, from.Format(time.DateOnly), err)
+			}
+			if len(rows) == 0 {
+				return nil, 0, nil
+			}
+			data, err := encode(rows, version)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to encode %s from %s: %w", name, from.Format(time.DateOnly), err)
+			}
+			return data, len(rows), nil
+		},
+	}
+}
+
+// encode writes rows as a Snappy compressed Parquet file, noting the schema
+// version in its metadata
+func encode[T any](rows []T, version int) ([]byte, error) {
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[T](&buf,
+		parquet.Compression(&parquet.Snappy),
+		parquet.KeyValueMetadata("schema_version", strconv.Itoa(version)),
+	)
+	if _, err := w.Write(rows); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// key is where the dataset's file for day is stored, partitioned Hive
+// style so Athena can prune by schema version and date
+func (d dataset) key(prefix string, day time.Time) string {
+	key := fmt.Sprintf("%s/schema_version=%d/dt=%s/%s.parquet", d.name, d.version, day.Format(time.DateOnly), d.name)
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
@@ -0,0 +1,47 @@
+package warehouse
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	ruling := "accepted"
+	attempted := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	rows := []Attempt{
+		{ID: "a1", GameID: "g1", PlayerID: "p1", Word: "necessary", Type: "text", Text: "neccessary",
+			Status: "judged", Points: 0, AttemptedAt: attempted},
+		{ID: "a2", GameID: "g1", PlayerID: "p2", Word: "rhythm", Type: "voice", Text: "rhythm", IsCorrect: true,
+			Status: "judged", Ruling: &ruling, Points: 10, HintsUsed: 1, AttemptedAt: attempted.Add(time.Minute)},
+	}
+
+	data, err := encode(rows, 3)
+	require.NoError(t, err)
+
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	version, ok := file.Lookup("schema_version")
+	assert.True(t, ok)
+	assert.Equal(t, "3", version)
+
+	read, err := parquet.Read[Attempt](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+	assert.Equal(t, "neccessary", read[0].Text)
+	assert.Nil(t, read[0].Ruling)
+	assert.Equal(t, &ruling, read[1].Ruling)
+	assert.True(t, read[1].AttemptedAt.Equal(rows[1].AttemptedAt))
+}
+
+func TestKey(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	games := datasets[0]
+
+	assert.Equal(t, "warehouse/games/schema_version=1/dt=2024-03-01/games.parquet", games.key("warehouse", day))
+	assert.Equal(t, "games/schema_version=1/dt=2024-03-01/games.parquet", games.key("", day))
+}
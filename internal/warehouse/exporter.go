@@ -0,0 +1,157 @@
+// Package warehouse exports daily snapshots of games, spelling attempts and
+// game results to S3 as Parquet, for Athena and the analytics warehouse to
+// query.
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ContentType is the media type the Parquet files are stored with
+const ContentType = "application/vnd.apache.parquet"
+
+// Storage stores the exported files. s3.Storage implements it.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// Exporter exports each dataset a day at a time, picking up from the last
+// day it exported. Only days that are over, in UTC, are exported.
+type Exporter struct {
+	db      *sqlx.DB
+	storage Storage
+	prefix  string
+	now     func() time.Time
+}
+
+// NewExporter stores files under prefix in storage
+func NewExporter(db *sqlx.DB, storage Storage, prefix string) *Exporter {
+	return &Exporter{
+		db:      db,
+		storage: storage,
+		prefix:  strings.Trim(prefix, "/"),
+		now:     time.Now,
+	}
+}
+
+// Export exports every day of every dataset that's due, returning how many
+// files it stored
+func (e *Exporter) Export(ctx context.Context) (int, error) {
+	today := e.now().UTC().Truncate(24 * time.Hour)
+
+	stored := 0
+	var errs []error
+	for _, d := range datasets {
+		for {
+			ok, n, err := e.exportNext(ctx, d, today)
+			stored += n
+			if err != nil {
+				// The rest of the dataset waits for the failed day, while
+				// the other datasets carry on
+				errs = append(errs, err)
+			}
+			if !ok || err != nil {
+				break
+			}
+		}
+	}
+	return stored, errors.Join(errs...)
+}
+
+// exportNext exports the day after the last one of d exported, reporting
+// whether there was one due and how many files it stored. The dataset's row
+// stays locked until the day is recorded as exported, so instances wait for
+// each other rather than exporting the same day.
+func (e *Exporter) exportNext(ctx context.Context, d dataset, today time.Time) (bool, int, error) {
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO warehouse_exports (dataset, schema_version) VALUES ($1, $2)
+		ON CONFLICT (dataset) DO NOTHING`, d.name, d.version); err != nil {
+		return false, 0, fmt.Errorf("failed to add %s export: %w", d.name, err)
+	}
+	var state struct {
+		Version int        `db:"schema_version"`
+		Through *time.Time `db:"exported_through"`
+	}
+	if err := tx.GetContext(ctx, &state, `
+		SELECT schema_version, exported_through FROM warehouse_exports
+		WHERE dataset = $1 FOR UPDATE`, d.name); err != nil {
+		return false, 0, fmt.Errorf("failed to get %s export: %w", d.name, err)
+	}
+
+	day, ok, err := e.nextDay(ctx, tx, d, state.Version, state.Through)
+	if err != nil || !ok || !day.Before(today) {
+		return false, 0, err
+	}
+
+	data, rows, err := d.export(ctx, tx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return false, 0, err
+	}
+	// Days without rows have no file, rather than an empty one
+	stored := 0
+	if rows > 0 {
+		if err := e.storage.Put(ctx, d.key(e.prefix, day), data, ContentType); err != nil {
+			return false, 0, fmt.Errorf("failed to store %s from %s: %w", d.name, day.Format(time.DateOnly), err)
+		}
+		stored = 1
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE warehouse_exports
+		SET schema_version = $2, exported_through = $3, exported_at = NOW()
+		WHERE dataset = $1`, d.name, d.version, day); err != nil {
+		return false, 0, fmt.Errorf("failed to record %s export: %w", d.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, 0, err
+	}
+	return true, stored, nil
+}
+
+// nextDay is the day of d to export next. A dataset that hasn't been
+// exported, or whose schema version has changed since, starts from the day
+// of its first row.
+func (e *Exporter) nextDay(ctx context.Context, tx *sqlx.Tx, d dataset, version int, through *time.Time) (time.Time, bool, error) {
+	if through != nil && version == d.version {
+		return through.UTC().AddDate(0, 0, 1), true, nil
+	}
+
+	var first sql.NullTime
+	if err := tx.GetContext(ctx, &first, `SELECT MIN(`+d.column+`) FROM `+d.table); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get the first day of %s: %w", d.name, err)
+	}
+	if !first.Valid {
+		return time.Time{}, false, nil
+	}
+	return first.Time.UTC().Truncate(24 * time.Hour), true, nil
+}
+
+// Run exports whatever is due every interval until ctx is done
+func (e *Exporter) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.Export(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
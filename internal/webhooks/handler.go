@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/response"
+	"big-spella-go/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Create registers a webhook for the caller, responding with the secret its
+// deliveries are signed with, which can't be shown again
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	owner, ok := currentOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var input CreateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.validate(); input.Validator.HasErrors() {
+		failedValidation(w, input.Validator)
+		return
+	}
+
+	webhook, err := h.service.Create(r.Context(), owner, input)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusCreated, webhook); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// List serves the caller's webhooks
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	owner, ok := currentOwner(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.service.List(r.Context(), owner)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusOK, map[string]any{"webhooks": webhooks}); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// Delete stops sending to one of the caller's webhooks
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	owner, ok := currentOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var v validator.Validator
+	webhookID := webhookParam(r, &v)
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), owner, webhookID); err != nil {
+		serviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries serves the latest deliveries to one of the caller's webhooks,
+// with how each went
+func (h *Handler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	owner, ok := currentOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var v validator.Validator
+	webhookID := webhookParam(r, &v)
+	limit := DefaultDeliveries
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		v.CheckField(err == nil && validator.Between(n, 1, MaxDeliveries), "limit", "Must be between 1 and 100")
+		limit = n
+	}
+	if v.HasErrors() {
+		failedValidation(w, v)
+		return
+	}
+
+	deliveries, err := h.service.Deliveries(r.Context(), owner, webhookID, limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	if err := response.JSON(w, http.StatusOK, map[string]any{"deliveries": deliveries}); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func webhookParam(r *http.Request, v *validator.Validator) string {
+	webhookID := httprouter.ParamsFromContext(r.Context()).ByName("webhookID")
+	_, err := uuid.Parse(webhookID)
+	v.CheckField(err == nil, "webhookID", "Must be a valid webhook ID")
+	return webhookID
+}
+
+// currentOwner names who the caller's webhooks belong to: the organizer, or
+// the service account their software signs in as
+func currentOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal := auth.GetPrincipal(r.Context())
+	if principal == nil || principal.ActorID() == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	return principal.ActorID(), true
+}
+
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrTooManyWebhooks):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func failedValidation(w http.ResponseWriter, v validator.Validator) {
+	if err := response.JSON(w, http.StatusUnprocessableEntity, v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,509 @@
+// Package webhooks sends signed event notifications to URLs registered by
+// organizers, so outside tournament software can follow games and new
+// players without polling. Deliveries go through the job queue, which
+// retries them with backoff, and each one's outcome is logged.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"big-spella-go/internal/audit"
+	"big-spella-go/internal/auth"
+	"big-spella-go/internal/game"
+	"big-spella-go/internal/jobs"
+	"big-spella-go/internal/validator"
+)
+
+// Event names something webhooks can be sent for
+type Event string
+
+const (
+	// EventGameEnded is sent with the results of each tournament game
+	EventGameEnded Event = "game_ended"
+	// EventRoundCompleted is sent with the scores after each round of a
+	// tournament game
+	EventRoundCompleted Event = "tournament_round_completed"
+	// EventPlayerRegistered is sent for each new account, other than
+	// children's
+	EventPlayerRegistered Event = "player_registered"
+)
+
+var Events = []Event{EventGameEnded, EventRoundCompleted, EventPlayerRegistered}
+
+// Headers sent with each delivery. The signature is "sha256=" and the hex
+// HMAC-SHA256, keyed with the webhook's secret, of the timestamp, a dot
+// and the body.
+const (
+	HeaderEvent     = "X-Spella-Event"
+	HeaderDelivery  = "X-Spella-Delivery"
+	HeaderTimestamp = "X-Spella-Timestamp"
+	HeaderSignature = "X-Spella-Signature"
+)
+
+const (
+	JobDeliver = "webhook_deliver"
+
+	// MaxAttempts is how many times a delivery is tried before it's marked
+	// failed
+	MaxAttempts = 8
+
+	// DeliveryTimeout is how long an endpoint has to respond
+	DeliveryTimeout = 10 * time.Second
+
+	// MaxWebhooks is how many webhooks an owner may register
+	MaxWebhooks = 20
+
+	DefaultDeliveries = 50
+	MaxDeliveries     = 100
+
+	secretPrefix = "whsec_"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrTooManyWebhooks = errors.New("too many webhooks, delete one first")
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Webhook is a registered URL as its owner sees it. The secret is only
+// ever shown when it's created.
+type Webhook struct {
+	ID        string         `json:"id" db:"id"`
+	URL       string         `json:"url" db:"url"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// CreatedWebhook is a new webhook along with the secret its deliveries are
+// signed with
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// Delivery is one event sent, or being sent, to a webhook
+type Delivery struct {
+	ID             string          `json:"id" db:"id"`
+	Event          Event           `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+type CreateInput struct {
+	// URL must be https
+	URL       string              `json:"url"`
+	Events    []Event             `json:"events"`
+	Validator validator.Validator `json:"-"`
+}
+
+func (i *CreateInput) validate() {
+	v := &i.Validator
+	u, err := url.Parse(i.URL)
+	v.CheckField(validator.NotBlank(i.URL), "url", "URL is required")
+	v.CheckField(validator.MaxRunes(i.URL, 2048), "url", "Must be no more than 2048 characters")
+	v.CheckField(err == nil && u.Scheme == "https" && u.Host != "", "url", "Must be an https URL")
+	v.CheckField(len(i.Events) > 0, "events", "At least one event is required")
+	v.CheckField(validator.AllIn(i.Events, Events...), "events", "Must be game_ended, tournament_round_completed or player_registered")
+	v.CheckField(validator.NoDuplicates(i.Events), "events", "Must not repeat an event")
+}
+
+// JobQueue queues deliveries to run in the background with retries
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any, opts ...jobs.EnqueueOption) (*jobs.Job, error)
+}
+
+type ServiceOption func(*Service)
+
+// WithJobs sends deliveries through queue, so failed ones are retried.
+// Without it they are tried once, straight away.
+func WithJobs(queue JobQueue) ServiceOption {
+	return func(s *Service) {
+		s.queue = queue
+	}
+}
+
+// WithHTTPClient delivers with client instead of a default one
+func WithHTTPClient(client *http.Client) ServiceOption {
+	return func(s *Service) {
+		s.client = client
+	}
+}
+
+// WithAuditLog records webhooks being registered and deleted in log
+func WithAuditLog(log audit.Recorder) ServiceOption {
+	return func(s *Service) {
+		s.audit = log
+	}
+}
+
+type Service struct {
+	db      *sqlx.DB
+	client  *http.Client
+	queue   JobQueue
+	audit   audit.Recorder
+	onError func(error)
+	now     func() time.Time
+}
+
+// NewService reports events it fails to send to onError
+func NewService(db *sqlx.DB, onError func(error), opts ...ServiceOption) *Service {
+	s := &Service{
+		db: db,
+		client: &http.Client{
+			Timeout: DeliveryTimeout,
+			// A redirect could send the payload somewhere the owner didn't
+			// register
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		onError: onError,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Service) record(ctx context.Context, action audit.Action, id string, before, after any) {
+	if s.audit != nil {
+		s.audit.Record(ctx, audit.Event{Action: action, TargetType: "webhook", TargetID: id, Before: before, After: after})
+	}
+}
+
+func (s *Service) report(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// Create registers a webhook for owner
+func (s *Service) Create(ctx context.Context, owner string, input CreateInput) (*CreatedWebhook, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	events := make(pq.StringArray, len(input.Events))
+	for i, event := range input.Events {
+		events[i] = string(event)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize an owner's registrations so two at once can't both squeeze
+	// under MaxWebhooks
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "webhooks:"+owner); err != nil {
+		return nil, fmt.Errorf("failed to lock owner: %w", err)
+	}
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM webhooks WHERE owner = $1`, owner); err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if count >= MaxWebhooks {
+		return nil, ErrTooManyWebhooks
+	}
+
+	created := &CreatedWebhook{Secret: secret}
+	if err := tx.GetContext(ctx, &created.Webhook, `
+		INSERT INTO webhooks (owner, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, url, events, created_at`,
+		owner, input.URL, secret, events); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.record(ctx, audit.ActionWebhookCreated, created.ID, nil, created.Webhook)
+	return created, nil
+}
+
+// List returns owner's webhooks, newest first
+func (s *Service) List(ctx context.Context, owner string) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	if err := s.db.SelectContext(ctx, &webhooks, `
+		SELECT id, url, events, created_at
+		FROM webhooks
+		WHERE owner = $1
+		ORDER BY created_at DESC`, owner); err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// Delete stops sending to one of owner's webhooks, dropping its deliveries
+func (s *Service) Delete(ctx context.Context, owner, webhookID string) error {
+	var deleted Webhook
+	err := s.db.GetContext(ctx, &deleted, `
+		DELETE FROM webhooks
+		WHERE id = $1 AND owner = $2
+		RETURNING id, url, events, created_at`, webhookID, owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	s.record(ctx, audit.ActionWebhookDeleted, webhookID, deleted, nil)
+	return nil
+}
+
+// Deliveries returns the latest deliveries to one of owner's webhooks,
+// newest first
+func (s *Service) Deliveries(ctx context.Context, owner, webhookID string, limit int) ([]Delivery, error) {
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, `
+		SELECT EXISTS(SELECT 1 FROM webhooks WHERE id = $1 AND owner = $2)`, webhookID, owner); err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+
+	deliveries := []Delivery{}
+	if err := s.db.SelectContext(ctx, &deliveries, `
+		SELECT id, event, payload, status, attempts, response_status, last_error, created_at, last_attempt_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, webhookID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// envelope is the body of every delivery
+type envelope struct {
+	Event     Event     `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Publish sends event, with data, to every webhook registered for it
+func (s *Service) Publish(ctx context.Context, event Event, data any) error {
+	payload, err := json.Marshal(envelope{Event: event, CreatedAt: s.now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", event, err)
+	}
+
+	// The event has already happened, so it's sent even if the request
+	// that caused it is gone
+	ctx = context.WithoutCancel(ctx)
+
+	var ids []string
+	if err := s.db.SelectContext(ctx, &ids, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $1, $2 FROM webhooks WHERE $1 = ANY(events)
+		RETURNING id`, event, string(payload)); err != nil {
+		return fmt.Errorf("failed to queue %s deliveries: %w", event, err)
+	}
+
+	for _, id := range ids {
+		if s.queue == nil {
+			go s.deliverOnce(id)
+			continue
+		}
+		if _, err := s.queue.Enqueue(ctx, JobDeliver, deliverJob{DeliveryID: id}, jobs.WithMaxAttempts(MaxAttempts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) publish(ctx context.Context, event Event, data any) {
+	if err := s.Publish(ctx, event, data); err != nil {
+		s.report(err)
+	}
+}
+
+// PublishResults sends tournament games' results. It implements
+// game.ResultPublisher.
+func (s *Service) PublishResults(ctx context.Context, g *game.Game, results []game.PlayerResult) {
+	if !g.Settings.IsTournament {
+		return
+	}
+	s.publish(ctx, EventGameEnded, map[string]any{
+		"game_id":   g.ID,
+		"game_type": g.Type,
+		"is_ranked": g.Settings.IsRanked,
+		"results":   results,
+	})
+}
+
+// RoundCompleted sends a tournament game's scores after a round. It
+// implements game.RoundReporter.
+func (s *Service) RoundCompleted(ctx context.Context, g *game.Game) {
+	scores := make([]map[string]any, len(g.Players))
+	for i, player := range g.Players {
+		scores[i] = map[string]any{"player_id": player.UserID, "score": player.Score}
+	}
+	s.publish(ctx, EventRoundCompleted, map[string]any{
+		"game_id":    g.ID,
+		"round":      g.Round,
+		"max_rounds": g.Settings.MaxRounds,
+		"scores":     scores,
+	})
+}
+
+// PlayerRegistered sends new accounts, leaving children's out. It
+// implements auth.RegistrationObserver.
+func (s *Service) PlayerRegistered(ctx context.Context, user *auth.User) {
+	if user.IsChild {
+		return
+	}
+	s.publish(ctx, EventPlayerRegistered, map[string]any{
+		"user_id":       user.ID,
+		"username":      user.Username,
+		"registered_at": user.CreatedAt,
+	})
+}
+
+type deliverJob struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// RegisterJobs has worker run the deliveries the service queues
+func (s *Service) RegisterJobs(worker *jobs.Worker) {
+	worker.Register(JobDeliver, func(ctx context.Context, job *jobs.Job) error {
+		var payload deliverJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return s.deliver(ctx, payload.DeliveryID, job.Attempts >= job.MaxAttempts)
+	})
+}
+
+func (s *Service) deliverOnce(deliveryID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), DeliveryTimeout+5*time.Second)
+	defer cancel()
+	if err := s.deliver(ctx, deliveryID, true); err != nil {
+		s.report(err)
+	}
+}
+
+// pending is a delivery along with where it's going
+type pending struct {
+	ID      string `db:"id"`
+	Event   Event  `db:"event"`
+	Payload []byte `db:"payload"`
+	URL     string `db:"url"`
+	Secret  string `db:"secret"`
+}
+
+// deliver makes an attempt at a delivery and logs how it went, marking it
+// failed if it doesn't arrive on the last attempt
+func (s *Service) deliver(ctx context.Context, deliveryID string, last bool) error {
+	var d pending
+	err := s.db.GetContext(ctx, &d, `
+		SELECT d.id, d.event, d.payload, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.id = $1 AND d.status = $2`, deliveryID, StatusPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The webhook was deleted, or the delivery already made
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	code, sendErr := s.send(ctx, d)
+
+	status, lastError := StatusDelivered, sql.NullString{}
+	if sendErr != nil {
+		status, lastError = StatusPending, sql.NullString{String: sendErr.Error(), Valid: true}
+		if last {
+			status = StatusFailed
+		}
+	}
+	responseStatus := sql.NullInt32{Int32: int32(code), Valid: code != 0}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4, last_attempt_at = NOW(),
+			delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END
+		WHERE id = $1`, d.ID, status, responseStatus, lastError); err != nil {
+		return fmt.Errorf("failed to log delivery: %w", errors.Join(sendErr, err))
+	}
+	return sendErr
+}
+
+// send posts a delivery, returning the status the endpoint responded with,
+// if it did
+func (s *Service) send(ctx context.Context, d pending) (int, error) {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, jobs.Permanent(fmt.Errorf("failed to build request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BigSpella-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(d.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign is the hex HMAC-SHA256 of a delivery's timestamp and body, for
+// receivers to check HeaderSignature against
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
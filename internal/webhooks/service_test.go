@@ -0,0 +1,79 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"game_ended"}`)
+	signature := Sign("whsec_test", "1700000000", body)
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, Sign("whsec_test", "1700000000", body))
+	assert.NotEqual(t, signature, Sign("whsec_other", "1700000000", body))
+	assert.NotEqual(t, signature, Sign("whsec_test", "1700000001", body), "the timestamp is signed too")
+
+	secret, err := newSecret()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, secretPrefix))
+	assert.Len(t, secret, len(secretPrefix)+43)
+}
+
+func TestCreateInputValidation(t *testing.T) {
+	input := CreateInput{URL: "https://brackets.example.com/hooks/spella", Events: []Event{EventGameEnded, EventRoundCompleted}}
+	input.validate()
+	assert.False(t, input.Validator.HasErrors())
+
+	input = CreateInput{URL: "http://brackets.example.com/hooks", Events: []Event{"game_started"}}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "url", "deliveries are only sent over https")
+	assert.Contains(t, input.Validator.FieldErrors, "events")
+
+	input = CreateInput{URL: "https:///hooks", Events: []Event{EventPlayerRegistered, EventPlayerRegistered}}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "url")
+	assert.Contains(t, input.Validator.FieldErrors, "events")
+
+	input = CreateInput{URL: "https://brackets.example.com"}
+	input.validate()
+	assert.Contains(t, input.Validator.FieldErrors, "events")
+}
+
+func TestSend(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	status := http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	s := NewService(nil, nil, WithHTTPClient(server.Client()))
+	s.now = func() time.Time { return now }
+
+	d := pending{ID: "delivery-1", Event: EventGameEnded, Payload: []byte(`{"event":"game_ended"}`), URL: server.URL, Secret: "whsec_test"}
+	code, err := s.send(context.Background(), d)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, d.Payload, body)
+	assert.Equal(t, "game_ended", received.Header.Get(HeaderEvent))
+	assert.Equal(t, "delivery-1", received.Header.Get(HeaderDelivery))
+	assert.Equal(t, "1700000000", received.Header.Get(HeaderTimestamp))
+	assert.Equal(t, "sha256="+Sign("whsec_test", "1700000000", d.Payload), received.Header.Get(HeaderSignature))
+
+	status = http.StatusServiceUnavailable
+	code, err = s.send(context.Background(), d)
+	assert.Error(t, err, "anything but a 2xx is retried")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -0,0 +1,4 @@
+-- The uuid-ossp extension is left, since other schemas may use it
+DROP TABLE IF EXISTS subscriptions, tournament_matches, tournament_players, tournaments,
+    game_players, games, words, users CASCADE;
+DROP FUNCTION IF EXISTS update_updated_at_column();
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS spelling_attempts, players;
+DROP INDEX IF EXISTS idx_words_level_category;
+
+ALTER TABLE games
+    DROP COLUMN IF EXISTS word_masked,
+    DROP COLUMN IF EXISTS hints_used,
+    DROP COLUMN IF EXISTS turn_started_at;
+
+ALTER TABLE words
+    DROP COLUMN IF EXISTS level,
+    DROP COLUMN IF EXISTS category;
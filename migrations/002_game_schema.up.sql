@@ -58,6 +58,18 @@ CREATE TABLE IF NOT EXISTS spelling_attempts (
     timestamp TIMESTAMP WITH TIME ZONE NOT NULL
 );
 
+-- 001 already created words and games, so the tables above are skipped on
+-- a new database and the columns the rest of this migration relies on are
+-- added here instead
+ALTER TABLE words
+    ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS level INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE games
+    ADD COLUMN IF NOT EXISTS turn_started_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS hints_used JSONB,
+    ADD COLUMN IF NOT EXISTS word_masked BOOLEAN NOT NULL DEFAULT true;
+
 -- Create indexes
 CREATE INDEX IF NOT EXISTS idx_words_level_category ON words(level, category);
 CREATE INDEX IF NOT EXISTS idx_games_status ON games(status);
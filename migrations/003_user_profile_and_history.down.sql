@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS user_follows, post_interactions, posts, user_word_history;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS notification_preferences,
+    DROP COLUMN IF EXISTS social_links,
+    DROP COLUMN IF EXISTS profile_image_url,
+    DROP COLUMN IF EXISTS bio;
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS game_recordings, game_results;
+DROP INDEX IF EXISTS idx_users_rank_points;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS games_played,
+    DROP COLUMN IF EXISTS games_won,
+    DROP COLUMN IF EXISTS rank_color,
+    DROP COLUMN IF EXISTS rank_points;
+
+ALTER TABLE games
+    DROP COLUMN IF EXISTS record_game,
+    DROP COLUMN IF EXISTS enable_voice,
+    DROP COLUMN IF EXISTS enable_video,
+    DROP COLUMN IF EXISTS max_rounds,
+    DROP COLUMN IF EXISTS time_limit,
+    DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_spelling_attempts_voice_expires_at;
+
+ALTER TABLE spelling_attempts
+    DROP COLUMN IF EXISTS voice_expires_at,
+    DROP COLUMN IF EXISTS voice_s3_key;
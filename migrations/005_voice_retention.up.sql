@@ -0,0 +1,8 @@
+-- Voice audio lives encrypted in S3 and expires
+ALTER TABLE spelling_attempts
+    ADD COLUMN IF NOT EXISTS voice_s3_key TEXT,
+    ADD COLUMN IF NOT EXISTS voice_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_spelling_attempts_voice_expires_at
+    ON spelling_attempts(voice_expires_at)
+    WHERE voice_s3_key IS NOT NULL;
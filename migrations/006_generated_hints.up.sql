@@ -0,0 +1,9 @@
+-- Example sentences and etymologies generated for words the dictionary
+-- has no content for, cached per word
+CREATE TABLE IF NOT EXISTS generated_hints (
+    word TEXT NOT NULL,
+    hint_type TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (word, hint_type)
+);
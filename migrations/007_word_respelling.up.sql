@@ -0,0 +1,3 @@
+-- Reader-friendly respelling of each word's pronunciation, e.g. "fuh-NET-ik"
+ALTER TABLE words
+    ADD COLUMN IF NOT EXISTS respelling TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Dictionary a word's content was looked up in, for attribution
+ALTER TABLE words
+    ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
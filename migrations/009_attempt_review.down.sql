@@ -0,0 +1,5 @@
+ALTER TABLE spelling_attempts
+    DROP COLUMN IF EXISTS judge_id,
+    DROP COLUMN IF EXISTS confidence,
+    DROP COLUMN IF EXISTS ruling,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+-- Voice attempts the recogniser wasn't sure about wait on a standby judge
+ALTER TABLE spelling_attempts
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'judged',
+    ADD COLUMN IF NOT EXISTS ruling TEXT,
+    ADD COLUMN IF NOT EXISTS confidence DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS judge_id UUID REFERENCES users(id);
@@ -0,0 +1,6 @@
+-- Integrity reports compiled for organizers once a tournament ends
+CREATE TABLE IF NOT EXISTS tournament_integrity_reports (
+    tournament_id UUID PRIMARY KEY REFERENCES tournaments(id) ON DELETE CASCADE,
+    report JSONB NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
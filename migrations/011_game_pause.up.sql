@@ -0,0 +1,3 @@
+-- When the host paused the game, so clients reconnecting mid-pause see it
+ALTER TABLE games
+    ADD COLUMN IF NOT EXISTS paused_at TIMESTAMP WITH TIME ZONE;
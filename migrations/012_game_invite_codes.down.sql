@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_games_invite_code;
+ALTER TABLE games DROP COLUMN IF EXISTS invite_code;
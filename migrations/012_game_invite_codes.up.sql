@@ -0,0 +1,5 @@
+-- Invite codes for private games
+ALTER TABLE games
+    ADD COLUMN IF NOT EXISTS invite_code TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_games_invite_code ON games(invite_code) WHERE invite_code IS NOT NULL;
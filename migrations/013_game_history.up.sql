@@ -0,0 +1,15 @@
+-- Each player's result in every game they finished
+CREATE TABLE IF NOT EXISTS game_history (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    game_id UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    game_type TEXT NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL,
+    words_spelled TEXT[] NOT NULL DEFAULT '{}',
+    duration INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, game_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_history_user_created ON game_history(user_id, created_at DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_words_empirical_level;
+
+ALTER TABLE words
+    DROP COLUMN IF EXISTS calibrated_at,
+    DROP COLUMN IF EXISTS empirical_level;
+
+ALTER TABLE spelling_attempts DROP COLUMN IF EXISTS answer_ms;
@@ -0,0 +1,10 @@
+-- How long each attempt took from the start of the turn, for calibration
+ALTER TABLE spelling_attempts
+    ADD COLUMN IF NOT EXISTS answer_ms INTEGER;
+
+-- Difficulty level observed from how players actually fare with each word
+ALTER TABLE words
+    ADD COLUMN IF NOT EXISTS empirical_level INTEGER,
+    ADD COLUMN IF NOT EXISTS calibrated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_words_empirical_level ON words(empirical_level);
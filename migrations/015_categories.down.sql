@@ -0,0 +1,2 @@
+-- words.category is left as it was, so nothing is lost
+DROP TABLE IF EXISTS word_categories, categories;
@@ -0,0 +1,31 @@
+-- Curated categories words can belong to, replacing the free-text
+-- words.category filter
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS word_categories (
+    word_id UUID NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (word_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_categories_category ON word_categories(category_id);
+
+-- Carry the existing free-text categories over
+INSERT INTO categories (slug, name)
+SELECT DISTINCT LOWER(category), category
+FROM words
+WHERE category <> ''
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO word_categories (word_id, category_id)
+SELECT w.id, c.id
+FROM words w
+JOIN categories c ON c.slug = LOWER(w.category)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+ALTER TABLE spelling_attempts
+    DROP COLUMN IF EXISTS points,
+    DROP COLUMN IF EXISTS match;
@@ -0,0 +1,9 @@
+-- How close each attempt came to the word and what it scored
+ALTER TABLE spelling_attempts
+    ADD COLUMN IF NOT EXISTS match TEXT,
+    ADD COLUMN IF NOT EXISTS points INTEGER NOT NULL DEFAULT 0;
+
+-- Correct attempts made before points existed score a full word
+UPDATE spelling_attempts
+SET match = 'exact', points = 10
+WHERE is_correct AND match IS NULL;
@@ -0,0 +1,17 @@
+-- Players can appeal an attempt ruled incorrect; a moderator or a second
+-- transcription decides whether the ruling stands
+CREATE TABLE IF NOT EXISTS appeals (
+    id UUID PRIMARY KEY,
+    attempt_id UUID NOT NULL UNIQUE REFERENCES spelling_attempts(id) ON DELETE CASCADE,
+    game_id UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    player_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    method TEXT,
+    reviewer TEXT,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    decided_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_appeals_status_created ON appeals(status, created_at);
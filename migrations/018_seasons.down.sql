@@ -0,0 +1 @@
+DROP TABLE IF EXISTS season_rewards, season_standings, seasons;
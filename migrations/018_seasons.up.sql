@@ -0,0 +1,36 @@
+-- Ranked play runs in seasons; ratings are soft reset between them
+CREATE TABLE IF NOT EXISTS seasons (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finalized_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seasons_starts_at ON seasons(starts_at);
+
+-- Each player's rating over the season they played ranked games in
+CREATE TABLE IF NOT EXISTS season_standings (
+    season_id UUID NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INTEGER NOT NULL,
+    games_played INTEGER NOT NULL DEFAULT 0,
+    games_won INTEGER NOT NULL DEFAULT 0,
+    final_placement INTEGER,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (season_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_season_standings_points ON season_standings(season_id, points DESC);
+
+-- What each player earned when a season was finalized
+CREATE TABLE IF NOT EXISTS season_rewards (
+    season_id UUID NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    badge TEXT NOT NULL,
+    premium_trial_days INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (season_id, user_id)
+);
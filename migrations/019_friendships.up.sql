@@ -0,0 +1,24 @@
+-- Friendships are stored once, from whoever asked. A blocked row belongs to
+-- the blocker and replaces any friendship between the two.
+CREATE TABLE IF NOT EXISTS friendships (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    friend_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'accepted', 'blocked'
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, friend_id),
+    CHECK (user_id <> friend_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_friendships_friend_id ON friendships(friend_id, status);
+
+-- Private games one friend challenged another to
+CREATE TABLE IF NOT EXISTS friend_challenges (
+    id UUID PRIMARY KEY,
+    challenger_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    challenged_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    game_id UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_friend_challenges_challenged_id ON friend_challenges(challenged_id, created_at);
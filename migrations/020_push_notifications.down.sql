@@ -0,0 +1,2 @@
+ALTER TABLE tournaments DROP COLUMN IF EXISTS start_notified_at;
+DROP TABLE IF EXISTS user_preferences, device_tokens;
@@ -0,0 +1,26 @@
+-- Devices players receive push notifications on
+CREATE TABLE IF NOT EXISTS device_tokens (
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL, -- 'ios', 'android'
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_tokens_user_id ON device_tokens(user_id);
+
+-- Players without a row get the defaults, which have notifications on
+CREATE TABLE IF NOT EXISTS user_preferences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    notifications_on BOOLEAN NOT NULL DEFAULT TRUE,
+    theme TEXT NOT NULL DEFAULT 'system',
+    language TEXT NOT NULL DEFAULT 'en',
+    sound_effects BOOLEAN NOT NULL DEFAULT TRUE,
+    music BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Players are told once when a tournament they entered is about to start
+ALTER TABLE tournaments
+    ADD COLUMN IF NOT EXISTS start_notified_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+-- Dropping the table drops its triggers, which would otherwise refuse
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS reject_audit_log_changes();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ranking_adjustments, account_suspensions;
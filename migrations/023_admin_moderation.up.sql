@@ -0,0 +1,29 @@
+-- Accounts kept from signing in by an admin. A suspension without ends_at is
+-- a ban.
+CREATE TABLE IF NOT EXISTS account_suspensions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    suspended_by TEXT NOT NULL,
+    lifted_at TIMESTAMP WITH TIME ZONE,
+    lifted_by TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_suspensions_user_id ON account_suspensions(user_id, created_at DESC);
+
+-- Manual corrections to players' ratings, with the reason they were made
+CREATE TABLE IF NOT EXISTS ranking_adjustments (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INTEGER NOT NULL,
+    reason_code TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    previous_rank_points INTEGER NOT NULL,
+    new_rank_points INTEGER NOT NULL,
+    adjusted_by TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ranking_adjustments_user_id ON ranking_adjustments(user_id, created_at DESC);
@@ -0,0 +1,35 @@
+-- Players flagging other players, their posts or their chat messages for
+-- moderators to review
+CREATE TABLE IF NOT EXISTS content_reports (
+    id UUID PRIMARY KEY,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    reported_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    excerpt TEXT,
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_by TEXT,
+    resolution_note TEXT,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_reports_status ON content_reports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_content_reports_reported_user ON content_reports(reported_user_id, created_at DESC);
+
+-- A player can only have one open report against the same thing
+CREATE UNIQUE INDEX IF NOT EXISTS idx_content_reports_open
+    ON content_reports(reporter_id, target_type, target_id) WHERE status = 'open';
+
+-- Players kept from chatting and posting for a while
+CREATE TABLE IF NOT EXISTS user_mutes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_mutes_user_id ON user_mutes(user_id, ends_at DESC);
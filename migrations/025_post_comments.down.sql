@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_user_created;
+DROP INDEX IF EXISTS idx_posts_highlight;
+DROP INDEX IF EXISTS idx_post_interactions_like;
+DROP INDEX IF EXISTS idx_post_interactions_thread;
+ALTER TABLE post_interactions DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,17 @@
+-- Replies to comments; a reply to a reply joins the thread of the comment
+-- it ultimately answers
+ALTER TABLE post_interactions
+    ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES post_interactions(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_post_interactions_thread
+    ON post_interactions(post_id, parent_id, created_at) WHERE type = 'comment';
+
+-- A player can only like a post once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_interactions_like
+    ON post_interactions(post_id, user_id) WHERE type = 'like';
+
+-- Highlights are generated once per game a player wins
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_highlight
+    ON posts(user_id, game_id) WHERE type = 'game_highlight';
+
+CREATE INDEX IF NOT EXISTS idx_posts_user_created ON posts(user_id, created_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS last_daily_date,
+    DROP COLUMN IF EXISTS longest_streak,
+    DROP COLUMN IF EXISTS current_streak;
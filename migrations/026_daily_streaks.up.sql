@@ -0,0 +1,5 @@
+-- Consecutive days a player has completed the daily challenge
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS current_streak INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS longest_streak INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_daily_date DATE;
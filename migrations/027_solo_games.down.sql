@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_word_history_user_word;
+DROP TABLE IF EXISTS solo_games;
@@ -0,0 +1,27 @@
+-- Solo games and daily challenge plays for servers keeping them in
+-- Postgres instead of DynamoDB. IDs are text because daily plays are keyed
+-- by day and player rather than by UUID.
+CREATE TABLE IF NOT EXISTS solo_games (
+    id TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    word_id UUID,
+    word TEXT NOT NULL DEFAULT '',
+    attempts JSONB NOT NULL DEFAULT '[]',
+    hints_used INTEGER NOT NULL DEFAULT 0,
+    score INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    challenge_date DATE,
+    level INTEGER NOT NULL DEFAULT 0,
+    word_ids TEXT[] NOT NULL DEFAULT '{}',
+    daily_board TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_solo_games_user ON solo_games(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_solo_games_daily_board ON solo_games(daily_board, score DESC)
+    WHERE daily_board IS NOT NULL;
+
+-- Per-word stats are counted with an upsert, so a player has one row a word
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_word_history_user_word ON user_word_history(user_id, word_id);
@@ -0,0 +1,3 @@
+-- Bumped by every update to a game, so an update made from a stale read of
+-- the game can be detected and retried instead of overwriting a newer one
+ALTER TABLE games ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 0;
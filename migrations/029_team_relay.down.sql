@@ -0,0 +1,2 @@
+ALTER TABLE game_history DROP COLUMN IF EXISTS team;
+ALTER TABLE players DROP COLUMN IF EXISTS team;
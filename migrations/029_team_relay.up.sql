@@ -0,0 +1,4 @@
+-- The team each player was dealt into when a team relay started, and the
+-- team whose placement their history records
+ALTER TABLE players ADD COLUMN IF NOT EXISTS team INTEGER;
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS team INTEGER;
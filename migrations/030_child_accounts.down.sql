@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS parental_consents;
+ALTER TABLE users DROP COLUMN IF EXISTS is_child;
@@ -0,0 +1,18 @@
+-- Accounts of players under 13, which can't be used until a parent consents
+-- and are kept out of social features
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_child BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One consent request per child. token_hash is the SHA-256 of the token in
+-- the link emailed to parent_email, cleared once the parent signed in as
+-- parent_id consents.
+CREATE TABLE IF NOT EXISTS parental_consents (
+    child_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    parent_email TEXT NOT NULL,
+    token_hash BYTEA UNIQUE,
+    parent_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    granted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_parental_consents_parent ON parental_consents(parent_id);
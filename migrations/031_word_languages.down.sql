@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_words_language_level;
+DROP INDEX IF EXISTS idx_words_word_language;
+ALTER TABLE words DROP COLUMN IF EXISTS language;
@@ -0,0 +1,6 @@
+-- The language each word is in, as an ISO 639-1 code. The same spelling can
+-- be a word in more than one language, so words are only unique within one.
+ALTER TABLE words ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE words DROP CONSTRAINT IF EXISTS words_word_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_words_word_language ON words(word, language);
+CREATE INDEX IF NOT EXISTS idx_words_language_level ON words(language, level);
@@ -0,0 +1,4 @@
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS slow_repeat,
+    DROP COLUMN IF EXISTS speech_rate,
+    DROP COLUMN IF EXISTS tts_voice;
@@ -0,0 +1,6 @@
+-- How players like words read out: the TTS voice (NULL for the voice of the
+-- word's language), how fast it speaks, and whether a slower reading
+-- follows
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS tts_voice TEXT;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS speech_rate REAL NOT NULL DEFAULT 1.0;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS slow_repeat BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,34 @@
+-- Suspected cheating the cheat screen found in finished games. Ranked games
+-- with pending flags hold back their ranking points until an admin clears
+-- or confirms them.
+CREATE TABLE IF NOT EXISTS game_flags (
+    id UUID PRIMARY KEY,
+    game_id UUID NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    player_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL, -- 'answer_latency', 'impossible_accuracy', 'paste_speed', 'shared_device'
+    detail TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'cleared', 'confirmed'
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (game_id, player_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_flags_status ON game_flags(status, created_at);
+
+-- Every account that has registered each device, which device_tokens only
+-- keeps the latest of
+CREATE TABLE IF NOT EXISTS device_accounts (
+    token TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (token, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_accounts_user_id ON device_accounts(user_id);
+
+INSERT INTO device_accounts (token, user_id, first_seen_at, last_seen_at)
+SELECT token, user_id, created_at, updated_at FROM device_tokens
+ON CONFLICT DO NOTHING;
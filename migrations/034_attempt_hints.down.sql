@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_game_results_player_created;
+ALTER TABLE spelling_attempts DROP COLUMN IF EXISTS hints_used;
@@ -0,0 +1,6 @@
+-- How many hints the player had taken on the word when they attempted it,
+-- for their practice stats
+ALTER TABLE spelling_attempts
+    ADD COLUMN IF NOT EXISTS hints_used INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_game_results_player_created ON game_results(player_id, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS word_of_the_day_email,
+    DROP COLUMN IF EXISTS word_of_the_day_push;
+DROP TABLE IF EXISTS words_of_the_day;
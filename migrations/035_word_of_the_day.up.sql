@@ -0,0 +1,18 @@
+-- One word a day for everyone, kept with its dictionary entry and audio so
+-- the dictionary is only asked once, and sent_at set once subscribers have
+-- been sent it
+CREATE TABLE IF NOT EXISTS words_of_the_day (
+    date DATE PRIMARY KEY,
+    word_id UUID REFERENCES words(id) ON DELETE SET NULL,
+    level INTEGER NOT NULL,
+    word JSONB NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_words_of_the_day_word_id ON words_of_the_day(word_id);
+
+-- Players ask to be sent the word each morning
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS word_of_the_day_push BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS word_of_the_day_email BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+-- Players moved down to the new default keep their rank points
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS new_elo,
+    DROP COLUMN IF EXISTS previous_elo;
+
+ALTER TABLE users
+    ALTER COLUMN rank_points SET DEFAULT 1200;
@@ -0,0 +1,16 @@
+-- Rank points start at the bottom of the Gray rank, as rank_color always
+-- did, rather than at the top of the Red one. Players who took the old
+-- default and have never played a ranked game are moved down to match.
+ALTER TABLE users
+    ALTER COLUMN rank_points SET DEFAULT 0;
+
+UPDATE users SET rank_points = 0, rank_color = 'Gray'
+WHERE rank_points = 1200 AND rank_color = 'Gray'
+    AND NOT EXISTS (SELECT 1 FROM game_results WHERE player_id = users.id)
+    AND NOT EXISTS (SELECT 1 FROM ranking_adjustments WHERE user_id = users.id);
+
+-- Ranked games move ELO alongside rank points. Results from before it did
+-- have neither.
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS previous_elo INTEGER,
+    ADD COLUMN IF NOT EXISTS new_elo INTEGER;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_users_last_ranked_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS rank_decay_exempt,
+    DROP COLUMN IF EXISTS rank_decayed_at,
+    DROP COLUMN IF EXISTS rank_decay_warned_at,
+    DROP COLUMN IF EXISTS last_ranked_at;
@@ -0,0 +1,20 @@
+-- Ratings of players who stop playing ranked games decay. last_ranked_at is
+-- when they last finished one, the warned and decayed times keep them from
+-- being warned or decayed twice for the same spell away, and admins can
+-- exempt players from decay altogether.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS last_ranked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS rank_decay_warned_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS rank_decayed_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS rank_decay_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET last_ranked_at = latest.created_at
+FROM (
+    SELECT player_id, MAX(created_at) AS created_at
+    FROM game_results
+    GROUP BY player_id
+) latest
+WHERE latest.player_id = users.id AND users.last_ranked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_last_ranked_at ON users(last_ranked_at)
+    WHERE NOT rank_decay_exempt;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_games_open_activity;
+ALTER TABLE games DROP COLUMN IF EXISTS last_activity;
@@ -0,0 +1,10 @@
+-- Games that go quiet are cancelled as abandoned. last_activity is when
+-- something last happened in a game that its row wasn't otherwise updated
+-- for, kept up to date by the reaper from the game's events.
+ALTER TABLE games
+    ADD COLUMN IF NOT EXISTS last_activity TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+UPDATE games SET last_activity = updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_games_open_activity ON games(updated_at)
+    WHERE status IN ('created', 'initializing', 'waiting', 'playing', 'active', 'paused');
@@ -0,0 +1,4 @@
+-- Players can delete their account. The row stays, anonymized, so the games
+-- they played still add up, and deleted_at marks it closed.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS password_changed_at;
+DROP TABLE IF EXISTS email_changes;
@@ -0,0 +1,12 @@
+-- A new email address waits here until the player follows the link sent to
+-- it. Changing password notes when, and signs other devices out.
+CREATE TABLE IF NOT EXISTS email_changes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    new_email TEXT NOT NULL,
+    token_hash BYTEA NOT NULL UNIQUE,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+ALTER TABLE games
+    DROP COLUMN IF EXISTS scores,
+    DROP COLUMN IF EXISTS host_id;
@@ -0,0 +1,5 @@
+-- Games have been written with a host and running scores since lobbies and
+-- scoring were added, but no migration created the columns
+ALTER TABLE games
+    ADD COLUMN IF NOT EXISTS host_id UUID REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS scores JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_game_history_created_at;
+DROP INDEX IF EXISTS idx_spelling_attempts_timestamp;
+DROP INDEX IF EXISTS idx_games_updated_at;
+
+DROP TABLE IF EXISTS warehouse_exports;
@@ -0,0 +1,15 @@
+-- How far each dataset has been exported to the analytics warehouse.
+-- exported_through is the last day, in UTC, whose rows have been exported
+-- with schema_version; the exporter holds the row locked while it exports
+-- the next day, so instances don't export a day twice.
+CREATE TABLE IF NOT EXISTS warehouse_exports (
+    dataset TEXT PRIMARY KEY,
+    schema_version INTEGER NOT NULL,
+    exported_through DATE,
+    exported_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Each day's rows are found by these timestamps
+CREATE INDEX IF NOT EXISTS idx_games_updated_at ON games(updated_at);
+CREATE INDEX IF NOT EXISTS idx_spelling_attempts_timestamp ON spelling_attempts(timestamp);
+CREATE INDEX IF NOT EXISTS idx_game_history_created_at ON game_history(created_at);
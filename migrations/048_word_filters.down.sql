@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_word_filters_list;
+DROP TABLE IF EXISTS word_filters;
+
+ALTER TABLE words DROP COLUMN IF EXISTS offensive;
@@ -0,0 +1,18 @@
+-- Words the dictionary marks offensive, which games keep out unless their
+-- word filter is off
+ALTER TABLE words ADD COLUMN IF NOT EXISTS offensive BOOLEAN NOT NULL DEFAULT false;
+
+-- Words admins have blocked from ever being served, or allowed to be served
+-- though the dictionary marks them offensive. word is lower case so it
+-- matches however the word is capitalized in the words table.
+CREATE TABLE IF NOT EXISTS word_filters (
+    word TEXT NOT NULL,
+    language TEXT NOT NULL DEFAULT 'en',
+    list TEXT NOT NULL CHECK (list IN ('block', 'allow')),
+    reason TEXT NOT NULL DEFAULT '',
+    added_by TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (word, language)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_filters_list ON word_filters(list, word);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_word_reviews_word_id;
+DROP TABLE IF EXISTS word_reviews;
+
+DROP INDEX IF EXISTS idx_words_status;
+ALTER TABLE words DROP COLUMN IF EXISTS status;
@@ -0,0 +1,21 @@
+-- Where each word is in curation: pending, reviewed, published or retired.
+-- Words already served to players are published; words added from now on
+-- wait for review.
+ALTER TABLE words ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('pending', 'reviewed', 'published', 'retired'));
+ALTER TABLE words ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_words_status ON words(status, created_at);
+
+-- Each move of a word between statuses, and who made it
+CREATE TABLE IF NOT EXISTS word_reviews (
+    id UUID PRIMARY KEY,
+    word_id UUID NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    reviewed_by TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_reviews_word_id ON word_reviews(word_id, created_at);
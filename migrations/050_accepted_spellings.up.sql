@@ -0,0 +1,4 @@
+-- Other ways each word may be spelled, as a JSON array of
+-- {"spelling": "theatre", "variety": "uk"} objects. variety is us or uk for
+-- spellings that belong to one variety of English and left out otherwise.
+ALTER TABLE words ADD COLUMN IF NOT EXISTS accepted_spellings JSONB NOT NULL DEFAULT '[]';
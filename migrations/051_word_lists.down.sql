@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_word_list_entries_position;
+DROP TABLE IF EXISTS word_list_entries;
+DROP INDEX IF EXISTS idx_word_lists_owner_id;
+DROP TABLE IF EXISTS word_lists;
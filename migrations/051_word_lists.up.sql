@@ -0,0 +1,23 @@
+-- Hosts' own lists of words for their private games to draw from instead
+-- of the word bank. Words on a list are rows in words like any other, so
+-- games can point current_word_id at them.
+CREATE TABLE IF NOT EXISTS word_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    language TEXT NOT NULL DEFAULT 'en',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_lists_owner_id ON word_lists(owner_id, updated_at);
+
+-- The words on each list, in the order they were entered
+CREATE TABLE IF NOT EXISTS word_list_entries (
+    list_id UUID NOT NULL REFERENCES word_lists(id) ON DELETE CASCADE,
+    word_id UUID NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (list_id, word_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_list_entries_position ON word_list_entries(list_id, position);
@@ -0,0 +1,7 @@
+-- Where each finished game's report is stored, once it has been generated
+CREATE TABLE IF NOT EXISTS game_reports (
+    game_id UUID PRIMARY KEY REFERENCES games(id) ON DELETE CASCADE,
+    json_key TEXT NOT NULL,
+    pdf_key TEXT NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
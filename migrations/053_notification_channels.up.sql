@@ -0,0 +1,5 @@
+-- The channels each player has turned on or off for each kind of
+-- notification, like {"turn_reminder": {"push": false}}. Channels missing
+-- from it are on.
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS notification_channels JSONB NOT NULL DEFAULT '{}';
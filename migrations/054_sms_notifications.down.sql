@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS sms_messages;
+DROP TABLE IF EXISTS sms_consents;
+DROP TABLE IF EXISTS phone_verifications;
+
+DROP INDEX IF EXISTS idx_users_phone_number;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS sms_consent_at,
+    DROP COLUMN IF EXISTS phone_verified_at,
+    DROP COLUMN IF EXISTS phone_number;
@@ -0,0 +1,43 @@
+-- A player's phone number is only kept once they have verified it, and is
+-- only texted while they consent to it
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS phone_number TEXT,
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS sms_consent_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone_number ON users(phone_number) WHERE phone_number IS NOT NULL;
+
+-- The code texted to the number a player is verifying, hashed
+CREATE TABLE IF NOT EXISTS phone_verifications (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    phone_number TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Every time a player gave or withdrew consent to texts, for the record
+-- carriers ask for. Rows outlive the player's number.
+CREATE TABLE IF NOT EXISTS sms_consents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phone_number TEXT NOT NULL,
+    consented BOOLEAN NOT NULL,
+    source TEXT NOT NULL, -- 'settings', 'number_removed', 'carrier_opt_out'
+    ip_address TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_consents_user_id ON sms_consents(user_id, created_at);
+
+-- Texts sent, verification codes included, which the rate caps count
+CREATE TABLE IF NOT EXISTS sms_messages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phone_number TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_messages_user_id ON sms_messages(user_id, sent_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_games_scheduled_at;
+
+ALTER TABLE games
+    DROP COLUMN IF EXISTS lobby_opens_at,
+    DROP COLUMN IF EXISTS scheduled_at;
@@ -0,0 +1,7 @@
+-- Scheduled games open their lobby at lobby_opens_at and start at
+-- scheduled_at
+ALTER TABLE games
+    ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS lobby_opens_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_games_scheduled_at ON games(scheduled_at) WHERE scheduled_at IS NOT NULL;
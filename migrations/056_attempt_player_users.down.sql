@@ -0,0 +1,6 @@
+ALTER TABLE spelling_attempts DROP CONSTRAINT IF EXISTS spelling_attempts_player_id_fkey;
+
+-- Attempts recorded since hold user IDs, so the old key isn't checked
+-- against them
+ALTER TABLE spelling_attempts
+    ADD CONSTRAINT spelling_attempts_player_id_fkey FOREIGN KEY (player_id) REFERENCES players(id) NOT VALID;
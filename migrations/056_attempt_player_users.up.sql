@@ -0,0 +1,6 @@
+-- spelling_attempts.player_id holds the user who made the attempt, as
+-- players.player_id does, not their seat in players
+ALTER TABLE spelling_attempts DROP CONSTRAINT IF EXISTS spelling_attempts_player_id_fkey;
+
+ALTER TABLE spelling_attempts
+    ADD CONSTRAINT spelling_attempts_player_id_fkey FOREIGN KEY (player_id) REFERENCES users(id);
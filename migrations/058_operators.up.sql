@@ -0,0 +1,4 @@
+-- Operators sign in to the admin console as themselves, so what they do
+-- there is recorded against them. The flag is set by hand.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,9 @@
+// Package migrations holds the versioned SQL that builds the database
+// schema. Each version is a NNN_name.up.sql file and the NNN_name.down.sql
+// that undoes it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS